@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/runner"
+)
+
+func newAttachCommand(ctx context.Context, input *Input) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attach [job id or container name]",
+		Short: "List the containers kept after failed jobs of the working directory or open a shell in the container of a job",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return listKeptContainers(ctx, input)
+			}
+			service, err := cmd.Flags().GetString("service")
+			if err != nil {
+				return err
+			}
+			return attachContainer(ctx, input, args[0], service)
+		},
+		// .actrc may contain flags of the run command
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		SilenceUsage:       true,
+	}
+	cmd.Flags().String("service", "", "open the shell in a service container of the job instead of the job container")
+	return cmd
+}
+
+func keptContainers(ctx context.Context, input *Input) ([]container.ContainerSummary, error) {
+	return container.ListContainers(ctx, map[string]string{
+		runner.LabelWorkdir: input.Workdir(),
+	})
+}
+
+func listKeptContainers(ctx context.Context, input *Input) error {
+	containers, err := keptContainers(ctx, input)
+	if err != nil {
+		return err
+	}
+	if len(containers) == 0 {
+		fmt.Println("No containers of jobs of this working directory, keep them after a failure with --keep-containers")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tSERVICE\tWORKFLOW\tCONTAINER\tSTATUS")
+	for _, c := range containers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			c.Labels[runner.LabelJob],
+			c.Labels[runner.LabelService],
+			c.Labels[runner.LabelWorkflow],
+			c.Name,
+			c.Status)
+	}
+	return w.Flush()
+}
+
+// attachContainer opens a shell in the container of the job or service, target is the id of the
+// job or the name of the container, e.g. to choose one of the containers of a matrix
+func attachContainer(ctx context.Context, input *Input, target string, service string) error {
+	containers, err := keptContainers(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	matches := []container.ContainerSummary{}
+	for _, c := range containers {
+		if c.Name == target {
+			matches = []container.ContainerSummary{c}
+			break
+		}
+		if c.Labels[runner.LabelJob] == target && c.Labels[runner.LabelService] == service {
+			matches = append(matches, c)
+		}
+	}
+	switch {
+	case len(matches) == 0 && service != "":
+		return fmt.Errorf("no container of the service %s of job %s, list the containers with 'act attach'", service, target)
+	case len(matches) == 0:
+		return fmt.Errorf("no container of job %s, keep the containers of failed jobs with --keep-containers", target)
+	case len(matches) > 1:
+		names := make([]string, 0, len(matches))
+		for _, c := range matches {
+			names = append(names, c.Name)
+		}
+		return fmt.Errorf("job %s has several containers, attach to one by its name: %s", target, strings.Join(names, ", "))
+	}
+
+	c := matches[0]
+	if c.State != "running" {
+		if err := container.StartContainer(ctx, c.Name); err != nil {
+			return err
+		}
+	}
+
+	jobContainer := container.NewContainer(&container.NewContainerInput{Name: c.Name})
+	interactive, ok := jobContainer.(container.InteractiveContainer)
+	if !ok {
+		return fmt.Errorf("attaching to containers isn't supported on this platform")
+	}
+	fmt.Printf("Opening a shell in %s, exit it to detach\n", c.Name)
+	return interactive.ExecInteractive(runner.DebugShell, nil, "", "").Finally(jobContainer.Close())(ctx)
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/runner"
+)
+
+func newAuditCommand(ctx context.Context, input *Input) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit [event name]",
+		Short: "List the remote actions, reusable workflows and docker actions the workflows use with the commits their refs resolve to, and flag references which can be moved and repositories which are archived or don't exist",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  newAuditRunCommand(ctx, input),
+		// .actrc may contain flags of the run command
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		SilenceUsage:       true,
+	}
+	cmd.Flags().StringP("job", "j", "", "audit the actions of a specific job ID")
+	cmd.Flags().String("format", "text", "output format of the audit, text or json")
+	cmd.Flags().StringArrayVarP(&input.secrets, "secret", "s", []string{}, "secret to make available to actions with optional value, GITHUB_TOKEN is used to clone private actions and to look up the repositories (e.g. -s GITHUB_TOKEN=foo)")
+	cmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env GITHUB_API_URL=https://ghe.example.com/api/v3)")
+	cmd.Flags().StringArrayVar(&input.actionReplacements, "replace-action", []string{}, "replace an action with another remote action or an action in the working directory, a trailing * matches any action (e.g. --replace-action mycorp/*=./actions/*)")
+	return cmd
+}
+
+func newAuditRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if input.jsonLogger {
+			log.SetFormatter(&log.JSONFormatter{})
+		}
+
+		jobID, err := cmd.Flags().GetString("job")
+		if err != nil {
+			return err
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		if format != "text" && format != "json" {
+			return fmt.Errorf("unknown --format %q, expected text or json", format)
+		}
+
+		envs := make(map[string]string)
+		_ = parseEnvs(input.envs, envs)
+		_ = readEnvs(input.Envfile(), envs, input.environment)
+
+		secrets := newSecrets(input.secrets)
+		_ = readEnvs(input.Secretfile(), secrets, input.environment)
+
+		actionReplacements := make(map[string]string)
+		_ = parseEnvs(input.actionReplacements, actionReplacements)
+
+		planner, err := newWorkflowPlanner(input)
+		if err != nil {
+			return err
+		}
+
+		// without an event the actions of all workflows are audited
+		var plan *model.Plan
+		var plannerErr error
+		if jobID != "" {
+			log.Debugf("Planning job: %s", jobID)
+			plan, plannerErr = planner.PlanJob(jobID)
+		} else if len(args) > 0 {
+			log.Debugf("Planning jobs for event: %s", args[0])
+			plan, plannerErr = planner.PlanEvent(args[0])
+		} else {
+			log.Debugf("Planning all jobs")
+			plan, plannerErr = planner.PlanAll()
+		}
+		if plan == nil && plannerErr != nil {
+			return plannerErr
+		}
+
+		r, err := runner.New(&runner.Config{
+			Actor:              input.actor,
+			Workdir:            input.Workdir(),
+			Env:                envs,
+			Secrets:            secrets,
+			Token:              secrets["GITHUB_TOKEN"],
+			GitHubInstance:     input.githubInstance,
+			RemoteName:         input.remoteName,
+			ActionReplacements: actionReplacements,
+			StrictMode:         input.StrictMode(),
+		})
+		if err != nil {
+			return err
+		}
+		report, err := r.NewAuditReport(ctx, plan)
+		if err != nil {
+			return err
+		}
+		if err := printAuditReport(os.Stdout, report, format); err != nil {
+			return err
+		}
+		return plannerErr
+	}
+}
+
+// printAuditReport prints the references of the audit as text or json
+func printAuditReport(w io.Writer, report *runner.AuditReport, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	warnings := 0
+	for _, reference := range report.References {
+		fmt.Fprintf(w, "%s (%s)\n", reference.Uses, reference.Type)
+		if reference.SHA != "" {
+			fmt.Fprintf(w, "    sha: %s\n", reference.SHA)
+		}
+		fmt.Fprintf(w, "    used by: %s\n", strings.Join(reference.UsedBy, ", "))
+		for _, warning := range reference.Warnings {
+			fmt.Fprintf(w, "    warning: %s\n", warning)
+		}
+		warnings += len(reference.Warnings)
+	}
+	fmt.Fprintf(w, "\n%d references, %d warnings\n", len(report.References), warnings)
+	return nil
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/nektos/act/pkg/runner"
+)
+
+// compatWorkflowsPaths returns the directory the forge of --compat reads the workflows from, the
+// first of its directories which exists in the repository
+func (i *Input) compatWorkflowsPaths() []string {
+	dirs, err := runner.CompatWorkflowDirs(i.compat)
+	if err != nil {
+		// the unknown mode is reported by the runner
+		return i.workflowsPaths
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(i.Workdir(), dir)); err == nil {
+			return []string{"./" + dir + "/"}
+		}
+	}
+	return i.workflowsPaths
+}
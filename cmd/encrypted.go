@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/adrg/xdg"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ageHeader        = []byte("age-encryption.org/v1\n")
+	ageArmoredHeader = []byte("-----BEGIN AGE ENCRYPTED FILE-----")
+	sopsDotenvRegex  = regexp.MustCompile(`(?m)^sops_mac=`)
+
+	// the files are read for the secrets and again for the environments, the sops keys may be
+	// in a KMS or need a passphrase so the decrypted contents are kept in memory by checksum
+	decryptedMu sync.Mutex
+	decrypted   = map[[sha256.Size]byte][]byte{}
+)
+
+// decryptEnvFile returns the content of a secrets, vars or env file encrypted with sops or age,
+// decrypted in memory by the sops or age CLI with the keys of the user, so the encrypted file can be
+// committed. The content of files which aren't encrypted is returned as is.
+func decryptEnvFile(path string, content []byte) ([]byte, error) {
+	var args []string
+	var tool string
+	if bytes.HasPrefix(content, ageHeader) || bytes.HasPrefix(bytes.TrimSpace(content), ageArmoredHeader) {
+		tool = "age"
+		args = []string{"age", "--decrypt"}
+		if identity := ageIdentityFile(); identity != "" {
+			args = append(args, "--identity", identity)
+		}
+		// without an identity age asks for the passphrase of the file on the terminal
+		args = append(args, path)
+	} else if format := sopsFormat(content); format != "" {
+		tool = "sops"
+		args = []string{"sops", "--decrypt", "--input-type", format, "--output-type", format, path}
+	} else {
+		return content, nil
+	}
+
+	checksum := sha256.Sum256(content)
+	decryptedMu.Lock()
+	defer decryptedMu.Unlock()
+	if plain, ok := decrypted[checksum]; ok {
+		return plain, nil
+	}
+
+	log.Debugf("Decrypting %s with %s", path, tool)
+	var stdout bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("%s is encrypted with %s, install %s to decrypt it", path, tool, tool)
+		}
+		return nil, fmt.Errorf("unable to decrypt %s with %s: %w", path, tool, err)
+	}
+	decrypted[checksum] = stdout.Bytes()
+	return stdout.Bytes(), nil
+}
+
+// ageIdentityFile returns the age identities of sops, SOPS_AGE_KEY_FILE or the keys.txt of its
+// config directory, if there are any
+func ageIdentityFile() string {
+	if file := os.Getenv("SOPS_AGE_KEY_FILE"); file != "" {
+		return file
+	}
+	file := filepath.Join(xdg.ConfigHome, "sops", "age", "keys.txt")
+	if _, err := os.Stat(file); err == nil {
+		return file
+	}
+	return ""
+}
+
+// sopsFormat returns the sops format of the content encrypted with sops, yaml, json or dotenv,
+// empty if it isn't
+func sopsFormat(content []byte) string {
+	if sopsDotenvRegex.Match(content) {
+		return "dotenv"
+	}
+	var document struct {
+		Sops *struct {
+			Mac string `yaml:"mac"`
+		} `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(content, &document); err != nil || document.Sops == nil || document.Sops.Mac == "" {
+		return ""
+	}
+	if strings.HasPrefix(string(bytes.TrimSpace(content)), "{") {
+		return "json"
+	}
+	return "yaml"
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// maxEventSize is the size limit of the events read from stdin or downloaded
+const maxEventSize = 25 << 20
+
+// readEventSource reads the event of --eventpath - from stdin or of --eventpath https://... from
+// the URL into a temp file the event path then points to, so scripts don't need temp files. The
+// returned func removes the temp file.
+func readEventSource(ctx context.Context, input *Input) (func(), error) {
+	source := input.eventPath
+	if source != "-" && !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return func() {}, nil
+	}
+
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(io.LimitReader(os.Stdin, maxEventSize))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the event from stdin: %w", err)
+		}
+		source = "stdin"
+	} else if data, err = downloadEvent(ctx, source); err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("the event from %s isn't valid JSON", source)
+	}
+
+	f, err := os.CreateTemp("", "act-event-*.json")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		cleanup()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return nil, err
+	}
+	input.eventPath = f.Name()
+	return cleanup, nil
+}
+
+func downloadEvent(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download the event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to download the event from %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxEventSize))
+}
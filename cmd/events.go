@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/runner"
+)
+
+// eventResult is the result of the workflows of an event of a run of several events
+type eventResult struct {
+	Event string
+	Err   error
+	Jobs  map[string]*runner.JobState // by workflow file and job id, nil if no job ran
+}
+
+// runEvents runs the workflows of each event one after the other, with the payload of the event
+// in --event-payload-dir if there is one, and prints the results of the jobs per event
+func runEvents(ctx context.Context, cmd *cobra.Command, input *Input, events []string) error {
+	if watch, _ := cmd.Flags().GetBool("watch"); watch && len(events) > 1 {
+		return fmt.Errorf("--watch runs the workflows of a single event")
+	}
+
+	results := make([]eventResult, 0, len(events))
+	for _, event := range events {
+		eventInput := *input
+		eventInput.eventPayloadDir = ""
+		if payload := input.eventPayload(event); payload != "" {
+			log.Debugf("Using the payload %s for %s", payload, event)
+			eventInput.eventPath = payload
+		}
+		if len(events) > 1 {
+			log.Infof("Running the workflows of %s", event)
+		}
+
+		start := time.Now()
+		err := newRunCommand(ctx, &eventInput)(cmd, []string{event})
+		results = append(results, eventResult{
+			Event: event,
+			Err:   err,
+			Jobs:  readEventJobs(eventInput.RunStateFile(), event, start),
+		})
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	list, _ := cmd.Flags().GetBool("list")
+	graph, _ := cmd.Flags().GetBool("graph")
+	if len(events) > 1 && !list && !graph {
+		printEventResults(results)
+	}
+
+	var failed []string
+	var firstErr error
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result.Event)
+			if firstErr == nil {
+				firstErr = result.Err
+			}
+		}
+	}
+	if len(events) == 1 || firstErr == nil {
+		return firstErr
+	}
+	return fmt.Errorf("the workflows of %s failed: %w", strings.Join(failed, ", "), firstErr)
+}
+
+// eventPayload returns the payload of the event in --event-payload-dir, e.g. pull_request.json,
+// or an empty string if it has none
+func (i *Input) eventPayload(event string) string {
+	if i.eventPayloadDir == "" {
+		return ""
+	}
+	path := filepath.Join(i.resolve(i.eventPayloadDir), event+".json")
+	if _, err := os.Stat(path); err != nil {
+		log.Debugf("No payload for %s in %s", event, i.eventPayloadDir)
+		return ""
+	}
+	return path
+}
+
+// readEventJobs returns the results of the jobs of the run of an event, the state of the run is
+// only written if the jobs ran, e.g. not for --list or --dryrun
+func readEventJobs(path string, event string, start time.Time) map[string]*runner.JobState {
+	fi, err := os.Stat(path)
+	if err != nil || fi.ModTime().Before(start) {
+		return nil
+	}
+	state, err := runner.ReadRunState(path)
+	if err != nil || state.EventName != event {
+		return nil
+	}
+	return state.Jobs
+}
+
+func printEventResults(results []eventResult) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EVENT\tRESULT\tJOBS")
+	for _, result := range results {
+		status := "success"
+		if result.Err != nil {
+			status = "failure"
+		}
+		keys := make([]string, 0, len(result.Jobs))
+		for key := range result.Jobs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		jobs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			jobs = append(jobs, fmt.Sprintf("%s: %s", key, result.Jobs[key].Result))
+		}
+		if len(jobs) == 0 {
+			jobs = append(jobs, "-")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Event, status, strings.Join(jobs, ", "))
+	}
+	_ = w.Flush()
+}
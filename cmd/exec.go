@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func newExecCommand(ctx context.Context, input *Input, runFlags *pflag.FlagSet) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec <job id> [-- command [args...]]",
+		Short: "Prepare the container, env, workspace and services of a job like a run of it does and run a command or, without one, a shell in the job container instead of the steps",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input.execCommand = append([]string{}, args[1:]...)
+			if err := cmd.Flags().Set("job", args[0]); err != nil {
+				return err
+			}
+			return newRunCommand(ctx, input)(cmd, nil)
+		},
+		SilenceUsage: true,
+	}
+	// the job is prepared like with the run command, e.g. -P, --env-file and --secret
+	cmd.Flags().AddFlagSet(runFlags)
+	return cmd
+}
+
+// planRun returns the run of the job in the plan, the other runs of the plan are the jobs it needs
+func planRun(plan *model.Plan, jobID string) *model.Run {
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if run.JobID == jobID {
+				return run
+			}
+		}
+	}
+	return nil
+}
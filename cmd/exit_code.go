@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// The exit codes of act for the classes of errors, wrapper scripts rely on them
+const (
+	exitCodeJobFailed      = 1   // a step of a job failed
+	exitCodeError          = 2   // any other error, e.g. invalid flags or files which can't be read
+	exitCodeWorkflow       = 3   // a workflow can't be parsed or isn't valid
+	exitCodePlan           = 4   // the jobs to run can't be planned, e.g. for unknown needs or an unknown job
+	exitCodeInfrastructure = 5   // the container engine, an image pull or a job container failed
+	exitCodeInterrupted    = 130 // the run was cancelled with Ctrl+C or SIGTERM
+)
+
+func exitCode(ctx context.Context, err error) int {
+	if ctx.Err() != nil {
+		return exitCodeInterrupted
+	}
+	switch common.ErrorClassOf(err) {
+	case common.ErrorClassJob:
+		return exitCodeJobFailed
+	case common.ErrorClassWorkflow:
+		return exitCodeWorkflow
+	case common.ErrorClassPlan:
+		return exitCodePlan
+	case common.ErrorClassInfrastructure:
+		return exitCodeInfrastructure
+	default:
+		return exitCodeError
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nektos/act/pkg/runner"
+)
+
+func newExportCommand(ctx context.Context, input *Input, runFlags *pflag.FlagSet) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [event name]",
+		Short: "Export the jobs which would run as standalone scripts with their env evaluated and the commands of their run and docker:// steps, or as docker compose files with their job and service containers, reading the secrets from the environment, to reproduce simple pipelines without act",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if input.exportFormat != "shell" && input.exportFormat != "compose" {
+				return fmt.Errorf("unknown --format '%s', expected shell or compose", input.exportFormat)
+			}
+			input.export = true
+			return newRunCommand(ctx, input)(cmd, args)
+		},
+		SilenceUsage: true,
+	}
+	// the plan is evaluated like with the run command, e.g. -P, --env-file and --input
+	cmd.Flags().AddFlagSet(runFlags)
+	cmd.Flags().StringVar(&input.exportFormat, "format", "shell", "format of the exported jobs, shell (bash scripts) or compose (docker compose files)")
+	cmd.Flags().StringVarP(&input.exportOutput, "output", "o", "", "directory to write a file per job to, the files are printed if omitted")
+	return cmd
+}
+
+// writeExport writes the exported files into the directory of --output or prints them
+func writeExport(input *Input, files []*runner.ExportFile) error {
+	if input.exportOutput == "" {
+		for i, file := range files {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("# ---- %s ----\n%s", file.Name, file.Content)
+		}
+		return nil
+	}
+	dir := input.resolve(input.exportOutput)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, file := range files {
+		mode := os.FileMode(0o644)
+		if strings.HasSuffix(file.Name, ".sh") {
+			mode = 0o755
+		}
+		path := filepath.Join(dir, file.Name)
+		//nolint:gosec // the scripts are meant to be executed
+		if err := os.WriteFile(path, []byte(file.Content), mode); err != nil {
+			return err
+		}
+		log.Infof("Exported %s", path)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"os"
 
+	"github.com/spf13/cobra"
+
 	"github.com/nektos/act/pkg/common"
 	"github.com/nektos/act/pkg/model"
 )
@@ -36,3 +38,50 @@ func drawGraph(plan *model.Plan) error {
 	}
 	return nil
 }
+
+func newGraphCommand(input *Input) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph [event name]",
+		Short: "Render the dependency graph of the jobs, with the combinations of their matrices and the jobs of the reusable workflows they call, as Graphviz DOT or Mermaid",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobID, err := cmd.Flags().GetString("job")
+			if err != nil {
+				return err
+			}
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			planner, err := newWorkflowPlanner(input)
+			if err != nil {
+				return err
+			}
+
+			var plan *model.Plan
+			var plannerErr error
+			if jobID != "" {
+				plan, plannerErr = planner.PlanJob(jobID)
+			} else if len(args) > 0 {
+				plan, plannerErr = planner.PlanEvent(args[0])
+			} else {
+				plan, plannerErr = planner.PlanAll()
+			}
+			if plan == nil && plannerErr != nil {
+				return plannerErr
+			}
+
+			if err := model.WriteGraph(os.Stdout, plan, format, input.Workdir()); err != nil {
+				return err
+			}
+			return plannerErr
+		},
+		// .actrc may contain flags of the run command
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		SilenceUsage:       true,
+	}
+	cmd.Flags().StringP("job", "j", "", "render the graph of a specific job ID and the jobs it needs")
+	cmd.Flags().String("format", "dot", "format of the graph, dot or mermaid (e.g. act graph | dot -Tsvg > graph.svg)")
+	return cmd
+}
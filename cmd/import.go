@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/importer"
+)
+
+func newImportCommand(input *Input) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <.gitlab-ci.yml or .circleci/config.yml>",
+		Short: "Convert a GitLab CI or CircleCI configuration into a workflow, with notes about the parts which aren't converted, to preview the pipeline with act",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conversion, err := importer.Import(input.resolve(args[0]))
+			if err != nil {
+				return err
+			}
+			if input.importOutput == "" {
+				_, err := os.Stdout.Write(conversion.Content)
+				return err
+			}
+
+			output := input.resolve(input.importOutput)
+			if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(output, conversion.Content, 0o644); err != nil {
+				return err
+			}
+			for _, note := range conversion.Notes {
+				log.Warnf("%s", note)
+			}
+			log.Infof("Converted %s into %s", conversion.Source, output)
+			return nil
+		},
+		// .actrc may contain flags of the run command
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		SilenceUsage:       true,
+	}
+	cmd.Flags().StringVarP(&input.importOutput, "output", "o", "", "workflow file to write, the workflow is printed if omitted (e.g. -o .github/workflows/gitlab.yml)")
+	return cmd
+}
@@ -1,31 +1,60 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"path/filepath"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/model"
 )
 
 // Input contains the input for the root command
 type Input struct {
 	actor                              string
 	workdir                            string
-	workflowsPath                      string
+	workflowsPaths                     []string
+	workflowNames                      []string
+	repo                               string
+	changedFrom                        string
+	needsMode                          string
+	needsOutputs                       []string
+	repoDir                            string // the clone of --repo
 	autodetectEvent                    bool
 	eventPath                          string
 	reuseContainers                    bool
 	bindWorkdir                        bool
+	copyWorkspace                      bool
+	copyTrackedOnly                    bool
+	copyBack                           []string
+	submodules                         string
+	lfs                                bool
 	secrets                            []string
 	envs                               []string
 	inputs                             []string
 	platforms                          []string
 	dryrun                             bool
+	planFormat                         string
 	forcePull                          bool
 	forceRebuild                       bool
 	noOutput                           bool
+	onlyFailures                       bool
 	envfile                            string
 	inputfile                          string
 	secretfile                         string
+	vars                               []string
+	varfile                            string
+	environment                        string
+	noInput                            bool
+	secretProviders                    []string
+	maskPatterns                       []string
+	rerunFailed                        bool
+	runStateFile                       string
+	breakBefore                        []string
+	breakOnFailure                     bool
+	execCommand                        []string
 	insecureSecrets                    bool
 	defaultBranch                      string
 	privileged                         bool
@@ -34,11 +63,17 @@ type Input struct {
 	containerDaemonSocket              string
 	containerOptions                   string
 	noWorkflowRecurse                  bool
+	strict                             bool
 	useGitIgnore                       bool
 	githubInstance                     string
+	compat                             string
+	hostPlatforms                      bool
+	compatWorkflows                    bool // the workflows are read from the directory of --compat, -W isn't set
+	defaultActionsURL                  string
 	containerCapAdd                    []string
 	containerCapDrop                   []string
 	autoRemove                         bool
+	keepContainers                     bool
 	artifactServerPath                 string
 	artifactServerAddr                 string
 	artifactServerPort                 string
@@ -46,12 +81,105 @@ type Input struct {
 	cacheServerPath                    string
 	cacheServerAddr                    string
 	cacheServerPort                    uint16
+	cacheServerStorage                 string
 	jsonLogger                         bool
 	noSkipCheckout                     bool
 	remoteName                         string
 	replaceGheActionWithGithubCom      []string
 	replaceGheActionTokenWithGithubCom string
 	matrix                             []string
+	noBuildKit                         bool
+	buildCacheFrom                     []string
+	buildCacheTo                       []string
+	stepCache                          bool
+	checkpoint                         bool
+	resume                             bool
+	requiredWorkflows                  []string
+	runnerVersion                      string
+	failOnDeprecation                  bool
+	setupFastPath                      bool
+	serviceTimeout                     time.Duration
+	serviceLogs                        bool
+	serviceLogsDir                     string
+	networkMode                        string
+	seed                               int64
+	noNetwork                          bool
+	proxyEnv                           bool
+	caCertificates                     []string
+	sandboxProfile                     string
+	jobSandboxProfiles                 []string
+	forwardSSHAgent                    bool
+	forwardGitConfig                   bool
+	toolCache                          string
+	actionOfflineMode                  bool
+	provisionNode                      bool
+	actionReplacements                 []string
+	actionVerifier                     string
+	imageVerifier                      string
+	enforceActionVerification          bool
+	skipSteps                          []string
+	retries                            []string
+	retryBackoff                       time.Duration
+	githubAPIMock                      bool
+	githubAPIFixtures                  string
+	oidc                               bool
+	oidcIssuer                         string
+	oidcKey                            string
+	oidcClaims                         []string
+	timings                            bool
+	timingsSort                        string
+	timingsJSON                        string
+	timingsFlamegraph                  string
+	resourceUsage                      bool
+	oomWatch                           bool
+	sbom                               string
+	sbomFormat                         string
+	export                             bool // the export command exports the plan instead of running it
+	exportFormat                       string
+	exportOutput                       string
+	importOutput                       string
+	listenPort                         uint16
+	listenAddr                         string
+	webhookSecret                      string
+	runnerURL                          string
+	runnerToken                        string
+	runnerName                         string
+	runnerLabels                       []string
+	eventPayloadDir                    string
+	workflowRun                        bool
+	workflowRunLevel                   int // how many workflow_run triggers led to the run
+	prNumber                           int
+	prBase                             string
+	prHead                             string
+	prDraft                            bool
+	prLabels                           []string
+	pushRange                          string
+	eventTemplate                      bool
+	eventFields                        []string
+	projectVolumes                     bool
+	volumeRetention                    time.Duration
+	mounts                             []string
+	hostEnv                            []string
+	noSummary                          bool
+	maxWorkflows                       int
+	maxJobs                            int
+	microVMKernel                      string
+	microVMVCPUs                       int
+	microVMMemory                      int
+	microVMTap                         string
+	gpus                               string
+	gpuRuntime                         string
+	prewarmContainers                  int
+	jobCache                           bool
+	prefetch                           bool
+	exportArtifacts                    string
+	exportPaths                        []string
+	repository                         string
+	sha                                string
+	ref                                string
+	isolateSteps                       bool
+	failureRecap                       int
+	reportToGitHub                     string
 }
 
 func (i *Input) resolve(path string) string {
@@ -78,14 +206,43 @@ func (i *Input) Secretfile() string {
 	return i.resolve(i.secretfile)
 }
 
-// Workdir returns path to workdir
+// Varfile returns path to configuration variables
+func (i *Input) Varfile() string {
+	return i.resolve(i.varfile)
+}
+
+// RunStateFile returns the path to the results of the last run, by default one per workdir in the cache
+func (i *Input) RunStateFile() string {
+	if i.runStateFile != "" {
+		return i.resolve(i.runStateFile)
+	}
+	sum := sha256.Sum256([]byte(i.Workdir()))
+	return filepath.Join(CacheHomeDir, "act", "runs", hex.EncodeToString(sum[:8])+".json")
+}
+
+// Workdir returns path to workdir, the clone of --repo if it is set
 func (i *Input) Workdir() string {
+	if i.repoDir != "" {
+		return i.repoDir
+	}
 	return i.resolve(".")
 }
 
-// WorkflowsPath returns path to workflow file(s)
-func (i *Input) WorkflowsPath() string {
-	return i.resolve(i.workflowsPath)
+// WorkflowsPaths returns the paths to the workflow files and directories, relative paths are
+// relative to the workdir
+func (i *Input) WorkflowsPaths() []string {
+	workflowsPaths := i.workflowsPaths
+	if i.compatWorkflows {
+		workflowsPaths = i.compatWorkflowsPaths()
+	}
+	paths := make([]string, 0, len(workflowsPaths))
+	for _, path := range workflowsPaths {
+		if i.repoDir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(i.repoDir, path)
+		}
+		paths = append(paths, i.resolve(path))
+	}
+	return paths
 }
 
 // EventPath returns the path to events file
@@ -97,3 +254,11 @@ func (i *Input) EventPath() string {
 func (i *Input) Inputfile() string {
 	return i.resolve(i.inputfile)
 }
+
+// StrictMode returns what the planner does with the unknown keys of the workflows
+func (i *Input) StrictMode() model.StrictMode {
+	if i.strict {
+		return model.StrictModeError
+	}
+	return model.StrictModeWarn
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -105,3 +108,78 @@ func printList(plan *model.Plan) error {
 	}
 	return nil
 }
+
+type listWorkflowJSON struct {
+	File   string                 `json:"file"`
+	Name   string                 `json:"name"`
+	Events map[string]interface{} `json:"events"` // the filters of the events, e.g. branches or paths
+	Jobs   []*listJobJSON         `json:"jobs"`
+}
+
+type listJobJSON struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Stage  int      `json:"stage"`
+	RunsOn []string `json:"runs_on"`
+	Uses   string   `json:"uses,omitempty"` // the reusable workflow called by the job
+	Needs  []string `json:"needs"`
+}
+
+// printListJSON prints the workflows of the plan with their events and jobs as JSON for -l --json
+func printListJSON(w io.Writer, plan *model.Plan) error {
+	workflows := []*listWorkflowJSON{}
+	byWorkflow := map[*model.Workflow]*listWorkflowJSON{}
+	for i, stage := range plan.Stages {
+		for _, r := range stage.Runs {
+			job := r.Job()
+			if job == nil {
+				continue
+			}
+			workflow, ok := byWorkflow[r.Workflow]
+			if !ok {
+				workflow = &listWorkflowJSON{
+					File:   r.Workflow.File,
+					Name:   r.Workflow.Name,
+					Events: map[string]interface{}{},
+					Jobs:   []*listJobJSON{},
+				}
+				for _, event := range r.Workflow.On() {
+					workflow.Events[event] = r.Workflow.OnEvent(event)
+				}
+				byWorkflow[r.Workflow] = workflow
+				workflows = append(workflows, workflow)
+			}
+
+			runsOn := job.RunsOn()
+			if runsOn == nil {
+				runsOn = []string{}
+			}
+			needs := job.Needs()
+			if needs == nil {
+				needs = []string{}
+			}
+			workflow.Jobs = append(workflow.Jobs, &listJobJSON{
+				ID:     r.JobID,
+				Name:   r.String(),
+				Stage:  i,
+				RunsOn: runsOn,
+				Uses:   job.Uses,
+				Needs:  needs,
+			})
+		}
+	}
+	for _, workflow := range workflows {
+		sort.SliceStable(workflow.Jobs, func(i, j int) bool {
+			if workflow.Jobs[i].Stage != workflow.Jobs[j].Stage {
+				return workflow.Jobs[i].Stage < workflow.Jobs[j].Stage
+			}
+			return workflow.Jobs[i].ID < workflow.Jobs[j].ID
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"workflows": workflows,
+	})
+}
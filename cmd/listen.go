@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nektos/act/pkg/webhook"
+)
+
+// listenQueueSize is how many deliveries wait for the run of the workflows of the previous ones
+const listenQueueSize = 16
+
+func newListenCommand(ctx context.Context, input *Input, runFlags *pflag.FlagSet) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "listen",
+		Short: "Receive the webhook deliveries of GitHub and run the workflows of their events with their payloads, one delivery after the other, to test event-driven workflows with the events of a real repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			secret := input.webhookSecret
+			if secret == "" {
+				secret = os.Getenv("ACT_WEBHOOK_SECRET")
+			}
+			// nobody answers the prompts of a run of a delivery
+			input.noInput = true
+
+			queue := make(chan *webhook.Delivery, listenQueueSize)
+			// the deliveries are received in the goroutines of the handler while the previous ones
+			// run, each of them reads a copy of the input
+			deliver := func(delivery *webhook.Delivery) error {
+				deliveryInput := *input
+				planner, err := newWorkflowPlanner(&deliveryInput)
+				if err != nil {
+					return err
+				}
+				if !containsEvent(planner.GetEvents(), delivery.Event) {
+					return fmt.Errorf("%w %s", webhook.ErrNotRun, delivery.Event)
+				}
+				select {
+				case queue <- delivery:
+					log.Infof("Queued the delivery %s of %s", delivery.ID, delivery.Event)
+					return nil
+				default:
+					return fmt.Errorf("%d deliveries are waiting already, try again later", listenQueueSize)
+				}
+			}
+
+			handler, err := webhook.StartHandler(secret, input.listenAddr, input.listenPort, deliver, log.StandardLogger())
+			if err != nil {
+				return err
+			}
+			defer handler.Close()
+			log.Infof("Listening for webhook deliveries on http://%s%s", handler.Addr(), webhook.Path)
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case delivery := <-queue:
+					if err := runDelivery(ctx, cmd, input, delivery); err != nil {
+						log.Errorf("The workflows of the delivery %s of %s failed: %v", delivery.ID, delivery.Event, err)
+					}
+				}
+			}
+		},
+		SilenceUsage: true,
+	}
+	// the workflows run like with the run command, e.g. -P, --secret and -W
+	cmd.Flags().AddFlagSet(runFlags)
+	cmd.Flags().Uint16Var(&input.listenPort, "port", 8080, "port to receive the webhook deliveries on")
+	cmd.Flags().StringVar(&input.listenAddr, "addr", "", "address to receive the webhook deliveries on, all interfaces if omitted")
+	cmd.Flags().StringVar(&input.webhookSecret, "webhook-secret", "", "secret of the webhook the deliveries are validated with, defaults to ACT_WEBHOOK_SECRET")
+	return cmd
+}
+
+// runDelivery runs the workflows of the event of a delivery with its payload as the event payload
+func runDelivery(ctx context.Context, cmd *cobra.Command, input *Input, delivery *webhook.Delivery) error {
+	f, err := os.CreateTemp("", "act-event-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(delivery.Payload); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	log.Infof("Running the workflows of the delivery %s of %s", delivery.ID, delivery.Event)
+	deliveryInput := *input
+	deliveryInput.eventPath = f.Name()
+	return newRunCommand(ctx, &deliveryInput)(cmd, []string{delivery.Event})
+}
+
+func containsEvent(events []string, event string) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
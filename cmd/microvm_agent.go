@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/container"
+)
+
+func newMicroVMAgentCommand(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "microvm-agent",
+		Short: "Serve the jobs of act in a microVM, run it when the root file system of a microvm:// platform boots",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			port, err := cmd.Flags().GetUint32("port")
+			if err != nil {
+				return err
+			}
+			return container.ListenMicroVMAgent(ctx, port)
+		},
+		SilenceUsage: true,
+	}
+	cmd.Flags().Uint32("port", container.MicroVMAgentPort, "vsock port to listen on")
+	return cmd
+}
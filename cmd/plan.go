@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/runner"
+)
+
+// printPlanReport prints the plan of --dryrun as text or json
+func printPlanReport(w io.Writer, report *runner.PlanReport, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "", "text":
+	default:
+		return fmt.Errorf("unknown --plan-format %q, expected text or json", format)
+	}
+
+	fmt.Fprintf(w, "Plan for the %s event\n", report.EventName)
+	for i, stage := range report.Stages {
+		fmt.Fprintf(w, "\nStage %d:\n", i+1)
+		for _, job := range stage.Jobs {
+			fmt.Fprintf(w, "  %s %s (%s)", planMark(job.Enabled), job.Name, job.WorkflowFile)
+			if !job.Enabled {
+				fmt.Fprintf(w, ": %s", job.Reason)
+			}
+			fmt.Fprintln(w)
+			printPlanCondition(w, "      ", job.If)
+			printPlanValue(w, "      ", "image", job.Image)
+			printPlanValue(w, "      ", "uses", job.Uses)
+			if len(job.Matrix) > 0 {
+				printPlanValue(w, "      ", "matrix", fmt.Sprint(job.Matrix))
+			}
+			printPlanMap(w, "      ", "env", job.Env)
+			for _, step := range job.Steps {
+				fmt.Fprintf(w, "      %s %s", planMark(step.Enabled), step.Name)
+				if !step.Enabled {
+					fmt.Fprintf(w, ": %s", step.Reason)
+				}
+				fmt.Fprintln(w)
+				printPlanCondition(w, "          ", step.If)
+				if step.Uses != step.Name {
+					printPlanValue(w, "          ", "uses", step.Uses)
+				}
+				if step.Run != step.Name {
+					printPlanValue(w, "          ", "run", step.Run)
+				}
+				printPlanMap(w, "          ", "with", step.With)
+				printPlanMap(w, "          ", "env", step.Env)
+			}
+		}
+	}
+	return nil
+}
+
+func planMark(enabled bool) string {
+	if enabled {
+		return "[run] "
+	}
+	return "[skip]"
+}
+
+// printPlanCondition prints the if condition unless it is the default one
+func printPlanCondition(w io.Writer, indent string, condition string) {
+	if condition != "success()" {
+		printPlanValue(w, indent, "if", condition)
+	}
+}
+
+func printPlanValue(w io.Writer, indent string, name string, value string) {
+	if value == "" {
+		return
+	}
+	lines := strings.Split(strings.TrimRight(value, "\n"), "\n")
+	fmt.Fprintf(w, "%s%s: %s\n", indent, name, lines[0])
+	for _, line := range lines[1:] {
+		fmt.Fprintf(w, "%s%s  %s\n", indent, strings.Repeat(" ", len(name)), line)
+	}
+}
+
+func printPlanMap(w io.Writer, indent string, name string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(w, "%s%s:\n", indent, name)
+	for _, k := range keys {
+		printPlanValue(w, indent+"  ", k, values[k])
+	}
+}
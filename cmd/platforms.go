@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"runtime"
 	"strings"
+
+	"github.com/nektos/act/pkg/runner"
 )
 
 func (i *Input) newPlatforms() map[string]string {
@@ -12,10 +15,26 @@ func (i *Input) newPlatforms() map[string]string {
 		"ubuntu-18.04":  "node:16-buster-slim",
 	}
 
+	// the jobs of the runners of the OS of the host run natively with --host-platforms, e.g. macos-* on macOS
+	if i.hostPlatforms {
+		for label, image := range runner.HostPlatforms(runtime.GOOS) {
+			platforms[label] = image
+		}
+	}
+
+	if compatPlatforms, err := runner.CompatPlatforms(i.compat); err == nil {
+		for label, image := range compatPlatforms {
+			platforms[label] = image
+		}
+	}
+
 	for _, p := range i.platforms {
 		pParts := strings.Split(p, "=")
 		if len(pParts) == 2 {
 			platforms[pParts[0]] = pParts[1]
+		} else if label, image, ok := runner.ParseCompatLabel(p); ok && i.compat != "" {
+			// the labels of the runners of Gitea and Forgejo, e.g. ubuntu-latest:docker://node:20
+			platforms[label] = image
 		}
 	}
 	return platforms
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigFiles are the project config files act looks for in the working directory
+var projectConfigFiles = []string{".act.yaml", ".act.yml"}
+
+// projectConfig is the project config file, with the named profiles of flags the team of the
+// repository shares, e.g. a profile for the CI and one for the laptops
+type projectConfig struct {
+	Default  string              `yaml:"default"` // the profile used without --profile
+	Profiles map[string]*profile `yaml:"profiles"`
+}
+
+type profile struct {
+	Platforms          map[string]string `yaml:"platforms"`
+	Pull               *bool             `yaml:"pull"`
+	Rebuild            *bool             `yaml:"rebuild"`
+	SecretFile         string            `yaml:"secret-file"`
+	VarFile            string            `yaml:"var-file"`
+	EnvFile            string            `yaml:"env-file"`
+	Env                map[string]string `yaml:"env"`
+	ContainerOptions   string            `yaml:"container-options"`
+	ArtifactServerPath string            `yaml:"artifact-server-path"`
+	Mounts             []string          `yaml:"mounts"`   // like --mount, e.g. ~/.cache/pip:/root/.cache/pip
+	HostEnv            []string          `yaml:"host-env"` // like --use-host-env, e.g. AWS_*
+	Flags              []string          `yaml:"flags"`    // any other flags, e.g. --bind
+}
+
+// args returns the flags of the profile, the platforms and env in the order of their names
+func (p *profile) args() []string {
+	var args []string
+	for _, platform := range sortedKeys(p.Platforms) {
+		args = append(args, "--platform", fmt.Sprintf("%s=%s", platform, p.Platforms[platform]))
+	}
+	if p.Pull != nil {
+		args = append(args, "--pull="+strconv.FormatBool(*p.Pull))
+	}
+	if p.Rebuild != nil {
+		args = append(args, "--rebuild="+strconv.FormatBool(*p.Rebuild))
+	}
+	for _, flag := range []struct{ name, value string }{
+		{"--secret-file", p.SecretFile},
+		{"--var-file", p.VarFile},
+		{"--env-file", p.EnvFile},
+		{"--container-options", p.ContainerOptions},
+		{"--artifact-server-path", p.ArtifactServerPath},
+	} {
+		if flag.value != "" {
+			args = append(args, flag.name+"="+flag.value)
+		}
+	}
+	for _, name := range sortedKeys(p.Env) {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", name, p.Env[name]))
+	}
+	for _, m := range p.Mounts {
+		args = append(args, "--mount", m)
+	}
+	for _, pattern := range p.HostEnv {
+		args = append(args, "--use-host-env", pattern)
+	}
+	return append(args, p.Flags...)
+}
+
+// profileArgs returns the flags of the profile selected with --profile in args, or of the default
+// profile of the project config file, nil if the working directory has no project config file
+func profileArgs(args []string) ([]string, error) {
+	var file string
+	var data []byte
+	for _, file = range projectConfigFiles {
+		var err error
+		if data, err = os.ReadFile(file); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	name := selectedProfile(args)
+	if data == nil {
+		if name != "" {
+			return nil, fmt.Errorf("unable to use the profile %s, the working directory has no %s", name, projectConfigFiles[0])
+		}
+		return nil, nil
+	}
+
+	var config projectConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", file, err)
+	}
+	if name == "" {
+		name = config.Default
+	}
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := config.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%s has no profile %s, its profiles are %s", file, name, strings.Join(sortedKeys(config.Profiles), ", "))
+	}
+	if p == nil {
+		return nil, nil
+	}
+	return p.args(), nil
+}
+
+// selectedProfile returns the value of the last --profile of args
+func selectedProfile(args []string) string {
+	name := ""
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--profile" && i+1 < len(args) {
+			name = args[i+1]
+		} else if strings.HasPrefix(arg, "--profile=") {
+			name = strings.TrimPrefix(arg, "--profile=")
+		}
+	}
+	return name
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
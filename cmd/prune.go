@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/container"
+)
+
+func newPruneCommand(ctx context.Context, input *Input) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the containers, networks, volumes and images act created, e.g. left behind by aborted runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+			olderThan, err := cmd.Flags().GetDuration("older-than")
+			if err != nil {
+				return err
+			}
+			force, err := cmd.Flags().GetBool("force")
+			if err != nil {
+				return err
+			}
+			return prune(ctx, dryRun || input.dryrun, olderThan, force)
+		},
+		// .actrc may contain flags of the run command
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		SilenceUsage:       true,
+	}
+	cmd.Flags().Bool("dry-run", false, "only list what would be removed")
+	cmd.Flags().Duration("older-than", 0, "only remove what was created longer ago than the duration (e.g. 24h)")
+	cmd.Flags().Bool("force", false, "also remove the running containers, which may belong to runs in progress")
+	return cmd
+}
+
+func prune(ctx context.Context, dryRun bool, olderThan time.Duration, force bool) error {
+	resources, err := container.ListResources(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		fmt.Println("Nothing to remove")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tCREATED\tRESULT")
+	failed := 0
+	for _, r := range resources {
+		created := "unknown"
+		if !r.Created.IsZero() {
+			created = r.Created.Format(time.RFC3339)
+		}
+		result := "removed"
+		switch {
+		case r.Running && !force:
+			result = "skipped, running (use --force)"
+		case dryRun:
+			result = "would be removed"
+		default:
+			if err := container.RemoveResource(ctx, r); err != nil {
+				log.Debugf("Unable to remove the %s %s: %v", r.Kind, r.Name, err)
+				result = "failed, in use?"
+				failed++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Kind, r.Name, created, result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d of %d resources, run with --verbose for the errors", failed, len(resources))
+	}
+	return nil
+}
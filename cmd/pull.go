@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/runner"
+)
+
+func newPullCommand(ctx context.Context, input *Input) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pull [event name]",
+		Short: "Download the remote actions and reusable workflows of the workflows into the action cache, e.g. for air-gapped environments with --action-offline-mode",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  newPullRunCommand(ctx, input),
+		// .actrc may contain flags of the run command
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		SilenceUsage:       true,
+	}
+	cmd.Flags().StringP("job", "j", "", "pull the actions of a specific job ID")
+	cmd.Flags().StringArrayVarP(&input.secrets, "secret", "s", []string{}, "secret to make available to actions with optional value, GITHUB_TOKEN is used to clone private actions (e.g. -s GITHUB_TOKEN=foo)")
+	cmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env GITHUB_SERVER_URL=https://ghe.example.com)")
+	cmd.Flags().StringArrayVar(&input.actionReplacements, "replace-action", []string{}, "replace an action with another remote action or an action in the working directory, a trailing * matches any action (e.g. --replace-action mycorp/*=./actions/*)")
+	return cmd
+}
+
+func newPullRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if input.jsonLogger {
+			log.SetFormatter(&log.JSONFormatter{})
+		}
+
+		envs := make(map[string]string)
+		_ = parseEnvs(input.envs, envs)
+		_ = readEnvs(input.Envfile(), envs, input.environment)
+
+		secrets := newSecrets(input.secrets)
+		_ = readEnvs(input.Secretfile(), secrets, input.environment)
+
+		actionReplacements := make(map[string]string)
+		_ = parseEnvs(input.actionReplacements, actionReplacements)
+
+		planner, err := newWorkflowPlanner(input)
+		if err != nil {
+			return err
+		}
+
+		jobID, err := cmd.Flags().GetString("job")
+		if err != nil {
+			return err
+		}
+
+		// without an event the actions of all workflows are pulled
+		var plan *model.Plan
+		var plannerErr error
+		if jobID != "" {
+			log.Debugf("Planning job: %s", jobID)
+			plan, plannerErr = planner.PlanJob(jobID)
+		} else if len(args) > 0 {
+			log.Debugf("Planning jobs for event: %s", args[0])
+			plan, plannerErr = planner.PlanEvent(args[0])
+		} else {
+			log.Debugf("Planning all jobs")
+			plan, plannerErr = planner.PlanAll()
+		}
+		if plan == nil && plannerErr != nil {
+			return plannerErr
+		}
+
+		r, err := runner.New(&runner.Config{
+			Actor:              input.actor,
+			Workdir:            input.Workdir(),
+			Env:                envs,
+			Secrets:            secrets,
+			Token:              secrets["GITHUB_TOKEN"],
+			GitHubInstance:     input.githubInstance,
+			RemoteName:         input.remoteName,
+			ActionReplacements: actionReplacements,
+			StrictMode:         input.StrictMode(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := r.NewPullExecutor(plan)(ctx); err != nil {
+			return err
+		}
+		return plannerErr
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/runner"
+)
+
+var remoteRepoPattern = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)((?:/[^@]+)?)(?:@(.+))?$`)
+
+// remoteRepo is the repository of --repo, owner/name with an optional subdirectory and ref,
+// e.g. nektos/act/examples@main
+type remoteRepo struct {
+	Owner  string
+	Name   string
+	Subdir string
+	Ref    string
+}
+
+func parseRemoteRepo(s string) (*remoteRepo, error) {
+	m := remoteRepoPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid repository '%s', expected owner/name[/path][@ref]", s)
+	}
+	return &remoteRepo{
+		Owner:  m[1],
+		Name:   m[2],
+		Subdir: filepath.FromSlash(strings.TrimPrefix(m[3], "/")),
+		Ref:    m[4],
+	}, nil
+}
+
+// cloneRemoteRepo clones the repository of --repo into a temp directory and makes it the
+// working directory of the run, the returned function removes it
+func cloneRemoteRepo(ctx context.Context, input *Input, token string) (func(), error) {
+	repo, err := parseRemoteRepo(input.repo)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "act-repo-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warnf("Unable to remove the clone of %s/%s: %v", repo.Owner, repo.Name, err)
+		}
+	}
+
+	clone := git.NewGitShallowCloneExecutor(git.NewGitCloneExecutorInput{
+		URL:   fmt.Sprintf("%s/%s/%s", runner.GitHubInstanceURL(input.githubInstance), repo.Owner, repo.Name),
+		Ref:   repo.Ref,
+		Dir:   dir,
+		Token: token,
+	})
+	if err := clone(ctx); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("unable to clone %s: %w", input.repo, err)
+	}
+
+	workdir := filepath.Join(dir, repo.Subdir)
+	if fi, err := os.Stat(workdir); err != nil || !fi.IsDir() {
+		cleanup()
+		return nil, fmt.Errorf("%s has no directory '%s'", input.repo, filepath.ToSlash(repo.Subdir))
+	}
+	input.repoDir = workdir
+	return cleanup, nil
+}
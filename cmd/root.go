@@ -2,62 +2,93 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/adrg/xdg"
-	"github.com/andreaskoch/go-fswatch"
 	"github.com/joho/godotenv"
-	gitignore "github.com/sabhiram/go-gitignore"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	"gopkg.in/yaml.v3"
 
 	"github.com/nektos/act/pkg/artifactcache"
 	"github.com/nektos/act/pkg/artifacts"
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
 	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/githubapi"
 	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/oidc"
 	"github.com/nektos/act/pkg/runner"
+	"github.com/nektos/act/pkg/secretprovider"
 )
 
 // Execute is the entry point to running the CLI
 func Execute(ctx context.Context, version string) {
 	input := new(Input)
 	var rootCmd = &cobra.Command{
-		Use:               "act [event name to run] [flags]\n\nIf no event name passed, will default to \"on: push\"\nIf actions handles only one event it will be used as default instead of \"on: push\"",
+		Use:               "act [event names to run] [flags]\n\nIf no event name passed, will default to \"on: push\"\nIf actions handles only one event it will be used as default instead of \"on: push\"\nSeveral event names run the workflows of each event one after the other",
 		Short:             "Run GitHub actions locally by specifying the event name (e.g. `push`) or an action name directly.",
-		Args:              cobra.MaximumNArgs(1),
+		Args:              cobra.ArbitraryArgs,
 		RunE:              newRunCommand(ctx, input),
 		PersistentPreRun:  setup(input),
 		PersistentPostRun: cleanup(input),
 		Version:           version,
 		SilenceUsage:      true,
 	}
-	rootCmd.Flags().BoolP("watch", "w", false, "watch the contents of the local repo and run when files change")
+	rootCmd.Flags().BoolP("watch", "w", false, "watch the contents of the local repo and run the workflows whose path filters match the changed files again")
 	rootCmd.Flags().BoolP("list", "l", false, "list workflows")
 	rootCmd.Flags().BoolP("graph", "g", false, "draw workflows")
 	rootCmd.Flags().StringP("job", "j", "", "run a specific job ID")
+	rootCmd.Flags().StringVar(&input.needsMode, "needs-mode", string(runner.NeedsModeRun), "what -j does with the jobs the job needs: run them first (run), assume they succeeded with the outputs of --needs-output (assume) or fail (fail)")
+	rootCmd.Flags().StringArrayVar(&input.needsOutputs, "needs-output", []string{}, "output of a job needed by the job of -j, which isn't run, implies --needs-mode assume (e.g. --needs-output build.version=1.2.3)")
 	rootCmd.Flags().BoolP("bug-report", "", false, "Display system information for bug report")
 
 	rootCmd.Flags().StringVar(&input.remoteName, "remote-name", "origin", "git remote name that will be used to retrieve url of git repo")
 	rootCmd.Flags().StringArrayVarP(&input.secrets, "secret", "s", []string{}, "secret to make available to actions with optional value (e.g. -s mysecret=foo or -s mysecret)")
+	rootCmd.Flags().StringArrayVar(&input.secretProviders, "secret-provider", []string{}, "resolve the secrets which have no value from cmd=<command> (called with the secret name, prints the value), vault=<path> (uses VAULT_ADDR and VAULT_TOKEN) or aws=<secret-id> (uses the aws CLI)")
+	rootCmd.Flags().StringArrayVar(&input.breakBefore, "break-before", []string{}, "pause before the steps whose name, id or uses: matches the pattern and open a shell in the job container with the environment of the step (e.g. --break-before 'Deploy*')")
+	rootCmd.Flags().BoolVar(&input.breakOnFailure, "break-on-failure", false, "open a shell in the job container with the environment of a failing step, then continue, retry the step or abort")
+	rootCmd.Flags().BoolVar(&input.rerunFailed, "rerun-failed", false, "run only the jobs which failed in the last run and the jobs needing them, the other jobs keep their results and outputs")
+	rootCmd.Flags().StringVar(&input.runStateFile, "run-state-file", "", "file the results of the jobs are saved to for --rerun-failed, defaults to a file per working directory in the cache directory")
+	rootCmd.Flags().BoolVar(&input.noInput, "no-input", false, "never prompt for the secrets the workflows refer to and the required workflow_dispatch inputs which have no value, e.g. in CI")
+	rootCmd.Flags().StringArrayVar(&input.vars, "var", []string{}, "configuration variable of the vars context (e.g. --var myvar=foo)")
 	rootCmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env myenv=foo or --env myenv)")
 	rootCmd.Flags().StringArrayVarP(&input.inputs, "input", "", []string{}, "action input to make available to actions (e.g. --input myinput=foo)")
 	rootCmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04)")
 	rootCmd.Flags().BoolVarP(&input.reuseContainers, "reuse", "r", false, "don't remove container(s) on successfully completed workflow(s) to maintain state between runs")
 	rootCmd.Flags().BoolVarP(&input.bindWorkdir, "bind", "b", false, "bind working directory to container, rather than copy")
+	rootCmd.Flags().BoolVar(&input.copyWorkspace, "copy-workspace", false, "copy the working directory into the job containers when the jobs start, rather than with the local actions/checkout steps")
+	rootCmd.Flags().BoolVar(&input.copyTrackedOnly, "copy-tracked-only", false, "with --copy-workspace, copy only the files tracked by git, e.g. without node_modules or a venv")
+	rootCmd.Flags().StringVar(&input.submodules, "submodules", "", "initialize the submodules of the workspace in the job containers, 'true' or 'recursive' like the submodules input of actions/checkout. Not with --bind, which leaves the workdir to you")
+	rootCmd.Flags().BoolVar(&input.lfs, "lfs", false, "fetch the git lfs files of the workspace in the job containers, like the lfs input of actions/checkout. Not with --bind, which leaves the workdir to you")
+	rootCmd.Flags().StringArrayVar(&input.mounts, "mount", []string{}, "path of the host to bind into the job containers, or to copy into them when they start, e.g. --mount ~/.cache/pip:/root/.cache/pip or --mount ./fixtures:/fixtures:copy, the modes are ro, rw, bind and copy")
+	rootCmd.Flags().StringArrayVar(&input.copyBack, "copy-back", []string{}, "path in the workspace to copy back to the working directory after the job, owned by the owner of the working directory (e.g. --copy-back dist)")
 	rootCmd.Flags().BoolVarP(&input.forcePull, "pull", "p", true, "pull docker image(s) even if already present")
 	rootCmd.Flags().BoolVarP(&input.forceRebuild, "rebuild", "", true, "rebuild local action docker image(s) even if already present")
 	rootCmd.Flags().BoolVarP(&input.autodetectEvent, "detect-event", "", false, "Use first event type from workflow as event that triggered the workflow")
-	rootCmd.Flags().StringVarP(&input.eventPath, "eventpath", "e", "", "path to event JSON file")
+	rootCmd.Flags().StringVarP(&input.eventPath, "eventpath", "e", "", "path to event JSON file, - to read it from stdin or a http(s) URL to download it")
+	rootCmd.Flags().BoolVar(&input.workflowRun, "workflow-run", false, "after the workflows ran, run the workflows whose workflow_run trigger matches their completion, with the workflow_run payload GitHub would send, up to three levels like GitHub")
+	rootCmd.Flags().IntVar(&input.prNumber, "pr-number", 0, "number of the pull request of the pull_request payload act builds without --eventpath (default 1)")
+	rootCmd.Flags().StringVar(&input.prBase, "pr-base", "", "branch the pull request of the pull_request payload merges into, the sha is the one of the local branch (default --defaultbranch, the default branch of the remote, main or master)")
+	rootCmd.Flags().StringVar(&input.prHead, "pr-head", "", "branch of the pull request of the pull_request payload, the sha is the one of the local branch (default the current branch)")
+	rootCmd.Flags().BoolVar(&input.prDraft, "pr-draft", false, "make the pull request of the pull_request payload a draft")
+	rootCmd.Flags().StringArrayVar(&input.prLabels, "pr-label", []string{}, "label of the pull request of the pull_request payload, can be repeated")
+	rootCmd.Flags().StringVar(&input.pushRange, "push-range", "", "commits before..after of the local history (e.g. v1.0.0..HEAD) of the push payload act builds without --eventpath, with the commits, the files they changed and whether the push is forced")
+	rootCmd.Flags().BoolVar(&input.eventTemplate, "event-template", false, "build the payload of the event from its template (push, tag push, delete, release, issue_comment, deployment and workflow_call) with the local branch and commit, instead of --eventpath")
+	rootCmd.Flags().StringArrayVar(&input.eventFields, "event-field", []string{}, "field of the payload act builds at its dotted path (e.g. --event-field release.prerelease=true --event-field ref=refs/tags/v2), the JSON values like numbers and booleans are set as such, implies --event-template")
+	rootCmd.Flags().StringVar(&input.eventPayloadDir, "event-payload-dir", "", "directory with the payloads of the events as <event>.json (e.g. fixtures/push.json), the events without one use --eventpath")
 	rootCmd.Flags().StringVar(&input.defaultBranch, "defaultbranch", "", "the name of the main branch")
 	rootCmd.Flags().BoolVar(&input.privileged, "privileged", false, "use privileged mode")
 	rootCmd.Flags().StringVar(&input.usernsMode, "userns", "", "user namespace to use")
@@ -65,25 +96,110 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.Flags().StringArrayVarP(&input.containerCapAdd, "container-cap-add", "", []string{}, "kernel capabilities to add to the workflow containers (e.g. --container-cap-add SYS_PTRACE)")
 	rootCmd.Flags().StringArrayVarP(&input.containerCapDrop, "container-cap-drop", "", []string{}, "kernel capabilities to remove from the workflow containers (e.g. --container-cap-drop SYS_PTRACE)")
 	rootCmd.Flags().BoolVar(&input.autoRemove, "rm", false, "automatically remove container(s)/volume(s) after a workflow(s) failure")
+	rootCmd.Flags().BoolVar(&input.keepContainers, "keep-containers", false, "keep the job and service containers and the network of failed jobs, even with --rm, to open a shell in them with 'act attach <job>'")
 	rootCmd.Flags().StringArrayVarP(&input.replaceGheActionWithGithubCom, "replace-ghe-action-with-github-com", "", []string{}, "If you are using GitHub Enterprise Server and allow specified actions from GitHub (github.com), you can set actions on this. (e.g. --replace-ghe-action-with-github-com =github/super-linter)")
 	rootCmd.Flags().StringVar(&input.replaceGheActionTokenWithGithubCom, "replace-ghe-action-token-with-github-com", "", "If you are using replace-ghe-action-with-github-com  and you want to use private actions on GitHub, you have to set personal access token")
-	rootCmd.Flags().StringArrayVarP(&input.matrix, "matrix", "", []string{}, "specify which matrix configuration to include (e.g. --matrix java:13")
+	rootCmd.Flags().StringArrayVarP(&input.matrix, "matrix", "", []string{}, "only run the matrix combinations with this value, keys of nested values are joined with dots, can be repeated (e.g. --matrix os:ubuntu-latest --matrix node:20 --matrix config.arch:arm64)")
+	rootCmd.Flags().BoolVar(&input.noBuildKit, "no-buildkit", false, "build docker actions with the legacy builder instead of BuildKit, which doesn't support the secret mounts of RUN --mount=type=secret")
+	rootCmd.Flags().StringArrayVarP(&input.buildCacheFrom, "cache-from", "", []string{}, "external cache sources for docker action builds (e.g. --cache-from user/app:cache)")
+	rootCmd.Flags().StringArrayVarP(&input.buildCacheTo, "cache-to", "", []string{}, "cache export destinations for docker action builds, the ones other than 'type=inline' are exported with docker buildx (e.g. --cache-to type=registry,ref=user/app:cache)")
+	rootCmd.Flags().BoolVar(&input.checkpoint, "checkpoint", false, "commit the job container after each successful step, so a failed job can continue after its last successful step with --resume")
+	rootCmd.Flags().BoolVar(&input.resume, "resume", false, "continue the jobs which failed with --checkpoint after their last successful step instead of running all steps again")
+	rootCmd.Flags().BoolVar(&input.stepCache, "step-cache", false, "skip run steps whose script, env and workspace content match a previous successful execution and replay their outputs")
+	rootCmd.Flags().StringVar(&input.runnerVersion, "emulate-runner-version", "", "emulate the behaviour of a GitHub runner release, e.g. disabled commands and available node versions (e.g. --emulate-runner-version 2.317)")
+	rootCmd.Flags().BoolVar(&input.failOnDeprecation, "fail-on-deprecation", false, "fail steps which use deprecated features like the set-output command or node12 actions")
+	rootCmd.Flags().BoolVar(&input.setupFastPath, "setup-fast-path", false, "satisfy actions/setup-go and actions/setup-node from the tool cache of the job container without fetching the action, if the requested version is cached")
+	rootCmd.Flags().DurationVar(&input.serviceTimeout, "service-timeout", 5*time.Minute, "how long to wait for service containers to become healthy")
+	rootCmd.Flags().BoolVar(&input.serviceLogs, "service-logs", false, "stream the output of service containers into the run output, prefixed with the service name")
+	rootCmd.Flags().StringVar(&input.networkMode, "network", "", "network of the job containers and services, e.g. 'host' for host networking, by default the jobs of a run share a bridge network")
+	rootCmd.Flags().BoolVar(&input.noNetwork, "no-network", false, "run job containers without network access, only the service containers of the job are reachable. Verifies that a workflow works in air-gapped environments")
+	rootCmd.Flags().StringArrayVar(&input.hostEnv, "use-host-env", []string{}, "pass the environment variables of the host matching the pattern to the job and step containers, * matches any characters (e.g. --use-host-env 'AWS_*' --use-host-env LC_ALL)")
+	rootCmd.Flags().BoolVar(&input.proxyEnv, "proxy-env", false, "pass HTTP_PROXY, HTTPS_PROXY and NO_PROXY of the environment to the job, service and step containers")
+	rootCmd.Flags().StringArrayVar(&input.caCertificates, "ca-cert", []string{}, "PEM file with additional CA certificates, which are trusted in the job containers (e.g. of a corporate proxy)")
+	rootCmd.Flags().StringVar(&input.sandboxProfile, "sandbox", "", "sandbox profile of the job containers, one of trusted, default or untrusted. Jobs can select a profile with a sandbox:<profile> runs-on label")
+	rootCmd.Flags().StringArrayVar(&input.jobSandboxProfiles, "job-sandbox", []string{}, "sandbox profile of a job, overrides --sandbox and runs-on labels (e.g. --job-sandbox build=untrusted)")
+	rootCmd.Flags().BoolVar(&input.forwardSSHAgent, "forward-ssh-agent", false, "mount the ssh agent (SSH_AUTH_SOCK) and ~/.ssh/known_hosts into the containers, e.g. to clone private repositories")
+	rootCmd.Flags().BoolVar(&input.forwardGitConfig, "forward-git-config", false, "mount ~/.gitconfig and ~/.git-credentials into the containers")
+	rootCmd.Flags().BoolVar(&input.projectVolumes, "project-volumes", false, "use named volumes per repository for the workspaces of the jobs, the tool cache and the act cache, which persist between runs instead of being removed after the jobs")
+	rootCmd.Flags().DurationVar(&input.volumeRetention, "volume-retention", 0, "remove the project volumes of --project-volumes which no run used for longer, e.g. 168h, at the start of the run (default keep them)")
+	rootCmd.Flags().StringVar(&input.toolCache, "tool-cache", "", "volume name or host directory which is mounted as RUNNER_TOOL_CACHE (/opt/hostedtoolcache), so setup actions reuse the toolchains of previous runs (e.g. --tool-cache act-hostedtoolcache)")
+	rootCmd.Flags().BoolVar(&input.actionOfflineMode, "action-offline-mode", false, "don't fetch remote actions and reusable workflows, use only the ones cached in $XDG_CACHE_HOME/act by previous runs")
+	rootCmd.Flags().BoolVar(&input.provisionNode, "provision-node", false, "download the Node.js release of the runner from nodejs.org, verified with its SHASUMS256.txt, for node12, node16 and node20 actions into job containers without that major version of node")
+	rootCmd.Flags().StringArrayVar(&input.actionReplacements, "replace-action", []string{}, "replace an action with another remote action or an action in the working directory, a trailing * matches any action (e.g. --replace-action actions/checkout@v4=./.github/stubs/checkout or --replace-action mycorp/*=./actions/*)")
+	rootCmd.Flags().StringVar(&input.actionVerifier, "action-verifier", "", "command which verifies each remote action and reusable workflow in the directory of its clone before it runs, with ACT_ACTION_USES, ACT_ACTION_REPOSITORY, ACT_ACTION_REF, ACT_ACTION_SHA and ACT_ACTION_PATH set, the commits which pass are cached (e.g. --action-verifier 'gitsign verify --certificate-identity-regexp=^https://github\\.com/my-org/ --certificate-oidc-issuer=https://token.actions.githubusercontent.com HEAD')")
+	rootCmd.Flags().StringVar(&input.imageVerifier, "image-verifier", "", "command which verifies the image of each docker:// step or action once it was pulled, with the image appended and ACT_IMAGE and ACT_IMAGE_DIGEST set, the digests which pass are cached (e.g. --image-verifier 'cosign verify --certificate-identity-regexp=^https://github\\.com/my-org/ --certificate-oidc-issuer=https://token.actions.githubusercontent.com')")
+	rootCmd.Flags().BoolVar(&input.enforceActionVerification, "enforce-action-verification", false, "refuse to run the remote actions, reusable workflows and images the verifiers fail for, instead of warning about them")
+	rootCmd.Flags().StringArrayVar(&input.retries, "retry", []string{}, "run the steps whose name, id or uses: matches the pattern again when they fail, up to the given number of attempts (e.g. --retry 'integration*=3'), x-act-retry: of a step takes precedence")
+	rootCmd.Flags().DurationVar(&input.retryBackoff, "retry-backoff", 5*time.Second, "how long to wait before the second attempt of a step retried with --retry or x-act-retry:, doubled for each further attempt")
+	rootCmd.Flags().StringArrayVar(&input.skipSteps, "skip-step", []string{}, "skip the steps whose name, id or uses: matches the pattern, * matches any characters (e.g. --skip-step '*upload-artifact*' --skip-step 'codecov/*')")
+	rootCmd.Flags().BoolVar(&input.githubAPIMock, "github-api-mock", false, "serve a local stub of the GitHub REST API and point GITHUB_API_URL at it, so actions calling the API with GITHUB_TOKEN don't change anything on GitHub. GraphQL isn't implemented, the queries are answered with the graphql.post.json fixture")
+	rootCmd.Flags().StringVar(&input.githubAPIFixtures, "github-api-fixtures", "", "directory with JSON responses of the GitHub API stub, e.g. repos/owner/repo/releases/latest.json or repos/owner/repo/issues.post.json, implies --github-api-mock")
+	rootCmd.Flags().BoolVar(&input.oidc, "oidc", false, "serve ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN to the jobs, so actions can request OIDC tokens signed by act")
+	rootCmd.Flags().StringVar(&input.oidcIssuer, "oidc-issuer", "", "issuer of the OIDC tokens, its /.well-known/openid-configuration and /.well-known/jwks must be served by act, defaults to the URL of the token endpoint, implies --oidc")
+	rootCmd.Flags().StringVar(&input.oidcKey, "oidc-key", "", "PEM file with the RSA key signing the OIDC tokens, so an identity provider can trust it across runs, a key is generated if omitted, implies --oidc")
+	rootCmd.Flags().BoolVar(&input.timings, "timings", false, "print a table of the durations of the plan, jobs, image pulls, container creation and steps at the end of the run")
+	rootCmd.Flags().StringVar(&input.timingsSort, "timings-sort", "duration", "order of the --timings table: duration (longest first), start or name")
+	rootCmd.Flags().StringVar(&input.timingsJSON, "timings-json", "", "write the durations of the run as JSON to a file")
+	rootCmd.Flags().StringVar(&input.timingsFlamegraph, "timings-flamegraph", "", "write the durations of the run to a file in the folded stack format of flamegraph.pl and speedscope")
+	rootCmd.Flags().StringVar(&input.sbom, "sbom", "", "write an SBOM of the images and the remote actions with their commits the run used to a file")
+	rootCmd.Flags().StringVar(&input.sbomFormat, "sbom-format", "cyclonedx", "format of the --sbom file: cyclonedx or spdx (JSON)")
+	rootCmd.Flags().BoolVar(&input.noSummary, "no-summary", false, "don't print the table of the results of the jobs at the end of the run")
+	rootCmd.Flags().IntVar(&input.maxWorkflows, "max-workflows", 0, "number of the workflows matching the event which run at the same time, 0 for all of them")
+	rootCmd.Flags().IntVar(&input.maxJobs, "max-jobs", 0, "number of the jobs which run at the same time across all workflows, 0 for the number of CPUs of the container engine")
+	rootCmd.Flags().StringVar(&input.microVMKernel, "microvm-kernel", "", "uncompressed Linux kernel (vmlinux) the Firecracker microVMs of the jobs of microvm:// platforms boot (e.g. -P ubuntu-latest=microvm:///path/to/rootfs.ext4)")
+	rootCmd.Flags().IntVar(&input.microVMVCPUs, "microvm-vcpus", 2, "number of the vCPUs of a microVM")
+	rootCmd.Flags().IntVar(&input.microVMMemory, "microvm-memory", 2048, "memory of a microVM in MiB")
+	rootCmd.Flags().StringVar(&input.microVMTap, "microvm-tap", "", "tap device of the network interface of the microVMs, they have no network without it")
+	rootCmd.Flags().StringVar(&input.gpus, "gpus", "", "GPU devices of the job containers like docker run --gpus, e.g. all or '\"device=0,1\"', unless their options set --gpus")
+	rootCmd.Flags().StringVar(&input.gpuRuntime, "gpu-runtime", "", "container runtime of the job containers with GPUs, e.g. nvidia for hosts whose docker has no GPU device requests")
+	rootCmd.Flags().IntVar(&input.prewarmContainers, "prewarm-containers", 0, "number of job containers to create ahead for the legs of a matrix waiting for max-parallel, so they start in a running container")
+	rootCmd.Flags().BoolVar(&input.jobCache, "job-cache", false, "skip the jobs whose definition, inputs and workspace didn't change since their last successful run, the remote actions, the image digests, the host env and the ignored files aren't compared")
+	rootCmd.Flags().BoolVar(&input.prefetch, "prefetch", true, "fetch the remote actions and pull the images of all the jobs in parallel before the jobs start, --prefetch=false fetches them when a job uses them")
+	rootCmd.Flags().StringVar(&input.exportArtifacts, "export-artifacts", "", "directory the artifacts uploaded by the jobs are exported to after each job, in <run id>/<job>, starts the artifact server if --artifact-server-path isn't set (--export-artifacts alone exports to act-artifacts)")
+	rootCmd.Flags().Lookup("export-artifacts").NoOptDefVal = "act-artifacts"
+	rootCmd.Flags().StringArrayVar(&input.exportPaths, "export-path", []string{}, "path in the workspace exported with the artifacts after the job (e.g. --export-path dist), exports to act-artifacts unless --export-artifacts is set")
+	rootCmd.Flags().BoolVar(&input.resourceUsage, "resource-usage", false, "print a table of the peak memory and CPU time of the steps and jobs in the job containers at the end of the run")
+	rootCmd.Flags().StringVar(&input.reportToGitHub, "report-to-github", "", "report the jobs to GitHub on the commit of the run with the GITHUB_TOKEN secret, as commit statuses or as check runs with the annotations of the steps (--report-to-github=checks, requires the token of a GitHub App)")
+	rootCmd.Flags().Lookup("report-to-github").NoOptDefVal = runner.GitHubReportStatuses
+	rootCmd.Flags().IntVar(&input.failureRecap, "failure-recap", 0, "print the last lines of the output of each failed step again after the run, so their errors don't have to be searched in the output of the parallel jobs (e.g. --failure-recap 50)")
+	rootCmd.Flags().BoolVar(&input.isolateSteps, "isolate-steps", false, "run each step in a fresh job container which only shares the workspace and the volumes with the previous steps, to find the steps depending on the tools or files another step left in the container")
+	rootCmd.Flags().BoolVar(&input.oomWatch, "oom-watch", false, "warn about steps which use more than 90% of the memory limit of the job container, e.g. set with --container-options --memory=2g")
+	rootCmd.Flags().StringArrayVar(&input.oidcClaims, "oidc-claim", []string{}, "claim of the OIDC tokens overriding the default, implies --oidc (e.g. --oidc-claim sub=repo:octo/hello:environment:prod)")
+	rootCmd.Flags().Int64Var(&input.seed, "seed", 0, "seed the generated run id, temp directory names and tokens, so the output of a run is reproducible for snapshot tests")
+	rootCmd.Flags().StringVar(&input.serviceLogsDir, "service-logs-dir", "", "write the output of every service container to a log file in this directory")
 	rootCmd.PersistentFlags().StringVarP(&input.actor, "actor", "a", "nektos/act", "user that triggered the event")
-	rootCmd.PersistentFlags().StringVarP(&input.workflowsPath, "workflows", "W", "./.github/workflows/", "path to workflow file(s)")
+	rootCmd.PersistentFlags().StringVar(&input.repository, "repository", "", "repository of the github context instead of the one of the git remote (e.g. --repository octo/hello)")
+	rootCmd.PersistentFlags().StringVar(&input.sha, "sha", "", "commit sha of the github context instead of HEAD")
+	rootCmd.PersistentFlags().StringVar(&input.ref, "ref", "", "ref of the github context instead of the one of the event, a branch name is a ref in refs/heads (e.g. --ref main or --ref refs/tags/v1.0.0)")
+	rootCmd.PersistentFlags().StringArrayVarP(&input.workflowsPaths, "workflows", "W", []string{"./.github/workflows/"}, "path to workflow file(s) or directories, which are scanned recursively unless --no-recurse is set, can be repeated")
+	rootCmd.Flags().StringVar(&input.changedFrom, "changed-from", "", "only run the workflows whose paths and paths-ignore filters match the files changed since the merge base of a ref and HEAD, or uncommitted (e.g. --changed-from origin/main)")
+	rootCmd.Flags().StringVar(&input.repo, "repo", "", "run the workflows of a remote repository, which is cloned shallowly into a temp directory, with an optional subdirectory and ref (e.g. --repo nektos/act@master or --repo owner/name/path@feature), secret and env files are still read from --directory")
+	rootCmd.PersistentFlags().StringArrayVar(&input.workflowNames, "workflow-name", []string{}, "only use the workflows with this name: (e.g. --workflow-name CI), can be repeated")
+	rootCmd.PersistentFlags().StringArrayVarP(&input.requiredWorkflows, "required-workflows", "", []string{}, "path to organization required workflow file(s) to run alongside the repository workflows (e.g. --required-workflows ../org/.github/workflows/checks.yml)")
 	rootCmd.PersistentFlags().BoolVarP(&input.noWorkflowRecurse, "no-recurse", "", false, "Flag to disable running workflows from subdirectories of specified path in '--workflows'/'-W' flag")
+	rootCmd.PersistentFlags().BoolVar(&input.strict, "strict", false, "fail on unknown keys in the workflows, e.g. misspelled ones, instead of warning about them")
 	rootCmd.PersistentFlags().StringVarP(&input.workdir, "directory", "C", ".", "working directory")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
-	rootCmd.PersistentFlags().BoolVar(&input.jsonLogger, "json", false, "Output logs in json format")
+	rootCmd.PersistentFlags().BoolVar(&input.jsonLogger, "json", false, "Output logs in json format, with --list the workflows and jobs are listed as json")
 	rootCmd.PersistentFlags().BoolVarP(&input.noOutput, "quiet", "q", false, "disable logging of output from steps")
-	rootCmd.PersistentFlags().BoolVarP(&input.dryrun, "dryrun", "n", false, "dryrun mode")
+	rootCmd.PersistentFlags().BoolVar(&input.onlyFailures, "only-failures", false, "print only the failing steps with their whole output, and warnings and errors, e.g. for git hooks")
+	rootCmd.PersistentFlags().BoolVarP(&input.dryrun, "dryrun", "n", false, "dryrun mode, prints the jobs and steps which would run with their if conditions, matrices, images and env evaluated instead of running them")
+	rootCmd.PersistentFlags().StringVar(&input.planFormat, "plan-format", "text", "format of the plan printed by --dryrun, text or json")
+	rootCmd.PersistentFlags().String("profile", "", "profile of the project config file .act.yaml whose flags to use, defaults to the default profile of the file")
 	rootCmd.PersistentFlags().StringVarP(&input.secretfile, "secret-file", "", ".secrets", "file with list of secrets to read from (e.g. --secret-file .secrets)")
+	rootCmd.PersistentFlags().StringVarP(&input.environment, "environment", "", "", "deployment environment whose sections of the env, secret and var files override their top-level values (e.g. --environment production)")
+	rootCmd.PersistentFlags().StringVarP(&input.varfile, "var-file", "", ".vars", "file with list of configuration variables of the vars context to read from (e.g. --var-file .vars)")
+	rootCmd.PersistentFlags().StringArrayVar(&input.maskPatterns, "mask-regex", []string{}, "regular expression of values masked in the output like secrets, e.g. account ids or internal host names (e.g. --mask-regex '[a-z0-9-]+\\.corp\\.example\\.com')")
 	rootCmd.PersistentFlags().BoolVarP(&input.insecureSecrets, "insecure-secrets", "", false, "NOT RECOMMENDED! Doesn't hide secrets while printing logs.")
 	rootCmd.PersistentFlags().StringVarP(&input.envfile, "env-file", "", ".env", "environment file to read and use as env in the containers")
 	rootCmd.PersistentFlags().StringVarP(&input.inputfile, "input-file", "", ".input", "input file to read and use as action input")
 	rootCmd.PersistentFlags().StringVarP(&input.containerArchitecture, "container-architecture", "", "", "Architecture which should be used to run containers, e.g.: linux/amd64. If not specified, will use host default architecture. Requires Docker server API Version 1.41+. Ignored on earlier Docker server platforms.")
 	rootCmd.PersistentFlags().StringVarP(&input.containerDaemonSocket, "container-daemon-socket", "", "", "URI to Docker Engine socket (e.g.: unix://~/.docker/run/docker.sock or - to disable bind mounting the socket)")
 	rootCmd.PersistentFlags().StringVarP(&input.containerOptions, "container-options", "", "", "Custom docker container options for the job container without an options property in the job definition")
-	rootCmd.PersistentFlags().StringVarP(&input.githubInstance, "github-instance", "", "github.com", "GitHub instance to use. Don't use this if you are not using GitHub Enterprise Server.")
+	rootCmd.PersistentFlags().BoolVar(&input.hostPlatforms, "host-platforms", false, "run the jobs of the runners of the OS of the host natively on the host, e.g. macos-* on macOS and windows-* on Windows, like with -P macos-latest=-self-hosted")
+	rootCmd.PersistentFlags().StringVar(&input.compat, "compat", "", "run the workflows like the runners of Gitea or Forgejo Actions: read .gitea/workflows (or .forgejo/workflows), clone actions from their default actions URL, accept their runner labels in -P and the gitea context (gitea or forgejo)")
+	rootCmd.PersistentFlags().StringVar(&input.defaultActionsURL, "default-actions-url", "", "server the actions without a URL in uses: are cloned from, like DEFAULT_ACTIONS_URL of Gitea (e.g. --default-actions-url https://gitea.com), defaults to the one of --compat")
+	rootCmd.PersistentFlags().StringVarP(&input.githubInstance, "github-instance", "", "github.com", "GitHub instance to use, a host name or a URL (e.g. ghe.example.com or http://ghe.example.com:8080). Don't use this if you are not using GitHub Enterprise Server.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPath, "artifact-server-path", "", "", "Defines the path where the artifact server stores uploads and retrieves downloads from. If not specified the artifact server will not start.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerAddr, "artifact-server-addr", "", common.GetOutboundIP().String(), "Defines the address to which the artifact server binds.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPort, "artifact-server-port", "", "34567", "Defines the port where the artifact server listens.")
@@ -92,10 +208,23 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.PersistentFlags().StringVarP(&input.cacheServerPath, "cache-server-path", "", filepath.Join(CacheHomeDir, "actcache"), "Defines the path where the cache server stores caches.")
 	rootCmd.PersistentFlags().StringVarP(&input.cacheServerAddr, "cache-server-addr", "", common.GetOutboundIP().String(), "Defines the address to which the cache server binds.")
 	rootCmd.PersistentFlags().Uint16VarP(&input.cacheServerPort, "cache-server-port", "", 0, "Defines the port where the artifact server listens. 0 means a randomly available port.")
+	rootCmd.PersistentFlags().StringVarP(&input.cacheServerStorage, "cache-server-storage", "", "", "Defines where the cache server also stores caches to share them between machines: a shared path like an NFS mount, s3://<bucket>/<prefix>, gs://<bucket>/<prefix> or azblob://<account>/<container>/<prefix>.")
+	rootCmd.AddCommand(newPullCommand(ctx, input))
+	rootCmd.AddCommand(newAuditCommand(ctx, input))
+	rootCmd.AddCommand(newExecCommand(ctx, input, rootCmd.Flags()))
+	rootCmd.AddCommand(newExportCommand(ctx, input, rootCmd.Flags()))
+	rootCmd.AddCommand(newListenCommand(ctx, input, rootCmd.Flags()))
+	rootCmd.AddCommand(newRunnerCommand(ctx, input, rootCmd.Flags()))
+	rootCmd.AddCommand(newAttachCommand(ctx, input))
+	rootCmd.AddCommand(newPruneCommand(ctx, input))
+	rootCmd.AddCommand(newMicroVMAgentCommand(ctx))
+	rootCmd.AddCommand(newGraphCommand(input))
+	rootCmd.AddCommand(newValidateCommand(input))
+	rootCmd.AddCommand(newImportCommand(input))
 	rootCmd.SetArgs(args())
 
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(ctx, err))
 	}
 }
 
@@ -153,6 +282,13 @@ func args() []string {
 		args = append(args, readArgsFile(f, true)...)
 	}
 
+	// the flags of the profile override the ones of .actrc, the flags passed override both
+	profile, err := profileArgs(append(args[:len(args):len(args)], os.Args[1:]...))
+	if err != nil {
+		log.Fatal(err)
+	}
+	args = append(args, profile...)
+
 	args = append(args, os.Args[1:]...)
 	return args
 }
@@ -275,6 +411,11 @@ func setup(inputs *Input) func(*cobra.Command, []string) {
 		verbose, _ := cmd.Flags().GetBool("verbose")
 		if verbose {
 			log.SetLevel(log.DebugLevel)
+		} else if inputs.onlyFailures {
+			log.SetLevel(log.WarnLevel)
+		}
+		if flag := cmd.Flag("workflows"); inputs.compat != "" && (flag == nil || !flag.Changed) {
+			inputs.compatWorkflows = true
 		}
 		loadVersionNotices(cmd.Version)
 	}
@@ -301,26 +442,167 @@ func parseEnvs(env []string, envs map[string]string) bool {
 	return false
 }
 
-func readYamlFile(file string) (map[string]string, error) {
-	content, err := os.ReadFile(file)
+// parseYamlEnvs parses a YAML or JSON file, mappings are the sections of environments
+func parseYamlEnvs(content []byte) (map[string]map[string]string, error) {
+	nodes := map[string]yaml.Node{}
+	if err := yaml.Unmarshal(content, &nodes); err != nil {
+		return nil, err
+	}
+	sections := map[string]map[string]string{"": {}}
+	for key, node := range nodes {
+		if node.Kind == yaml.MappingNode {
+			section := map[string]string{}
+			if err := node.Decode(&section); err != nil {
+				return nil, fmt.Errorf("environment %s: %w", key, err)
+			}
+			sections[key] = section
+			continue
+		}
+		var value string
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		sections[""][key] = value
+	}
+	return sections, nil
+}
+
+// parseDotenvs parses a dotenv file, which has no sections of environments, all its values are
+// top-level ones
+func parseDotenvs(content []byte) (map[string]map[string]string, error) {
+	env, err := godotenv.Unmarshal(string(content))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]map[string]string{"": env}, nil
+}
+
+// readEnvFile reads a dotenv, YAML or JSON file, the format is detected by the extension or
+// the content. The values in the section of the environment override the top-level values.
+func readEnvFile(path string, environment string) (map[string]string, error) {
+	sections, err := readEnvSections(path)
+	if err != nil {
+		return nil, err
+	}
+	env := sections[""]
+	if environment != "" {
+		for k, v := range sections[environment] {
+			env[k] = v
+		}
+	}
+	return env, nil
+}
+
+// readEnvSections reads the top-level values of a dotenv, YAML or JSON file, in the section "",
+// and the sections of the environments. The file may be encrypted with sops or age.
+func readEnvSections(path string) (map[string]map[string]string, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	ret := map[string]string{}
-	if err = yaml.Unmarshal(content, &ret); err != nil {
+	if content, err = decryptEnvFile(path, content); err != nil {
 		return nil, err
 	}
-	return ret, nil
+	switch ext := filepath.Ext(path); {
+	case ext == ".yml" || ext == ".yaml" || ext == ".json" || bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")):
+		return parseYamlEnvs(content)
+	default:
+		return parseDotenvs(content)
+	}
 }
 
-func readEnvs(path string, envs map[string]string) bool {
-	if _, err := os.Stat(path); err == nil {
-		var env map[string]string
-		if ext := filepath.Ext(path); ext == ".yml" || ext == ".yaml" {
-			env, err = readYamlFile(path)
-		} else {
-			env, err = godotenv.Read(path)
+// newPullRequest returns the pull request of the --pr-* flags, nil if none is set
+func newPullRequest(cmd *cobra.Command, input *Input) (*runner.PullRequest, error) {
+	set := false
+	for _, name := range []string{"pr-number", "pr-base", "pr-head", "pr-draft", "pr-label"} {
+		set = set || cmd.Flags().Changed(name)
+	}
+	if !set {
+		return nil, nil
+	}
+	if input.eventPath != "" {
+		return nil, fmt.Errorf("the --pr-* flags build the pull_request payload, they can't be used with --eventpath")
+	}
+	if input.prNumber < 0 {
+		return nil, fmt.Errorf("invalid --pr-number %d", input.prNumber)
+	}
+	return &runner.PullRequest{
+		Number: input.prNumber,
+		Base:   input.prBase,
+		Head:   input.prHead,
+		Draft:  input.prDraft,
+		Labels: input.prLabels,
+	}, nil
+}
+
+// projectName returns the repository of the working directory the project volumes are namespaced
+// by, e.g. nektos/act, or the working directory if it has no GitHub remote
+func projectName(ctx context.Context, input *Input, envs map[string]string) string {
+	if input.repository != "" {
+		return input.repository
+	}
+	if repo := envs["GITHUB_REPOSITORY"]; repo != "" {
+		return repo
+	}
+	instance := input.githubInstance
+	if u, err := url.Parse(instance); err == nil && u.Host != "" {
+		instance = u.Host
+	}
+	if repo, err := git.FindGithubRepo(ctx, input.Workdir(), instance, input.remoteName); err == nil {
+		return repo
+	}
+	return input.Workdir()
+}
+
+// newEventFields returns the fields of --event-field by dotted path, nil if act doesn't build the
+// template of the event
+func newEventFields(input *Input) (map[string]string, error) {
+	if !input.eventTemplate && len(input.eventFields) == 0 {
+		return nil, nil
+	}
+	if input.eventPath != "" {
+		return nil, fmt.Errorf("--event-template and --event-field build the payload, they can't be used with --eventpath")
+	}
+	fields := map[string]string{}
+	for _, field := range input.eventFields {
+		path, value, ok := strings.Cut(field, "=")
+		if !ok || path == "" || strings.Contains(path, "..") || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") {
+			return nil, fmt.Errorf("invalid --event-field %q, expected path.to.field=value", field)
+		}
+		fields[path] = value
+	}
+	return fields, nil
+}
+
+// readEnvironments returns the deployment environments of the sections of the secret and var
+// files, which the jobs with environment: use instead of the top-level values
+func readEnvironments(secretfile, varfile string) map[string]*runner.Environment {
+	environments := map[string]*runner.Environment{}
+	environment := func(name string) *runner.Environment {
+		if environments[name] == nil {
+			environments[name] = &runner.Environment{}
+		}
+		return environments[name]
+	}
+	// the files which can't be read are reported by readEnvs
+	secretSections, _ := readEnvSections(secretfile)
+	for name, values := range secretSections {
+		if name != "" {
+			environment(name).Secrets = values
 		}
+	}
+	varSections, _ := readEnvSections(varfile)
+	for name, values := range varSections {
+		if name != "" {
+			environment(name).Vars = values
+		}
+	}
+	return environments
+}
+
+func readEnvs(path string, envs map[string]string, environment string) bool {
+	if _, err := os.Stat(path); err == nil {
+		env, err := readEnvFile(path, environment)
 		if err != nil {
 			log.Fatalf("Error loading from %s: %v", path, err)
 		}
@@ -332,6 +614,36 @@ func readEnvs(path string, envs map[string]string) bool {
 	return false
 }
 
+// parseNeedsOutputs parses the job.output=value entries of --needs-output into the outputs by job
+func parseNeedsOutputs(entries []string) (map[string]map[string]string, error) {
+	outputs := map[string]map[string]string{}
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		job, output, dotted := strings.Cut(name, ".")
+		if !ok || !dotted || job == "" || output == "" {
+			return nil, fmt.Errorf("invalid needs output '%s', expected job.output=value", entry)
+		}
+		if outputs[job] == nil {
+			outputs[job] = map[string]string{}
+		}
+		outputs[job][output] = value
+	}
+	return outputs, nil
+}
+
+// parseMaskPatterns compiles the regular expressions of --mask-regex
+func parseMaskPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --mask-regex '%s': %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
 func parseMatrix(matrix []string) map[string]map[string]bool {
 	// each matrix entry should be of the form - string:string
 	r := regexp.MustCompile(":")
@@ -362,6 +674,49 @@ func isDockerHostURI(daemonPath string) bool {
 	return false
 }
 
+// newWorkflowPlanner loads the workflows of -W, keeps the ones selected by --workflow-name and
+// adds the required workflows
+func newWorkflowPlanner(input *Input) (model.WorkflowPlanner, error) {
+	paths := input.WorkflowsPaths()
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no workflow path, set -W")
+	}
+	planner, err := model.NewStrictWorkflowPlanner(paths[0], input.noWorkflowRecurse, input.StrictMode())
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths[1:] {
+		log.Debugf("Loading workflows from %s", path)
+		if err := planner.AddWorkflows(path, input.noWorkflowRecurse); err != nil {
+			return nil, err
+		}
+	}
+	if len(input.workflowNames) > 0 {
+		if err := planner.SelectWorkflows(input.workflowNames); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range input.requiredWorkflows {
+		log.Debugf("Loading required workflows from %s", input.resolve(path))
+		if err := planner.AddWorkflows(input.resolve(path), input.noWorkflowRecurse); err != nil {
+			return nil, fmt.Errorf("unable to load required workflows: %w", err)
+		}
+	}
+	return planner, nil
+}
+
+// filterChangedPaths keeps the workflows of the plan whose path filters match the changed files
+// for the event, like GitHub triggers them for the change
+func filterChangedPaths(plan *model.Plan, eventName string, changed []string) *model.Plan {
+	return plan.FilterWorkflows(func(w *model.Workflow) bool {
+		if w.TriggeredByPaths(eventName, changed) {
+			return true
+		}
+		log.Infof("Skipping workflow '%s', its path filters match none of the changed files", w.Name)
+		return false
+	})
+}
+
 //nolint:gocyclo
 func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
@@ -373,6 +728,22 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			return bugReport(ctx, cmd.Version)
 		}
 
+		if input.compat != "" {
+			if _, err := runner.CompatWorkflowDirs(input.compat); err != nil {
+				return err
+			}
+		}
+
+		cleanup, err := readEventSource(ctx, input)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		if len(args) > 1 || (len(args) == 1 && input.eventPayloadDir != "") {
+			return runEvents(ctx, cmd, input, args)
+		}
+
 		// Prefer DOCKER_HOST, don't override it
 		socketPath, hasDockerHost := os.LookupEnv("DOCKER_HOST")
 		if !hasDockerHost {
@@ -407,29 +778,138 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		log.Debugf("Loading environment from %s", input.Envfile())
 		envs := make(map[string]string)
 		_ = parseEnvs(input.envs, envs)
-		_ = readEnvs(input.Envfile(), envs)
+		_ = readEnvs(input.Envfile(), envs, input.environment)
 
 		log.Debugf("Loading action inputs from %s", input.Inputfile())
 		inputs := make(map[string]string)
 		_ = parseEnvs(input.inputs, inputs)
-		_ = readEnvs(input.Inputfile(), inputs)
+		_ = readEnvs(input.Inputfile(), inputs, input.environment)
 
 		log.Debugf("Loading secrets from %s", input.Secretfile())
 		secrets := newSecrets(input.secrets)
-		_ = readEnvs(input.Secretfile(), secrets)
+		_ = readEnvs(input.Secretfile(), secrets, input.environment)
+
+		if input.repo != "" {
+			cleanup, err := cloneRemoteRepo(ctx, input, secrets["GITHUB_TOKEN"])
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+		}
+
+		log.Debugf("Loading variables from %s", input.Varfile())
+		vars := make(map[string]string)
+		_ = parseEnvs(input.vars, vars)
+		_ = readEnvs(input.Varfile(), vars, input.environment)
+		environments := readEnvironments(input.Secretfile(), input.Varfile())
 
 		matrixes := parseMatrix(input.matrix)
 		log.Debugf("Evaluated matrix inclusions: %v", matrixes)
 
-		planner, err := model.NewWorkflowPlanner(input.WorkflowsPath(), input.noWorkflowRecurse)
+		toolCache := input.toolCache
+		if strings.ContainsAny(toolCache, `/\`) || strings.HasPrefix(toolCache, ".") {
+			// a host directory instead of a volume name
+			toolCache = input.resolve(toolCache)
+		}
+
+		projectVolumes := ""
+		if input.projectVolumes {
+			projectVolumes = projectName(ctx, input, envs)
+			log.Debugf("Using the volumes of the project %s", projectVolumes)
+		}
+
+		jobSandboxProfiles := make(map[string]string)
+		_ = parseEnvs(input.jobSandboxProfiles, jobSandboxProfiles)
+
+		actionReplacements := make(map[string]string)
+		_ = parseEnvs(input.actionReplacements, actionReplacements)
+
+		var timings *runner.Timings
+		if input.timings || input.timingsJSON != "" || input.timingsFlamegraph != "" {
+			timings = &runner.Timings{}
+		}
+		if _, err := timings.Sorted(input.timingsSort); err != nil {
+			return err
+		}
+		var sbom *runner.SBOM
+		if input.sbom != "" {
+			if input.sbomFormat != "cyclonedx" && input.sbomFormat != "spdx" {
+				return fmt.Errorf("unknown --sbom-format '%s', expected cyclonedx or spdx", input.sbomFormat)
+			}
+			sbom = &runner.SBOM{Version: cmd.Version}
+		}
+		planStart := time.Now()
+
+		planner, err := newWorkflowPlanner(input)
 		if err != nil {
 			return err
 		}
 
+		var changed []string
+		if input.changedFrom != "" {
+			changed, err = git.ChangedFiles(ctx, input.Workdir(), input.changedFrom)
+			if err != nil {
+				return fmt.Errorf("unable to find the files changed since %s: %w", input.changedFrom, err)
+			}
+			log.Debugf("Files changed since %s: %v", input.changedFrom, changed)
+		}
+
 		jobID, err := cmd.Flags().GetString("job")
 		if err != nil {
 			return err
 		}
+		// -j may be the name of a job, or the rendered name of legs of its matrix
+		var jobLeg *runner.JobLeg
+		if id, rendered := planner.FindJob(jobID); jobID != "" && id != "" {
+			if rendered {
+				jobLeg = &runner.JobLeg{JobID: id, Name: jobID}
+			}
+			jobID = id
+		}
+
+		needsOutputs, err := parseNeedsOutputs(input.needsOutputs)
+		if err != nil {
+			return err
+		}
+		needsMode := runner.NeedsMode(input.needsMode)
+		if len(needsOutputs) > 0 && !cmd.Flags().Changed("needs-mode") {
+			needsMode = runner.NeedsModeAssume
+		}
+		if jobID == "" && (len(needsOutputs) > 0 || cmd.Flags().Changed("needs-mode")) {
+			return fmt.Errorf("--needs-mode and --needs-output only apply to the job selected with -j")
+		}
+		if input.submodules != "" && input.submodules != "true" && input.submodules != "recursive" && input.submodules != "false" {
+			return fmt.Errorf("invalid --submodules '%s', expected true, recursive or false", input.submodules)
+		}
+		if input.bindWorkdir && (input.copyWorkspace || len(input.copyBack) > 0) {
+			return fmt.Errorf("--copy-workspace and --copy-back can't be used with --bind")
+		}
+		if r := input.reportToGitHub; r != "" && r != runner.GitHubReportStatuses && r != runner.GitHubReportChecks {
+			return fmt.Errorf("invalid --report-to-github '%s', expected %s or %s", r, runner.GitHubReportStatuses, runner.GitHubReportChecks)
+		}
+		if len(input.exportPaths) > 0 && input.exportArtifacts == "" {
+			input.exportArtifacts = "act-artifacts"
+		}
+		if input.exportArtifacts != "" && input.artifactServerPath == "" {
+			// the artifacts of the jobs are exported from the artifact server
+			input.artifactServerPath = filepath.Join(CacheHomeDir, "artifacts")
+		}
+		retries := make([]runner.StepRetry, 0, len(input.retries))
+		for _, s := range input.retries {
+			retry, err := runner.ParseStepRetry(s)
+			if err != nil {
+				return err
+			}
+			retries = append(retries, retry)
+		}
+		mounts := make([]runner.Mount, 0, len(input.mounts))
+		for _, s := range input.mounts {
+			m, err := runner.ParseMount(s)
+			if err != nil {
+				return err
+			}
+			mounts = append(mounts, m)
+		}
 
 		// check if we should just list the workflows
 		list, err := cmd.Flags().GetBool("list")
@@ -476,7 +956,23 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		if filterPlan == nil && plannerErr != nil {
 			return plannerErr
 		}
+		if input.changedFrom != "" {
+			filterPlan = filterChangedPaths(filterPlan, filterEventName, changed)
+		}
+		if jobID != "" {
+			filterPlan, err = runner.JobPlan(filterPlan, jobID, needsMode, needsOutputs)
+			if err != nil {
+				return err
+			}
+		}
 
+		if list && input.jsonLogger {
+			err = printListJSON(os.Stdout, filterPlan)
+			if err != nil {
+				return err
+			}
+			return plannerErr
+		}
 		if list {
 			err = printList(filterPlan)
 			if err != nil {
@@ -496,12 +992,23 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		// plan with triggered jobs
 		var plan *model.Plan
 
+		var runState *runner.RunState
+		if input.rerunFailed {
+			runState, err = runner.ReadRunState(input.RunStateFile())
+			if err != nil {
+				return fmt.Errorf("unable to read the results of the last run: %w", err)
+			}
+		}
+
 		// Determine the event name to be triggered
 		var eventName string
 
 		if len(args) > 0 {
 			log.Debugf("Using first passed in arguments event: %s", args[0])
 			eventName = args[0]
+		} else if runState != nil && runState.EventName != "" {
+			log.Debugf("Using the event of the last run: %s", runState.EventName)
+			eventName = runState.EventName
 		} else if len(events) == 1 && len(events[0]) > 0 {
 			log.Debugf("Using the only detected workflow event: %s", events[0])
 			eventName = events[0]
@@ -526,6 +1033,52 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		if plan == nil && plannerErr != nil {
 			return plannerErr
 		}
+		if input.changedFrom != "" {
+			plan = filterChangedPaths(plan, eventName, changed)
+		}
+		if jobID != "" {
+			plan, err = runner.JobPlan(plan, jobID, needsMode, needsOutputs)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the state of the whole plan is saved, the jobs which aren't run again keep their results
+		fullPlan := plan
+		if runState != nil {
+			plan = runState.RerunPlan(plan)
+			if len(plan.Stages) == 0 {
+				log.Infof("All jobs succeeded in the last run, nothing to re-run")
+				return plannerErr
+			}
+		}
+		timings.Record("", runner.TimingPlan, eventName, planStart)
+
+		if len(input.secretProviders) > 0 {
+			providers := make([]secretprovider.Provider, 0, len(input.secretProviders))
+			for _, spec := range input.secretProviders {
+				provider, err := secretprovider.NewProvider(spec)
+				if err != nil {
+					return err
+				}
+				providers = append(providers, provider)
+			}
+			if err := secretprovider.Resolve(ctx, providers, plan.Secrets(), secrets); err != nil {
+				return err
+			}
+		}
+
+		// prompt for missing values instead of silently running with empty strings
+		if !input.noInput && term.IsTerminal(int(os.Stdin.Fd())) {
+			if err := promptMissingSecrets(plan, secrets, environments); err != nil {
+				return err
+			}
+			if eventName == "workflow_dispatch" && input.eventPath == "" {
+				if err := promptMissingInputs(plan, inputs); err != nil {
+					return err
+				}
+			}
+		}
 
 		// check to see if the main branch was defined
 		defaultbranch, err := cmd.Flags().GetString("defaultbranch")
@@ -533,6 +1086,22 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			return err
 		}
 
+		pullRequest, err := newPullRequest(cmd, input)
+		if err != nil {
+			return err
+		}
+		if input.pushRange != "" && input.eventPath != "" {
+			return fmt.Errorf("--push-range builds the push payload, it can't be used with --eventpath")
+		}
+		eventFields, err := newEventFields(input)
+		if err != nil {
+			return err
+		}
+		maskPatterns, err := parseMaskPatterns(input.maskPatterns)
+		if err != nil {
+			return err
+		}
+
 		// Check if platforms flag is set, if not, run default image survey
 		if len(input.platforms) == 0 {
 			cfgFound := false
@@ -564,9 +1133,19 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			log.Warnf(deprecationWarning, "container-cap-drop", fmt.Sprintf("--cap-drop=%s", input.containerCapDrop))
 		}
 
+		microVM := runner.MicroVMConfig{
+			Kernel:    input.resolve(input.microVMKernel),
+			VCPUs:     input.microVMVCPUs,
+			MemoryMiB: input.microVMMemory,
+			TapDevice: input.microVMTap,
+		}
+
 		// run the plan
 		config := &runner.Config{
 			Actor:                              input.actor,
+			Repository:                         input.repository,
+			Sha:                                input.sha,
+			Ref:                                input.ref,
 			EventName:                          eventName,
 			EventPath:                          input.EventPath(),
 			DefaultBranch:                      defaultbranch,
@@ -575,13 +1154,27 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			ReuseContainers:                    input.reuseContainers,
 			Workdir:                            input.Workdir(),
 			BindWorkdir:                        input.bindWorkdir,
+			CopyWorkspace:                      input.copyWorkspace,
+			CopyTrackedOnly:                    input.copyTrackedOnly,
+			CopyBack:                           input.copyBack,
+			Submodules:                         input.submodules,
+			LFS:                                input.lfs,
 			LogOutput:                          !input.noOutput,
+			OnlyFailures:                       input.onlyFailures,
 			JSONLogger:                         input.jsonLogger,
 			Env:                                envs,
 			Secrets:                            secrets,
+			Vars:                               vars,
+			Environments:                       environments,
+			PullRequest:                        pullRequest,
+			PushRange:                          input.pushRange,
+			EventFields:                        eventFields,
 			Inputs:                             inputs,
 			Token:                              secrets["GITHUB_TOKEN"],
 			InsecureSecrets:                    input.insecureSecrets,
+			MaskPatterns:                       maskPatterns,
+			BreakBefore:                        input.breakBefore,
+			BreakOnFailure:                     input.breakOnFailure,
 			Platforms:                          input.newPlatforms(),
 			Privileged:                         input.privileged,
 			UsernsMode:                         input.usernsMode,
@@ -590,10 +1183,17 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			ContainerOptions:                   input.containerOptions,
 			UseGitIgnore:                       input.useGitIgnore,
 			GitHubInstance:                     input.githubInstance,
+			Compat:                             input.compat,
+			DefaultActionsURL:                  input.defaultActionsURL,
 			ContainerCapAdd:                    input.containerCapAdd,
 			ContainerCapDrop:                   input.containerCapDrop,
 			AutoRemove:                         input.autoRemove,
+			KeepContainers:                     input.keepContainers,
+			IsolateSteps:                       input.isolateSteps,
+			GitHubReport:                       input.reportToGitHub,
 			ArtifactServerPath:                 input.artifactServerPath,
+			ArtifactsExportDir:                 input.resolve(input.exportArtifacts),
+			ExportPaths:                        input.exportPaths,
 			ArtifactServerAddr:                 input.artifactServerAddr,
 			ArtifactServerPort:                 input.artifactServerPort,
 			NoSkipCheckout:                     input.noSkipCheckout,
@@ -601,42 +1201,219 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			ReplaceGheActionWithGithubCom:      input.replaceGheActionWithGithubCom,
 			ReplaceGheActionTokenWithGithubCom: input.replaceGheActionTokenWithGithubCom,
 			Matrix:                             matrixes,
+			JobLeg:                             jobLeg,
+			MaxWorkflows:                       input.maxWorkflows,
+			MaxJobs:                            input.maxJobs,
+			MicroVM:                            microVM,
+			GPUs:                               input.gpus,
+			GPURuntime:                         input.gpuRuntime,
+			PrewarmContainers:                  input.prewarmContainers,
+			JobCache:                           input.jobCache,
+			Prefetch:                           input.prefetch,
+			UseBuildKit:                        !input.noBuildKit,
+			BuildCacheFrom:                     input.buildCacheFrom,
+			BuildCacheTo:                       input.buildCacheTo,
+			StepCache:                          input.stepCache,
+			Checkpoint:                         input.checkpoint || input.resume,
+			Resume:                             input.resume,
+			RunnerVersion:                      input.runnerVersion,
+			FailOnDeprecation:                  input.failOnDeprecation,
+			SetupFastPath:                      input.setupFastPath,
+			ServiceTimeout:                     input.serviceTimeout,
+			ServiceLogs:                        input.serviceLogs,
+			ServiceLogsDir:                     input.serviceLogsDir,
+			ContainerNetworkMode:               input.networkMode,
+			Seed:                               input.seed,
+			NoNetwork:                          input.noNetwork,
+			ProxyEnv:                           input.proxyEnv,
+			CACertificates:                     input.caCertificates,
+			SandboxProfile:                     input.sandboxProfile,
+			JobSandboxProfiles:                 jobSandboxProfiles,
+			ForwardSSHAgent:                    input.forwardSSHAgent,
+			ForwardGitConfig:                   input.forwardGitConfig,
+			ToolCache:                          toolCache,
+			ProjectVolumes:                     projectVolumes,
+			VolumeRetention:                    input.volumeRetention,
+			Mounts:                             mounts,
+			HostEnv:                            input.hostEnv,
+			ActionOfflineMode:                  input.actionOfflineMode,
+			ProvisionNode:                      input.provisionNode,
+			ActionReplacements:                 actionReplacements,
+			StrictMode:                         input.StrictMode(),
+			ActionVerifier:                     input.actionVerifier,
+			ImageVerifier:                      input.imageVerifier,
+			EnforceActionVerification:          input.enforceActionVerification,
+			SkipSteps:                          input.skipSteps,
+			Retries:                            retries,
+			RetryBackoff:                       input.retryBackoff,
+			Timings:                            timings,
+			SBOM:                               sbom,
+			OOMWatch:                           input.oomWatch,
+		}
+		if input.resourceUsage {
+			config.ResourceUsages = &runner.ResourceUsages{}
+		}
+		// the failing steps are printed whole with --only-failures
+		if input.failureRecap > 0 && !input.onlyFailures && !input.jsonLogger && input.execCommand == nil {
+			config.FailureRecap = &runner.FailureRecap{Lines: input.failureRecap}
+		}
+		if !input.noSummary && !input.dryrun && !input.jsonLogger && input.execCommand == nil {
+			config.Summary = &runner.Summary{}
 		}
+		var idTokenIssuer *oidc.Handler
+		if input.oidc || input.oidcIssuer != "" || input.oidcKey != "" || len(input.oidcClaims) > 0 {
+			idTokenIssuer, err = oidc.StartHandler(input.resolve(input.oidcKey), input.oidcIssuer, input.cacheServerAddr, 0, common.Logger(ctx))
+			if err != nil {
+				return err
+			}
+			config.IDTokenIssuer = idTokenIssuer
+			config.IDTokenClaims = make(map[string]string)
+			_ = parseEnvs(input.oidcClaims, config.IDTokenClaims)
+			log.Infof("OIDC tokens are issued by %s", idTokenIssuer.Issuer())
+		}
+
 		r, err := runner.New(config)
 		if err != nil {
 			return err
 		}
 
-		cancel := artifacts.Serve(ctx, input.artifactServerPath, input.artifactServerAddr, input.artifactServerPort)
+		if input.export {
+			_ = idTokenIssuer.Close()
+			export := r.NewShellExport
+			if input.exportFormat == "compose" {
+				export = r.NewComposeExport
+			}
+			files, err := export(ctx, plan)
+			if err != nil {
+				return err
+			}
+			if err := writeExport(input, files); err != nil {
+				return err
+			}
+			return plannerErr
+		}
+
+		if input.dryrun && input.execCommand == nil {
+			_ = idTokenIssuer.Close()
+			report, err := r.NewPlanReport(ctx, plan)
+			if err != nil {
+				return err
+			}
+			if err := printPlanReport(os.Stdout, report, input.planFormat); err != nil {
+				return err
+			}
+			if input.workflowRun && jobID == "" {
+				if err := runWorkflowRuns(ctx, cmd, input, fullPlan, eventName); err != nil {
+					return err
+				}
+			}
+			return plannerErr
+		}
+
+		cancel, artifactServerPort, err := artifacts.Serve(ctx, input.artifactServerPath, input.artifactServerAddr, input.artifactServerPort)
+		if err != nil {
+			return err
+		}
+		config.ArtifactServerPort = artifactServerPort
 
 		const cacheURLKey = "ACTIONS_CACHE_URL"
 		var cacheHandler *artifactcache.Handler
 		if !input.noCacheServer && envs[cacheURLKey] == "" {
+			var backend artifactcache.Backend
 			var err error
-			cacheHandler, err = artifactcache.StartHandler(input.cacheServerPath, input.cacheServerAddr, input.cacheServerPort, common.Logger(ctx))
+			if input.cacheServerStorage != "" {
+				if backend, err = artifactcache.NewBackend(input.cacheServerStorage); err != nil {
+					return err
+				}
+			}
+			cacheHandler, err = artifactcache.StartHandlerWithBackend(input.cacheServerPath, input.cacheServerAddr, input.cacheServerPort, backend, common.Logger(ctx))
 			if err != nil {
 				return err
 			}
 			envs[cacheURLKey] = cacheHandler.ExternalURL() + "/"
 		}
 
+		var githubAPIHandler *githubapi.Handler
+		if input.githubAPIMock || input.githubAPIFixtures != "" {
+			var err error
+			githubAPIHandler, err = githubapi.StartHandler(input.resolve(input.githubAPIFixtures), input.cacheServerAddr, 0, common.Logger(ctx))
+			if err != nil {
+				return err
+			}
+			envs["GITHUB_API_URL"] = githubAPIHandler.ExternalURL()
+			envs["GITHUB_GRAPHQL_URL"] = githubAPIHandler.ExternalURL() + "/graphql"
+		}
+
 		ctx = common.WithDryrun(ctx, input.dryrun)
 		if watch, err := cmd.Flags().GetBool("watch"); err != nil {
 			return err
 		} else if watch {
-			err = watchAndRun(ctx, r.NewPlanExecutor(plan))
+			replan := func() (*model.Plan, error) {
+				planner, err := newWorkflowPlanner(input)
+				if err != nil {
+					return nil, err
+				}
+				if jobID != "" {
+					return planner.PlanJob(jobID)
+				}
+				return planner.PlanEvent(eventName)
+			}
+			err = watchAndRun(ctx, input.Workdir(), eventName, replan, r.NewPlanExecutor)
 			if err != nil {
 				return err
 			}
 			return plannerErr
 		}
 
-		executor := r.NewPlanExecutor(plan).Finally(func(ctx context.Context) error {
+		executor := r.NewPlanExecutor(plan)
+		if input.execCommand != nil {
+			run := planRun(plan, jobID)
+			if run == nil {
+				return common.WithErrorClass(fmt.Errorf("job %s not found", jobID), common.ErrorClassPlan)
+			}
+			executor = r.NewExecExecutor(run, input.execCommand)
+		}
+		executor = executor.Finally(func(ctx context.Context) error {
+			if !input.dryrun && input.execCommand == nil {
+				if err := runner.NewRunState(eventName, fullPlan).Write(input.RunStateFile()); err != nil {
+					log.Warnf("Unable to save the results of the jobs for --rerun-failed: %v", err)
+				}
+			}
 			cancel()
 			_ = cacheHandler.Close()
+			_ = githubAPIHandler.Close()
+			_ = idTokenIssuer.Close()
+			if err := writeTimings(input, timings); err != nil {
+				log.Warnf("Unable to write the timings: %v", err)
+			}
+			if err := writeSBOM(input, sbom); err != nil {
+				log.Warnf("Unable to write the SBOM: %v", err)
+			}
+			if failed := config.FailureRecap.Failed(); len(failed) > 0 {
+				fmt.Println()
+				_ = config.FailureRecap.Write(os.Stdout)
+			}
+			if config.ResourceUsages != nil {
+				fmt.Println()
+				_ = config.ResourceUsages.WriteTable(os.Stdout)
+			}
+			if len(config.Summary.Jobs()) > 0 {
+				fmt.Println()
+				_ = config.Summary.WriteTable(os.Stdout)
+			}
 			return nil
 		})
 		err = executor(ctx)
+		// a cancelled job, e.g. by its timeout, fails the run too
+		if failed := config.Summary.Failed(); err == nil && failed > 0 {
+			err = common.WithErrorClass(fmt.Errorf("%d of %d jobs failed", failed, len(config.Summary.Jobs())), common.ErrorClassJob)
+		}
+		// the workflows triggered by workflow_run run after the whole plan, whether it failed or not
+		if input.workflowRun && jobID == "" && input.execCommand == nil && ctx.Err() == nil {
+			if chainErr := runWorkflowRuns(ctx, cmd, input, fullPlan, eventName); err == nil {
+				err = chainErr
+			}
+		}
 		if err != nil {
 			return err
 		}
@@ -686,49 +1463,3 @@ func defaultImageSurvey(actrc string) error {
 
 	return nil
 }
-
-func watchAndRun(ctx context.Context, fn common.Executor) error {
-	dir, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	ignoreFile := filepath.Join(dir, ".gitignore")
-	ignore := &gitignore.GitIgnore{}
-	if info, err := os.Stat(ignoreFile); err == nil && !info.IsDir() {
-		ignore, err = gitignore.CompileIgnoreFile(ignoreFile)
-		if err != nil {
-			return fmt.Errorf("compile %q: %w", ignoreFile, err)
-		}
-	}
-
-	folderWatcher := fswatch.NewFolderWatcher(
-		dir,
-		true,
-		ignore.MatchesPath,
-		2, // 2 seconds
-	)
-
-	folderWatcher.Start()
-	defer folderWatcher.Stop()
-
-	// run once before watching
-	if err := fn(ctx); err != nil {
-		return err
-	}
-
-	for folderWatcher.IsRunning() {
-		log.Debugf("Watching %s for changes", dir)
-		select {
-		case <-ctx.Done():
-			return nil
-		case changes := <-folderWatcher.ChangeDetails():
-			log.Debugf("%s", changes.String())
-			if err := fn(ctx); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
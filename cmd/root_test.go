@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvs(t *testing.T) {
+	envs := map[string]string{}
+	assert.False(t, parseEnvs(nil, envs))
+	assert.True(t, parseEnvs([]string{"A=1", "B=x=y", "C"}, envs))
+	assert.Equal(t, map[string]string{"A": "1", "B": "x=y", "C": ""}, envs)
+}
+
+func TestParseDotenvs(t *testing.T) {
+	sections, err := parseDotenvs([]byte("A=1\nB=\"multi\nline\"\n# comment\nexport C=3\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"": {"A": "1", "B": "multi\nline", "C": "3"}}, sections)
+
+	// dotenv files have no sections, a [production] line isn't a header
+	_, err = parseDotenvs([]byte("A=1\n[production]\nA=2\n"))
+	assert.Error(t, err)
+}
+
+func TestParseYamlEnvs(t *testing.T) {
+	sections, err := parseYamlEnvs([]byte("A: 1\nB: |\n  multi\n  line\nproduction:\n  A: 2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"":           {"A": "1", "B": "multi\nline\n"},
+		"production": {"A": "2"},
+	}, sections)
+
+	sections, err = parseYamlEnvs([]byte(`{"A": "1", "staging": {"A": "3"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"": {"A": "1"}, "staging": {"A": "3"}}, sections)
+
+	_, err = parseYamlEnvs([]byte("A: [1, 2]\n"))
+	assert.Error(t, err)
+}
+
+func TestReadEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	for name, tt := range map[string]struct {
+		path        string
+		environment string
+		env         map[string]string
+	}{
+		"dotenv":              {write(".secrets", "A=1\n"), "production", map[string]string{"A": "1"}},
+		"yaml":                {write("secrets.yml", "A: 1\nproduction:\n  A: 2\n"), "", map[string]string{"A": "1"}},
+		"yaml-environment":    {write("secrets.yaml", "A: 1\nB: 1\nproduction:\n  A: 2\n"), "production", map[string]string{"A": "2", "B": "1"}},
+		"json-content":        {write(".vars", `{"A": "1", "production": {"A": "2"}}`), "production", map[string]string{"A": "2"}},
+		"unknown-environment": {write("vars.json", `{"A": "1"}`), "staging", map[string]string{"A": "1"}},
+	} {
+		env, err := readEnvFile(tt.path, tt.environment)
+		require.NoError(t, err, name)
+		assert.Equal(t, tt.env, env, name)
+	}
+
+	environments := readEnvironments(write("env-secrets.yml", "A: 1\nproduction:\n  A: 2\n"), write("env-vars.yml", "staging:\n  B: 3\n"))
+	assert.Equal(t, map[string]string{"A": "2"}, environments["production"].Secrets)
+	assert.Nil(t, environments["production"].Vars)
+	assert.Equal(t, map[string]string{"B": "3"}, environments["staging"].Vars)
+}
+
+func TestParseNeedsOutputs(t *testing.T) {
+	outputs, err := parseNeedsOutputs([]string{"build.version=1.2.3", "build.sha=abc=def", "test.result=ok"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"build": {"version": "1.2.3", "sha": "abc=def"},
+		"test":  {"result": "ok"},
+	}, outputs)
+
+	for _, entry := range []string{"build=1", "build.version", ".version=1", "build.=1"} {
+		_, err := parseNeedsOutputs([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestNewEventFields(t *testing.T) {
+	fields, err := newEventFields(&Input{})
+	require.NoError(t, err)
+	assert.Nil(t, fields)
+
+	fields, err = newEventFields(&Input{eventFields: []string{"pull_request.title=a=b", "ref=refs/heads/main"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pull_request.title": "a=b", "ref": "refs/heads/main"}, fields)
+
+	for _, field := range []string{"ref", "=x", ".ref=x", "ref.=x", "a..b=x"} {
+		_, err := newEventFields(&Input{eventFields: []string{field}})
+		assert.Error(t, err, field)
+	}
+	_, err = newEventFields(&Input{eventTemplate: true, eventPath: "event.json"})
+	assert.Error(t, err)
+}
+
+func TestParseMaskPatterns(t *testing.T) {
+	patterns, err := parseMaskPatterns([]string{`\b\d{12}\b`, `[a-z]+\.corp\.internal`})
+	require.NoError(t, err)
+	assert.Len(t, patterns, 2)
+	assert.Equal(t, "*** ***", patterns[1].ReplaceAllLiteralString(patterns[0].ReplaceAllLiteralString("123456789012 build.corp.internal", "***"), "***"))
+
+	_, err = parseMaskPatterns([]string{"("})
+	assert.ErrorContains(t, err, "invalid --mask-regex '('")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nektos/act/pkg/runner"
+	"github.com/nektos/act/pkg/selfhosted"
+)
+
+func newRunnerCommand(ctx context.Context, input *Input, runFlags *pflag.FlagSet) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "runner",
+		Short: "Register as an ephemeral self-hosted runner of a repository, run the first job GitHub assigns to it with the container engine of act and report its result, the logs are printed here only",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			token := input.runnerToken
+			if token == "" {
+				token = os.Getenv("ACT_RUNNER_TOKEN")
+			}
+			if input.runnerURL == "" || token == "" {
+				return fmt.Errorf("the runner needs the URL of the repository (--url) and its registration token (--token or ACT_RUNNER_TOKEN)")
+			}
+			name := input.runnerName
+			if name == "" {
+				hostname, _ := os.Hostname()
+				name = "act-" + hostname
+			}
+
+			r, err := selfhosted.Register(ctx, selfhosted.Options{
+				URL:    input.runnerURL,
+				Token:  token,
+				Name:   name,
+				Labels: input.runnerLabels,
+			}, log.StandardLogger())
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := r.Close(context.Background()); err != nil {
+					log.Warnf("Unable to remove the runner %s: %v", name, err)
+				}
+			}()
+			log.Infof("Registered the runner %s, waiting for a job", name)
+
+			request, err := r.GetJob(ctx)
+			if err != nil {
+				return err
+			}
+			log.Infof("Running the job %s", request.Message.JobDisplayName)
+
+			renewCtx, cancelRenew := context.WithCancel(ctx)
+			go r.KeepRenewing(renewCtx, request)
+			result, outputs, runErr := runRunnerJob(ctx, cmd, input, request)
+			cancelRenew()
+
+			if err := r.Complete(context.Background(), request, result, outputs); err != nil {
+				return fmt.Errorf("unable to report the result of the job: %w", err)
+			}
+			log.Infof("The job %s %s", request.Message.JobDisplayName, result)
+			return runErr
+		},
+		SilenceUsage: true,
+	}
+	// the job runs like with the run command, e.g. -P and --container-architecture
+	cmd.Flags().AddFlagSet(runFlags)
+	cmd.Flags().StringVar(&input.runnerURL, "url", "", "URL of the repository the runner is registered with (e.g. https://github.com/octo/app)")
+	cmd.Flags().StringVar(&input.runnerToken, "token", "", "registration token of the runner, defaults to ACT_RUNNER_TOKEN")
+	cmd.Flags().StringVar(&input.runnerName, "name", "", "name of the runner, defaults to act-<hostname>")
+	cmd.Flags().StringSliceVar(&input.runnerLabels, "labels", []string{}, "labels of the runner besides self-hosted and the OS and architecture, the jobs on other labels run on the image of -P for ubuntu-latest")
+	return cmd
+}
+
+// runRunnerJob runs the job of a request in the repository of the workflow at the commit of the job
+// and returns its result and outputs
+func runRunnerJob(ctx context.Context, cmd *cobra.Command, input *Input, request *selfhosted.Request) (string, map[string]string, error) {
+	job, err := selfhosted.NewJob(request.Message, input.runnerLabels)
+	if err != nil {
+		return selfhosted.ResultFailed, nil, err
+	}
+	dir, err := os.MkdirTemp("", "act-runner-")
+	if err != nil {
+		return selfhosted.ResultFailed, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	workflow := filepath.Join(dir, "workflow.yml")
+	event := filepath.Join(dir, "event.json")
+	state := filepath.Join(dir, "state.json")
+	for path, data := range map[string][]byte{workflow: job.Workflow, event: job.Event} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return selfhosted.ResultFailed, nil, err
+		}
+	}
+	log.Debugf("Workflow of the job:\n%s", job.Workflow)
+
+	input.noInput = true
+	input.repo = job.Repository + "@" + job.Sha
+	input.githubInstance = job.ServerURL
+	input.workflowsPaths = []string{workflow}
+	input.eventPath = event
+	input.runStateFile = state
+	input.needsOutputs = job.NeedsOutputs
+	input.secrets = keyValues(job.Secrets)
+	input.vars = keyValues(job.Vars)
+	if err := cmd.Flags().Set("job", job.ID); err != nil {
+		return selfhosted.ResultFailed, nil, err
+	}
+
+	runErr := newRunCommand(ctx, input)(cmd, []string{job.EventName})
+	if ctx.Err() != nil {
+		return selfhosted.ResultCancelled, nil, runErr
+	}
+	runState, err := runner.ReadRunState(state)
+	if err != nil {
+		return selfhosted.ResultFailed, nil, runErr
+	}
+	for key, jobState := range runState.Jobs {
+		if strings.HasSuffix(key, "/"+job.ID) && jobState.Result == "success" {
+			return selfhosted.ResultSucceeded, jobState.Outputs, runErr
+		}
+	}
+	return selfhosted.ResultFailed, nil, runErr
+}
+
+func keyValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for key, value := range m {
+		values = append(values, key+"="+value)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/nektos/act/pkg/runner"
+)
+
+// writeSBOM writes the file of --sbom in the format of --sbom-format
+func writeSBOM(input *Input, sbom *runner.SBOM) error {
+	if sbom == nil {
+		return nil
+	}
+	write := sbom.WriteCycloneDX
+	if input.sbomFormat == "spdx" {
+		write = sbom.WriteSPDX
+	}
+	return writeReportFile(input.resolve(input.sbom), write)
+}
@@ -3,10 +3,15 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/AlecAivazis/survey/v2"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/term"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/runner"
 )
 
 type secrets map[string]string
@@ -40,3 +45,82 @@ func newSecrets(secretList []string) secrets {
 func (s secrets) AsMap() map[string]string {
 	return s
 }
+
+// promptMissingSecrets asks for the secrets the plan refers to which have no value, instead of
+// running the steps with empty strings
+func promptMissingSecrets(plan *model.Plan, s secrets, environments map[string]*runner.Environment) error {
+	defined := make(map[string]bool, len(s))
+	for name := range s {
+		defined[strings.ToUpper(name)] = true
+	}
+	// the secrets of deployment environments are only asked for if no environment has them
+	for _, environment := range environments {
+		for name := range environment.Secrets {
+			defined[strings.ToUpper(name)] = true
+		}
+	}
+	for _, name := range plan.Secrets() {
+		// GITHUB_TOKEN is optional, act runs without it
+		if defined[name] || name == "GITHUB_TOKEN" {
+			continue
+		}
+		var value string
+		if err := survey.AskOne(&survey.Password{
+			Message: fmt.Sprintf("Provide value for secret '%s' (empty to leave it unset):", name),
+		}, &value); err != nil {
+			return err
+		}
+		if value != "" {
+			s[name] = value
+		}
+	}
+	return nil
+}
+
+// promptMissingInputs asks for the required workflow_dispatch inputs of the plan which have
+// neither a value nor a default
+func promptMissingInputs(plan *model.Plan, inputs map[string]string) error {
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			config := run.Workflow.WorkflowDispatchConfig()
+			if config == nil {
+				continue
+			}
+			names := make([]string, 0, len(config.Inputs))
+			for name := range config.Inputs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				dispatchInput := config.Inputs[name]
+				if !dispatchInput.Required || dispatchInput.Default != "" || inputs[name] != "" {
+					continue
+				}
+				value, err := promptInput(name, dispatchInput)
+				if err != nil {
+					return err
+				}
+				inputs[name] = value
+			}
+		}
+	}
+	return nil
+}
+
+func promptInput(name string, dispatchInput model.WorkflowDispatchInput) (string, error) {
+	message := fmt.Sprintf("Provide value for input '%s':", name)
+	switch {
+	case dispatchInput.Type == "boolean":
+		var value bool
+		err := survey.AskOne(&survey.Confirm{Message: message, Help: dispatchInput.Description}, &value)
+		return fmt.Sprint(value), err
+	case dispatchInput.Type == "choice" && len(dispatchInput.Options) > 0:
+		var value string
+		err := survey.AskOne(&survey.Select{Message: message, Help: dispatchInput.Description, Options: dispatchInput.Options}, &value)
+		return value, err
+	default:
+		var value string
+		err := survey.AskOne(&survey.Input{Message: message, Help: dispatchInput.Description}, &value, survey.WithValidator(survey.Required))
+		return value, err
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nektos/act/pkg/runner"
+)
+
+// writeTimings prints the table of --timings and writes the files of --timings-json and --timings-flamegraph
+func writeTimings(input *Input, timings *runner.Timings) error {
+	if timings == nil {
+		return nil
+	}
+	if input.timings {
+		fmt.Println()
+		if err := timings.WriteTable(os.Stdout, input.timingsSort); err != nil {
+			return err
+		}
+	}
+	if input.timingsJSON != "" {
+		if err := writeReportFile(input.resolve(input.timingsJSON), timings.WriteJSON); err != nil {
+			return err
+		}
+	}
+	if input.timingsFlamegraph != "" {
+		if err := writeReportFile(input.resolve(input.timingsFlamegraph), timings.WriteFlamegraph); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReportFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
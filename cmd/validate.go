@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/lint"
+)
+
+func newValidateCommand(input *Input) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [workflow file or directory]",
+		Short: "Check the workflows against the workflow schema and semantic rules, e.g. unknown needs, invalid shells, bad cron syntax and undefined inputs or secrets, and report the findings with their positions",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := input.WorkflowsPaths()
+			if len(args) > 0 {
+				paths = []string{input.resolve(args[0])}
+			}
+
+			opts := lint.Options{WorkingDir: input.Workdir()}
+			secrets := newSecrets(input.secrets)
+			if readEnvs(input.Secretfile(), secrets, input.environment) || len(input.secrets) > 0 {
+				opts.Secrets = make([]string, 0, len(secrets))
+				for name := range secrets {
+					opts.Secrets = append(opts.Secrets, name)
+				}
+			}
+
+			findings := []*lint.Finding{}
+			for _, path := range paths {
+				pathFindings, err := lint.Validate(path, !input.noWorkflowRecurse, opts)
+				if err != nil {
+					return err
+				}
+				findings = append(findings, pathFindings...)
+			}
+
+			errors := 0
+			for _, finding := range findings {
+				if finding.Severity == lint.SeverityError {
+					errors++
+				}
+			}
+			if input.jsonLogger {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(findings); err != nil {
+					return err
+				}
+			} else {
+				for _, finding := range findings {
+					fmt.Println(finding)
+				}
+			}
+			if errors > 0 {
+				return common.WithErrorClass(fmt.Errorf("found %d errors and %d warnings in the workflows", errors, len(findings)-errors), common.ErrorClassWorkflow)
+			}
+			return nil
+		},
+		// .actrc may contain flags of the run command
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		SilenceUsage:       true,
+	}
+	cmd.Flags().StringArrayVarP(&input.secrets, "secret", "s", []string{}, "secret which is defined with optional value, secrets which are neither defined with --secret nor in the secret file are reported if either is used (e.g. -s GITHUB_TOKEN)")
+	return cmd
+}
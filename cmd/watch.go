@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andreaskoch/go-fswatch"
+	gitignore "github.com/sabhiram/go-gitignore"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// watchDebounce is how long the files have to stay unchanged before the workflows run again,
+// so saving several files runs them once
+const watchDebounce = 2 * time.Second
+
+// watchAndRun runs the plan, then watches dir and runs the workflows whose path filters match
+// the changed files again. The workflows are planned again for every run to pick up their changes.
+func watchAndRun(ctx context.Context, dir string, eventName string, replan func() (*model.Plan, error), newExecutor func(*model.Plan) common.Executor) error {
+	ignoreFile := filepath.Join(dir, ".gitignore")
+	ignore := &gitignore.GitIgnore{}
+	if info, err := os.Stat(ignoreFile); err == nil && !info.IsDir() {
+		ignore, err = gitignore.CompileIgnoreFile(ignoreFile)
+		if err != nil {
+			return fmt.Errorf("compile %q: %w", ignoreFile, err)
+		}
+	}
+	skip := func(path string) bool {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return false
+		}
+		rel = filepath.ToSlash(rel)
+		return rel == ".git" || strings.HasPrefix(rel, ".git/") || ignore.MatchesPath(rel)
+	}
+
+	folderWatcher := fswatch.NewFolderWatcher(
+		dir,
+		true,
+		skip,
+		1, // 1 second
+	)
+
+	folderWatcher.Start()
+	defer folderWatcher.Stop()
+
+	run := func(changed []string) {
+		plan, err := replan()
+		if plan == nil {
+			log.Errorf("Unable to plan the workflows: %v", err)
+			return
+		}
+		if changed != nil {
+			plan = plan.FilterWorkflows(func(w *model.Workflow) bool {
+				if workflowChanged(w, changed) || w.TriggeredByPaths(eventName, changed) {
+					return true
+				}
+				log.Debugf("Workflow '%s' isn't affected by the changes", w.Name)
+				return false
+			})
+			if len(plan.Stages) == 0 {
+				log.Infof("No workflow is affected by the changes")
+				return
+			}
+		}
+		if err := newExecutor(plan)(ctx); err != nil {
+			log.Errorf("%v", err)
+		}
+	}
+	// run once before watching
+	run(nil)
+
+	for folderWatcher.IsRunning() {
+		log.Infof("Watching %s for changes", dir)
+		select {
+		case <-ctx.Done():
+			return nil
+		case change := <-folderWatcher.ChangeDetails():
+			changed := changedFiles(dir, change)
+			// wait for the other files of the change
+			for debounce := true; debounce; {
+				select {
+				case <-ctx.Done():
+					return nil
+				case change := <-folderWatcher.ChangeDetails():
+					changed = append(changed, changedFiles(dir, change)...)
+				case <-time.After(watchDebounce):
+					debounce = false
+				}
+			}
+			sort.Strings(changed)
+			log.Infof("Changed files: %v", changed)
+			run(changed)
+		}
+	}
+
+	return nil
+}
+
+// changedFiles returns the files of a change relative to dir, with slashes like path filters
+func changedFiles(dir string, change *fswatch.FolderChange) []string {
+	files := []string{}
+	for _, items := range [][]string{change.New(), change.Modified(), change.Moved()} {
+		for _, item := range items {
+			if rel, err := filepath.Rel(dir, item); err == nil {
+				files = append(files, filepath.ToSlash(rel))
+			}
+		}
+	}
+	return files
+}
+
+func workflowChanged(w *model.Workflow, changed []string) bool {
+	for _, file := range changed {
+		if filepath.Base(file) == w.File {
+			return true
+		}
+	}
+	return false
+}
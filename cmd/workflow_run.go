@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/model"
+)
+
+// workflowRunLevels is how many levels of workflows GitHub chains with workflow_run, e.g. A
+// triggers B, B triggers C and C triggers D, but D doesn't trigger E
+const workflowRunLevels = 3
+
+// runWorkflowRuns runs the workflows whose workflow_run trigger matches the completion of the
+// workflows of the plan, with the workflow_run payload GitHub would send, one workflow which
+// completed after the other
+func runWorkflowRuns(ctx context.Context, cmd *cobra.Command, input *Input, plan *model.Plan, eventName string) error {
+	completed := planWorkflows(plan)
+	if len(completed) == 0 {
+		return nil
+	}
+
+	// the workflows triggered by the chain aren't limited by --workflow-name
+	all := *input
+	all.workflowNames = nil
+	planner, err := newWorkflowPlanner(&all)
+	if err != nil {
+		return err
+	}
+	candidates, err := planner.PlanEvent("workflow_run")
+	if err != nil && candidates == nil {
+		return err
+	}
+
+	branch, sha := workflowRunRevision(ctx, input.Workdir())
+	var firstErr error
+	for _, w := range completed {
+		var names []string
+		for _, candidate := range planWorkflows(candidates) {
+			if candidate.TriggeredByWorkflowRun(w.Name, "completed", branch) && !containsEvent(names, candidate.Name) {
+				names = append(names, candidate.Name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		if input.workflowRunLevel >= workflowRunLevels {
+			log.Warnf("Not running %s after %s, GitHub chains only %d levels of workflows with workflow_run", strings.Join(names, ", "), w.Name, workflowRunLevels)
+			continue
+		}
+		sort.Strings(names)
+
+		if err := runWorkflowRun(ctx, cmd, input, w, names, eventName, workflowConclusion(plan, w), branch, sha); err != nil {
+			log.Errorf("The workflows triggered by %s failed: %v", w.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return firstErr
+}
+
+func runWorkflowRun(ctx context.Context, cmd *cobra.Command, input *Input, w *model.Workflow, names []string, eventName, conclusion, branch, sha string) error {
+	payload := workflowRunPayload(input, w, eventName, conclusion, branch, sha)
+	f, err := os.CreateTemp("", "act-workflow-run-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := json.NewEncoder(f).Encode(payload); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	log.Infof("Running %s after %s completed with %s", strings.Join(names, ", "), w.Name, conclusion)
+	chained := *input
+	chained.workflowNames = names
+	chained.eventPath = f.Name()
+	chained.eventPayloadDir = ""
+	chained.rerunFailed = false
+	chained.workflowRunLevel++
+	return newRunCommand(ctx, &chained)(cmd, []string{"workflow_run"})
+}
+
+// workflowRunPayload returns the payload of the workflow_run event of the completion of the
+// workflow, with the repository and the sender of the payload of the event it ran on
+func workflowRunPayload(input *Input, w *model.Workflow, eventName, conclusion, branch, sha string) map[string]interface{} {
+	path := ".github/workflows/" + w.File
+	payload := map[string]interface{}{
+		"action": "completed",
+		"workflow": map[string]interface{}{
+			"name": w.Name,
+			"path": path,
+		},
+		"workflow_run": map[string]interface{}{
+			"name":          w.Name,
+			"display_title": w.Name,
+			"path":          path,
+			"event":         eventName,
+			"status":        "completed",
+			"conclusion":    conclusion,
+			"head_branch":   branch,
+			"head_sha":      sha,
+			"run_number":    1,
+			"run_attempt":   1,
+		},
+	}
+	if input.eventPath == "" {
+		return payload
+	}
+	data, err := os.ReadFile(input.EventPath())
+	if err != nil {
+		return payload
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return payload
+	}
+	for _, key := range []string{"repository", "sender", "organization"} {
+		if value, ok := event[key]; ok {
+			payload[key] = value
+			payload["workflow_run"].(map[string]interface{})[key] = value
+		}
+	}
+	return payload
+}
+
+func workflowRunRevision(ctx context.Context, workdir string) (string, string) {
+	_, sha, err := git.FindGitRevision(ctx, workdir)
+	if err != nil {
+		log.Debugf("Unable to find the revision of the workflow_run payload: %v", err)
+	}
+	ref, err := git.FindGitRef(ctx, workdir)
+	if err != nil {
+		log.Debugf("Unable to find the branch of the workflow_run payload: %v", err)
+	}
+	return strings.TrimPrefix(ref, "refs/heads/"), sha
+}
+
+// planWorkflows returns the workflows of the plan in the order of their first job
+func planWorkflows(plan *model.Plan) []*model.Workflow {
+	var workflows []*model.Workflow
+	if plan == nil {
+		return nil
+	}
+	seen := map[*model.Workflow]bool{}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if !seen[run.Workflow] {
+				seen[run.Workflow] = true
+				workflows = append(workflows, run.Workflow)
+			}
+		}
+	}
+	return workflows
+}
+
+// workflowConclusion returns the conclusion of the workflow from the results of its jobs, the jobs
+// of a dry run have none and succeed
+func workflowConclusion(plan *model.Plan, w *model.Workflow) string {
+	skipped := true
+	conclusion := "success"
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			job := run.Job()
+			if run.Workflow != w || job == nil {
+				continue
+			}
+			switch job.Result {
+			case "failure":
+				return "failure"
+			case "cancelled":
+				conclusion = "cancelled"
+			}
+			if job.Result != "skipped" {
+				skipped = false
+			}
+		}
+	}
+	if skipped {
+		return "skipped"
+	}
+	return conclusion
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	_ "embed"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -27,9 +28,14 @@ func main() {
 	go func() {
 		select {
 		case <-c:
+			fmt.Fprintln(os.Stderr, "Cancelling the run, press Ctrl+C again to exit without cleaning up")
 			cancel()
 		case <-ctx.Done():
+			return
 		}
+		// a second Ctrl+C doesn't wait for the post steps and the removal of the containers
+		<-c
+		os.Exit(130)
 	}()
 
 	// run the command
@@ -0,0 +1,176 @@
+package artifactcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backend stores the complete caches in a place shared by the act processes of a team and their
+// self-hosted CI. The handler keeps a local copy of the caches it uploads or downloads, the backend
+// is asked for a cache when there is no local one.
+type Backend interface {
+	// Get returns the content of the object, an error wrapping os.ErrNotExist if there is none
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// Put stores the object, replacing an object with the same name
+	Put(ctx context.Context, name string, content io.Reader, size int64) error
+	// List returns the objects starting with the prefix, sorted by name
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// Object is an object listed by a backend
+type Object struct {
+	Name     string
+	Modified time.Time // when the object was stored, a cache is stored once it's complete
+}
+
+// newestObject returns the most recently stored of the objects
+func newestObject(objects []Object) Object {
+	newest := objects[0]
+	for _, object := range objects[1:] {
+		if object.Modified.After(newest.Modified) {
+			newest = object
+		}
+	}
+	return newest
+}
+
+// NewBackend creates the backend of a --cache-server-storage value, which is one of
+//
+//	<path>                              a directory, e.g. on an NFS mount
+//	s3://<bucket>/<prefix>              an S3 bucket, using AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+//	                                    AWS_REGION and AWS_ENDPOINT_URL for S3 compatible stores like MinIO
+//	gs://<bucket>/<prefix>              a Google Cloud Storage bucket, using GOOGLE_OAUTH_ACCESS_TOKEN
+//	azblob://<account>/<container>/<prefix>
+//	                                    an Azure Blob Storage container, using AZURE_STORAGE_SAS_TOKEN and
+//	                                    AZURE_STORAGE_BLOB_ENDPOINT for emulators like Azurite
+//
+// The credentials are only read from these variables, the cloud SDKs aren't used: the AWS profiles
+// and instance roles aren't supported and the Google Cloud token isn't refreshed.
+func NewBackend(spec string) (Backend, error) {
+	u, err := url.Parse(spec)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// a path, also a windows one like C:\cache
+		return newFileBackend(spec)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "file":
+		return newFileBackend(u.Path)
+	case "s3":
+		return newS3Backend(u.Host, prefix)
+	case "gs":
+		return newGCSBackend(u.Host, prefix)
+	case "azblob":
+		container, prefix, _ := strings.Cut(prefix, "/")
+		return newAzureBackend(u.Host, container, prefix)
+	}
+	return nil, fmt.Errorf("unknown cache storage '%s', expected a path, s3://, gs:// or azblob://", spec)
+}
+
+// objectName returns the name of the object of the cache, the name of a key prefix is a prefix of
+// the names of the keys starting with it
+func objectName(key, version string) string {
+	return path.Join(fmt.Sprintf("%x", sha256.Sum256([]byte(version))), hex.EncodeToString([]byte(key)))
+}
+
+// objectKey returns the key of the cache of the object
+func objectKey(name string) (string, error) {
+	key, err := hex.DecodeString(path.Base(name))
+	if err != nil {
+		return "", fmt.Errorf("invalid cache object name '%s': %w", name, err)
+	}
+	return string(key), nil
+}
+
+// joinPrefix prepends the prefix of the backend to the name of an object
+func joinPrefix(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// fileBackend stores the objects in a directory, an object is renamed into place once it's
+// complete so the processes sharing the directory never read a partial one
+type fileBackend struct {
+	dir string
+}
+
+func newFileBackend(dir string) (*fileBackend, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the cache storage path is empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileBackend{dir: dir}, nil
+}
+
+func (b *fileBackend) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(b.dir, filepath.FromSlash(name)))
+}
+
+func (b *fileBackend) Put(_ context.Context, name string, content io.Reader, size int64) error {
+	target := filepath.Join(b.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	written, err := io.Copy(file, content)
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("broken file: %v != %v", written, size)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), target)
+}
+
+func (b *fileBackend) List(_ context.Context, prefix string) ([]Object, error) {
+	dir, base := path.Split(prefix)
+	entries, err := os.ReadDir(filepath.Join(b.dir, filepath.FromSlash(dir)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	objects := make([]Object, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), base) && !strings.HasSuffix(entry.Name(), ".tmp") {
+			info, err := entry.Info()
+			if err != nil {
+				// removed since
+				continue
+			}
+			objects = append(objects, Object{Name: dir + entry.Name(), Modified: info.ModTime()})
+		}
+	}
+	sortObjects(objects)
+	return objects, nil
+}
+
+func sortObjects(objects []Object) {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+}
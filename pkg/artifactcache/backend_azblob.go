@@ -0,0 +1,140 @@
+package artifactcache
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// azureBackend stores the objects as block blobs of an Azure Blob Storage container, authorized
+// with a shared access signature
+type azureBackend struct {
+	endpoint string // the URL of the container
+	prefix   string
+	sas      url.Values
+	client   *http.Client
+}
+
+func newAzureBackend(account, container, prefix string) (*azureBackend, error) {
+	if account == "" || container == "" {
+		return nil, fmt.Errorf("the Azure Blob Storage cache storage has no container, expected azblob://<account>/<container>/<prefix>")
+	}
+	token := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+	if token == "" {
+		return nil, fmt.Errorf("AZURE_STORAGE_SAS_TOKEN is required to store the caches in Azure Blob Storage")
+	}
+	sas, err := url.ParseQuery(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AZURE_STORAGE_SAS_TOKEN: %w", err)
+	}
+	// emulators like Azurite serve the account in the path
+	endpoint := fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	if custom := strings.TrimSuffix(os.Getenv("AZURE_STORAGE_BLOB_ENDPOINT"), "/"); custom != "" {
+		endpoint = custom
+	}
+	return &azureBackend{
+		endpoint: endpoint + "/" + container,
+		prefix:   prefix,
+		sas:      sas,
+		client:   http.DefaultClient,
+	}, nil
+}
+
+func (b *azureBackend) do(ctx context.Context, method, name string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	values := url.Values{}
+	for key, value := range b.sas {
+		values[key] = value
+	}
+	for key, value := range query {
+		values[key] = value
+	}
+	u := b.endpoint
+	if name != "" {
+		u += "/" + awsURIEncode(joinPrefix(b.prefix, name), false)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u+"?"+values.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Ms-Version", "2020-10-02")
+	if body != nil {
+		req.ContentLength = size
+		req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	}
+	return b.client.Do(req)
+}
+
+func (b *azureBackend) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := b.do(ctx, http.MethodGet, name, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, storageError("get", name, resp)
+	}
+	return resp.Body, nil
+}
+
+func (b *azureBackend) Put(ctx context.Context, name string, content io.Reader, size int64) error {
+	resp, err := b.do(ctx, http.MethodPut, name, nil, content, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return storageError("put", name, resp)
+	}
+	return nil
+}
+
+func (b *azureBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	query := url.Values{
+		"restype": {"container"},
+		"comp":    {"list"},
+		"prefix":  {joinPrefix(b.prefix, prefix)},
+	}
+	for {
+		resp, err := b.do(ctx, http.MethodGet, "", query, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Blobs []struct {
+				Name         string `xml:"Name"`
+				LastModified string `xml:"Properties>Last-Modified"`
+			} `xml:"Blobs>Blob"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = storageError("list", prefix, resp)
+		} else {
+			err = xml.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range result.Blobs {
+			// in the format of the HTTP dates, the zero time if it's invalid
+			modified, _ := http.ParseTime(blob.LastModified)
+			objects = append(objects, Object{Name: strings.TrimPrefix(blob.Name, joinPrefix(b.prefix, "")), Modified: modified})
+		}
+		if result.NextMarker == "" {
+			break
+		}
+		query.Set("marker", result.NextMarker)
+	}
+	sortObjects(objects)
+	return objects, nil
+}
@@ -0,0 +1,388 @@
+package artifactcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3PartSize is the size of the parts of the objects uploaded in parts, a single PUT is limited to
+// 5GB
+const s3PartSize = 64 << 20
+
+// s3MaxParts is the maximum number of parts of an object
+const s3MaxParts = 10000
+
+// s3Backend stores the objects with the S3 API, which Google Cloud Storage serves as well. The
+// requests are signed with the credentials of the environment, the profiles, the instance roles
+// and the other sources of the AWS SDKs aren't read.
+type s3Backend struct {
+	endpoint  string // the URL of the bucket
+	prefix    string
+	client    *http.Client
+	authorize func(req *http.Request) error
+	partSize  int64 // the objects larger than a part are uploaded in parts
+}
+
+func newS3Backend(bucket, prefix string) (*s3Backend, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("the S3 cache storage has no bucket, expected s3://<bucket>/<prefix>")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	signer := &awsSigner{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		region:          region,
+		service:         "s3",
+	}
+	if signer.accessKeyID == "" || signer.secretAccessKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required to store the caches in S3")
+	}
+
+	// the certificate of the virtual-hosted endpoint doesn't cover the buckets with dots, S3
+	// compatible stores like MinIO serve the buckets in the path as well
+	endpoint := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	if strings.Contains(bucket, ".") {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com/%s", region, bucket)
+	}
+	if custom := strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/"); custom != "" {
+		endpoint = custom + "/" + bucket
+	}
+	return &s3Backend{
+		endpoint:  endpoint,
+		prefix:    prefix,
+		client:    http.DefaultClient,
+		authorize: signer.sign,
+		partSize:  s3PartSize,
+	}, nil
+}
+
+func newGCSBackend(bucket, prefix string) (*s3Backend, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("the Google Cloud Storage cache storage has no bucket, expected gs://<bucket>/<prefix>")
+	}
+	// the token isn't refreshed, it has to last for the run
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GOOGLE_OAUTH_ACCESS_TOKEN is required to store the caches in Google Cloud Storage, e.g. from 'gcloud auth print-access-token'")
+	}
+	return &s3Backend{
+		endpoint: "https://storage.googleapis.com/" + bucket,
+		prefix:   prefix,
+		client:   http.DefaultClient,
+		authorize: func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		},
+		partSize: s3PartSize,
+	}, nil
+}
+
+func (b *s3Backend) do(ctx context.Context, method, name string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	u := b.endpoint + "/" + awsURIEncode(joinPrefix(b.prefix, name), false)
+	if name == "" {
+		u = b.endpoint + "/"
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if err := b.authorize(req); err != nil {
+		return nil, err
+	}
+	return b.client.Do(req)
+}
+
+func (b *s3Backend) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := b.do(ctx, http.MethodGet, name, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, storageError("get", name, resp)
+	}
+	return resp.Body, nil
+}
+
+func (b *s3Backend) Put(ctx context.Context, name string, content io.Reader, size int64) error {
+	if size > b.partSize {
+		return b.putParts(ctx, name, content, size)
+	}
+	resp, err := b.do(ctx, http.MethodPut, name, nil, content, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return storageError("put", name, resp)
+	}
+	return nil
+}
+
+// putParts uploads the object with a multipart upload, which is aborted when a part fails
+func (b *s3Backend) putParts(ctx context.Context, name string, content io.Reader, size int64) error {
+	partSize := b.partSize
+	if minSize := (size + s3MaxParts - 1) / s3MaxParts; partSize < minSize {
+		partSize = minSize
+	}
+
+	resp, err := b.do(ctx, http.MethodPost, name, url.Values{"uploads": {""}}, nil, 0)
+	if err != nil {
+		return err
+	}
+	var upload struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = storageError("upload", name, resp)
+	} else {
+		err = xml.NewDecoder(resp.Body).Decode(&upload)
+	}
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	type part struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	err = func() error {
+		var parts []part
+		for number, offset := 1, int64(0); offset < size; number, offset = number+1, offset+partSize {
+			length := partSize
+			if size-offset < length {
+				length = size - offset
+			}
+			query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {upload.UploadID}}
+			resp, err := b.do(ctx, http.MethodPut, name, query, io.LimitReader(content, length), length)
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode != http.StatusOK {
+				err = storageError("upload a part of", name, resp)
+			}
+			resp.Body.Close()
+			if err != nil {
+				return err
+			}
+			parts = append(parts, part{PartNumber: number, ETag: resp.Header.Get("ETag")})
+		}
+
+		body, err := xml.Marshal(struct {
+			XMLName xml.Name `xml:"CompleteMultipartUpload"`
+			Parts   []part   `xml:"Part"`
+		}{Parts: parts})
+		if err != nil {
+			return err
+		}
+		resp, err := b.do(ctx, http.MethodPost, name, url.Values{"uploadId": {upload.UploadID}}, bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return storageError("complete the upload of", name, resp)
+		}
+		// the completion fails with an error in the body of a 200 response as well
+		result, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(result, []byte("<Error>")) {
+			return fmt.Errorf("unable to complete the upload of %s in the cache storage: %s", name, strings.TrimSpace(string(result)))
+		}
+		return nil
+	}()
+	if err != nil {
+		// the parts of an upload which isn't aborted are kept, and billed
+		if resp, abortErr := b.do(context.Background(), http.MethodDelete, name, url.Values{"uploadId": {upload.UploadID}}, nil, 0); abortErr == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+func (b *s3Backend) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	query := url.Values{
+		"list-type": {"2"},
+		"prefix":    {joinPrefix(b.prefix, prefix)},
+	}
+	for {
+		resp, err := b.do(ctx, http.MethodGet, "", query, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = storageError("list", prefix, resp)
+		} else {
+			err = xml.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Name: strings.TrimPrefix(content.Key, joinPrefix(b.prefix, "")), Modified: content.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+	sortObjects(objects)
+	return objects, nil
+}
+
+// storageError returns the error of a failed request to the cache storage with the message of
+// its body
+func storageError(op, name string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("unable to %s %s in the cache storage: %s: %s", op, name, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// awsSigner signs the requests with AWS Signature Version 4, the payload isn't signed so the
+// caches are streamed
+type awsSigner struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+	service         string
+	now             func() time.Time
+}
+
+func (s *awsSigner) sign(req *http.Request) error {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			params = append(params, awsURIEncode(key, true)+"="+awsURIEncode(value, true))
+		}
+	}
+
+	uri := req.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uri,
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, s.region, s.service)
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(hash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsURIEncode encodes everything but the unreserved characters, the slashes too unless it's a
+// path
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,357 @@
+package artifactcache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBackend(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "")
+
+	dir := t.TempDir()
+	backend, err := NewBackend(filepath.Join(dir, "shared"))
+	require.NoError(t, err)
+	assert.Equal(t, &fileBackend{dir: filepath.Join(dir, "shared")}, backend)
+	assert.DirExists(t, filepath.Join(dir, "shared"))
+
+	for spec, msg := range map[string]string{
+		"s3://bucket/prefix":           "AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required",
+		"s3:///prefix":                 "has no bucket",
+		"gs://bucket":                  "GOOGLE_OAUTH_ACCESS_TOKEN is required",
+		"azblob://account/container/p": "AZURE_STORAGE_SAS_TOKEN is required",
+		"azblob://account":             "has no container",
+		"ftp://host/cache":             "unknown cache storage",
+	} {
+		_, err := NewBackend(spec)
+		assert.ErrorContains(t, err, msg, spec)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	backend, err = NewBackend("s3://bucket/team/cache/")
+	require.NoError(t, err)
+	assert.Equal(t, "https://bucket.s3.eu-west-1.amazonaws.com", backend.(*s3Backend).endpoint)
+	assert.Equal(t, "team/cache", backend.(*s3Backend).prefix)
+
+	backend, err = NewBackend("s3://bucket.example.com/cache")
+	require.NoError(t, err)
+	assert.Equal(t, "https://s3.eu-west-1.amazonaws.com/bucket.example.com", backend.(*s3Backend).endpoint)
+
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=2020-10-02&sig=abc")
+	backend, err = NewBackend("azblob://account/container/team")
+	require.NoError(t, err)
+	assert.Equal(t, "https://account.blob.core.windows.net/container", backend.(*azureBackend).endpoint)
+	assert.Equal(t, "team", backend.(*azureBackend).prefix)
+}
+
+func TestFileBackend(t *testing.T) {
+	backend, err := newFileBackend(t.TempDir())
+	require.NoError(t, err)
+	testBackend(t, backend)
+}
+
+func TestS3Backend(t *testing.T) {
+	objects := &fakeObjects{}
+	var parts []string // the parts of the multipart upload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") ||
+			r.Header.Get("X-Amz-Content-Sha256") != "UNSIGNED-PAYLOAD" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		require.True(t, strings.HasPrefix(r.URL.Path, "/bucket/"), r.URL.Path)
+		name := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			parts = nil
+			_, _ = io.WriteString(w, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut && query.Get("uploadId") == "upload-1":
+			content, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, strconv.Itoa(len(parts)+1), query.Get("partNumber"))
+			parts = append(parts, string(content))
+			w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, len(parts)))
+		case r.Method == http.MethodPost && query.Get("uploadId") == "upload-1":
+			var complete struct {
+				Parts []struct {
+					PartNumber int    `xml:"PartNumber"`
+					ETag       string `xml:"ETag"`
+				} `xml:"Part"`
+			}
+			require.NoError(t, xml.NewDecoder(r.Body).Decode(&complete))
+			require.Len(t, complete.Parts, len(parts))
+			for i, part := range complete.Parts {
+				assert.Equal(t, i+1, part.PartNumber)
+				assert.Equal(t, fmt.Sprintf(`"etag-%d"`, i+1), part.ETag)
+			}
+			objects.put(t, name, strings.NewReader(strings.Join(parts, "")))
+			_, _ = io.WriteString(w, `<CompleteMultipartUploadResult></CompleteMultipartUploadResult>`)
+		case r.Method == http.MethodPut:
+			objects.put(t, name, r.Body)
+		case r.Method == http.MethodGet && name == "":
+			assert.Equal(t, "2", r.URL.Query().Get("list-type"))
+			// one object per page
+			names := objects.list(r.URL.Query().Get("prefix"))
+			if token := r.URL.Query().Get("continuation-token"); token != "" {
+				for len(names) > 0 && names[0] != token {
+					names = names[1:]
+				}
+			}
+			result := struct {
+				XMLName  xml.Name `xml:"ListBucketResult"`
+				Contents []struct {
+					Key          string    `xml:"Key"`
+					LastModified time.Time `xml:"LastModified"`
+				} `xml:"Contents"`
+				IsTruncated           bool   `xml:"IsTruncated"`
+				NextContinuationToken string `xml:"NextContinuationToken,omitempty"`
+			}{}
+			if len(names) > 0 {
+				result.Contents = append(result.Contents, struct {
+					Key          string    `xml:"Key"`
+					LastModified time.Time `xml:"LastModified"`
+				}{names[0], objects.modifiedAt(names[0])})
+			}
+			if len(names) > 1 {
+				result.IsTruncated = true
+				result.NextContinuationToken = names[1]
+			}
+			_ = xml.NewEncoder(w).Encode(result)
+		case r.Method == http.MethodGet:
+			objects.get(w, name)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+	backend, err := NewBackend("s3://bucket/team")
+	require.NoError(t, err)
+	testBackend(t, backend)
+	assert.Contains(t, objects.list(""), "team/"+objectName("key-1", "v1"))
+
+	// the large objects are uploaded in parts
+	backend.(*s3Backend).partSize = 10
+	content := "0123456789abcdefghijklmnopqrstuvwxyz"
+	require.NoError(t, backend.Put(context.Background(), "large", strings.NewReader(content), int64(len(content))))
+	assert.Equal(t, []string{"0123456789", "abcdefghij", "klmnopqrst", "uvwxyz"}, parts)
+	reader, err := backend.Get(context.Background(), "large")
+	require.NoError(t, err)
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.NoError(t, reader.Close())
+	assert.Equal(t, content, string(got))
+}
+
+func TestAzureBackend(t *testing.T) {
+	objects := &fakeObjects{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sig") != "abc" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/account/container"), "/")
+		switch {
+		case r.Method == http.MethodPut:
+			assert.Equal(t, "BlockBlob", r.Header.Get("X-Ms-Blob-Type"))
+			objects.put(t, name, r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Query().Get("comp") == "list":
+			type blob struct {
+				Name         string `xml:"Name"`
+				LastModified string `xml:"Properties>Last-Modified"`
+			}
+			result := struct {
+				XMLName xml.Name `xml:"EnumerationResults"`
+				Blobs   []blob   `xml:"Blobs>Blob"`
+			}{}
+			for _, name := range objects.list(r.URL.Query().Get("prefix")) {
+				result.Blobs = append(result.Blobs, blob{Name: name, LastModified: objects.modifiedAt(name).Format(http.TimeFormat)})
+			}
+			_ = xml.NewEncoder(w).Encode(result)
+		case r.Method == http.MethodGet:
+			objects.get(w, name)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "sv=2020-10-02&sig=abc")
+	t.Setenv("AZURE_STORAGE_BLOB_ENDPOINT", server.URL+"/account")
+	backend, err := NewBackend("azblob://account/container")
+	require.NoError(t, err)
+	testBackend(t, backend)
+}
+
+func TestHandler_Backend(t *testing.T) {
+	// the handlers of two machines sharing a directory
+	backend, err := newFileBackend(t.TempDir())
+	require.NoError(t, err)
+	first, err := StartHandlerWithBackend(filepath.Join(t.TempDir(), "artifactcache"), "", 0, backend, nil)
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := StartHandlerWithBackend(filepath.Join(t.TempDir(), "artifactcache"), "", 0, backend, nil)
+	require.NoError(t, err)
+	defer second.Close()
+
+	version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+	content := make([]byte, 100)
+	_, err = rand.Read(content)
+	require.NoError(t, err)
+	uploadCacheNormally(t, first.ExternalURL()+urlBase, "linux-go-abc", version, content)
+
+	for _, keys := range []string{"linux-go-abc", "linux-go-def,linux-go-"} {
+		resp, err := http.Get(fmt.Sprintf("%s%s/cache?keys=%s&version=%s", second.ExternalURL(), urlBase, keys, version))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode, keys)
+	}
+	cache, err := second.findCache([]string{"linux-go-abc"}, version)
+	require.NoError(t, err)
+	require.NotNil(t, cache)
+	assert.Equal(t, int64(100), cache.Size)
+	file, err := second.storage.Open(cache.ID)
+	require.NoError(t, err)
+	defer file.Close()
+	got, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	resp, err := http.Get(fmt.Sprintf("%s%s/cache?keys=%s&version=%s", second.ExternalURL(), urlBase, "windows-go-", version))
+	require.NoError(t, err)
+	assert.Equal(t, 204, resp.StatusCode)
+}
+
+func TestHandler_BackendNewestCache(t *testing.T) {
+	backend, err := newFileBackend(t.TempDir())
+	require.NoError(t, err)
+	version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+	now := time.Now()
+	for key, modified := range map[string]time.Time{"linux-go-abc": now.Add(-time.Hour), "linux-go-xyz": now} {
+		name := objectName(key, version)
+		require.NoError(t, backend.Put(context.Background(), name, strings.NewReader(key), int64(len(key))))
+		require.NoError(t, os.Chtimes(filepath.Join(backend.dir, filepath.FromSlash(name)), modified, modified))
+	}
+
+	for _, tt := range []struct {
+		keys []string
+		key  string
+	}{
+		{[]string{"linux-go-abc"}, "linux-go-abc"},
+		{[]string{"linux-go-"}, "linux-go-xyz"}, // the primary key is also a prefix
+		{[]string{"linux-go-def", "linux-"}, "linux-go-xyz"},
+		{[]string{"linux-go-def", "windows-", "linux-go-a"}, "linux-go-abc"},
+	} {
+		handler, err := StartHandlerWithBackend(filepath.Join(t.TempDir(), "artifactcache"), "", 0, backend, nil)
+		require.NoError(t, err)
+		cache, err := handler.fetchSharedCache(context.Background(), tt.keys, version)
+		require.NoError(t, err)
+		require.NotNil(t, cache, tt.keys)
+		assert.Equal(t, tt.key, cache.Key, tt.keys)
+		require.NoError(t, handler.Close())
+	}
+}
+
+// testBackend stores, lists and reads caches in the backend
+func testBackend(t *testing.T, backend Backend) {
+	ctx := context.Background()
+	for _, name := range []string{objectName("key-2", "v1"), objectName("key-1", "v1"), objectName("key-1", "v2")} {
+		require.NoError(t, backend.Put(ctx, name, strings.NewReader(name), int64(len(name))))
+	}
+
+	objects, err := backend.List(ctx, objectName("key-", "v1"))
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, objectName("key-1", "v1"), objects[0].Name)
+	assert.Equal(t, objectName("key-2", "v1"), objects[1].Name)
+	assert.False(t, objects[0].Modified.IsZero())
+	key, err := objectKey(objects[1].Name)
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", key)
+
+	objects, err = backend.List(ctx, objectName("other", "v1"))
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	content, err := backend.Get(ctx, objectName("key-1", "v2"))
+	require.NoError(t, err)
+	got, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.NoError(t, content.Close())
+	assert.Equal(t, objectName("key-1", "v2"), string(got))
+
+	_, err = backend.Get(ctx, objectName("key-3", "v1"))
+	assert.True(t, errors.Is(err, os.ErrNotExist), err)
+}
+
+// fakeObjects are the objects of a fake object store
+type fakeObjects struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	modified map[string]time.Time
+}
+
+func (o *fakeObjects) put(t *testing.T, name string, body io.Reader) {
+	content, err := io.ReadAll(body)
+	require.NoError(t, err)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.objects == nil {
+		o.objects = map[string][]byte{}
+		o.modified = map[string]time.Time{}
+	}
+	o.objects[name] = content
+	o.modified[name] = time.Now().UTC().Truncate(time.Second)
+}
+
+func (o *fakeObjects) modifiedAt(name string) time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.modified[name]
+}
+
+func (o *fakeObjects) get(w http.ResponseWriter, name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	content, ok := o.objects[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write(content)
+}
+
+func (o *fakeObjects) list(prefix string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	names := []string{}
+	for name := range o.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
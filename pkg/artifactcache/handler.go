@@ -1,6 +1,7 @@
 package artifactcache
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,8 +28,10 @@ const (
 )
 
 type Handler struct {
+	dir      string
 	db       *bolthold.Store
 	storage  *Storage
+	backend  Backend // shares the caches with other machines, nil to keep them local
 	router   *httprouter.Router
 	listener net.Listener
 	server   *http.Server
@@ -37,11 +40,18 @@ type Handler struct {
 	gcing int32 // TODO: use atomic.Bool when we can use Go 1.19
 	gcAt  time.Time
 
-	outboundIP string
+	outboundIP  string
+	externalURL string // the URL of the cache server of another act process, see sharedHandler
 }
 
 func StartHandler(dir, outboundIP string, port uint16, logger logrus.FieldLogger) (*Handler, error) {
-	h := &Handler{}
+	return StartHandlerWithBackend(dir, outboundIP, port, nil, logger)
+}
+
+// StartHandlerWithBackend starts the handler like StartHandler, the caches are stored in the
+// backend as well. A cache missing in dir is downloaded from the backend.
+func StartHandlerWithBackend(dir, outboundIP string, port uint16, backend Backend, logger logrus.FieldLogger) (*Handler, error) {
+	h := &Handler{backend: backend}
 
 	if logger == nil {
 		discard := logrus.New()
@@ -62,16 +72,12 @@ func StartHandler(dir, outboundIP string, port uint16, logger logrus.FieldLogger
 		return nil, err
 	}
 
-	db, err := bolthold.Open(filepath.Join(dir, "bolt.db"), 0o644, &bolthold.Options{
-		Encoder: json.Marshal,
-		Decoder: json.Unmarshal,
-		Options: &bbolt.Options{
-			Timeout:      5 * time.Second,
-			NoGrowSync:   bbolt.DefaultOptions.NoGrowSync,
-			FreelistType: bbolt.DefaultOptions.FreelistType,
-		},
-	})
-	if err != nil {
+	db, err := openDB(filepath.Join(dir, "bolt.db"))
+	if errors.Is(err, bbolt.ErrTimeout) {
+		// bolt lets a single process open the database, the cache server of the act process
+		// holding it serves the jobs of this one too
+		return sharedHandler(dir, logger)
+	} else if err != nil {
 		return nil, err
 	}
 	h.db = db
@@ -118,10 +124,53 @@ func StartHandler(dir, outboundIP string, port uint16, logger logrus.FieldLogger
 	h.listener = listener
 	h.server = server
 
+	if err := common.WriteFileAtomic(filepath.Join(dir, serverURLFile), []byte(h.ExternalURL()), 0o644); err != nil {
+		_ = h.Close()
+		return nil, err
+	}
+	h.dir = dir
+
 	return h, nil
 }
 
+// serverURLFile is the file of the cache directory with the URL of the cache server of the act
+// process which opened its database
+const serverURLFile = "server-url"
+
+// sharedHandler returns a handler for the cache server of another act process, which opened the
+// database of the directory. The caches are lost for the jobs of this process when the other one
+// exits before.
+func sharedHandler(dir string, logger logrus.FieldLogger) (*Handler, error) {
+	// the other process writes the file once it listens
+	for i := 0; ; i++ {
+		url, err := os.ReadFile(filepath.Join(dir, serverURLFile))
+		if err == nil {
+			logger.Infof("using the cache server of another act process at %s", url)
+			return &Handler{externalURL: string(url), logger: logger}, nil
+		}
+		if i == 50 {
+			return nil, fmt.Errorf("the cache directory %s is used by another process: %w", dir, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func openDB(path string) (*bolthold.Store, error) {
+	return bolthold.Open(path, 0o644, &bolthold.Options{
+		Encoder: json.Marshal,
+		Decoder: json.Unmarshal,
+		Options: &bbolt.Options{
+			Timeout:      time.Second,
+			NoGrowSync:   bbolt.DefaultOptions.NoGrowSync,
+			FreelistType: bbolt.DefaultOptions.FreelistType,
+		},
+	})
+}
+
 func (h *Handler) ExternalURL() string {
+	if h.externalURL != "" {
+		return h.externalURL
+	}
 	// TODO: make the external url configurable if necessary
 	return fmt.Sprintf("http://%s:%d",
 		h.outboundIP,
@@ -151,6 +200,9 @@ func (h *Handler) Close() error {
 		h.listener = nil
 	}
 	if h.db != nil {
+		if h.dir != "" {
+			_ = os.Remove(filepath.Join(h.dir, serverURLFile))
+		}
 		err := h.db.Close()
 		if err != nil {
 			retErr = err
@@ -160,6 +212,15 @@ func (h *Handler) Close() error {
 	return retErr
 }
 
+// getCache returns the cache, which must have been reserved
+func (h *Handler) getCache(id int64) (*Cache, error) {
+	cache := &Cache{}
+	if err := h.db.Get(id, cache); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
 // GET /_apis/artifactcache/cache
 func (h *Handler) find(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	keys := strings.Split(r.URL.Query().Get("keys"), ",")
@@ -174,6 +235,12 @@ func (h *Handler) find(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 		h.responseJSON(w, r, 500, err)
 		return
 	}
+	if cache == nil && h.backend != nil {
+		if cache, err = h.fetchSharedCache(r.Context(), keys, version); err != nil {
+			// the cache misses, the job uploads it again
+			h.logger.Warnf("unable to download the cache from the storage: %v", err)
+		}
+	}
 	if cache == nil {
 		h.responseJSON(w, r, 204)
 		return
@@ -206,14 +273,12 @@ func (h *Handler) reserve(w http.ResponseWriter, r *http.Request, _ httprouter.P
 
 	cache := api.ToCache()
 	cache.FillKeyVersionHash()
-	if err := h.db.FindOne(cache, bolthold.Where("KeyVersionHash").Eq(cache.KeyVersionHash)); err != nil {
-		if !errors.Is(err, bolthold.ErrNotFound) {
-			h.responseJSON(w, r, 500, err)
-			return
-		}
-	} else {
+	if err := h.db.FindOne(cache, bolthold.Where("KeyVersionHash").Eq(cache.KeyVersionHash)); err == nil {
 		h.responseJSON(w, r, 400, fmt.Errorf("already exist"))
 		return
+	} else if !errors.Is(err, bolthold.ErrNotFound) {
+		h.responseJSON(w, r, 500, err)
+		return
 	}
 
 	now := time.Now().Unix()
@@ -241,8 +306,8 @@ func (h *Handler) upload(w http.ResponseWriter, r *http.Request, params httprout
 		return
 	}
 
-	cache := &Cache{}
-	if err := h.db.Get(id, cache); err != nil {
+	cache, err := h.getCache(id)
+	if err != nil {
 		if errors.Is(err, bolthold.ErrNotFound) {
 			h.responseJSON(w, r, 400, fmt.Errorf("cache %d: not reserved", id))
 			return
@@ -275,8 +340,8 @@ func (h *Handler) commit(w http.ResponseWriter, r *http.Request, params httprout
 		return
 	}
 
-	cache := &Cache{}
-	if err := h.db.Get(id, cache); err != nil {
+	cache, err := h.getCache(id)
+	if err != nil {
 		if errors.Is(err, bolthold.ErrNotFound) {
 			h.responseJSON(w, r, 400, fmt.Errorf("cache %d: not reserved", id))
 			return
@@ -301,6 +366,11 @@ func (h *Handler) commit(w http.ResponseWriter, r *http.Request, params httprout
 		return
 	}
 
+	if err := h.shareCache(r.Context(), cache); err != nil {
+		// the cache is still there for this machine
+		h.logger.Warnf("unable to upload the cache to the storage: %v", err)
+	}
+
 	h.responseJSON(w, r, 200)
 }
 
@@ -377,6 +447,80 @@ func (h *Handler) findCache(keys []string, version string) (*Cache, error) {
 	return nil, nil
 }
 
+// shareCache uploads the complete cache to the backend
+func (h *Handler) shareCache(ctx context.Context, cache *Cache) error {
+	if h.backend == nil {
+		return nil
+	}
+	file, err := h.storage.Open(cache.ID)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return h.backend.Put(ctx, objectName(cache.Key, cache.Version), file, cache.Size)
+}
+
+// fetchSharedCache downloads the cache matching the keys from the backend, nil if there is none.
+// Like on GitHub the first key is matched exactly, then it and the restore keys are matched as
+// prefixes of the keys of the caches, the most recently stored cache matching a prefix is chosen.
+func (h *Handler) fetchSharedCache(ctx context.Context, keys []string, version string) (*Cache, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	name := objectName(keys[0], version)
+	content, err := h.backend.Get(ctx, name)
+	for _, prefix := range keys {
+		if !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		var objects []Object
+		if objects, err = h.backend.List(ctx, objectName(prefix, version)); err != nil {
+			return nil, err
+		}
+		if len(objects) > 0 {
+			name = newestObject(objects).Name
+			content, err = h.backend.Get(ctx, name)
+		} else {
+			err = os.ErrNotExist
+		}
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	key, err := objectKey(name)
+	if err != nil {
+		return nil, err
+	}
+	cache := &Cache{Key: key, Version: version}
+	cache.FillKeyVersionHash()
+	now := time.Now().Unix()
+	cache.CreatedAt = now
+	cache.UsedAt = now
+	// fails e.g. when a job of this machine is uploading the cache
+	if err := h.db.Insert(bolthold.NextSequence(), cache); err != nil {
+		return nil, err
+	}
+	if err := h.db.Update(cache.ID, cache); err != nil {
+		return nil, err
+	}
+
+	if cache.Size, err = h.storage.Import(cache.ID, content); err != nil {
+		h.storage.Remove(cache.ID)
+		_ = h.db.Delete(cache.ID, cache)
+		return nil, err
+	}
+	cache.Complete = true
+	if err := h.db.Update(cache.ID, cache); err != nil {
+		return nil, err
+	}
+	h.logger.Infof("downloaded cache %q from the storage", cache.Key)
+	return cache, nil
+}
+
 func (h *Handler) useCache(id int64) {
 	cache := &Cache{}
 	if err := h.db.Get(id, cache); err != nil {
@@ -409,6 +409,34 @@ func TestHandler(t *testing.T) {
 	})
 }
 
+func TestHandler_SharedDir(t *testing.T) {
+	// the handlers of two act processes running at the same time
+	dir := filepath.Join(t.TempDir(), "artifactcache")
+	first, err := StartHandler(dir, "", 0, nil)
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := StartHandler(dir, "", 0, nil)
+	require.NoError(t, err)
+	defer second.Close()
+
+	version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+	content := make([]byte, 100)
+	_, err = rand.Read(content)
+	require.NoError(t, err)
+	uploadCacheNormally(t, first.ExternalURL()+urlBase, "first", version, content)
+	uploadCacheNormally(t, second.ExternalURL()+urlBase, "second", version, content)
+
+	resp, err := http.Get(fmt.Sprintf("%s%s/cache?keys=%s&version=%s", second.ExternalURL(), urlBase, "first", version))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// the second uses the cache server of the first, which opened the database
+	assert.Equal(t, first.ExternalURL(), second.ExternalURL())
+	assert.Nil(t, second.db)
+	require.NoError(t, first.Close())
+	assert.NoFileExists(t, filepath.Join(dir, serverURLFile))
+}
+
 func uploadCacheNormally(t *testing.T, base, key, version string, content []byte) {
 	var id uint64
 	{
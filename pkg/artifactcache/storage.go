@@ -60,11 +60,16 @@ func (s *Storage) Commit(id uint64, size int64) error {
 	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
 		return err
 	}
-	file, err := os.Create(name)
+	// the file is renamed when complete, the act processes sharing the storage never serve a
+	// partial file
+	file, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
 
 	var written int64
 	for _, v := range tempNames {
@@ -81,11 +86,41 @@ func (s *Storage) Commit(id uint64, size int64) error {
 	}
 
 	if written != size {
-		_ = file.Close()
-		_ = os.Remove(name)
 		return fmt.Errorf("broken file: %v != %v", written, size)
 	}
-	return nil
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+// Open opens the complete file of the cache
+func (s *Storage) Open(id uint64) (*os.File, error) {
+	return os.Open(s.filename(id))
+}
+
+// Import writes the complete file of the cache from the reader and returns its size
+func (s *Storage) Import(id uint64, reader io.Reader) (int64, error) {
+	name := s.filename(id)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return 0, err
+	}
+	file, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	size, err := io.Copy(file, reader)
+	if err != nil {
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+	return size, os.Rename(file.Name(), name)
 }
 
 func (s *Storage) Serve(w http.ResponseWriter, r *http.Request, id uint64) {
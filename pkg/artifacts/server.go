@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -88,6 +90,59 @@ func (fwfs readWriteFSImpl) OpenAppendable(name string) (WritableFile, error) {
 
 var gzipExtension = ".gz__"
 
+// lockFile is the file of the artifact path the artifact server locks against other act processes
+const lockFile = ".act.lock"
+
+// jobsDir is the directory of the artifact store recording the artifacts each job uploaded, in
+// <job>/<run id>/<artifact name>. The jobs upload through /jobs/<job>/ to be recorded.
+const jobsDir = ".jobs"
+
+type jobContextKey struct{}
+
+// withJobs serves the requests of /jobs/<job>/ like the others, the uploads are recorded for the
+// job
+func withJobs(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if rest := strings.TrimPrefix(req.URL.Path, "/jobs/"); rest != req.URL.Path {
+			if job, p, ok := strings.Cut(rest, "/"); ok && job != "" {
+				req = req.WithContext(context.WithValue(req.Context(), jobContextKey{}, job))
+				req.URL.Path = "/" + p
+				req.URL.RawPath = ""
+			}
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// jobPrefix returns the path prefix of the job of the request, empty if it has none
+func jobPrefix(req *http.Request) string {
+	if job, ok := req.Context().Value(jobContextKey{}).(string); ok {
+		return "/jobs/" + job
+	}
+	return ""
+}
+
+func jobRunDir(artifactPath, job, runID string) string {
+	return safeResolve(safeResolve(artifactPath, jobsDir), filepath.Join(job, runID))
+}
+
+// recordJobArtifact records that the job of the request uploaded to the artifact
+func recordJobArtifact(req *http.Request, baseDir, runID, itemPath string, fsys WriteFS) error {
+	job, ok := req.Context().Value(jobContextKey{}).(string)
+	if !ok {
+		return nil
+	}
+	name := strings.SplitN(strings.TrimLeft(filepath.ToSlash(itemPath), "/"), "/", 2)[0]
+	if name == "" {
+		return nil
+	}
+	file, err := fsys.OpenWritable(safeResolve(jobRunDir(baseDir, job, runID), name))
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
 func safeResolve(baseDir string, relPath string) string {
 	return filepath.Join(baseDir, filepath.Clean(filepath.Join(string(os.PathSeparator), relPath)))
 }
@@ -97,7 +152,7 @@ func uploads(router *httprouter.Router, baseDir string, fsys WriteFS) {
 		runID := params.ByName("runId")
 
 		json, err := json.Marshal(FileContainerResourceURL{
-			FileContainerResourceURL: fmt.Sprintf("http://%s/upload/%s", req.Host, runID),
+			FileContainerResourceURL: fmt.Sprintf("http://%s%s/upload/%s", req.Host, jobPrefix(req), runID),
 		})
 		if err != nil {
 			panic(err)
@@ -147,6 +202,10 @@ func uploads(router *httprouter.Router, baseDir string, fsys WriteFS) {
 			panic(err)
 		}
 
+		if err := recordJobArtifact(req, baseDir, runID, req.URL.Query().Get("itemPath"), fsys); err != nil {
+			panic(err)
+		}
+
 		json, err := json.Marshal(ResponseMessage{
 			Message: "success",
 		})
@@ -271,12 +330,23 @@ func downloads(router *httprouter.Router, baseDir string, fsys fs.FS) {
 	})
 }
 
-func Serve(ctx context.Context, artifactPath string, addr string, port string) context.CancelFunc {
+// Serve starts the artifact server, which stores the artifacts in artifactPath, and returns the
+// port it listens on. The act processes running at the same time with the same artifactPath take
+// turns, the runs store their artifacts by run id: the path is locked until the server shuts
+// down. When the port is taken, e.g. by the artifact server of another act process, the server
+// listens on a free port instead.
+func Serve(ctx context.Context, artifactPath string, addr string, port string) (context.CancelFunc, string, error) {
 	serverContext, cancel := context.WithCancel(ctx)
 	logger := common.Logger(serverContext)
 
 	if artifactPath == "" {
-		return cancel
+		return cancel, port, nil
+	}
+
+	unlock, err := common.LockFile(ctx, filepath.Join(artifactPath, lockFile))
+	if err != nil {
+		cancel()
+		return nil, "", err
 	}
 
 	router := httprouter.New()
@@ -286,16 +356,27 @@ func Serve(ctx context.Context, artifactPath string, addr string, port string) c
 	uploads(router, artifactPath, fsys)
 	downloads(router, artifactPath, fsys)
 
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%s", addr, port))
+	if err != nil {
+		logger.Warnf("Unable to listen on port %s, using a free port for the artifact server: %v", port, err)
+		listener, err = net.Listen("tcp", fmt.Sprintf("%s:0", addr))
+	}
+	if err != nil {
+		unlock()
+		cancel()
+		return nil, "", err
+	}
+	port = strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
 	server := &http.Server{
-		Addr:              fmt.Sprintf("%s:%s", addr, port),
 		ReadHeaderTimeout: 2 * time.Second,
-		Handler:           router,
+		Handler:           withJobs(router),
 	}
 
 	// run server
 	go func() {
 		logger.Infof("Start server on http://%s:%s", addr, port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			logger.Fatal(err)
 		}
 	}()
@@ -303,6 +384,7 @@ func Serve(ctx context.Context, artifactPath string, addr string, port string) c
 	// wait for cancel to gracefully shutdown server
 	go func() {
 		<-serverContext.Done()
+		defer unlock()
 
 		if err := server.Shutdown(ctx); err != nil {
 			logger.Errorf("Failed shutdown gracefully - force shutdown: %v", err)
@@ -310,5 +392,5 @@ func Serve(ctx context.Context, artifactPath string, addr string, port string) c
 		}
 	}()
 
-	return cancel
+	return cancel, port, nil
 }
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"testing/fstest"
@@ -112,6 +114,34 @@ func TestArtifactUploadBlob(t *testing.T) {
 	assert.Equal("content", string(memfs["artifact/server/path/1/some/file"].Data))
 }
 
+func TestArtifactUploadOfJob(t *testing.T) {
+	assert := assert.New(t)
+
+	var memfs = fstest.MapFS(map[string]*fstest.MapFile{})
+
+	router := httprouter.New()
+	uploads(router, "artifact/server/path", writeMapFS{memfs})
+	handler := withJobs(router)
+
+	req, _ := http.NewRequest("POST", "http://localhost/jobs/build-16/_apis/pipelines/workflows/1/artifacts", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(http.StatusOK, rr.Code)
+
+	response := FileContainerResourceURL{}
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal("http://localhost/jobs/build-16/upload/1", response.FileContainerResourceURL)
+
+	req, _ = http.NewRequest("PUT", response.FileContainerResourceURL+"?itemPath=dist/bin/app", strings.NewReader("content"))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(http.StatusOK, rr.Code)
+
+	assert.Equal("content", string(memfs["artifact/server/path/1/dist/bin/app"].Data))
+	// the upload is recorded for the job
+	assert.Contains(memfs, "artifact/server/path/.jobs/build-16/1/dist")
+}
+
 func TestFinalizeArtifactUpload(t *testing.T) {
 	assert := assert.New(t)
 
@@ -242,6 +272,29 @@ var artifactsPath = path.Join(os.TempDir(), "test-artifacts")
 var artifactsAddr = "127.0.0.1"
 var artifactsPort = "12345"
 
+func TestServeTakenPort(t *testing.T) {
+	assert := assert.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer listener.Close()
+	taken := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	dir := t.TempDir()
+	assert.NoError(os.Mkdir(filepath.Join(dir, "1"), 0o755))
+
+	cancel, port, err := Serve(context.Background(), dir, "127.0.0.1", taken)
+	assert.NoError(err)
+	defer cancel()
+
+	assert.NotEqual(taken, port)
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%s/_apis/pipelines/workflows/1/artifacts", port))
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusOK, resp.StatusCode)
+	}
+}
+
 func TestArtifactFlow(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -249,7 +302,8 @@ func TestArtifactFlow(t *testing.T) {
 
 	ctx := context.Background()
 
-	cancel := Serve(ctx, artifactsPath, artifactsAddr, artifactsPort)
+	cancel, _, err := Serve(ctx, artifactsPath, artifactsAddr, artifactsPort)
+	assert.NoError(t, err)
 	defer cancel()
 
 	platforms := map[string]string{
@@ -0,0 +1,32 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// WithoutCancel returns a context with the values of ctx, like the logger and the job error, which
+// isn't cancelled when ctx is. The cleanup of a cancelled run uses it.
+func WithoutCancel(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
@@ -0,0 +1,22 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithoutCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(WithJobErrorContainer(WithDryrun(context.Background(), true)))
+	cancel()
+	assert.Error(t, ctx.Err())
+
+	detached := WithoutCancel(ctx)
+	assert.NoError(t, detached.Err())
+	assert.Nil(t, detached.Done())
+	assert.True(t, Dryrun(detached))
+
+	SetJobError(detached, context.Canceled)
+	assert.Equal(t, context.Canceled, JobError(ctx))
+}
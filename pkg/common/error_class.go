@@ -0,0 +1,47 @@
+package common
+
+import (
+	"errors"
+)
+
+// ErrorClass is what went wrong in a run, act exits with a different code for each class
+type ErrorClass int
+
+const (
+	ErrorClassUnknown        ErrorClass = iota // e.g. invalid flags or files which can't be read
+	ErrorClassWorkflow                         // a workflow can't be parsed
+	ErrorClassPlan                             // the jobs to run can't be planned, e.g. for an unknown job or needs
+	ErrorClassInfrastructure                   // the container engine, an image or a job container failed
+	ErrorClassJob                              // a step of a job failed
+)
+
+type classifiedError struct {
+	class ErrorClass
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// WithErrorClass marks err with class, the message of err stays the same. An error which has a
+// class already keeps it, nil stays nil.
+func WithErrorClass(err error, class ErrorClass) error {
+	if err == nil || ErrorClassOf(err) != ErrorClassUnknown {
+		return err
+	}
+	return &classifiedError{class: class, err: err}
+}
+
+// ErrorClassOf returns the class of err, ErrorClassUnknown if it has none
+func ErrorClassOf(err error) ErrorClass {
+	var classified *classifiedError
+	if errors.As(err, &classified) {
+		return classified.class
+	}
+	return ErrorClassUnknown
+}
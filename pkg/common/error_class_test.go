@@ -0,0 +1,25 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClass(t *testing.T) {
+	assert.Nil(t, WithErrorClass(nil, ErrorClassJob))
+
+	err := errors.New("no such image")
+	assert.Equal(t, ErrorClassUnknown, ErrorClassOf(err))
+
+	classified := WithErrorClass(err, ErrorClassInfrastructure)
+	assert.EqualError(t, classified, "no such image")
+	assert.True(t, errors.Is(classified, err))
+	assert.Equal(t, ErrorClassInfrastructure, ErrorClassOf(classified))
+
+	wrapped := fmt.Errorf("job failed: %w", classified)
+	assert.Equal(t, ErrorClassInfrastructure, ErrorClassOf(wrapped))
+	assert.Equal(t, ErrorClassInfrastructure, ErrorClassOf(WithErrorClass(wrapped, ErrorClassJob)), "the first class is kept")
+}
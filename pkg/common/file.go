@@ -1,9 +1,12 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 // CopyFile copy file
@@ -71,3 +74,62 @@ func CopyDir(source string, dest string) (err error) {
 	}
 	return err
 }
+
+// WriteFileAtomic writes the file like os.WriteFile, but into a temporary file which is renamed
+// when complete, so the act processes reading the file at the same time never see it partial
+func WriteFileAtomic(name string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// LockFile locks the file, which it creates if needed, against the other act processes locking it,
+// e.g. to fill a cache shared by the processes running at the same time. It waits for the process
+// holding the lock until ctx is done. The lock is released by unlock, or when the process exits.
+func LockFile(ctx context.Context, name string) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	logged := false
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("unable to lock %s: %w", name, err)
+		}
+		if locked {
+			break
+		}
+		if !logged {
+			Logger(ctx).Debugf("Waiting for another act process to release %s", name)
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
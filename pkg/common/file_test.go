@@ -0,0 +1,53 @@
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "cache", "action.lock")
+	unlock, err := LockFile(context.Background(), name)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, err = LockFile(ctx, name)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := LockFile(context.Background(), name)
+		if assert.NoError(t, err) {
+			unlock()
+		}
+		close(locked)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lock wasn't released")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "entry.json")
+	require.NoError(t, os.WriteFile(name, []byte("old"), 0o600))
+	require.NoError(t, WriteFileAtomic(name, []byte("new"), 0o644))
+
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left")
+}
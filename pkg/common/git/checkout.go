@@ -0,0 +1,176 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+var fullShaRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// NewGitCheckoutExecutorInput the input for the NewGitCheckoutExecutor
+type NewGitCheckoutExecutorInput struct {
+	URL           string
+	Ref           string // branch, tag, fully qualified ref or commit sha, empty for the default branch
+	Dir           string
+	Depth         int    // number of commits to fetch, 0 fetches the whole history
+	Submodules    string // true checks out the submodules, recursive also their submodules
+	FetchTags     bool
+	Token         string
+	SSHKey        string // private key for ssh URLs, the ssh agent is used if it is empty
+	SSHKnownHosts string // known hosts of ssh URLs in addition to ~/.ssh/known_hosts
+	SSHStrict     bool   // verify the host keys of ssh URLs
+}
+
+// checkoutRefNames returns the refs a branch or tag name of a checkout may refer to
+func checkoutRefNames(ref string) []plumbing.ReferenceName {
+	if strings.HasPrefix(ref, "refs/") {
+		return []plumbing.ReferenceName{plumbing.ReferenceName(ref)}
+	}
+	return []plumbing.ReferenceName{plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)}
+}
+
+func checkoutAuth(input NewGitCheckoutExecutorInput) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(input.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch endpoint.Protocol {
+	case "ssh":
+		if input.SSHKey == "" {
+			return nil, nil
+		}
+		auth, err := gitssh.NewPublicKeys(endpoint.User, []byte(input.SSHKey), "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh key: %w", err)
+		}
+		if !input.SSHStrict {
+			//nolint:gosec // ssh-strict: false of actions/checkout
+			auth.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		} else if input.SSHKnownHosts != "" {
+			knownHosts, err := os.CreateTemp("", "act-known-hosts")
+			if err != nil {
+				return nil, err
+			}
+			defer os.Remove(knownHosts.Name())
+			defer knownHosts.Close()
+			if _, err := knownHosts.WriteString(input.SSHKnownHosts + "\n"); err != nil {
+				return nil, err
+			}
+			files := []string{knownHosts.Name()}
+			if home, err := os.UserHomeDir(); err == nil {
+				if _, err := os.Stat(filepath.Join(home, ".ssh", "known_hosts")); err == nil {
+					files = append(files, filepath.Join(home, ".ssh", "known_hosts"))
+				}
+			}
+			if auth.HostKeyCallback, err = gitssh.NewKnownHostsCallback(files...); err != nil {
+				return nil, err
+			}
+		}
+		return auth, nil
+	case "http", "https":
+		if input.Token == "" {
+			return nil, nil
+		}
+		return &http.BasicAuth{
+			Username: "token",
+			Password: input.Token,
+		}, nil
+	}
+	return nil, nil
+}
+
+// NewGitCheckoutExecutor creates an executor to check out a single ref of a repo into
+// an empty directory, like actions/checkout does
+func NewGitCheckoutExecutor(input NewGitCheckoutExecutorInput) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		logger.Infof("  \u2601  git checkout '%s' # ref=%s", input.URL, input.Ref)
+
+		auth, err := checkoutAuth(input)
+		if err != nil {
+			return err
+		}
+		options := &git.CloneOptions{
+			URL:   input.URL,
+			Auth:  auth,
+			Depth: input.Depth,
+			Tags:  git.NoTags,
+		}
+		if input.FetchTags {
+			options.Tags = git.AllTags
+		}
+
+		var r *git.Repository
+		isSha := fullShaRegex.MatchString(input.Ref)
+		if input.Ref == "" || isSha {
+			if isSha {
+				// a single commit can't be fetched, it needs the history of the default branch
+				options.Depth = 0
+			}
+			r, err = git.PlainCloneContext(ctx, input.Dir, false, options)
+		} else {
+			options.SingleBranch = true
+			for _, refName := range checkoutRefNames(input.Ref) {
+				options.ReferenceName = refName
+				r, err = git.PlainCloneContext(ctx, input.Dir, false, options)
+				if !errors.Is(err, git.NoMatchingRefSpecError{}) && !errors.Is(err, plumbing.ErrReferenceNotFound) {
+					break
+				}
+			}
+		}
+		if err != nil {
+			logger.Errorf("Unable to check out %s %s: %v", input.URL, input.Ref, err)
+			return err
+		}
+
+		w, err := r.Worktree()
+		if err != nil {
+			return err
+		}
+		if isSha {
+			if err = w.Checkout(&git.CheckoutOptions{
+				Hash:  plumbing.NewHash(input.Ref),
+				Force: true,
+			}); err != nil {
+				logger.Errorf("Unable to checkout %s: %v", input.Ref, err)
+				return err
+			}
+		}
+
+		if input.Submodules == "true" || input.Submodules == "recursive" {
+			submodules, err := w.Submodules()
+			if err != nil {
+				return err
+			}
+			recurse := git.NoRecurseSubmodules
+			if input.Submodules == "recursive" {
+				recurse = git.DefaultSubmoduleRecursionDepth
+			}
+			if err = submodules.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+				Init:              true,
+				RecurseSubmodules: recurse,
+				Auth:              auth,
+			}); err != nil {
+				logger.Errorf("Unable to update the submodules of %s: %v", input.URL, err)
+				return err
+			}
+		}
+
+		logger.Debugf("Checked out %s %s to %s", input.URL, input.Ref, input.Dir)
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitCheckoutExecutor(t *testing.T) {
+	basedir := testDir(t)
+	commit := func(dir string, file string, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0o600))
+		require.NoError(t, gitCmd("-C", dir, "add", file))
+		require.NoError(t, gitCmd("-C", dir, "-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "-m", content))
+	}
+
+	submodule := filepath.Join(basedir, "submodule")
+	require.NoError(t, os.MkdirAll(submodule, 0o755))
+	require.NoError(t, gitCmd("-C", submodule, "init", "--initial-branch=master"))
+	commit(submodule, "sub.txt", "sub")
+
+	origin := filepath.Join(basedir, "origin")
+	require.NoError(t, os.MkdirAll(origin, 0o755))
+	require.NoError(t, gitCmd("-C", origin, "init", "--initial-branch=master"))
+	require.NoError(t, cleanGitHooks(origin))
+	commit(origin, "file.txt", "first")
+	require.NoError(t, gitCmd("-C", origin, "tag", "v1"))
+	require.NoError(t, gitCmd("-C", origin, "-c", "protocol.file.allow=always", "submodule", "--quiet", "add", submodule, "sub"))
+	commit(origin, "file.txt", "second")
+	require.NoError(t, gitCmd("-C", origin, "checkout", "--quiet", "-b", "feature"))
+	commit(origin, "file.txt", "feature")
+	require.NoError(t, gitCmd("-C", origin, "checkout", "--quiet", "master"))
+
+	repo, err := git.PlainOpen(origin)
+	require.NoError(t, err)
+	tag, err := repo.ResolveRevision("v1")
+	require.NoError(t, err)
+
+	for name, tt := range map[string]struct {
+		Input   NewGitCheckoutExecutorInput
+		Content string
+		Sub     bool
+		Err     bool
+	}{
+		"default-branch": {Input: NewGitCheckoutExecutorInput{Depth: 1}, Content: "second"},
+		"branch":         {Input: NewGitCheckoutExecutorInput{Ref: "feature", Depth: 1}, Content: "feature"},
+		"qualified-ref":  {Input: NewGitCheckoutExecutorInput{Ref: "refs/heads/feature"}, Content: "feature"},
+		"tag":            {Input: NewGitCheckoutExecutorInput{Ref: "v1", Depth: 1}, Content: "first"},
+		"sha":            {Input: NewGitCheckoutExecutorInput{Ref: tag.String(), Depth: 1}, Content: "first"},
+		"submodules":     {Input: NewGitCheckoutExecutorInput{Submodules: "true"}, Content: "second", Sub: true},
+		"missing-ref":    {Input: NewGitCheckoutExecutorInput{Ref: "missing"}, Err: true},
+	} {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			tt.Input.URL = origin
+			tt.Input.Dir = filepath.Join(basedir, "checkout-"+name)
+			require.NoError(t, os.MkdirAll(tt.Input.Dir, 0o755))
+
+			err := NewGitCheckoutExecutor(tt.Input)(context.Background())
+			if tt.Err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(filepath.Join(tt.Input.Dir, "file.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.Content, strings.TrimSpace(string(content)))
+
+			_, err = os.Stat(filepath.Join(tt.Input.Dir, "sub", "sub.txt"))
+			assert.Equal(t, tt.Sub, err == nil)
+		})
+	}
+}
@@ -7,15 +7,20 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
 	"github.com/mattn/go-isatty"
 	log "github.com/sirupsen/logrus"
 
@@ -28,7 +33,9 @@ var (
 	githubHTTPRegex     = regexp.MustCompile(`^https?://.*github.com.*/(.+)/(.+?)(?:.git)?$`)
 	githubSSHRegex      = regexp.MustCompile(`github.com[:/](.+)/(.+?)(?:.git)?$`)
 
-	cloneLock sync.Mutex
+	// the clones into a directory run one after the other, the ones into different directories at
+	// the same time
+	cloneLocks sync.Map
 
 	ErrShortRef = errors.New("short SHA references are not supported")
 	ErrNoRepo   = errors.New("unable to find git repo")
@@ -53,6 +60,14 @@ func (e *Error) Commit() string {
 
 // FindGitRevision get the current git revision
 func FindGitRevision(ctx context.Context, file string) (shortSha string, sha string, err error) {
+	values, err := cachedLookup(ctx, func() ([]string, error) {
+		shortSha, sha, err := findGitRevision(ctx, file)
+		return []string{shortSha, sha}, err
+	}, "revision", file)
+	return values[0], values[1], err
+}
+
+func findGitRevision(ctx context.Context, file string) (shortSha string, sha string, err error) {
 	logger := common.Logger(ctx)
 
 	gitDir, err := git.PlainOpenWithOptions(
@@ -85,6 +100,14 @@ func FindGitRevision(ctx context.Context, file string) (shortSha string, sha str
 
 // FindGitRef get the current git ref
 func FindGitRef(ctx context.Context, file string) (string, error) {
+	values, err := cachedLookup(ctx, func() ([]string, error) {
+		ref, err := findGitRef(ctx, file)
+		return []string{ref}, err
+	}, "ref", file)
+	return values[0], err
+}
+
+func findGitRef(ctx context.Context, file string) (string, error) {
 	logger := common.Logger(ctx)
 
 	logger.Debugf("Loading revision from git directory")
@@ -166,12 +189,15 @@ func FindGithubRepo(ctx context.Context, file, githubInstance, remoteName string
 		remoteName = "origin"
 	}
 
-	url, err := findGitRemoteURL(ctx, file, remoteName)
-	if err != nil {
-		return "", err
-	}
-	_, slug, err := findGitSlug(url, githubInstance)
-	return slug, err
+	values, err := cachedLookup(ctx, func() ([]string, error) {
+		url, err := findGitRemoteURL(ctx, file, remoteName)
+		if err != nil {
+			return []string{""}, err
+		}
+		_, slug, err := findGitSlug(url, githubInstance)
+		return []string{slug}, err
+	}, "repo", file, githubInstance, remoteName)
+	return values[0], err
 }
 
 func findGitRemoteURL(ctx context.Context, file, remoteName string) (string, error) {
@@ -208,8 +234,10 @@ func findGitSlug(url string, githubInstance string) (string, string, error) {
 	} else if matches := githubSSHRegex.FindStringSubmatch(url); matches != nil {
 		return "GitHub", fmt.Sprintf("%s/%s", matches[1], matches[2]), nil
 	} else if githubInstance != "github.com" {
-		gheHTTPRegex := regexp.MustCompile(fmt.Sprintf(`^https?://%s/(.+)/(.+?)(?:.git)?$`, githubInstance))
-		gheSSHRegex := regexp.MustCompile(fmt.Sprintf(`%s[:/](.+)/(.+?)(?:.git)?$`, githubInstance))
+		// ssh remotes don't contain the port of the web interface
+		sshHost := strings.SplitN(githubInstance, ":", 2)[0]
+		gheHTTPRegex := regexp.MustCompile(fmt.Sprintf(`^https?://%s/(.+)/(.+?)(?:.git)?$`, regexp.QuoteMeta(githubInstance)))
+		gheSSHRegex := regexp.MustCompile(fmt.Sprintf(`%s[:/](.+)/(.+?)(?:.git)?$`, regexp.QuoteMeta(sshHost)))
 		if matches := gheHTTPRegex.FindStringSubmatch(url); matches != nil {
 			return "GitHubEnterprise", fmt.Sprintf("%s/%s", matches[1], matches[2]), nil
 		} else if matches := gheSSHRegex.FindStringSubmatch(url); matches != nil {
@@ -221,15 +249,19 @@ func findGitSlug(url string, githubInstance string) (string, string, error) {
 
 // NewGitCloneExecutorInput the input for the NewGitCloneExecutor
 type NewGitCloneExecutorInput struct {
-	URL   string
-	Ref   string
-	Dir   string
-	Token string
+	URL         string
+	Ref         string
+	Dir         string
+	Token       string
+	OfflineMode bool // use only the repo already cloned to Dir, never fetch
 }
 
 // CloneIfRequired ...
 func CloneIfRequired(ctx context.Context, refName plumbing.ReferenceName, input NewGitCloneExecutorInput, logger log.FieldLogger) (*git.Repository, error) {
 	r, err := git.PlainOpen(input.Dir)
+	if err != nil && input.OfflineMode {
+		return nil, fmt.Errorf("%s is not in the action cache and can't be cloned in offline mode", input.URL)
+	}
 	if err != nil {
 		var progressWriter io.Writer
 		if isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()) {
@@ -284,17 +316,35 @@ func gitOptions(token string) (fetchOptions git.FetchOptions, pullOptions git.Pu
 	return fetchOptions, pullOptions
 }
 
+// cloneLock returns the lock of the clones into the directory
+func cloneLock(dir string) *sync.Mutex {
+	lock, _ := cloneLocks.LoadOrStore(filepath.Clean(dir), &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
 // NewGitCloneExecutor creates an executor to clone git repos
 //
 //nolint:gocyclo
 func NewGitCloneExecutor(input NewGitCloneExecutorInput) common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
-		logger.Infof("  \u2601  git clone '%s' # ref=%s", input.URL, input.Ref)
+		if input.OfflineMode {
+			logger.Infof("  \u2601  using cached '%s' # ref=%s", input.URL, input.Ref)
+		} else {
+			logger.Infof("  \u2601  git clone '%s' # ref=%s", input.URL, input.Ref)
+		}
 		logger.Debugf("  cloning %s to %s", input.URL, input.Dir)
 
-		cloneLock.Lock()
-		defer cloneLock.Unlock()
+		lock := cloneLock(input.Dir)
+		lock.Lock()
+		defer lock.Unlock()
+
+		// the act processes running at the same time share the action cache
+		unlock, err := common.LockFile(ctx, filepath.Clean(input.Dir)+".lock")
+		if err != nil {
+			return err
+		}
+		defer unlock()
 
 		refName := plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", input.Ref))
 		r, err := CloneIfRequired(ctx, refName, input, logger)
@@ -305,9 +355,11 @@ func NewGitCloneExecutor(input NewGitCloneExecutorInput) common.Executor {
 		// fetch latest changes
 		fetchOptions, pullOptions := gitOptions(input.Token)
 
-		err = r.Fetch(&fetchOptions)
-		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
-			return err
+		if !input.OfflineMode {
+			err = r.Fetch(&fetchOptions)
+			if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+				return err
+			}
 		}
 
 		var hash *plumbing.Hash
@@ -368,7 +420,9 @@ func NewGitCloneExecutor(input NewGitCloneExecutorInput) common.Executor {
 			}
 		}
 
-		if err = w.Pull(&pullOptions); err != nil && err != git.NoErrAlreadyUpToDate {
+		if input.OfflineMode {
+			logger.Debugf("Not pulling %s in offline mode", refName)
+		} else if err = w.Pull(&pullOptions); err != nil && err != git.NoErrAlreadyUpToDate {
 			logger.Debugf("Unable to pull %s: %v", refName, err)
 		}
 		logger.Debugf("Cloned %s to %s", input.URL, input.Dir)
@@ -400,3 +454,295 @@ func NewGitCloneExecutor(input NewGitCloneExecutorInput) common.Executor {
 		return nil
 	}
 }
+
+// NewGitShallowCloneExecutor creates an executor to clone only the commit of input.Ref, a branch
+// or a tag, into input.Dir. The default branch is cloned without a ref, and a commit sha, which
+// can't be cloned shallowly, with the history of the repository.
+func NewGitShallowCloneExecutor(input NewGitCloneExecutorInput) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		logger.Infof("  \u2601  git clone --depth 1 '%s' # ref=%s", input.URL, input.Ref)
+
+		cloneOptions := git.CloneOptions{
+			URL:          input.URL,
+			Depth:        1,
+			SingleBranch: input.Ref != "",
+		}
+		if input.Token != "" {
+			cloneOptions.Auth = &http.BasicAuth{
+				Username: "token",
+				Password: input.Token,
+			}
+		}
+
+		refNames := []plumbing.ReferenceName{""}
+		if input.Ref != "" {
+			refNames = []plumbing.ReferenceName{plumbing.NewBranchReferenceName(input.Ref), plumbing.NewTagReferenceName(input.Ref)}
+		}
+		for _, refName := range refNames {
+			cloneOptions.ReferenceName = refName
+			_, err := git.PlainCloneContext(ctx, input.Dir, false, &cloneOptions)
+			if err == nil {
+				return nil
+			}
+			if input.Ref == "" || (!errors.Is(err, git.NoMatchingRefSpecError{}) && !errors.Is(err, plumbing.ErrReferenceNotFound)) {
+				return err
+			}
+			logger.Debugf("Unable to clone %s: %v", refName, err)
+			if err := os.RemoveAll(input.Dir); err != nil {
+				return err
+			}
+		}
+
+		logger.Debugf("%s is neither a branch nor a tag, cloning the history to check it out", input.Ref)
+		return NewGitCloneExecutor(input)(ctx)
+	}
+}
+
+// ResolveRevision returns the sha of the commit of rev, e.g. a branch, a tag or a short sha, in the
+// repository of dir
+func ResolveRevision(dir string, rev string) (string, error) {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", err
+	}
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %s: %w", rev, err)
+	}
+	return hash.String(), nil
+}
+
+// FindDefaultBranch returns the default branch of the remote of the repository of dir, which is
+// the branch its HEAD points to since the clone, an empty string if it's unknown
+func FindDefaultBranch(dir string, remoteName string) string {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return ""
+	}
+	ref, err := r.Reference(plumbing.NewRemoteHEADReferenceName(remoteName), false)
+	if err != nil || ref.Type() != plumbing.SymbolicReference {
+		return ""
+	}
+	return strings.TrimPrefix(ref.Target().String(), "refs/remotes/"+remoteName+"/")
+}
+
+// ChangedFiles returns the files changed since ref in the repository of dir, which are the files
+// changed from the merge base of ref and HEAD, like the files of a pull request, and the
+// uncommitted ones. The paths are relative to the repository, like the paths filters of workflows.
+func ChangedFiles(ctx context.Context, dir string, ref string) ([]string, error) {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+	hash, err := r.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %s: %w", ref, err)
+	}
+	from, err := r.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	bases, err := from.MergeBase(to)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("%s has no common history with HEAD", ref)
+	}
+
+	fromTree, err := bases[0].Tree()
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]bool{}
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" {
+				changed[name] = true
+			}
+		}
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return nil, err
+	}
+	for name, s := range status {
+		if s.Worktree != git.Unmodified || s.Staging != git.Unmodified {
+			changed[name] = true
+		}
+	}
+
+	files := make([]string, 0, len(changed))
+	for name := range changed {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// maxPushCommits is the most commits the payload of a push event lists
+const maxPushCommits = 2048
+
+// Commit is a commit of a push, with the files it added, removed and modified
+type Commit struct {
+	ID             string
+	TreeID         string
+	Message        string
+	Timestamp      time.Time
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
+	Added          []string
+	Removed        []string
+	Modified       []string
+}
+
+// Push is a push from the commit Before to the commit After
+type Push struct {
+	Before  string
+	After   string
+	Forced  bool     // Before isn't an ancestor of After, the push rewrote the history
+	Commits []Commit // reachable from After but not from Before, the oldest first
+	Head    Commit   // the commit After
+}
+
+// FindPush returns the push from before to after in the repository of dir, with the commits of
+// `git log before..after`
+func FindPush(ctx context.Context, dir string, before string, after string) (*Push, error) {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+	resolve := func(rev string) (*object.Commit, error) {
+		hash, err := r.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve %s: %w", rev, err)
+		}
+		return r.CommitObject(*hash)
+	}
+	from, err := resolve(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolve(after)
+	if err != nil {
+		return nil, err
+	}
+	ancestor, err := from.IsAncestor(to)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[plumbing.Hash]bool{}
+	if err := object.NewCommitPreorderIter(from, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	var commits []*object.Commit
+	if err := object.NewCommitPreorderIter(to, seen, nil).ForEach(func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	// the iterator visits the commits before their parents
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	if len(commits) > maxPushCommits {
+		commits = commits[len(commits)-maxPushCommits:]
+	}
+
+	push := &Push{
+		Before: from.Hash.String(),
+		After:  to.Hash.String(),
+		Forced: !ancestor,
+	}
+	for _, c := range commits {
+		commit, err := newCommit(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		push.Commits = append(push.Commits, commit)
+	}
+	if push.Head, err = newCommit(ctx, to); err != nil {
+		return nil, err
+	}
+	return push, nil
+}
+
+func newCommit(ctx context.Context, c *object.Commit) (Commit, error) {
+	commit := Commit{
+		ID:             c.Hash.String(),
+		TreeID:         c.TreeHash.String(),
+		Message:        c.Message,
+		Timestamp:      c.Committer.When,
+		AuthorName:     c.Author.Name,
+		AuthorEmail:    c.Author.Email,
+		CommitterName:  c.Committer.Name,
+		CommitterEmail: c.Committer.Email,
+		Added:          []string{},
+		Removed:        []string{},
+		Modified:       []string{},
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return commit, err
+	}
+	// the files of a merge are the ones it changed from its first parent, like on GitHub
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return commit, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return commit, err
+		}
+	}
+	changes, err := object.DiffTreeWithOptions(ctx, parentTree, tree, nil)
+	if err != nil {
+		return commit, err
+	}
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return commit, err
+		}
+		switch action {
+		case merkletrie.Insert:
+			commit.Added = append(commit.Added, change.To.Name)
+		case merkletrie.Delete:
+			commit.Removed = append(commit.Removed, change.From.Name)
+		default:
+			commit.Modified = append(commit.Modified, change.To.Name)
+		}
+	}
+	return commit, nil
+}
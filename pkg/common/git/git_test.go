@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
 
@@ -43,6 +44,24 @@ func TestFindGitSlug(t *testing.T) {
 	}
 }
 
+func TestFindGitSlugEnterprise(t *testing.T) {
+	for _, tt := range []struct {
+		url      string
+		instance string
+		slug     string
+	}{
+		{"https://ghe.example.com/org/repo.git", "ghe.example.com", "org/repo"},
+		{"git@ghe.example.com:org/repo.git", "ghe.example.com", "org/repo"},
+		{"http://ghe.example.com:8080/org/repo.git", "ghe.example.com:8080", "org/repo"},
+		{"git@ghe.example.com:org/repo.git", "ghe.example.com:8080", "org/repo"},
+		{"https://gheXexample.com/org/repo.git", "ghe.example.com", "https://gheXexample.com/org/repo.git"},
+	} {
+		_, slug, err := findGitSlug(tt.url, tt.instance)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.slug, slug, tt.url)
+	}
+}
+
 func testDir(t *testing.T) string {
 	basedir, err := os.MkdirTemp("", "act-test")
 	require.NoError(t, err)
@@ -221,6 +240,182 @@ func TestGitCloneExecutor(t *testing.T) {
 	}
 }
 
+func TestGitCloneExecutorOfflineMode(t *testing.T) {
+	basedir := testDir(t)
+	gitConfig()
+
+	origin := filepath.Join(basedir, "origin")
+	require.NoError(t, os.MkdirAll(origin, 0o755))
+	require.NoError(t, gitCmd("-C", origin, "init", "--initial-branch=master"))
+	require.NoError(t, cleanGitHooks(origin))
+	require.NoError(t, gitCmd("-C", origin, "commit", "--allow-empty", "-m", "msg"))
+	require.NoError(t, gitCmd("-C", origin, "tag", "v1"))
+
+	cached := filepath.Join(basedir, "cached")
+	require.NoError(t, gitCmd("clone", origin, cached))
+
+	t.Run("cached", func(t *testing.T) {
+		clone := NewGitCloneExecutor(NewGitCloneExecutorInput{
+			URL:         "https://github.invalid/actions/offline",
+			Ref:         "v1",
+			Dir:         cached,
+			OfflineMode: true,
+		})
+		assert.NoError(t, clone(context.Background()))
+	})
+
+	t.Run("not-cached", func(t *testing.T) {
+		clone := NewGitCloneExecutor(NewGitCloneExecutorInput{
+			URL:         origin,
+			Ref:         "v1",
+			Dir:         filepath.Join(basedir, "missing"),
+			OfflineMode: true,
+		})
+		err := clone(context.Background())
+		assert.ErrorContains(t, err, "can't be cloned in offline mode")
+		assert.NoDirExists(t, filepath.Join(basedir, "missing"))
+	})
+}
+
+func TestGitShallowCloneExecutor(t *testing.T) {
+	basedir := testDir(t)
+	gitConfig()
+
+	origin := filepath.Join(basedir, "origin")
+	require.NoError(t, os.MkdirAll(origin, 0o755))
+	require.NoError(t, gitCmd("-C", origin, "init", "--initial-branch=master"))
+	require.NoError(t, cleanGitHooks(origin))
+	require.NoError(t, gitCmd("-C", origin, "commit", "--allow-empty", "-m", "first"))
+	sha, err := exec.Command("git", "-C", origin, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+	require.NoError(t, gitCmd("-C", origin, "tag", "v1"))
+	require.NoError(t, gitCmd("-C", origin, "checkout", "-b", "feature"))
+	require.NoError(t, gitCmd("-C", origin, "commit", "--allow-empty", "-m", "feature"))
+	require.NoError(t, gitCmd("-C", origin, "checkout", "master"))
+
+	for name, tt := range map[string]struct {
+		Ref     string
+		Message string
+	}{
+		"default": {"", "first"},
+		"branch":  {"feature", "feature"},
+		"tag":     {"v1", "first"},
+		"sha":     {strings.TrimSpace(string(sha)), "first"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(basedir, name)
+			clone := NewGitShallowCloneExecutor(NewGitCloneExecutorInput{
+				URL: "file://" + filepath.ToSlash(origin),
+				Ref: tt.Ref,
+				Dir: dir,
+			})
+			require.NoError(t, clone(context.Background()))
+
+			message, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%s").Output()
+			require.NoError(t, err)
+			assert.Equal(t, tt.Message, strings.TrimSpace(string(message)))
+		})
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := testDir(t)
+	gitConfig()
+
+	require.NoError(t, gitCmd("-C", dir, "init", "--initial-branch=master"))
+	require.NoError(t, cleanGitHooks(dir))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o600))
+	require.NoError(t, gitCmd("-C", dir, "add", "README.md"))
+	require.NoError(t, gitCmd("-C", dir, "commit", "-m", "first"))
+
+	require.NoError(t, gitCmd("-C", dir, "checkout", "-b", "feature"))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main"), 0o600))
+	require.NoError(t, gitCmd("-C", dir, "add", "src/main.go"))
+	require.NoError(t, gitCmd("-C", dir, "commit", "-m", "feature"))
+
+	// changes of master after the merge base aren't changes of the branch
+	require.NoError(t, gitCmd("-C", dir, "checkout", "master"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "master.txt"), []byte("master"), 0o600))
+	require.NoError(t, gitCmd("-C", dir, "add", "master.txt"))
+	require.NoError(t, gitCmd("-C", dir, "commit", "-m", "master"))
+	require.NoError(t, gitCmd("-C", dir, "checkout", "feature"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed"), 0o600))
+
+	files, err := ChangedFiles(context.Background(), filepath.Join(dir, "src"), "master")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "src/main.go"}, files)
+
+	_, err = ChangedFiles(context.Background(), dir, "missing")
+	assert.ErrorContains(t, err, "unable to resolve missing")
+}
+
+func TestResolveRevision(t *testing.T) {
+	dir := testDir(t)
+	gitConfig()
+
+	require.NoError(t, gitCmd("-C", dir, "init", "--initial-branch=master"))
+	require.NoError(t, cleanGitHooks(dir))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o600))
+	require.NoError(t, gitCmd("-C", dir, "add", "README.md"))
+	require.NoError(t, gitCmd("-C", dir, "commit", "-m", "first"))
+	require.NoError(t, gitCmd("-C", dir, "checkout", "-b", "feature"))
+	require.NoError(t, gitCmd("-C", dir, "commit", "--allow-empty", "-m", "feature"))
+
+	_, head, err := FindGitRevision(context.Background(), dir)
+	require.NoError(t, err)
+	sha, err := ResolveRevision(dir, "feature")
+	require.NoError(t, err)
+	assert.Equal(t, head, sha)
+	master, err := ResolveRevision(dir, "master")
+	require.NoError(t, err)
+	assert.NotEqual(t, head, master)
+
+	_, err = ResolveRevision(dir, "missing")
+	assert.ErrorContains(t, err, "unable to resolve missing")
+}
+
+func TestFindPush(t *testing.T) {
+	dir := testDir(t)
+	gitConfig()
+
+	require.NoError(t, gitCmd("-C", dir, "init", "--initial-branch=master"))
+	require.NoError(t, cleanGitHooks(dir))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old.txt"), []byte("old"), 0o600))
+	require.NoError(t, gitCmd("-C", dir, "add", "."))
+	require.NoError(t, gitCmd("-C", dir, "commit", "-m", "first"))
+	require.NoError(t, gitCmd("-C", dir, "tag", "v1"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0o600))
+	require.NoError(t, gitCmd("-C", dir, "add", "."))
+	require.NoError(t, gitCmd("-C", dir, "commit", "-m", "feat: second"))
+	require.NoError(t, gitCmd("-C", dir, "rm", "-q", "old.txt"))
+	require.NoError(t, gitCmd("-C", dir, "commit", "-m", "fix: third"))
+
+	push, err := FindPush(context.Background(), dir, "v1", "master")
+	require.NoError(t, err)
+	assert.False(t, push.Forced)
+	require.Len(t, push.Commits, 2)
+	assert.Equal(t, "feat: second\n", push.Commits[0].Message)
+	assert.Equal(t, []string{"new.txt"}, push.Commits[0].Added)
+	assert.Equal(t, []string{"README.md"}, push.Commits[0].Modified)
+	assert.Equal(t, []string{"old.txt"}, push.Commits[1].Removed)
+	assert.Equal(t, push.After, push.Commits[1].ID)
+	assert.Equal(t, push.After, push.Head.ID)
+
+	// a push of an older commit rewrites the history
+	push, err = FindPush(context.Background(), dir, "master", "v1")
+	require.NoError(t, err)
+	assert.True(t, push.Forced)
+	assert.Empty(t, push.Commits)
+
+	_, err = FindPush(context.Background(), dir, "missing", "master")
+	assert.ErrorContains(t, err, "unable to resolve missing")
+}
+
 func gitConfig() {
 	if os.Getenv("GITHUB_ACTIONS") == "true" {
 		var err error
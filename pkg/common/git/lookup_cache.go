@@ -0,0 +1,50 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type lookupCacheKey struct{}
+
+type lookupCache struct {
+	mu      sync.Mutex
+	results map[string]*lookupResult
+}
+
+type lookupResult struct {
+	once   sync.Once
+	values []string
+	err    error
+}
+
+// WithLookupCache returns a context in which FindGitRevision, FindGitRef and FindGithubRepo look
+// into each repository once, e.g. for all the legs of the matrices of a run, which see the
+// repository like it was when the run started
+func WithLookupCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(lookupCacheKey{}).(*lookupCache); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, lookupCacheKey{}, &lookupCache{results: map[string]*lookupResult{}})
+}
+
+// cachedLookup returns the result of the lookup of the key in the cache of the context, if it has one
+func cachedLookup(ctx context.Context, lookup func() ([]string, error), key ...string) ([]string, error) {
+	cache, ok := ctx.Value(lookupCacheKey{}).(*lookupCache)
+	if !ok {
+		return lookup()
+	}
+	k := strings.Join(key, "\x00")
+	cache.mu.Lock()
+	result, ok := cache.results[k]
+	if !ok {
+		result = &lookupResult{}
+		cache.results[k] = result
+	}
+	cache.mu.Unlock()
+	result.once.Do(func() {
+		result.values, result.err = lookup()
+	})
+	return result.values, result.err
+}
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCache(t *testing.T) {
+	calls := 0
+	lookup := func() ([]string, error) {
+		calls++
+		return []string{"sha"}, nil
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		values, err := cachedLookup(ctx, lookup, "revision", "repo")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"sha"}, values)
+	}
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	ctx = WithLookupCache(ctx)
+	assert.Equal(t, ctx, WithLookupCache(ctx))
+	for i := 0; i < 2; i++ {
+		values, err := cachedLookup(ctx, lookup, "revision", "repo")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"sha"}, values)
+	}
+	assert.Equal(t, 1, calls)
+
+	_, err := cachedLookup(ctx, lookup, "revision", "other")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
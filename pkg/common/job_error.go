@@ -19,8 +19,12 @@ func JobError(ctx context.Context) error {
 	return nil
 }
 
+// SetJobError sets the job error of the current context, a context without a container for it
+// keeps no error
 func SetJobError(ctx context.Context, err error) {
-	ctx.Value(jobErrorContextKeyVal).(map[string]error)["error"] = err
+	if container, ok := ctx.Value(jobErrorContextKeyVal).(map[string]error); ok {
+		container["error"] = err
+	}
 }
 
 // WithJobErrorContainer adds a value to the context as a container for an error
@@ -5,6 +5,11 @@ import (
 	"io"
 )
 
+// MaxLineLength is the length after which the line writer handles a line which has no newline yet,
+// e.g. the output of a progress bar or a minified file, so its buffer stays bounded. The rest of
+// the line is handled as the next line.
+const MaxLineLength = 64 * 1024
+
 // LineHandler is a callback function for handling a line
 type LineHandler func(line string) bool
 
@@ -20,20 +25,36 @@ func NewLineWriter(handlers ...LineHandler) io.Writer {
 	return w
 }
 
+// Write handles the complete lines of p as they arrive, only a line without a newline yet is
+// buffered
 func (lw *lineWriter) Write(p []byte) (n int, err error) {
-	pBuf := bytes.NewBuffer(p)
-	written := 0
-	for {
-		line, err := pBuf.ReadString('\n')
-		w, _ := lw.buffer.WriteString(line)
-		written += w
-		if err == nil {
+	written := len(p)
+	for len(p) > 0 {
+		end := bytes.IndexByte(p, '\n') + 1
+		complete := end > 0
+		if !complete {
+			end = len(p)
+		}
+		if room := MaxLineLength - lw.buffer.Len(); end > room {
+			end = room
+			complete = false
+		}
+		chunk := p[:end]
+		p = p[end:]
+
+		switch {
+		case complete && lw.buffer.Len() == 0:
+			lw.handleLine(string(chunk))
+		case complete:
+			lw.buffer.Write(chunk)
 			lw.handleLine(lw.buffer.String())
 			lw.buffer.Reset()
-		} else if err == io.EOF {
-			break
-		} else {
-			return written, err
+		default:
+			lw.buffer.Write(chunk)
+			if lw.buffer.Len() >= MaxLineLength {
+				lw.handleLine(lw.buffer.String())
+				lw.buffer.Reset()
+			}
 		}
 	}
 
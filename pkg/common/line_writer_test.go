@@ -1,6 +1,7 @@
 package common
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,3 +36,20 @@ func TestLineWriter(t *testing.T) {
 	assert.Equal(" and another\n", lines[2])
 	assert.Equal("last line\n", lines[3])
 }
+
+func TestLineWriterLongLine(t *testing.T) {
+	lines := make([]string, 0)
+	w := NewLineWriter(func(s string) bool {
+		lines = append(lines, s)
+		return true
+	})
+
+	long := strings.Repeat("x", MaxLineLength)
+	_, err := w.Write([]byte(long[:10]))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte(long[10:] + "tail\nnext\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{long, "tail\n", "next\n"}, lines)
+	assert.Zero(t, w.(*lineWriter).buffer.Len())
+}
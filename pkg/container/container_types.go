@@ -3,6 +3,7 @@ package container
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/nektos/act/pkg/common"
 )
@@ -26,6 +27,44 @@ type NewContainerInput struct {
 	UsernsMode  string
 	Platform    string
 	Options     string
+
+	// Ports are published like "host:container" or "container" port specs of docker run
+	Ports          []string
+	NetworkAliases []string
+	// Labels identify the containers of a job, e.g. to attach to them after the run
+	Labels map[string]string
+	// VolumeLabels are the values of LabelManaged of the volumes of Mounts act creates by name,
+	// the other volumes, e.g. the ones of container.volumes, aren't labeled
+	VolumeLabels map[string]string
+	// StreamOutput attaches Stdout and Stderr to a container which is started without waiting for it
+	StreamOutput bool
+}
+
+// ContainerSummary is a container found by ListContainers
+type ContainerSummary struct {
+	Name   string
+	State  string // e.g. running or exited
+	Status string // e.g. Up 5 minutes
+	Labels map[string]string
+}
+
+// LabelManaged marks the containers, networks, volumes and images act creates, act prune finds
+// them by it
+const LabelManaged = "act.managed"
+
+// the values of LabelManaged
+const (
+	ManagedRun        = "true"       // the resources of a run, which act prune removes
+	ManagedCheckpoint = "checkpoint" // the images of --checkpoint, kept for a later run
+	ManagedPersistent = "persistent" // the volumes kept between the runs, e.g. the tool cache
+)
+
+// Resource is a container, network, volume or image act created, found by ListResources
+type Resource struct {
+	Kind    string // container, network, volume or image
+	Name    string
+	Created time.Time
+	Running bool // a running container, e.g. of a run in progress
 }
 
 // FileEntry is a file to copy to a container
@@ -49,6 +88,44 @@ type Container interface {
 	Remove() common.Executor
 	Close() common.Executor
 	ReplaceLogWriter(io.Writer, io.Writer) (io.Writer, io.Writer)
+	IsHealthy(ctx context.Context) (time.Duration, error)
+}
+
+// InteractiveContainer is implemented by the environments which can attach the terminal of act
+// to a command, e.g. to open a debug shell
+type InteractiveContainer interface {
+	ExecInteractive(command []string, env map[string]string, user, workdir string) common.Executor
+}
+
+// TrackedFilesContainer is implemented by the environments which can copy only the files of a
+// directory which are tracked by git
+type TrackedFilesContainer interface {
+	CopyTrackedDir(destPath string, srcPath string) common.Executor
+}
+
+// ContainerInfo is what the expressions of a job can know about one of its running containers
+type ContainerInfo struct {
+	ID    string            // full ID of the container
+	Ports map[string]string // host port of each published container port
+}
+
+// ResourceStats is the resource usage of a container at a point in time
+type ResourceStats struct {
+	Memory      uint64        // bytes of memory in use, without the inactive page cache
+	MemoryLimit uint64        // the memory limit of the container, the memory of the host without one
+	CPUTime     time.Duration // CPU time used by the container since it started
+}
+
+// StatsContainer is implemented by the environments which can report their resource usage
+type StatsContainer interface {
+	Stats(ctx context.Context) (*ResourceStats, error)
+}
+
+// CheckpointContainer is implemented by the environments which can save their filesystem as an
+// image to start from again later
+type CheckpointContainer interface {
+	// Commit saves the filesystem of the container as the image and returns the ID of the image
+	Commit(ctx context.Context, image string) (string, error)
 }
 
 // NewDockerBuildExecutorInput the input for the NewDockerBuildExecutor function
@@ -58,6 +135,28 @@ type NewDockerBuildExecutorInput struct {
 	Container  Container
 	ImageTag   string
 	Platform   string
+	BuildArgs  map[string]string
+	CacheFrom  []string
+	CacheTo    []string
+	BuildKit   bool
+	Secrets    map[string]string
+}
+
+// IsInlineCacheTo reports whether a cache export destination is the inline cache, the only one
+// the docker daemon exports, the others are exported by docker buildx
+func IsInlineCacheTo(cacheTo string) bool {
+	return cacheTo == "inline" || cacheTo == "type=inline"
+}
+
+// DaemonFeatures are the features of a docker daemon which depend on its version
+type DaemonFeatures struct {
+	APIVersion       string
+	ServerVersion    string
+	BuildKit         bool
+	Platform         bool
+	HostGateway      bool
+	CgroupNamespaces bool
+	CgroupV2         bool
 }
 
 // NewDockerPullExecutorInput the input for the NewDockerPullExecutor function
@@ -4,15 +4,23 @@ package container
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/pkg/archive"
 
 	// github.com/docker/docker/builder/dockerignore is deprecated
 	"github.com/moby/buildkit/frontend/dockerfile/dockerignore"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
 	"github.com/moby/patternmatcher"
 
 	"github.com/nektos/act/pkg/common"
@@ -27,9 +35,18 @@ func NewDockerBuildExecutor(input NewDockerBuildExecutorInput) common.Executor {
 		} else {
 			logger.Infof("%sdocker build -t %s %s", logPrefix, input.ImageTag, input.ContextDir)
 		}
+		// the Dockerfile of an action in the job container is only read by the build
+		if input.Container == nil && !input.BuildKit {
+			if err := checkDockerfile(input.ContextDir, input.Dockerfile); err != nil {
+				return err
+			}
+		}
 		if common.Dryrun(ctx) {
 			return nil
 		}
+		if input.BuildKit && !isInlineCacheTo(input.CacheTo) {
+			return buildxBuild(ctx, input)
+		}
 
 		cli, err := GetDockerClient(ctx)
 		if err != nil {
@@ -46,6 +63,28 @@ func NewDockerBuildExecutor(input NewDockerBuildExecutorInput) common.Executor {
 			Platform:    input.Platform,
 			AuthConfigs: LoadDockerAuthConfigs(ctx),
 			Dockerfile:  input.Dockerfile,
+			BuildArgs:   buildArgs(input),
+			CacheFrom:   input.CacheFrom,
+			Labels:      managedLabels(nil),
+		}
+		if input.BuildKit {
+			options.Version = types.BuilderBuildKit
+			// the secrets of RUN --mount=type=secret are requested from the session by BuildKit
+			sess, err := session.NewSession(ctx, "act", "")
+			if err != nil {
+				return err
+			}
+			sess.Allow(secretsprovider.FromMap(buildSecrets(input)))
+			dialSession := func(ctx context.Context, proto string, meta map[string][]string) (net.Conn, error) {
+				return cli.DialHijack(ctx, "/session", proto, meta)
+			}
+			go func() {
+				if err := sess.Run(ctx, dialSession); err != nil {
+					logger.Debugf("BuildKit session of %s: %v", input.ImageTag, err)
+				}
+			}()
+			defer sess.Close()
+			options.SessionID = sess.ID()
 		}
 		var buildContext io.ReadCloser
 		if input.Container != nil {
@@ -69,6 +108,130 @@ func NewDockerBuildExecutor(input NewDockerBuildExecutorInput) common.Executor {
 		return nil
 	}
 }
+
+// buildArgs converts the build args of the input into the form expected by the docker API, the
+// inline cache is exported by the daemon when BUILDKIT_INLINE_CACHE is set
+func buildArgs(input NewDockerBuildExecutorInput) map[string]*string {
+	args := make(map[string]*string, len(input.BuildArgs))
+	for k, v := range input.BuildArgs {
+		v := v
+		args[k] = &v
+	}
+	for _, cacheTo := range input.CacheTo {
+		if IsInlineCacheTo(cacheTo) {
+			inline := "1"
+			args["BUILDKIT_INLINE_CACHE"] = &inline
+		}
+	}
+	return args
+}
+
+// buildSecrets returns the secrets of the input by id, the id of a secret mount being the name of the secret
+func buildSecrets(input NewDockerBuildExecutorInput) map[string][]byte {
+	secrets := make(map[string][]byte, len(input.Secrets))
+	for k, v := range input.Secrets {
+		secrets[k] = []byte(v)
+	}
+	return secrets
+}
+
+// isInlineCacheTo reports whether the daemon can export all the caches, the other exporters
+// are only available to docker buildx
+func isInlineCacheTo(cacheTo []string) bool {
+	for _, c := range cacheTo {
+		if !IsInlineCacheTo(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildxSecretEnv is the prefix of the environment variables passing the secrets to docker buildx
+const buildxSecretEnv = "ACT_BUILD_SECRET_"
+
+// buildxArgs returns the arguments of docker buildx build for the input, the build context being
+// read from stdin for an action in the job container
+func buildxArgs(input NewDockerBuildExecutorInput) []string {
+	args := []string{"buildx", "build", "--load", "--tag", input.ImageTag}
+	contextDir, dockerfile := input.ContextDir, filepath.Join(input.ContextDir, input.Dockerfile)
+	if input.Container != nil {
+		contextDir, dockerfile = "-", input.Dockerfile
+	}
+	args = append(args, "--file", dockerfile)
+	if input.Platform != "" {
+		args = append(args, "--platform", input.Platform)
+	}
+	for _, k := range sortedKeys(input.BuildArgs) {
+		args = append(args, "--build-arg", k+"="+input.BuildArgs[k])
+	}
+	for k, v := range managedLabels(nil) {
+		args = append(args, "--label", k+"="+v)
+	}
+	for _, cacheFrom := range input.CacheFrom {
+		args = append(args, "--cache-from", cacheFrom)
+	}
+	for _, cacheTo := range input.CacheTo {
+		args = append(args, "--cache-to", cacheTo)
+	}
+	for _, k := range sortedKeys(input.Secrets) {
+		args = append(args, "--secret", fmt.Sprintf("id=%s,env=%s%s", k, buildxSecretEnv, k))
+	}
+	return append(args, contextDir)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// buildxBuild builds the image with docker buildx, which exports the registry and local caches the
+// docker API can't. The secrets are passed in the environment rather than on the command line.
+func buildxBuild(ctx context.Context, input NewDockerBuildExecutorInput) error {
+	logger := common.Logger(ctx)
+	args := buildxArgs(input)
+	logger.Debugf("docker %s", strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Env = os.Environ()
+	for k, v := range input.Secrets {
+		cmd.Env = append(cmd.Env, buildxSecretEnv+k+"="+v)
+	}
+	if input.Container != nil {
+		buildContext, err := input.Container.GetContainerArchive(ctx, input.ContextDir+"/.")
+		if err != nil {
+			return err
+		}
+		defer buildContext.Close()
+		cmd.Stdin = buildContext
+	}
+	output, err := cmd.CombinedOutput()
+	logger.Debugf("%s", output)
+	if err != nil {
+		return fmt.Errorf("unable to build %s with docker buildx for --cache-to: %w: %s", input.ImageTag, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// secretMountPattern matches the RUN instructions mounting a secret
+var secretMountPattern = regexp.MustCompile(`(?im)^\s*RUN\b.*--mount=(\S*,)?type=secret\b`)
+
+// checkDockerfile rejects the Dockerfiles mounting secrets when they are built by the legacy
+// builder, only BuildKit requests the secrets from act
+func checkDockerfile(contextDir, dockerfile string) error {
+	content, err := os.ReadFile(filepath.Join(contextDir, dockerfile))
+	if err != nil {
+		// the build reports it
+		return nil
+	}
+	if secretMountPattern.Match(content) {
+		return fmt.Errorf("unable to build %s: the secret mounts of RUN --mount=type=secret require BuildKit, run it without --no-buildkit", filepath.Join(contextDir, dockerfile))
+	}
+	return nil
+}
+
 func createBuildContext(ctx context.Context, contextDir string, relDockerfile string) (io.ReadCloser, error) {
 	common.Logger(ctx).Debugf("Creating archive for build context dir '%s' with relative dockerfile '%s'", contextDir, relDockerfile)
 
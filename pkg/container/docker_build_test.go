@@ -0,0 +1,78 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildArgs(t *testing.T) {
+	args := buildArgs(NewDockerBuildExecutorInput{
+		BuildArgs: map[string]string{"INPUT_VERSION": "1.2.3"},
+		CacheTo:   []string{"type=inline"},
+	})
+
+	assert.Len(t, args, 2)
+	assert.Equal(t, "1.2.3", *args["INPUT_VERSION"])
+	assert.Equal(t, "1", *args["BUILDKIT_INLINE_CACHE"])
+
+	assert.True(t, IsInlineCacheTo("inline"))
+	assert.False(t, IsInlineCacheTo("type=registry,ref=user/app:cache"))
+	assert.True(t, isInlineCacheTo(nil))
+	assert.False(t, isInlineCacheTo([]string{"type=inline", "type=local,dest=/tmp/cache"}))
+}
+
+func TestBuildSecrets(t *testing.T) {
+	assert.Equal(t, map[string][]byte{"TOKEN": []byte("s3cr3t")}, buildSecrets(NewDockerBuildExecutorInput{Secrets: map[string]string{"TOKEN": "s3cr3t"}}))
+}
+
+func TestBuildxArgs(t *testing.T) {
+	input := NewDockerBuildExecutorInput{
+		ContextDir: "/actions/hello",
+		Dockerfile: "Dockerfile",
+		ImageTag:   "act-hello-dockeraction:1234",
+		Platform:   "linux/amd64",
+		BuildArgs:  map[string]string{"INPUT_B": "2", "INPUT_A": "1"},
+		CacheFrom:  []string{"user/app:cache"},
+		CacheTo:    []string{"type=registry,ref=user/app:cache"},
+		Secrets:    map[string]string{"TOKEN": "s3cr3t"},
+	}
+	assert.Equal(t, []string{
+		"buildx", "build", "--load", "--tag", "act-hello-dockeraction:1234",
+		"--file", "/actions/hello/Dockerfile",
+		"--platform", "linux/amd64",
+		"--build-arg", "INPUT_A=1", "--build-arg", "INPUT_B=2",
+		"--label", LabelManaged + "=" + ManagedRun,
+		"--cache-from", "user/app:cache",
+		"--cache-to", "type=registry,ref=user/app:cache",
+		"--secret", "id=TOKEN,env=ACT_BUILD_SECRET_TOKEN",
+		"/actions/hello",
+	}, buildxArgs(input))
+	assert.NotContains(t, buildxArgs(input), "s3cr3t", "the secrets aren't passed on the command line")
+
+	input.Container = &containerReference{}
+	args := buildxArgs(input)
+	assert.Equal(t, "-", args[len(args)-1])
+	assert.Contains(t, args, "Dockerfile")
+}
+
+func TestCheckDockerfile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(content), 0o600))
+	}
+
+	write("FROM alpine\nRUN --mount=type=cache,target=/root/.cache apk add git\n")
+	assert.NoError(t, checkDockerfile(dir, "Dockerfile"))
+
+	write("FROM alpine\nRUN --mount=type=secret,id=token cat /run/secrets/token\n")
+	assert.ErrorContains(t, checkDockerfile(dir, "Dockerfile"), "require BuildKit")
+
+	write("FROM alpine\nrun --mount=id=token,type=secret cat /run/secrets/token\n")
+	assert.Error(t, checkDockerfile(dir, "Dockerfile"))
+
+	assert.NoError(t, checkDockerfile(dir, "missing"))
+}
@@ -0,0 +1,32 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows))
+
+package container
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// Commit saves the filesystem of the container as the image, the volumes and binds aren't part of it
+func (cr *containerReference) Commit(ctx context.Context, image string) (string, error) {
+	common.Logger(ctx).Debugf("%sdocker commit %s %s", logPrefix, cr.input.Name, image)
+	if common.Dryrun(ctx) {
+		return "", nil
+	}
+	if err := common.NewPipelineExecutor(cr.connect(), cr.find())(ctx); err != nil {
+		return "", err
+	}
+	resp, err := cr.cli.ContainerCommit(ctx, cr.id, types.ContainerCommitOptions{
+		Reference: image,
+		Pause:     true,
+		// a checkpoint is kept for a later run, act prune only removes the images of a run
+		Changes: []string{"LABEL " + LabelManaged + "=" + ManagedCheckpoint},
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
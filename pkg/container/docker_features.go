@@ -0,0 +1,45 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows))
+
+package container
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/versions"
+)
+
+// GetDaemonFeatures returns the features of the docker daemon act is connected to
+func GetDaemonFeatures(ctx context.Context) (DaemonFeatures, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return DaemonFeatures{}, err
+	}
+	defer cli.Close()
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return DaemonFeatures{}, err
+	}
+	return daemonFeatures(cli.ClientVersion(), info), nil
+}
+
+// daemonFeatures derives the supported features from the negotiated API version
+func daemonFeatures(apiVersion string, info types.Info) DaemonFeatures {
+	atLeast := func(v string) bool {
+		return !versions.LessThan(apiVersion, v)
+	}
+	return DaemonFeatures{
+		APIVersion:    apiVersion,
+		ServerVersion: info.ServerVersion,
+		// BuildKit is available since Docker 18.09 and only for linux daemons
+		BuildKit: atLeast("1.39") && info.OSType != "windows",
+		// the platform of a container can be selected since Docker 20.10
+		Platform: atLeast("1.41"),
+		// host-gateway in --add-host is available since Docker 20.10
+		HostGateway: atLeast("1.41") && info.OSType != "windows",
+		// --cgroupns is available since Docker 20.10
+		CgroupNamespaces: atLeast("1.41"),
+		CgroupV2:         info.CgroupVersion == "2",
+	}
+}
@@ -5,6 +5,7 @@ package container
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
@@ -33,6 +34,44 @@ func ImageExistsLocally(ctx context.Context, imageName string, platform string)
 	return false, nil
 }
 
+// ImagePlatform returns the os/architecture of an image in the local docker image store
+func ImagePlatform(ctx context.Context, imageName string) (string, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	inspectImage, _, err := cli.ImageInspectWithRaw(ctx, imageName)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", inspectImage.Os, inspectImage.Architecture), nil
+}
+
+// ImageDigest returns the digest the image was pulled with from its registry, or the id of an image
+// which was built locally
+func ImageDigest(ctx context.Context, imageName string) (string, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	inspectImage, _, err := cli.ImageInspectWithRaw(ctx, imageName)
+	if err != nil {
+		return "", err
+	}
+
+	for _, repoDigest := range inspectImage.RepoDigests {
+		if _, digest, ok := strings.Cut(repoDigest, "@"); ok {
+			return digest, nil
+		}
+	}
+	return inspectImage.ID, nil
+}
+
 // RemoveImage removes image from local store, the function is used to run different
 // container image architectures
 func RemoveImage(ctx context.Context, imageName string, force bool, pruneChildren bool) (bool, error) {
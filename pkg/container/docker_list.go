@@ -0,0 +1,66 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows))
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// ListContainers returns the containers, running or not, which have all the labels
+func ListContainers(ctx context.Context, labels map[string]string) ([]ContainerSummary, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	args := filters.NewArgs()
+	for key, value := range labels {
+		args.Add("label", fmt.Sprintf("%s=%s", key, value))
+	}
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: args,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	summaries := make([]ContainerSummary, 0, len(containers))
+	for _, c := range containers {
+		name := c.ID
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		summaries = append(summaries, ContainerSummary{
+			Name:   name,
+			State:  c.State,
+			Status: c.Status,
+			Labels: c.Labels,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
+
+// StartContainer starts a stopped container
+func StartContainer(ctx context.Context, name string) error {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	if err := cli.ContainerStart(ctx, name, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+	return nil
+}
@@ -58,6 +58,11 @@ func logDockerResponse(logger logrus.FieldLogger, dockerResponse io.ReadCloser,
 			return errors.New(msg.Error)
 		}
 
+		if msg.ID == "moby.buildkit.trace" {
+			// BuildKit progress is encoded as protobuf in the aux field
+			continue
+		}
+
 		if msg.Status != "" {
 			if msg.Progress != "" {
 				writeLog(logger, isError, "%s :: %s :: %s\n", msg.Status, msg.ID, msg.Progress)
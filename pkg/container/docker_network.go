@@ -0,0 +1,110 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows))
+
+package container
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/nektos/act/pkg/common"
+)
+
+// NewDockerNetworkCreateExecutor creates a bridge network, if it doesn't exist yet.
+// Containers in an internal network can only reach each other.
+func NewDockerNetworkCreateExecutor(name string, internal bool) common.Executor {
+	return func(ctx context.Context) error {
+		common.Logger(ctx).Debugf("%sdocker network create %s", logPrefix, name)
+		if common.Dryrun(ctx) {
+			return nil
+		}
+
+		cli, err := GetDockerClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		exists, err := networkExists(ctx, cli, name)
+		if err != nil || exists {
+			return err
+		}
+
+		_, err = cli.NetworkCreate(ctx, name, types.NetworkCreate{
+			CheckDuplicate: true,
+			Driver:         "bridge",
+			Scope:          "local",
+			Internal:       internal,
+			Labels:         managedLabels(nil),
+		})
+		return err
+	}
+}
+
+// NewDockerNetworkRemoveExecutor removes a network, if it exists
+func NewDockerNetworkRemoveExecutor(name string) common.Executor {
+	return func(ctx context.Context) error {
+		common.Logger(ctx).Debugf("%sdocker network rm %s", logPrefix, name)
+		if common.Dryrun(ctx) {
+			return nil
+		}
+
+		cli, err := GetDockerClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		exists, err := networkExists(ctx, cli, name)
+		if err != nil || !exists {
+			return err
+		}
+
+		return cli.NetworkRemove(ctx, name)
+	}
+}
+
+func networkExists(ctx context.Context, cli interface {
+	NetworkList(context.Context, types.NetworkListOptions) ([]types.NetworkResource, error)
+}, name string) (bool, error) {
+	list, err := cli.NetworkList(ctx, types.NetworkListOptions{Filters: filters.NewArgs(filters.Arg("name", name))})
+	if err != nil {
+		return false, err
+	}
+	for _, n := range list {
+		if n.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// InspectContainer returns the ID of a running container and the host ports its container ports
+// are published on
+func InspectContainer(ctx context.Context, name string) (*ContainerInfo, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	inspect, err := cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := map[string]string{}
+	info := &ContainerInfo{ID: inspect.ID, Ports: ports}
+	if inspect.NetworkSettings == nil {
+		return info, nil
+	}
+	for port, bindings := range inspect.NetworkSettings.Ports {
+		for _, binding := range bindings {
+			if binding.HostPort != "" {
+				ports[port.Port()] = binding.HostPort
+				break
+			}
+		}
+	}
+	return info, nil
+}
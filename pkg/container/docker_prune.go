@@ -0,0 +1,117 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows))
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// managedLabels returns the labels with LabelManaged added
+func managedLabels(labels map[string]string) map[string]string {
+	managed := map[string]string{LabelManaged: ManagedRun}
+	for key, value := range labels {
+		managed[key] = value
+	}
+	return managed
+}
+
+// ListResources returns the containers, networks, volumes and images act created before until,
+// in the order they can be removed in
+func ListResources(ctx context.Context, until time.Time) ([]Resource, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	args := filters.NewArgs(filters.Arg("label", LabelManaged+"="+ManagedRun))
+	var resources []Resource
+	add := func(kind string, name string, created time.Time, running bool) {
+		if created.Before(until) {
+			resources = append(resources, Resource{Kind: kind, Name: name, Created: created, Running: running})
+		}
+	}
+
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, c := range containers {
+		name := c.ID
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		add("container", name, time.Unix(c.Created, 0), c.State == "running")
+	}
+
+	networks, err := cli.NetworkList(ctx, types.NetworkListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, n := range networks {
+		add("network", n.Name, n.Created, false)
+	}
+
+	volumes, err := cli.VolumeList(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	for _, v := range volumes.Volumes {
+		created, err := time.Parse(time.RFC3339, v.CreatedAt)
+		if err != nil {
+			// a volume driver without the creation time
+			created = time.Time{}
+		}
+		add("volume", v.Name, created, false)
+	}
+
+	images, err := cli.ImageList(ctx, types.ImageListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	for _, i := range images {
+		name := i.ID
+		if len(i.RepoTags) > 0 && i.RepoTags[0] != "<none>:<none>" {
+			name = i.RepoTags[0]
+		}
+		add("image", name, time.Unix(i.Created, 0), false)
+	}
+
+	order := map[string]int{"container": 0, "network": 1, "volume": 2, "image": 3}
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Kind != resources[j].Kind {
+			return order[resources[i].Kind] < order[resources[j].Kind]
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	return resources, nil
+}
+
+// RemoveResource removes the container with its anonymous volumes, network, volume or image
+func RemoveResource(ctx context.Context, resource Resource) error {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	switch resource.Kind {
+	case "container":
+		return cli.ContainerRemove(ctx, resource.Name, types.ContainerRemoveOptions{RemoveVolumes: true, Force: true})
+	case "network":
+		return cli.NetworkRemove(ctx, resource.Name)
+	case "volume":
+		return cli.VolumeRemove(ctx, resource.Name, false)
+	case "image":
+		_, err := cli.ImageRemove(ctx, resource.Name, types.ImageRemoveOptions{PruneChildren: true})
+		return err
+	}
+	return fmt.Errorf("unknown kind of resource %s", resource.Kind)
+}
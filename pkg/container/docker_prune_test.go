@@ -0,0 +1,37 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagedLabels(t *testing.T) {
+	labels := map[string]string{"act.job": "build"}
+	assert.Equal(t, map[string]string{"act.job": "build", LabelManaged: "true"}, managedLabels(labels))
+	assert.Equal(t, map[string]string{"act.job": "build"}, labels, "the labels of the input aren't changed")
+	assert.Equal(t, map[string]string{LabelManaged: "true"}, managedLabels(nil))
+}
+
+func TestVolumeMounts(t *testing.T) {
+	mounts := volumeMounts(map[string]string{
+		"act-build-env": "/var/run/act",
+		"act-toolcache": "/toolcache",
+		"my-data":       "/data",
+	}, map[string]string{
+		"act-build-env": ManagedRun,
+		"act-toolcache": ManagedPersistent,
+	})
+	labels := map[string]map[string]string{}
+	for _, m := range mounts {
+		assert.Equal(t, mount.TypeVolume, m.Type)
+		if m.VolumeOptions != nil {
+			labels[m.Source] = m.VolumeOptions.Labels
+		}
+	}
+	assert.Equal(t, map[string]map[string]string{
+		"act-build-env": {LabelManaged: ManagedRun},
+		"act-toolcache": {LabelManaged: ManagedPersistent},
+	}, labels, "the volumes named by the user aren't labeled")
+}
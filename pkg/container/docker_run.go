@@ -10,15 +10,12 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/go-git/go-billy/v5/helper/polyfill"
-	"github.com/go-git/go-billy/v5/osfs"
-	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/joho/godotenv"
 
 	"github.com/imdario/mergo"
@@ -29,8 +26,10 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/Masterminds/semver"
@@ -83,7 +82,7 @@ func (cr *containerReference) Start(attach bool) common.Executor {
 			common.NewPipelineExecutor(
 				cr.connect(),
 				cr.find(),
-				cr.attach().IfBool(attach),
+				cr.attach().IfBool(attach || cr.input.StreamOutput),
 				cr.start(),
 				cr.wait().IfBool(attach),
 				cr.tryReadUID(),
@@ -122,9 +121,18 @@ func (cr *containerReference) Copy(destPath string, files ...*FileEntry) common.
 }
 
 func (cr *containerReference) CopyDir(destPath string, srcPath string, useGitIgnore bool) common.Executor {
+	return cr.copyDirOwned(destPath, srcPath, useGitIgnore, false)
+}
+
+// CopyTrackedDir copies only the files of srcPath which are tracked by git and not ignored
+func (cr *containerReference) CopyTrackedDir(destPath string, srcPath string) common.Executor {
+	return cr.copyDirOwned(destPath, srcPath, true, true)
+}
+
+func (cr *containerReference) copyDirOwned(destPath string, srcPath string, useGitIgnore bool, trackedOnly bool) common.Executor {
 	return common.NewPipelineExecutor(
 		common.NewInfoExecutor("%sdocker cp src=%s dst=%s", logPrefix, srcPath, destPath),
-		cr.copyDir(destPath, srcPath, useGitIgnore),
+		cr.copyDir(destPath, srcPath, useGitIgnore, trackedOnly),
 		func(ctx context.Context) error {
 			// If this fails, then folders have wrong permissions on non root container
 			if cr.UID != 0 || cr.GID != 0 {
@@ -160,6 +168,15 @@ func (cr *containerReference) Exec(command []string, env map[string]string, user
 	).IfNot(common.Dryrun)
 }
 
+// ExecInteractive runs a command attached to the terminal of act
+func (cr *containerReference) ExecInteractive(command []string, env map[string]string, user, workdir string) common.Executor {
+	return common.NewPipelineExecutor(
+		cr.connect(),
+		cr.find(),
+		cr.execInteractive(command, env, user, workdir),
+	).IfNot(common.Dryrun)
+}
+
 func (cr *containerReference) Remove() common.Executor {
 	return common.NewPipelineExecutor(
 		cr.connect(),
@@ -179,6 +196,44 @@ func (cr *containerReference) ReplaceLogWriter(stdout io.Writer, stderr io.Write
 	return out, err
 }
 
+// IsHealthy returns the time to wait before the health of the container should be checked again,
+// zero if it is healthy or has no health check and an error if it became unhealthy
+func (cr *containerReference) IsHealthy(ctx context.Context) (time.Duration, error) {
+	if common.Dryrun(ctx) {
+		return 0, nil
+	}
+	if err := common.NewPipelineExecutor(cr.connect(), cr.find())(ctx); err != nil {
+		return -1, err
+	}
+	resp, err := cr.cli.ContainerInspect(ctx, cr.id)
+	if err != nil {
+		return -1, err
+	}
+
+	logger := common.Logger(ctx)
+	if resp.Config == nil || resp.Config.Healthcheck == nil || resp.State == nil || resp.State.Health == nil ||
+		len(resp.Config.Healthcheck.Test) == 1 && strings.EqualFold(resp.Config.Healthcheck.Test[0], "NONE") {
+		logger.Debugf("no health check defined for container %s", cr.input.Name)
+		return 0, nil
+	}
+
+	logger.Debugf("health of container %s (%s) is %s", cr.input.Name, cr.input.Image, resp.State.Health.Status)
+	switch resp.State.Health.Status {
+	case types.Starting:
+		wait := resp.Config.Healthcheck.Interval
+		if wait <= 0 {
+			wait = time.Second
+		}
+		return wait, nil
+	case types.Healthy:
+		return 0, nil
+	case types.Unhealthy:
+		return -1, fmt.Errorf("container %s (%s) is unhealthy", cr.input.Name, cr.input.Image)
+	default:
+		return -1, fmt.Errorf("unexpected health status %s of container %s", resp.State.Health.Status, cr.input.Name)
+	}
+}
+
 type containerReference struct {
 	cli   client.APIClient
 	id    string
@@ -346,8 +401,12 @@ func (cr *containerReference) mergeContainerConfigs(ctx context.Context, config
 	}
 
 	if len(copts.netMode.Value()) == 0 {
-		if err = copts.netMode.Set("host"); err != nil {
-			return nil, nil, fmt.Errorf("Cannot parse networkmode=host. This is an internal error and should not happen: '%w'", err)
+		networkMode := string(hostConfig.NetworkMode)
+		if networkMode == "" {
+			networkMode = "host"
+		}
+		if err = copts.netMode.Set(networkMode); err != nil {
+			return nil, nil, fmt.Errorf("Cannot parse networkmode=%s. This is an internal error and should not happen: '%w'", networkMode, err)
 		}
 	}
 
@@ -381,6 +440,25 @@ func (cr *containerReference) mergeContainerConfigs(ctx context.Context, config
 	return config, hostConfig, nil
 }
 
+// volumeMounts returns the mounts of the volumes by name, the volumes act creates are labeled
+// with their value of LabelManaged
+func volumeMounts(volumes map[string]string, labels map[string]string) []mount.Mount {
+	mounts := make([]mount.Mount, 0, len(volumes))
+	for source, target := range volumes {
+		m := mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: source,
+			Target: target,
+		}
+		if value, ok := labels[source]; ok {
+			// the labels are set when docker creates the volume
+			m.VolumeOptions = &mount.VolumeOptions{Labels: map[string]string{LabelManaged: value}}
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts
+}
+
 func (cr *containerReference) create(capAdd []string, capDrop []string) common.Executor {
 	return func(ctx context.Context) error {
 		if cr.id != "" {
@@ -394,6 +472,7 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 			Image:      input.Image,
 			WorkingDir: input.WorkingDir,
 			Env:        input.Env,
+			Labels:     managedLabels(input.Labels),
 			Tty:        isTerminal,
 		}
 		logger.Debugf("Common container.Config ==> %+v", config)
@@ -406,14 +485,7 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 			config.Entrypoint = input.Entrypoint
 		}
 
-		mounts := make([]mount.Mount, 0)
-		for mountSource, mountTarget := range input.Mounts {
-			mounts = append(mounts, mount.Mount{
-				Type:   mount.TypeVolume,
-				Source: mountSource,
-				Target: mountTarget,
-			})
-		}
+		mounts := volumeMounts(input.Mounts, input.VolumeLabels)
 
 		var platSpecs *specs.Platform
 		if supportsContainerImagePlatform(ctx, cr.cli) && cr.input.Platform != "" {
@@ -429,23 +501,41 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 			}
 		}
 
+		exposedPorts, portBindings, err := nat.ParsePortSpecs(input.Ports)
+		if err != nil {
+			return fmt.Errorf("invalid ports %v: %w", input.Ports, err)
+		}
+		config.ExposedPorts = exposedPorts
+
 		hostConfig := &container.HostConfig{
-			CapAdd:      capAdd,
-			CapDrop:     capDrop,
-			Binds:       input.Binds,
-			Mounts:      mounts,
-			NetworkMode: container.NetworkMode(input.NetworkMode),
-			Privileged:  input.Privileged,
-			UsernsMode:  container.UsernsMode(input.UsernsMode),
+			CapAdd:       capAdd,
+			CapDrop:      capDrop,
+			Binds:        input.Binds,
+			Mounts:       mounts,
+			NetworkMode:  container.NetworkMode(input.NetworkMode),
+			Privileged:   input.Privileged,
+			UsernsMode:   container.UsernsMode(input.UsernsMode),
+			PortBindings: portBindings,
 		}
 		logger.Debugf("Common container.HostConfig ==> %+v", hostConfig)
 
-		config, hostConfig, err := cr.mergeContainerConfigs(ctx, config, hostConfig)
+		config, hostConfig, err = cr.mergeContainerConfigs(ctx, config, hostConfig)
 		if err != nil {
 			return err
 		}
 
-		resp, err := cr.cli.ContainerCreate(ctx, config, hostConfig, nil, platSpecs, input.Name)
+		var networkingConfig *network.NetworkingConfig
+		if len(input.NetworkAliases) > 0 && hostConfig.NetworkMode.IsUserDefined() {
+			networkingConfig = &network.NetworkingConfig{
+				EndpointsConfig: map[string]*network.EndpointSettings{
+					string(hostConfig.NetworkMode): {
+						Aliases: input.NetworkAliases,
+					},
+				},
+			}
+		}
+
+		resp, err := cr.cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, platSpecs, input.Name)
 		if err != nil {
 			return fmt.Errorf("failed to create container: '%w'", err)
 		}
@@ -563,6 +653,67 @@ func (cr *containerReference) exec(cmd []string, env map[string]string, user, wo
 	}
 }
 
+func (cr *containerReference) execInteractive(cmd []string, env map[string]string, user, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		envList := make([]string, 0, len(env))
+		for k, v := range env {
+			envList = append(envList, fmt.Sprintf("%s=%s", k, v))
+		}
+		wd := cr.input.WorkingDir
+		if strings.HasPrefix(workdir, "/") {
+			wd = workdir
+		} else if workdir != "" {
+			wd = fmt.Sprintf("%s/%s", cr.input.WorkingDir, workdir)
+		}
+
+		idResp, err := cr.cli.ContainerExecCreate(ctx, cr.id, types.ExecConfig{
+			User:         user,
+			Cmd:          cmd,
+			WorkingDir:   wd,
+			Env:          envList,
+			Tty:          true,
+			AttachStdin:  true,
+			AttachStderr: true,
+			AttachStdout: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create exec: %w", err)
+		}
+		resp, err := cr.cli.ContainerExecAttach(ctx, idResp.ID, types.ExecStartCheck{
+			Tty: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to attach to exec: %w", err)
+		}
+		defer resp.Close()
+
+		stdin := int(os.Stdin.Fd())
+		if width, height, err := term.GetSize(stdin); err == nil {
+			_ = cr.cli.ContainerExecResize(ctx, idResp.ID, types.ResizeOptions{Width: uint(width), Height: uint(height)})
+		}
+		if state, err := term.MakeRaw(stdin); err == nil {
+			defer func() {
+				_ = term.Restore(stdin, state)
+			}()
+		}
+
+		stop := forwardStdin(resp.Conn)
+		defer stop()
+		if _, err = io.Copy(os.Stdout, resp.Reader); err != nil {
+			return err
+		}
+
+		inspectResp, err := cr.cli.ContainerExecInspect(ctx, idResp.ID)
+		if err != nil {
+			return fmt.Errorf("failed to inspect exec: %w", err)
+		}
+		if inspectResp.ExitCode != 0 {
+			return fmt.Errorf("exitcode '%d': failure", inspectResp.ExitCode)
+		}
+		return nil
+	}
+}
+
 func (cr *containerReference) tryReadID(opt string, cbk func(id int)) common.Executor {
 	return func(ctx context.Context) error {
 		idResp, err := cr.cli.ContainerExecCreate(ctx, cr.id, types.ExecConfig{
@@ -649,7 +800,7 @@ func (cr *containerReference) waitForCommand(ctx context.Context, isTerminal boo
 	}
 }
 
-func (cr *containerReference) copyDir(dstPath string, srcPath string, useGitIgnore bool) common.Executor {
+func (cr *containerReference) copyDir(dstPath string, srcPath string, useGitIgnore bool, trackedOnly bool) common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
 		tarFile, err := os.CreateTemp("", "act")
@@ -669,38 +820,7 @@ func (cr *containerReference) copyDir(dstPath string, srcPath string, useGitIgno
 			}
 		}(tarFile)
 		tw := tar.NewWriter(tarFile)
-
-		srcPrefix := filepath.Dir(srcPath)
-		if !strings.HasSuffix(srcPrefix, string(filepath.Separator)) {
-			srcPrefix += string(filepath.Separator)
-		}
-		logger.Debugf("Stripping prefix:%s src:%s", srcPrefix, srcPath)
-
-		var ignorer gitignore.Matcher
-		if useGitIgnore {
-			ps, err := gitignore.ReadPatterns(polyfill.New(osfs.New(srcPath)), nil)
-			if err != nil {
-				logger.Debugf("Error loading .gitignore: %v", err)
-			}
-
-			ignorer = gitignore.NewMatcher(ps)
-		}
-
-		fc := &fileCollector{
-			Fs:        &defaultFs{},
-			Ignorer:   ignorer,
-			SrcPath:   srcPath,
-			SrcPrefix: srcPrefix,
-			Handler: &tarCollector{
-				TarWriter: tw,
-				UID:       cr.UID,
-				GID:       cr.GID,
-				DstDir:    dstPath[1:],
-			},
-		}
-
-		err = filepath.Walk(srcPath, fc.collectFiles(ctx, []string{}))
-		if err != nil {
+		if err := writeDirTar(ctx, tw, dstPath, srcPath, useGitIgnore, trackedOnly, cr.UID, cr.GID); err != nil {
 			return err
 		}
 		if err := tw.Close(); err != nil {
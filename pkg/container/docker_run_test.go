@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -166,3 +167,46 @@ func TestDockerExecFailure(t *testing.T) {
 
 // Type assert containerReference implements ExecutionsEnvironment
 var _ ExecutionsEnvironment = &containerReference{}
+
+func TestMergeContainerConfigsResources(t *testing.T) {
+	cr := &containerReference{
+		input: &NewContainerInput{
+			Options: "--cpus 1.5 --memory 512m --user 1001:1001 --privileged --ulimit nofile=1024:2048 --gpus all",
+		},
+	}
+
+	config, hostConfig, err := cr.mergeContainerConfigs(context.Background(), &container.Config{
+		Image: "node:16",
+	}, &container.HostConfig{
+		NetworkMode: "host",
+		Binds:       []string{"/var/run/docker.sock:/var/run/docker.sock"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "node:16", config.Image)
+	assert.Equal(t, "1001:1001", config.User)
+	assert.True(t, hostConfig.Privileged)
+	assert.Equal(t, int64(1500000000), hostConfig.NanoCPUs)
+	assert.Equal(t, int64(512*1024*1024), hostConfig.Memory)
+	assert.Equal(t, "nofile", hostConfig.Ulimits[0].Name)
+	assert.Equal(t, int64(1024), hostConfig.Ulimits[0].Soft)
+	assert.Equal(t, int64(2048), hostConfig.Ulimits[0].Hard)
+	assert.Equal(t, -1, hostConfig.DeviceRequests[0].Count)
+	assert.Equal(t, []string{"/var/run/docker.sock:/var/run/docker.sock"}, hostConfig.Binds)
+}
+
+func TestDaemonFeatures(t *testing.T) {
+	features := daemonFeatures("1.41", types.Info{ServerVersion: "20.10.21", OSType: "linux", CgroupVersion: "2"})
+	assert.Equal(t, DaemonFeatures{
+		APIVersion:       "1.41",
+		ServerVersion:    "20.10.21",
+		BuildKit:         true,
+		Platform:         true,
+		HostGateway:      true,
+		CgroupNamespaces: true,
+		CgroupV2:         true,
+	}, features)
+
+	features = daemonFeatures("1.40", types.Info{ServerVersion: "19.03.15", OSType: "windows", CgroupVersion: "1"})
+	assert.Equal(t, DaemonFeatures{APIVersion: "1.40", ServerVersion: "19.03.15"}, features)
+}
@@ -0,0 +1,51 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows))
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// Stats returns the current memory usage and CPU time of the container, read from its cgroup by the docker daemon
+func (cr *containerReference) Stats(ctx context.Context) (*ResourceStats, error) {
+	if common.Dryrun(ctx) {
+		return &ResourceStats{}, nil
+	}
+	if err := common.NewPipelineExecutor(cr.connect(), cr.find())(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := cr.cli.ContainerStatsOneShot(ctx, cr.id)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var stats types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return newResourceStats(&stats), nil
+}
+
+func newResourceStats(stats *types.StatsJSON) *ResourceStats {
+	// like docker stats, the inactive page cache can be reclaimed and isn't counted
+	memory := stats.MemoryStats.Usage
+	inactive, ok := stats.MemoryStats.Stats["total_inactive_file"] // cgroup v1
+	if !ok {
+		inactive = stats.MemoryStats.Stats["inactive_file"] // cgroup v2
+	}
+	if inactive < memory {
+		memory -= inactive
+	}
+	return &ResourceStats{
+		Memory:      memory,
+		MemoryLimit: stats.MemoryStats.Limit,
+		CPUTime:     time.Duration(stats.CPUStats.CPUUsage.TotalUsage),
+	}
+}
@@ -0,0 +1,36 @@
+package container
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResourceStats(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		stats    map[string]uint64
+		expected uint64
+	}{
+		{"cgroup v1", map[string]uint64{"total_inactive_file": 100, "inactive_file": 50}, 900},
+		{"cgroup v2", map[string]uint64{"inactive_file": 300}, 700},
+		{"no page cache", nil, 1000},
+		{"more page cache than usage", map[string]uint64{"inactive_file": 2000}, 1000},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := &types.StatsJSON{}
+			stats.MemoryStats.Usage = 1000
+			stats.MemoryStats.Limit = 4000
+			stats.MemoryStats.Stats = tt.stats
+			stats.CPUStats.CPUUsage.TotalUsage = uint64(1500 * time.Millisecond)
+
+			assert.Equal(t, &ResourceStats{
+				Memory:      tt.expected,
+				MemoryLimit: 4000,
+				CPUTime:     1500 * time.Millisecond,
+			}, newResourceStats(stats))
+		})
+	}
+}
@@ -5,6 +5,7 @@ package container
 import (
 	"context"
 	"runtime"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/nektos/act/pkg/common"
@@ -17,6 +18,22 @@ func ImageExistsLocally(ctx context.Context, imageName string, platform string)
 	return false, errors.New("Unsupported Operation")
 }
 
+// ImagePlatform returns the os/architecture of an image in the local docker image store
+func ImagePlatform(ctx context.Context, imageName string) (string, error) {
+	return "", errors.New("Unsupported Operation")
+}
+
+// ImageDigest returns the digest the image was pulled with from its registry, or the id of an image
+// which was built locally
+func ImageDigest(ctx context.Context, imageName string) (string, error) {
+	return "", errors.New("Unsupported Operation")
+}
+
+// GetDaemonFeatures returns the features of the docker daemon act is connected to
+func GetDaemonFeatures(ctx context.Context) (DaemonFeatures, error) {
+	return DaemonFeatures{}, errors.New("Unsupported Operation")
+}
+
 // RemoveImage removes image from local store, the function is used to run different
 // container image architectures
 func RemoveImage(ctx context.Context, imageName string, force bool, pruneChildren bool) (bool, error) {
@@ -55,3 +72,42 @@ func NewDockerVolumeRemoveExecutor(volume string, force bool) common.Executor {
 		return nil
 	}
 }
+
+func NewDockerNetworkCreateExecutor(name string, internal bool) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func NewDockerNetworkRemoveExecutor(name string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+// ListContainers returns the containers, running or not, which have all the labels
+func ListContainers(ctx context.Context, labels map[string]string) ([]ContainerSummary, error) {
+	return nil, errors.New("Unsupported Operation")
+}
+
+// StartContainer starts a stopped container
+func StartContainer(ctx context.Context, name string) error {
+	return errors.New("Unsupported Operation")
+}
+
+// InspectContainer returns the ID of a running container and the host ports its container ports
+// are published on
+func InspectContainer(ctx context.Context, name string) (*ContainerInfo, error) {
+	return nil, errors.New("Unsupported Operation")
+}
+
+// ListResources returns the containers, networks, volumes and images act created before until,
+// in the order they can be removed in
+func ListResources(ctx context.Context, until time.Time) ([]Resource, error) {
+	return nil, errors.New("Unsupported Operation")
+}
+
+// RemoveResource removes the container with its anonymous volumes, network, volume or image
+func RemoveResource(ctx context.Context, resource Resource) error {
+	return errors.New("Unsupported Operation")
+}
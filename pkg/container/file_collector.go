@@ -11,10 +11,14 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-billy/v5/osfs"
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/go-git/go-git/v5/plumbing/format/index"
+
+	"github.com/nektos/act/pkg/common"
 )
 
 type fileCollectorHandler interface {
@@ -82,12 +86,51 @@ func (cc *copyCollector) WriteFile(fpath string, fi fs.FileInfo, linkName string
 	return nil
 }
 
+// writeDirTar writes the files of srcPath to tw, to be extracted at / to dstPath. The files ignored
+// by the .gitignore are left out with useGitIgnore, the files not tracked by git with trackedOnly.
+func writeDirTar(ctx context.Context, tw *tar.Writer, dstPath string, srcPath string, useGitIgnore bool, trackedOnly bool, uid int, gid int) error {
+	logger := common.Logger(ctx)
+	srcPrefix := filepath.Dir(srcPath)
+	if !strings.HasSuffix(srcPrefix, string(filepath.Separator)) {
+		srcPrefix += string(filepath.Separator)
+	}
+	logger.Debugf("Stripping prefix:%s src:%s", srcPrefix, srcPath)
+
+	var ignorer gitignore.Matcher
+	if useGitIgnore {
+		ps, err := gitignore.ReadPatterns(polyfill.New(osfs.New(srcPath)), nil)
+		if err != nil {
+			logger.Debugf("Error loading .gitignore: %v", err)
+		}
+
+		ignorer = gitignore.NewMatcher(ps)
+	}
+
+	fc := &fileCollector{
+		Fs:        &defaultFs{},
+		Ignorer:   ignorer,
+		SrcPath:   srcPath,
+		SrcPrefix: srcPrefix,
+		Handler: &tarCollector{
+			TarWriter: tw,
+			UID:       uid,
+			GID:       gid,
+			DstDir:    dstPath[1:],
+		},
+		TrackedOnly: trackedOnly,
+	}
+
+	return filepath.Walk(srcPath, fc.collectFiles(ctx, []string{}))
+}
+
 type fileCollector struct {
 	Ignorer   gitignore.Matcher
 	SrcPath   string
 	SrcPrefix string
 	Fs        fileCollectorFs
 	Handler   fileCollectorHandler
+	// TrackedOnly skips the files which aren't in the git index, all files are collected outside of a git repository
+	TrackedOnly bool
 }
 
 type fileCollectorFs interface {
@@ -151,7 +194,7 @@ func (fc *fileCollector) collectFiles(ctx context.Context, submodulePath []strin
 		} else {
 			err = index.ErrEntryNotFound
 		}
-		if err != nil && fc.Ignorer != nil && fc.Ignorer.Match(split, fi.IsDir()) {
+		if err != nil && (fc.TrackedOnly && i != nil || fc.Ignorer != nil && fc.Ignorer.Match(split, fi.IsDir())) {
 			if fi.IsDir() {
 				if i != nil {
 					ms, err := i.Glob(strings.Join(append(split[len(submodulePath):], "**"), "/"))
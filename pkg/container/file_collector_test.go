@@ -115,3 +115,46 @@ func TestIgnoredTrackedfile(t *testing.T) {
 	_, err = tr.Next()
 	assert.ErrorIs(t, err, io.EOF, "tar must only contain one element")
 }
+
+func TestTrackedOnlyFiles(t *testing.T) {
+	fs := memfs.New()
+	_ = fs.MkdirAll("mygitrepo/.git", 0o777)
+	dotgit, _ := fs.Chroot("mygitrepo/.git")
+	worktree, _ := fs.Chroot("mygitrepo")
+	repo, _ := git.Init(filesystem.NewStorage(dotgit, cache.NewObjectLRUDefault()), worktree)
+	for _, name := range []string{"main.go", "src/lib.go", "untracked.txt", "node_modules/dep/index.js"} {
+		f, _ := worktree.Create(name)
+		_, _ = f.Write([]byte(name))
+		f.Close()
+	}
+	w, _ := repo.Worktree()
+	_, _ = w.Add("main.go")
+	_, _ = w.Add("src/lib.go")
+
+	tmpTar, _ := fs.Create("temp.tar")
+	tw := tar.NewWriter(tmpTar)
+	fc := &fileCollector{
+		Fs:        &memoryFs{Filesystem: fs},
+		SrcPath:   "mygitrepo",
+		SrcPrefix: "mygitrepo" + string(filepath.Separator),
+		Handler: &tarCollector{
+			TarWriter: tw,
+		},
+		TrackedOnly: true,
+	}
+	err := fc.Fs.Walk("mygitrepo", fc.collectFiles(context.Background(), []string{}))
+	assert.NoError(t, err, "successfully collect files")
+	tw.Close()
+	_, _ = tmpTar.Seek(0, io.SeekStart)
+	tr := tar.NewReader(tmpTar)
+	names := []string{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, h.Name)
+	}
+	assert.ElementsMatch(t, []string{"main.go", "src/lib.go"}, names)
+}
@@ -339,6 +339,38 @@ func (e *HostEnvironment) Exec(command []string /*cmdline string, */, env map[st
 	}
 }
 
+// ExecInteractive runs a command attached to the terminal of act
+func (e *HostEnvironment) ExecInteractive(command []string, env map[string]string, user, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		wd := e.Path
+		if filepath.IsAbs(workdir) {
+			wd = workdir
+		} else if workdir != "" {
+			wd = filepath.Join(e.Path, workdir)
+		}
+		f, err := lookupPathHost(command[0], env, e.StdOut)
+		if err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, f)
+		cmd.Args = command
+		cmd.Env = getEnvListFromMap(env)
+		cmd.Dir = wd
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		stop := forwardStdin(stdin)
+		defer stop()
+		return cmd.Wait()
+	}
+}
+
 func (e *HostEnvironment) UpdateFromEnv(srcPath string, env *map[string]string) common.Executor {
 	return parseEnvFile(e, srcPath, env)
 }
@@ -352,6 +384,10 @@ func (e *HostEnvironment) Remove() common.Executor {
 	}
 }
 
+func (e *HostEnvironment) IsHealthy(ctx context.Context) (time.Duration, error) {
+	return 0, nil
+}
+
 func (e *HostEnvironment) ToContainerPath(path string) string {
 	if bp, err := filepath.Rel(e.Workdir, path); err != nil {
 		return filepath.Join(e.Path, bp)
@@ -391,9 +427,10 @@ func (*HostEnvironment) JoinPathVariable(paths ...string) string {
 // https://docs.github.com/en/actions/learn-github-actions/contexts#runner-context
 func goArchToActionArch(arch string) string {
 	archMapper := map[string]string{
-		"x86_64":  "X64",
-		"386":     "X86",
-		"aarch64": "ARM64",
+		"amd64": "X64",
+		"386":   "X86",
+		"arm64": "ARM64",
+		"arm":   "ARM",
 	}
 	if arch, ok := archMapper[arch]; ok {
 		return arch
@@ -403,7 +440,9 @@ func goArchToActionArch(arch string) string {
 
 func goOsToActionOs(os string) string {
 	osMapper := map[string]string{
-		"darwin": "macOS",
+		"linux":   "Linux",
+		"windows": "Windows",
+		"darwin":  "macOS",
 	}
 	if os, ok := osMapper[os]; ok {
 		return os
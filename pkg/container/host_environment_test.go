@@ -1,4 +1,25 @@
 package container
 
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
 // Type assert HostEnvironment implements ExecutionsEnvironment
 var _ ExecutionsEnvironment = &HostEnvironment{}
+
+func TestHostEnvironmentRunnerContext(t *testing.T) {
+	assert.Equal(t, "X64", goArchToActionArch("amd64"))
+	assert.Equal(t, "ARM64", goArchToActionArch("arm64"))
+	assert.Equal(t, "X86", goArchToActionArch("386"))
+	assert.Equal(t, "macOS", goOsToActionOs("darwin"))
+	assert.Equal(t, "Linux", goOsToActionOs("linux"))
+	assert.Equal(t, "Windows", goOsToActionOs("windows"))
+
+	e := &HostEnvironment{TmpDir: "/tmp/act/tmp", ToolCache: "/tmp/act/tool_cache"}
+	ctx := e.GetRunnerContext(context.Background())
+	assert.Equal(t, "/tmp/act/tmp", ctx["temp"])
+	assert.Equal(t, "/tmp/act/tool_cache", ctx["tool_cache"])
+}
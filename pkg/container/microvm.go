@@ -0,0 +1,489 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// NewMicroVMInput the input for the NewMicroVM function
+type NewMicroVMInput struct {
+	Firecracker string   // the firecracker binary, looked up in PATH
+	Kernel      string   // the uncompressed Linux kernel (vmlinux) the microVM boots
+	RootFS      string   // the ext4 image of the root file system, the microVM boots a copy of it
+	VCPUs       int      // number of the vCPUs of the microVM
+	MemoryMiB   int      // memory of the microVM in MiB
+	TapDevice   string   // the tap device of the network interface of the microVM, none if empty
+	Dir         string   // directory of the copy of the root file system, the config, console and vsock
+	Env         []string // environment of the commands, like the one of a job container
+	Stdout      io.Writer
+	Stderr      io.Writer
+}
+
+// MicroVM runs the steps of a job in a Firecracker microVM instead of a container sharing the
+// kernel of the host. The root file system of the microVM runs `act microvm-agent` when it boots,
+// the agent runs the commands and copies the files of act over vsock.
+type MicroVM struct {
+	LinuxContainerEnvironmentExtensions
+	input  *NewMicroVMInput
+	cmd    *exec.Cmd
+	exited chan struct{}
+	dial   func(ctx context.Context) (net.Conn, error)
+}
+
+// NewMicroVM creates a reference to a microVM
+func NewMicroVM(input *NewMicroVMInput) ExecutionsEnvironment {
+	vm := &MicroVM{input: input}
+	vm.dial = vm.dialVsock
+	return vm
+}
+
+// Pull checks the kernel, the root file system and the firecracker binary, there are no images
+func (vm *MicroVM) Pull(forcePull bool) common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		for _, file := range []string{vm.input.Kernel, vm.input.RootFS} {
+			if _, err := os.Stat(file); err != nil {
+				return fmt.Errorf("unable to boot the microVM: %w", err)
+			}
+		}
+		if _, err := exec.LookPath(vm.firecracker()); err != nil {
+			return fmt.Errorf("unable to boot the microVM, install Firecracker: %w", err)
+		}
+		if _, err := os.Stat("/dev/kvm"); err != nil {
+			return fmt.Errorf("unable to boot the microVM, KVM is required: %w", err)
+		}
+		return nil
+	}).IfNot(common.Dryrun)
+}
+
+// Create copies the root file system and writes the config of the microVM
+func (vm *MicroVM) Create(capAdd []string, capDrop []string) common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		common.Logger(ctx).Debugf("Copying the root file system %s", vm.input.RootFS)
+		if err := os.MkdirAll(vm.input.Dir, 0o700); err != nil {
+			return err
+		}
+		rootFS := filepath.Join(vm.input.Dir, "rootfs.ext4")
+		if err := copyFile(vm.input.RootFS, rootFS); err != nil {
+			return fmt.Errorf("failed to copy the root file system: %w", err)
+		}
+		config, err := firecrackerConfig(vm.input, rootFS, vm.vsockPath())
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(vm.input.Dir, "config.json"), config, 0o600)
+	}).IfNot(common.Dryrun)
+}
+
+// Start boots the microVM and waits for its agent
+func (vm *MicroVM) Start(attach bool) common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		consolePath := filepath.Join(vm.input.Dir, "console.log")
+		console, err := os.Create(consolePath)
+		if err != nil {
+			return err
+		}
+		logger.Debugf("Booting the microVM, its console is in %s", consolePath)
+		// the microVM outlives the executor, Remove stops it
+		cmd := exec.Command(vm.firecracker(), "--no-api", "--config-file", filepath.Join(vm.input.Dir, "config.json"))
+		cmd.Dir = vm.input.Dir
+		cmd.Stdout = console
+		cmd.Stderr = console
+		if err := cmd.Start(); err != nil {
+			console.Close()
+			return fmt.Errorf("failed to start firecracker: %w", err)
+		}
+		vm.cmd = cmd
+		vm.exited = make(chan struct{})
+		go func() {
+			_ = cmd.Wait()
+			console.Close()
+			close(vm.exited)
+		}()
+
+		timeout := time.NewTimer(time.Minute)
+		defer timeout.Stop()
+		for {
+			_, err := vm.request(ctx, microVMRequest{Op: "ping"}, nil, nil)
+			if err == nil {
+				return nil
+			}
+			logger.Debugf("Waiting for the agent of the microVM: %v", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-vm.exited:
+				return fmt.Errorf("the microVM stopped while booting, its console ends with:\n%s", tailFile(consolePath, 10))
+			case <-timeout.C:
+				return fmt.Errorf("the agent of the microVM didn't respond in time, does the root file system run 'act microvm-agent'? Its console ends with:\n%s", tailFile(consolePath, 10))
+			case <-time.After(250 * time.Millisecond):
+			}
+		}
+	}).IfNot(common.Dryrun)
+}
+
+func (vm *MicroVM) Copy(destPath string, files ...*FileEntry) common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, file := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: file.Name, Mode: file.Mode, Size: int64(len(file.Body))}); err != nil {
+				return err
+			}
+			if _, err := tw.Write([]byte(file.Body)); err != nil {
+				return err
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return vm.put(ctx, destPath, func(w io.Writer) error {
+			_, err := buf.WriteTo(w)
+			return err
+		})
+	}).IfNot(common.Dryrun)
+}
+
+func (vm *MicroVM) CopyDir(destPath string, srcPath string, useGitIgnore bool) common.Executor {
+	return vm.copyDir(destPath, srcPath, useGitIgnore, false)
+}
+
+// CopyTrackedDir copies only the files of the directory which are tracked by git
+func (vm *MicroVM) CopyTrackedDir(destPath string, srcPath string) common.Executor {
+	return vm.copyDir(destPath, srcPath, false, true)
+}
+
+func (vm *MicroVM) copyDir(destPath string, srcPath string, useGitIgnore bool, trackedOnly bool) common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		common.Logger(ctx).Debugf("Copying %s to %s in the microVM", srcPath, destPath)
+		return vm.put(ctx, "/", func(w io.Writer) error {
+			tw := tar.NewWriter(w)
+			if err := writeDirTar(ctx, tw, destPath, srcPath, useGitIgnore, trackedOnly, 0, 0); err != nil {
+				return err
+			}
+			return tw.Close()
+		})
+	}).IfNot(common.Dryrun)
+}
+
+func (vm *MicroVM) put(ctx context.Context, destPath string, body func(io.Writer) error) error {
+	_, err := vm.request(ctx, microVMRequest{Op: "put", Path: destPath}, body, nil)
+	if err != nil {
+		return fmt.Errorf("failed to copy content to the microVM: %w", err)
+	}
+	return nil
+}
+
+func (vm *MicroVM) GetContainerArchive(ctx context.Context, srcPath string) (io.ReadCloser, error) {
+	if common.Dryrun(ctx) {
+		return nil, fmt.Errorf("DRYRUN is not supported in GetContainerArchive")
+	}
+	conn, err := vm.open(ctx, microVMRequest{Op: "get", Path: srcPath}, nil)
+	if err != nil {
+		return nil, err
+	}
+	// the first frame is the exit frame if the path doesn't exist
+	kind, payload, err := readMicroVMFrame(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if kind == microVMFrameExit {
+		conn.Close()
+		if err := microVMExitError(payload); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(&bytes.Buffer{}), nil
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer conn.Close()
+		for {
+			if kind == microVMFrameExit {
+				pw.CloseWithError(microVMExitError(payload))
+				return
+			}
+			if _, err := pw.Write(payload); err != nil {
+				return
+			}
+			if kind, payload, err = readMicroVMFrame(conn); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr, nil
+}
+
+func (vm *MicroVM) Exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		common.Logger(ctx).Debugf("Exec command '%s' in the microVM", strings.Join(command, " "))
+		// the default PATH takes the place of the one of an image, the last value of a variable wins
+		envList := append([]string{vm.GetPathVariableName() + "=" + vm.DefaultPathVariable()}, vm.input.Env...)
+		envList = append(envList, getEnvListFromMap(env)...)
+		code, err := vm.request(ctx, microVMRequest{Op: "exec", Command: command, Env: envList, User: user, Workdir: workdir}, nil, func(kind byte, payload []byte) error {
+			w := vm.input.Stdout
+			if kind == microVMFrameStderr {
+				w = vm.input.Stderr
+			}
+			_, err := w.Write(payload)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("exitcode '%d': failure", code)
+		}
+		return nil
+	}).IfNot(common.Dryrun)
+}
+
+func (vm *MicroVM) UpdateFromEnv(srcPath string, env *map[string]string) common.Executor {
+	return parseEnvFile(vm, srcPath, env).IfNot(common.Dryrun)
+}
+
+// UpdateFromImageEnv does nothing, the root file system of a microVM has no image env
+func (vm *MicroVM) UpdateFromImageEnv(env *map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+// Remove stops the microVM and removes its copy of the root file system
+func (vm *MicroVM) Remove() common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		if vm.cmd != nil && vm.cmd.Process != nil {
+			select {
+			case <-vm.exited:
+			default:
+				_ = vm.cmd.Process.Kill()
+				<-vm.exited
+			}
+		}
+		return os.RemoveAll(vm.input.Dir)
+	}).IfNot(common.Dryrun)
+}
+
+func (vm *MicroVM) Close() common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (vm *MicroVM) ReplaceLogWriter(stdout io.Writer, stderr io.Writer) (io.Writer, io.Writer) {
+	out, err := vm.input.Stdout, vm.input.Stderr
+	vm.input.Stdout, vm.input.Stderr = stdout, stderr
+	return out, err
+}
+
+func (vm *MicroVM) IsHealthy(ctx context.Context) (time.Duration, error) {
+	return 0, nil
+}
+
+func (vm *MicroVM) firecracker() string {
+	if vm.input.Firecracker != "" {
+		return vm.input.Firecracker
+	}
+	return "firecracker"
+}
+
+func (vm *MicroVM) vsockPath() string {
+	return filepath.Join(vm.input.Dir, "vsock.sock")
+}
+
+// dialVsock connects to the agent through the vsock of Firecracker, which forwards the
+// connections of its unix socket to a port of the microVM after a CONNECT line
+func (vm *MicroVM) dialVsock(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", vm.vsockPath())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %d\n", MicroVMAgentPort); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// read the OK line byte by byte, the response of the agent follows it
+	var line []byte
+	b := make([]byte, 1)
+	for len(line) < 64 {
+		if _, err := conn.Read(b); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if b[0] == '\n' {
+			break
+		}
+		line = append(line, b[0])
+	}
+	if !strings.HasPrefix(string(line), "OK ") {
+		conn.Close()
+		return nil, fmt.Errorf("the vsock of the microVM refused the connection: %s", line)
+	}
+	return conn, nil
+}
+
+// open sends a request to the agent, body writes what follows the request, e.g. a tar archive
+func (vm *MicroVM) open(ctx context.Context, req microVMRequest, body func(io.Writer) error) (net.Conn, error) {
+	conn, err := vm.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.Write(append(line, '\n')); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if body != nil {
+		if err := body(conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// request sends a request to the agent and passes the frames of the response to handle until the
+// exit frame, it returns the exit code of an exec request
+func (vm *MicroVM) request(ctx context.Context, req microVMRequest, body func(io.Writer) error, handle func(kind byte, payload []byte) error) (int, error) {
+	conn, err := vm.open(ctx, req, body)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// closing the connection kills the command of an exec request
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		kind, payload, err := readMicroVMFrame(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			return 0, err
+		}
+		if kind == microVMFrameExit {
+			var exit microVMExit
+			if err := json.Unmarshal(payload, &exit); err != nil {
+				return 0, err
+			}
+			if exit.Error != "" {
+				return exit.Code, errors.New(exit.Error)
+			}
+			return exit.Code, nil
+		}
+		if handle != nil {
+			if err := handle(kind, payload); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
+
+func microVMExitError(payload []byte) error {
+	var exit microVMExit
+	if err := json.Unmarshal(payload, &exit); err != nil {
+		return err
+	}
+	if exit.Error != "" {
+		return errors.New(exit.Error)
+	}
+	return nil
+}
+
+// firecrackerConfig returns the config file of the microVM for firecracker --config-file
+func firecrackerConfig(input *NewMicroVMInput, rootFS string, vsockPath string) ([]byte, error) {
+	type drive struct {
+		DriveID      string `json:"drive_id"`
+		PathOnHost   string `json:"path_on_host"`
+		IsRootDevice bool   `json:"is_root_device"`
+		IsReadOnly   bool   `json:"is_read_only"`
+	}
+	type networkInterface struct {
+		IfaceID     string `json:"iface_id"`
+		HostDevName string `json:"host_dev_name"`
+	}
+	config := struct {
+		BootSource struct {
+			KernelImagePath string `json:"kernel_image_path"`
+			BootArgs        string `json:"boot_args"`
+		} `json:"boot-source"`
+		Drives        []drive `json:"drives"`
+		MachineConfig struct {
+			VCPUCount  int `json:"vcpu_count"`
+			MemSizeMiB int `json:"mem_size_mib"`
+		} `json:"machine-config"`
+		NetworkInterfaces []networkInterface `json:"network-interfaces,omitempty"`
+		Vsock             struct {
+			GuestCID int    `json:"guest_cid"`
+			UDSPath  string `json:"uds_path"`
+		} `json:"vsock"`
+	}{}
+	config.BootSource.KernelImagePath = input.Kernel
+	config.BootSource.BootArgs = "console=ttyS0 reboot=k panic=1 pci=off"
+	config.Drives = []drive{{DriveID: "rootfs", PathOnHost: rootFS, IsRootDevice: true}}
+	config.MachineConfig.VCPUCount = input.VCPUs
+	config.MachineConfig.MemSizeMiB = input.MemoryMiB
+	if input.TapDevice != "" {
+		config.NetworkInterfaces = []networkInterface{{IfaceID: "eth0", HostDevName: input.TapDevice}}
+	}
+	config.Vsock.GuestCID = 3
+	config.Vsock.UDSPath = vsockPath
+	return json.MarshalIndent(config, "", "  ")
+}
+
+// tailFile returns the last lines of the file, e.g. of the console of a microVM
+func tailFile(name string, lines int) string {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return err.Error()
+	}
+	all := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	return strings.Join(all, "\n")
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,211 @@
+package container
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// serveMicroVMConn serves a request of act to the agent in a microVM
+func serveMicroVMConn(ctx context.Context, conn io.ReadWriteCloser) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		log.Debugf("Failed to read the request: %v", err)
+		return
+	}
+	var mu sync.Mutex
+	exit := func(code int, err error) {
+		e := microVMExit{Code: code}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		payload, _ := json.Marshal(e)
+		mu.Lock()
+		defer mu.Unlock()
+		if err := writeMicroVMFrame(conn, microVMFrameExit, payload); err != nil {
+			log.Debugf("Failed to write the response: %v", err)
+		}
+	}
+
+	var req microVMRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		exit(0, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	log.Debugf("%s %s%s", req.Op, strings.Join(req.Command, " "), req.Path)
+	switch req.Op {
+	case "ping":
+		exit(0, nil)
+	case "exec":
+		exit(agentExec(ctx, req, r, &microVMFrameWriter{mu: &mu, w: conn, kind: microVMFrameStdout}, &microVMFrameWriter{mu: &mu, w: conn, kind: microVMFrameStderr}))
+	case "put":
+		exit(0, extractTar(tar.NewReader(r), req.Path))
+	case "get":
+		if _, err := os.Lstat(req.Path); err != nil {
+			exit(0, err)
+			return
+		}
+		exit(0, writePathTar(&microVMFrameWriter{mu: &mu, w: conn, kind: microVMFrameData}, req.Path))
+	default:
+		exit(0, fmt.Errorf("unknown request '%s'", req.Op))
+	}
+}
+
+// agentExec runs the command of an exec request, it's killed when act closes the connection
+func agentExec(ctx context.Context, req microVMRequest, conn io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	if len(req.Command) == 0 {
+		return 0, errors.New("no command to run")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		cancel()
+	}()
+
+	name, err := lookPathIn(req.Command[0], req.Env)
+	if err != nil {
+		return 127, err
+	}
+	cmd := exec.CommandContext(ctx, name, req.Command[1:]...)
+	cmd.Args[0] = req.Command[0]
+	cmd.Env = req.Env
+	cmd.Dir = req.Workdir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := setCommandUser(cmd, req.User); err != nil {
+		return 0, err
+	}
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// lookPathIn looks up the command in the PATH of env, not in the one of the agent
+func lookPathIn(command string, env []string) (string, error) {
+	if strings.Contains(command, "/") {
+		return command, nil
+	}
+	path := ""
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == "PATH" {
+			path = v
+		}
+	}
+	for _, dir := range filepath.SplitList(path) {
+		name := filepath.Join(dir, command)
+		if fi, err := os.Stat(name); err == nil && !fi.IsDir() && fi.Mode()&0o111 != 0 {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%s: command not found", command)
+}
+
+// extractTar extracts the archive of a put request at dst, like docker cp
+func extractTar(tr *tar.Reader, dst string) error {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name := filepath.Join(dst, header.Name)
+		mode := os.FileMode(header.Mode).Perm()
+		if err := os.MkdirAll(filepath.Dir(name), 0o777); err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(name, mode); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			_ = os.Remove(name)
+			if err := os.Symlink(header.Linkname, name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		if header.Typeflag != tar.TypeSymlink {
+			if err := os.Chmod(name, mode); err != nil {
+				return err
+			}
+		}
+		// the files are owned by the user of the job, if the agent may change the owner
+		_ = os.Lchown(name, header.Uid, header.Gid)
+	}
+}
+
+// writePathTar writes the file or directory of a get request as a tar archive, its entries start
+// with the base name of the path like the ones of docker cp
+func writePathTar(w io.Writer, src string) error {
+	tw := tar.NewWriter(w)
+	base := filepath.Dir(src)
+	err := filepath.Walk(src, func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(name); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, name)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
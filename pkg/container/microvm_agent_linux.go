@@ -0,0 +1,86 @@
+//go:build linux
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ListenMicroVMAgent serves the requests of act on the vsock port in a microVM until ctx is done
+func ListenMicroVMAgent(ctx context.Context, port uint32) error {
+	fd, err := unix.Socket(unix.AF_VSOCK, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create the vsock socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrVM{CID: unix.VMADDR_CID_ANY, Port: port}); err != nil {
+		unix.Close(fd)
+		return fmt.Errorf("failed to bind the vsock port %d: %w", port, err)
+	}
+	if err := unix.Listen(fd, 16); err != nil {
+		unix.Close(fd)
+		return fmt.Errorf("failed to listen on the vsock port %d: %w", port, err)
+	}
+	go func() {
+		<-ctx.Done()
+		unix.Shutdown(fd, unix.SHUT_RDWR)
+	}()
+	defer unix.Close(fd)
+
+	for {
+		nfd, _, err := unix.Accept4(fd, unix.SOCK_CLOEXEC)
+		if err == unix.EINTR {
+			continue
+		} else if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept a connection: %w", err)
+		}
+		go serveMicroVMConn(ctx, os.NewFile(uintptr(nfd), "vsock"))
+	}
+}
+
+// setCommandUser runs the command as the user of an exec request, a name or uid with an optional
+// group like docker exec --user
+func setCommandUser(cmd *exec.Cmd, name string) error {
+	if name == "" {
+		return nil
+	}
+	name, group, _ := strings.Cut(name, ":")
+	u, err := user.Lookup(name)
+	if err != nil {
+		if u, err = user.LookupId(name); err != nil {
+			return fmt.Errorf("unknown user '%s'", name)
+		}
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return err
+	}
+	if group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			if g, err = user.LookupGroupId(group); err != nil {
+				return fmt.Errorf("unknown group '%s'", group)
+			}
+		}
+		if gid, err = strconv.ParseUint(g.Gid, 10, 32); err != nil {
+			return err
+		}
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}}
+	return nil
+}
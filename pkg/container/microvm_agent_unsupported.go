@@ -0,0 +1,22 @@
+//go:build !linux
+
+package container
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// ListenMicroVMAgent serves the requests of act on the vsock port in a microVM until ctx is done
+func ListenMicroVMAgent(ctx context.Context, port uint32) error {
+	return errors.New("the microVM agent runs only on Linux")
+}
+
+// setCommandUser runs the command as the user of an exec request
+func setCommandUser(cmd *exec.Cmd, name string) error {
+	if name != "" {
+		return errors.New("the microVM agent runs only on Linux")
+	}
+	return nil
+}
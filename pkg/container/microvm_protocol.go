@@ -0,0 +1,92 @@
+package container
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MicroVMAgentPort is the vsock port the agent in a microVM listens on
+const MicroVMAgentPort = 10789
+
+// microVMRequest is the first line of a connection to the agent in a microVM, each request has a
+// connection of its own. The tar archive of a put request follows the line.
+type microVMRequest struct {
+	Op      string   `json:"op"` // ping, exec, put or get
+	Command []string `json:"command,omitempty"`
+	Env     []string `json:"env,omitempty"`
+	User    string   `json:"user,omitempty"`
+	Workdir string   `json:"workdir,omitempty"`
+	Path    string   `json:"path,omitempty"` // where put extracts the archive, what get archives
+}
+
+// microVMExit is the payload of the last frame of the response to a request
+type microVMExit struct {
+	Code  int    `json:"code"`            // the exit code of the command of an exec request
+	Error string `json:"error,omitempty"` // why the request failed
+}
+
+// the kinds of the frames of a response of the agent
+const (
+	microVMFrameStdout byte = 1
+	microVMFrameStderr byte = 2
+	microVMFrameData   byte = 3 // a part of the tar archive of a get request
+	microVMFrameExit   byte = 4
+)
+
+const microVMMaxFrameSize = 1 << 20
+
+// writeMicroVMFrame writes a frame, its kind, the big endian length of the payload and the payload
+func writeMicroVMFrame(w io.Writer, kind byte, payload []byte) error {
+	header := make([]byte, 5)
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readMicroVMFrame(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > microVMMaxFrameSize {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds the limit of %d bytes", size, microVMMaxFrameSize)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+// microVMFrameWriter writes what is written to it as frames of a kind, the writers of the
+// streams of a response share the lock
+type microVMFrameWriter struct {
+	mu   *sync.Mutex
+	w    io.Writer
+	kind byte
+}
+
+func (fw *microVMFrameWriter) Write(p []byte) (int, error) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		if n > microVMMaxFrameSize {
+			n = microVMMaxFrameSize
+		}
+		if err := writeMicroVMFrame(fw.w, fw.kind, p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
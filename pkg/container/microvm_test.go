@@ -0,0 +1,109 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMicroVM returns a microVM whose requests are served by an agent in the test process
+func newTestMicroVM(stdout io.Writer) *MicroVM {
+	return &MicroVM{
+		input: &NewMicroVMInput{
+			Env:    []string{"PATH=" + os.Getenv("PATH"), "FROM_VM=vm"},
+			Stdout: stdout,
+			Stderr: stdout,
+		},
+		dial: func(ctx context.Context) (net.Conn, error) {
+			host, agent := net.Pipe()
+			go serveMicroVMConn(ctx, agent)
+			return host, nil
+		},
+	}
+}
+
+func TestMicroVMExec(t *testing.T) {
+	var out bytes.Buffer
+	vm := newTestMicroVM(&out)
+	dir := t.TempDir()
+
+	err := vm.Exec([]string{"sh", "-c", "echo $FROM_VM $FROM_STEP $(pwd)"}, map[string]string{"FROM_STEP": "step"}, "", dir)(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "vm step "+dir+"\n", out.String())
+
+	err = vm.Exec([]string{"sh", "-c", "exit 3"}, nil, "", dir)(context.Background())
+	assert.EqualError(t, err, "exitcode '3': failure")
+
+	err = vm.Exec([]string{"unknown-command"}, nil, "", dir)(context.Background())
+	assert.EqualError(t, err, "unknown-command: command not found")
+}
+
+func TestMicroVMCopy(t *testing.T) {
+	vm := newTestMicroVM(io.Discard)
+	dir := t.TempDir()
+
+	err := vm.Copy(dir+"/", &FileEntry{Name: "workflow/envs.txt", Mode: 0o644, Body: "FOO=bar\n"})(context.Background())
+	require.NoError(t, err)
+	body, err := os.ReadFile(filepath.Join(dir, "workflow", "envs.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "FOO=bar\n", string(body))
+
+	env := map[string]string{}
+	err = vm.UpdateFromEnv(filepath.Join(dir, "workflow", "envs.txt"), &env)(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, env)
+
+	_, err = vm.GetContainerArchive(context.Background(), filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestMicroVMCopyDir(t *testing.T) {
+	vm := newTestMicroVM(io.Discard)
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "file.txt"), []byte("content"), 0o644))
+	dst := filepath.Join(t.TempDir(), "workspace")
+
+	err := vm.CopyDir(dst, src+string(filepath.Separator)+".", false)(context.Background())
+	require.NoError(t, err)
+	body, err := os.ReadFile(filepath.Join(dst, "sub", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(body))
+
+	archive, err := vm.GetContainerArchive(context.Background(), filepath.Join(dst, "sub"))
+	require.NoError(t, err)
+	defer archive.Close()
+	var names []string
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.Equal(t, []string{"sub", "sub/file.txt"}, names)
+}
+
+func TestFirecrackerConfig(t *testing.T) {
+	config, err := firecrackerConfig(&NewMicroVMInput{Kernel: "/vmlinux", VCPUs: 2, MemoryMiB: 1024, TapDevice: "tap0"}, "/vm/rootfs.ext4", "/vm/vsock.sock")
+	require.NoError(t, err)
+
+	var parsed map[string]interface{}
+	require.NoError(t, json.Unmarshal(config, &parsed))
+	assert.Equal(t, map[string]interface{}{"kernel_image_path": "/vmlinux", "boot_args": "console=ttyS0 reboot=k panic=1 pci=off"}, parsed["boot-source"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"drive_id": "rootfs", "path_on_host": "/vm/rootfs.ext4", "is_root_device": true, "is_read_only": false}}, parsed["drives"])
+	assert.Equal(t, map[string]interface{}{"vcpu_count": float64(2), "mem_size_mib": float64(1024)}, parsed["machine-config"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"iface_id": "eth0", "host_dev_name": "tap0"}}, parsed["network-interfaces"])
+	assert.Equal(t, map[string]interface{}{"guest_cid": float64(3), "uds_path": "/vm/vsock.sock"}, parsed["vsock"])
+}
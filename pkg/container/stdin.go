@@ -0,0 +1,82 @@
+package container
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	stdinOnce   sync.Once
+	stdinChunks chan []byte
+)
+
+// StdinChunks returns the input of the terminal. A single goroutine reads os.Stdin for the
+// interactive commands and the prompts, since a pending read can't be interrupted when a
+// command exits.
+func StdinChunks() <-chan []byte {
+	stdinOnce.Do(func() {
+		stdinChunks = make(chan []byte)
+		go func() {
+			defer close(stdinChunks)
+			for {
+				buf := make([]byte, 1024)
+				n, err := os.Stdin.Read(buf)
+				if n > 0 {
+					stdinChunks <- buf[:n]
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	})
+	return stdinChunks
+}
+
+// ReadStdinLine reads a line of the terminal input
+func ReadStdinLine(ctx context.Context) (string, error) {
+	var line strings.Builder
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case chunk, ok := <-StdinChunks():
+			if !ok {
+				return line.String(), io.EOF
+			}
+			line.Write(chunk)
+			if strings.ContainsAny(line.String(), "\r\n") {
+				return strings.TrimRight(line.String(), "\r\n"), nil
+			}
+		}
+	}
+}
+
+// forwardStdin writes the terminal input to w until the returned function is called
+func forwardStdin(w io.Writer) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case chunk, ok := <-StdinChunks():
+				if !ok {
+					if closer, ok := w.(io.Closer); ok {
+						_ = closer.Close()
+					}
+					return
+				}
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
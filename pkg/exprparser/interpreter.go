@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/nektos/act/pkg/model"
@@ -19,10 +20,12 @@ type EvaluationEnvironment struct {
 	Steps    map[string]*model.StepResult
 	Runner   map[string]interface{}
 	Secrets  map[string]string
+	Vars     map[string]string
 	Strategy map[string]interface{}
 	Matrix   map[string]interface{}
 	Needs    map[string]Needs
 	Inputs   map[string]interface{}
+	Aliases  map[string]string // other names of the contexts, e.g. gitea for github in the workflows of Gitea
 }
 
 type Needs struct {
@@ -167,6 +170,8 @@ func (impl *interperterImpl) evaluateVariable(variableNode *actionlint.VariableN
 		return impl.env.Runner, nil
 	case "secrets":
 		return impl.env.Secrets, nil
+	case "vars":
+		return impl.env.Vars, nil
 	case "strategy":
 		return impl.env.Strategy, nil
 	case "matrix":
@@ -180,6 +185,9 @@ func (impl *interperterImpl) evaluateVariable(variableNode *actionlint.VariableN
 	case "nan":
 		return math.NaN(), nil
 	default:
+		if name, ok := impl.env.Aliases[strings.ToLower(variableNode.Name)]; ok {
+			return impl.evaluateVariable(&actionlint.VariableNode{Name: name})
+		}
 		return nil, fmt.Errorf("Unavailable context: %s", variableNode.Name)
 	}
 }
@@ -210,6 +218,9 @@ func (impl *interperterImpl) evaluateIndexAccess(indexAccessNode *actionlint.Ind
 				return nil, nil
 			}
 			return leftValue.Index(int(rightValue.Int())).Interface(), nil
+		case reflect.Map:
+			// e.g. job.services.<service_id>.ports[5432]
+			return impl.getPropertyValue(leftValue, strconv.FormatInt(rightValue.Int(), 10))
 		default:
 			return nil, nil
 		}
@@ -557,6 +557,7 @@ func TestContexts(t *testing.T) {
 		// {"contains(steps.*.outputs.name, 'value')", true, "steps-context-array-outputs"},
 		{"runner.os", "Linux", "runner-context"},
 		{"secrets.name", "value", "secrets-context"},
+		{"vars.name", "value", "vars-context"},
 		{"strategy.fail-fast", true, "strategy-context"},
 		{"matrix.os", "Linux", "matrix-context"},
 		{"needs.job-id.outputs.output-name", "value", "needs-context"},
@@ -593,6 +594,9 @@ func TestContexts(t *testing.T) {
 		Secrets: map[string]string{
 			"name": "value",
 		},
+		Vars: map[string]string{
+			"name": "value",
+		},
 		Strategy: map[string]interface{}{
 			"fail-fast": true,
 		},
@@ -621,3 +625,17 @@ func TestContexts(t *testing.T) {
 		})
 	}
 }
+
+func TestContextAliases(t *testing.T) {
+	env := &EvaluationEnvironment{
+		Github:  &model.GithubContext{Repository: "octo/app"},
+		Aliases: map[string]string{"gitea": "github"},
+	}
+
+	output, err := NewInterpeter(env, Config{}).Evaluate("gitea.repository", DefaultStatusCheckNone)
+	assert.Nil(t, err)
+	assert.Equal(t, "octo/app", output)
+
+	_, err = NewInterpeter(env, Config{}).Evaluate("forgejo.repository", DefaultStatusCheckNone)
+	assert.EqualError(t, err, "Unavailable context: forgejo")
+}
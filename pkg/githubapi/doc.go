@@ -0,0 +1,10 @@
+// Package githubapi provides a local stub of the GitHub REST API for the runner.
+//
+// Actions which call the API with GITHUB_TOKEN, e.g. to comment on issues, create check
+// runs or releases, talk to the stub instead of github.com when GITHUB_API_URL points at it.
+// Responses are read from JSON fixtures, requests without a fixture get a minimal default
+// response and requests which change something are logged and answered with the request body.
+//
+// GraphQL isn't implemented: GITHUB_GRAPHQL_URL points at the stub as well, which answers the
+// queries with the graphql.post.json fixture, or with a "not implemented" error without one.
+package githubapi
@@ -0,0 +1,312 @@
+package githubapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+const documentationURL = "https://docs.github.com/rest"
+
+// graphqlPath is the endpoint of the GraphQL API, GITHUB_GRAPHQL_URL points at it
+const graphqlPath = "/graphql"
+
+// route is a default response of the stub for requests without a fixture
+type route struct {
+	method  string
+	pattern *regexp.Regexp
+	handle  func(h *Handler, r *http.Request, match []string, body map[string]any) (int, any)
+}
+
+var routes = []route{
+	{http.MethodGet, regexp.MustCompile(`^/repos/([^/]+)/([^/]+)$`), (*Handler).getRepo},
+	{http.MethodGet, regexp.MustCompile(`^/repos/[^/]+/[^/]+/(issues|pulls|releases|check-runs|issues/\d+/comments|commits/[^/]+/check-runs)$`), (*Handler).list},
+	{http.MethodPost, regexp.MustCompile(`^/repos/([^/]+)/([^/]+)/(issues|pulls)$`), (*Handler).createIssue},
+	{http.MethodPost, regexp.MustCompile(`^/repos/([^/]+)/([^/]+)/issues/(\d+)/comments$`), (*Handler).create},
+	{http.MethodPost, regexp.MustCompile(`^/repos/([^/]+)/([^/]+)/issues/(\d+)/labels$`), (*Handler).echoList},
+	{http.MethodPost, regexp.MustCompile(`^/repos/([^/]+)/([^/]+)/check-runs$`), (*Handler).create},
+	{http.MethodPatch, regexp.MustCompile(`^/repos/([^/]+)/([^/]+)/(issues|pulls|check-runs|releases)/(\d+)$`), (*Handler).update},
+	{http.MethodPost, regexp.MustCompile(`^/repos/([^/]+)/([^/]+)/releases$`), (*Handler).createRelease},
+}
+
+// Handler serves the GitHub API stub
+type Handler struct {
+	listener net.Listener
+	server   *http.Server
+	logger   logrus.FieldLogger
+
+	fixtures string
+
+	mu     sync.Mutex
+	lastID int64
+
+	outboundIP string
+}
+
+// StartHandler starts the stub, which serves the fixtures in dir if it isn't empty
+func StartHandler(dir, outboundIP string, port uint16, logger logrus.FieldLogger) (*Handler, error) {
+	h := &Handler{
+		fixtures: dir,
+	}
+
+	if logger == nil {
+		discard := logrus.New()
+		discard.Out = io.Discard
+		logger = discard
+	}
+	logger = logger.WithField("module", "githubapi")
+	h.logger = logger
+
+	if outboundIP != "" {
+		h.outboundIP = outboundIP
+	} else if ip := common.GetOutboundIP(); ip == nil {
+		return nil, fmt.Errorf("unable to determine outbound IP address")
+	} else {
+		h.outboundIP = ip.String()
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port)) // listen on all interfaces
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{
+		ReadHeaderTimeout: 2 * time.Second,
+		Handler:           h,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("http serve: %v", err)
+		}
+	}()
+	h.listener = listener
+	h.server = server
+
+	return h, nil
+}
+
+func (h *Handler) ExternalURL() string {
+	return fmt.Sprintf("http://%s:%d",
+		h.outboundIP,
+		h.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (h *Handler) Close() error {
+	if h == nil {
+		return nil
+	}
+	var retErr error
+	if h.server != nil {
+		err := h.server.Close()
+		if err != nil {
+			retErr = err
+		}
+		h.server = nil
+	}
+	if h.listener != nil {
+		err := h.listener.Close()
+		if errors.Is(err, net.ErrClosed) {
+			err = nil
+		}
+		if err != nil {
+			retErr = err
+		}
+		h.listener = nil
+	}
+	return retErr
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	urlPath := "/" + strings.Trim(path.Clean(r.URL.Path), "/")
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		h.logger.Debugf("%s %s", r.Method, r.RequestURI)
+	} else {
+		// requests which change something are what the user wants to see
+		h.logger.Infof("%s %s", r.Method, r.RequestURI)
+	}
+
+	var body map[string]any
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			h.responseJSON(w, r, http.StatusBadRequest, fmt.Errorf("Problems parsing JSON: %w", err))
+			return
+		}
+	}
+
+	if data, err := h.readFixture(r.Method, urlPath); err == nil {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(successCode(r.Method, urlPath))
+		_, _ = w.Write(data)
+		return
+	} else if !errors.Is(err, os.ErrNotExist) {
+		h.responseJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	if urlPath == graphqlPath {
+		// the queries can't be answered without knowing the schema, only fixtures are served
+		h.logger.Warnf("%s %s: the GitHub API stub doesn't implement GraphQL, add a graphql.post.json fixture", r.Method, r.RequestURI)
+		h.responseJSON(w, r, http.StatusNotImplemented, map[string]any{
+			"errors": []any{map[string]any{
+				"message": "GraphQL is not implemented by the GitHub API stub of act, add a graphql.post.json fixture to --github-api-fixtures",
+			}},
+		})
+		return
+	}
+
+	for _, route := range routes {
+		if route.method != r.Method {
+			continue
+		}
+		if match := route.pattern.FindStringSubmatch(urlPath); match != nil {
+			code, v := route.handle(h, r, match, body)
+			h.responseJSON(w, r, code, v)
+			return
+		}
+	}
+	h.responseJSON(w, r, http.StatusNotFound, map[string]any{
+		"message":           "Not Found",
+		"documentation_url": documentationURL,
+	})
+}
+
+// readFixture reads the fixture of a request, <path>.json for GET requests and
+// <path>.<method>.json for the other methods, e.g. repos/octo/hello/issues.post.json
+func (h *Handler) readFixture(method, urlPath string) ([]byte, error) {
+	if h.fixtures == "" {
+		return nil, os.ErrNotExist
+	}
+	name := filepath.Join(h.fixtures, filepath.FromSlash(strings.TrimPrefix(urlPath, "/")))
+	if urlPath == "/" {
+		name = filepath.Join(h.fixtures, "index")
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		name += "." + strings.ToLower(method)
+	}
+	return os.ReadFile(name + ".json")
+}
+
+func successCode(method, urlPath string) int {
+	// GraphQL answers the queries and the mutations with 200
+	if method == http.MethodPost && urlPath != graphqlPath {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
+func (h *Handler) nextID() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	return h.lastID
+}
+
+func (h *Handler) htmlURL(owner, repo string, elem ...string) string {
+	return strings.Join(append([]string{"https://github.com", owner, repo}, elem...), "/")
+}
+
+// GET /repos/{owner}/{repo}
+func (h *Handler) getRepo(r *http.Request, match []string, _ map[string]any) (int, any) {
+	owner, repo := match[1], match[2]
+	return http.StatusOK, map[string]any{
+		"id":             1,
+		"name":           repo,
+		"full_name":      owner + "/" + repo,
+		"owner":          map[string]any{"login": owner},
+		"private":        false,
+		"default_branch": "main",
+		"html_url":       h.htmlURL(owner, repo),
+	}
+}
+
+// GET /repos/{owner}/{repo}/issues and other lists
+func (h *Handler) list(r *http.Request, match []string, _ map[string]any) (int, any) {
+	if strings.HasSuffix(match[0], "/check-runs") {
+		return http.StatusOK, map[string]any{"total_count": 0, "check_runs": []any{}}
+	}
+	return http.StatusOK, []any{}
+}
+
+// POST /repos/{owner}/{repo}/issues and /pulls
+func (h *Handler) createIssue(r *http.Request, match []string, body map[string]any) (int, any) {
+	id := h.nextID()
+	v := echo(body)
+	v["id"] = id
+	v["number"] = id
+	v["state"] = "open"
+	v["html_url"] = h.htmlURL(match[1], match[2], match[3], fmt.Sprint(id))
+	return http.StatusCreated, v
+}
+
+// POST /repos/{owner}/{repo}/releases
+func (h *Handler) createRelease(r *http.Request, match []string, body map[string]any) (int, any) {
+	id := h.nextID()
+	v := echo(body)
+	v["id"] = id
+	v["html_url"] = h.htmlURL(match[1], match[2], "releases", "tag", fmt.Sprint(body["tag_name"]))
+	v["upload_url"] = fmt.Sprintf("%s/repos/%s/%s/releases/%d/assets{?name,label}", h.ExternalURL(), match[1], match[2], id)
+	v["assets"] = []any{}
+	return http.StatusCreated, v
+}
+
+// POST /repos/{owner}/{repo}/check-runs and /issues/{number}/comments
+func (h *Handler) create(r *http.Request, match []string, body map[string]any) (int, any) {
+	v := echo(body)
+	v["id"] = h.nextID()
+	return http.StatusCreated, v
+}
+
+// PATCH /repos/{owner}/{repo}/{issues,pulls,check-runs,releases}/{id}
+func (h *Handler) update(r *http.Request, match []string, body map[string]any) (int, any) {
+	v := echo(body)
+	v["id"] = json.Number(match[4])
+	return http.StatusOK, v
+}
+
+// POST /repos/{owner}/{repo}/issues/{number}/labels
+func (h *Handler) echoList(r *http.Request, _ []string, body map[string]any) (int, any) {
+	labels := []any{}
+	if names, ok := body["labels"].([]any); ok {
+		for _, name := range names {
+			labels = append(labels, map[string]any{"name": name})
+		}
+	}
+	return http.StatusOK, labels
+}
+
+func echo(body map[string]any) map[string]any {
+	v := make(map[string]any, len(body))
+	for key, value := range body {
+		v[key] = value
+	}
+	return v
+}
+
+func (h *Handler) responseJSON(w http.ResponseWriter, r *http.Request, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	var data []byte
+	if err, ok := v.(error); ok {
+		h.logger.Errorf("%v %v: %v", r.Method, r.RequestURI, err)
+		data, _ = json.Marshal(map[string]any{
+			"message":           err.Error(),
+			"documentation_url": documentationURL,
+		})
+	} else {
+		data, _ = json.Marshal(v)
+	}
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,101 @@
+package githubapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "repos", "octo", "hello", "releases"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "repos", "octo", "hello", "releases", "latest.json"), []byte(`{"tag_name":"v1.2.3"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "repos", "octo", "hello", "issues.post.json"), []byte(`{"number":42}`), 0o600))
+
+	handler, err := StartHandler(dir, "127.0.0.1", 0, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, handler.Close())
+		assert.Nil(t, handler.server)
+		assert.Nil(t, handler.listener)
+	}()
+	base := handler.ExternalURL()
+
+	request := func(t *testing.T, method, url string, body any) (int, any) {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req, err := http.NewRequest(method, base+url, bytes.NewReader(data))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var v any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+		return resp.StatusCode, v
+	}
+
+	t.Run("fixture", func(t *testing.T) {
+		code, v := request(t, http.MethodGet, "/repos/octo/hello/releases/latest", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"tag_name": "v1.2.3"}, v)
+
+		code, v = request(t, http.MethodPost, "/repos/octo/hello/issues", map[string]any{"title": "bug"})
+		assert.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, map[string]any{"number": float64(42)}, v)
+	})
+
+	t.Run("repo", func(t *testing.T) {
+		code, v := request(t, http.MethodGet, "/repos/octo/other", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "octo/other", v.(map[string]any)["full_name"])
+	})
+
+	t.Run("list", func(t *testing.T) {
+		code, v := request(t, http.MethodGet, "/repos/octo/other/issues", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []any{}, v)
+	})
+
+	t.Run("create and update", func(t *testing.T) {
+		code, v := request(t, http.MethodPost, "/repos/octo/other/check-runs", map[string]any{"name": "lint", "head_sha": "abc"})
+		assert.Equal(t, http.StatusCreated, code)
+		checkRun := v.(map[string]any)
+		assert.Equal(t, "lint", checkRun["name"])
+		assert.NotZero(t, checkRun["id"])
+
+		code, v = request(t, http.MethodPatch, "/repos/octo/other/check-runs/7", map[string]any{"conclusion": "success"})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"id": float64(7), "conclusion": "success"}, v)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		code, v := request(t, http.MethodPost, "/repos/octo/other/releases", map[string]any{"tag_name": "v1"})
+		assert.Equal(t, http.StatusCreated, code)
+		release := v.(map[string]any)
+		assert.Equal(t, "https://github.com/octo/other/releases/tag/v1", release["html_url"])
+		assert.Contains(t, release["upload_url"], base+"/repos/octo/other/releases/")
+	})
+
+	t.Run("graphql", func(t *testing.T) {
+		code, v := request(t, http.MethodPost, "/graphql", map[string]any{"query": "{ viewer { login } }"})
+		assert.Equal(t, http.StatusNotImplemented, code)
+		assert.Contains(t, v.(map[string]any)["errors"].([]any)[0].(map[string]any)["message"], "not implemented")
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "graphql.post.json"), []byte(`{"data":{"viewer":{"login":"octo"}}}`), 0o600))
+		code, v = request(t, http.MethodPost, "/graphql", map[string]any{"query": "{ viewer { login } }"})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"data": map[string]any{"viewer": map[string]any{"login": "octo"}}}, v)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		code, v := request(t, http.MethodDelete, "/repos/octo/other", nil)
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, "Not Found", v.(map[string]any)["message"])
+	})
+}
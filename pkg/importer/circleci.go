@@ -0,0 +1,698 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// circlePredefined are the predefined variables of CircleCI with their equivalent in the workflow
+var circlePredefined = map[string]string{
+	"CIRCLECI":                 "true",
+	"CIRCLE_BRANCH":            "${{ github.ref_name }}",
+	"CIRCLE_BUILD_NUM":         "${{ github.run_number }}",
+	"CIRCLE_JOB":               "${{ github.job }}",
+	"CIRCLE_NODE_INDEX":        "0",
+	"CIRCLE_NODE_TOTAL":        "1",
+	"CIRCLE_PROJECT_REPONAME":  "${{ github.event.repository.name }}",
+	"CIRCLE_PROJECT_USERNAME":  "${{ github.repository_owner }}",
+	"CIRCLE_REPOSITORY_URL":    "${{ github.server_url }}/${{ github.repository }}",
+	"CIRCLE_SHA1":              "${{ github.sha }}",
+	"CIRCLE_TAG":               "",
+	"CIRCLE_WORKING_DIRECTORY": "${{ github.workspace }}",
+}
+
+// circlePipelineValues are the pipeline values of CircleCI with their equivalent in the workflow
+var circlePipelineValues = map[string]string{
+	"pipeline.git.branch":   "${{ github.ref_name }}",
+	"pipeline.git.revision": "${{ github.sha }}",
+	"pipeline.number":       "${{ github.run_number }}",
+	"pipeline.id":           "${{ github.run_id }}",
+}
+
+var (
+	circleVariableReference  = regexp.MustCompile(`\b(CIRCLECI|CIRCLE_[A-Z0-9_]+)\b`)
+	circleMatrixReference    = regexp.MustCompile(`<<\s*matrix\.([A-Za-z0-9_-]+)\s*>>`)
+	circleParameterReference = regexp.MustCompile(`<<\s*((?:parameters|pipeline)\.[A-Za-z0-9_.-]+)\s*>>`)
+)
+
+// circleUnsupportedSteps are the steps of CircleCI which aren't converted, with the reason
+var circleUnsupportedSteps = map[string]string{
+	"save_cache":           "isn't converted, use actions/cache",
+	"restore_cache":        "isn't converted, use actions/cache",
+	"persist_to_workspace": "isn't converted, use actions/upload-artifact",
+	"attach_workspace":     "isn't converted, use actions/download-artifact",
+	"store_test_results":   "isn't converted",
+	"add_ssh_keys":         "isn't converted",
+	"setup_remote_docker":  "isn't needed, act mounts the docker socket into the job containers",
+}
+
+type circleConfig struct {
+	Orbs       map[string]interface{}      `yaml:"orbs"`
+	Executors  map[string]yaml.Node        `yaml:"executors"`
+	Commands   map[string]yaml.Node        `yaml:"commands"`
+	Parameters map[string]*circleParameter `yaml:"parameters"`
+	Jobs       yaml.Node                   `yaml:"jobs"`
+	Workflows  yaml.Node                   `yaml:"workflows"`
+}
+
+type circleParameter struct {
+	Type    string    `yaml:"type"`
+	Default yaml.Node `yaml:"default"`
+}
+
+type circleExecutor struct {
+	Docker           []*circleImage    `yaml:"docker"`
+	Machine          yaml.Node         `yaml:"machine"`
+	Macos            yaml.Node         `yaml:"macos"`
+	ResourceClass    string            `yaml:"resource_class"`
+	WorkingDirectory string            `yaml:"working_directory"`
+	Environment      map[string]string `yaml:"environment"`
+	Shell            string            `yaml:"shell"`
+}
+
+type circleImage struct {
+	Image       string            `yaml:"image"`
+	Name        string            `yaml:"name"`
+	Environment map[string]string `yaml:"environment"`
+	Entrypoint  yaml.Node         `yaml:"entrypoint"`
+	Command     yaml.Node         `yaml:"command"`
+	User        string            `yaml:"user"`
+}
+
+type circleJob struct {
+	circleExecutor `yaml:",inline"`
+	Executor       yaml.Node                   `yaml:"executor"`
+	Parameters     map[string]*circleParameter `yaml:"parameters"`
+	Parallelism    int                         `yaml:"parallelism"`
+	Steps          []yaml.Node                 `yaml:"steps"`
+}
+
+type circleCommand struct {
+	Parameters map[string]*circleParameter `yaml:"parameters"`
+	Steps      []yaml.Node                 `yaml:"steps"`
+}
+
+type circleRun struct {
+	Name             string            `yaml:"name"`
+	Command          string            `yaml:"command"`
+	Environment      map[string]string `yaml:"environment"`
+	WorkingDirectory string            `yaml:"working_directory"`
+	Shell            string            `yaml:"shell"`
+	Background       bool              `yaml:"background"`
+	When             string            `yaml:"when"`
+	NoOutputTimeout  string            `yaml:"no_output_timeout"`
+}
+
+// circleWorkflowJob is a job of a workflow of CircleCI
+type circleWorkflowJob struct {
+	job      string // the job of jobs:
+	id       string
+	name     string
+	requires []string
+	args     map[string]yaml.Node
+	matrix   map[string][]string
+}
+
+// circleImporter converts the config of CircleCI
+type circleImporter struct {
+	*converter
+	config *circleConfig
+	jobs   map[string]*yaml.Node
+}
+
+// ImportCircleCI converts a .circleci/config.yml. The jobs of the workflows become the jobs of
+// the workflow with requires: as needs:, the first image of docker: becomes the container of the
+// job and the others its services. Commands, executors and parameters are resolved, orbs,
+// caches and workspaces aren't converted.
+func ImportCircleCI(r io.Reader, source string) (*Conversion, error) {
+	root, err := decode(r, source)
+	if err != nil {
+		return nil, err
+	}
+	config := &circleConfig{}
+	if err := root.Decode(config); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", source, err)
+	}
+	c := &circleImporter{converter: &converter{}, config: config, jobs: map[string]*yaml.Node{}}
+	for name := range config.Orbs {
+		c.note("", "the orb %s isn't converted", name)
+	}
+
+	jobOrder, jobNodes := keys(&config.Jobs)
+	for name, node := range jobNodes {
+		c.jobs[name] = node
+	}
+
+	workflowJobs, err := c.workflowJobs(jobOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := newMapping()
+	workflow.set("name", source)
+	workflow.set("on", []string{"push"})
+	env := map[string]string{}
+	var content interface{}
+	if err := root.Decode(&content); err != nil {
+		return nil, err
+	}
+	if err := c.predefinedEnv(env, content, circleVariableReference, circlePredefined); err != nil {
+		return nil, err
+	}
+	workflow.set("env", env)
+
+	jobs := newMapping()
+	for _, wj := range workflowJobs {
+		job, err := c.job(wj)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", wj.job, err)
+		}
+		jobs.set(wj.id, job)
+	}
+	workflow.set("jobs", jobs)
+	return c.conversion(source, workflow)
+}
+
+// workflowJobs returns the jobs of the workflows, or every job if the config has no workflows.
+// The workflows are merged into the workflow, a job which is in several of them is converted
+// once per workflow.
+func (c *circleImporter) workflowJobs(jobOrder []string) ([]*circleWorkflowJob, error) {
+	ids := jobIDs{}
+	workflowOrder, workflows := keys(&c.config.Workflows)
+	if len(workflowOrder) == 0 {
+		jobs := make([]*circleWorkflowJob, 0, len(jobOrder))
+		for _, name := range jobOrder {
+			jobs = append(jobs, &circleWorkflowJob{job: name, id: ids.add(name), name: name})
+		}
+		return jobs, nil
+	}
+
+	jobs := []*circleWorkflowJob{}
+	for _, workflowName := range workflowOrder {
+		if workflowName == "version" {
+			continue
+		}
+		order, workflow := keys(workflows[workflowName])
+		for _, key := range order {
+			if key != "jobs" {
+				c.note("", "%s: of the workflow %s isn't converted", key, workflowName)
+			}
+		}
+		if workflow["jobs"] == nil {
+			continue
+		}
+
+		byName := map[string]*circleWorkflowJob{}
+		// the approvals and the jobs of orbs aren't converted, the jobs requiring them need their requires
+		skipped := map[string][]string{}
+		workflowJobs := []*circleWorkflowJob{}
+		for _, entry := range workflow["jobs"].Content {
+			wj := &circleWorkflowJob{args: map[string]yaml.Node{}}
+			approval := false
+			if entry.Kind == yaml.ScalarNode {
+				wj.job = entry.Value
+			} else {
+				entryOrder, entryValues := keys(entry)
+				if len(entryOrder) != 1 {
+					return nil, fmt.Errorf("line %d: invalid job of the workflow %s", entry.Line, workflowName)
+				}
+				wj.job = entryOrder[0]
+				argOrder, args := keys(entryValues[wj.job])
+				for _, arg := range argOrder {
+					value := args[arg]
+					switch arg {
+					case "requires":
+						var requires stringList
+						if err := value.Decode(&requires); err != nil {
+							return nil, fmt.Errorf("line %d: invalid requires: %w", value.Line, err)
+						}
+						wj.requires = requires
+					case "name":
+						wj.name = circleMatrixReference.ReplaceAllString(value.Value, "$${{ matrix.$1 }}")
+					case "type":
+						if value.Value == "approval" {
+							approval = true
+						}
+					case "matrix":
+						var matrix struct {
+							Parameters map[string]stringList `yaml:"parameters"`
+						}
+						if err := value.Decode(&matrix); err != nil {
+							return nil, fmt.Errorf("line %d: invalid matrix: %w", value.Line, err)
+						}
+						wj.matrix = map[string][]string{}
+						for k, v := range matrix.Parameters {
+							wj.matrix[k] = v
+						}
+					case "context", "filters":
+						c.note(wj.job, "%s: isn't converted", arg)
+					default:
+						wj.args[arg] = *value
+					}
+				}
+			}
+			if approval {
+				c.note(wj.job, "is an approval of the workflow %s, the jobs requiring it don't wait for it", workflowName)
+				skipped[wj.job] = wj.requires
+				continue
+			}
+			if _, ok := c.jobs[wj.job]; !ok {
+				c.note(wj.job, "is a job of an orb, it isn't converted")
+				skipped[wj.job] = wj.requires
+				continue
+			}
+			if wj.name == "" {
+				wj.name = wj.job
+			}
+			wj.id = ids.add(wj.name)
+			byName[wj.name] = wj
+			workflowJobs = append(workflowJobs, wj)
+		}
+
+		for _, wj := range workflowJobs {
+			needs := []string{}
+			seen := map[string]bool{}
+			var require func(names []string)
+			require = func(names []string) {
+				for _, name := range names {
+					if seen[name] {
+						continue
+					}
+					seen[name] = true
+					if requires, ok := skipped[name]; ok {
+						require(requires)
+					} else if required, ok := byName[name]; ok {
+						needs = append(needs, required.id)
+					} else {
+						c.note(wj.job, "requires %s, which isn't converted", name)
+					}
+				}
+			}
+			require(wj.requires)
+			wj.requires = needs
+		}
+		jobs = append(jobs, workflowJobs...)
+	}
+	return jobs, nil
+}
+
+// job converts a job of a workflow with its arguments
+func (c *circleImporter) job(wj *circleWorkflowJob) (*mapping, error) {
+	raw := c.jobs[wj.job]
+	var declared struct {
+		Parameters map[string]*circleParameter `yaml:"parameters"`
+	}
+	if err := raw.Decode(&declared); err != nil {
+		return nil, err
+	}
+	args := wj.args
+	if len(wj.matrix) > 0 {
+		args = map[string]yaml.Node{}
+		for k, v := range wj.args {
+			args[k] = v
+		}
+		for k := range wj.matrix {
+			args[k] = yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprintf("${{ matrix.%s }}", k)}
+		}
+	}
+	job := &circleJob{}
+	if err := c.substitute(raw, declared.Parameters, args).Decode(job); err != nil {
+		return nil, err
+	}
+
+	result := newMapping()
+	if wj.id != wj.name {
+		result.set("name", wj.name)
+	}
+	result.set("needs", wj.requires)
+
+	executor := job.circleExecutor
+	if !job.Executor.IsZero() {
+		resolved, err := c.executor(wj.job, &job.Executor)
+		if err != nil {
+			return nil, err
+		}
+		if resolved != nil {
+			executor = mergeExecutors(*resolved, job.circleExecutor)
+		}
+	}
+
+	env := map[string]string{}
+	for k, v := range executor.Environment {
+		env[k] = v
+	}
+	switch {
+	case len(executor.Docker) > 0:
+		result.set("runs-on", "ubuntu-latest")
+		primary := executor.Docker[0]
+		container := newMapping()
+		container.set("image", primary.Image)
+		container.set("env", primary.Environment)
+		if primary.User != "" {
+			container.set("options", "--user "+primary.User)
+		}
+		result.set("container", container)
+		if !primary.Entrypoint.IsZero() || !primary.Command.IsZero() {
+			c.note(wj.job, "the entrypoint and command of the image aren't converted")
+		}
+		if len(executor.Docker) > 1 {
+			services := newMapping()
+			for _, image := range executor.Docker[1:] {
+				id := image.Name
+				if id == "" {
+					id = serviceID(image.Image)
+				}
+				service := newMapping()
+				service.set("image", image.Image)
+				service.set("env", image.Environment)
+				services.set(id, service)
+			}
+			result.set("services", services)
+			c.note(wj.job, "the containers after the first of docker: are services, which are reachable by their names instead of localhost")
+		}
+	case !executor.Macos.IsZero():
+		result.set("runs-on", "macos-latest")
+		c.note(wj.job, "runs on macos-latest, the xcode version isn't converted")
+	default:
+		if executor.Machine.IsZero() {
+			c.note(wj.job, "has no executor, it runs on ubuntu-latest")
+		}
+		result.set("runs-on", "ubuntu-latest")
+	}
+	if executor.ResourceClass != "" {
+		c.note(wj.job, "resource_class: isn't converted")
+	}
+	if executor.WorkingDirectory != "" && executor.WorkingDirectory != "~/project" {
+		c.note(wj.job, "working_directory: isn't converted, the steps run in the workspace")
+	}
+	for k, v := range job.Environment {
+		env[k] = v
+	}
+
+	if len(wj.matrix) > 0 {
+		matrix := newMapping()
+		for _, k := range sortedMatrixKeys(wj.matrix) {
+			matrix.set(k, wj.matrix[k])
+		}
+		strategy := newMapping()
+		strategy.set("matrix", matrix)
+		result.set("strategy", strategy)
+		if job.Parallelism > 1 {
+			c.note(wj.job, "parallelism: isn't converted for a job with a matrix")
+		}
+	} else if job.Parallelism > 1 {
+		parallelMatrix(result, env, job.Parallelism, 0, "CIRCLE_NODE_INDEX", "CIRCLE_NODE_TOTAL")
+	}
+	result.set("env", env)
+	if executor.Shell != "" {
+		run := newMapping()
+		run.set("shell", executor.Shell+" {0}")
+		defaults := newMapping()
+		defaults.set("run", run)
+		result.set("defaults", defaults)
+	}
+
+	steps, err := c.steps(wj.job, job.Steps, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		result.append("steps", step)
+	}
+	return result, nil
+}
+
+// executor resolves the executor of a job, which is the name of an executor or a mapping with
+// the name and the arguments of its parameters
+func (c *circleImporter) executor(job string, node *yaml.Node) (*circleExecutor, error) {
+	name := node.Value
+	args := map[string]yaml.Node{}
+	if node.Kind == yaml.MappingNode {
+		order, values := keys(node)
+		for _, k := range order {
+			if k == "name" {
+				name = values[k].Value
+			} else {
+				args[k] = *values[k]
+			}
+		}
+	}
+	raw, ok := c.config.Executors[name]
+	if !ok {
+		c.note(job, "the executor %s isn't converted", name)
+		return nil, nil
+	}
+	var declared struct {
+		Parameters map[string]*circleParameter `yaml:"parameters"`
+	}
+	if err := raw.Decode(&declared); err != nil {
+		return nil, err
+	}
+	executor := &circleExecutor{}
+	if err := c.substitute(&raw, declared.Parameters, args).Decode(executor); err != nil {
+		return nil, fmt.Errorf("executor %s: %w", name, err)
+	}
+	return executor, nil
+}
+
+// mergeExecutors returns the executor with the keys the job sets itself
+func mergeExecutors(executor circleExecutor, job circleExecutor) circleExecutor {
+	if len(job.Docker) > 0 || !job.Machine.IsZero() || !job.Macos.IsZero() {
+		executor.Docker, executor.Machine, executor.Macos = job.Docker, job.Machine, job.Macos
+	}
+	if job.ResourceClass != "" {
+		executor.ResourceClass = job.ResourceClass
+	}
+	if job.WorkingDirectory != "" {
+		executor.WorkingDirectory = job.WorkingDirectory
+	}
+	if job.Shell != "" {
+		executor.Shell = job.Shell
+	}
+	return executor
+}
+
+// steps converts the steps of a job or a command, the commands are expanded
+func (c *circleImporter) steps(job string, steps []yaml.Node, depth int) ([]*mapping, error) {
+	if depth > 10 {
+		return nil, fmt.Errorf("the commands are nested too deeply")
+	}
+	result := []*mapping{}
+	for i := range steps {
+		node := &steps[i]
+		name := node.Value
+		var value *yaml.Node
+		if node.Kind == yaml.MappingNode {
+			order, values := keys(node)
+			if len(order) != 1 {
+				return nil, fmt.Errorf("line %d: invalid step", node.Line)
+			}
+			name, value = order[0], values[order[0]]
+		}
+
+		switch name {
+		case "checkout":
+			step := newMapping()
+			step.set("uses", checkoutAction)
+			result = append(result, step)
+			continue
+		case "run", "deploy":
+			step, err := c.run(job, value)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, step)
+			continue
+		case "store_artifacts":
+			var artifacts struct {
+				Path        string `yaml:"path"`
+				Destination string `yaml:"destination"`
+			}
+			if err := value.Decode(&artifacts); err != nil {
+				return nil, fmt.Errorf("line %d: invalid store_artifacts: %w", value.Line, err)
+			}
+			if artifacts.Destination == "" {
+				artifacts.Destination = path.Base(artifacts.Path)
+			}
+			with := newMapping()
+			with.set("name", artifacts.Destination)
+			with.set("path", artifacts.Path)
+			step := newMapping()
+			step.set("uses", "actions/upload-artifact@v4")
+			step.set("with", with)
+			result = append(result, step)
+			continue
+		case "when", "unless":
+			var conditional struct {
+				Condition yaml.Node   `yaml:"condition"`
+				Steps     []yaml.Node `yaml:"steps"`
+			}
+			if err := value.Decode(&conditional); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", value.Line, name, err)
+			}
+			if conditional.Condition.Kind == yaml.ScalarNode && (conditional.Condition.Value == "true" || conditional.Condition.Value == "false") {
+				if (conditional.Condition.Value == "true") != (name == "when") {
+					continue
+				}
+			} else {
+				c.note(job, "the condition of %s: isn't converted, its steps run", name)
+			}
+			nested, err := c.steps(job, conditional.Steps, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, nested...)
+			continue
+		}
+
+		if reason, ok := circleUnsupportedSteps[name]; ok {
+			c.note(job, "the step %s %s", name, reason)
+			continue
+		}
+		raw, ok := c.config.Commands[name]
+		if !ok {
+			if strings.Contains(name, "/") {
+				c.note(job, "the step %s is a command of an orb, it isn't converted", name)
+			} else {
+				c.note(job, "the step %s isn't converted", name)
+			}
+			continue
+		}
+		args := map[string]yaml.Node{}
+		if value != nil {
+			argOrder, argValues := keys(value)
+			for _, k := range argOrder {
+				args[k] = *argValues[k]
+			}
+		}
+		var declared struct {
+			Parameters map[string]*circleParameter `yaml:"parameters"`
+		}
+		if err := raw.Decode(&declared); err != nil {
+			return nil, err
+		}
+		command := &circleCommand{}
+		if err := c.substitute(&raw, declared.Parameters, args).Decode(command); err != nil {
+			return nil, fmt.Errorf("command %s: %w", name, err)
+		}
+		nested, err := c.steps(job, command.Steps, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, nested...)
+	}
+	return result, nil
+}
+
+func (c *circleImporter) run(job string, node *yaml.Node) (*mapping, error) {
+	run := &circleRun{}
+	if node.Kind == yaml.ScalarNode {
+		run.Command = node.Value
+	} else if err := node.Decode(run); err != nil {
+		return nil, fmt.Errorf("line %d: invalid run: %w", node.Line, err)
+	}
+
+	step := newMapping()
+	step.set("name", run.Name)
+	switch run.When {
+	case "", "on_success":
+	case "always":
+		step.set("if", "always()")
+	case "on_fail":
+		step.set("if", "failure()")
+	default:
+		c.note(job, "when: %s of a step isn't converted", run.When)
+	}
+	if run.WorkingDirectory != "" {
+		if strings.HasPrefix(run.WorkingDirectory, "~") || strings.HasPrefix(run.WorkingDirectory, "/") {
+			c.note(job, "the working directory %s of a step isn't converted", run.WorkingDirectory)
+		} else {
+			step.set("working-directory", run.WorkingDirectory)
+		}
+	}
+	if run.Shell != "" {
+		step.set("shell", run.Shell+" {0}")
+	}
+	step.set("env", run.Environment)
+	if run.NoOutputTimeout != "" {
+		c.note(job, "no_output_timeout: isn't converted")
+	}
+	command := run.Command
+	if run.Background {
+		// the process keeps running while the next steps run, like on CircleCI
+		command = fmt.Sprintf("(\n%s\n) > /dev/null 2>&1 &", strings.TrimRight(command, "\n"))
+	}
+	step.set("run", command)
+	return step, nil
+}
+
+// substitute returns a copy of the node with the parameters replaced by the arguments or the
+// defaults of the parameters, and the pipeline values by their equivalents
+func (c *circleImporter) substitute(node *yaml.Node, parameters map[string]*circleParameter, args map[string]yaml.Node) *yaml.Node {
+	values := map[string]string{}
+	for name, parameter := range parameters {
+		if parameter != nil && !parameter.Default.IsZero() {
+			values["parameters."+name] = parameter.Default.Value
+		}
+	}
+	for name, arg := range args {
+		values["parameters."+name] = arg.Value
+	}
+	for name, parameter := range c.config.Parameters {
+		if parameter != nil {
+			values["pipeline.parameters."+name] = parameter.Default.Value
+		}
+	}
+	for name, value := range circlePipelineValues {
+		values[name] = value
+	}
+	return substituteNode(node, values)
+}
+
+func substituteNode(node *yaml.Node, values map[string]string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	copied := *node
+	if node.Kind == yaml.AliasNode {
+		return substituteNode(node.Alias, values)
+	}
+	if node.Kind == yaml.ScalarNode {
+		copied.Value = circleParameterReference.ReplaceAllStringFunc(node.Value, func(reference string) string {
+			name := circleParameterReference.FindStringSubmatch(reference)[1]
+			if value, ok := values[name]; ok {
+				return value
+			}
+			return reference
+		})
+		if copied.Value != node.Value {
+			// a boolean or number parameter stays a value of its type
+			copied.Tag = ""
+			if _, err := strconv.ParseFloat(copied.Value, 64); err != nil && copied.Value != "true" && copied.Value != "false" {
+				copied.Tag = "!!str"
+			}
+		}
+		return &copied
+	}
+	copied.Content = make([]*yaml.Node, 0, len(node.Content))
+	for _, content := range node.Content {
+		copied.Content = append(copied.Content, substituteNode(content, values))
+	}
+	return &copied
+}
+
+func sortedMatrixKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
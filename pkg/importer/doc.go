@@ -0,0 +1,9 @@
+// Package importer converts the configurations of other CI systems into GitHub Actions workflows.
+//
+// The conversion is best-effort: the jobs, their images, services, env, dependencies and scripts
+// are converted, the rest is described by the notes of the conversion, which are written at the
+// top of the workflow. Teams migrating to GitHub Actions can preview their pipelines with act:
+//
+//	act import .gitlab-ci.yml -o .github/workflows/gitlab.yml
+//	act -n -W .github/workflows/gitlab.yml
+package importer
@@ -0,0 +1,611 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// gitlabStages are the stages of GitLab if the configuration has no stages:
+var gitlabStages = []string{"build", "test", "deploy"}
+
+// gitlabGlobalKeys are the keys of .gitlab-ci.yml which aren't jobs
+var gitlabGlobalKeys = map[string]bool{
+	"default": true, "include": true, "stages": true, "variables": true, "workflow": true,
+	"image": true, "services": true, "before_script": true, "after_script": true, "cache": true,
+}
+
+// gitlabDefaultKeys are the keys of default: the jobs inherit, the global keys of the same
+// name are the old way to set them
+var gitlabDefaultKeys = []string{"image", "services", "before_script", "after_script", "cache", "artifacts", "retry", "timeout", "tags", "interruptible"}
+
+// gitlabJobKeys are the keys of the jobs which are converted
+var gitlabJobKeys = map[string]bool{
+	"stage": true, "image": true, "services": true, "before_script": true, "script": true, "after_script": true,
+	"variables": true, "needs": true, "dependencies": true, "when": true, "allow_failure": true, "timeout": true,
+	"retry": true, "parallel": true, "artifacts": true, "extends": true,
+}
+
+// gitlabPredefined are the predefined variables of GitLab with their equivalent in the workflow
+var gitlabPredefined = map[string]string{
+	"GITLAB_CI":            "true",
+	"CI_COMMIT_SHA":        "${{ github.sha }}",
+	"CI_COMMIT_REF_NAME":   "${{ github.ref_name }}",
+	"CI_COMMIT_BRANCH":     "${{ github.ref_name }}",
+	"CI_DEFAULT_BRANCH":    "${{ github.event.repository.default_branch }}",
+	"CI_JOB_NAME":          "${{ github.job }}",
+	"CI_NODE_INDEX":        "", // set by the jobs with parallel:
+	"CI_NODE_TOTAL":        "1",
+	"CI_PIPELINE_ID":       "${{ github.run_id }}",
+	"CI_PIPELINE_SOURCE":   "${{ github.event_name }}",
+	"CI_PROJECT_DIR":       "${{ github.workspace }}",
+	"CI_PROJECT_NAME":      "${{ github.event.repository.name }}",
+	"CI_PROJECT_NAMESPACE": "${{ github.repository_owner }}",
+	"CI_PROJECT_PATH":      "${{ github.repository }}",
+	"CI_PROJECT_URL":       "${{ github.server_url }}/${{ github.repository }}",
+	"CI_SERVER_URL":        "${{ github.server_url }}",
+}
+
+var (
+	gitlabVariableReference = regexp.MustCompile(`\b(GITLAB_CI|CI_[A-Z0-9_]+)\b`)
+	gitlabDurationPart      = regexp.MustCompile(`(\d+)\s*([a-z]*)`)
+	gitlabDurationUnits     = map[string]int{
+		"": 1, "s": 1, "sec": 1, "secs": 1, "second": 1, "seconds": 1,
+		"m": 60, "min": 60, "mins": 60, "minute": 60, "minutes": 60,
+		"h": 3600, "hr": 3600, "hrs": 3600, "hour": 3600, "hours": 3600,
+		"d": 86400, "day": 86400, "days": 86400,
+	}
+)
+
+// gitlabJob is a job of .gitlab-ci.yml with extends: and default: resolved
+type gitlabJob struct {
+	Stage        string                    `yaml:"stage"`
+	Image        *gitlabImage              `yaml:"image"`
+	Services     []*gitlabImage            `yaml:"services"`
+	BeforeScript stringList                `yaml:"before_script"`
+	Script       stringList                `yaml:"script"`
+	AfterScript  stringList                `yaml:"after_script"`
+	Variables    map[string]gitlabVariable `yaml:"variables"`
+	Needs        *[]gitlabNeed             `yaml:"needs"`
+	Dependencies *[]string                 `yaml:"dependencies"`
+	When         string                    `yaml:"when"`
+	AllowFailure yaml.Node                 `yaml:"allow_failure"`
+	Timeout      string                    `yaml:"timeout"`
+	Retry        yaml.Node                 `yaml:"retry"`
+	Parallel     yaml.Node                 `yaml:"parallel"`
+	Artifacts    *gitlabArtifacts          `yaml:"artifacts"`
+
+	name     string
+	id       string
+	stage    int
+	keys     []string
+	parallel bool // the job is converted to a matrix
+}
+
+// gitlabImage is an image or a service, either its name or a mapping
+type gitlabImage struct {
+	Name       string                    `yaml:"name"`
+	Alias      string                    `yaml:"alias"`
+	Entrypoint []string                  `yaml:"entrypoint"`
+	Command    []string                  `yaml:"command"`
+	Variables  map[string]gitlabVariable `yaml:"variables"`
+}
+
+func (i *gitlabImage) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		i.Name = node.Value
+		return nil
+	}
+	type plain gitlabImage
+	return node.Decode((*plain)(i))
+}
+
+// gitlabVariable is the value of a variable, either the value or a mapping with the value
+type gitlabVariable string
+
+func (v *gitlabVariable) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = gitlabVariable(node.Value)
+		return nil
+	}
+	var variable struct {
+		Value string `yaml:"value"`
+	}
+	if err := node.Decode(&variable); err != nil {
+		return err
+	}
+	*v = gitlabVariable(variable.Value)
+	return nil
+}
+
+// gitlabNeed is a job the job needs, either its name or a mapping with the job. The needs of
+// other pipelines or projects are empty.
+type gitlabNeed string
+
+func (n *gitlabNeed) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*n = gitlabNeed(node.Value)
+		return nil
+	}
+	var need struct {
+		Job      string `yaml:"job"`
+		Pipeline string `yaml:"pipeline"`
+		Project  string `yaml:"project"`
+	}
+	if err := node.Decode(&need); err != nil {
+		return err
+	}
+	if need.Pipeline == "" && need.Project == "" {
+		*n = gitlabNeed(need.Job)
+	}
+	return nil
+}
+
+type gitlabArtifacts struct {
+	Paths []string `yaml:"paths"`
+	When  string   `yaml:"when"`
+}
+
+// ImportGitLab converts a .gitlab-ci.yml. The jobs run in the order of their stages unless they
+// have needs:, before_script: and script: become a run step and after_script: a step which
+// always runs. The jobs inherit default: and extends: is resolved, rules: and only: aren't
+// converted, so every job runs on push.
+func ImportGitLab(r io.Reader, source string) (*Conversion, error) {
+	root, err := decode(r, source)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]interface{}
+	if err := root.Decode(&config); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", source, err)
+	}
+	order, _ := keys(root)
+
+	c := &converter{}
+	for _, key := range []string{"include", "workflow"} {
+		if _, ok := config[key]; ok {
+			c.note("", "%s: isn't converted", key)
+		}
+	}
+
+	defaults := map[string]interface{}{}
+	for _, key := range gitlabDefaultKeys {
+		if value, ok := config[key]; ok {
+			defaults[key] = value
+		}
+	}
+	if d, ok := config["default"].(map[string]interface{}); ok {
+		for k, v := range d {
+			defaults[k] = v
+		}
+	}
+
+	stages := gitlabStages
+	if raw, ok := config["stages"]; ok {
+		var list stringList
+		if err := remarshal(raw, &list); err != nil {
+			return nil, fmt.Errorf("invalid stages: %w", err)
+		}
+		stages = list
+	}
+	stages = append(append([]string{".pre"}, stages...), ".post")
+	stageIndex := map[string]int{}
+	for i, stage := range stages {
+		stageIndex[stage] = i
+	}
+
+	jobs := []*gitlabJob{}
+	byName := map[string]*gitlabJob{}
+	ids := jobIDs{}
+	for _, name := range order {
+		raw, ok := config[name].(map[string]interface{})
+		if gitlabGlobalKeys[name] || strings.HasPrefix(name, ".") || !ok {
+			continue
+		}
+		if _, ok := raw["trigger"]; ok {
+			c.note(name, "triggers a downstream pipeline, it isn't converted")
+			continue
+		}
+		resolved, err := gitlabExtends(config, raw, 0)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+		for k, v := range defaults {
+			if _, ok := resolved[k]; !ok {
+				resolved[k] = v
+			}
+		}
+		job := &gitlabJob{}
+		if err := remarshal(resolved, job); err != nil {
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+		if job.When == "never" {
+			c.note(name, "when: never, it isn't converted")
+			continue
+		}
+		if job.Stage == "" {
+			job.Stage = "test"
+		}
+		index, ok := stageIndex[job.Stage]
+		if !ok {
+			return nil, fmt.Errorf("job %s: unknown stage '%s'", name, job.Stage)
+		}
+		job.name = name
+		job.id = ids.add(name)
+		job.stage = index
+		for k := range resolved {
+			job.keys = append(job.keys, k)
+		}
+		sort.Strings(job.keys)
+		jobs = append(jobs, job)
+		byName[name] = job
+	}
+	sort.SliceStable(jobs, func(a, b int) bool { return jobs[a].stage < jobs[b].stage })
+
+	workflow := newMapping()
+	workflow.set("name", source)
+	workflow.set("on", []string{"push"})
+
+	env := map[string]string{}
+	if raw, ok := config["variables"]; ok {
+		variables := map[string]gitlabVariable{}
+		if err := remarshal(raw, &variables); err != nil {
+			return nil, fmt.Errorf("invalid variables: %w", err)
+		}
+		for k, v := range variables {
+			env[k] = string(v)
+		}
+	}
+	if err := c.predefinedEnv(env, config, gitlabVariableReference, gitlabPredefined); err != nil {
+		return nil, err
+	}
+	workflow.set("env", env)
+
+	jobsMapping := newMapping()
+	for _, job := range jobs {
+		job.parallel = !job.Parallel.IsZero()
+	}
+	for _, job := range jobs {
+		jobsMapping.set(job.id, c.gitlabJob(job, jobs, byName))
+	}
+	workflow.set("jobs", jobsMapping)
+	return c.conversion(source, workflow)
+}
+
+func (c *converter) gitlabJob(job *gitlabJob, jobs []*gitlabJob, byName map[string]*gitlabJob) *mapping {
+	for _, k := range job.keys {
+		if !gitlabJobKeys[k] {
+			c.note(job.name, "%s: isn't converted", k)
+		}
+	}
+
+	needs := []*gitlabJob{}
+	if job.Needs != nil {
+		for _, need := range *job.Needs {
+			if need == "" {
+				c.note(job.name, "the needs of other pipelines aren't converted")
+				continue
+			}
+			needed, ok := byName[string(need)]
+			if !ok {
+				c.note(job.name, "needs the job %s, which isn't converted", need)
+				continue
+			}
+			needs = append(needs, needed)
+		}
+	} else {
+		// the jobs without needs: run once the jobs of the stage before succeeded
+		previous := -1
+		for _, other := range jobs {
+			if other.stage < job.stage && other.stage > previous {
+				previous = other.stage
+			}
+		}
+		for _, other := range jobs {
+			if other.stage == previous {
+				needs = append(needs, other)
+			}
+		}
+	}
+	// the jobs get the artifacts of the jobs they need or of dependencies:
+	dependencies := needs
+	if job.Dependencies != nil {
+		dependencies = []*gitlabJob{}
+		for _, name := range *job.Dependencies {
+			if dependency, ok := byName[name]; ok {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+
+	result := newMapping()
+	if job.id != job.name {
+		result.set("name", job.name)
+	}
+	needIDs := make([]string, 0, len(needs))
+	for _, need := range needs {
+		needIDs = append(needIDs, need.id)
+	}
+	result.set("needs", needIDs)
+	result.set("runs-on", "ubuntu-latest")
+
+	switch job.When {
+	case "", "on_success":
+	case "always":
+		result.set("if", "always()")
+	case "on_failure":
+		result.set("if", "failure()")
+	case "manual":
+		c.note(job.name, "runs only when it is started manually on GitLab")
+	default:
+		c.note(job.name, "when: %s isn't converted", job.When)
+	}
+
+	var allowFailure bool
+	if job.AllowFailure.Kind == yaml.MappingNode {
+		allowFailure = true
+		c.note(job.name, "allow_failure: allows every exit code")
+	} else if !job.AllowFailure.IsZero() {
+		_ = job.AllowFailure.Decode(&allowFailure)
+	}
+	if allowFailure {
+		result.set("continue-on-error", true)
+	}
+	if job.Timeout != "" {
+		if minutes, err := gitlabTimeout(job.Timeout); err == nil {
+			result.set("timeout-minutes", minutes)
+		} else {
+			c.note(job.name, "%v", err)
+		}
+	}
+
+	env := map[string]string{}
+	for k, v := range job.Variables {
+		env[k] = string(v)
+	}
+	if job.parallel {
+		c.gitlabParallel(job, result, env)
+	}
+
+	if job.Image != nil && job.Image.Name != "" {
+		container := newMapping()
+		container.set("image", job.Image.Name)
+		result.set("container", container)
+		if len(job.Image.Entrypoint) > 0 {
+			c.note(job.name, "the entrypoint of the image isn't converted")
+		}
+	}
+	if len(job.Services) > 0 {
+		services := newMapping()
+		for _, service := range job.Services {
+			id := service.Alias
+			if id == "" {
+				id = serviceID(service.Name)
+			}
+			serviceEnv := map[string]string{}
+			for k, v := range service.Variables {
+				serviceEnv[k] = string(v)
+			}
+			if len(service.Entrypoint) > 0 || len(service.Command) > 0 {
+				c.note(job.name, "the entrypoint and command of the service %s aren't converted", id)
+			}
+			spec := newMapping()
+			spec.set("image", service.Name)
+			spec.set("env", serviceEnv)
+			services.set(id, spec)
+		}
+		result.set("services", services)
+	}
+	result.set("env", env)
+
+	if env["GIT_STRATEGY"] != "none" {
+		checkout := newMapping()
+		checkout.set("uses", checkoutAction)
+		result.append("steps", checkout)
+	}
+	for _, dependency := range dependencies {
+		if dependency.Artifacts == nil || len(dependency.Artifacts.Paths) == 0 {
+			continue
+		}
+		download := newMapping()
+		download.set("uses", "actions/download-artifact@v4")
+		with := newMapping()
+		if dependency.parallel {
+			with.set("pattern", dependency.id+"-*")
+			with.set("merge-multiple", true)
+		} else {
+			with.set("name", dependency.id)
+		}
+		download.set("with", with)
+		result.append("steps", download)
+	}
+
+	script := newMapping()
+	script.set("name", "script")
+	script.set("run", strings.Join(append(append([]string{}, job.BeforeScript...), job.Script...), "\n"))
+	if !job.Retry.IsZero() {
+		var retry struct {
+			Max int `yaml:"max"`
+		}
+		if job.Retry.Kind == yaml.ScalarNode {
+			_ = job.Retry.Decode(&retry.Max)
+		} else {
+			_ = job.Retry.Decode(&retry)
+		}
+		if retry.Max > 0 {
+			attempts := newMapping()
+			attempts.set("max-attempts", retry.Max+1)
+			script.set("x-act-retry", attempts)
+		}
+	}
+	result.append("steps", script)
+
+	if len(job.AfterScript) > 0 {
+		after := newMapping()
+		after.set("name", "after_script")
+		after.set("if", "always()")
+		after.set("run", strings.Join(job.AfterScript, "\n"))
+		after.set("continue-on-error", true)
+		result.append("steps", after)
+	}
+
+	if job.Artifacts != nil && len(job.Artifacts.Paths) > 0 {
+		upload := newMapping()
+		upload.set("uses", "actions/upload-artifact@v4")
+		switch job.Artifacts.When {
+		case "always":
+			upload.set("if", "always()")
+		case "on_failure":
+			upload.set("if", "failure()")
+		}
+		with := newMapping()
+		if job.parallel {
+			with.set("name", job.id+"-${{ strategy.job-index }}")
+		} else {
+			with.set("name", job.id)
+		}
+		with.set("path", strings.Join(job.Artifacts.Paths, "\n"))
+		upload.set("with", with)
+		result.append("steps", upload)
+	}
+	return result
+}
+
+// gitlabParallel converts parallel: to a matrix, a number runs the job that many times and the
+// combinations of parallel:matrix: become the include: of the matrix
+func (c *converter) gitlabParallel(job *gitlabJob, result *mapping, env map[string]string) {
+	if job.Parallel.Kind == yaml.ScalarNode {
+		count, err := strconv.Atoi(job.Parallel.Value)
+		if err != nil {
+			c.note(job.name, "invalid parallel: %s", job.Parallel.Value)
+			job.parallel = false
+			return
+		}
+		parallelMatrix(result, env, count, 1, "CI_NODE_INDEX", "CI_NODE_TOTAL")
+		return
+	}
+
+	var parallel struct {
+		Matrix []map[string]stringList `yaml:"matrix"`
+	}
+	if err := job.Parallel.Decode(&parallel); err != nil || len(parallel.Matrix) == 0 {
+		c.note(job.name, "parallel: isn't converted")
+		job.parallel = false
+		return
+	}
+	include := []map[string]string{}
+	variables := map[string]bool{}
+	for _, entry := range parallel.Matrix {
+		combinations := []map[string]string{{}}
+		for _, name := range sortedListKeys(entry) {
+			variables[name] = true
+			expanded := []map[string]string{}
+			for _, combination := range combinations {
+				for _, value := range entry[name] {
+					next := map[string]string{name: value}
+					for k, v := range combination {
+						next[k] = v
+					}
+					expanded = append(expanded, next)
+				}
+			}
+			combinations = expanded
+		}
+		include = append(include, combinations...)
+	}
+	matrix := newMapping()
+	matrix.set("include", include)
+	strategy := newMapping()
+	strategy.set("matrix", matrix)
+	result.set("strategy", strategy)
+	for name := range variables {
+		env[name] = fmt.Sprintf("${{ matrix.%s }}", name)
+	}
+}
+
+func sortedListKeys(m map[string]stringList) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// gitlabExtends merges the jobs of extends: into a job, the mappings are merged deeply and the
+// other values of the job win
+func gitlabExtends(config map[string]interface{}, job map[string]interface{}, depth int) (map[string]interface{}, error) {
+	if depth > 10 {
+		return nil, fmt.Errorf("extends: is nested too deeply")
+	}
+	var extends stringList
+	if raw, ok := job["extends"]; ok {
+		if err := remarshal(raw, &extends); err != nil {
+			return nil, fmt.Errorf("invalid extends: %w", err)
+		}
+	}
+	resolved := map[string]interface{}{}
+	for _, name := range extends {
+		base, ok := config[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("extends the unknown job %s", name)
+		}
+		base, err := gitlabExtends(config, base, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		resolved = deepMerge(resolved, base)
+	}
+	return deepMerge(resolved, job), nil
+}
+
+func deepMerge(base map[string]interface{}, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		if baseMap, ok := merged[k].(map[string]interface{}); ok {
+			if overrideMap, ok := v.(map[string]interface{}); ok {
+				merged[k] = deepMerge(baseMap, overrideMap)
+				continue
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// remarshal decodes a value which was decoded into an interface{} into out
+func remarshal(in interface{}, out interface{}) error {
+	content, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(content, out)
+}
+
+// gitlabTimeout returns the minutes of a timeout of GitLab, e.g. 1h 30m or 2 hours, a number
+// alone is seconds
+func gitlabTimeout(timeout string) (int, error) {
+	value := strings.ToLower(timeout)
+	seconds := 0
+	for _, match := range gitlabDurationPart.FindAllStringSubmatch(value, -1) {
+		unit, ok := gitlabDurationUnits[match[2]]
+		if !ok {
+			return 0, fmt.Errorf("invalid timeout '%s'", timeout)
+		}
+		n, _ := strconv.Atoi(match[1])
+		seconds += n * unit
+	}
+	rest := strings.NewReplacer(" ", "", ",", "", "and", "").Replace(gitlabDurationPart.ReplaceAllString(value, ""))
+	if seconds == 0 || rest != "" {
+		return 0, fmt.Errorf("invalid timeout '%s'", timeout)
+	}
+	return (seconds + 59) / 60, nil
+}
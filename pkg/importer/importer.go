@@ -0,0 +1,308 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// checkoutAction is the action the jobs check out the repository with, like the other CI systems do
+const checkoutAction = "actions/checkout@v4"
+
+// Note is a part of the configuration which isn't converted or runs differently in the workflow
+type Note struct {
+	Job     string `json:"job,omitempty"` // the job of the source, empty for the whole configuration
+	Message string `json:"message"`
+}
+
+func (n *Note) String() string {
+	if n.Job == "" {
+		return n.Message
+	}
+	return fmt.Sprintf("%s: %s", n.Job, n.Message)
+}
+
+// Conversion is a workflow converted from the configuration of another CI system
+type Conversion struct {
+	Source   string          // the file of the configuration
+	Workflow *model.Workflow // the converted workflow as act reads it
+	Content  []byte          // the converted workflow file, with the notes at the top
+	Notes    []*Note
+}
+
+// Import converts the configuration in path, a .gitlab-ci.yml or the .circleci/config.yml of CircleCI
+func Import(path string) (*Conversion, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(name, ".gitlab-ci.yml") || strings.HasSuffix(name, ".gitlab-ci.yaml"):
+		return ImportGitLab(f, name)
+	case filepath.Base(filepath.Dir(path)) == ".circleci":
+		return ImportCircleCI(f, filepath.Join(".circleci", name))
+	}
+	return nil, fmt.Errorf("unable to tell the CI system of '%s', expected a .gitlab-ci.yml or a .circleci/config.yml", path)
+}
+
+// converter collects the notes of a conversion
+type converter struct {
+	notes []*Note
+}
+
+func (c *converter) note(job string, format string, args ...interface{}) {
+	c.notes = append(c.notes, &Note{Job: job, Message: fmt.Sprintf(format, args...)})
+}
+
+// predefinedEnv sets the predefined variables of the other CI system the configuration refers to
+// to their equivalents in the workflow, the variables without an equivalent are empty
+func (c *converter) predefinedEnv(env map[string]string, config interface{}, reference *regexp.Regexp, predefined map[string]string) error {
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	for _, match := range reference.FindAllStringSubmatch(string(content), -1) {
+		name := match[1]
+		if _, ok := env[name]; ok {
+			continue
+		}
+		if value, ok := predefined[name]; ok {
+			env[name] = value
+		} else {
+			env[name] = ""
+			c.note("", "the predefined variable %s has no equivalent, it is empty", name)
+		}
+	}
+	return nil
+}
+
+// conversion writes the converted workflow and reads it like act does, so the workflow is valid
+func (c *converter) conversion(source string, workflow *mapping) (*Conversion, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# Converted from %s by act import.\n", source)
+	if len(c.notes) > 0 {
+		fmt.Fprintf(buf, "#\n# Notes of the conversion:\n")
+		for _, note := range c.notes {
+			fmt.Fprintf(buf, "#   - %s\n", strings.Join(strings.Fields(note.String()), " "))
+		}
+	}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(workflow.node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	converted, err := model.ReadWorkflow(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the workflow converted from %s: %w", source, err)
+	}
+	converted.File = source
+	return &Conversion{Source: source, Workflow: converted, Content: buf.Bytes(), Notes: c.notes}, nil
+}
+
+// mapping is a yaml mapping which keeps the order of its keys, so the workflow reads like the source
+type mapping struct {
+	node *yaml.Node
+}
+
+func newMapping() *mapping {
+	return &mapping{node: &yaml.Node{Kind: yaml.MappingNode}}
+}
+
+// set appends the key to the mapping, values which are empty are left out
+func (m *mapping) set(key string, value interface{}) {
+	var node *yaml.Node
+	switch value := value.(type) {
+	case *mapping:
+		if value == nil || len(value.node.Content) == 0 {
+			return
+		}
+		node = value.node
+	case string:
+		if value == "" {
+			return
+		}
+		node = &yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: "!!str"}
+		if strings.Contains(value, "\n") {
+			node.Style = yaml.LiteralStyle
+		}
+	case []string:
+		if len(value) == 0 {
+			return
+		}
+		node = &yaml.Node{Kind: yaml.SequenceNode}
+		for _, v := range value {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: v, Tag: "!!str"})
+		}
+	case map[string]string:
+		if len(value) == 0 {
+			return
+		}
+		env := newMapping()
+		for _, k := range sortedKeys(value) {
+			env.node.Content = append(env.node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, &yaml.Node{Kind: yaml.ScalarNode, Value: value[k], Tag: "!!str"})
+		}
+		node = env.node
+	default:
+		node = &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			panic(err)
+		}
+	}
+	m.node.Content = append(m.node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, node)
+}
+
+// append appends a mapping to the sequence of key, e.g. a step to the steps of a job
+func (m *mapping) append(key string, value *mapping) {
+	for i := 0; i+1 < len(m.node.Content); i += 2 {
+		if m.node.Content[i].Value == key {
+			m.node.Content[i+1].Content = append(m.node.Content[i+1].Content, value.node)
+			return
+		}
+	}
+	m.node.Content = append(m.node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{value.node}})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	jobIDInvalid = regexp.MustCompile(`[^A-Za-z0-9_]*[^A-Za-z0-9_-][^A-Za-z0-9_]*`)
+	imageTag     = regexp.MustCompile(`(:[^/]*)?(@.*)?$`)
+)
+
+// jobIDs turns the names of the jobs of the source into unique ids of workflow jobs
+type jobIDs map[string]bool
+
+func (ids jobIDs) add(name string) string {
+	id := strings.Trim(jobIDInvalid.ReplaceAllString(name, "-"), "-")
+	if id == "" || (id[0] >= '0' && id[0] <= '9') || id[0] == '-' {
+		id = "_" + id
+	}
+	unique := id
+	for i := 2; ids[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", id, i)
+	}
+	ids[unique] = true
+	return unique
+}
+
+// serviceID returns the name a service container is reachable by, the image without its tag
+// and registry path, e.g. postgres for postgres:15
+func serviceID(image string) string {
+	name := imageTag.ReplaceAllString(image, "")
+	name = name[strings.LastIndex(name, "/")+1:]
+	return strings.Trim(jobIDInvalid.ReplaceAllString(name, "-"), "-")
+}
+
+// parallelMatrix runs a job count times like the parallel jobs of the other CI systems, with the
+// index of the job, starting at first, in indexVar and the count in totalVar
+func parallelMatrix(job *mapping, env map[string]string, count int, first int, indexVar string, totalVar string) {
+	indexes := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		indexes = append(indexes, first+i)
+	}
+	matrix := newMapping()
+	matrix.set("index", indexes)
+	strategy := newMapping()
+	strategy.set("matrix", matrix)
+	job.set("strategy", strategy)
+	env[indexVar] = "${{ matrix.index }}"
+	env[totalVar] = strconv.Itoa(count)
+}
+
+// stringList decodes a string or a list of strings, nested lists are flattened like GitLab does
+// with the scripts referenced by YAML anchors
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*l = append(*l, node.Value)
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := l.UnmarshalYAML(item); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+		return l.UnmarshalYAML(node.Alias)
+	default:
+		return fmt.Errorf("line %d: expected a string or a list of strings", node.Line)
+	}
+	return nil
+}
+
+// decode reads a yaml document, io.EOF is reported as an empty configuration
+func decode(r io.Reader, source string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s is empty", source)
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", source, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("unable to read %s: expected a mapping", source)
+	}
+	return doc.Content[0], nil
+}
+
+// keys returns the keys of a mapping in their order with their values, the merge keys of YAML
+// are resolved
+func keys(node *yaml.Node) ([]string, map[string]*yaml.Node) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	order := []string{}
+	values := map[string]*yaml.Node{}
+	if node.Kind != yaml.MappingNode {
+		return order, values
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		if key == "<<" && node.Content[i].Tag == "!!merge" {
+			merged := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				merged = value.Content
+			}
+			for _, m := range merged {
+				mergedOrder, mergedValues := keys(m)
+				for _, k := range mergedOrder {
+					if _, ok := values[k]; !ok {
+						order = append(order, k)
+						values[k] = mergedValues[k]
+					}
+				}
+			}
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			order = append(order, key)
+		}
+		values[key] = value
+	}
+	return order, values
+}
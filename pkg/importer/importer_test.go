@@ -0,0 +1,170 @@
+package importer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func sortedJobs(jobs map[string]*model.Job) []string {
+	ids := make([]string, 0, len(jobs))
+	for id := range jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func notes(conversion *Conversion) []string {
+	messages := make([]string, 0, len(conversion.Notes))
+	for _, note := range conversion.Notes {
+		messages = append(messages, note.String())
+	}
+	return messages
+}
+
+func TestImportGitLab(t *testing.T) {
+	conversion, err := Import(filepath.Join("testdata", "gitlab", ".gitlab-ci.yml"))
+	require.NoError(t, err)
+	workflow := conversion.Workflow
+
+	assert.Equal(t, ".gitlab-ci.yml", workflow.File)
+	assert.Equal(t, []string{"build", "deploy", "lint", "unit"}, sortedJobs(workflow.Jobs))
+	assert.Equal(t, "${{ github.sha }}", workflow.Env["CI_COMMIT_SHA"])
+	assert.Equal(t, "1.20", workflow.Env["GO_VERSION"])
+
+	build := workflow.GetJob("build")
+	assert.Equal(t, "golang:1.20", build.Container().Image)
+	assert.Equal(t, checkoutAction, build.Steps[0].Uses)
+	assert.Equal(t, "go version\ngo build -o bin/app ./...\necho \"$CI_COMMIT_SHA\"", build.Steps[1].Run)
+	assert.Equal(t, "actions/upload-artifact@v4", build.Steps[2].Uses)
+
+	unit := workflow.GetJob("unit")
+	assert.Equal(t, []string{"build"}, unit.Needs())
+	assert.Equal(t, "postgres:15", unit.Services["postgres"].Image)
+	assert.Equal(t, "postgres", unit.Environment()["POSTGRES_PASSWORD"])
+	assert.Equal(t, "${{ matrix.index }}", unit.Environment()["CI_NODE_INDEX"])
+	assert.Equal(t, "actions/download-artifact@v4", unit.Steps[1].Uses)
+
+	lint := workflow.GetJob("lint")
+	assert.Equal(t, "golangci/golangci-lint", lint.Container().Image)
+	assert.Equal(t, "always()", lint.Steps[3].If.Value)
+
+	deploy := workflow.GetJob("deploy")
+	assert.ElementsMatch(t, []string{"unit", "lint"}, deploy.Needs())
+	assert.Equal(t, "90", deploy.TimeoutMinutes)
+	assert.Equal(t, []string{"deploy: cache: isn't converted", "deploy: runs only when it is started manually on GitLab"}, notes(conversion))
+	assert.True(t, strings.HasPrefix(string(conversion.Content), "# Converted from .gitlab-ci.yml by act import.\n"))
+}
+
+func TestImportGitLabWhen(t *testing.T) {
+	conversion, err := ImportGitLab(strings.NewReader(`
+test:
+  script: make test
+notify:
+  stage: .post
+  when: on_failure
+  script: ./notify.sh
+trigger:
+  trigger: other/project
+never:
+  when: never
+  script: exit 1
+`), ".gitlab-ci.yml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"notify", "test"}, sortedJobs(conversion.Workflow.Jobs))
+	notify := conversion.Workflow.GetJob("notify")
+	assert.Equal(t, []string{"test"}, notify.Needs())
+	assert.Equal(t, "failure()", notify.If.Value)
+	assert.Contains(t, notes(conversion), "trigger: triggers a downstream pipeline, it isn't converted")
+}
+
+func TestImportCircleCI(t *testing.T) {
+	conversion, err := Import(filepath.Join("testdata", "circleci", ".circleci", "config.yml"))
+	require.NoError(t, err)
+	workflow := conversion.Workflow
+
+	assert.Equal(t, ".circleci/config.yml", workflow.File)
+	assert.Equal(t, []string{"build", "deploy", "test"}, sortedJobs(workflow.Jobs))
+	assert.Equal(t, "${{ github.sha }}", workflow.Env["CIRCLE_SHA1"])
+
+	build := workflow.GetJob("build")
+	assert.Equal(t, "cimg/go:1.21", build.Container().Image)
+	assert.Equal(t, "postgres", build.Services["postgres"].Env["POSTGRES_PASSWORD"])
+	assert.Len(t, build.Steps, 3)
+	assert.Equal(t, "bin", build.Steps[2].With["path"])
+
+	test := workflow.GetJob("test")
+	assert.Equal(t, "cimg/go:1.20", test.Container().Image)
+	assert.Equal(t, []string{"build"}, test.Needs())
+	assert.Equal(t, "${{ matrix.index }}", test.Environment()["CIRCLE_NODE_INDEX"])
+	assert.Equal(t, "go test -race ./...", test.Steps[1].Run)
+	assert.Equal(t, "failure()", test.Steps[2].If.Value)
+
+	deploy := workflow.GetJob("deploy")
+	assert.Equal(t, []string{"test"}, deploy.Needs(), "the jobs requiring an approval need the requires of the approval")
+	assert.Nil(t, deploy.Container())
+	assert.Equal(t, "./deploy.sh ${{ github.ref_name }}", deploy.Steps[0].Run)
+
+	assert.Contains(t, notes(conversion), "the orb node isn't converted")
+	assert.Contains(t, notes(conversion), "hold: is an approval of the workflow main, the jobs requiring it don't wait for it")
+	assert.Contains(t, notes(conversion), "build: the step restore_cache isn't converted, use actions/cache")
+}
+
+func TestImportCircleCIMatrix(t *testing.T) {
+	conversion, err := ImportCircleCI(strings.NewReader(`
+version: 2.1
+jobs:
+  test:
+    parameters:
+      go:
+        type: string
+    docker:
+      - image: cimg/go:<< parameters.go >>
+    steps:
+      - run: go test ./...
+workflows:
+  all:
+    jobs:
+      - test:
+          name: test-<< matrix.go >>
+          matrix:
+            parameters:
+              go: ["1.20", "1.21"]
+`), ".circleci/config.yml")
+	require.NoError(t, err)
+
+	test := conversion.Workflow.GetJob("test-matrix-go")
+	require.NotNil(t, test)
+	assert.Equal(t, "cimg/go:${{ matrix.go }}", test.Container().Image)
+	assert.Equal(t, "test-${{ matrix.go }}", test.Name)
+	matrixes, err := test.GetMatrixes()
+	require.NoError(t, err)
+	assert.Len(t, matrixes, 2)
+}
+
+func TestImportUnknown(t *testing.T) {
+	_, err := Import("doc.go")
+	assert.ErrorContains(t, err, "unable to tell the CI system")
+}
+
+func TestJobIDs(t *testing.T) {
+	ids := jobIDs{}
+	assert.Equal(t, "build", ids.add("build"))
+	assert.Equal(t, "build-2", ids.add("build"))
+	assert.Equal(t, "test-go-1-20", ids.add("test: go 1.20"))
+	assert.Equal(t, "_1-deploy", ids.add("1 deploy"))
+}
+
+func TestServiceID(t *testing.T) {
+	assert.Equal(t, "postgres", serviceID("postgres:15"))
+	assert.Equal(t, "redis", serviceID("docker.io/library/redis@sha256:abc"))
+	assert.Equal(t, "mysql", serviceID("registry.example.com:5000/db/mysql:8"))
+}
@@ -0,0 +1,7 @@
+// Package lint validates workflows before act runs them.
+//
+// The schema and most semantic rules are checked with actionlint, act adds the secrets which
+// are referenced but not defined in the secret file or with --secret.
+//
+// See https://github.com/rhysd/actionlint/blob/main/docs/checks.md
+package lint
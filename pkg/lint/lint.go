@@ -0,0 +1,213 @@
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+)
+
+// Severity of a finding, errors fail act validate
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem found in a workflow
+type Finding struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", f.File, f.Line, f.Column, f.Severity, f.Message, f.Rule)
+}
+
+// Options of Validate
+type Options struct {
+	// WorkingDir is the repository, local reusable workflows and actions are read relative to it
+	WorkingDir string
+	// Secrets are the names of the secrets act runs the workflows with. If it isn't nil,
+	// referencing other secrets is reported.
+	Secrets []string
+}
+
+// warningRules are the rules of actionlint whose findings don't prevent act from running the workflow
+var warningRules = map[string]bool{
+	"runner-label":        true, // act maps the labels to images with -P
+	"credentials":         true,
+	"deprecated-commands": true,
+}
+
+// extensionKey matches the findings of actionlint about the keys act extends workflows with
+var extensionKey = regexp.MustCompile(`^unexpected key "x-act-[^"]*"`)
+
+// secretsContext matches the findings of actionlint about the secrets context where GitHub doesn't
+// provide it, e.g. in the if of a job. act evaluates these expressions with the secrets, so the
+// workflow runs under act but is rejected by GitHub.
+var secretsContext = regexp.MustCompile(`^context "secrets" is not allowed here`)
+
+// Validate checks the workflows in path, a workflow file or a directory, against the schema of
+// workflows and their semantics, e.g. unknown needs, invalid shells, bad cron syntax and undefined
+// inputs or secrets referenced in expressions
+func Validate(path string, recurse bool, opts Options) ([]*Finding, error) {
+	files, err := workflowFiles(path, recurse)
+	if err != nil {
+		return nil, err
+	}
+
+	linter, err := actionlint.NewLinter(io.Discard, &actionlint.LinterOptions{
+		LogWriter:  io.Discard,
+		WorkingDir: opts.WorkingDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	errs, err := linter.LintFiles(files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := make([]*Finding, 0, len(errs))
+	for _, err := range errs {
+		if err.Kind == "syntax-check" && extensionKey.MatchString(err.Message) {
+			continue
+		}
+		severity := SeverityError
+		message := err.Message
+		if warningRules[err.Kind] {
+			severity = SeverityWarning
+		} else if err.Kind == "expression" && secretsContext.MatchString(message) {
+			severity = SeverityWarning
+			message += ". act provides the secrets here, GitHub rejects the workflow"
+		}
+		findings = append(findings, &Finding{
+			File:     err.Filepath,
+			Line:     err.Line,
+			Column:   err.Column,
+			Severity: severity,
+			Rule:     err.Kind,
+			Message:  message,
+		})
+	}
+
+	if opts.Secrets != nil {
+		for _, file := range files {
+			undefined, err := undefinedSecrets(file, opts.Secrets)
+			if err != nil {
+				return nil, err
+			}
+			findings = append(findings, undefined...)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings, nil
+}
+
+// workflowFiles returns the YAML files in path like the workflow planner reads them
+func workflowFiles(path string, recurse bool) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := []string{}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && !recurse {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(p); ext == ".yml" || ext == ".yaml" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+var (
+	expressionPattern = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+	ifPattern         = regexp.MustCompile(`^\s*(?:-\s+)?if:\s*(.*)$`)
+	secretPattern     = regexp.MustCompile(`\bsecrets\s*(?:\.\s*([A-Za-z_][A-Za-z0-9_-]*)|\[\s*'([^']+)'\s*\])`)
+)
+
+// undefinedSecrets reports the secrets referenced in the expressions of a workflow which
+// aren't defined, GITHUB_TOKEN is always defined
+func undefinedSecrets(file string, secrets []string) ([]*Finding, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	defined := map[string]bool{"GITHUB_TOKEN": true}
+	for _, name := range secrets {
+		defined[strings.ToUpper(name)] = true
+	}
+
+	findings := []*Finding{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		// expressions are in ${{ }} except in if conditions
+		spans := expressionPattern.FindAllStringSubmatchIndex(text, -1)
+		if len(spans) == 0 {
+			if m := ifPattern.FindStringSubmatchIndex(text); m != nil {
+				spans = [][]int{m}
+			}
+		}
+		for _, span := range spans {
+			offset := span[2]
+			expression := text[offset:span[3]]
+			for _, m := range secretPattern.FindAllStringSubmatchIndex(expression, -1) {
+				var name string
+				if m[2] >= 0 {
+					name = expression[m[2]:m[3]]
+				} else {
+					name = expression[m[4]:m[5]]
+				}
+				if defined[strings.ToUpper(name)] {
+					continue
+				}
+				findings = append(findings, &Finding{
+					File:     file,
+					Line:     line,
+					Column:   offset + m[0] + 1,
+					Severity: SeverityWarning,
+					Rule:     "secrets",
+					Message:  fmt.Sprintf("secret %q is not defined, it is an empty string unless it is passed with --secret or --secret-file", name),
+				})
+			}
+		}
+	}
+	return findings, scanner.Err()
+}
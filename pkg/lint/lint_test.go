@@ -0,0 +1,49 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	findings, err := Validate("testdata/invalid", false, Options{Secrets: []string{"token"}})
+	require.NoError(t, err)
+
+	rules := map[string]*Finding{}
+	for _, f := range findings {
+		t.Log(f)
+		rules[f.Rule] = f
+	}
+	for _, rule := range []string{"syntax-check", "events", "shell-name", "job-needs", "expression", "secrets"} {
+		assert.Contains(t, rules, rule)
+	}
+
+	secrets := rules["secrets"]
+	assert.Equal(t, "testdata/invalid/ci.yml", secrets.File)
+	assert.Equal(t, 11, secrets.Line)
+	assert.Equal(t, 23, secrets.Column)
+	assert.Equal(t, SeverityWarning, secrets.Severity)
+	assert.Contains(t, secrets.Message, `"DEPLOY_KEY"`)
+	assert.Equal(t, SeverityError, rules["job-needs"].Severity)
+
+	var jobIf *Finding
+	for _, f := range findings {
+		if f.Line == 15 && f.Rule == "expression" {
+			jobIf = f
+		}
+	}
+	require.NotNil(t, jobIf)
+	assert.Equal(t, SeverityWarning, jobIf.Severity, "act evaluates the if of a job with the secrets")
+	assert.Contains(t, jobIf.Message, "GitHub")
+}
+
+func TestValidateValid(t *testing.T) {
+	findings, err := Validate("testdata/valid/ci.yml", false, Options{Secrets: []string{"TOKEN"}})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	_, err = Validate("testdata/missing", false, Options{})
+	assert.Error(t, err)
+}
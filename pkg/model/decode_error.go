@@ -0,0 +1,104 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeError is a node of a workflow which doesn't match the type of its key, e.g. a string
+// where a mapping is expected
+type DecodeError struct {
+	File    string // the workflow file, set by the planner
+	Line    int
+	Column  int
+	Path    string // the keys leading to the node, e.g. jobs.build.steps[3].with
+	Message string
+}
+
+func (e *DecodeError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "the workflow"
+	}
+	if e.File == "" {
+		return fmt.Sprintf("%d:%d: %s %s", e.Line, e.Column, path, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s %s", e.File, e.Line, e.Column, path, e.Message)
+}
+
+// findDecodeError returns the first node which can't be decoded into its field of t, or nil
+func findDecodeError(node *yaml.Node, t reflect.Type, path string) *DecodeError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	if t == reflect.TypeOf(yaml.Node{}) || t.Kind() == reflect.Interface || isNull(node) {
+		return nil
+	}
+
+	newError := func(message string) *DecodeError {
+		return &DecodeError{Line: node.Line, Column: node.Column, Path: path, Message: message}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return newError("must be a mapping")
+		}
+		fields := structKeys(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if field, ok := fields[key]; ok {
+				if err := findDecodeError(node.Content[i+1], field, joinKeyPath(path, key)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return newError("must be a mapping")
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := findDecodeError(node.Content[i+1], t.Elem(), joinKeyPath(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return newError("must be a sequence")
+		}
+		for i, item := range node.Content {
+			if err := findDecodeError(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		if node.Kind != yaml.ScalarNode || node.Decode(reflect.New(t).Interface()) != nil {
+			return newError(fmt.Sprintf("must be a %s", scalarTypeName(t)))
+		}
+	}
+	return nil
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+func scalarTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,315 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxGraphDepth limits the nesting of reusable workflows, which may call each other
+const maxGraphDepth = 10
+
+// graphGroup is a workflow of the graph, or the jobs of a reusable workflow called by a job
+type graphGroup struct {
+	id    string
+	label string
+	jobs  []*graphJob
+	edges [][2]*graphJob // needs, from the needed job to the job needing it
+}
+
+// graphJob is a job of the graph. A job with a matrix is a group of its combinations,
+// a job calling a reusable workflow is a group of the jobs of the workflow.
+type graphJob struct {
+	id    string
+	label string
+	legs  []*graphJob
+	call  *graphGroup
+}
+
+func (j *graphJob) isGroup() bool {
+	return j.anchor() != j
+}
+
+// anchor returns the first node of a group, which edges to and from the group are drawn to
+func (j *graphJob) anchor() *graphJob {
+	if len(j.legs) > 0 {
+		return j.legs[0]
+	}
+	if j.call != nil {
+		for _, job := range j.call.jobs {
+			return job.anchor()
+		}
+	}
+	return j
+}
+
+type graphBuilder struct {
+	workdir string
+	lastID  int
+}
+
+func (b *graphBuilder) nextID() string {
+	b.lastID++
+	return fmt.Sprintf("n%d", b.lastID)
+}
+
+// WriteGraph renders the jobs of the plan and their needs as Graphviz DOT or Mermaid. Jobs with a
+// matrix are drawn as a group of their combinations and jobs calling a local reusable workflow
+// as a group of the jobs of the workflow, which is read relative to workdir.
+func WriteGraph(w io.Writer, plan *Plan, format string, workdir string) error {
+	b := &graphBuilder{workdir: workdir}
+	groups := b.planGroups(plan)
+	switch format {
+	case "dot":
+		writeDot(w, groups)
+	case "mermaid":
+		writeMermaid(w, groups)
+	default:
+		return fmt.Errorf("unknown graph format %q, expected dot or mermaid", format)
+	}
+	return nil
+}
+
+func (b *graphBuilder) planGroups(plan *Plan) []*graphGroup {
+	groups := []*graphGroup{}
+	byWorkflow := map[*Workflow]*graphGroup{}
+	jobs := map[*Workflow]map[string]*graphJob{}
+	runs := graphRuns(plan)
+	for _, run := range runs {
+		group, ok := byWorkflow[run.Workflow]
+		if !ok {
+			group = &graphGroup{id: b.nextID(), label: workflowLabel(run.Workflow)}
+			byWorkflow[run.Workflow] = group
+			jobs[run.Workflow] = map[string]*graphJob{}
+			groups = append(groups, group)
+		}
+		job := b.job(run.Workflow, run.JobID, 0)
+		group.jobs = append(group.jobs, job)
+		jobs[run.Workflow][run.JobID] = job
+	}
+	for _, run := range runs {
+		for _, need := range run.Job().Needs() {
+			if needed, ok := jobs[run.Workflow][need]; ok {
+				group := byWorkflow[run.Workflow]
+				group.edges = append(group.edges, [2]*graphJob{needed, jobs[run.Workflow][run.JobID]})
+			}
+		}
+	}
+	return groups
+}
+
+// graphRuns returns the runs of the plan stage by stage, sorted by workflow file and job id in
+// the stages to draw the same graph every time
+func graphRuns(plan *Plan) []*Run {
+	runs := []*Run{}
+	for _, stage := range plan.Stages {
+		stageRuns := []*Run{}
+		for _, run := range stage.Runs {
+			if run.Job() != nil {
+				stageRuns = append(stageRuns, run)
+			}
+		}
+		sort.SliceStable(stageRuns, func(i, j int) bool {
+			if stageRuns[i].Workflow.File != stageRuns[j].Workflow.File {
+				return stageRuns[i].Workflow.File < stageRuns[j].Workflow.File
+			}
+			return stageRuns[i].JobID < stageRuns[j].JobID
+		})
+		runs = append(runs, stageRuns...)
+	}
+	return runs
+}
+
+func workflowLabel(workflow *Workflow) string {
+	if workflow.Name == "" || workflow.Name == workflow.File {
+		return workflow.File
+	}
+	return fmt.Sprintf("%s (%s)", workflow.Name, workflow.File)
+}
+
+func (b *graphBuilder) job(workflow *Workflow, jobID string, depth int) *graphJob {
+	job := workflow.GetJob(jobID)
+	label := job.Name
+	if label == "" {
+		label = jobID
+	}
+	node := &graphJob{id: b.nextID(), label: label}
+
+	switch job.Type() {
+	case JobTypeReusableWorkflowLocal:
+		node.label = fmt.Sprintf("%s\n%s", label, job.Uses)
+		node.call = b.calledWorkflow(job.Uses, depth)
+		return node
+	case JobTypeReusableWorkflowRemote:
+		node.label = fmt.Sprintf("%s\n%s", label, job.Uses)
+		return node
+	}
+
+	if job.Strategy == nil {
+		return node
+	}
+	matrixes, err := job.GetMatrixes()
+	if err != nil || len(matrixes) == 0 || (len(matrixes) == 1 && len(matrixes[0]) == 0) {
+		return node
+	}
+	for _, matrix := range matrixes {
+		node.legs = append(node.legs, &graphJob{id: b.nextID(), label: matrixLabel(matrix)})
+	}
+	return node
+}
+
+// calledWorkflow returns the jobs of a local reusable workflow, or nil if it can't be read
+func (b *graphBuilder) calledWorkflow(uses string, depth int) *graphGroup {
+	if depth >= maxGraphDepth {
+		return nil
+	}
+	planner, err := NewWorkflowPlanner(filepath.Join(b.workdir, uses), true)
+	if err != nil {
+		return nil
+	}
+	plan, err := planner.PlanAll()
+	if plan == nil || err != nil {
+		return nil
+	}
+
+	group := &graphGroup{}
+	jobs := map[string]*graphJob{}
+	runs := graphRuns(plan)
+	for _, run := range runs {
+		job := b.job(run.Workflow, run.JobID, depth+1)
+		group.jobs = append(group.jobs, job)
+		jobs[run.JobID] = job
+	}
+	for _, run := range runs {
+		for _, need := range run.Job().Needs() {
+			if needed, ok := jobs[need]; ok {
+				group.edges = append(group.edges, [2]*graphJob{needed, jobs[run.JobID]})
+			}
+		}
+	}
+	return group
+}
+
+func matrixLabel(matrix map[string]interface{}) string {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, fmt.Sprintf("%s: %v", k, matrix[k]))
+	}
+	return strings.Join(values, ", ")
+}
+
+func writeDot(w io.Writer, groups []*graphGroup) {
+	fmt.Fprintln(w, "digraph act {")
+	fmt.Fprintln(w, "  compound=true;")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, style=rounded];")
+	edges := [][2]*graphJob{}
+	for _, group := range groups {
+		edges = writeDotGroup(w, group, "  ", edges)
+	}
+	for _, edge := range edges {
+		from, to := edge[0], edge[1]
+		attrs := []string{}
+		if from.isGroup() {
+			attrs = append(attrs, fmt.Sprintf("ltail=cluster_%s", from.id))
+		}
+		if to.isGroup() {
+			attrs = append(attrs, fmt.Sprintf("lhead=cluster_%s", to.id))
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(w, "  %s -> %s [%s];\n", from.anchor().id, to.anchor().id, strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(w, "  %s -> %s;\n", from.anchor().id, to.anchor().id)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func writeDotGroup(w io.Writer, group *graphGroup, indent string, edges [][2]*graphJob) [][2]*graphJob {
+	fmt.Fprintf(w, "%ssubgraph cluster_%s {\n", indent, group.id)
+	fmt.Fprintf(w, "%s  label=%s;\n", indent, dotQuote(group.label))
+	for _, job := range group.jobs {
+		edges = writeDotJob(w, job, indent+"  ", edges)
+	}
+	fmt.Fprintf(w, "%s}\n", indent)
+	return append(edges, group.edges...)
+}
+
+func writeDotJob(w io.Writer, job *graphJob, indent string, edges [][2]*graphJob) [][2]*graphJob {
+	if !job.isGroup() {
+		fmt.Fprintf(w, "%s%s [label=%s];\n", indent, job.id, dotQuote(job.label))
+		return edges
+	}
+	fmt.Fprintf(w, "%ssubgraph cluster_%s {\n", indent, job.id)
+	fmt.Fprintf(w, "%s  label=%s;\n", indent, dotQuote(job.label))
+	for _, leg := range job.legs {
+		fmt.Fprintf(w, "%s  %s [label=%s];\n", indent, leg.id, dotQuote(leg.label))
+	}
+	if job.call != nil {
+		for _, called := range job.call.jobs {
+			edges = writeDotJob(w, called, indent+"  ", edges)
+		}
+		edges = append(edges, job.call.edges...)
+	}
+	fmt.Fprintf(w, "%s}\n", indent)
+	return edges
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+func writeMermaid(w io.Writer, groups []*graphGroup) {
+	fmt.Fprintln(w, "flowchart LR")
+	edges := [][2]*graphJob{}
+	for _, group := range groups {
+		edges = writeMermaidGroup(w, group, "  ", edges)
+	}
+	for _, edge := range edges {
+		fmt.Fprintf(w, "  %s --> %s\n", edge[0].id, edge[1].id)
+	}
+}
+
+func writeMermaidGroup(w io.Writer, group *graphGroup, indent string, edges [][2]*graphJob) [][2]*graphJob {
+	fmt.Fprintf(w, "%ssubgraph %s [%s]\n", indent, group.id, mermaidQuote(group.label))
+	for _, job := range group.jobs {
+		edges = writeMermaidJob(w, job, indent+"  ", edges)
+	}
+	fmt.Fprintf(w, "%send\n", indent)
+	return append(edges, group.edges...)
+}
+
+func writeMermaidJob(w io.Writer, job *graphJob, indent string, edges [][2]*graphJob) [][2]*graphJob {
+	if !job.isGroup() {
+		fmt.Fprintf(w, "%s%s[%s]\n", indent, job.id, mermaidQuote(job.label))
+		return edges
+	}
+	fmt.Fprintf(w, "%ssubgraph %s [%s]\n", indent, job.id, mermaidQuote(job.label))
+	for _, leg := range job.legs {
+		fmt.Fprintf(w, "%s  %s[%s]\n", indent, leg.id, mermaidQuote(leg.label))
+	}
+	if job.call != nil {
+		for _, called := range job.call.jobs {
+			edges = writeMermaidJob(w, called, indent+"  ", edges)
+		}
+		edges = append(edges, job.call.edges...)
+	}
+	fmt.Fprintf(w, "%send\n", indent)
+	return edges
+}
+
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return `"` + s + `"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func graphPlan(t *testing.T) *Plan {
+	planner, err := NewWorkflowPlanner("testdata/graph/.github/workflows", true)
+	require.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	require.NoError(t, err)
+	return plan
+}
+
+func TestWriteGraphMermaid(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteGraph(&out, graphPlan(t), "mermaid", "testdata/graph"))
+	assert.Equal(t, `flowchart LR
+  subgraph n1 ["ci (ci.yml)"]
+    subgraph n2 ["build"]
+      n3["node: 16"]
+      n4["node: 18"]
+    end
+    subgraph n5 ["call<br>./.github/workflows/reusable.yml"]
+      n6["test"]
+      n7["package"]
+    end
+    n8["remote<br>octo-org/workflows/.github/workflows/deploy.yml@v1"]
+    n9["Notify #quot;team#quot;"]
+  end
+  n6 --> n7
+  n2 --> n5
+  n2 --> n8
+  n5 --> n9
+  n8 --> n9
+`, out.String())
+}
+
+func TestWriteGraphDot(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteGraph(&out, graphPlan(t), "dot", "testdata/graph"))
+	assert.Contains(t, out.String(), `n9 [label="Notify \"team\""];`)
+	assert.Contains(t, out.String(), "n3 -> n6 [ltail=cluster_n2, lhead=cluster_n5];")
+	assert.Contains(t, out.String(), "n6 -> n7;")
+	assert.Contains(t, out.String(), "n6 -> n9 [ltail=cluster_n5];")
+
+	assert.EqualError(t, WriteGraph(&out, graphPlan(t), "svg", "testdata/graph"), `unknown graph format "svg", expected dot or mermaid`)
+}
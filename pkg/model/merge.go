@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxExpandedNodes limits the nodes of a workflow with its aliases expanded, aliases of aliases
+// grow exponentially
+const maxExpandedNodes = 1000000
+
+type nodeExpander struct {
+	nodes int
+}
+
+// expandAliases returns a copy of node with the aliases replaced by the nodes they refer to and
+// the merge keys (<<) of the mappings resolved like other YAML tooling does: the keys of the
+// mapping override the merged ones, and a merged mapping overrides the mappings after it in a
+// merged sequence. The yaml.Node fields of the model, e.g. runs-on or if, don't resolve aliases
+// when they are decoded later on.
+func expandAliases(node *yaml.Node) (*yaml.Node, error) {
+	e := &nodeExpander{}
+	return e.expand(node, map[*yaml.Node]bool{})
+}
+
+func (e *nodeExpander) expand(node *yaml.Node, parents map[*yaml.Node]bool) (*yaml.Node, error) {
+	e.nodes++
+	if e.nodes > maxExpandedNodes {
+		return nil, fmt.Errorf("line %d: the workflow has too many nodes with its aliases expanded", node.Line)
+	}
+	if node.Kind == yaml.AliasNode {
+		if node.Alias == nil || parents[node.Alias] {
+			return nil, fmt.Errorf("line %d: alias '%s' contains itself", node.Line, node.Value)
+		}
+		expanded, err := e.expand(node.Alias, parents)
+		if err != nil {
+			return nil, err
+		}
+		expanded.Anchor = ""
+		return expanded, nil
+	}
+
+	parents[node] = true
+	defer delete(parents, node)
+
+	expanded := *node
+	expanded.Content = nil
+	if node.Kind != yaml.MappingNode {
+		for _, child := range node.Content {
+			c, err := e.expand(child, parents)
+			if err != nil {
+				return nil, err
+			}
+			expanded.Content = append(expanded.Content, c)
+		}
+		return &expanded, nil
+	}
+
+	explicit := map[string]bool{}
+	pairs := [][2]*yaml.Node{}
+	merged := [][2]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Kind == yaml.ScalarNode && key.ShortTag() == "!!merge" {
+			m, err := e.mergedPairs(key, value, parents)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, m...)
+			continue
+		}
+		k, err := e.expand(key, parents)
+		if err != nil {
+			return nil, err
+		}
+		v, err := e.expand(value, parents)
+		if err != nil {
+			return nil, err
+		}
+		explicit[k.Value] = true
+		pairs = append(pairs, [2]*yaml.Node{k, v})
+	}
+
+	seen := map[string]bool{}
+	for _, pair := range merged {
+		if explicit[pair[0].Value] || seen[pair[0].Value] {
+			continue
+		}
+		seen[pair[0].Value] = true
+		expanded.Content = append(expanded.Content, pair[0], pair[1])
+	}
+	for _, pair := range pairs {
+		expanded.Content = append(expanded.Content, pair[0], pair[1])
+	}
+	return &expanded, nil
+}
+
+// mergedPairs returns the keys and values of the mapping, or the mappings of the sequence, merged by <<
+func (e *nodeExpander) mergedPairs(key *yaml.Node, value *yaml.Node, parents map[*yaml.Node]bool) ([][2]*yaml.Node, error) {
+	v, err := e.expand(value, parents)
+	if err != nil {
+		return nil, err
+	}
+	mappings := []*yaml.Node{v}
+	if v.Kind == yaml.SequenceNode {
+		mappings = v.Content
+	}
+
+	pairs := [][2]*yaml.Node{}
+	for _, mapping := range mappings {
+		if mapping.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: the value of '<<' must be a mapping or a sequence of mappings", key.Line)
+		}
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{mapping.Content[i], mapping.Content[i+1]})
+		}
+	}
+	return pairs, nil
+}
@@ -1,16 +1,22 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nektos/act/pkg/common"
 )
 
 // WorkflowPlanner contains methods for creating plans
@@ -18,7 +24,10 @@ type WorkflowPlanner interface {
 	PlanEvent(eventName string) (*Plan, error)
 	PlanJob(jobName string) (*Plan, error)
 	PlanAll() (*Plan, error)
+	FindJob(name string) (jobID string, rendered bool)
 	GetEvents() []string
+	AddWorkflows(path string, noWorkflowRecurse bool) error
+	SelectWorkflows(names []string) error
 }
 
 // Plan contains a list of stages to run in series
@@ -56,17 +65,32 @@ type WorkflowFiles struct {
 }
 
 // NewWorkflowPlanner will load a specific workflow, all workflows from a directory or all workflows from a directory and its subdirectories
+func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, error) {
+	return NewStrictWorkflowPlanner(path, noWorkflowRecurse, StrictModeWarn)
+}
+
+// NewStrictWorkflowPlanner is NewWorkflowPlanner with what to do with the unknown keys of the
+// workflows, the ones it loads and the ones added later on
+func NewStrictWorkflowPlanner(path string, noWorkflowRecurse bool, strict StrictMode) (WorkflowPlanner, error) {
+	wp := &workflowPlanner{strict: strict}
+	if err := wp.AddWorkflows(path, noWorkflowRecurse); err != nil {
+		return nil, err
+	}
+	return wp, nil
+}
+
+// AddWorkflows loads additional workflows into the planner, e.g. the required workflows of an organization
 //
 //nolint:gocyclo
-func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, error) {
+func (wp *workflowPlanner) AddWorkflows(path string, noWorkflowRecurse bool) error {
 	path, err := filepath.Abs(path)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	fi, err := os.Stat(path)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	var workflows []WorkflowFiles
@@ -76,7 +100,7 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 		if noWorkflowRecurse {
 			files, err := os.ReadDir(path)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			for _, v := range files {
@@ -103,7 +127,7 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 
 					return nil
 				}); err != nil {
-				return nil, err
+				return err
 			}
 		}
 	} else {
@@ -116,16 +140,15 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 		})
 	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	wp := new(workflowPlanner)
 	for _, wf := range workflows {
 		ext := filepath.Ext(wf.workflowDirEntry.Name())
 		if ext == ".yml" || ext == ".yaml" {
 			f, err := os.Open(filepath.Join(wf.dirPath, wf.workflowDirEntry.Name()))
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			log.Debugf("Reading workflow '%s'", f.Name())
@@ -133,14 +156,19 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 			if err != nil {
 				_ = f.Close()
 				if err == io.EOF {
-					return nil, fmt.Errorf("unable to read workflow '%s': file is empty: %w", wf.workflowDirEntry.Name(), err)
+					return common.WithErrorClass(fmt.Errorf("unable to read workflow '%s': file is empty: %w", wf.workflowDirEntry.Name(), err), common.ErrorClassWorkflow)
+				}
+				var decodeErr *DecodeError
+				if errors.As(err, &decodeErr) {
+					decodeErr.File = wf.workflowDirEntry.Name()
+					return common.WithErrorClass(fmt.Errorf("workflow is not valid. %w", decodeErr), common.ErrorClassWorkflow)
 				}
-				return nil, fmt.Errorf("workflow is not valid. '%s': %w", wf.workflowDirEntry.Name(), err)
+				return common.WithErrorClass(fmt.Errorf("workflow is not valid. '%s': %w", wf.workflowDirEntry.Name(), err), common.ErrorClassWorkflow)
 			}
 			_, err = f.Seek(0, 0)
 			if err != nil {
 				_ = f.Close()
-				return nil, fmt.Errorf("error occurring when resetting io pointer in '%s': %w", wf.workflowDirEntry.Name(), err)
+				return fmt.Errorf("error occurring when resetting io pointer in '%s': %w", wf.workflowDirEntry.Name(), err)
 			}
 
 			workflow.File = wf.workflowDirEntry.Name()
@@ -148,11 +176,16 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 				workflow.Name = wf.workflowDirEntry.Name()
 			}
 
+			if err := checkUnknownKeys(workflow, wp.strict); err != nil {
+				_ = f.Close()
+				return common.WithErrorClass(fmt.Errorf("workflow is not valid. '%s': %w", wf.workflowDirEntry.Name(), err), common.ErrorClassWorkflow)
+			}
+
 			jobNameRegex := regexp.MustCompile(`^([[:alpha:]_][[:alnum:]_\-]*)$`)
 			for k := range workflow.Jobs {
 				if ok := jobNameRegex.MatchString(k); !ok {
 					_ = f.Close()
-					return nil, fmt.Errorf("workflow is not valid. '%s': Job name '%s' is invalid. Names must start with a letter or '_' and contain only alphanumeric characters, '-', or '_'", workflow.Name, k)
+					return common.WithErrorClass(fmt.Errorf("workflow is not valid. '%s': Job name '%s' is invalid. Names must start with a letter or '_' and contain only alphanumeric characters, '-', or '_'", workflow.Name, k), common.ErrorClassWorkflow)
 				}
 			}
 
@@ -161,11 +194,32 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 		}
 	}
 
-	return wp, nil
+	return nil
 }
 
 type workflowPlanner struct {
 	workflows []*Workflow
+	strict    StrictMode
+}
+
+// SelectWorkflows keeps only the workflows whose name is one of names, the name of a workflow
+// without a name: is its file name
+func (wp *workflowPlanner) SelectWorkflows(names []string) error {
+	selected := make([]*Workflow, 0, len(wp.workflows))
+	for _, name := range names {
+		found := false
+		for _, w := range wp.workflows {
+			if w.Name == name {
+				selected = append(selected, w)
+				found = true
+			}
+		}
+		if !found {
+			return common.WithErrorClass(fmt.Errorf("no workflow named '%s'", name), common.ErrorClassPlan)
+		}
+	}
+	wp.workflows = selected
+	return nil
 }
 
 // PlanEvent builds a new list of runs to execute in parallel for an event name
@@ -219,6 +273,41 @@ func (wp *workflowPlanner) PlanJob(jobName string) (*Plan, error) {
 	return plan, lastErr
 }
 
+// expressionPattern matches the expressions in a job name
+var expressionPattern = regexp.MustCompile(`\$\{\{.*?\}\}`)
+
+// FindJob returns the ID of the job whose ID or name is name, rendered reports a match of the
+// name of a job with expressions, e.g. "test (ubuntu-latest)" of "test (${{ matrix.os }})", which
+// only some legs of its matrix may render to. The ID is empty if no job matches.
+func (wp *workflowPlanner) FindJob(name string) (string, bool) {
+	for _, w := range wp.workflows {
+		if w.GetJob(name) != nil {
+			return name, false
+		}
+	}
+	for _, w := range wp.workflows {
+		ids := w.GetJobIDs()
+		sort.Strings(ids)
+		for _, id := range ids {
+			jobName := w.GetJob(id).Name
+			if jobName == name {
+				return id, false
+			}
+			if !expressionPattern.MatchString(jobName) {
+				continue
+			}
+			parts := expressionPattern.Split(jobName, -1)
+			for i, part := range parts {
+				parts[i] = regexp.QuoteMeta(part)
+			}
+			if regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(name) {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
 // PlanAll builds a new run to execute in parallel all
 func (wp *workflowPlanner) PlanAll() (*Plan, error) {
 	plan := new(Plan)
@@ -271,6 +360,32 @@ func (wp *workflowPlanner) GetEvents() []string {
 	return events
 }
 
+// Workflows splits the plan into a plan for each of its workflows, in the order the workflows
+// appear in the plan, so they can run independently of each other
+func (p *Plan) Workflows() []*Plan {
+	plans := make([]*Plan, 0)
+	byWorkflow := make(map[*Workflow]*Plan)
+	for _, stage := range p.Stages {
+		stages := make(map[*Workflow]*Stage)
+		for _, run := range stage.Runs {
+			wp, ok := byWorkflow[run.Workflow]
+			if !ok {
+				wp = &Plan{}
+				byWorkflow[run.Workflow] = wp
+				plans = append(plans, wp)
+			}
+			s, ok := stages[run.Workflow]
+			if !ok {
+				s = &Stage{}
+				stages[run.Workflow] = s
+				wp.Stages = append(wp.Stages, s)
+			}
+			s.Runs = append(s.Runs, run)
+		}
+	}
+	return plans
+}
+
 // MaxRunNameLen determines the max name length of all jobs
 func (p *Plan) MaxRunNameLen() int {
 	maxRunNameLen := 0
@@ -285,6 +400,89 @@ func (p *Plan) MaxRunNameLen() int {
 	return maxRunNameLen
 }
 
+var secretReferenceRegex = regexp.MustCompile(`\bsecrets\s*(?:\.\s*([A-Za-z_][A-Za-z0-9_-]*)|\[\s*'([^']+)'\s*\])`)
+
+// Secrets returns the uppercased names of the secrets the workflows and jobs of the plan refer to
+func (p *Plan) Secrets() []string {
+	found := map[string]bool{}
+	collect := func(s string) {
+		for _, match := range secretReferenceRegex.FindAllStringSubmatch(s, -1) {
+			found[strings.ToUpper(match[1]+match[2])] = true
+		}
+	}
+	for _, stage := range p.Stages {
+		for _, run := range stage.Runs {
+			for _, value := range run.Workflow.Env {
+				collect(value)
+			}
+			walkStrings(reflect.ValueOf(run.Job()), collect)
+		}
+	}
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// walkStrings calls fn with the strings in v, including the scalars of yaml nodes but not their comments
+func walkStrings(v reflect.Value, fn func(string)) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walkStrings(v.Elem(), fn)
+		}
+	case reflect.String:
+		fn(v.String())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), fn)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			walkStrings(iter.Key(), fn)
+			walkStrings(iter.Value(), fn)
+		}
+	case reflect.Struct:
+		if node, ok := v.Interface().(yaml.Node); ok {
+			fn(node.Value)
+			for _, content := range node.Content {
+				walkStrings(reflect.ValueOf(content), fn)
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				walkStrings(v.Field(i), fn)
+			}
+		}
+	}
+}
+
+// FilterWorkflows returns the plan with only the runs of the workflows keep returns true for,
+// the stages which are left without runs are dropped
+func (p *Plan) FilterWorkflows(keep func(w *Workflow) bool) *Plan {
+	kept := map[*Workflow]bool{}
+	filtered := &Plan{}
+	for _, stage := range p.Stages {
+		filteredStage := &Stage{}
+		for _, run := range stage.Runs {
+			if _, ok := kept[run.Workflow]; !ok {
+				kept[run.Workflow] = keep(run.Workflow)
+			}
+			if kept[run.Workflow] {
+				filteredStage.Runs = append(filteredStage.Runs, run)
+			}
+		}
+		if len(filteredStage.Runs) > 0 {
+			filtered.Stages = append(filtered.Stages, filteredStage)
+		}
+	}
+	return filtered
+}
+
 // GetJobIDs will get all the job names in the stage
 func (s *Stage) GetJobIDs() []string {
 	names := make([]string, 0)
@@ -328,6 +526,10 @@ func createStages(w *Workflow, jobIDs ...string) ([]*Stage, error) {
 		jobIDs = newJobIDs
 	}
 
+	if err := checkNeeds(w, jobDependencies); err != nil {
+		return nil, common.WithErrorClass(fmt.Errorf("unable to build dependency graph for %s (%s): %w", w.Name, w.File, err), common.ErrorClassPlan)
+	}
+
 	var err error
 
 	// next, build an execution graph
@@ -345,7 +547,7 @@ func createStages(w *Workflow, jobIDs ...string) ([]*Stage, error) {
 			}
 		}
 		if len(stage.Runs) == 0 {
-			return nil, fmt.Errorf("unable to build dependency graph for %s (%s)", w.Name, w.File)
+			return nil, common.WithErrorClass(fmt.Errorf("unable to build dependency graph for %s (%s)", w.Name, w.File), common.ErrorClassPlan)
 		}
 		stages = append(stages, stage)
 	}
@@ -357,6 +559,60 @@ func createStages(w *Workflow, jobIDs ...string) ([]*Stage, error) {
 	return stages, nil
 }
 
+// checkNeeds returns an error naming the first job which needs an unknown job, or the first
+// cycle of jobs needing each other
+func checkNeeds(w *Workflow, jobDependencies map[string][]string) error {
+	jobIDs := make([]string, 0, len(jobDependencies))
+	for jID := range jobDependencies {
+		jobIDs = append(jobIDs, jID)
+	}
+	sort.Strings(jobIDs)
+
+	for _, jID := range jobIDs {
+		for _, need := range jobDependencies[jID] {
+			if _, ok := w.Jobs[need]; !ok {
+				return fmt.Errorf("job '%s' needs unknown job '%s'", jID, need)
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(jID string) error
+	visit = func(jID string) error {
+		switch state[jID] {
+		case visited:
+			return nil
+		case visiting:
+			for i, p := range path {
+				if p == jID {
+					return fmt.Errorf("jobs need each other in a cycle: %s", strings.Join(append(path[i:], jID), " -> "))
+				}
+			}
+		}
+		state[jID] = visiting
+		path = append(path, jID)
+		for _, need := range jobDependencies[jID] {
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[jID] = visited
+		return nil
+	}
+	for _, jID := range jobIDs {
+		if err := visit(jID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // return true iff all strings in srcList exist in at least one of the stages
 func listInStages(srcList []string, stages ...*Stage) bool {
 	for _, src := range srcList {
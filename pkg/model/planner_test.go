@@ -6,6 +6,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/common"
 )
 
 type WorkflowPlanTest struct {
@@ -25,6 +27,7 @@ func TestPlanner(t *testing.T) {
 		{"empty-workflow", "unable to read workflow 'push.yml': file is empty: EOF", false},
 		{"nested", "unable to read workflow 'fail.yml': file is empty: EOF", false},
 		{"nested", "", true},
+		{"decode-error", "workflow is not valid. ci.yml:9:15: jobs.build.steps[0].with must be a mapping", false},
 	}
 
 	workdir, err := filepath.Abs("testdata")
@@ -36,6 +39,152 @@ func TestPlanner(t *testing.T) {
 			assert.NoError(t, err, "WorkflowPlanner should exit without any error")
 		} else {
 			assert.EqualError(t, err, table.errorMessage)
+			assert.Equal(t, common.ErrorClassWorkflow, common.ErrorClassOf(err))
+		}
+	}
+}
+
+func TestPlannerUnknownKeys(t *testing.T) {
+	workdir, err := filepath.Abs("testdata")
+	assert.NoError(t, err, workdir)
+
+	_, err = NewWorkflowPlanner(filepath.Join(workdir, "unknown-keys"), true)
+	assert.NoError(t, err, "unknown keys are warnings by default")
+
+	_, err = NewStrictWorkflowPlanner(filepath.Join(workdir, "unknown-keys"), true, StrictModeError)
+	assert.EqualError(t, err, "workflow is not valid. 'push.yml': unknown keys:\n  line 10: unknown key 'need' in 'jobs.test', did you mean 'needs'?")
+}
+
+func TestPlannerNeeds(t *testing.T) {
+	tables := []struct {
+		workflowPath string
+		jobID        string
+		errorMessage string
+	}{
+		{"needs/cycle.yml", "", "unable to build dependency graph for cycle (cycle.yml): jobs need each other in a cycle: build -> deploy -> test -> build"},
+		{"needs/cycle.yml", "test", "unable to build dependency graph for cycle (cycle.yml): jobs need each other in a cycle: build -> deploy -> test -> build"},
+		{"needs/self.yml", "", "unable to build dependency graph for self (self.yml): jobs need each other in a cycle: build -> build"},
+		{"needs/unknown.yml", "", "unable to build dependency graph for unknown (unknown.yml): job 'test' needs unknown job 'biuld'"},
+		{"needs/unknown.yml", "build", ""},
+	}
+
+	workdir, err := filepath.Abs("testdata")
+	assert.NoError(t, err, workdir)
+	for _, table := range tables {
+		planner, err := NewWorkflowPlanner(filepath.Join(workdir, table.workflowPath), true)
+		assert.NoError(t, err)
+
+		var plan *Plan
+		if table.jobID != "" {
+			plan, err = planner.PlanJob(table.jobID)
+		} else {
+			plan, err = planner.PlanAll()
+		}
+		if table.errorMessage == "" {
+			assert.NoError(t, err)
+			assert.Len(t, plan.Stages, 1)
+		} else {
+			assert.EqualError(t, err, table.errorMessage)
+			assert.Equal(t, common.ErrorClassPlan, common.ErrorClassOf(err))
+			assert.Empty(t, plan.Stages)
 		}
 	}
 }
+
+func TestPlannerAddWorkflows(t *testing.T) {
+	workdir, err := filepath.Abs("testdata")
+	assert.NoError(t, err, workdir)
+
+	planner, err := NewWorkflowPlanner(filepath.Join(workdir, "invalid-job-name/valid-1.yml"), true)
+	assert.NoError(t, err)
+
+	plan, err := planner.PlanAll()
+	assert.NoError(t, err)
+	assert.Len(t, plan.Stages[0].Runs, 1)
+
+	err = planner.AddWorkflows(filepath.Join(workdir, "invalid-job-name/valid-2.yml"), true)
+	assert.NoError(t, err)
+
+	plan, err = planner.PlanAll()
+	assert.NoError(t, err)
+	assert.Len(t, plan.Stages[0].Runs, 2)
+
+	err = planner.AddWorkflows(filepath.Join(workdir, "empty-workflow"), true)
+	assert.EqualError(t, err, "unable to read workflow 'push.yml': file is empty: EOF")
+}
+
+func TestPlannerSelectWorkflows(t *testing.T) {
+	workdir, err := filepath.Abs("testdata")
+	assert.NoError(t, err, workdir)
+
+	planner, err := NewWorkflowPlanner(filepath.Join(workdir, "invalid-job-name/valid-1.yml"), true)
+	assert.NoError(t, err)
+	for _, path := range []string{"invalid-job-name/valid-2.yml", "needs/unknown.yml"} {
+		err = planner.AddWorkflows(filepath.Join(workdir, path), true)
+		assert.NoError(t, err)
+	}
+
+	err = planner.SelectWorkflows([]string{"valid-job-name-1", "unknown"})
+	assert.NoError(t, err)
+	plan, err := planner.PlanJob("build")
+	assert.NoError(t, err)
+	assert.Len(t, plan.Stages, 1)
+	assert.Equal(t, "unknown", plan.Stages[0].Runs[0].Workflow.Name)
+
+	err = planner.SelectWorkflows([]string{"CI"})
+	assert.EqualError(t, err, "no workflow named 'CI'")
+}
+
+func TestPlannerFindJob(t *testing.T) {
+	planner, err := NewWorkflowPlanner("testdata/matrix-job-name/push.yml", true)
+	assert.NoError(t, err)
+
+	for name, want := range map[string]struct {
+		id       string
+		rendered bool
+	}{
+		"test":                          {"test", false},
+		"Lint":                          {"lint", false},
+		"test (ubuntu-latest, node 18)": {"test", true},
+		"test (windows-latest)":         {"", false},
+		"build":                         {"", false},
+	} {
+		id, rendered := planner.FindJob(name)
+		assert.Equal(t, want.id, id, name)
+		assert.Equal(t, want.rendered, rendered, name)
+	}
+}
+
+func TestPlanFilterWorkflows(t *testing.T) {
+	first, second := &Workflow{Name: "first"}, &Workflow{Name: "second"}
+	plan := &Plan{Stages: []*Stage{
+		{Runs: []*Run{{Workflow: first, JobID: "build"}, {Workflow: second, JobID: "build"}}},
+		{Runs: []*Run{{Workflow: second, JobID: "test"}}},
+	}}
+
+	filtered := plan.FilterWorkflows(func(w *Workflow) bool { return w == first })
+	assert.Equal(t, &Plan{Stages: []*Stage{{Runs: []*Run{{Workflow: first, JobID: "build"}}}}}, filtered)
+	assert.Len(t, plan.Stages, 2)
+}
+
+func TestPlanWorkflows(t *testing.T) {
+	first, second := &Workflow{Name: "first"}, &Workflow{Name: "second"}
+	plan := &Plan{Stages: []*Stage{
+		{Runs: []*Run{{Workflow: first, JobID: "build"}, {Workflow: second, JobID: "build"}, {Workflow: first, JobID: "lint"}}},
+		{Runs: []*Run{{Workflow: second, JobID: "test"}}},
+	}}
+
+	assert.Equal(t, []*Plan{
+		{Stages: []*Stage{{Runs: []*Run{{Workflow: first, JobID: "build"}, {Workflow: first, JobID: "lint"}}}}},
+		{Stages: []*Stage{{Runs: []*Run{{Workflow: second, JobID: "build"}}}, {Runs: []*Run{{Workflow: second, JobID: "test"}}}}},
+	}, plan.Workflows())
+}
+
+func TestPlanSecrets(t *testing.T) {
+	planner, err := NewWorkflowPlanner("testdata/secrets", true)
+	assert.NoError(t, err)
+
+	plan, err := planner.PlanEvent("push")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"IF_SECRET", "INPUT_SECRET", "STEP_SECRET", "WORKFLOW_SECRET"}, plan.Secrets())
+}
@@ -0,0 +1,225 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownKey is a key of a workflow which act doesn't know, usually a misspelled one like need: or envs:
+type UnknownKey struct {
+	Path       string `json:"path"` // the keys leading to the key, e.g. jobs.build.steps[0]
+	Key        string `json:"key"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Suggestion string `json:"suggestion,omitempty"` // the known key the key is a typo of
+}
+
+func (k *UnknownKey) String() string {
+	s := fmt.Sprintf("line %d: unknown key '%s'", k.Line, k.Key)
+	if k.Path != "" {
+		s = fmt.Sprintf("%s in '%s'", s, k.Path)
+	}
+	if k.Suggestion != "" {
+		s = fmt.Sprintf("%s, did you mean '%s'?", s, k.Suggestion)
+	}
+	return s
+}
+
+// StrictMode is what the planner does with the unknown keys of the workflows it reads
+type StrictMode int
+
+const (
+	// StrictModeWarn logs a warning for every unknown key
+	StrictModeWarn StrictMode = iota
+	// StrictModeError fails to read workflows with unknown keys
+	StrictModeError
+	// StrictModeOff drops unknown keys silently
+	StrictModeOff
+)
+
+// supportedKeys are keys of GitHub Actions which act accepts without a field of the model
+var supportedKeys = map[reflect.Type][]string{
+	reflect.TypeOf(Workflow{}): {"run-name", "permissions", "concurrency"},
+	reflect.TypeOf(Job{}):      {"permissions", "concurrency", "continue-on-error"},
+}
+
+// nodeTypes are the types of the yaml.Node fields which are decoded later on, when they are mappings
+var nodeTypes = map[reflect.Type]map[string]reflect.Type{
+	reflect.TypeOf(Job{}): {"container": reflect.TypeOf(ContainerSpec{})},
+}
+
+// ReadWorkflowStrict reads a workflow like ReadWorkflow but fails if it has unknown keys
+func ReadWorkflowStrict(in io.Reader) (*Workflow, error) {
+	w, err := ReadWorkflow(in)
+	if err != nil {
+		return w, err
+	}
+	return w, unknownKeysError(w.UnknownKeys)
+}
+
+func unknownKeysError(keys []*UnknownKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, key.String())
+	}
+	return fmt.Errorf("unknown keys:\n  %s", strings.Join(messages, "\n  "))
+}
+
+// checkUnknownKeys handles the unknown keys of a workflow read by the planner according to strict
+func checkUnknownKeys(w *Workflow, strict StrictMode) error {
+	switch strict {
+	case StrictModeError:
+		return unknownKeysError(w.UnknownKeys)
+	case StrictModeWarn:
+		for _, key := range w.UnknownKeys {
+			log.Warnf("Workflow '%s' %s", w.File, key)
+		}
+	}
+	return nil
+}
+
+// unknownKeys returns the keys of the node which aren't keys of the yaml struct tags of t
+func unknownKeys(node *yaml.Node, t reflect.Type, path string) []*UnknownKey {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+
+	keys := []*UnknownKey{}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode || t == reflect.TypeOf(yaml.Node{}) {
+			return keys
+		}
+		fields := structKeys(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok && value.Anchor != "" {
+				// a block shared with aliases, e.g. x-defaults: &defaults
+				continue
+			}
+			if !ok {
+				keys = append(keys, &UnknownKey{
+					Path:       path,
+					Key:        key.Value,
+					Line:       key.Line,
+					Column:     key.Column,
+					Suggestion: suggestKey(key.Value, fields),
+				})
+				continue
+			}
+			if field == reflect.TypeOf(yaml.Node{}) {
+				nodeType, ok := nodeTypes[t][key.Value]
+				if !ok || value.Kind != yaml.MappingNode {
+					continue
+				}
+				field = nodeType
+			}
+			keys = append(keys, unknownKeys(value, field, joinKeyPath(path, key.Value))...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return keys
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keys = append(keys, unknownKeys(node.Content[i+1], t.Elem(), joinKeyPath(path, node.Content[i].Value))...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return keys
+		}
+		for i, item := range node.Content {
+			keys = append(keys, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return keys
+}
+
+// structKeys returns the types of the fields of a struct by their keys, fields without a yaml
+// struct tag aren't read from workflows
+func structKeys(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field.Type
+	}
+	for _, key := range supportedKeys[t] {
+		fields[key] = reflect.TypeOf(yaml.Node{})
+	}
+	return fields
+}
+
+func joinKeyPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// suggestKey returns the known key closest to key, if it is close enough to be a typo
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	known := make([]string, 0, len(fields))
+	for k := range fields {
+		known = append(known, k)
+	}
+	sort.Strings(known)
+
+	suggestion := ""
+	best := 3
+	for _, k := range known {
+		if d := editDistance(strings.ToLower(key), k); d < best {
+			suggestion = k
+			best = d
+		}
+	}
+	return suggestion
+}
+
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
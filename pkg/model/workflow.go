@@ -5,10 +5,12 @@ import (
 	"io"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/nektos/act/pkg/common"
+	"github.com/kballard/go-shellquote"
+	"github.com/nektos/act/pkg/workflowpattern"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -21,6 +23,8 @@ type Workflow struct {
 	Env      map[string]string `yaml:"env"`
 	Jobs     map[string]*Job   `yaml:"jobs"`
 	Defaults Defaults          `yaml:"defaults"`
+
+	UnknownKeys []*UnknownKey `yaml:"-"` // keys of the file which act doesn't know, see StrictMode
 }
 
 // On events for the workflow
@@ -66,6 +70,79 @@ func (w *Workflow) OnEvent(event string) interface{} {
 	return nil
 }
 
+// TriggeredByPaths returns whether changing the files at paths, relative to the repository and
+// with slashes, triggers the workflow for the event according to its paths or paths-ignore
+// filter. Events without a filter are triggered by any change.
+func (w *Workflow) TriggeredByPaths(event string, paths []string) bool {
+	filters, ok := w.OnEvent(event).(map[string]interface{})
+	if !ok {
+		return true
+	}
+	tw := &workflowpattern.EmptyTraceWriter{}
+	if patterns, ok := filterPatterns(filters["paths"]); ok {
+		return !workflowpattern.Skip(patterns, paths, tw)
+	}
+	if patterns, ok := filterPatterns(filters["paths-ignore"]); ok {
+		return !workflowpattern.Filter(patterns, paths, tw)
+	}
+	return true
+}
+
+// TriggeredByWorkflowRun returns whether the completion of the workflow named workflow on branch
+// triggers the workflow according to its workflow_run trigger, with its workflows, types and
+// branches or branches-ignore filters
+func (w *Workflow) TriggeredByWorkflowRun(workflow, action, branch string) bool {
+	filters, ok := w.OnEvent("workflow_run").(map[string]interface{})
+	if !ok || !containsFilterValue(filters["workflows"], workflow) {
+		return false
+	}
+	if types, ok := filters["types"]; ok && !containsFilterValue(types, action) {
+		return false
+	}
+	tw := &workflowpattern.EmptyTraceWriter{}
+	if patterns, ok := filterPatterns(filters["branches"]); ok {
+		return !workflowpattern.Skip(patterns, []string{branch}, tw)
+	}
+	if patterns, ok := filterPatterns(filters["branches-ignore"]); ok {
+		return !workflowpattern.Filter(patterns, []string{branch}, tw)
+	}
+	return true
+}
+
+func containsFilterValue(filter interface{}, value string) bool {
+	switch f := filter.(type) {
+	case string:
+		return f == value
+	case []interface{}:
+		for _, v := range f {
+			if fmt.Sprint(v) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func filterPatterns(filter interface{}) ([]*workflowpattern.WorkflowPattern, bool) {
+	var raw []string
+	switch f := filter.(type) {
+	case string:
+		raw = []string{f}
+	case []interface{}:
+		for _, v := range f {
+			raw = append(raw, fmt.Sprint(v))
+		}
+	default:
+		return nil, false
+	}
+	patterns, err := workflowpattern.CompilePatterns(raw...)
+	if err != nil {
+		log.Warnf("Invalid path filter %v: %v", raw, err)
+		return nil, false
+	}
+	return patterns, true
+}
+
 type WorkflowDispatchInput struct {
 	Description string   `yaml:"description"`
 	Required    bool     `yaml:"required"`
@@ -155,6 +232,7 @@ type Job struct {
 	Uses           string                    `yaml:"uses"`
 	With           map[string]interface{}    `yaml:"with"`
 	RawSecrets     yaml.Node                 `yaml:"secrets"`
+	RawEnvironment yaml.Node                 `yaml:"environment"`
 	Result         string
 }
 
@@ -235,6 +313,24 @@ func (j *Job) Secrets() map[string]string {
 	return val
 }
 
+// EnvironmentName returns the name of the deployment environment of the job, environment: is the
+// name or a mapping with the name and the url
+func (j *Job) EnvironmentName() string {
+	var name string
+	switch j.RawEnvironment.Kind {
+	case yaml.ScalarNode:
+		decodeNode(j.RawEnvironment, &name)
+	case yaml.MappingNode:
+		var val struct {
+			Name string `yaml:"name"`
+		}
+		if decodeNode(j.RawEnvironment, &val) {
+			name = val.Name
+		}
+	}
+	return name
+}
+
 // Container details for the job
 func (j *Job) Container() *ContainerSpec {
 	var val *ContainerSpec
@@ -379,21 +475,12 @@ func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
 			}
 			delete(m, "exclude")
 
-			matrixProduct := common.CartesianProduct(m)
-		MATRIX:
-			for _, matrix := range matrixProduct {
-				for _, exclude := range excludes {
-					if commonKeysMatch(matrix, exclude) {
-						log.Debugf("Skipping matrix '%v' due to exclude '%v'", matrix, exclude)
-						continue MATRIX
-					}
-				}
-				matrixes = append(matrixes, matrix)
-			}
+			matrixes = matrixCombinations(m, excludes)
 			for _, include := range includes {
 				matched := false
+				filter := newMatrixFilter(include, m)
 				for _, matrix := range matrixes {
-					if commonKeysMatch2(matrix, include, m) {
+					if filter.matches(matrix) {
 						matched = true
 						log.Debugf("Adding include values '%v' to existing entry", include)
 						for k, v := range include {
@@ -421,25 +508,131 @@ func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
 	return matrixes, nil
 }
 
-func commonKeysMatch(a map[string]interface{}, b map[string]interface{}) bool {
-	for aKey, aVal := range a {
-		if bVal, ok := b[aKey]; ok && !reflect.DeepEqual(aVal, bVal) {
-			return false
+// matrixCombinations returns the combinations of the values of the matrix without the excluded
+// ones. The excludes are matched against the positions of the values in the lists of the matrix,
+// so the maps of the excluded combinations are never built.
+func matrixCombinations(matrix map[string][]interface{}, excludes []map[string]interface{}) []map[string]interface{} {
+	keys := make([]string, 0, len(matrix))
+	total := 1
+	for key, values := range matrix {
+		keys = append(keys, key)
+		total *= len(values)
+	}
+	if len(keys) == 0 || total == 0 {
+		return []map[string]interface{}{}
+	}
+	sort.Strings(keys)
+
+	// excluded[e][k][i] is whether the i-th value of the k-th key matches the e-th exclude, nil if
+	// the exclude doesn't have the key
+	excluded := make([][][]bool, len(excludes))
+	for e, exclude := range excludes {
+		excluded[e] = make([][]bool, len(keys))
+		for k, key := range keys {
+			value, ok := exclude[key]
+			if !ok {
+				continue
+			}
+			excluded[e][k] = make([]bool, len(matrix[key]))
+			for i, v := range matrix[key] {
+				excluded[e][k][i] = matrixValueEqual(v, value)
+			}
 		}
 	}
-	return true
+	isExcluded := func(e int, indices []int) bool {
+		for k, i := range indices {
+			if excluded[e][k] != nil && !excluded[e][k][i] {
+				return false
+			}
+		}
+		return true
+	}
+	combination := func(indices []int) map[string]interface{} {
+		c := make(map[string]interface{}, len(keys))
+		for k, i := range indices {
+			c[keys[k]] = matrix[keys[k]][i]
+		}
+		return c
+	}
+
+	combinations := make([]map[string]interface{}, 0, total)
+	indices := make([]int, len(keys))
+COMBINATION:
+	for n := 0; n < total; n++ {
+		if n > 0 {
+			// the next combination, the last key changes the fastest
+			for k := len(keys) - 1; k >= 0; k-- {
+				indices[k]++
+				if indices[k] < len(matrix[keys[k]]) {
+					break
+				}
+				indices[k] = 0
+			}
+		}
+		for e := range excludes {
+			if isExcluded(e, indices) {
+				if log.IsLevelEnabled(log.DebugLevel) {
+					log.Debugf("Skipping matrix '%v' due to exclude '%v'", combination(indices), excludes[e])
+				}
+				continue COMBINATION
+			}
+		}
+		combinations = append(combinations, combination(indices))
+	}
+	return combinations
 }
 
-func commonKeysMatch2(a map[string]interface{}, b map[string]interface{}, m map[string][]interface{}) bool {
-	for aKey, aVal := range a {
-		_, useKey := m[aKey]
-		if bVal, ok := b[aKey]; useKey && ok && !reflect.DeepEqual(aVal, bVal) {
+// matrixFilter are the values of an include a combination of the matrix is compared with, the
+// include matches the combinations which have the same values for the keys of the matrix they
+// share. Every combination is compared with every include, so their keys are collected once
+// instead of iterating over the maps.
+type matrixFilter []matrixFilterValue
+
+type matrixFilterValue struct {
+	key   string
+	value interface{}
+}
+
+// newMatrixFilter returns the filter of the values of an include of the keys of the matrix
+func newMatrixFilter(values map[string]interface{}, matrix map[string][]interface{}) matrixFilter {
+	filter := make(matrixFilter, 0, len(values))
+	for key, value := range values {
+		if _, ok := matrix[key]; ok {
+			filter = append(filter, matrixFilterValue{key, value})
+		}
+	}
+	return filter
+}
+
+func (f matrixFilter) matches(combination map[string]interface{}) bool {
+	for _, v := range f {
+		if value, ok := combination[v.key]; ok && !matrixValueEqual(value, v.value) {
 			return false
 		}
 	}
 	return true
 }
 
+// matrixValueEqual compares the values of a matrix, the scalars which most matrices consist of
+// without reflection, the includes and excludes are compared with every combination
+func matrixValueEqual(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case string:
+		b, ok := b.(string)
+		return ok && a == b
+	case float64:
+		b, ok := b.(float64)
+		return ok && a == b
+	case int:
+		b, ok := b.(int)
+		return ok && a == b
+	case bool:
+		b, ok := b.(bool)
+		return ok && a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
 // JobType describes what type of job we are about to run
 type JobType int
 
@@ -466,9 +659,25 @@ func (j JobType) String() string {
 	return "unknown"
 }
 
+// localWorkflowDirs are the directories of the reusable workflows of the repository, the ones of
+// Gitea and Forgejo are read by their compat modes
+var localWorkflowDirs = []string{"./.github/workflows/", "./.gitea/workflows/", "./.forgejo/workflows/"}
+
+func isLocalWorkflow(uses string) bool {
+	if !strings.HasSuffix(uses, ".yml") && !strings.HasSuffix(uses, ".yaml") {
+		return false
+	}
+	for _, dir := range localWorkflowDirs {
+		if strings.HasPrefix(uses, dir) {
+			return true
+		}
+	}
+	return false
+}
+
 // Type returns the type of the job
 func (j *Job) Type() JobType {
-	if strings.HasPrefix(j.Uses, "./.github/workflows") && (strings.HasSuffix(j.Uses, ".yml") || strings.HasSuffix(j.Uses, ".yaml")) {
+	if isLocalWorkflow(j.Uses) {
 		return JobTypeReusableWorkflowLocal
 	} else if !strings.HasPrefix(j.Uses, "./") && strings.Contains(j.Uses, ".github/workflows") && (strings.Contains(j.Uses, ".yml@") || strings.Contains(j.Uses, ".yaml@")) {
 		return JobTypeReusableWorkflowRemote
@@ -503,6 +712,14 @@ type Step struct {
 	With               map[string]string `yaml:"with"`
 	RawContinueOnError string            `yaml:"continue-on-error"`
 	TimeoutMinutes     string            `yaml:"timeout-minutes"`
+	Retry              *StepRetry        `yaml:"x-act-retry"`
+}
+
+// StepRetry is the x-act-retry extension of act for a step, a failing step runs again until it
+// succeeds or ran MaxAttempts times. GitHub ignores it like every key starting with x-.
+type StepRetry struct {
+	MaxAttempts int    `yaml:"max-attempts"`
+	Backoff     string `yaml:"backoff"` // time to wait before the second attempt, doubled for each further one, e.g. 10s
 }
 
 // String gets the name of step
@@ -534,28 +751,36 @@ func (s *Step) GetEnv() map[string]string {
 	return env
 }
 
-// ShellCommand returns the command for the shell
+// shellArgs are the arguments of the built-in shells, {0} is the script
+//
+// Reference: https://github.com/actions/runner/blob/8109c962f09d9acc473d92c595ff43afceddb347/src/Runner.Worker/Handlers/ScriptHandlerHelpers.cs#L9-L17
+var shellArgs = map[string]string{
+	"bash":       "--noprofile --norc -e -o pipefail {0}",
+	"pwsh":       "-command \". '{0}'\"",
+	"python":     "{0}",
+	"sh":         "-e {0}",
+	"cmd":        "/D /E:ON /V:OFF /S /C \"CALL \"{0}\"\"",
+	"powershell": "-command \". '{0}'\"",
+}
+
+// ShellCommand returns the command for the shell. A built-in shell, also given as the path of its
+// program like /bin/bash, gets its default arguments, other shells are command templates with {0}
+// for the script like "perl -w {0}".
 func (s *Step) ShellCommand() string {
-	shellCommand := ""
-
-	//Reference: https://github.com/actions/runner/blob/8109c962f09d9acc473d92c595ff43afceddb347/src/Runner.Worker/Handlers/ScriptHandlerHelpers.cs#L9-L17
 	switch s.Shell {
-	case "", "bash":
-		shellCommand = "bash --noprofile --norc -e -o pipefail {0}"
-	case "pwsh":
-		shellCommand = "pwsh -command . '{0}'"
-	case "python":
-		shellCommand = "python {0}"
-	case "sh":
-		shellCommand = "sh -e {0}"
+	case "":
+		return "bash " + shellArgs["bash"]
 	case "cmd":
-		shellCommand = "%ComSpec% /D /E:ON /V:OFF /S /C \"CALL \"{0}\"\""
-	case "powershell":
-		shellCommand = "powershell -command . '{0}'"
-	default:
-		shellCommand = s.Shell
+		return "%ComSpec% " + shellArgs["cmd"]
+	}
+	if strings.ContainsAny(s.Shell, " \t") {
+		return s.Shell
 	}
-	return shellCommand
+	name := s.Shell[strings.LastIndexAny(s.Shell, `/\`)+1:]
+	if args, ok := shellArgs[strings.TrimSuffix(name, ".exe")]; ok {
+		return shellquote.Join(s.Shell) + " " + args
+	}
+	return s.Shell
 }
 
 // StepType describes what type of step we are about to run
@@ -617,21 +842,51 @@ func (s *Step) Type() StepType {
 		return StepTypeRun
 	} else if strings.HasPrefix(s.Uses, "docker://") {
 		return StepTypeUsesDockerURL
-	} else if strings.HasPrefix(s.Uses, "./.github/workflows") && (strings.HasSuffix(s.Uses, ".yml") || strings.HasSuffix(s.Uses, ".yaml")) {
+	} else if isLocalWorkflow(s.Uses) {
 		return StepTypeReusableWorkflowLocal
 	} else if !strings.HasPrefix(s.Uses, "./") && strings.Contains(s.Uses, ".github/workflows") && (strings.Contains(s.Uses, ".yml@") || strings.Contains(s.Uses, ".yaml@")) {
 		return StepTypeReusableWorkflowRemote
 	} else if strings.HasPrefix(s.Uses, "./") {
 		return StepTypeUsesActionLocal
+	} else if _, ok := s.UsesActionPath(); ok {
+		return StepTypeUsesActionLocal
 	}
 	return StepTypeUsesActionRemote
 }
 
-// ReadWorkflow returns a list of jobs for a given workflow file reader
+var actionPathPrefix = regexp.MustCompile(`^\$\{\{\s*github\.action_path\s*\}\}`)
+
+// UsesActionPath returns the path of the action relative to the directory of the composite action
+// the step is in, for a `uses` which starts with ${{ github.action_path }}
+func (s *Step) UsesActionPath() (string, bool) {
+	loc := actionPathPrefix.FindStringIndex(s.Uses)
+	if loc == nil {
+		return "", false
+	}
+	return "./" + strings.TrimPrefix(s.Uses[loc[1]:], "/"), true
+}
+
+// ReadWorkflow returns a list of jobs for a given workflow file reader, the keys which aren't
+// workflow keys are recorded in UnknownKeys. Anchors, aliases and merge keys (<<) are expanded.
+// A node which doesn't match the type of its key fails with a *DecodeError.
 func ReadWorkflow(in io.Reader) (*Workflow, error) {
 	w := new(Workflow)
-	err := yaml.NewDecoder(in).Decode(w)
-	return w, err
+	var raw yaml.Node
+	if err := yaml.NewDecoder(in).Decode(&raw); err != nil {
+		return w, err
+	}
+	node, err := expandAliases(&raw)
+	if err != nil {
+		return w, err
+	}
+	if err := node.Decode(w); err != nil {
+		if decodeErr := findDecodeError(node, reflect.TypeOf(w), ""); decodeErr != nil {
+			return w, decodeErr
+		}
+		return w, err
+	}
+	w.UnknownKeys = unknownKeys(node, reflect.TypeOf(w), "")
+	return w, nil
 }
 
 // GetJob will get a job by name in the workflow
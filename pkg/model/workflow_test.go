@@ -1,10 +1,12 @@
 package model
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func TestReadWorkflow_StringEvent(t *testing.T) {
@@ -153,14 +155,18 @@ jobs:
   local-reusable-workflow:
     runs-on: ubuntu-latest
     uses: ./.github/workflows/workflow.yml
+  gitea-reusable-workflow:
+    runs-on: ubuntu-latest
+    uses: ./.gitea/workflows/workflow.yaml
 `
 
 	workflow, err := ReadWorkflow(strings.NewReader(yaml))
 	assert.NoError(t, err, "read workflow should succeed")
-	assert.Len(t, workflow.Jobs, 3)
+	assert.Len(t, workflow.Jobs, 4)
 	assert.Equal(t, workflow.Jobs["default-job"].Type(), JobTypeDefault)
 	assert.Equal(t, workflow.Jobs["remote-reusable-workflow"].Type(), JobTypeReusableWorkflowRemote)
 	assert.Equal(t, workflow.Jobs["local-reusable-workflow"].Type(), JobTypeReusableWorkflowLocal)
+	assert.Equal(t, workflow.Jobs["gitea-reusable-workflow"].Type(), JobTypeReusableWorkflowLocal)
 }
 
 func TestReadWorkflow_StepsTypes(t *testing.T) {
@@ -305,14 +311,45 @@ func TestReadWorkflow_Strategy(t *testing.T) {
 	assert.Equal(t, job.Strategy.FailFast, false)
 }
 
+func TestStep_UsesActionPath(t *testing.T) {
+	tests := []struct {
+		uses string
+		want string
+		ok   bool
+	}{
+		{"${{ github.action_path }}/../other-action", "./../other-action", true},
+		{"${{github.action_path}}/sub", "./sub", true},
+		{"${{ github.action_path }}", "./", true},
+		{"./../other-action", "", false},
+		{"actions/checkout@v3", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uses, func(t *testing.T) {
+			step := &Step{Uses: tt.uses}
+			got, ok := step.UsesActionPath()
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, StepTypeUsesActionLocal, step.Type())
+			}
+		})
+	}
+}
+
 func TestStep_ShellCommand(t *testing.T) {
 	tests := []struct {
 		shell string
 		want  string
 	}{
 		{"pwsh -v '. {0}'", "pwsh -v '. {0}'"},
-		{"pwsh", "pwsh -command . '{0}'"},
-		{"powershell", "powershell -command . '{0}'"},
+		{"", "bash --noprofile --norc -e -o pipefail {0}"},
+		{"/bin/bash", "/bin/bash --noprofile --norc -e -o pipefail {0}"},
+		{"perl -w {0}", "perl -w {0}"},
+		{"bash -leo pipefail {0}", "bash -leo pipefail {0}"},
+		{"cmd", `%ComSpec% /D /E:ON /V:OFF /S /C "CALL "{0}""`},
+		{"perl", "perl"},
+		{"pwsh", `pwsh -command ". '{0}'"`},
+		{"powershell", `powershell -command ". '{0}'"`},
 	}
 	for _, tt := range tests {
 		t.Run(tt.shell, func(t *testing.T) {
@@ -321,3 +358,328 @@ func TestStep_ShellCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestReadWorkflow_UnknownKeys(t *testing.T) {
+	yaml := `
+name: unknown-keys
+on: push
+permissions: read-all
+envs:
+  FOO: bar
+
+jobs:
+  build:
+    need: test
+    runs-on: ubuntu-latest
+    environment: production
+    container:
+      image: node:16
+      option: --cpus 1
+    services:
+      redis:
+        image: redis
+        port: [6379]
+    strategy:
+      matrix:
+        anything: [1, 2]
+      failfast: false
+    steps:
+      - run: echo
+        with:
+          whatever: true
+        continue-on-errors: true
+`
+
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	assert.Equal(t, []*UnknownKey{
+		{Path: "", Key: "envs", Line: 5, Column: 1, Suggestion: "env"},
+		{Path: "jobs.build", Key: "need", Line: 10, Column: 5, Suggestion: "needs"},
+		{Path: "jobs.build.container", Key: "option", Line: 15, Column: 7, Suggestion: "options"},
+		{Path: "jobs.build.services.redis", Key: "port", Line: 19, Column: 9, Suggestion: "ports"},
+		{Path: "jobs.build.strategy", Key: "failfast", Line: 23, Column: 7, Suggestion: "fail-fast"},
+		{Path: "jobs.build.steps[0]", Key: "continue-on-errors", Line: 28, Column: 9, Suggestion: "continue-on-error"},
+	}, workflow.UnknownKeys)
+	assert.Equal(t, "line 10: unknown key 'need' in 'jobs.build', did you mean 'needs'?", workflow.UnknownKeys[1].String())
+
+	_, err = ReadWorkflowStrict(strings.NewReader(yaml))
+	assert.ErrorContains(t, err, "line 5: unknown key 'envs', did you mean 'env'?")
+
+	workflow, err = ReadWorkflowStrict(strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: ./actions/docker-url
+`))
+	assert.NoError(t, err)
+	assert.Empty(t, workflow.UnknownKeys)
+}
+
+func TestReadWorkflow_MergeKeys(t *testing.T) {
+	yaml := `
+on: push
+x-defaults: &defaults
+  runs-on: ubuntu-latest
+  timeout-minutes: 10
+  env: &env
+    A: a
+    B: b
+jobs:
+  build:
+    <<: *defaults
+    runs-on: &os windows-latest
+    if: &cond github.ref == 'refs/heads/main'
+    needs: &needs [lint]
+    env:
+      <<: *env
+      B: override
+    steps: &steps
+      - &checkout
+        uses: actions/checkout@v3
+        with:
+          fetch-depth: 0
+      - <<: [*checkout, {name: never}]
+        name: shallow
+        with:
+          fetch-depth: 1
+  test:
+    runs-on: *os
+    if: *cond
+    needs: *needs
+    steps: *steps
+`
+
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+	assert.Empty(t, workflow.UnknownKeys)
+
+	build := workflow.Jobs["build"]
+	assert.Equal(t, []string{"windows-latest"}, build.RunsOn())
+	assert.Equal(t, "10", build.TimeoutMinutes)
+	assert.Equal(t, map[string]string{"A": "a", "B": "override"}, build.Environment())
+	assert.Len(t, build.Steps, 2)
+	assert.Equal(t, "shallow", build.Steps[1].Name)
+	assert.Equal(t, "actions/checkout@v3", build.Steps[1].Uses)
+	assert.Equal(t, map[string]string{"fetch-depth": "1"}, build.Steps[1].With)
+
+	test := workflow.Jobs["test"]
+	assert.Equal(t, []string{"windows-latest"}, test.RunsOn())
+	assert.Equal(t, "github.ref == 'refs/heads/main'", test.If.Value)
+	assert.Equal(t, []string{"lint"}, test.Needs())
+	assert.Len(t, test.Steps, 2)
+
+	_, err = ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  build:
+    <<: [a, b]
+`))
+	assert.EqualError(t, err, "line 5: the value of '<<' must be a mapping or a sequence of mappings")
+
+	_, err = ReadWorkflow(strings.NewReader(`
+on: push
+jobs: &jobs
+  build:
+    <<: *jobs
+`))
+	assert.EqualError(t, err, "line 5: alias 'jobs' contains itself")
+}
+
+func TestReadWorkflow_DecodeError(t *testing.T) {
+	tables := []struct {
+		yaml  string
+		error string
+	}{
+		{"- push", "1:1: the workflow must be a mapping"},
+		{"on: push\njobs: [build]", "2:7: jobs must be a mapping"},
+		{"on: push\njobs:\n  build:\n    steps:\n      run: echo", "5:7: jobs.build.steps must be a sequence"},
+		{"on: push\njobs:\n  build:\n    steps:\n      - run: echo\n      - with: [1]", "6:15: jobs.build.steps[1].with must be a mapping"},
+		{"on: push\njobs:\n  build:\n    steps:\n      - with:\n          ref: {a: b}", "6:16: jobs.build.steps[0].with.ref must be a string"},
+		{"on: push\njobs:\n  build:\n    services:\n      redis:\n        image: redis\n        ports: 6379", "7:16: jobs.build.services.redis.ports must be a sequence"},
+	}
+
+	for _, table := range tables {
+		_, err := ReadWorkflow(strings.NewReader(table.yaml))
+		var decodeErr *DecodeError
+		if assert.ErrorAs(t, err, &decodeErr, table.yaml) {
+			assert.EqualError(t, err, table.error)
+		}
+	}
+}
+
+func TestWorkflow_TriggeredByPaths(t *testing.T) {
+	yaml := `
+on:
+  push:
+    paths:
+      - 'src/**'
+      - '!src/**/*.md'
+  pull_request:
+    paths-ignore: docs/**
+  workflow_dispatch:
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	assert.True(t, workflow.TriggeredByPaths("push", []string{"README.md", "src/main.go"}))
+	assert.False(t, workflow.TriggeredByPaths("push", []string{"README.md", "src/docs/index.md"}))
+	assert.True(t, workflow.TriggeredByPaths("pull_request", []string{"docs/index.md", "main.go"}))
+	assert.False(t, workflow.TriggeredByPaths("pull_request", []string{"docs/index.md"}))
+	assert.True(t, workflow.TriggeredByPaths("workflow_dispatch", []string{"docs/index.md"}))
+
+	workflow, err = ReadWorkflow(strings.NewReader("on: push\njobs: {}"))
+	assert.NoError(t, err)
+	assert.True(t, workflow.TriggeredByPaths("push", []string{"main.go"}))
+}
+
+func TestWorkflow_TriggeredByWorkflowRun(t *testing.T) {
+	yaml := `
+on:
+  workflow_run:
+    workflows: [CI, Build]
+    types: [completed]
+    branches: ['main', 'releases/**']
+
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	assert.True(t, workflow.TriggeredByWorkflowRun("CI", "completed", "main"))
+	assert.True(t, workflow.TriggeredByWorkflowRun("Build", "completed", "releases/1.0"))
+	assert.False(t, workflow.TriggeredByWorkflowRun("Lint", "completed", "main"))
+	assert.False(t, workflow.TriggeredByWorkflowRun("CI", "requested", "main"))
+	assert.False(t, workflow.TriggeredByWorkflowRun("CI", "completed", "feature"))
+
+	workflow, err = ReadWorkflow(strings.NewReader("on:\n  workflow_run:\n    workflows: CI\n    branches-ignore: wip/*\njobs: {}"))
+	assert.NoError(t, err)
+	assert.True(t, workflow.TriggeredByWorkflowRun("CI", "requested", "main"))
+	assert.False(t, workflow.TriggeredByWorkflowRun("CI", "completed", "wip/x"))
+
+	workflow, err = ReadWorkflow(strings.NewReader("on: workflow_run\njobs: {}"))
+	assert.NoError(t, err)
+	assert.False(t, workflow.TriggeredByWorkflowRun("CI", "completed", "main"))
+}
+
+func TestReadWorkflow_Environment(t *testing.T) {
+	yaml := `
+on: push
+jobs:
+  staging:
+    runs-on: ubuntu-latest
+    environment: staging
+    steps:
+      - run: echo
+  production:
+    runs-on: ubuntu-latest
+    environment:
+      name: production
+      url: https://example.com
+    steps:
+      - run: echo
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	assert.Equal(t, "staging", workflow.GetJob("staging").EnvironmentName())
+	assert.Equal(t, "production", workflow.GetJob("production").EnvironmentName())
+	assert.Equal(t, "", workflow.GetJob("test").EnvironmentName())
+}
+
+func TestMatrixFilter(t *testing.T) {
+	matrix := map[string][]interface{}{
+		"os":     {"ubuntu", "windows"},
+		"node":   {14, 16},
+		"config": {map[string]interface{}{"debug": true}},
+	}
+
+	filter := newMatrixFilter(map[string]interface{}{"os": "ubuntu", "extra": "x"}, matrix)
+	assert.True(t, filter.matches(map[string]interface{}{"os": "ubuntu", "node": 14}))
+	assert.False(t, filter.matches(map[string]interface{}{"os": "windows", "node": 14}))
+
+	filter = newMatrixFilter(map[string]interface{}{"config": map[string]interface{}{"debug": true}}, matrix)
+	assert.True(t, filter.matches(map[string]interface{}{"config": map[string]interface{}{"debug": true}}))
+	assert.False(t, filter.matches(map[string]interface{}{"config": map[string]interface{}{"debug": false}}))
+
+	filter = newMatrixFilter(map[string]interface{}{"node": 16}, matrix)
+	assert.False(t, filter.matches(map[string]interface{}{"node": "16"}))
+	assert.True(t, filter.matches(map[string]interface{}{"node": 16}))
+}
+
+func TestMatrixCombinations(t *testing.T) {
+	matrix := map[string][]interface{}{
+		"os":   {"ubuntu", "windows"},
+		"node": {14, 16, 18},
+	}
+	combinations := matrixCombinations(matrix, []map[string]interface{}{
+		{"os": "windows", "node": 14},
+		{"node": 18},
+	})
+	assert.Equal(t, []map[string]interface{}{
+		{"os": "ubuntu", "node": 14},
+		{"os": "ubuntu", "node": 16},
+		{"os": "windows", "node": 16},
+	}, combinations)
+
+	assert.Len(t, matrixCombinations(matrix, nil), 6)
+	assert.Empty(t, matrixCombinations(map[string][]interface{}{"os": {}, "node": {14}}, nil))
+	assert.Empty(t, matrixCombinations(map[string][]interface{}{}, nil))
+}
+
+// BenchmarkGetMatrixes plans a matrix of 10000 combinations with excludes and includes
+func BenchmarkGetMatrixes(b *testing.B) {
+	values := func(prefix string) []interface{} {
+		v := make([]interface{}, 10)
+		for i := range v {
+			v[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return v
+	}
+	matrix := map[string][]interface{}{"a": values("a"), "b": values("b"), "c": values("c"), "d": values("d")}
+	excludes := make([]interface{}, 0, 10)
+	includes := make([]interface{}, 0, 10)
+	for i := 0; i < 10; i++ {
+		excludes = append(excludes, map[string]interface{}{"a": fmt.Sprintf("a%d", i), "b": fmt.Sprintf("b%d", i)})
+		includes = append(includes, map[string]interface{}{"c": fmt.Sprintf("c%d", i), "extra": i})
+	}
+	strategy := map[string]interface{}{"matrix": map[string]interface{}{"exclude": excludes, "include": includes}}
+	for k, v := range matrix {
+		strategy["matrix"].(map[string]interface{})[k] = v
+	}
+	content, err := yaml.Marshal(strategy)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		job := &Job{Strategy: &Strategy{}}
+		if err := yaml.Unmarshal(content, job.Strategy); err != nil {
+			b.Fatal(err)
+		}
+		matrixes, err := job.GetMatrixes()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(matrixes) != 9000 {
+			b.Fatalf("expected 9000 combinations, got %d", len(matrixes))
+		}
+	}
+}
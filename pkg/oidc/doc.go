@@ -0,0 +1,8 @@
+// Package oidc emulates the OIDC token endpoint of GitHub Actions for the runner.
+//
+// Jobs get ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN, actions exchange
+// them for a JWT signed with the key of the handler, which is published with the discovery
+// document under the issuer URL, so local or staging identity providers can trust it.
+//
+// See https://docs.github.com/en/actions/deployment/security-hardening-your-deployments/about-security-hardening-with-openid-connect
+package oidc
@@ -0,0 +1,304 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+const (
+	tokenPath     = "/token"
+	jwksPath      = "/.well-known/jwks"
+	discoveryPath = "/.well-known/openid-configuration"
+
+	tokenLifetime = 10 * time.Minute
+)
+
+// Handler serves the token endpoint, the discovery document and the keys of the issuer
+type Handler struct {
+	listener net.Listener
+	server   *http.Server
+	logger   logrus.FieldLogger
+
+	key    *rsa.PrivateKey
+	keyID  string
+	issuer string
+
+	mu     sync.Mutex
+	claims map[string]map[string]any // claims of the jobs by request token
+
+	outboundIP string
+}
+
+// StartHandler starts the token endpoint. The tokens are signed with the RSA key in
+// keyFile or a generated key if it is empty, the issuer defaults to the URL of the handler.
+func StartHandler(keyFile, issuer, outboundIP string, port uint16, logger logrus.FieldLogger) (*Handler, error) {
+	h := &Handler{
+		claims: map[string]map[string]any{},
+	}
+
+	if logger == nil {
+		discard := logrus.New()
+		discard.Out = io.Discard
+		logger = discard
+	}
+	logger = logger.WithField("module", "oidc")
+	h.logger = logger
+
+	var err error
+	if keyFile != "" {
+		h.key, err = readKey(keyFile)
+	} else {
+		h.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(h.key.PublicKey.N.Bytes())
+	h.keyID = hex.EncodeToString(sum[:8])
+
+	if outboundIP != "" {
+		h.outboundIP = outboundIP
+	} else if ip := common.GetOutboundIP(); ip == nil {
+		return nil, fmt.Errorf("unable to determine outbound IP address")
+	} else {
+		h.outboundIP = ip.String()
+	}
+
+	router := httprouter.New()
+	router.GET(tokenPath, h.middleware(h.token))
+	router.GET(jwksPath, h.middleware(h.jwks))
+	router.GET(discoveryPath, h.middleware(h.discovery))
+
+	// the tokens are only served on the address the containers reach, not on all interfaces
+	listener, err := net.Listen("tcp", net.JoinHostPort(h.outboundIP, strconv.Itoa(int(port))))
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{
+		ReadHeaderTimeout: 2 * time.Second,
+		Handler:           router,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("http serve: %v", err)
+		}
+	}()
+	h.listener = listener
+	h.server = server
+
+	h.issuer = strings.TrimSuffix(issuer, "/")
+	if h.issuer == "" {
+		h.issuer = h.ExternalURL()
+	}
+
+	return h, nil
+}
+
+func readKey(keyFile string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s doesn't contain a PEM encoded key", keyFile)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the key in %s: %w", keyFile, err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s doesn't contain an RSA key", keyFile)
+	}
+	return rsaKey, nil
+}
+
+func (h *Handler) ExternalURL() string {
+	return fmt.Sprintf("http://%s:%d",
+		h.outboundIP,
+		h.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// Issuer returns the iss claim of the tokens
+func (h *Handler) Issuer() string {
+	return h.issuer
+}
+
+// RequestURL returns the value of ACTIONS_ID_TOKEN_REQUEST_URL, actions append &audience=
+func (h *Handler) RequestURL() string {
+	return h.ExternalURL() + tokenPath + "?api-version=2.0"
+}
+
+// Register returns the request token of a job, which is exchanged for tokens with the claims
+func (h *Handler) Register(claims map[string]any) string {
+	b := make([]byte, 20)
+	_, _ = rand.Read(b)
+	requestToken := hex.EncodeToString(b)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.claims[requestToken] = claims
+	return requestToken
+}
+
+func (h *Handler) Close() error {
+	if h == nil {
+		return nil
+	}
+	var retErr error
+	if h.server != nil {
+		err := h.server.Close()
+		if err != nil {
+			retErr = err
+		}
+		h.server = nil
+	}
+	if h.listener != nil {
+		err := h.listener.Close()
+		if errors.Is(err, net.ErrClosed) {
+			err = nil
+		}
+		if err != nil {
+			retErr = err
+		}
+		h.listener = nil
+	}
+	return retErr
+}
+
+// GET /token?audience=
+func (h *Handler) token(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	requestToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	h.mu.Lock()
+	jobClaims, ok := h.claims[requestToken]
+	h.mu.Unlock()
+	if !ok {
+		h.responseJSON(w, r, http.StatusUnauthorized, fmt.Errorf("invalid request token"))
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{}
+	for key, value := range jobClaims {
+		claims[key] = value
+	}
+	if audience := r.URL.Query().Get("audience"); audience != "" {
+		claims["aud"] = audience
+	}
+	claims["iss"] = h.issuer
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Add(-5 * time.Second).Unix()
+	claims["exp"] = now.Add(tokenLifetime).Unix()
+	jti := make([]byte, 16)
+	_, _ = rand.Read(jti)
+	claims["jti"] = hex.EncodeToString(jti)
+
+	token, err := h.sign(claims)
+	if err != nil {
+		h.responseJSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.logger.Infof("Issued an OIDC token for %v with audience %v", claims["sub"], claims["aud"])
+	h.responseJSON(w, r, http.StatusOK, map[string]any{
+		"count": len(token),
+		"value": token,
+	})
+}
+
+// GET /.well-known/jwks
+func (h *Handler) jwks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.responseJSON(w, r, http.StatusOK, map[string]any{
+		"keys": []map[string]any{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": h.keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(h.key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(h.key.PublicKey.E)).Bytes()),
+		}},
+	})
+}
+
+// GET /.well-known/openid-configuration
+func (h *Handler) discovery(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.responseJSON(w, r, http.StatusOK, map[string]any{
+		"issuer":                                h.issuer,
+		"jwks_uri":                              h.issuer + jwksPath,
+		"subject_types_supported":               []string{"public", "pairwise"},
+		"response_types_supported":              []string{"id_token"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      []string{"openid"},
+	})
+}
+
+// sign returns the claims as a JWT signed with RS256
+func (h *Handler) sign(claims map[string]any) (string, error) {
+	header, err := json.Marshal(map[string]any{
+		"typ": "JWT",
+		"alg": "RS256",
+		"kid": h.keyID,
+	})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, h.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (h *Handler) middleware(handler httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		h.logger.Debugf("%s %s", r.Method, r.RequestURI)
+		handler(w, r, params)
+	}
+}
+
+func (h *Handler) responseJSON(w http.ResponseWriter, r *http.Request, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	var data []byte
+	if err, ok := v.(error); ok {
+		h.logger.Errorf("%v %v: %v", r.Method, r.RequestURI, err)
+		data, _ = json.Marshal(map[string]any{
+			"message": err.Error(),
+		})
+	} else {
+		data, _ = json.Marshal(v)
+	}
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
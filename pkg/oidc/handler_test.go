@@ -0,0 +1,115 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getJSON(t *testing.T, url string, requestToken string, v any) int {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if requestToken != "" {
+		req.Header.Set("Authorization", "Bearer "+requestToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	return resp.StatusCode
+}
+
+func TestHandler(t *testing.T) {
+	handler, err := StartHandler("", "", "127.0.0.1", 0, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, handler.Close())
+		assert.Nil(t, handler.server)
+		assert.Nil(t, handler.listener)
+	}()
+	assert.Equal(t, handler.ExternalURL(), handler.Issuer())
+
+	requestToken := handler.Register(map[string]any{
+		"sub": "repo:octo/hello:ref:refs/heads/main",
+		"aud": "https://github.com/octo",
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		var v map[string]any
+		assert.Equal(t, http.StatusUnauthorized, getJSON(t, handler.RequestURL(), "invalid", &v))
+	})
+
+	t.Run("token", func(t *testing.T) {
+		var discovery map[string]any
+		require.Equal(t, http.StatusOK, getJSON(t, handler.Issuer()+discoveryPath, "", &discovery))
+		var jwks struct {
+			Keys []map[string]string
+		}
+		require.Equal(t, http.StatusOK, getJSON(t, discovery["jwks_uri"].(string), "", &jwks))
+		require.Len(t, jwks.Keys, 1)
+		n, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0]["n"])
+		require.NoError(t, err)
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+
+		var response map[string]any
+		require.Equal(t, http.StatusOK, getJSON(t, handler.RequestURL()+"&audience=sts.amazonaws.com", requestToken, &response))
+		parts := strings.Split(response["value"].(string), ".")
+		require.Len(t, parts, 3)
+
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		assert.NoError(t, rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature))
+
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		var claims map[string]any
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "repo:octo/hello:ref:refs/heads/main", claims["sub"])
+		assert.Equal(t, "sts.amazonaws.com", claims["aud"])
+		assert.Equal(t, discovery["issuer"], claims["iss"])
+		assert.Greater(t, claims["exp"], claims["iat"])
+	})
+}
+
+func TestStartHandlerOutboundIP(t *testing.T) {
+	handler, err := StartHandler("", "", "127.0.0.1", 0, nil)
+	require.NoError(t, err)
+	defer handler.Close()
+	assert.True(t, handler.listener.Addr().(*net.TCPAddr).IP.IsLoopback(), "the handler only listens on the outbound IP")
+}
+
+func TestStartHandlerKeyFile(t *testing.T) {
+	handler, err := StartHandler("", "", "127.0.0.1", 0, nil)
+	require.NoError(t, err)
+	defer handler.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(handler.key),
+	}), 0o600))
+
+	other, err := StartHandler(keyFile, "https://token.example.com/", "127.0.0.1", 0, nil)
+	require.NoError(t, err)
+	defer other.Close()
+	assert.Equal(t, handler.keyID, other.keyID)
+	assert.Equal(t, "https://token.example.com", other.Issuer())
+
+	_, err = StartHandler(filepath.Join(t.TempDir(), "missing.pem"), "", "127.0.0.1", 0, nil)
+	assert.Error(t, err)
+}
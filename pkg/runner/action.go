@@ -2,7 +2,9 @@ package runner
 
 import (
 	"context"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
@@ -11,6 +13,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/kballard/go-shellquote"
@@ -149,12 +152,20 @@ func runActionImpl(step actionStep, actionDir string, remoteAction *remoteAction
 		logger.Debugf("type=%v actionDir=%s actionPath=%s workdir=%s actionCacheDir=%s actionName=%s containerActionDir=%s", stepModel.Type(), actionDir, actionPath, rc.Config.Workdir, rc.ActionCacheDir(), actionName, containerActionDir)
 
 		switch action.Runs.Using {
-		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16:
+		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16, model.ActionRunsUsingNode20:
+			runtime, err := rc.nodeRuntime(ctx, stepModel.Uses, action.Runs.Using)
+			if err != nil {
+				return err
+			}
 			if err := maybeCopyToActionDir(ctx, step, actionDir, actionPath, containerActionDir); err != nil {
 				return err
 			}
-			containerArgs := []string{"node", path.Join(containerActionDir, action.Runs.Main)}
-			logger.Debugf("executing remote job container: %s", containerArgs)
+			node, err := rc.nodeCommand(ctx, runtime)
+			if err != nil {
+				return err
+			}
+			containerArgs := []string{node, path.Join(containerActionDir, action.Runs.Main)}
+			logger.Debugf("executing remote job container with %s: %s", runtime, containerArgs)
 
 			rc.ApplyExtraPath(ctx, step.getEnv())
 
@@ -176,12 +187,38 @@ func runActionImpl(step actionStep, actionDir string, remoteAction *remoteAction
 				model.ActionRunsUsingDocker,
 				model.ActionRunsUsingNode12,
 				model.ActionRunsUsingNode16,
+				model.ActionRunsUsingNode20,
 				model.ActionRunsUsingComposite,
 			}, action.Runs.Using))
 		}
 	}
 }
 
+// declareActionOutputs sets the outputs declared in the action.yml of a node or docker action which
+// it didn't set with GITHUB_OUTPUT or ::set-output to empty strings, and logs the ones it set
+// without declaring them. The outputs of a composite action are the values of its declarations.
+func declareActionOutputs(ctx context.Context, step actionStep, result *model.StepResult) {
+	action := step.getActionModel()
+	if action == nil || action.Name == "(Synthetic)" || action.Runs.Using == model.ActionRunsUsingComposite || result == nil {
+		return
+	}
+	for name := range action.Outputs {
+		if _, ok := result.Outputs[name]; !ok {
+			result.Outputs[name] = ""
+		}
+	}
+	undeclared := make([]string, 0)
+	for name := range result.Outputs {
+		if _, ok := action.Outputs[name]; !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		sort.Strings(undeclared)
+		common.Logger(ctx).Debugf("%s set the outputs %s which aren't declared in its action.yml", step.getStepModel(), strings.Join(undeclared, ", "))
+	}
+}
+
 func setupActionEnv(ctx context.Context, step actionStep, remoteAction *remoteAction) error {
 	rc := step.getRunContext()
 
@@ -213,6 +250,24 @@ func removeGitIgnore(ctx context.Context, directory string) error {
 	return nil
 }
 
+// buildArgsTag returns the tag of the image of a docker action built with the build args, latest
+// without any
+func buildArgsTag(buildArgs map[string]string) string {
+	if len(buildArgs) == 0 {
+		return "latest"
+	}
+	names := make([]string, 0, len(buildArgs))
+	for name := range buildArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s=%s\n", name, buildArgs[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
 // TODO: break out parts of function to reduce complexicity
 //
 //nolint:gocyclo
@@ -220,6 +275,9 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 	logger := common.Logger(ctx)
 	rc := step.getRunContext()
 	action := step.getActionModel()
+	if rc.isMicroVM(ctx) {
+		return errMicroVMDockerAction
+	}
 
 	var prepImage common.Executor
 	var image string
@@ -230,7 +288,14 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 		forcePull = rc.Config.ForcePull
 	} else {
 		// "-dockeraction" enshures that "./", "./test " won't get converted to "act-:latest", "act-test-:latest" which are invalid docker image names
-		image = fmt.Sprintf("%s-dockeraction:%s", regexp.MustCompile("[^a-zA-Z0-9]").ReplaceAllString(actionName, "-"), "latest")
+		buildArgs := make(map[string]string)
+		for k, v := range *step.getEnv() {
+			if strings.HasPrefix(k, "INPUT_") {
+				buildArgs[k] = v
+			}
+		}
+		// the image built with other inputs isn't reused
+		image = fmt.Sprintf("%s-dockeraction:%s", regexp.MustCompile("[^a-zA-Z0-9]").ReplaceAllString(actionName, "-"), buildArgsTag(buildArgs))
 		image = fmt.Sprintf("act-%s", strings.TrimLeft(image, "-"))
 		image = strings.ToLower(image)
 		contextDir, fileName := filepath.Split(filepath.Join(basedir, action.Runs.Image))
@@ -267,6 +332,11 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 				ImageTag:   image,
 				Container:  actionContainer,
 				Platform:   rc.Config.ContainerArchitecture,
+				BuildArgs:  buildArgs,
+				CacheFrom:  rc.Config.BuildCacheFrom,
+				CacheTo:    rc.Config.BuildCacheTo,
+				BuildKit:   rc.Config.UseBuildKit,
+				Secrets:    getWorkflowSecrets(ctx, rc),
 			})
 		} else {
 			logger.Debugf("image '%s' for architecture '%s' already exists", image, rc.Config.ContainerArchitecture)
@@ -281,6 +351,7 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 		cmd = action.Runs.Args
 		evalDockerArgs(ctx, step, action, &cmd)
 	}
+	evalDockerEnv(ctx, step, action)
 	entrypoint := strings.Fields(eval.Interpolate(ctx, step.getStepModel().With["entrypoint"]))
 	if len(entrypoint) == 0 {
 		if action.Runs.Entrypoint != "" {
@@ -295,9 +366,11 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 	stepContainer := newStepContainer(ctx, step, image, cmd, entrypoint)
 	return common.NewPipelineExecutor(
 		prepImage,
-		stepContainer.Pull(forcePull),
+		rc.timed(TimingPull, image, stepContainer.Pull(forcePull)),
+		rc.verifyImage(action.Runs.Image, image).IfBool(strings.HasPrefix(action.Runs.Image, "docker://")),
+		rc.recordImage(SBOMStepImage, image),
 		stepContainer.Remove().IfBool(!rc.Config.ReuseContainers),
-		stepContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
+		rc.timed(TimingCreate, "step container "+image, stepContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop)),
 		stepContainer.Start(true),
 	).Finally(
 		stepContainer.Remove().IfBool(!rc.Config.ReuseContainers),
@@ -321,30 +394,27 @@ func evalDockerArgs(ctx context.Context, step step, action *model.Action, cmd *[
 	}
 	mergeIntoMap(step, step.getEnv(), inputs)
 
-	stepEE := rc.NewStepExpressionEvaluator(ctx, step)
+	ee := rc.NewActionExpressionEvaluator(ctx, step)
 	for i, v := range *cmd {
-		(*cmd)[i] = stepEE.Interpolate(ctx, v)
+		(*cmd)[i] = ee.Interpolate(ctx, v)
 	}
-	mergeIntoMap(step, step.getEnv(), action.Runs.Env)
+}
 
-	ee := rc.NewStepExpressionEvaluator(ctx, step)
-	for k, v := range *step.getEnv() {
-		(*step.getEnv())[k] = ee.Interpolate(ctx, v)
+// evalDockerEnv adds the env of the action.yml of a docker action to the env of the step, also when
+// the step overrides the args of the action
+func evalDockerEnv(ctx context.Context, step step, action *model.Action) {
+	ee := step.getRunContext().NewActionExpressionEvaluator(ctx, step)
+	env := make(map[string]string, len(action.Runs.Env))
+	for k, v := range action.Runs.Env {
+		env[k] = ee.Interpolate(ctx, v)
 	}
+	mergeIntoMap(step, step.getEnv(), env)
 }
 
 func newStepContainer(ctx context.Context, step step, image string, cmd []string, entrypoint []string) container.Container {
 	rc := step.getRunContext()
 	stepModel := step.getStepModel()
-	rawLogger := common.Logger(ctx).WithField("raw_output", true)
-	logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-		if rc.Config.LogOutput {
-			rawLogger.Infof("%s", s)
-		} else {
-			rawLogger.Debugf("%s", s)
-		}
-		return true
-	})
+	logWriter := rc.newLogWriter(ctx)
 	envList := make([]string, 0)
 	for k, v := range *step.getEnv() {
 		envList = append(envList, fmt.Sprintf("%s=%s", k, v))
@@ -354,30 +424,35 @@ func newStepContainer(ctx context.Context, step step, image string, cmd []string
 	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_OS", "Linux"))
 	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_ARCH", container.RunnerArch(ctx)))
 	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", "/tmp"))
+	envList = append(envList, rc.containerProxyEnv()...)
+	envList = append(envList, rc.containerHostEnv()...)
+	envList = append(envList, rc.caCertificatesEnv(rc.JobContainer.GetActPath())...)
+	envList = append(envList, rc.credentialEnv()...)
 
 	binds, mounts := rc.GetBindsAndMounts()
 	networkMode := fmt.Sprintf("container:%s", rc.jobContainerName())
 	if rc.IsHostEnv(ctx) {
 		networkMode = "default"
 	}
-	stepContainer := container.NewContainer(&container.NewContainerInput{
-		Cmd:         cmd,
-		Entrypoint:  entrypoint,
-		WorkingDir:  rc.JobContainer.ToContainerPath(rc.Config.Workdir),
-		Image:       image,
-		Username:    rc.Config.Secrets["DOCKER_USERNAME"],
-		Password:    rc.Config.Secrets["DOCKER_PASSWORD"],
-		Name:        createContainerName(rc.jobContainerName(), stepModel.ID),
-		Env:         envList,
-		Mounts:      mounts,
-		NetworkMode: networkMode,
-		Binds:       binds,
-		Stdout:      logWriter,
-		Stderr:      logWriter,
-		Privileged:  rc.Config.Privileged,
-		UsernsMode:  rc.Config.UsernsMode,
-		Platform:    rc.Config.ContainerArchitecture,
-		Options:     rc.Config.ContainerOptions,
+	stepContainer := ContainerNewContainer(&container.NewContainerInput{
+		Cmd:          cmd,
+		Entrypoint:   entrypoint,
+		WorkingDir:   rc.JobContainer.ToContainerPath(rc.Config.Workdir),
+		Image:        image,
+		Username:     rc.Config.Secrets["DOCKER_USERNAME"],
+		Password:     rc.Config.Secrets["DOCKER_PASSWORD"],
+		Name:         createContainerName(rc.jobContainerName(), stepModel.ID),
+		Env:          envList,
+		Mounts:       mounts,
+		VolumeLabels: rc.volumeLabels(),
+		NetworkMode:  networkMode,
+		Binds:        binds,
+		Stdout:       logWriter,
+		Stderr:       logWriter,
+		Privileged:   rc.Config.Privileged,
+		UsernsMode:   rc.Config.UsernsMode,
+		Platform:     rc.Config.ContainerArchitecture,
+		Options:      rc.Config.ContainerOptions,
 	})
 	return stepContainer
 }
@@ -406,12 +481,14 @@ func populateEnvsFromInput(ctx context.Context, env *map[string]string, action *
 func getContainerActionPaths(step *model.Step, actionDir string, rc *RunContext) (string, string) {
 	actionName := ""
 	containerActionDir := "."
-	if step.Type() != model.StepTypeUsesActionRemote {
+	// a local action in a remote composite action is copied into the container with it
+	inActionCache := strings.HasPrefix(filepath.Clean(actionDir), filepath.Clean(rc.ActionCacheDir())+string(filepath.Separator))
+	if step.Type() != model.StepTypeUsesActionRemote && !inActionCache {
 		actionName = getOsSafeRelativePath(actionDir, rc.Config.Workdir)
 		containerActionDir = rc.JobContainer.ToContainerPath(rc.Config.Workdir) + "/" + actionName
 		actionName = "./" + actionName
-	} else if step.Type() == model.StepTypeUsesActionRemote {
-		actionName = getOsSafeRelativePath(actionDir, rc.ActionCacheDir())
+	} else {
+		actionName = getOsSafeRelativePath(filepath.Clean(actionDir), filepath.Clean(rc.ActionCacheDir()))
 		containerActionDir = rc.JobContainer.GetActPath() + "/actions/" + actionName
 	}
 
@@ -424,6 +501,21 @@ func getContainerActionPaths(step *model.Step, actionDir string, rc *RunContext)
 	return actionName, containerActionDir
 }
 
+// localActionDir returns the directory of the local action a step uses on the host. In a composite
+// action in compositeDir, a `uses` which starts with ${{ github.action_path }} or leaves the
+// workspace like ./../other-action is relative to the directory of the composite action.
+func localActionDir(workdir string, compositeDir string, step *model.Step) string {
+	uses, relative := step.UsesActionPath()
+	if !relative {
+		uses = step.Uses
+		relative = strings.HasPrefix(path.Clean(uses), "../")
+	}
+	if relative && compositeDir != "" {
+		return filepath.Join(compositeDir, uses)
+	}
+	return filepath.Join(workdir, uses)
+}
+
 func getOsSafeRelativePath(s, prefix string) string {
 	actionName := strings.TrimPrefix(s, prefix)
 	if runtime.GOOS == "windows" {
@@ -451,9 +543,7 @@ func hasPreStep(step actionStep) common.Conditional {
 	return func(ctx context.Context) bool {
 		action := step.getActionModel()
 		return action.Runs.Using == model.ActionRunsUsingComposite ||
-			((action.Runs.Using == model.ActionRunsUsingNode12 ||
-				action.Runs.Using == model.ActionRunsUsingNode16) &&
-				action.Runs.Pre != "")
+			(action.Runs.Using.IsNode() && action.Runs.Pre != "")
 	}
 }
 
@@ -467,7 +557,7 @@ func runPreStep(step actionStep) common.Executor {
 		action := step.getActionModel()
 
 		switch action.Runs.Using {
-		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16:
+		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16, model.ActionRunsUsingNode20:
 			// defaults in pre steps were missing, however provided inputs are available
 			populateEnvsFromInput(ctx, step.getEnv(), action, rc)
 			// todo: refactor into step
@@ -477,7 +567,7 @@ func runPreStep(step actionStep) common.Executor {
 				actionPath = newRemoteAction(stepModel.Uses).Path
 				actionDir = fmt.Sprintf("%s/%s", rc.ActionCacheDir(), safeFilename(stepModel.Uses))
 			} else {
-				actionDir = filepath.Join(rc.Config.Workdir, stepModel.Uses)
+				actionDir = localActionDir(rc.Config.Workdir, rc.actionDir, stepModel)
 				actionPath = ""
 			}
 
@@ -494,7 +584,11 @@ func runPreStep(step actionStep) common.Executor {
 				return err
 			}
 
-			containerArgs := []string{"node", path.Join(containerActionDir, action.Runs.Pre)}
+			node, err := rc.nodeCommand(ctx, action.Runs.Using)
+			if err != nil {
+				return err
+			}
+			containerArgs := []string{node, path.Join(containerActionDir, action.Runs.Pre)}
 			logger.Debugf("executing remote job container: %s", containerArgs)
 
 			rc.ApplyExtraPath(ctx, step.getEnv())
@@ -546,9 +640,7 @@ func hasPostStep(step actionStep) common.Conditional {
 	return func(ctx context.Context) bool {
 		action := step.getActionModel()
 		return action.Runs.Using == model.ActionRunsUsingComposite ||
-			((action.Runs.Using == model.ActionRunsUsingNode12 ||
-				action.Runs.Using == model.ActionRunsUsingNode16) &&
-				action.Runs.Post != "")
+			(action.Runs.Using.IsNode() && action.Runs.Post != "")
 	}
 }
 
@@ -568,7 +660,7 @@ func runPostStep(step actionStep) common.Executor {
 			actionPath = newRemoteAction(stepModel.Uses).Path
 			actionDir = fmt.Sprintf("%s/%s", rc.ActionCacheDir(), safeFilename(stepModel.Uses))
 		} else {
-			actionDir = filepath.Join(rc.Config.Workdir, stepModel.Uses)
+			actionDir = localActionDir(rc.Config.Workdir, rc.actionDir, stepModel)
 			actionPath = ""
 		}
 
@@ -582,11 +674,15 @@ func runPostStep(step actionStep) common.Executor {
 		_, containerActionDir := getContainerActionPaths(stepModel, actionLocation, rc)
 
 		switch action.Runs.Using {
-		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16:
+		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16, model.ActionRunsUsingNode20:
 
 			populateEnvsFromSavedState(step.getEnv(), step, rc)
 
-			containerArgs := []string{"node", path.Join(containerActionDir, action.Runs.Post)}
+			node, err := rc.nodeCommand(ctx, action.Runs.Using)
+			if err != nil {
+				return err
+			}
+			containerArgs := []string{node, path.Join(containerActionDir, action.Runs.Post)}
 			logger.Debugf("executing remote job container: %s", containerArgs)
 
 			rc.ApplyExtraPath(ctx, step.getEnv())
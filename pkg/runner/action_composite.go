@@ -41,7 +41,7 @@ func evaluateCompositeInputAndEnv(ctx context.Context, parent *RunContext, step
 	return env
 }
 
-func newCompositeRunContext(ctx context.Context, parent *RunContext, step actionStep, actionPath string) *RunContext {
+func newCompositeRunContext(ctx context.Context, parent *RunContext, step actionStep, actionDir string, actionPath string) *RunContext {
 	env := evaluateCompositeInputAndEnv(ctx, parent, step)
 
 	// run with the global config but without secrets
@@ -71,6 +71,9 @@ func newCompositeRunContext(ctx context.Context, parent *RunContext, step action
 		ExtraPath:    parent.ExtraPath,
 		Parent:       parent,
 		EventJSON:    parent.EventJSON,
+		actionDir:    actionDir,
+		networks:     parent.networks,
+		workspaceGit: parent.workspaceGit,
 	}
 	compositerc.ExprEval = compositerc.NewExpressionEvaluator(ctx)
 
@@ -198,15 +201,7 @@ func (rc *RunContext) newCompositeCommandExecutor(executor common.Executor) comm
 		// handler into the current running job container
 		// We need this, to support scoping commands to the composite action
 		// executing.
-		rawLogger := common.Logger(ctx).WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
-			}
-			return true
-		})
+		logWriter := rc.newLogWriter(ctx)
 
 		oldout, olderr := rc.JobContainer.ReplaceLogWriter(logWriter, logWriter)
 		defer rc.JobContainer.ReplaceLogWriter(oldout, olderr)
@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// validateActionInputs compares the inputs of a step with the inputs declared in the
+// action.yml and returns the warnings GitHub prints for them, and an error for the
+// required inputs without a default which the step doesn't supply
+func validateActionInputs(step *model.Step, action *model.Action) ([]string, error) {
+	if step == nil || action == nil || action.Name == "(Synthetic)" {
+		return nil, nil
+	}
+
+	declared := make(map[string]bool, len(action.Inputs))
+	valid := make([]string, 0, len(action.Inputs))
+	for name := range action.Inputs {
+		declared[strings.ToLower(name)] = true
+		valid = append(valid, fmt.Sprintf("'%s'", name))
+	}
+	sort.Strings(valid)
+
+	supplied := make(map[string]bool, len(step.With))
+	unexpected := make([]string, 0)
+	for name := range step.With {
+		supplied[strings.ToLower(name)] = true
+		if declared[strings.ToLower(name)] {
+			continue
+		}
+		// args and entrypoint are handled by the runner for docker actions
+		if action.Runs.Using == model.ActionRunsUsingDocker && (name == "args" || name == "entrypoint") {
+			continue
+		}
+		unexpected = append(unexpected, fmt.Sprintf("'%s'", name))
+	}
+	sort.Strings(unexpected)
+
+	warnings := make([]string, 0)
+	if len(unexpected) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Unexpected input(s) %s, valid inputs are [%s]", strings.Join(unexpected, ", "), strings.Join(valid, ", ")))
+	}
+
+	deprecated := make([]string, 0)
+	missing := make([]string, 0)
+	for name, input := range action.Inputs {
+		if input.DeprecationMessage != "" && supplied[strings.ToLower(name)] {
+			deprecated = append(deprecated, fmt.Sprintf("Input '%s' has been deprecated with message: %s", name, input.DeprecationMessage))
+		}
+		if input.Required && input.Default == "" && !supplied[strings.ToLower(name)] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(deprecated)
+	warnings = append(warnings, deprecated...)
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return warnings, fmt.Errorf("Input required and not supplied: %s", strings.Join(missing, ", "))
+	}
+	return warnings, nil
+}
+
+// checkActionInputs logs the input warnings of a step, unless they were already
+// reported when the workflow was planned, and fails for missing required inputs
+func (rc *RunContext) checkActionInputs(ctx context.Context, step *model.Step, action *model.Action) error {
+	warnings, err := validateActionInputs(step, action)
+	if !rc.validatedSteps[step] {
+		for _, warning := range warnings {
+			common.Logger(ctx).Warnf("  \u26A0  %s: %s", step, warning)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", step, err)
+	}
+	return nil
+}
+
+// validateCachedActionInputs checks the inputs of the remote actions of a job which
+// are already in the action cache, before any container is started. It returns
+// the steps which were checked.
+func validateCachedActionInputs(ctx context.Context, cacheDir string, replacements map[string]string, job *model.Job) map[*model.Step]bool {
+	validated := map[*model.Step]bool{}
+	if job == nil {
+		return validated
+	}
+	for _, step := range job.Steps {
+		if step == nil || step.Type() != model.StepTypeUsesActionRemote || replaceActionReference(replacements, step.Uses) != step.Uses {
+			continue
+		}
+		ra := newRemoteAction(step.Uses)
+		if ra == nil {
+			continue
+		}
+		actionDir := filepath.Join(cacheDir, safeFilename(step.Uses))
+		if _, err := os.Stat(actionDir); err != nil {
+			continue
+		}
+		reader := func(filename string) (io.Reader, io.Closer, error) {
+			f, err := os.Open(filepath.Join(actionDir, ra.Path, filename))
+			return f, f, err
+		}
+		action, err := readActionImpl(ctx, step, actionDir, ra.Path, reader, func(string, []byte, fs.FileMode) error { return nil })
+		if err != nil {
+			continue
+		}
+		// missing required inputs fail the step once it runs
+		warnings, _ := validateActionInputs(step, action)
+		for _, warning := range warnings {
+			common.Logger(ctx).Warnf("%s: %s", step, warning)
+		}
+		validated[step] = true
+	}
+	return validated
+}
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestValidateActionInputs(t *testing.T) {
+	action := &model.Action{
+		Name: "test",
+		Inputs: map[string]model.Input{
+			"token":   {Required: true},
+			"user":    {Required: true},
+			"path":    {Required: true, Default: "."},
+			"verbose": {DeprecationMessage: "use debug logging"},
+		},
+		Runs: model.ActionRuns{Using: model.ActionRunsUsingNode16},
+	}
+
+	warnings, err := validateActionInputs(&model.Step{With: map[string]string{"Token": "x", "user": "me"}}, action)
+	assert.Empty(t, warnings)
+	assert.NoError(t, err)
+
+	warnings, err = validateActionInputs(&model.Step{With: map[string]string{"pth": "src", "args": "-v", "verbose": "true"}}, action)
+	assert.Equal(t, []string{
+		"Unexpected input(s) 'args', 'pth', valid inputs are ['path', 'token', 'user', 'verbose']",
+		"Input 'verbose' has been deprecated with message: use debug logging",
+	}, warnings)
+	assert.EqualError(t, err, "Input required and not supplied: token, user")
+
+	action.Runs.Using = model.ActionRunsUsingDocker
+	warnings, err = validateActionInputs(&model.Step{With: map[string]string{"token": "x", "user": "me", "args": "-v", "entrypoint": "sh"}}, action)
+	assert.Empty(t, warnings)
+	assert.NoError(t, err)
+
+	warnings, err = validateActionInputs(&model.Step{With: map[string]string{"args": "-v"}}, &model.Action{Name: "(Synthetic)"})
+	assert.Empty(t, warnings)
+	assert.NoError(t, err)
+}
+
+func TestCheckActionInputs(t *testing.T) {
+	action := &model.Action{
+		Name:   "test",
+		Inputs: map[string]model.Input{"token": {Required: true}},
+		Runs:   model.ActionRuns{Using: model.ActionRunsUsingNode16},
+	}
+	step := &model.Step{ID: "checkout", Uses: "org/repo@v1"}
+	rc := &RunContext{validatedSteps: map[*model.Step]bool{step: true}}
+
+	// the step fails even if its warnings were reported when the workflow was planned
+	assert.EqualError(t, rc.checkActionInputs(context.Background(), step, action), "org/repo@v1: Input required and not supplied: token")
+
+	step.With = map[string]string{"token": "x"}
+	assert.NoError(t, rc.checkActionInputs(context.Background(), step, action))
+}
+
+func TestValidateCachedActionInputs(t *testing.T) {
+	cacheDir := t.TempDir()
+	actionDir := filepath.Join(cacheDir, safeFilename("org/repo/sub@v1"), "sub")
+	assert.NoError(t, os.MkdirAll(actionDir, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(actionDir, "action.yml"), []byte(`
+name: cached
+inputs:
+  name:
+    required: true
+runs:
+  using: node16
+  main: index.js
+`), 0o644))
+
+	cached := &model.Step{Uses: "org/repo/sub@v1", With: map[string]string{"nme": "x"}}
+	notCached := &model.Step{Uses: "org/other@v1"}
+	local := &model.Step{Uses: "./local"}
+	job := &model.Job{Steps: []*model.Step{cached, notCached, local}}
+
+	validated := validateCachedActionInputs(context.Background(), cacheDir, nil, job)
+	assert.Equal(t, map[*model.Step]bool{cached: true}, validated)
+}
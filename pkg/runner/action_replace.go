@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// replaceActionReference rewrites the action reference of a uses: key with the most
+// specific matching replacement. A pattern with @ matches the whole reference, a
+// pattern without @ matches every ref of the action, and a trailing * matches any
+// suffix, which is substituted for the * of the replacement, e.g.
+//
+//	actions/checkout@v4 = ./.github/stubs/checkout
+//	actions/cache       = mycorp/cache
+//	mycorp/*            = ./actions/*
+//
+// Replacements by another remote action keep the ref of the original reference
+// if they have none.
+func replaceActionReference(replacements map[string]string, uses string) string {
+	name, ref, hasRef := strings.Cut(uses, "@")
+
+	matched := ""
+	replacement := ""
+	for pattern, r := range replacements {
+		subject := name
+		if strings.Contains(pattern, "@") {
+			subject = uses
+		}
+		suffix := ""
+		if strings.HasSuffix(pattern, "*") {
+			prefix := strings.TrimSuffix(pattern, "*")
+			if !strings.HasPrefix(subject, prefix) {
+				continue
+			}
+			suffix = strings.TrimPrefix(subject, prefix)
+		} else if subject != pattern {
+			continue
+		}
+		if len(pattern) > len(matched) || (len(pattern) == len(matched) && pattern < matched) {
+			matched = pattern
+			replacement = strings.ReplaceAll(r, "*", suffix)
+		}
+	}
+
+	if matched == "" {
+		return uses
+	}
+	if !strings.HasPrefix(replacement, "./") && !strings.Contains(replacement, "@") && hasRef {
+		replacement = fmt.Sprintf("%s@%s", replacement, ref)
+	}
+	return replacement
+}
+
+// validateActionReplacement checks that an action is replaced by a remote action or
+// an action in the working directory
+func validateActionReplacement(pattern string, replacement string) error {
+	if pattern == "" || strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "docker://") {
+		return fmt.Errorf("invalid action replacement '%s', only remote actions can be replaced", pattern)
+	}
+	if strings.HasPrefix(replacement, "./") {
+		return nil
+	}
+	uses := replacement
+	if !strings.Contains(uses, "@") {
+		uses += "@ref"
+	}
+	if newRemoteAction(uses) == nil {
+		return fmt.Errorf("invalid replacement '%s' of action '%s', expected {org}/{repo}[/path][@ref] or a ./path in the working directory", replacement, pattern)
+	}
+	return nil
+}
+
+// replaceAction returns a copy of the step using the replacement of its action, or
+// the step itself if the action isn't replaced
+func (rc *RunContext) replaceAction(step *model.Step) *model.Step {
+	if rc.Config == nil || len(rc.Config.ActionReplacements) == 0 || step.Type() != model.StepTypeUsesActionRemote {
+		return step
+	}
+	uses := replaceActionReference(rc.Config.ActionReplacements, step.Uses)
+	if uses == step.Uses {
+		return step
+	}
+	replaced := *step
+	replaced.Uses = uses
+	return &replaced
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestReplaceActionReference(t *testing.T) {
+	replacements := map[string]string{
+		"actions/checkout@v4": "./.github/stubs/checkout",
+		"actions/cache":       "mycorp/cache",
+		"actions/setup-go":    "mycorp/setup-go@main",
+		"mycorp/*":            "./actions/*",
+		"mycorp/special":      "other/special",
+	}
+
+	for uses, expected := range map[string]string{
+		"actions/checkout@v4":  "./.github/stubs/checkout",
+		"actions/checkout@v3":  "actions/checkout@v3",
+		"actions/cache@v3":     "mycorp/cache@v3",
+		"actions/setup-go@v4":  "mycorp/setup-go@main",
+		"mycorp/lint@v1":       "./actions/lint",
+		"mycorp/lint/sub@v1":   "./actions/lint/sub",
+		"mycorp/special@v2":    "other/special@v2",
+		"actions/upload@v3":    "actions/upload@v3",
+		"notmycorp/action@v1":  "notmycorp/action@v1",
+		"actions/checkoutx@v4": "actions/checkoutx@v4",
+	} {
+		assert.Equal(t, expected, replaceActionReference(replacements, uses), uses)
+	}
+}
+
+func TestValidateActionReplacement(t *testing.T) {
+	assert.NoError(t, validateActionReplacement("actions/checkout@v4", "./stubs/checkout"))
+	assert.NoError(t, validateActionReplacement("actions/cache", "mycorp/cache"))
+	assert.NoError(t, validateActionReplacement("mycorp/*", "other/*@main"))
+	assert.Error(t, validateActionReplacement("./local", "org/repo@v1"))
+	assert.Error(t, validateActionReplacement("actions/cache", "/abs/path"))
+	assert.Error(t, validateActionReplacement("actions/cache", "cache"))
+}
+
+func TestRunContextReplaceAction(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			ActionReplacements: map[string]string{"actions/checkout@v4": "./stubs/checkout"},
+		},
+	}
+
+	step := &model.Step{ID: "checkout", Uses: "actions/checkout@v4"}
+	replaced := rc.replaceAction(step)
+	assert.Equal(t, "./stubs/checkout", replaced.Uses)
+	assert.Equal(t, model.StepTypeUsesActionLocal, replaced.Type())
+	assert.Equal(t, "checkout", replaced.ID)
+	assert.Equal(t, "actions/checkout@v4", step.Uses)
+
+	other := &model.Step{Uses: "actions/cache@v3"}
+	assert.Same(t, other, rc.replaceAction(other))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"io/fs"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -247,3 +248,132 @@ func TestActionRunner(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalActionDir(t *testing.T) {
+	table := []struct {
+		uses         string
+		compositeDir string
+		expected     string
+	}{
+		{"./action", "", "/workdir/action"},
+		{"./action", "/workdir/composite", "/workdir/action"},
+		{"./../action", "/workdir/composite", "/workdir/action"},
+		{"./../action", "/cache/org-repo@v1/composite", "/cache/org-repo@v1/action"},
+		{"${{ github.action_path }}/action", "/workdir/composite", "/workdir/composite/action"},
+		{"${{ github.action_path }}/../action", "/cache/org-repo@v1/composite", "/cache/org-repo@v1/action"},
+		{"${{ github.action_path }}/action", "", "/workdir/action"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.uses+" in "+tt.compositeDir, func(t *testing.T) {
+			assert.Equal(t, tt.expected, localActionDir("/workdir", tt.compositeDir, &model.Step{Uses: tt.uses}))
+		})
+	}
+}
+
+func TestGetContainerActionPathsInActionCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	rc := &RunContext{
+		Config:       &Config{Workdir: "/workdir"},
+		JobContainer: &containerMock{},
+	}
+
+	// a local action next to a remote composite action
+	actionName, containerActionDir := getContainerActionPaths(&model.Step{Uses: "./../other"}, filepath.Join(cacheDir, "act", "org-repo@v1", "other"), rc)
+	assert.Equal(t, "org-repo@v1/other", actionName)
+	assert.Equal(t, "/var/run/act/actions/org-repo@v1/other", containerActionDir)
+
+	actionName, containerActionDir = getContainerActionPaths(&model.Step{Uses: "./other"}, "/workdir/other", rc)
+	assert.Equal(t, "./other", actionName)
+	assert.Equal(t, "/workdir/other", containerActionDir)
+}
+
+func TestDeclareActionOutputs(t *testing.T) {
+	step := &stepActionRemote{
+		Step: &model.Step{ID: "step", Uses: "org/repo@v1"},
+		action: &model.Action{
+			Outputs: map[string]model.Output{
+				"version": {},
+				"path":    {},
+			},
+			Runs: model.ActionRuns{Using: model.ActionRunsUsingNode16},
+		},
+	}
+	result := &model.StepResult{Outputs: map[string]string{"version": "1.2.3", "extra": "x"}}
+
+	declareActionOutputs(context.Background(), step, result)
+	assert.Equal(t, map[string]string{"version": "1.2.3", "path": "", "extra": "x"}, result.Outputs)
+
+	// the outputs of a composite action are the values of its declarations
+	step.action.Runs.Using = model.ActionRunsUsingComposite
+	result = &model.StepResult{Outputs: map[string]string{}}
+	declareActionOutputs(context.Background(), step, result)
+	assert.Empty(t, result.Outputs)
+}
+
+func TestEvalDockerArgsAndEnv(t *testing.T) {
+	newRunContext := func(jobID string) *RunContext {
+		return &RunContext{
+			Config: &Config{Workdir: "/workdir"},
+			Run: &model.Run{
+				JobID:    jobID,
+				Workflow: &model.Workflow{Jobs: map[string]*model.Job{jobID: {}}},
+			},
+			JobContainer: &containerMock{},
+		}
+	}
+	parent := newRunContext("job")
+	rc := newRunContext("composite-job")
+	// the inputs of the composite action the docker action is used in
+	rc.Env = map[string]string{"INPUT_NAME": "outer"}
+	rc.Parent = parent
+
+	step := &stepActionRemote{
+		Step:       &model.Step{ID: "step", Uses: "org/docker@v1"},
+		RunContext: rc,
+		env: map[string]string{
+			"INPUT_NAME": "inner",
+		},
+	}
+	action := &model.Action{
+		Inputs: map[string]model.Input{"name": {}},
+		Runs: model.ActionRuns{
+			Using: model.ActionRunsUsingDocker,
+			Args:  []string{"--name", "${{ inputs.name }}"},
+			Env: map[string]string{
+				"WHO": "${{ inputs.name }}",
+				"OUT": "${{ github.workspace }}/out",
+			},
+		},
+	}
+
+	cmd := append([]string{}, action.Runs.Args...)
+	evalDockerArgs(context.Background(), step, action, &cmd)
+	assert.Equal(t, []string{"--name", "inner"}, cmd)
+
+	evalDockerEnv(context.Background(), step, action)
+	assert.Equal(t, "inner", step.env["WHO"])
+	assert.Equal(t, "/workdir/out", step.env["OUT"])
+}
+
+func TestBuildArgsTag(t *testing.T) {
+	assert.Equal(t, "latest", buildArgsTag(nil))
+
+	tag := buildArgsTag(map[string]string{"INPUT_A": "1", "INPUT_B": "2"})
+	assert.Len(t, tag, 16)
+	assert.Equal(t, tag, buildArgsTag(map[string]string{"INPUT_B": "2", "INPUT_A": "1"}))
+	assert.NotEqual(t, tag, buildArgsTag(map[string]string{"INPUT_A": "1", "INPUT_B": "3"}))
+}
+
+func TestRejectCacheTo(t *testing.T) {
+	_, err := New(&Config{BuildCacheTo: []string{"type=inline"}})
+	assert.NoError(t, err)
+
+	_, err = New(&Config{BuildCacheTo: []string{"type=registry,ref=user/app:cache"}, UseBuildKit: true})
+	assert.NoError(t, err)
+
+	_, err = New(&Config{BuildCacheTo: []string{"type=registry,ref=user/app:cache"}})
+	assert.EqualError(t, err, "--cache-to type=registry,ref=user/app:cache requires BuildKit, only type=inline is supported with --no-buildkit")
+}
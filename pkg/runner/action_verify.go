@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/kballard/go-shellquote"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// headCommit returns the commit checked out in the clone of a remote action or reusable workflow
+func headCommit(dir string) (string, error) {
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// verifyAction runs the --action-verifier command in the clone of a remote action or reusable
+// workflow before it is read, e.g. gitsign verify for commits signed with sigstore or gh
+// attestation verify. The action is refused when the command fails with
+// --enforce-action-verification, otherwise the failure is a warning. The commits which passed are
+// cached, they aren't verified again by the next runs.
+func (rc *RunContext) verifyAction(ctx context.Context, uses string, repository string, ref string, dir string) error {
+	if rc.Config.ActionVerifier == "" {
+		return nil
+	}
+	err := func() error {
+		sha, err := headCommit(dir)
+		if err != nil {
+			return fmt.Errorf("unable to resolve the commit of the action: %w", err)
+		}
+		return rc.runVerifier(ctx, uses, rc.Config.ActionVerifier, nil, dir, sha,
+			"ACT_ACTION_USES="+uses,
+			"ACT_ACTION_REPOSITORY="+repository,
+			"ACT_ACTION_REF="+ref,
+			"ACT_ACTION_SHA="+sha,
+			"ACT_ACTION_PATH="+dir,
+		)
+	}()
+	return rc.verified(ctx, uses, err)
+}
+
+// verifyImage runs the --image-verifier command with the image of a docker:// step appended once
+// it was pulled, e.g. cosign verify. The digests which passed are cached like the commits of the
+// actions.
+func (rc *RunContext) verifyImage(uses string, image string) common.Executor {
+	return func(ctx context.Context) error {
+		if rc.Config.ImageVerifier == "" || common.Dryrun(ctx) {
+			return nil
+		}
+		err := func() error {
+			digest, err := container.ImageDigest(ctx, image)
+			if err != nil {
+				return fmt.Errorf("unable to resolve the digest of the image: %w", err)
+			}
+			return rc.runVerifier(ctx, uses, rc.Config.ImageVerifier, []string{image}, "", image+"@"+digest,
+				"ACT_IMAGE="+image,
+				"ACT_IMAGE_DIGEST="+digest,
+			)
+		}()
+		return rc.verified(ctx, uses, err)
+	}
+}
+
+// verified reports the result of a verification
+func (rc *RunContext) verified(ctx context.Context, uses string, err error) error {
+	logger := common.Logger(ctx)
+	if err == nil {
+		logger.Debugf("Verified %s", uses)
+		return nil
+	}
+	if rc.Config.EnforceActionVerification {
+		return fmt.Errorf("refusing to run %s: %w", uses, err)
+	}
+	logger.Warnf("Running %s, which failed verification: %v", uses, err)
+	return nil
+}
+
+// runVerifier runs the verifier unless it passed for the commit or digest before
+func (rc *RunContext) runVerifier(ctx context.Context, uses string, verifier string, extraArgs []string, dir string, version string, env ...string) error {
+	args, err := shellquote.Split(verifier)
+	if err != nil {
+		return fmt.Errorf("invalid verifier '%s': %w", verifier, err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("invalid verifier '%s'", verifier)
+	}
+
+	key := sha256.Sum256([]byte(verifier + "\n" + uses + "\n" + version))
+	cached := filepath.Join(rc.ActionCacheDir(), "verified", hex.EncodeToString(key[:]))
+	if _, err := os.Stat(cached); err == nil {
+		common.Logger(ctx).Debugf("%s passed the verifier before", uses)
+		return nil
+	}
+
+	var output bytes.Buffer
+	args = append(args, extraArgs...)
+	//nolint:gosec // the command is given by the user
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("verifier '%s' failed: %w: %s", verifier, err, strings.TrimSpace(output.String()))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cached), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(cached, []byte(version+"\n"), 0o644)
+}
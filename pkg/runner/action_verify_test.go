@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAction(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "action.yml"), []byte("runs:\n  using: node20\n  main: index.js\n"), 0o600))
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("action.yml")
+	require.NoError(t, err)
+	hash, err := wt.Commit("action", &gogit.CommitOptions{Author: &object.Signature{Name: "act", Email: "act@example.com"}})
+	require.NoError(t, err)
+
+	verifier := `sh -c 'test "$ACT_ACTION_USES $ACT_ACTION_REPOSITORY $ACT_ACTION_REF $ACT_ACTION_SHA" = "org/repo/path@v1 org/repo v1 ` + hash.String() + `" && test -f action.yml || { echo unsigned; exit 1; }'`
+
+	table := []struct {
+		name     string
+		verifier string
+		enforce  bool
+		err      string
+	}{
+		{"disabled", "", false, ""},
+		{"verified", verifier, true, ""},
+		{"warn", "false", false, ""},
+		{"enforce", "sh -c 'echo unsigned; exit 1'", true, "refusing to run org/repo/path@v1: verifier 'sh -c 'echo unsigned; exit 1'' failed: exit status 1: unsigned"},
+		{"invalid", "sh -c 'unterminated", true, "refusing to run org/repo/path@v1: invalid verifier 'sh -c 'unterminated': Unterminated single-quoted string"},
+	}
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &RunContext{Config: &Config{ActionVerifier: tt.verifier, EnforceActionVerification: tt.enforce}}
+			err := rc.verifyAction(context.Background(), "org/repo/path@v1", "org/repo", "v1", dir)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+
+	// an action which isn't a clone can't be verified
+	rc := &RunContext{Config: &Config{ActionVerifier: "true", EnforceActionVerification: true}}
+	assert.Error(t, rc.verifyAction(context.Background(), "org/repo/path@v1", "org/repo", "v1", t.TempDir()))
+
+	// the verifier doesn't run again for a commit which passed it
+	counter := filepath.Join(t.TempDir(), "runs")
+	counting := `sh -c 'echo run >> ` + counter + `'`
+	rc = &RunContext{Config: &Config{ActionVerifier: counting, EnforceActionVerification: true}}
+	require.NoError(t, rc.verifyAction(context.Background(), "org/repo/path@v1", "org/repo", "v1", dir))
+	require.NoError(t, rc.verifyAction(context.Background(), "org/repo/path@v1", "org/repo", "v1", dir))
+	runs, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(runs))
+}
@@ -0,0 +1,164 @@
+package runner
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+var exportNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+const (
+	// artifactsJobsDir is the directory of the artifact server recording the artifacts each leg
+	// uploaded, in <job>/<run id>/<artifact name>
+	artifactsJobsDir = ".jobs"
+	// artifactsGzipExtension is appended by the artifact server to the files uploaded compressed
+	artifactsGzipExtension = ".gz__"
+)
+
+// exportName is the name of the leg in the artifacts export, e.g. build-ubuntu-latest-16 for the
+// leg (ubuntu-latest, 16) of the job build. The legs of a reusable workflow are prefixed with the
+// job calling it.
+func (rc *RunContext) exportName() string {
+	name := rc.Name
+	if rc.caller != nil {
+		name = rc.caller.runContext.Run.JobID + "-" + name
+	}
+	return strings.Trim(exportNameRe.ReplaceAllString(name, "-"), "-.")
+}
+
+// exportDir is the directory the artifacts and the export paths of the leg are exported to
+func (rc *RunContext) exportDir(ctx context.Context) string {
+	return filepath.Join(rc.Config.ArtifactsExportDir, rc.getGithubContext(ctx).RunID, rc.exportName())
+}
+
+// resetArtifactsExport forgets the artifacts of a previous run of the leg with the same run id
+// before the leg starts
+func (rc *RunContext) resetArtifactsExport() common.Executor {
+	return func(ctx context.Context) error {
+		if rc.Config.ArtifactsExportDir == "" || common.Dryrun(ctx) {
+			return nil
+		}
+		if rc.Config.ArtifactServerPath != "" {
+			uploaded := filepath.Join(rc.Config.ArtifactServerPath, artifactsJobsDir, rc.exportName(), rc.getGithubContext(ctx).RunID)
+			if err := os.RemoveAll(uploaded); err != nil {
+				return err
+			}
+		}
+		return os.RemoveAll(rc.exportDir(ctx))
+	}
+}
+
+// exportArtifacts copies the artifacts the leg uploaded and the ExportPaths of its workspace into
+// ArtifactsExportDir/<run id>/<job> once it finished, failed legs too
+func (rc *RunContext) exportArtifacts() common.Executor {
+	return func(ctx context.Context) error {
+		if rc.Config.ArtifactsExportDir == "" || common.Dryrun(ctx) {
+			return nil
+		}
+		logger := common.Logger(ctx)
+		dir := rc.exportDir(ctx)
+
+		if rc.Config.ArtifactServerPath != "" {
+			names, err := exportUploadedArtifacts(rc.Config.ArtifactServerPath, rc.exportName(), rc.getGithubContext(ctx).RunID, dir)
+			if err != nil {
+				return fmt.Errorf("failed to export the artifacts: %w", err)
+			}
+			for _, name := range names {
+				logger.Infof("\U0001F4E6  Exported the artifact %s to %s", name, filepath.Join(dir, name))
+			}
+		}
+
+		if len(rc.Config.ExportPaths) == 0 || rc.JobContainer == nil {
+			return nil
+		}
+		for _, p := range rc.Config.ExportPaths {
+			rel, err := workspacePath(p)
+			if err != nil {
+				return fmt.Errorf("the path '%s' to export isn't in the workspace", p)
+			}
+			archive, err := rc.JobContainer.GetContainerArchive(ctx, path.Join(rc.JobContainer.ToContainerPath(rc.Config.Workdir), rel))
+			if err != nil {
+				// e.g. the leg failed before it built the path
+				logger.Warnf("Unable to export %s: %v", rel, err)
+				continue
+			}
+			err = extractWorkspaceArchive(archive, filepath.Join(dir, filepath.FromSlash(rel)), func(string) error { return nil })
+			archive.Close()
+			if err != nil {
+				return fmt.Errorf("failed to export %s: %w", rel, err)
+			}
+			logger.Infof("\U0001F4E6  Exported %s to %s", rel, filepath.Join(dir, filepath.FromSlash(rel)))
+		}
+		return nil
+	}
+}
+
+// exportUploadedArtifacts copies the artifacts the leg uploaded to the artifact server in the run
+// into dst/<artifact name>, the files uploaded compressed are decompressed. It returns the names
+// of the artifacts.
+func exportUploadedArtifacts(artifactPath, job, runID, dst string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(artifactPath, artifactsJobsDir, job, runID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		src := filepath.Join(artifactPath, runID, entry.Name())
+		err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(src, p)
+			if err != nil {
+				return err
+			}
+			gzipped := strings.HasSuffix(rel, artifactsGzipExtension)
+			return exportFile(p, filepath.Join(dst, entry.Name(), strings.TrimSuffix(rel, artifactsGzipExtension)), gzipped)
+		})
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+func exportFile(src, dst string, gzipped bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	var reader io.Reader = in
+	if gzipped {
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
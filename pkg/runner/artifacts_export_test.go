@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestExportArtifacts(t *testing.T) {
+	t.Setenv("ACTIONS_RUNTIME_URL", "")
+	serverDir := t.TempDir()
+	exportDir := t.TempDir()
+	writeFile := func(name string, content []byte) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, content, 0o600))
+	}
+
+	rc := &RunContext{
+		Name: "build (ubuntu-latest, 16)",
+		Config: &Config{
+			ArtifactServerPath: serverDir,
+			ArtifactServerAddr: "127.0.0.1",
+			ArtifactServerPort: "34567",
+			ArtifactsExportDir: exportDir,
+			Env:                map[string]string{"GITHUB_RUN_ID": "42"},
+		},
+		Run: &model.Run{JobID: "build", Workflow: &model.Workflow{Name: "CI", Jobs: map[string]*model.Job{"build": {}}}},
+	}
+	assert.Equal(t, "build-ubuntu-latest-16", rc.exportName())
+
+	env := map[string]string{}
+	setActionRuntimeVars(rc, env)
+	assert.Equal(t, "http://127.0.0.1:34567/jobs/build-ubuntu-latest-16/", env["ACTIONS_RUNTIME_URL"])
+
+	ctx := context.Background()
+	// the artifacts of a previous run with the same id are forgotten
+	writeFile(filepath.Join(serverDir, ".jobs", "build-ubuntu-latest-16", "42", "old"), nil)
+	writeFile(filepath.Join(serverDir, "42", "old", "file"), []byte("old"))
+	require.NoError(t, rc.resetArtifactsExport()(ctx))
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte("compressed"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	writeFile(filepath.Join(serverDir, ".jobs", "build-ubuntu-latest-16", "42", "dist"), nil)
+	writeFile(filepath.Join(serverDir, "42", "dist", "bin", "app"), []byte("binary"))
+	writeFile(filepath.Join(serverDir, "42", "dist", "notes.txt.gz__"), compressed.Bytes())
+	// uploaded by another leg
+	writeFile(filepath.Join(serverDir, ".jobs", "build-ubuntu-latest-18", "42", "other"), nil)
+	writeFile(filepath.Join(serverDir, "42", "other", "file"), []byte("other"))
+
+	require.NoError(t, rc.exportArtifacts()(ctx))
+
+	dir := filepath.Join(exportDir, "42", "build-ubuntu-latest-16")
+	content, err := os.ReadFile(filepath.Join(dir, "dist", "bin", "app"))
+	require.NoError(t, err)
+	assert.Equal(t, "binary", string(content))
+	content, err = os.ReadFile(filepath.Join(dir, "dist", "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "compressed", string(content))
+	assert.NoDirExists(t, filepath.Join(dir, "old"))
+	assert.NoDirExists(t, filepath.Join(dir, "other"))
+}
+
+func TestExportArtifactsDisabled(t *testing.T) {
+	t.Setenv("ACTIONS_RUNTIME_URL", "")
+	rc := &RunContext{Name: "build", Config: &Config{ArtifactServerAddr: "127.0.0.1", ArtifactServerPort: "34567"}}
+	env := map[string]string{}
+	setActionRuntimeVars(rc, env)
+	assert.Equal(t, "http://127.0.0.1:34567/", env["ACTIONS_RUNTIME_URL"])
+	assert.NoError(t, rc.exportArtifacts()(context.Background()))
+}
@@ -0,0 +1,238 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// the types of the references of an audit
+const (
+	AuditTypeAction   = "action"
+	AuditTypeWorkflow = "workflow"
+	AuditTypeDocker   = "docker"
+)
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// AuditReport lists the remote actions, reusable workflows and docker actions a plan uses,
+// including the ones used by composite actions and reusable workflows
+type AuditReport struct {
+	References []*ReferenceAudit `json:"references"`
+}
+
+// ReferenceAudit is a remote action, reusable workflow or docker action used by the plan
+type ReferenceAudit struct {
+	Uses       string   `json:"uses"`
+	Type       string   `json:"type"`
+	Repository string   `json:"repository,omitempty"`
+	Ref        string   `json:"ref"`
+	SHA        string   `json:"sha,omitempty"` // the commit the ref resolves to
+	Pinned     bool     `json:"pinned"`        // the ref is a full commit SHA or an image digest, which can't be moved
+	Archived   bool     `json:"archived,omitempty"`
+	Unknown    bool     `json:"unknown,omitempty"` // the repository doesn't exist or isn't accessible
+	UsedBy     []string `json:"used_by"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// actionAuditor records the references act audit finds while pulling the plan
+type actionAuditor struct {
+	report       *AuditReport
+	references   map[string]*ReferenceAudit
+	repositories map[string]*githubRepository
+	fetched      map[string]bool
+}
+
+// githubRepository is the part of a repository of the GitHub API the audit checks
+type githubRepository struct {
+	Archived bool `json:"archived"`
+}
+
+// NewAuditReport pulls the remote actions and reusable workflows of the plan into the action cache
+// and reports the commits their refs resolve to, references which can be moved and repositories
+// which are archived or don't exist
+func (runner *runnerImpl) NewAuditReport(ctx context.Context, plan *model.Plan) (*AuditReport, error) {
+	auditor := &actionAuditor{
+		report:       &AuditReport{References: []*ReferenceAudit{}},
+		references:   map[string]*ReferenceAudit{},
+		repositories: map[string]*githubRepository{},
+		fetched:      map[string]bool{},
+	}
+	puller := &actionPuller{
+		runner: runner,
+		pulled: map[string]bool{},
+		audit:  auditor,
+	}
+	if err := puller.pullPlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	for _, reference := range auditor.report.References {
+		sort.Strings(reference.UsedBy)
+		reference.Warnings = reference.warnings()
+	}
+	return auditor.report, nil
+}
+
+// use records that the job of the run context uses the reference
+func (a *actionAuditor) use(rc *RunContext, uses string, referenceType string) *ReferenceAudit {
+	reference, ok := a.references[uses]
+	if !ok {
+		reference = newReferenceAudit(uses, referenceType)
+		a.references[uses] = reference
+		a.report.References = append(a.report.References, reference)
+	}
+	usedBy := rc.String()
+	for _, job := range reference.UsedBy {
+		if job == usedBy {
+			return reference
+		}
+	}
+	reference.UsedBy = append(reference.UsedBy, usedBy)
+	return reference
+}
+
+func newReferenceAudit(uses string, referenceType string) *ReferenceAudit {
+	reference := &ReferenceAudit{
+		Uses:   uses,
+		Type:   referenceType,
+		UsedBy: []string{},
+	}
+	if referenceType == AuditTypeDocker {
+		reference.Ref = strings.TrimPrefix(uses, "docker://")
+		reference.Pinned = strings.Contains(reference.Ref, "@sha256:")
+		return reference
+	}
+	repository, ref, _ := strings.Cut(uses, "@")
+	if parts := strings.SplitN(repository, "/", 3); len(parts) >= 2 {
+		repository = parts[0] + "/" + parts[1]
+	}
+	reference.Repository = repository
+	reference.Ref = ref
+	reference.Pinned = commitSHAPattern.MatchString(ref)
+	return reference
+}
+
+func (reference *ReferenceAudit) warnings() []string {
+	warnings := []string{}
+	if reference.Unknown {
+		warnings = append(warnings, fmt.Sprintf("the repository %s doesn't exist or isn't accessible", reference.Repository))
+	}
+	if reference.Archived {
+		warnings = append(warnings, fmt.Sprintf("the repository %s is archived", reference.Repository))
+	}
+	if !reference.Pinned {
+		if reference.SHA != "" {
+			warnings = append(warnings, fmt.Sprintf("the ref %s can be moved, pin the commit %s instead", reference.Ref, reference.SHA))
+		} else if reference.Type == AuditTypeDocker {
+			warnings = append(warnings, fmt.Sprintf("the image %s can be moved, pin its digest instead", reference.Ref))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("the ref %s can be moved, pin a commit instead", reference.Ref))
+		}
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	return warnings
+}
+
+// fetch clones the repository of the reference with the clone executor, a reference which can't
+// be cloned is reported instead of failing the audit. It returns whether the reference was cloned.
+func (a *actionAuditor) fetch(ctx context.Context, rc *RunContext, uses string, dir string, clone common.Executor) bool {
+	if cloned, ok := a.fetched[uses]; ok {
+		return cloned
+	}
+	cloned := a.fetchReference(ctx, rc, uses, dir, clone)
+	a.fetched[uses] = cloned
+	return cloned
+}
+
+func (a *actionAuditor) fetchReference(ctx context.Context, rc *RunContext, uses string, dir string, clone common.Executor) bool {
+	logger := common.Logger(ctx)
+	reference := a.references[uses]
+	if err := a.checkRepository(ctx, rc, reference); err != nil {
+		logger.Warnf("Unable to check the repository %s: %v", reference.Repository, err)
+	}
+	if reference.Unknown {
+		return false
+	}
+	if err := clone(ctx); err != nil {
+		logger.Warnf("Unable to clone %s: %v", uses, err)
+		reference.Unknown = true
+		return false
+	}
+	sha, err := headCommit(dir)
+	if err != nil {
+		logger.Warnf("Unable to resolve the ref of %s: %v", uses, err)
+		return true
+	}
+	reference.SHA = sha
+	return true
+}
+
+// checkRepository looks the repository of the reference up in the GitHub API, the action cache is
+// used as is in offline mode
+func (a *actionAuditor) checkRepository(ctx context.Context, rc *RunContext, reference *ReferenceAudit) error {
+	if rc.Config.ActionOfflineMode {
+		return nil
+	}
+	repository, ok := a.repositories[reference.Repository]
+	if !ok {
+		var err error
+		repository, err = fetchGithubRepository(ctx, rc, reference.Repository)
+		if err != nil {
+			return err
+		}
+		a.repositories[reference.Repository] = repository
+	}
+	if repository == nil {
+		reference.Unknown = true
+		return nil
+	}
+	reference.Archived = repository.Archived
+	return nil
+}
+
+// fetchGithubRepository returns nil when the repository doesn't exist
+func fetchGithubRepository(ctx context.Context, rc *RunContext, name string) (*githubRepository, error) {
+	github := rc.getGithubContext(ctx)
+	org, repo, _ := strings.Cut(name, "/")
+	apiURL := github.APIURL
+	serverURL, token := rc.cloneSource(github, org, repo)
+	if serverURL != github.ServerURL {
+		// the action is replaced with the one of github.com
+		apiURL = "https://api.github.com"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s", apiURL, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+	}
+	repository := &githubRepository{}
+	if err := json.NewDecoder(resp.Body).Decode(repository); err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
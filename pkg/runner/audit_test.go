@@ -0,0 +1,196 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestNewReferenceAudit(t *testing.T) {
+	table := []struct {
+		uses       string
+		typ        string
+		repository string
+		ref        string
+		pinned     bool
+	}{
+		{"actions/checkout@v4", AuditTypeAction, "actions/checkout", "v4", false},
+		{"org/repo/path/to/action@main", AuditTypeAction, "org/repo", "main", false},
+		{"org/repo@8ade135a41bc03ea155e62e844d188df1ea18608", AuditTypeAction, "org/repo", "8ade135a41bc03ea155e62e844d188df1ea18608", true},
+		{"org/repo@8ade135", AuditTypeAction, "org/repo", "8ade135", false},
+		{"org/repo/.github/workflows/ci.yml@v1", AuditTypeWorkflow, "org/repo", "v1", false},
+		{"docker://alpine:3", AuditTypeDocker, "", "alpine:3", false},
+		{"docker://alpine@sha256:51b67269f354137895d43f3b3d810bfacd3945438e94dc5ac55fdac340352f48", AuditTypeDocker, "", "alpine@sha256:51b67269f354137895d43f3b3d810bfacd3945438e94dc5ac55fdac340352f48", true},
+	}
+	for _, tt := range table {
+		reference := newReferenceAudit(tt.uses, tt.typ)
+		assert.Equal(t, tt.repository, reference.Repository, tt.uses)
+		assert.Equal(t, tt.ref, reference.Ref, tt.uses)
+		assert.Equal(t, tt.pinned, reference.Pinned, tt.uses)
+	}
+}
+
+func TestRunnerAuditReport(t *testing.T) {
+	workdir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	writeFile := func(name string, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+	writeFile(filepath.Join(workdir, ".github", "workflows", "push.yml"), `
+name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: org/composite@v1
+      - uses: org/archived@0123456789abcdef0123456789abcdef01234567
+      - uses: org/missing@v1
+      - uses: docker://alpine:3
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: org/composite@v1
+`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/composite", "/repos/org/nested":
+			_, _ = w.Write([]byte(`{"archived": false}`))
+		case "/repos/org/archived":
+			_, _ = w.Write([]byte(`{"archived": true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cloned := []string{}
+	origStepActionRemoteNewCloneExecutor := stepActionRemoteNewCloneExecutor
+	stepActionRemoteNewCloneExecutor = func(input git.NewGitCloneExecutorInput) common.Executor {
+		return func(ctx context.Context) error {
+			cloned = append(cloned, input.URL)
+			action := "runs:\n  using: node20\n  main: index.js\n"
+			if strings.HasSuffix(input.URL, "/composite") {
+				action = "runs:\n  using: composite\n  steps:\n    - uses: org/nested@v2\n"
+			}
+			repo, err := gogit.PlainInit(input.Dir, false)
+			require.NoError(t, err)
+			writeFile(filepath.Join(input.Dir, "action.yml"), action)
+			wt, err := repo.Worktree()
+			require.NoError(t, err)
+			_, err = wt.Add("action.yml")
+			require.NoError(t, err)
+			_, err = wt.Commit("action", &gogit.CommitOptions{Author: &object.Signature{Name: "act", Email: "act@example.com"}})
+			require.NoError(t, err)
+			return nil
+		}
+	}
+	defer (func() {
+		stepActionRemoteNewCloneExecutor = origStepActionRemoteNewCloneExecutor
+	})()
+
+	planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, ".github", "workflows"), true)
+	require.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	require.NoError(t, err)
+
+	r, err := New(&Config{
+		Workdir:        workdir,
+		GitHubInstance: "github.com",
+		Env:            map[string]string{"GITHUB_API_URL": server.URL},
+	})
+	require.NoError(t, err)
+	report, err := r.NewAuditReport(context.Background(), plan)
+	require.NoError(t, err)
+
+	// the repository which doesn't exist isn't cloned
+	assert.ElementsMatch(t, []string{"https://github.com/org/composite", "https://github.com/org/nested", "https://github.com/org/archived"}, cloned)
+
+	references := map[string]*ReferenceAudit{}
+	for _, reference := range report.References {
+		references[reference.Uses] = reference
+	}
+	assert.Len(t, references, 5)
+
+	composite := references["org/composite@v1"]
+	assert.Equal(t, []string{"ci/build", "ci/test"}, composite.UsedBy)
+	assert.Len(t, composite.SHA, 40)
+	assert.Equal(t, []string{"the ref v1 can be moved, pin the commit " + composite.SHA + " instead"}, composite.Warnings)
+
+	nested := references["org/nested@v2"]
+	assert.Equal(t, AuditTypeAction, nested.Type)
+	assert.Equal(t, []string{"ci/build", "ci/test"}, nested.UsedBy)
+
+	archived := references["org/archived@0123456789abcdef0123456789abcdef01234567"]
+	assert.True(t, archived.Pinned)
+	assert.True(t, archived.Archived)
+	assert.Equal(t, []string{"the repository org/archived is archived"}, archived.Warnings)
+
+	missing := references["org/missing@v1"]
+	assert.True(t, missing.Unknown)
+	assert.Empty(t, missing.SHA)
+	assert.Equal(t, []string{"the repository org/missing doesn't exist or isn't accessible", "the ref v1 can be moved, pin a commit instead"}, missing.Warnings)
+
+	docker := references["docker://alpine:3"]
+	assert.Equal(t, AuditTypeDocker, docker.Type)
+	assert.Equal(t, []string{"the image alpine:3 can be moved, pin its digest instead"}, docker.Warnings)
+}
+
+func TestRunnerAuditReportCycle(t *testing.T) {
+	workdir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	writeFile := func(name string, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+	// the reusable workflows call each other
+	writeFile(filepath.Join(workdir, ".github", "workflows", "a.yml"), `
+name: a
+on: [push, workflow_call]
+jobs:
+  call:
+    uses: ./.github/workflows/b.yml
+`)
+	writeFile(filepath.Join(workdir, ".github", "workflows", "b.yml"), `
+name: b
+on: workflow_call
+jobs:
+  call:
+    uses: ./.github/workflows/a.yml
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: docker://alpine:3
+`)
+
+	planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, ".github", "workflows", "a.yml"), true)
+	require.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	require.NoError(t, err)
+
+	r, err := New(&Config{Workdir: workdir, ActionOfflineMode: true})
+	require.NoError(t, err)
+	report, err := r.NewAuditReport(context.Background(), plan)
+	require.NoError(t, err)
+
+	require.Len(t, report.References, 1)
+	assert.Equal(t, []string{"b/build"}, report.References[0].UsedBy)
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// breakpointMutex serializes the breakpoints of parallel jobs, which share the terminal
+var breakpointMutex sync.Mutex
+
+type breakpointAction int
+
+const (
+	breakpointContinue breakpointAction = iota
+	breakpointRetry
+	breakpointShell
+	breakpointAbort
+)
+
+// breakBefore reports whether the name, the id or the action of the step matches a --break-before pattern
+func (rc *RunContext) breakBefore(step step) bool {
+	stepModel := step.getStepModel()
+	for _, pattern := range rc.Config.BreakBefore {
+		for _, value := range []string{stepModel.ID, stepModel.Name, stepModel.Uses} {
+			if value != "" && matchStepPattern(pattern, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runWithBreakpoints runs the main stage of a step, pausing before it with --break-before and
+// after it failed with --break-on-failure to open a shell with the environment of the step
+func (rc *RunContext) runWithBreakpoints(ctx context.Context, step step, stage stepStage, executor common.Executor) error {
+	if stage != stepStageMain || (len(rc.Config.BreakBefore) == 0 && !rc.Config.BreakOnFailure) {
+		return executor(ctx)
+	}
+
+	if rc.breakBefore(step) {
+		action, err := rc.breakpoint(ctx, step, fmt.Sprintf("Paused before %s", step.getStepModel()), false)
+		if err != nil {
+			return err
+		}
+		if action == breakpointAbort {
+			return fmt.Errorf("aborted at the breakpoint before %s", step.getStepModel())
+		}
+	}
+
+	for {
+		err := executor(ctx)
+		if err == nil || !rc.Config.BreakOnFailure || ctx.Err() != nil {
+			return err
+		}
+		action, breakErr := rc.breakpoint(ctx, step, fmt.Sprintf("%s failed: %v", step.getStepModel(), err), true)
+		if breakErr != nil {
+			return breakErr
+		}
+		switch action {
+		case breakpointAbort:
+			return fmt.Errorf("aborted at the breakpoint: %w", err)
+		case breakpointContinue:
+			return err
+		}
+	}
+}
+
+// breakpoint opens a shell in the job container with the environment of the step and asks
+// how to go on when it exits
+func (rc *RunContext) breakpoint(ctx context.Context, step step, reason string, failed bool) (breakpointAction, error) {
+	logger := common.Logger(ctx)
+	interactive, ok := rc.JobContainer.(container.InteractiveContainer)
+	if !ok || !term.IsTerminal(int(os.Stdin.Fd())) {
+		logger.Warnf("%s, but a debug shell needs a terminal", reason)
+		return breakpointContinue, nil
+	}
+
+	breakpointMutex.Lock()
+	defer breakpointMutex.Unlock()
+
+	env := map[string]string{}
+	for k, v := range *step.getEnv() {
+		env[k] = v
+	}
+	rc.ApplyExtraPath(ctx, &env)
+
+	shell := DebugShell
+	if rc.IsHostEnv(ctx) && runtime.GOOS == "windows" {
+		shell = []string{"cmd"}
+	}
+
+	for {
+		logger.Infof("\U0001F41E  %s, opening a shell with the environment of the step, exit it to go on", reason)
+		if err := interactive.ExecInteractive(shell, env, "", "")(ctx); err != nil {
+			logger.Warnf("Debug shell exited: %v", err)
+		}
+
+		action, err := askBreakpointAction(ctx, failed)
+		if err != nil || action != breakpointShell {
+			return action, err
+		}
+	}
+}
+
+func askBreakpointAction(ctx context.Context, failed bool) (breakpointAction, error) {
+	options := "[c]ontinue, [s]hell or [a]bort"
+	if failed {
+		options = "[c]ontinue, [r]etry the step, [s]hell or [a]bort"
+	}
+	for {
+		fmt.Printf("%s? ", options)
+		answer, err := container.ReadStdinLine(ctx)
+		if err != nil {
+			return breakpointAbort, err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "c", "continue":
+			return breakpointContinue, nil
+		case "r", "retry":
+			if failed {
+				return breakpointRetry, nil
+			}
+		case "s", "shell":
+			return breakpointShell, nil
+		case "a", "abort":
+			return breakpointAbort, nil
+		}
+	}
+}
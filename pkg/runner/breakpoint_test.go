@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunContextBreakBefore(t *testing.T) {
+	rc := &RunContext{Config: &Config{BreakBefore: []string{"Deploy *", "docker/*"}}}
+
+	assert.True(t, rc.breakBefore(&stepRun{Step: &model.Step{Name: "Deploy to staging"}}))
+	assert.True(t, rc.breakBefore(&stepActionRemote{Step: &model.Step{Uses: "docker/login-action@v2"}}))
+	assert.False(t, rc.breakBefore(&stepRun{Step: &model.Step{ID: "deploy", Name: "Build"}}))
+}
+
+func TestRunContextRunWithBreakpoints(t *testing.T) {
+	rc := &RunContext{Config: &Config{BreakOnFailure: true, BreakBefore: []string{"*"}}}
+	step := &stepRun{Step: &model.Step{ID: "test"}}
+
+	runs := 0
+	executor := func(ctx context.Context) error {
+		runs++
+		return fmt.Errorf("exit code 1")
+	}
+
+	// without a terminal the breakpoints only log a warning
+	assert.EqualError(t, rc.runWithBreakpoints(context.Background(), step, stepStageMain, executor), "exit code 1")
+	assert.Equal(t, 1, runs)
+
+	// the pre and post stages have no breakpoints
+	assert.EqualError(t, rc.runWithBreakpoints(context.Background(), step, stepStagePost, executor), "exit code 1")
+	assert.Equal(t, 2, runs)
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/model"
+)
+
+// inputs of actions/checkout which act can't emulate, steps using them run the action
+var unsupportedCheckoutInputs = []string{"sparse-checkout", "lfs"}
+
+// isEmulatedCheckout reports whether act checks out the repository of an actions/checkout
+// step itself instead of running the action. Checkouts of the repository act runs in copy
+// the working directory, other refs and repositories are cloned on the host.
+func isEmulatedCheckout(ghc *model.GithubContext, step *model.Step, with map[string]string) bool {
+	if !isCheckoutStep(step) {
+		return false
+	}
+	if isLocalCheckout(ghc, step, with) {
+		return true
+	}
+	for _, input := range unsupportedCheckoutInputs {
+		if value := with[input]; value != "" && value != "false" {
+			return false
+		}
+	}
+	return true
+}
+
+// checkoutWith returns the inputs of an actions/checkout step with their expressions evaluated
+func (sar *stepActionRemote) checkoutWith(ctx context.Context) map[string]string {
+	eval := sar.RunContext.NewExpressionEvaluator(ctx)
+	with := make(map[string]string, len(sar.Step.With))
+	for name, value := range sar.Step.With {
+		with[name] = eval.Interpolate(ctx, value)
+	}
+	return with
+}
+
+// checkoutPath returns the path input of an actions/checkout step relative to the workspace, it is
+// empty for the workspace itself. Absolute paths must be in the workspace like for the action.
+func checkoutPath(workspace string, p string) (string, error) {
+	if path.IsAbs(p) {
+		if p != workspace && !strings.HasPrefix(p, workspace+"/") {
+			return "", fmt.Errorf("the path '%s' of actions/checkout isn't under the workspace '%s'", p, workspace)
+		}
+		p = strings.TrimPrefix(strings.TrimPrefix(p, workspace), "/")
+	}
+	clean := path.Clean(p)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("the path '%s' of actions/checkout isn't under the workspace '%s'", p, workspace)
+	}
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
+
+// checkoutInput returns the clone options of an actions/checkout step and the directory
+// in the workspace the repository is checked out to
+func (sar *stepActionRemote) checkoutInput(ctx context.Context) (git.NewGitCheckoutExecutorInput, string, error) {
+	rc := sar.RunContext
+	github := sar.getGithubContext(ctx)
+	inputs := sar.checkoutWith(ctx)
+	with := func(name string, defaultValue string) string {
+		if value := inputs[name]; value != "" {
+			return value
+		}
+		return defaultValue
+	}
+
+	input := git.NewGitCheckoutExecutorInput{
+		Ref:           with("ref", ""),
+		Submodules:    with("submodules", "false"),
+		FetchTags:     with("fetch-tags", "false") == "true",
+		Token:         with("token", github.Token),
+		SSHKey:        with("ssh-key", ""),
+		SSHKnownHosts: with("ssh-known-hosts", ""),
+		SSHStrict:     with("ssh-strict", "true") == "true",
+	}
+	depth, err := strconv.Atoi(with("fetch-depth", "1"))
+	if err != nil || depth < 0 {
+		return input, "", fmt.Errorf("invalid fetch-depth '%s' of %s", sar.Step.With["fetch-depth"], sar.Step)
+	}
+	input.Depth = depth
+
+	repository := with("repository", github.Repository)
+	serverURL := strings.TrimSuffix(with("github-server-url", github.ServerURL), "/")
+	if strings.EqualFold(repository, github.Repository) {
+		// the local repository also has the commits and refs which aren't pushed yet
+		input.URL = rc.Config.Workdir
+		input.Token = ""
+	} else if input.SSHKey != "" {
+		host := strings.TrimPrefix(strings.TrimPrefix(serverURL, "https://"), "http://")
+		input.URL = fmt.Sprintf("git@%s:%s.git", host, repository)
+	} else {
+		input.URL = fmt.Sprintf("%s/%s", serverURL, repository)
+	}
+
+	workspace := rc.JobContainer.ToContainerPath(rc.Config.Workdir)
+	checkoutPath, err := checkoutPath(workspace, inputs["path"])
+	if err != nil {
+		return input, "", err
+	}
+	return input, path.Join(workspace, checkoutPath), nil
+}
+
+// runEmulatedCheckout clones the ref or repository of an actions/checkout step on the
+// host and copies it into the workspace, which is emptied first like with clean: true of the
+// action. A bound workspace is the working directory of the user, it isn't overwritten.
+func (sar *stepActionRemote) runEmulatedCheckout() common.Executor {
+	return func(ctx context.Context) error {
+		rc := sar.RunContext
+		if rc.Config.BindWorkdir {
+			return fmt.Errorf("%s would overwrite the working directory bound with --bind, run it without --bind", sar.Step)
+		}
+		input, copyToPath, err := sar.checkoutInput(ctx)
+		if err != nil {
+			return err
+		}
+
+		dir, err := os.MkdirTemp("", "act-checkout")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		input.Dir = dir
+
+		clean := sar.checkoutWith(ctx)["clean"] != "false"
+		return common.NewPipelineExecutor(
+			git.NewGitCheckoutExecutor(input),
+			rc.JobContainer.Exec([]string{"mkdir", "-p", copyToPath}, nil, "0", "").IfBool(clean),
+			rc.JobContainer.Exec([]string{"find", copyToPath, "-mindepth", "1", "-delete"}, nil, "0", "").IfBool(clean),
+			rc.JobContainer.CopyDir(copyToPath, dir+string(filepath.Separator)+".", false),
+		)(ctx)
+	}
+}
@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestIsEmulatedCheckout(t *testing.T) {
+	ghc := &model.GithubContext{Repository: "org/repo", Ref: "refs/heads/main"}
+
+	for name, tt := range map[string]struct {
+		step     *model.Step
+		local    bool
+		emulated bool
+	}{
+		"local":            {&model.Step{Uses: "actions/checkout@v3"}, true, true},
+		"same-ref":         {&model.Step{Uses: "actions/checkout@v3", With: map[string]string{"ref": "refs/heads/main"}}, true, true},
+		"other-ref":        {&model.Step{Uses: "actions/checkout@v3", With: map[string]string{"ref": "v1", "fetch-depth": "0"}}, false, true},
+		"other-repository": {&model.Step{Uses: "actions/checkout@v3", With: map[string]string{"repository": "org/other", "submodules": "true"}}, false, true},
+		"lfs":              {&model.Step{Uses: "actions/checkout@v3", With: map[string]string{"repository": "org/other", "lfs": "true"}}, false, false},
+		"sparse-checkout":  {&model.Step{Uses: "actions/checkout@v3", With: map[string]string{"ref": "v1", "sparse-checkout": "src"}}, false, false},
+		"other-action":     {&model.Step{Uses: "actions/cache@v3"}, false, false},
+		"same-repository":  {&model.Step{Uses: "actions/checkout@v3", With: map[string]string{"repository": "Org/Repo", "ref": ""}}, true, true},
+	} {
+		assert.Equal(t, tt.local, isLocalCheckout(ghc, tt.step, tt.step.With), name)
+		assert.Equal(t, tt.emulated, isEmulatedCheckout(ghc, tt.step, tt.step.With), name)
+	}
+}
+
+func TestCheckoutPath(t *testing.T) {
+	for p, expected := range map[string]string{
+		"":                       "",
+		".":                      "",
+		"./":                     "",
+		"/github/workspace":      "",
+		"/github/workspace/":     "",
+		"sub/dir/":               "sub/dir",
+		"./sub/../other":         "other",
+		"/github/workspace/sub":  "sub",
+		"/github/workspace2":     "error",
+		"/tmp/sub":               "error",
+		"../sub":                 "error",
+		"sub/../../github/other": "error",
+	} {
+		actual, err := checkoutPath("/github/workspace", p)
+		if expected == "error" {
+			assert.Error(t, err, p)
+			continue
+		}
+		assert.NoError(t, err, p)
+		assert.Equal(t, expected, actual, p)
+	}
+}
+
+func TestStepActionRemoteEmulatedCheckout(t *testing.T) {
+	basedir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@test.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	origin := filepath.Join(basedir, "org", "other")
+	require.NoError(t, os.MkdirAll(origin, 0o755))
+	git("-C", origin, "init", "--quiet", "--initial-branch=master")
+	require.NoError(t, os.WriteFile(filepath.Join(origin, "file.txt"), []byte("master"), 0o600))
+	git("-C", origin, "add", "file.txt")
+	git("-C", origin, "commit", "--quiet", "-m", "master")
+	git("-C", origin, "checkout", "--quiet", "-b", "feature")
+	require.NoError(t, os.WriteFile(filepath.Join(origin, "file.txt"), []byte("feature"), 0o600))
+	git("-C", origin, "commit", "--quiet", "-am", "feature")
+
+	cm := &containerMock{}
+	sar := &stepActionRemote{
+		Step: &model.Step{
+			Uses: "actions/checkout@v3",
+			With: map[string]string{"repository": "org/other", "ref": "feature", "path": "other"},
+		},
+		RunContext: &RunContext{
+			Config: &Config{
+				Workdir: t.TempDir(),
+				Env:     map[string]string{"GITHUB_SERVER_URL": basedir},
+			},
+			Run: &model.Run{
+				JobID: "1",
+				Workflow: &model.Workflow{
+					Jobs: map[string]*model.Job{"1": {}},
+				},
+			},
+			JobContainer: cm,
+		},
+	}
+
+	input, copyToPath, err := sar.checkoutInput(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, origin, input.URL)
+	assert.Equal(t, "feature", input.Ref)
+	assert.Equal(t, 1, input.Depth)
+	assert.True(t, input.SSHStrict)
+	assert.Equal(t, filepath.ToSlash(filepath.Join(cm.ToContainerPath(sar.RunContext.Config.Workdir), "other")), copyToPath)
+
+	// the files of the workspace are removed before the checkout is copied, like with clean: true
+	calls := []string{}
+	cm.On("Exec", []string{"mkdir", "-p", copyToPath}, map[string]string(nil), "0", "").Return(func(ctx context.Context) error {
+		calls = append(calls, "mkdir")
+		return nil
+	})
+	cm.On("Exec", []string{"find", copyToPath, "-mindepth", "1", "-delete"}, map[string]string(nil), "0", "").Return(func(ctx context.Context) error {
+		calls = append(calls, "clean")
+		return nil
+	})
+	copied := ""
+	var copyFrom string
+	cm.On("CopyDir", copyToPath, mock.Anything, false).Run(func(args mock.Arguments) {
+		copyFrom = args.String(1)
+	}).Return(func(ctx context.Context) error {
+		calls = append(calls, "copy")
+		content, err := os.ReadFile(filepath.Join(copyFrom, "file.txt"))
+		copied = string(content)
+		return err
+	})
+	require.NoError(t, sar.runEmulatedCheckout()(context.Background()))
+	assert.Equal(t, "feature", copied)
+	assert.Equal(t, []string{"mkdir", "clean", "copy"}, calls)
+	cm.AssertExpectations(t)
+
+	// clean: false keeps the files of the workspace
+	calls = []string{}
+	sar.Step.With["clean"] = "false"
+	require.NoError(t, sar.runEmulatedCheckout()(context.Background()))
+	assert.Equal(t, []string{"copy"}, calls)
+	delete(sar.Step.With, "clean")
+
+	// a bound workspace is the working directory of the user
+	calls = []string{}
+	sar.RunContext.Config.BindWorkdir = true
+	assert.ErrorContains(t, sar.runEmulatedCheckout()(context.Background()), "--bind")
+	assert.Empty(t, calls)
+	sar.RunContext.Config.BindWorkdir = false
+
+	sar.Step.With["fetch-depth"] = "all"
+	_, _, err = sar.checkoutInput(context.Background())
+	assert.Error(t, err)
+}
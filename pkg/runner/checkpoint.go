@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+// checkpointRepository is the repository of the images of the checkpoints, tagged per job
+const checkpointRepository = "act-checkpoint"
+
+// jobCheckpoint is the state of a job after its last successful step, --resume continues the job
+// from it in a container of the committed image
+type jobCheckpoint struct {
+	Fingerprint      string                       `json:"fingerprint"` // changes with the steps, the image and the matrix of the job
+	Image            string                       `json:"image"`
+	ImageID          string                       `json:"image_id"`
+	Steps            int                          `json:"steps"` // number of main steps which ran before the checkpoint
+	StepResults      map[string]*model.StepResult `json:"step_results"`
+	Env              map[string]string            `json:"env"`
+	ExtraPath        []string                     `json:"path"`
+	IntraActionState map[string]map[string]string `json:"state"`
+}
+
+// checkpointKey identifies the job in the working directory, it doesn't change with the workflow
+func (rc *RunContext) checkpointKey() string {
+	h := sha256.Sum256([]byte(rc.Config.Workdir + "\n" + rc.String()))
+	return hex.EncodeToString(h[:])
+}
+
+func (rc *RunContext) checkpointFile() string {
+	return filepath.Join(rc.ActionCacheDir(), "checkpoints", rc.checkpointKey()+".json")
+}
+
+// checkpointFingerprint hashes what the steps before a checkpoint depend on, a checkpoint of a
+// job whose steps, image or matrix changed isn't resumed
+func (rc *RunContext) checkpointFingerprint(image string) (string, error) {
+	steps, err := yaml.Marshal(rc.Run.Job().Steps)
+	if err != nil {
+		return "", err
+	}
+	matrix, err := json.Marshal(rc.Matrix)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "image=%s\nmatrix=%s\n", image, matrix)
+	_, _ = h.Write(steps)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (rc *RunContext) readCheckpoint() (*jobCheckpoint, error) {
+	content, err := os.ReadFile(rc.checkpointFile())
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &jobCheckpoint{}
+	if err := json.Unmarshal(content, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// loadCheckpoint prepares the job to resume from its checkpoint with --resume and returns the
+// image of the checkpoint, "" if the job starts from the beginning. A checkpoint left by a previous
+// run is dropped when the job doesn't resume from it.
+func (rc *RunContext) loadCheckpoint(ctx context.Context, image string) string {
+	if !rc.Config.Checkpoint || common.Dryrun(ctx) {
+		return ""
+	}
+	logger := common.Logger(ctx)
+	checkpoint, err := rc.readCheckpoint()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("Unable to read the checkpoint of the job: %v", err)
+		}
+		return ""
+	}
+	fingerprint, err := rc.checkpointFingerprint(image)
+	if err != nil {
+		logger.Warnf("Unable to read the checkpoint of the job: %v", err)
+		return ""
+	}
+	if rc.Config.Resume && checkpoint.Fingerprint == fingerprint {
+		if exists, err := container.ImageExistsLocally(ctx, checkpoint.Image, rc.Config.ContainerArchitecture); err == nil && exists {
+			logger.Infof("\u23E9  Resuming after step %d from the checkpoint %s", checkpoint.Steps, checkpoint.Image)
+			rc.checkpoint = checkpoint
+			return checkpoint.Image
+		}
+		logger.Warnf("The image %s of the checkpoint of the job is gone, running all steps", checkpoint.Image)
+	} else if rc.Config.Resume {
+		logger.Warnf("The steps, the image or the matrix of the job changed since its checkpoint, running all steps")
+	}
+	rc.checkpoint = checkpoint
+	_ = rc.dropCheckpoint()(ctx)
+	return ""
+}
+
+// saveCheckpoint commits the job container after the main step with the index succeeded
+func (rc *RunContext) saveCheckpoint(ctx context.Context, index int) error {
+	committer, ok := rc.JobContainer.(container.CheckpointContainer)
+	if !rc.Config.Checkpoint || !ok || rc.IsHostEnv(ctx) || common.Dryrun(ctx) {
+		return nil
+	}
+	fingerprint, err := rc.checkpointFingerprint(rc.platformImage(ctx))
+	if err != nil {
+		return err
+	}
+	image := fmt.Sprintf("%s:%s", checkpointRepository, rc.checkpointKey()[:20])
+	common.Logger(ctx).Debugf("Saving the checkpoint %s after step %d", image, index+1)
+	imageID, err := committer.Commit(ctx, image)
+	if err != nil {
+		return err
+	}
+	if rc.checkpoint != nil && rc.checkpoint.ImageID != "" && rc.checkpoint.ImageID != imageID {
+		// the image of the previous checkpoint is in use when the job was resumed from it
+		_, _ = container.RemoveImage(ctx, rc.checkpoint.ImageID, false, true)
+	}
+
+	rc.checkpoint = &jobCheckpoint{
+		Fingerprint:      fingerprint,
+		Image:            image,
+		ImageID:          imageID,
+		Steps:            index + 1,
+		StepResults:      rc.StepResults,
+		Env:              rc.GlobalEnv,
+		ExtraPath:        rc.ExtraPath,
+		IntraActionState: rc.IntraActionState,
+	}
+	content, err := json.Marshal(rc.checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(rc.checkpointFile()), 0o755); err != nil {
+		return err
+	}
+	return common.WriteFileAtomic(rc.checkpointFile(), content, 0o644)
+}
+
+// dropCheckpoint removes the checkpoint of a job which succeeded or doesn't resume from it. The
+// volumes of the job are removed with its containers again once it has no checkpoint.
+func (rc *RunContext) dropCheckpoint() common.Executor {
+	checkpoint := rc.checkpoint
+	rc.checkpoint = nil
+	return func(ctx context.Context) error {
+		if checkpoint == nil {
+			return nil
+		}
+		if err := os.Remove(rc.checkpointFile()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		_, err := container.RemoveImage(ctx, checkpoint.Image, false, true)
+		return err
+	}
+}
+
+// resumed reports whether the step with the index ran before the checkpoint the job resumes from
+func (rc *RunContext) resumed(index int) bool {
+	return rc.checkpoint != nil && rc.Config.Resume && index < rc.checkpoint.Steps
+}
+
+// skipResumedStep replaces the pre stage of a step which ran before the checkpoint
+func (rc *RunContext) skipResumedStep(index int, executor common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		if rc.resumed(index) {
+			return nil
+		}
+		return executor(ctx)
+	}
+}
+
+// resumeStep replaces the main stage of a step which ran before the checkpoint, the results,
+// env, paths and state of the steps before the checkpoint are restored with the first one
+func (rc *RunContext) resumeStep(index int, stepModel *model.Step, executor common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		if !rc.resumed(index) {
+			return executor(ctx)
+		}
+		checkpoint := rc.checkpoint
+		if index == 0 {
+			if rc.GlobalEnv == nil {
+				rc.GlobalEnv = map[string]string{}
+			}
+			if rc.Env == nil {
+				rc.Env = map[string]string{}
+			}
+			for k, v := range checkpoint.Env {
+				rc.GlobalEnv[k] = v
+				rc.Env[k] = v
+			}
+			rc.ExtraPath = append(rc.ExtraPath, checkpoint.ExtraPath...)
+			rc.IntraActionState = checkpoint.IntraActionState
+		}
+		result, ok := checkpoint.StepResults[stepModel.ID]
+		if !ok {
+			result = &model.StepResult{
+				Outcome:    model.StepStatusSkipped,
+				Conclusion: model.StepStatusSkipped,
+			}
+		}
+		if result.Outputs == nil {
+			result.Outputs = map[string]string{}
+		}
+		rc.StepResults[stepModel.ID] = result
+		common.Logger(ctx).WithField("stepResult", result.Outcome).Infof("\u23E9  Resumed %s from the checkpoint", stepModel)
+		return nil
+	}
+}
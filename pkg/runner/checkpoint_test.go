@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+type checkpointContainerMock struct {
+	containerMock
+	images []string
+}
+
+func (cm *checkpointContainerMock) Commit(ctx context.Context, image string) (string, error) {
+	cm.images = append(cm.images, image)
+	return "sha256:" + strings.Repeat("1", len(cm.images)), nil
+}
+
+func newCheckpointRunContext(t *testing.T, workflow string) *RunContext {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	w, err := model.ReadWorkflow(strings.NewReader(workflow))
+	require.NoError(t, err)
+	rc := &RunContext{
+		Name:        "test",
+		Config:      &Config{Workdir: "/home/act/repo", Checkpoint: true},
+		Run:         &model.Run{JobID: "test", Workflow: w},
+		StepResults: map[string]*model.StepResult{},
+		Env:         map[string]string{},
+	}
+	rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+	return rc
+}
+
+const checkpointWorkflow = `
+name: ci
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    container: node:16
+    steps:
+      - id: install
+        run: npm ci
+      - id: test
+        run: npm test
+`
+
+func TestSaveCheckpoint(t *testing.T) {
+	rc := newCheckpointRunContext(t, checkpointWorkflow)
+	cm := &checkpointContainerMock{}
+	rc.JobContainer = cm
+	ctx := context.Background()
+
+	rc.StepResults["install"] = &model.StepResult{Outputs: map[string]string{"cache": "hit"}}
+	rc.GlobalEnv = map[string]string{"NODE_ENV": "test"}
+	rc.ExtraPath = []string{"/home/act/repo/node_modules/.bin"}
+	require.NoError(t, rc.saveCheckpoint(ctx, 0))
+	assert.Equal(t, []string{"act-checkpoint:" + rc.checkpointKey()[:20]}, cm.images)
+
+	checkpoint, err := rc.readCheckpoint()
+	require.NoError(t, err)
+	fingerprint, err := rc.checkpointFingerprint("node:16")
+	require.NoError(t, err)
+	assert.Equal(t, &jobCheckpoint{
+		Fingerprint:      fingerprint,
+		Image:            cm.images[0],
+		ImageID:          "sha256:1",
+		Steps:            1,
+		StepResults:      map[string]*model.StepResult{"install": {Outputs: map[string]string{"cache": "hit"}}},
+		Env:              map[string]string{"NODE_ENV": "test"},
+		ExtraPath:        []string{"/home/act/repo/node_modules/.bin"},
+		IntraActionState: nil,
+	}, checkpoint)
+
+	// a job which changed isn't resumed, its checkpoint is dropped
+	rc.Config.Resume = true
+	rc.checkpoint = nil
+	assert.Equal(t, "", rc.loadCheckpoint(ctx, "node:18"))
+	assert.Nil(t, rc.checkpoint)
+	_, err = os.Stat(rc.checkpointFile())
+	assert.True(t, os.IsNotExist(err))
+
+	// without --checkpoint nothing is saved
+	rc.Config.Checkpoint = false
+	require.NoError(t, rc.saveCheckpoint(ctx, 1))
+	assert.Len(t, cm.images, 1)
+}
+
+func TestCheckpointFingerprint(t *testing.T) {
+	rc := newCheckpointRunContext(t, checkpointWorkflow)
+	fingerprint, err := rc.checkpointFingerprint("node:16")
+	require.NoError(t, err)
+
+	other, err := rc.checkpointFingerprint("node:18")
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, other)
+
+	rc.Matrix = map[string]interface{}{"node": 18}
+	other, err = rc.checkpointFingerprint("node:16")
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, other)
+
+	rc = newCheckpointRunContext(t, strings.Replace(checkpointWorkflow, "npm ci", "npm install", 1))
+	other, err = rc.checkpointFingerprint("node:16")
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, other)
+}
+
+func TestResumeStep(t *testing.T) {
+	rc := newCheckpointRunContext(t, checkpointWorkflow)
+	rc.Config.Resume = true
+	rc.Env = map[string]string{"CI": "true"}
+	rc.checkpoint = &jobCheckpoint{
+		Steps:            1,
+		StepResults:      map[string]*model.StepResult{"install": {Outputs: map[string]string{"cache": "hit"}}},
+		Env:              map[string]string{"NODE_ENV": "test"},
+		ExtraPath:        []string{"/home/act/repo/node_modules/.bin"},
+		IntraActionState: map[string]map[string]string{"install": {"key": "value"}},
+	}
+	steps := rc.Run.Job().Steps
+	ran := []string{}
+	run := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, rc.skipResumedStep(0, run("pre install"))(ctx))
+	assert.NoError(t, rc.skipResumedStep(1, run("pre test"))(ctx))
+	assert.NoError(t, rc.resumeStep(0, steps[0], run("install"))(ctx))
+	assert.NoError(t, rc.resumeStep(1, steps[1], run("test"))(ctx))
+	assert.Equal(t, []string{"pre test", "test"}, ran)
+
+	assert.Equal(t, "hit", rc.StepResults["install"].Outputs["cache"])
+	assert.Equal(t, map[string]string{"CI": "true", "NODE_ENV": "test"}, rc.Env)
+	assert.Equal(t, map[string]string{"NODE_ENV": "test"}, rc.GlobalEnv)
+	assert.Equal(t, []string{"/home/act/repo/node_modules/.bin"}, rc.ExtraPath)
+	assert.Equal(t, "value", rc.IntraActionState["install"]["key"])
+}
@@ -2,9 +2,12 @@ package runner
 
 import (
 	"context"
+	"io"
 	"regexp"
 	"strings"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/nektos/act/pkg/common"
 )
 
@@ -48,17 +51,27 @@ func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 		kvPairs = unescapeKvPairs(kvPairs)
 		switch command {
 		case "set-env":
-			rc.setEnv(ctx, kvPairs, arg)
+			if rc.unsecureCommandsAllowed(ctx, command) {
+				rc.setEnv(ctx, kvPairs, arg)
+			}
 		case "set-output":
+			rc.deprecatedCommand(ctx, command)
 			rc.setOutput(ctx, kvPairs, arg)
 		case "add-path":
-			rc.addPath(ctx, arg)
+			if rc.unsecureCommandsAllowed(ctx, command) {
+				rc.addPath(ctx, arg)
+			}
 		case "debug":
 			logger.Infof("  \U0001F4AC  %s", line)
+		case "notice":
+			logger.Infof("  \U0001F4DD  %s", line)
+			rc.annotate(ctx, command, kvPairs, arg)
 		case "warning":
 			logger.Infof("  \U0001F6A7  %s", line)
+			rc.annotate(ctx, command, kvPairs, arg)
 		case "error":
 			logger.Infof("  \U00002757  %s", line)
+			rc.annotate(ctx, command, kvPairs, arg)
 		case "add-mask":
 			rc.AddMask(arg)
 			logger.Infof("  \U00002699  %s", "***")
@@ -69,8 +82,12 @@ func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 			resumeCommand = ""
 			logger.Infof("  \U00002699  %s", line)
 		case "save-state":
+			rc.deprecatedCommand(ctx, command)
 			logger.Infof("  \U0001f4be  %s", line)
 			rc.saveState(ctx, kvPairs, arg)
+		case "group", "endgroup":
+			// rendered by newLogWriter
+			return true
 		case "add-matcher":
 			logger.Infof("  \U00002753 add-matcher %s", arg)
 		default:
@@ -81,6 +98,61 @@ func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 	}
 }
 
+// newLogWriter returns the writer for the output of the containers, which runs the workflow commands
+// and logs the other lines. A ::group:: line is logged as the header of a section with the group
+// field, like the lines up to ::endgroup::, the formatters indent them below the header.
+func (rc *RunContext) newLogWriter(ctx context.Context) io.Writer {
+	rawLogger := common.Logger(ctx).WithField("raw_output", true)
+	logRaw := func(logger *logrus.Entry, s string) {
+		if rc.Config.LogOutput {
+			logger.Infof("%s", s)
+		} else {
+			logger.Debugf("%s", s)
+		}
+	}
+
+	group := ""
+	return common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
+		command, _, arg, ok := tryParseRawActionCommand(s)
+		switch {
+		case ok && command == "group":
+			group = unescapeCommandData(arg)
+			logRaw(rawLogger.WithFields(logrus.Fields{"group": group, "groupHeader": true}), group)
+			return false
+		case ok && command == "endgroup":
+			group = ""
+			return false
+		case group != "":
+			logRaw(rawLogger.WithField("group", group), s)
+		default:
+			logRaw(rawLogger, s)
+		}
+		return true
+	})
+}
+
+// unsecureCommandsAllowed reports if set-env and add-path can be used with the emulated runner version
+func (rc *RunContext) unsecureCommandsAllowed(ctx context.Context, command string) bool {
+	features := rc.runnerFeatures()
+	if features.unsecureCommands {
+		if features.deprecatedUnsecure {
+			rc.deprecated(ctx, "The `%s` command is disabled on GitHub. Please upgrade to using Environment Files or opt into unsecure command execution by setting the `ACTIONS_ALLOW_UNSECURE_COMMANDS` environment variable to `true`. For more information see: https://github.blog/changelog/2020-10-01-github-actions-deprecating-set-env-and-add-path-commands/", command)
+		}
+		return true
+	}
+	if rc.GetEnv()["ACTIONS_ALLOW_UNSECURE_COMMANDS"] == "true" {
+		return true
+	}
+	common.Logger(ctx).Errorf("  \U00002757  The `%s` command is disabled. Please upgrade to using Environment Files or opt into unsecure command execution by setting the `ACTIONS_ALLOW_UNSECURE_COMMANDS` environment variable to `true`. For more information see: https://github.blog/changelog/2020-10-01-github-actions-deprecating-set-env-and-add-path-commands/", command)
+	return false
+}
+
+func (rc *RunContext) deprecatedCommand(ctx context.Context, command string) {
+	if rc.runnerFeatures().deprecatedCommands {
+		rc.deprecated(ctx, "The `%s` command is deprecated and will be disabled soon. Please upgrade to using Environment Files. For more information see: https://github.blog/changelog/2022-10-11-github-actions-deprecating-save-state-and-set-output-commands/", command)
+	}
+}
+
 func (rc *RunContext) setEnv(ctx context.Context, kvPairs map[string]string, arg string) {
 	name := kvPairs["name"]
 	common.Logger(ctx).Infof("  \U00002699  ::set-env:: %s=%s", name, arg)
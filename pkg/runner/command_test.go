@@ -171,7 +171,9 @@ func TestAddmaskUsemask(t *testing.T) {
 		handler("::set-output:: token=secret\n")
 	})
 
-	a.Equal("[testjob]   \U00002699  ***\n[testjob]   \U00002699  ::set-output:: = token=***\n", re)
+	a.Equal("[testjob]   \U00002699  ***\n"+
+		"[testjob]   \U0001F6A7  The `set-output` command is deprecated and will be disabled soon. Please upgrade to using Environment Files. For more information see: https://github.blog/changelog/2022-10-11-github-actions-deprecating-save-state-and-set-output-commands/\n"+
+		"[testjob]   \U00002699  ::set-output:: = token=***\n", re)
 }
 
 func TestSaveState(t *testing.T) {
@@ -187,3 +189,39 @@ func TestSaveState(t *testing.T) {
 
 	assert.Equal(t, "state-value", rc.IntraActionState["step"]["state-name"])
 }
+
+func TestLogWriterGroups(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	ctx := common.WithLogger(context.Background(), logger)
+	rc := &RunContext{Config: &Config{LogOutput: true}}
+
+	w := rc.newLogWriter(ctx)
+	_, err := io.WriteString(w, "before\n::group::Install%25 dependencies\nnpm ci\nadded 42 packages\n::endgroup::\nafter\n")
+	assert.NoError(t, err)
+
+	type line struct {
+		message string
+		group   interface{}
+		header  interface{}
+	}
+	lines := []line{}
+	for _, entry := range hook.AllEntries() {
+		lines = append(lines, line{entry.Message, entry.Data["group"], entry.Data["groupHeader"]})
+	}
+	assert.Equal(t, []line{
+		{"before\n", nil, nil},
+		{"Install% dependencies", "Install% dependencies", true},
+		{"npm ci\n", "Install% dependencies", nil},
+		{"added 42 packages\n", "Install% dependencies", nil},
+		{"after\n", nil, nil},
+	}, lines)
+
+	hook.Reset()
+	w = rc.newLogWriter(ctx)
+	_, err = io.WriteString(w, "::stop-commands::token\n::group::literal\n::token::\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "  \U00002699  ::group::literal\n", hook.AllEntries()[1].Message, "groups aren't started while the commands are stopped")
+	for _, entry := range hook.AllEntries() {
+		assert.NotContains(t, entry.Data, "group")
+	}
+}
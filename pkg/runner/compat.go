@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// Compat modes run the workflows like the runners of other forges which reuse the format of the
+// workflows of GitHub Actions
+const (
+	CompatGitea   = "gitea"
+	CompatForgejo = "forgejo"
+)
+
+type compatMode struct {
+	// workflowDirs are the directories the forge reads the workflows from, in their order, the first
+	// which exists is used
+	workflowDirs []string
+	// actionsURL is the server the actions without a URL in uses: are cloned from
+	actionsURL string
+	// platforms are the default labels of the runners of the forge
+	platforms map[string]string
+	// env is set in the job containers in addition to the variables of GitHub
+	env map[string]string
+}
+
+var compatModes = map[string]compatMode{
+	CompatGitea: {
+		workflowDirs: []string{".gitea/workflows", ".github/workflows"},
+		actionsURL:   "https://github.com",
+		platforms: map[string]string{
+			"ubuntu-latest": "gitea/runner-images:ubuntu-latest",
+			"ubuntu-22.04":  "gitea/runner-images:ubuntu-22.04",
+			"ubuntu-20.04":  "gitea/runner-images:ubuntu-20.04",
+		},
+		env: map[string]string{"GITEA_ACTIONS": "true"},
+	},
+	CompatForgejo: {
+		workflowDirs: []string{".forgejo/workflows", ".gitea/workflows", ".github/workflows"},
+		actionsURL:   "https://code.forgejo.org",
+		platforms: map[string]string{
+			"docker": "node:20-bullseye",
+		},
+		// the runner of Forgejo is derived from the one of Gitea
+		env: map[string]string{"GITEA_ACTIONS": "true", "FORGEJO_ACTIONS": "true"},
+	},
+}
+
+func lookupCompatMode(name string) (*compatMode, error) {
+	mode, ok := compatModes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown compat mode '%s', expected %s or %s", name, CompatGitea, CompatForgejo)
+	}
+	return &mode, nil
+}
+
+// CompatWorkflowDirs returns the directories the forge of the compat mode reads the workflows from
+func CompatWorkflowDirs(name string) ([]string, error) {
+	mode, err := lookupCompatMode(name)
+	if err != nil {
+		return nil, err
+	}
+	return mode.workflowDirs, nil
+}
+
+// CompatPlatforms returns the default labels of the runners of the forge of the compat mode
+func CompatPlatforms(name string) (map[string]string, error) {
+	mode, err := lookupCompatMode(name)
+	if err != nil {
+		return nil, err
+	}
+	return mode.platforms, nil
+}
+
+// ParseCompatLabel parses a label of a runner of Gitea or Forgejo into the platform and its image,
+// e.g. ubuntu-latest:docker://node:20 or self-hosted:host, the jobs of host labels run on the host
+func ParseCompatLabel(label string) (string, string, bool) {
+	name, scheme, ok := strings.Cut(label, ":")
+	if !ok {
+		return "", "", false
+	}
+	switch {
+	case scheme == "host":
+		return name, "-self-hosted", true
+	case strings.HasPrefix(scheme, "docker://"):
+		return name, strings.TrimPrefix(scheme, "docker://"), true
+	}
+	return "", "", false
+}
+
+// compatContextAliases are the other names of the contexts in the workflows of the compat modes
+func (rc *RunContext) compatContextAliases() map[string]string {
+	if rc.Config.Compat == "" {
+		return nil
+	}
+	return map[string]string{"gitea": "github"}
+}
+
+func (rc *RunContext) withCompatEnv(env map[string]string) {
+	if mode, err := lookupCompatMode(rc.Config.Compat); err == nil {
+		for k, v := range mode.env {
+			env[k] = v
+		}
+	}
+}
+
+// actionSource sets the server the remote action is cloned from and returns the token to clone it
+// with. The actions without a URL in uses: are cloned from the default actions URL of the compat
+// mode, the token of the instance is only sent to the instance itself.
+func (rc *RunContext) actionSource(ghc *model.GithubContext, action *remoteAction) (string, error) {
+	actionsURL := rc.Config.DefaultActionsURL
+	if mode, err := lookupCompatMode(rc.Config.Compat); err == nil && actionsURL == "" {
+		actionsURL = mode.actionsURL
+	}
+	if action.absolute {
+		if rc.Config.Compat == "" {
+			return "", fmt.Errorf("the action '%s' has a URL, which is only supported with --compat %s or %s", action.URL, CompatGitea, CompatForgejo)
+		}
+		return sameServerToken(ghc, action.URL), nil
+	}
+	if actionsURL == "" {
+		var token string
+		action.URL, token = rc.cloneSource(ghc, action.Org, action.Repo)
+		return token, nil
+	}
+	action.URL = strings.TrimSuffix(actionsURL, "/")
+	return sameServerToken(ghc, action.URL), nil
+}
+
+// sameServerToken returns the token of the instance if serverURL is the instance
+func sameServerToken(ghc *model.GithubContext, serverURL string) string {
+	instance, err := url.Parse(ghc.ServerURL)
+	if err != nil {
+		return ""
+	}
+	server, err := url.Parse(serverURL)
+	if err != nil || !strings.EqualFold(server.Host, instance.Host) {
+		return ""
+	}
+	return ghc.Token
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestParseCompatLabel(t *testing.T) {
+	table := []struct {
+		label    string
+		platform string
+		image    string
+		ok       bool
+	}{
+		{"ubuntu-latest:docker://node:20-bullseye", "ubuntu-latest", "node:20-bullseye", true},
+		{"docker:docker://node:20", "docker", "node:20", true},
+		{"self-hosted:host", "self-hosted", "-self-hosted", true},
+		{"ubuntu-latest", "", "", false},
+		{"ubuntu-latest:lxc://debian:bookworm", "", "", false},
+	}
+	for _, tt := range table {
+		t.Run(tt.label, func(t *testing.T) {
+			platform, image, ok := ParseCompatLabel(tt.label)
+			assert.Equal(t, tt.platform, platform)
+			assert.Equal(t, tt.image, image)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestNewRemoteActionAbsolute(t *testing.T) {
+	action := newRemoteAction("https://code.forgejo.org/actions/setup-go/sub@v5")
+	if assert.NotNil(t, action) {
+		assert.Equal(t, "https://code.forgejo.org", action.URL)
+		assert.Equal(t, "actions", action.Org)
+		assert.Equal(t, "setup-go", action.Repo)
+		assert.Equal(t, "sub", action.Path)
+		assert.Equal(t, "v5", action.Ref)
+		assert.True(t, action.absolute)
+		assert.Equal(t, "https://code.forgejo.org/actions/setup-go", action.CloneURL())
+	}
+	assert.Nil(t, newRemoteAction("https://code.forgejo.org/actions/setup-go"))
+	assert.False(t, newRemoteAction("actions/setup-go@v5").absolute)
+}
+
+func TestActionSource(t *testing.T) {
+	ghc := &model.GithubContext{ServerURL: "https://git.example.com", Token: "instance-token"}
+	table := []struct {
+		name   string
+		config Config
+		uses   string
+		url    string
+		token  string
+	}{
+		{"github", Config{}, "actions/checkout@v4", "https://git.example.com", "instance-token"},
+		{"gitea", Config{Compat: CompatGitea}, "actions/checkout@v4", "https://github.com", ""},
+		{"forgejo", Config{Compat: CompatForgejo}, "actions/checkout@v4", "https://code.forgejo.org", ""},
+		{"default-actions-url", Config{Compat: CompatGitea, DefaultActionsURL: "https://git.example.com/"}, "actions/checkout@v4", "https://git.example.com", "instance-token"},
+		{"absolute", Config{Compat: CompatGitea}, "https://git.example.com/org/action@v1", "https://git.example.com", "instance-token"},
+		{"absolute-other-server", Config{Compat: CompatForgejo}, "https://gitea.com/org/action@v1", "https://gitea.com", ""},
+	}
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			rc := &RunContext{Config: &config}
+			action := newRemoteAction(tt.uses)
+			token, err := rc.actionSource(ghc, action)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.url, action.URL)
+			assert.Equal(t, tt.token, token)
+		})
+	}
+
+	rc := &RunContext{Config: &Config{}}
+	_, err := rc.actionSource(ghc, newRemoteAction("https://gitea.com/org/action@v1"))
+	assert.ErrorContains(t, err, "only supported with --compat")
+}
+
+func TestCompatEnv(t *testing.T) {
+	env := map[string]string{}
+	(&RunContext{Config: &Config{}}).withCompatEnv(env)
+	assert.Empty(t, env)
+
+	(&RunContext{Config: &Config{Compat: CompatForgejo}}).withCompatEnv(env)
+	assert.Equal(t, map[string]string{"GITEA_ACTIONS": "true", "FORGEJO_ACTIONS": "true"}, env)
+}
@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/nektos/act/pkg/common"
 	"github.com/nektos/act/pkg/container"
@@ -73,3 +74,8 @@ func (cm *containerMock) GetContainerArchive(ctx context.Context, srcPath string
 	}
 	return args.Get(0).(io.ReadCloser), err
 }
+
+func (cm *containerMock) IsHealthy(ctx context.Context) (time.Duration, error) {
+	args := cm.Called(ctx)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
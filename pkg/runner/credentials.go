@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// credentialsPath is the directory of the forwarded credentials in the containers
+const credentialsPath = "/run/act-credentials"
+
+// forwardedCredential is a file or socket of the host forwarded into the containers
+type forwardedCredential struct {
+	source string
+	target string
+}
+
+// forwardedCredentials returns the ssh agent socket, known_hosts, .gitconfig and
+// .git-credentials of the host which exist and are enabled by --forward-ssh-agent and --forward-git-config
+func (rc *RunContext) forwardedCredentials() map[string]forwardedCredential {
+	credentials := map[string]forwardedCredential{}
+	home, _ := os.UserHomeDir()
+	exists := func(path string) bool {
+		if path == "" {
+			return false
+		}
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	if rc.Config.ForwardSSHAgent {
+		if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+			if runtime.GOOS == "darwin" {
+				// Docker Desktop can't mount sockets of macOS, but forwards the agent of the host
+				socket = "/run/host-services/ssh-auth.sock"
+			}
+			credentials["ssh-agent"] = forwardedCredential{socket, credentialsPath + "/ssh-agent.sock"}
+		}
+		if knownHosts := filepath.Join(home, ".ssh", "known_hosts"); home != "" && exists(knownHosts) {
+			credentials["known_hosts"] = forwardedCredential{knownHosts, credentialsPath + "/known_hosts"}
+		}
+	}
+	if rc.Config.ForwardGitConfig {
+		if gitConfig := filepath.Join(home, ".gitconfig"); home != "" && exists(gitConfig) {
+			credentials["gitconfig"] = forwardedCredential{gitConfig, credentialsPath + "/gitconfig"}
+		}
+		if gitCredentials := filepath.Join(home, ".git-credentials"); home != "" && exists(gitCredentials) {
+			credentials["git-credentials"] = forwardedCredential{gitCredentials, credentialsPath + "/git-credentials"}
+		}
+	}
+	return credentials
+}
+
+// credentialBinds mounts the forwarded credentials read-only into the containers
+func (rc *RunContext) credentialBinds() []string {
+	credentials := rc.forwardedCredentials()
+	binds := make([]string, 0)
+	for _, name := range []string{"ssh-agent", "known_hosts", "gitconfig", "git-credentials"} {
+		if c, ok := credentials[name]; ok {
+			mode := ":ro"
+			if name == "ssh-agent" {
+				mode = ""
+			}
+			binds = append(binds, fmt.Sprintf("%s:%s%s", c.source, c.target, mode))
+		}
+	}
+	return binds
+}
+
+// credentialEnv points ssh and git to the forwarded credentials
+func (rc *RunContext) credentialEnv() []string {
+	credentials := rc.forwardedCredentials()
+	env := make([]string, 0)
+	if c, ok := credentials["ssh-agent"]; ok {
+		env = append(env, fmt.Sprintf("SSH_AUTH_SOCK=%s", c.target))
+	}
+	if c, ok := credentials["known_hosts"]; ok {
+		env = append(env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -o UserKnownHostsFile=%s", c.target))
+	}
+	if c, ok := credentials["gitconfig"]; ok {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_GLOBAL=%s", c.target))
+	}
+	if c, ok := credentials["git-credentials"]; ok {
+		// the credential helpers of the host, like osxkeychain, don't work in the containers
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=credential.helper",
+			fmt.Sprintf("GIT_CONFIG_VALUE_0=store --file=%s", c.target),
+		)
+	}
+	return env
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+// checkDaemonFeatures adapts the configuration to the docker daemon, or fails with a
+// remediation, before any job is started instead of failing inside a step
+func (runner *runnerImpl) checkDaemonFeatures(plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		if common.Dryrun(ctx) {
+			return nil
+		}
+		features, err := container.GetDaemonFeatures(ctx)
+		if err != nil {
+			// jobs running on the host don't need a docker daemon
+			log.Debugf("unable to detect the features of the docker daemon: %v", err)
+			return nil
+		}
+		log.Debugf("Docker %s (API %s) features: %+v", features.ServerVersion, features.APIVersion, features)
+
+		options := []string{runner.config.ContainerOptions}
+		for _, stage := range plan.Stages {
+			for _, run := range stage.Runs {
+				job := run.Job()
+				if job == nil {
+					continue
+				}
+				if c := job.Container(); c != nil {
+					options = append(options, c.Options)
+				}
+				for _, s := range job.Services {
+					if s != nil {
+						options = append(options, s.Options)
+					}
+				}
+			}
+		}
+
+		warnings, err := adaptToDaemonFeatures(runner.config, features, options)
+		for _, warning := range warnings {
+			log.Warn(warning)
+		}
+		return err
+	}
+}
+
+func adaptToDaemonFeatures(config *Config, features container.DaemonFeatures, options []string) ([]string, error) {
+	docker := fmt.Sprintf("Docker %s (API %s)", features.ServerVersion, features.APIVersion)
+	warnings := make([]string, 0)
+
+	if config.ContainerArchitecture != "" && !features.Platform {
+		return nil, fmt.Errorf("--container-architecture requires Docker 20.10 or later, but the daemon runs %s. Upgrade Docker or remove --container-architecture", docker)
+	}
+
+	if config.UseBuildKit && !features.BuildKit {
+		config.UseBuildKit = false
+		warnings = append(warnings, fmt.Sprintf("%s doesn't support BuildKit, docker actions are built with the legacy builder. Upgrade to Docker 18.09 or later, or use --no-buildkit to hide this warning", docker))
+	}
+
+	flags := map[string]string{}
+	for _, option := range options {
+		args, err := shellquote.Split(option)
+		if err != nil {
+			// invalid options are reported when the container is created
+			continue
+		}
+		for i, arg := range args {
+			name, value, hasValue := strings.Cut(arg, "=")
+			if !strings.HasPrefix(name, "--") {
+				continue
+			}
+			if !hasValue && i+1 < len(args) {
+				value = args[i+1]
+			}
+			flags[name] = flags[name] + " " + value
+		}
+	}
+
+	if strings.Contains(flags["--add-host"], "host-gateway") && !features.HostGateway {
+		warnings = append(warnings, fmt.Sprintf("%s doesn't support host-gateway in --add-host. Upgrade to Docker 20.10 or later, or use the ip address of the host", docker))
+	}
+	if _, ok := flags["--cgroupns"]; ok && !features.CgroupNamespaces {
+		warnings = append(warnings, fmt.Sprintf("%s ignores --cgroupns. Upgrade to Docker 20.10 or later to select the cgroup namespace", docker))
+	}
+	if _, ok := flags["--kernel-memory"]; ok && features.CgroupV2 {
+		warnings = append(warnings, fmt.Sprintf("%s uses cgroup v2, which doesn't support --kernel-memory. Remove it from the container options", docker))
+	}
+	return warnings, nil
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/container"
+)
+
+func TestAdaptToDaemonFeatures(t *testing.T) {
+	modern := container.DaemonFeatures{
+		APIVersion:       "1.43",
+		ServerVersion:    "24.0.2",
+		BuildKit:         true,
+		Platform:         true,
+		HostGateway:      true,
+		CgroupNamespaces: true,
+	}
+	legacy := container.DaemonFeatures{
+		APIVersion:    "1.38",
+		ServerVersion: "18.06.3",
+		CgroupV2:      true,
+	}
+	options := []string{"--add-host=host.docker.internal:host-gateway", "--cgroupns private --kernel-memory 64m", "--cpus '"}
+
+	config := &Config{UseBuildKit: true}
+	warnings, err := adaptToDaemonFeatures(config, modern, options)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.True(t, config.UseBuildKit)
+
+	warnings, err = adaptToDaemonFeatures(config, legacy, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"Docker 18.06.3 (API 1.38) doesn't support BuildKit, docker actions are built with the legacy builder. Upgrade to Docker 18.09 or later, or use --no-buildkit to hide this warning",
+		"Docker 18.06.3 (API 1.38) doesn't support host-gateway in --add-host. Upgrade to Docker 20.10 or later, or use the ip address of the host",
+		"Docker 18.06.3 (API 1.38) ignores --cgroupns. Upgrade to Docker 20.10 or later to select the cgroup namespace",
+		"Docker 18.06.3 (API 1.38) uses cgroup v2, which doesn't support --kernel-memory. Remove it from the container options",
+	}, warnings)
+	assert.False(t, config.UseBuildKit)
+
+	_, err = adaptToDaemonFeatures(&Config{ContainerArchitecture: "linux/arm64"}, legacy, nil)
+	assert.EqualError(t, err, "--container-architecture requires Docker 20.10 or later, but the daemon runs Docker 18.06.3 (API 1.38). Upgrade Docker or remove --container-architecture")
+}
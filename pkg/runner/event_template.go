@@ -0,0 +1,243 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+)
+
+// zeroSha is the sha of the before of the push of a new ref
+const zeroSha = "0000000000000000000000000000000000000000"
+
+// eventTemplate is the local repository and the field overrides a payload template is built from
+type eventTemplate struct {
+	repository map[string]interface{}
+	actor      string
+	repoURL    string
+	branch     string // the current branch, without refs/heads/
+	sha        string
+	fields     map[string]string
+	inputs     map[string]string
+}
+
+// user returns the user of the actor, a new object for each user field so that the overrides of
+// one don't change the others
+func (t *eventTemplate) user() map[string]interface{} {
+	return map[string]interface{}{"login": t.actor}
+}
+
+// field returns the override of the field at path, or def
+func (t *eventTemplate) field(path string, def string) string {
+	if value, ok := t.fields[path]; ok {
+		return value
+	}
+	return def
+}
+
+// eventTemplates builds the payloads of the events whose template act knows, the others start
+// from the repository and the sender only
+var eventTemplates = map[string]func(t *eventTemplate) map[string]interface{}{
+	"push": func(t *eventTemplate) map[string]interface{} {
+		ref := t.field("ref", "refs/heads/"+t.branch)
+		event := map[string]interface{}{
+			"ref":         ref,
+			"before":      zeroSha,
+			"after":       t.sha,
+			"created":     false,
+			"deleted":     false,
+			"forced":      false,
+			"base_ref":    nil,
+			"commits":     []interface{}{},
+			"head_commit": map[string]interface{}{"id": t.sha},
+			"pusher":      map[string]interface{}{"name": t.actor},
+		}
+		// the push of a tag creates it, with the branch it was pushed from as base
+		if strings.HasPrefix(ref, "refs/tags/") {
+			event["created"] = true
+			event["base_ref"] = "refs/heads/" + t.branch
+		}
+		return event
+	},
+	"delete": func(t *eventTemplate) map[string]interface{} {
+		return map[string]interface{}{
+			"ref":         t.branch,
+			"ref_type":    "branch",
+			"pusher_type": "user",
+		}
+	},
+	"release": func(t *eventTemplate) map[string]interface{} {
+		tag := t.field("release.tag_name", "v1.0.0")
+		return map[string]interface{}{
+			"action": "published",
+			"release": map[string]interface{}{
+				"id":               1,
+				"tag_name":         tag,
+				"name":             tag,
+				"target_commitish": t.branch,
+				"draft":            false,
+				"prerelease":       false,
+				"body":             "",
+				"author":           t.user(),
+				"html_url":         fmt.Sprintf("%s/releases/tag/%s", t.repoURL, tag),
+				"created_at":       time.Now().UTC().Format(time.RFC3339),
+				"published_at":     time.Now().UTC().Format(time.RFC3339),
+				"assets":           []interface{}{},
+			},
+		}
+	},
+	"issue_comment": func(t *eventTemplate) map[string]interface{} {
+		return map[string]interface{}{
+			"action": "created",
+			"issue": map[string]interface{}{
+				"number":   1,
+				"title":    "act",
+				"state":    "open",
+				"user":     t.user(),
+				"labels":   []interface{}{},
+				"html_url": t.repoURL + "/issues/1",
+			},
+			"comment": map[string]interface{}{
+				"id":         1,
+				"body":       "",
+				"user":       t.user(),
+				"html_url":   t.repoURL + "/issues/1#issuecomment-1",
+				"created_at": time.Now().UTC().Format(time.RFC3339),
+			},
+		}
+	},
+	"deployment": func(t *eventTemplate) map[string]interface{} {
+		return map[string]interface{}{
+			"action": "created",
+			"deployment": map[string]interface{}{
+				"id":          1,
+				"sha":         t.sha,
+				"ref":         t.branch,
+				"task":        "deploy",
+				"environment": "production",
+				"payload":     map[string]interface{}{},
+				"description": nil,
+				"creator":     t.user(),
+				"created_at":  time.Now().UTC().Format(time.RFC3339),
+			},
+		}
+	},
+	"workflow_call": func(t *eventTemplate) map[string]interface{} {
+		inputs := map[string]interface{}{}
+		for k, v := range t.inputs {
+			inputs[k] = v
+		}
+		return map[string]interface{}{
+			"inputs": inputs,
+		}
+	},
+}
+
+// buildEvent returns the payload act builds for the event without EventPath, with the fields of
+// EventFields, or nil if the config asks for none
+func buildEvent(ctx context.Context, config *Config) (map[string]interface{}, error) {
+	var event map[string]interface{}
+	var err error
+	switch {
+	case config.PullRequest != nil && strings.HasPrefix(config.EventName, "pull_request"):
+		event = pullRequestEvent(ctx, config)
+	case config.PushRange != "" && config.EventName == "push":
+		event, err = pushEvent(ctx, config)
+	case config.EventFields != nil:
+		event = templateEvent(ctx, config, config.EventName)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := setEventFields(event, config.EventFields); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// templateEvent returns the payload of the template of the event
+func templateEvent(ctx context.Context, config *Config, eventName string) map[string]interface{} {
+	logger := common.Logger(ctx)
+	ref, err := git.FindGitRef(ctx, config.Workdir)
+	if err != nil {
+		logger.Warnf("unable to find the current branch for the payload of %s: %v", eventName, err)
+	}
+	_, sha, err := git.FindGitRevision(ctx, config.Workdir)
+	if err != nil {
+		logger.Warnf("unable to find the revision for the payload of %s: %v", eventName, err)
+	}
+	repoName := config.Env["GITHUB_REPOSITORY"]
+	if repoName == "" {
+		if repoName, err = git.FindGithubRepo(ctx, config.Workdir, githubInstanceHost(config.GitHubInstance), config.RemoteName); err != nil {
+			logger.Debugf("unable to find the repository for the payload of %s: %v", eventName, err)
+		}
+	}
+	owner, name, _ := strings.Cut(repoName, "/")
+	defaultBranch := config.DefaultBranch
+	if defaultBranch == "" {
+		defaultBranch = "master"
+	}
+	actor := config.Actor
+	if actor == "" {
+		actor = "nektos/act"
+	}
+
+	t := &eventTemplate{
+		repoURL: fmt.Sprintf("%s/%s", GitHubInstanceURL(config.GitHubInstance), repoName),
+		actor:   actor,
+		branch:  strings.TrimPrefix(ref, "refs/heads/"),
+		sha:     sha,
+		fields:  config.EventFields,
+		inputs:  config.Inputs,
+	}
+	t.repository = map[string]interface{}{
+		"full_name":      repoName,
+		"name":           name,
+		"owner":          map[string]interface{}{"login": owner},
+		"default_branch": defaultBranch,
+		"html_url":       t.repoURL,
+	}
+
+	event := map[string]interface{}{}
+	if build, ok := eventTemplates[eventName]; ok {
+		event = build(t)
+	} else {
+		logger.Debugf("no payload template for %s, using the repository and the sender only", eventName)
+	}
+	event["repository"] = t.repository
+	event["sender"] = t.user()
+	return event
+}
+
+// setEventFields sets the fields of the payload at their dotted paths, e.g. release.prerelease,
+// creating the objects on the way. The values which are JSON, like 5, true or ["bug"], are set as
+// such, the others as strings.
+func setEventFields(event map[string]interface{}, fields map[string]string) error {
+	for path, raw := range fields {
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			value = raw
+		}
+		keys := strings.Split(path, ".")
+		m := event
+		for i, key := range keys[:len(keys)-1] {
+			switch next := m[key].(type) {
+			case map[string]interface{}:
+				m = next
+			case nil:
+				m[key] = map[string]interface{}{}
+				m = m[key].(map[string]interface{})
+			default:
+				return fmt.Errorf("unable to set the event field %s, %s isn't an object", path, strings.Join(keys[:i+1], "."))
+			}
+		}
+		m[keys[len(keys)-1]] = value
+	}
+	return nil
+}
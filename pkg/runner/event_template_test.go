@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildEvent(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	hash, err := wt.Commit("first", &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            &object.Signature{Name: "act", Email: "act@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	sha := hash.String()
+
+	build := func(eventName string, fields map[string]string) map[string]interface{} {
+		event, err := buildEvent(context.Background(), &Config{
+			Workdir:     dir,
+			EventName:   eventName,
+			Actor:       "octocat",
+			Env:         map[string]string{"GITHUB_REPOSITORY": "nektos/act"},
+			EventFields: fields,
+		})
+		require.NoError(t, err)
+		return event
+	}
+
+	event, err := buildEvent(context.Background(), &Config{Workdir: dir, EventName: "release"})
+	require.NoError(t, err)
+	assert.Nil(t, event, "no payload without EventFields")
+
+	event = build("release", map[string]string{"release.tag_name": "v2.0.0", "release.prerelease": "true"})
+	assert.Equal(t, "published", event["action"])
+	release := event["release"].(map[string]interface{})
+	assert.Equal(t, "v2.0.0", release["tag_name"])
+	assert.Equal(t, "v2.0.0", release["name"])
+	assert.Equal(t, true, release["prerelease"])
+	assert.Equal(t, "nektos/act", event["repository"].(map[string]interface{})["full_name"])
+
+	event = build("push", map[string]string{"ref": "refs/tags/v1"})
+	assert.Equal(t, "refs/tags/v1", event["ref"])
+	assert.Equal(t, true, event["created"])
+	assert.Equal(t, "refs/heads/master", event["base_ref"])
+	assert.Equal(t, sha, event["after"])
+
+	event = build("deployment", map[string]string{"deployment.environment": "staging"})
+	deployment := event["deployment"].(map[string]interface{})
+	assert.Equal(t, "staging", deployment["environment"])
+	assert.Equal(t, "master", deployment["ref"])
+	assert.Equal(t, sha, deployment["sha"])
+
+	// the user fields are separate objects
+	event = build("issue_comment", map[string]string{"comment.user.login": "mona", "issue.number": "7"})
+	assert.Equal(t, "mona", event["comment"].(map[string]interface{})["user"].(map[string]interface{})["login"])
+	assert.Equal(t, "octocat", event["sender"].(map[string]interface{})["login"])
+	assert.Equal(t, float64(7), event["issue"].(map[string]interface{})["number"])
+
+	event = build("delete", map[string]string{})
+	assert.Equal(t, "branch", event["ref_type"])
+
+	event = build("watch", map[string]string{"action": "started", "a.b": "c"})
+	assert.Equal(t, "started", event["action"])
+	assert.Equal(t, map[string]interface{}{"b": "c"}, event["a"])
+
+	_, err = buildEvent(context.Background(), &Config{Workdir: dir, EventName: "release", EventFields: map[string]string{"action.name": "x"}})
+	assert.ErrorContains(t, err, "action isn't an object")
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+// NewExecExecutor prepares the container, env, workspace and services of a job like a run of
+// the job does, then runs the command attached to the terminal in the job container instead of
+// the steps. A shell is run if the command is empty.
+func (runner *runnerImpl) NewExecExecutor(run *model.Run, command []string) common.Executor {
+	return func(ctx context.Context) error {
+		job := run.Job()
+		if job == nil {
+			return fmt.Errorf("job %s not found", run.JobID)
+		}
+		if job.Type() != model.JobTypeDefault {
+			return fmt.Errorf("job %s calls a reusable workflow, it has no job container", run.JobID)
+		}
+
+		matrixes, err := runner.evaluateMatrixes(ctx, run)
+		if err != nil {
+			return err
+		}
+		legs := runner.matrixLegs(ctx, run, matrixes)
+		if len(legs) == 0 {
+			return fmt.Errorf("no matrix combination of job %s matches --matrix", run.JobID)
+		}
+
+		rc := legs[0]
+		ctx = WithJobLogger(ctx, rc.Run.JobID, rc.String(), rc.Config, &rc.Masks, rc.Matrix)
+		if len(legs) > 1 {
+			common.Logger(ctx).Infof("Using the matrix %v, select another one with --matrix", rc.Matrix)
+		}
+
+		if len(command) == 0 {
+			command = DebugShell
+		}
+
+		return common.NewPipelineExecutor(
+			func(ctx context.Context) error {
+				rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+				rc.evaluateEnv(ctx)
+				return nil
+			},
+			rc.startContainer(),
+			func(ctx context.Context) error {
+				interactive, ok := rc.JobContainer.(container.InteractiveContainer)
+				if !ok {
+					return fmt.Errorf("the environment of job %s can't run interactive commands", run.JobID)
+				}
+				return interactive.ExecInteractive(command, rc.execEnv(ctx), "", "")(ctx)
+			},
+		).Finally(rc.stopContainer()).Finally(rc.closeContainer())(ctx)
+	}
+}
+
+// execEnv returns the environment the steps of the job start with
+func (rc *RunContext) execEnv(ctx context.Context) map[string]string {
+	env := map[string]string{}
+	for k, v := range rc.GetEnv() {
+		env[k] = v
+	}
+	if c := rc.Run.Job().Container(); c != nil {
+		for k, v := range c.Env {
+			env[k] = rc.ExprEval.Interpolate(ctx, v)
+		}
+	}
+	rc.withGithubEnv(ctx, rc.getGithubContext(ctx), env)
+	rc.ApplyExtraPath(ctx, &env)
+	return env
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunContextExecEnv(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: exec
+on: push
+env:
+  WORKFLOW_ENV: workflow
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    env:
+      JOB_ENV: job
+    container:
+      image: node:16
+      env:
+        CONTAINER_ENV: ${{ github.job }}-container
+    steps:
+      - run: exit 0
+`))
+	require.NoError(t, err)
+
+	rc := &RunContext{
+		Config: &Config{Workdir: t.TempDir()},
+		Run:    &model.Run{JobID: "build", Workflow: workflow},
+	}
+	ctx := context.Background()
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+
+	env := rc.execEnv(ctx)
+	assert.Equal(t, "workflow", env["WORKFLOW_ENV"])
+	assert.Equal(t, "job", env["JOB_ENV"])
+	assert.Equal(t, "build-container", env["CONTAINER_ENV"])
+	assert.Equal(t, "true", env["GITHUB_ACTIONS"])
+	assert.Equal(t, "build", env["GITHUB_JOB"])
+}
+
+func TestNewExecExecutorReusableWorkflow(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  call:
+    uses: ./.github/workflows/reusable.yml
+`))
+	require.NoError(t, err)
+
+	r, err := New(&Config{Workdir: t.TempDir()})
+	require.NoError(t, err)
+	err = r.NewExecExecutor(&model.Run{JobID: "call", Workflow: workflow}, nil)(context.Background())
+	assert.EqualError(t, err, "job call calls a reusable workflow, it has no job container")
+}
@@ -0,0 +1,245 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// ExportFile is a job of the plan exported as a standalone script or compose file
+type ExportFile struct {
+	Name    string // file name of the export
+	Content string
+}
+
+var (
+	secretPlaceholderPattern = regexp.MustCompile("\x00([A-Z0-9_]+)\x00")
+	scriptNamePattern        = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// secretPlaceholder stands in for the value of a secret while the plan is evaluated, the exported
+// scripts read the secret from their environment instead
+func secretPlaceholder(name string) string {
+	return "\x00" + strings.ToUpper(name) + "\x00"
+}
+
+// NewShellExport evaluates the plan like NewPlanReport and exports the jobs which run as bash
+// scripts with the env and the commands of their run steps and docker:// steps. The secrets are
+// read from the environment of the scripts, the other actions can't be exported.
+func (runner *runnerImpl) NewShellExport(ctx context.Context, plan *model.Plan) ([]*ExportFile, error) {
+	scripts := []*ExportFile{}
+	err := runner.exporter(plan).walkPlan(ctx, plan, func(stage int, rc *RunContext, job *JobReport) {
+		if exportable(ctx, job) {
+			scripts = append(scripts, exportJob(job))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scripts, nil
+}
+
+// exporter returns a runner evaluating the plan with placeholders for the values of the secrets
+func (runner *runnerImpl) exporter(plan *model.Plan) *runnerImpl {
+	config := *runner.config
+	config.InsecureSecrets = true
+	config.Secrets = map[string]string{}
+	for name := range runner.config.Secrets {
+		config.Secrets[strings.ToUpper(name)] = secretPlaceholder(name)
+	}
+	for _, name := range plan.Secrets() {
+		config.Secrets[name] = secretPlaceholder(name)
+	}
+	return &runnerImpl{
+		config:    &config,
+		eventJSON: runner.eventJSON,
+		caller:    runner.caller,
+	}
+}
+
+// exportable reports whether the job runs and can be exported
+func exportable(ctx context.Context, job *JobReport) bool {
+	logger := common.Logger(ctx)
+	switch {
+	case !job.Enabled:
+		logger.Infof("Not exporting the job %s: %s", job.Name, job.Reason)
+	case job.Uses != "":
+		logger.Warnf("Unable to export the job %s, which calls the reusable workflow %s", job.Name, job.Uses)
+	default:
+		return true
+	}
+	return false
+}
+
+// exportName returns the name of the files of an exported job, e.g. ci-build for the job build of ci.yml
+func exportName(job *JobReport) string {
+	name := strings.TrimSuffix(path.Base(job.WorkflowFile), path.Ext(job.WorkflowFile))
+	return strings.Trim(scriptNamePattern.ReplaceAllString(name+"-"+job.Name, "-"), "-")
+}
+
+func appendValues(values []string, m map[string]string) []string {
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// shellValue quotes a value for bash, the secrets in it expand to the env vars of the same name
+func shellValue(value string) string {
+	var quoted strings.Builder
+	last := 0
+	for _, match := range secretPlaceholderPattern.FindAllStringSubmatchIndex(value, -1) {
+		if match[0] > last {
+			quoted.WriteString(singleQuote(value[last:match[0]]))
+		}
+		fmt.Fprintf(&quoted, `"${%s}"`, value[match[2]:match[3]])
+		last = match[1]
+	}
+	if last < len(value) || last == 0 {
+		quoted.WriteString(singleQuote(value[last:]))
+	}
+	return quoted.String()
+}
+
+// comment keeps a value on the line of a comment
+func comment(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func singleQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// usedSecrets returns the names of the secrets in the values
+func usedSecrets(values ...string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, match := range secretPlaceholderPattern.FindAllStringSubmatch(value, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+func writeExports(w *strings.Builder, indent string, env map[string]string) {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%sexport %s=%s\n", indent, k, shellValue(env[k]))
+	}
+}
+
+func exportJob(job *JobReport) *ExportFile {
+	values := appendValues(nil, job.Env)
+	for _, step := range job.Steps {
+		if !step.Enabled {
+			continue
+		}
+		values = append(values, step.Run, step.WorkingDirectory)
+		values = appendValues(values, step.Env)
+		values = appendValues(values, step.With)
+	}
+	secrets := usedSecrets(values...)
+	sort.Strings(secrets)
+
+	w := &strings.Builder{}
+	fmt.Fprintf(w, "#!/usr/bin/env bash\n")
+	fmt.Fprintf(w, "# The job %s of %s, exported by act. Run it from the root of the repository.\n", job.Name, job.WorkflowFile)
+	if job.Image != "" && job.Image != "host" {
+		fmt.Fprintf(w, "# act runs the job in the image %s.\n", job.Image)
+	}
+	if len(secrets) > 0 {
+		fmt.Fprintf(w, "# The secrets are read from the environment: %s\n", strings.Join(secrets, ", "))
+	}
+	fmt.Fprintf(w, "set -e\n\n")
+	for _, secret := range secrets {
+		fmt.Fprintf(w, ": \"${%s:?the secret %s isn't set}\"\n", secret, secret)
+	}
+	fmt.Fprintf(w, "export CI=true\n")
+	fmt.Fprintf(w, "export GITHUB_WORKSPACE=\"${GITHUB_WORKSPACE:-$PWD}\"\n")
+	writeExports(w, "", job.Env)
+	fmt.Fprintf(w, "ACT_SCRIPTS=\"$(mktemp -d)\"\n")
+	fmt.Fprintf(w, "trap 'rm -rf \"$ACT_SCRIPTS\"' EXIT\n")
+
+	for i, step := range job.Steps {
+		fmt.Fprintf(w, "\n# %s\n", comment(step.Name))
+		if !step.Enabled {
+			fmt.Fprintf(w, "# skipped: %s\n", comment(step.Reason))
+			continue
+		}
+		if step.If != "" && step.If != "success()" {
+			fmt.Fprintf(w, "# if: %s\n", comment(step.If))
+		}
+		switch {
+		case step.Run != "":
+			exportRunStep(w, i, step)
+		case strings.HasPrefix(step.Uses, "docker://"):
+			exportDockerStep(w, step)
+		default:
+			fmt.Fprintf(w, "# the action %s can't be exported, run its equivalent here\n", step.Uses)
+			fmt.Fprintf(w, "echo %s >&2\n", singleQuote(fmt.Sprintf("Skipping the action %s", step.Uses)))
+		}
+	}
+	return &ExportFile{Name: exportName(job) + ".sh", Content: w.String()}
+}
+
+func exportRunStep(w *strings.Builder, index int, step *StepReport) {
+	stepModel := &model.Step{Shell: step.Shell}
+	shellCommand := stepModel.ShellCommand()
+	ext, before, after := scriptWrapper(scriptShell(shellCommand))
+	script := fmt.Sprintf("\"$ACT_SCRIPTS\"/%d%s", index, ext)
+
+	fmt.Fprintf(w, "(\n")
+	writeExports(w, "  ", step.Env)
+	if step.WorkingDirectory != "" {
+		fmt.Fprintf(w, "  cd %s\n", shellValue(step.WorkingDirectory))
+	}
+	fmt.Fprintf(w, "  printf '%%s\\n' %s > %s\n", shellValue(fmt.Sprintf("%s\n%s\n%s", before, step.Run, after)), script)
+	if strings.Contains(shellCommand, "{0}") {
+		fmt.Fprintf(w, "  %s\n", strings.NewReplacer("'{0}'", script, "{0}", script).Replace(shellCommand))
+	} else {
+		fmt.Fprintf(w, "  %s %s\n", shellCommand, script)
+	}
+	fmt.Fprintf(w, ")\n")
+}
+
+func exportDockerStep(w *strings.Builder, step *StepReport) {
+	args := []string{"docker", "run", "--rm", "-v", `"$GITHUB_WORKSPACE":/github/workspace`, "-w", "/github/workspace"}
+	keys := make([]string, 0, len(step.Env))
+	for k := range step.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-e", k)
+	}
+	if entrypoint := step.With["entrypoint"]; entrypoint != "" {
+		args = append(args, "--entrypoint", shellValue(entrypoint))
+	}
+	args = append(args, shellValue(strings.TrimPrefix(step.Uses, "docker://")))
+	if cmd, err := shellquote.Split(step.With["args"]); err == nil {
+		for _, arg := range cmd {
+			args = append(args, shellValue(arg))
+		}
+	}
+
+	fmt.Fprintf(w, "(\n")
+	writeExports(w, "  ", step.Env)
+	fmt.Fprintf(w, "  %s\n", strings.Join(args, " "))
+	fmt.Fprintf(w, ")\n")
+}
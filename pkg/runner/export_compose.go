@@ -0,0 +1,326 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+// composeJobService is the service of the job container in the exported compose files
+const composeJobService = "job"
+
+type composeFile struct {
+	Name     string                     `yaml:"name"`
+	Services map[string]*composeService `yaml:"services"`
+	Networks map[string]*composeNetwork `yaml:"networks,omitempty"`
+	Volumes  map[string]*composeVolume  `yaml:"volumes,omitempty"`
+
+	secrets map[string]bool // the secrets read from the environment
+}
+
+type composeService struct {
+	Image       string                       `yaml:"image"`
+	Platform    string                       `yaml:"platform,omitempty"`
+	Entrypoint  []string                     `yaml:"entrypoint,omitempty"`
+	WorkingDir  string                       `yaml:"working_dir,omitempty"`
+	Environment map[string]string            `yaml:"environment,omitempty"`
+	Volumes     []string                     `yaml:"volumes,omitempty"`
+	Ports       []string                     `yaml:"ports,omitempty"`
+	Privileged  bool                         `yaml:"privileged,omitempty"`
+	UsernsMode  string                       `yaml:"userns_mode,omitempty"`
+	CapAdd      []string                     `yaml:"cap_add,omitempty"`
+	CapDrop     []string                     `yaml:"cap_drop,omitempty"`
+	NetworkMode string                       `yaml:"network_mode,omitempty"`
+	Healthcheck *composeHealthcheck          `yaml:"healthcheck,omitempty"`
+	DependsOn   map[string]composeDependency `yaml:"depends_on,omitempty"`
+}
+
+type composeHealthcheck struct {
+	Test        []string `yaml:"test,omitempty"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+}
+
+type composeDependency struct {
+	Condition string `yaml:"condition"`
+}
+
+type composeNetwork struct {
+	Name     string `yaml:"name"`
+	Internal bool   `yaml:"internal,omitempty"`
+	External bool   `yaml:"external,omitempty"`
+}
+
+type composeVolume struct {
+	Name string `yaml:"name"`
+}
+
+// NewComposeExport evaluates the plan like NewPlanReport and exports the jobs which run as
+// docker compose files with the job container and the service containers of the job, with
+// their images, env, volumes and network like act creates them. The job container idles
+// like with act, so the steps can be run in it with docker compose exec. The secrets are
+// read from the environment when the compose file is used.
+func (runner *runnerImpl) NewComposeExport(ctx context.Context, plan *model.Plan) ([]*ExportFile, error) {
+	exporter := runner.exporter(plan)
+	// act copies the workspace into a volume of the job container, the compose file binds it
+	exporter.config.BindWorkdir = true
+
+	files := []*ExportFile{}
+	var composeErr error
+	err := exporter.walkPlan(ctx, plan, func(stage int, rc *RunContext, job *JobReport) {
+		if composeErr != nil || !exportable(ctx, job) {
+			return
+		}
+		if job.Image == "host" {
+			common.Logger(ctx).Warnf("Unable to export the job %s, which runs on the host", job.Name)
+			return
+		}
+		var file *ExportFile
+		file, composeErr = rc.exportCompose(ctx, job)
+		if composeErr == nil {
+			files = append(files, file)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if composeErr != nil {
+		return nil, composeErr
+	}
+	return files, nil
+}
+
+func (rc *RunContext) exportCompose(ctx context.Context, job *JobReport) (*ExportFile, error) {
+	name := exportName(job)
+	project := &composeFile{
+		Name:     strings.Trim(scriptNamePattern.ReplaceAllString(strings.ToLower("act-"+name), "-"), "-."),
+		Services: map[string]*composeService{},
+		secrets:  map[string]bool{},
+	}
+	unsupported := map[string][]string{}
+
+	ext := container.LinuxContainerEnvironmentExtensions{}
+	env := map[string]string{}
+	for _, kv := range rc.jobContainerEnv(ctx) {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+	for k, v := range job.Env {
+		env[k] = v
+	}
+	env["CI"] = "true"
+	env["GITHUB_WORKSPACE"] = ext.ToContainerPath(rc.Config.Workdir)
+
+	binds, mounts := rc.GetBindsAndMounts()
+	jobService := &composeService{
+		Image:       project.value(job.Image),
+		Platform:    rc.Config.ContainerArchitecture,
+		Entrypoint:  []string{"tail", "-f", "/dev/null"},
+		WorkingDir:  ext.ToContainerPath(rc.Config.Workdir),
+		Environment: project.values(env),
+		Volumes:     project.volumes(binds, mounts),
+		Privileged:  rc.Config.Privileged,
+		UsernsMode:  rc.Config.UsernsMode,
+		CapAdd:      rc.Config.ContainerCapAdd,
+		CapDrop:     rc.Config.ContainerCapDrop,
+	}
+	if c := rc.Run.Job().Container(); c != nil {
+		jobService.Ports = project.list(interpolateList(ctx, rc.ExprEval, c.Ports))
+	}
+
+	jobServiceName := composeJobService
+	ids := make([]string, 0, len(rc.Run.Job().Services))
+	for id, spec := range rc.Run.Job().Services {
+		if spec != nil {
+			ids = append(ids, id)
+		}
+		if id == jobServiceName {
+			jobServiceName = "act-" + composeJobService
+		}
+	}
+	sort.Strings(ids)
+	if err := project.options(jobService, rc.options(ctx), unsupported, jobServiceName); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		spec := rc.Run.Job().Services[id]
+		serviceEnv := map[string]string{}
+		for k, v := range spec.Env {
+			serviceEnv[k] = rc.ExprEval.Interpolate(ctx, v)
+		}
+		for _, kv := range rc.containerProxyEnv() {
+			k, v, _ := strings.Cut(kv, "=")
+			serviceEnv[k] = v
+		}
+		serviceBinds, serviceMounts := containerSpecVolumes(interpolateList(ctx, rc.ExprEval, spec.Volumes))
+		service := &composeService{
+			Image:       project.value(rc.ExprEval.Interpolate(ctx, spec.Image)),
+			Platform:    rc.Config.ContainerArchitecture,
+			Environment: project.values(serviceEnv),
+			Volumes:     project.volumes(serviceBinds, serviceMounts),
+			Ports:       project.list(interpolateList(ctx, rc.ExprEval, spec.Ports)),
+			Privileged:  rc.Config.Privileged,
+			UsernsMode:  rc.Config.UsernsMode,
+		}
+		if err := project.options(service, rc.containerSpecOptions(ctx, spec.Options), unsupported, id); err != nil {
+			return nil, err
+		}
+		project.Services[id] = service
+
+		if jobService.DependsOn == nil {
+			jobService.DependsOn = map[string]composeDependency{}
+		}
+		// act waits for the health checks of the services before the steps run
+		condition := "service_started"
+		if service.Healthcheck != nil {
+			condition = "service_healthy"
+		}
+		jobService.DependsOn[id] = composeDependency{Condition: condition}
+	}
+	project.Services[jobServiceName] = jobService
+
+	// the services reach each other by their ids like on GitHub
+	networkName, createNetwork := rc.networkName()
+	switch {
+	case createNetwork:
+		project.Networks = map[string]*composeNetwork{"default": {Name: networkName, Internal: rc.noNetwork()}}
+	case networkName == "host" || networkName == "none" || networkName == "bridge" || strings.HasPrefix(networkName, "container:"):
+		for _, service := range project.Services {
+			service.NetworkMode = networkName
+		}
+	default:
+		project.Networks = map[string]*composeNetwork{"default": {Name: networkName, External: true}}
+	}
+
+	w := &strings.Builder{}
+	fmt.Fprintf(w, "# The job %s of %s, exported by act.\n", job.Name, job.WorkflowFile)
+	fmt.Fprintf(w, "# Start it with `docker compose -f %s.compose.yml up -d` and run the steps in `docker compose -f %s.compose.yml exec %s bash`.\n", name, name, jobServiceName)
+	if len(project.secrets) > 0 {
+		secrets := make([]string, 0, len(project.secrets))
+		for secret := range project.secrets {
+			secrets = append(secrets, secret)
+		}
+		sort.Strings(secrets)
+		fmt.Fprintf(w, "# The secrets are read from the environment: %s\n", strings.Join(secrets, ", "))
+	}
+	services := make([]string, 0, len(unsupported))
+	for service := range unsupported {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		fmt.Fprintf(w, "# The options of %s which aren't exported: %s\n", service, comment(strings.Join(unsupported[service], " ")))
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(project); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return &ExportFile{Name: name + ".compose.yml", Content: w.String()}, nil
+}
+
+// value escapes the variables of compose in a value, the secrets in it become variables of the same name
+func (project *composeFile) value(value string) string {
+	value = strings.ReplaceAll(value, "$", "$$")
+	for _, secret := range usedSecrets(value) {
+		project.secrets[secret] = true
+	}
+	return secretPlaceholderPattern.ReplaceAllString(value, "$${$1}")
+}
+
+func (project *composeFile) values(values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	escaped := make(map[string]string, len(values))
+	for k, v := range values {
+		escaped[k] = project.value(v)
+	}
+	return escaped
+}
+
+func (project *composeFile) list(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	escaped := make([]string, 0, len(values))
+	for _, v := range values {
+		escaped = append(escaped, project.value(v))
+	}
+	return escaped
+}
+
+// volumes returns the volumes of a service and declares the named volumes in the project, they
+// keep the names act uses, e.g. the tool cache is shared with act
+func (project *composeFile) volumes(binds []string, mounts map[string]string) []string {
+	volumes := project.list(binds)
+	names := make([]string, 0, len(mounts))
+	for name := range mounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if project.Volumes == nil {
+			project.Volumes = map[string]*composeVolume{}
+		}
+		project.Volumes[name] = &composeVolume{Name: project.value(name)}
+		volumes = append(volumes, project.value(name+":"+mounts[name]))
+	}
+	return volumes
+}
+
+// options maps the health check options of a container to the compose service, e.g. the
+// --health-cmd of the services of GitHub, the other options are collected in unsupported
+func (project *composeFile) options(service *composeService, options string, unsupported map[string][]string, name string) error {
+	args, err := shellquote.Split(options)
+	if err != nil {
+		return fmt.Errorf("invalid options of %s: %w", name, err)
+	}
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if !strings.HasPrefix(flag, "--health-") {
+			unsupported[name] = append(unsupported[name], args[i])
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if service.Healthcheck == nil {
+			service.Healthcheck = &composeHealthcheck{}
+		}
+		switch flag {
+		case "--health-cmd":
+			service.Healthcheck.Test = []string{"CMD-SHELL", project.value(value)}
+		case "--health-interval":
+			service.Healthcheck.Interval = value
+		case "--health-timeout":
+			service.Healthcheck.Timeout = value
+		case "--health-start-period":
+			service.Healthcheck.StartPeriod = value
+		case "--health-retries":
+			retries, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid --health-retries of %s: %w", name, err)
+			}
+			service.Healthcheck.Retries = retries
+		default:
+			unsupported[name] = append(unsupported[name], flag+"="+value)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/nektos/act/pkg/common"
@@ -29,13 +30,9 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 
 	// todo: cleanup EvaluationEnvironment creation
 	using := make(map[string]exprparser.Needs)
-	strategy := make(map[string]interface{})
+	var strategy map[string]interface{}
 	if rc.Run != nil {
-		job := rc.Run.Job()
-		if job != nil && job.Strategy != nil {
-			strategy["fail-fast"] = job.Strategy.FailFast
-			strategy["max-parallel"] = job.Strategy.MaxParallel
-		}
+		strategy = rc.getStrategyContext()
 
 		jobs := rc.Run.Workflow.Jobs
 		jobNeeds := rc.Run.Job().Needs()
@@ -77,10 +74,12 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 		// but required to interpolate/evaluate the step outputs on the job
 		Steps:    rc.getStepsContext(),
 		Secrets:  getWorkflowSecrets(ctx, rc),
+		Vars:     rc.vars(ctx),
 		Strategy: strategy,
 		Matrix:   rc.Matrix,
 		Needs:    using,
 		Inputs:   inputs,
+		Aliases:  rc.compatContextAliases(),
 	}
 	if rc.JobContainer != nil {
 		ee.Runner = rc.JobContainer.GetRunnerContext(ctx)
@@ -96,13 +95,25 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 
 // NewExpressionEvaluator creates a new evaluator
 func (rc *RunContext) NewStepExpressionEvaluator(ctx context.Context, step step) ExpressionEvaluator {
-	// todo: cleanup EvaluationEnvironment creation
-	job := rc.Run.Job()
-	strategy := make(map[string]interface{})
-	if job.Strategy != nil {
-		strategy["fail-fast"] = job.Strategy.FailFast
-		strategy["max-parallel"] = job.Strategy.MaxParallel
+	return rc.newStepExpressionEvaluator(ctx, step, getEvaluatorInputs(ctx, rc, step, rc.getGithubContext(ctx)))
+}
+
+// NewActionExpressionEvaluator creates an expression evaluator for the expressions in the action.yml
+// of the action a step uses, the inputs context has the inputs of the action instead of the ones of
+// the workflow or of the composite action the step is in
+func (rc *RunContext) NewActionExpressionEvaluator(ctx context.Context, step step) ExpressionEvaluator {
+	inputs := map[string]interface{}{}
+	for k, v := range *step.getEnv() {
+		if strings.HasPrefix(k, "INPUT_") {
+			inputs[strings.ToLower(strings.TrimPrefix(k, "INPUT_"))] = v
+		}
 	}
+	return rc.newStepExpressionEvaluator(ctx, step, inputs)
+}
+
+func (rc *RunContext) newStepExpressionEvaluator(ctx context.Context, step step, inputs map[string]interface{}) ExpressionEvaluator {
+	// todo: cleanup EvaluationEnvironment creation
+	strategy := rc.getStrategyContext()
 
 	jobs := rc.Run.Workflow.Jobs
 	jobNeeds := rc.Run.Job().Needs()
@@ -115,21 +126,20 @@ func (rc *RunContext) NewStepExpressionEvaluator(ctx context.Context, step step)
 		}
 	}
 
-	ghc := rc.getGithubContext(ctx)
-	inputs := getEvaluatorInputs(ctx, rc, step, ghc)
-
 	ee := &exprparser.EvaluationEnvironment{
 		Github:   step.getGithubContext(ctx),
 		Env:      *step.getEnv(),
 		Job:      rc.getJobContext(),
 		Steps:    rc.getStepsContext(),
 		Secrets:  getWorkflowSecrets(ctx, rc),
+		Vars:     rc.vars(ctx),
 		Strategy: strategy,
 		Matrix:   rc.Matrix,
 		Needs:    using,
 		// todo: should be unavailable
 		// but required to interpolate/evaluate the inputs in actions/composite
-		Inputs: inputs,
+		Inputs:  inputs,
+		Aliases: rc.compatContextAliases(),
 	}
 	if rc.JobContainer != nil {
 		ee.Runner = rc.JobContainer.GetRunnerContext(ctx)
@@ -147,12 +157,18 @@ type expressionEvaluator struct {
 	interpreter exprparser.Interpreter
 }
 
+var (
+	addMaskPattern         = regexp.MustCompile(`::add-mask::.*`)
+	insertDirectivePattern = regexp.MustCompile(`\${{\s*insert\s*}}`)
+	stringLiteralPattern   = regexp.MustCompile("(?:''|[^'])*'")
+)
+
 func (ee expressionEvaluator) evaluate(ctx context.Context, in string, defaultStatusCheck exprparser.DefaultStatusCheck) (interface{}, error) {
 	logger := common.Logger(ctx)
 	logger.Debugf("evaluating expression '%s'", in)
 	evaluated, err := ee.interpreter.Evaluate(in, defaultStatusCheck)
 
-	printable := regexp.MustCompile(`::add-mask::.*`).ReplaceAllString(fmt.Sprintf("%t", evaluated), "::add-mask::***)")
+	printable := addMaskPattern.ReplaceAllString(fmt.Sprintf("%t", evaluated), "::add-mask::***)")
 	logger.Debugf("expression '%s' evaluated to '%s'", in, printable)
 
 	return evaluated, err
@@ -180,8 +196,6 @@ func (ee expressionEvaluator) evaluateScalarYamlNode(ctx context.Context, node *
 
 func (ee expressionEvaluator) evaluateMappingYamlNode(ctx context.Context, node *yaml.Node) (*yaml.Node, error) {
 	var ret *yaml.Node = nil
-	// GitHub has this undocumented feature to merge maps, called insert directive
-	insertDirective := regexp.MustCompile(`\${{\s*insert\s*}}`)
 	for i := 0; i < len(node.Content)/2; i++ {
 		changed := func() error {
 			if ret == nil {
@@ -207,8 +221,8 @@ func (ee expressionEvaluator) evaluateMappingYamlNode(ctx context.Context, node
 			ev = v
 		}
 		var sk string
-		// Merge the nested map of the insert directive
-		if k.Decode(&sk) == nil && insertDirective.MatchString(sk) {
+		// Merge the nested map of the insert directive, an undocumented feature of GitHub to merge maps
+		if k.Decode(&sk) == nil && insertDirectivePattern.MatchString(sk) {
 			if ev.Kind != yaml.MappingNode {
 				return nil, fmt.Errorf("failed to insert node %v into mapping %v unexpected type %v expected MappingNode", ev, node, ev.Kind)
 			}
@@ -334,7 +348,7 @@ func rewriteSubExpression(ctx context.Context, in string, forceFormat bool) (str
 		return in, nil
 	}
 
-	strPattern := regexp.MustCompile("(?:''|[^'])*'")
+	strPattern := stringLiteralPattern
 	pos := 0
 	exprStart := -1
 	strStart := -1
@@ -400,8 +414,10 @@ func getEvaluatorInputs(ctx context.Context, rc *RunContext, step step, ghc *mod
 
 	setupWorkflowInputs(ctx, &inputs, rc)
 
+	// the steps of a composite action see the inputs of the composite action, the inputs of the
+	// actions they use are only in the env of the steps
 	var env map[string]string
-	if step != nil {
+	if step != nil && rc.Parent == nil {
 		env = *step.getEnv()
 	} else {
 		env = rc.GetEnv()
@@ -413,26 +429,56 @@ func getEvaluatorInputs(ctx context.Context, rc *RunContext, step step, ghc *mod
 		}
 	}
 
-	if ghc.EventName == "workflow_dispatch" {
-		config := rc.Run.Workflow.WorkflowDispatchConfig()
-		if config != nil && config.Inputs != nil {
-			for k, v := range config.Inputs {
-				value := nestedMapLookup(ghc.Event, "inputs", k)
-				if value == nil {
-					value = v.Default
-				}
-				if v.Type == "boolean" {
-					inputs[k] = value == "true"
-				} else {
-					inputs[k] = value
+	// the inputs of the event which triggered the run, a called workflow has the inputs of its caller
+	if rc.caller == nil {
+		switch ghc.EventName {
+		case "workflow_dispatch":
+			if config := rc.Run.Workflow.WorkflowDispatchConfig(); config != nil {
+				for k, v := range config.Inputs {
+					inputs[k] = eventInput(ghc.Event, k, v.Type, v.Default)
 				}
 			}
+		case "workflow_call":
+			for k, v := range rc.Run.Workflow.WorkflowCallConfig().Inputs {
+				inputs[k] = eventInput(ghc.Event, k, v.Type, v.Default)
+			}
 		}
 	}
 
 	return inputs
 }
 
+// eventInput returns the value of the input of the event, or the default value
+func eventInput(event map[string]interface{}, name string, inputType string, defaultValue string) interface{} {
+	value := nestedMapLookup(event, "inputs", name)
+	if value == nil {
+		value = defaultValue
+	}
+	return coerceInput(inputType, value)
+}
+
+// coerceInput converts the value of a boolean or number input from a string, e.g. of the event or
+// of an interpolated expression of a caller, like GitHub provides them in the inputs context
+func coerceInput(inputType string, value interface{}) interface{} {
+	str, ok := value.(string)
+	switch {
+	case inputType == "boolean" && ok:
+		return str == "true"
+	case inputType == "number" && ok:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+			return f
+		}
+	case inputType == "number":
+		switch n := value.(type) {
+		case int:
+			return float64(n)
+		case int64:
+			return float64(n)
+		}
+	}
+	return value
+}
+
 func setupWorkflowInputs(ctx context.Context, inputs *map[string]interface{}, rc *RunContext) {
 	if rc.caller != nil {
 		config := rc.Run.Workflow.WorkflowCallConfig()
@@ -456,7 +502,7 @@ func setupWorkflowInputs(ctx context.Context, inputs *map[string]interface{}, rc
 				}
 			}
 
-			(*inputs)[name] = value
+			(*inputs)[name] = coerceInput(input.Type, value)
 		}
 	}
 }
@@ -467,7 +513,7 @@ func getWorkflowSecrets(ctx context.Context, rc *RunContext) map[string]string {
 		secrets := job.Secrets()
 
 		if secrets == nil && job.InheritSecrets() {
-			secrets = rc.caller.runContext.Config.Secrets
+			secrets = environmentValues(rc.caller.runContext.Config.Secrets, rc.environment(ctx).secrets())
 		}
 
 		if secrets == nil {
@@ -481,5 +527,63 @@ func getWorkflowSecrets(ctx context.Context, rc *RunContext) map[string]string {
 		return secrets
 	}
 
-	return rc.Config.Secrets
+	return environmentValues(rc.Config.Secrets, rc.environment(ctx).secrets())
+}
+
+// vars returns the configuration variables of the job, with the ones of its deployment environment
+func (rc *RunContext) vars(ctx context.Context) map[string]string {
+	return environmentValues(rc.Config.Vars, rc.environment(ctx).vars())
+}
+
+// environment returns the deployment environment of the job, nil if it has none or act has no
+// values of it. An expression in its name is evaluated once the job has an expression evaluator.
+func (rc *RunContext) environment(ctx context.Context) *Environment {
+	job := rc.Run.Job()
+	if job == nil {
+		return nil
+	}
+	name := job.EnvironmentName()
+	if strings.Contains(name, "${{") {
+		if rc.ExprEval == nil {
+			return nil
+		}
+		name = rc.ExprEval.Interpolate(ctx, name)
+	}
+	if name == "" {
+		return nil
+	}
+	return rc.Config.Environments[name]
+}
+
+// environmentValues returns the values with the ones of the deployment environment, which override
+// them like the secrets and variables of environments on GitHub
+func environmentValues(values map[string]string, environment map[string]string) map[string]string {
+	if len(environment) == 0 {
+		return values
+	}
+	merged := make(map[string]string, len(values)+len(environment))
+	for k, v := range values {
+		merged[k] = v
+	}
+	for k, v := range environment {
+		merged[k] = v
+	}
+	return merged
+}
+
+// maskedSecrets returns the secrets masked in the output, with the ones of all deployment environments
+func maskedSecrets(config *Config) map[string]string {
+	if len(config.Environments) == 0 {
+		return config.Secrets
+	}
+	secrets := make(map[string]string, len(config.Secrets))
+	for k, v := range config.Secrets {
+		secrets[k] = v
+	}
+	for name, environment := range config.Environments {
+		for k, v := range environment.secrets() {
+			secrets[name+"/"+k] = v
+		}
+	}
+	return secrets
 }
@@ -28,6 +28,9 @@ func createRunContext(t *testing.T) *RunContext {
 			Secrets: map[string]string{
 				"CASE_INSENSITIVE_SECRET": "value",
 			},
+			Vars: map[string]string{
+				"CASE_INSENSITIVE_VAR": "value",
+			},
 		},
 		Env: map[string]string{
 			"key": "value",
@@ -119,9 +122,13 @@ func TestEvaluateRunContext(t *testing.T) {
 		{"job.status", "success", ""},
 		{"matrix.os", "Linux", ""},
 		{"matrix.foo", "bar", ""},
+		{"strategy.job-index", 0, ""},
+		{"strategy.job-total", 1, ""},
 		{"env.key", "value", ""},
 		{"secrets.CASE_INSENSITIVE_SECRET", "value", ""},
 		{"secrets.case_insensitive_secret", "value", ""},
+		{"vars.CASE_INSENSITIVE_VAR", "value", ""},
+		{"vars.case_insensitive_var", "value", ""},
 		{"format('{{0}}', 'test')", "{0}", ""},
 		{"format('{{{0}}}', 'test')", "{test}", ""},
 		{"format('}}')", "}", ""},
@@ -148,6 +155,39 @@ func TestEvaluateRunContext(t *testing.T) {
 	}
 }
 
+func TestEvaluateEnvironment(t *testing.T) {
+	rc := createRunContext(t)
+	rc.Config.Environments = map[string]*Environment{
+		"production": {
+			Secrets: map[string]string{"CASE_INSENSITIVE_SECRET": "production", "DEPLOY_KEY": "key"},
+			Vars:    map[string]string{"REGION": "eu"},
+		},
+	}
+	job := rc.Run.Job()
+	assert.NoError(t, job.RawEnvironment.Encode("${{ matrix.os == 'Linux' && 'production' || 'staging' }}"))
+
+	// the name of the environment is evaluated with the evaluator of the job
+	rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+	ee := rc.NewExpressionEvaluator(context.Background())
+	for in, out := range map[string]interface{}{
+		"secrets.CASE_INSENSITIVE_SECRET": "production",
+		"secrets.DEPLOY_KEY":              "key",
+		"vars.REGION":                     "eu",
+		"vars.CASE_INSENSITIVE_VAR":       "value",
+	} {
+		got, err := ee.evaluate(context.Background(), in, exprparser.DefaultStatusCheckNone)
+		assert.NoError(t, err, in)
+		assert.Equal(t, out, got, in)
+	}
+	assert.Equal(t, "value", rc.Config.Secrets["CASE_INSENSITIVE_SECRET"], "the secrets of the repository are unchanged")
+
+	rc.Matrix["os"] = "Windows"
+	rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+	got, err := rc.NewExpressionEvaluator(context.Background()).evaluate(context.Background(), "secrets.CASE_INSENSITIVE_SECRET", exprparser.DefaultStatusCheckNone)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", got, "staging has no values")
+}
+
 func TestEvaluateStep(t *testing.T) {
 	rc := createRunContext(t)
 	step := &stepRun{
@@ -195,6 +235,9 @@ func TestInterpolate(t *testing.T) {
 			Secrets: map[string]string{
 				"CASE_INSENSITIVE_SECRET": "value",
 			},
+			Vars: map[string]string{
+				"CASE_INSENSITIVE_VAR": "value",
+			},
 		},
 		Env: map[string]string{
 			"KEYWITHNOTHING":       "valuewithnothing",
@@ -364,3 +407,55 @@ func TestRewriteSubExpressionForceFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateInputs(t *testing.T) {
+	for _, eventName := range []string{"workflow_dispatch", "workflow_call"} {
+		t.Run(eventName, func(t *testing.T) {
+			var workflow *model.Workflow
+			assert.NoError(t, yaml.Unmarshal([]byte(fmt.Sprintf(`
+on:
+  %s:
+    inputs:
+      debug:
+        type: boolean
+      retries:
+        type: number
+        default: "3"
+      target:
+        type: string
+        default: staging
+jobs:
+  job1:
+    runs-on: ubuntu-latest
+`, eventName)), &workflow))
+			rc := &RunContext{
+				Config:    &Config{Workdir: ".", EventName: eventName},
+				Env:       map[string]string{},
+				EventJSON: `{"inputs": {"debug": "true", "target": "production"}}`,
+				Run:       &model.Run{JobID: "job1", Workflow: workflow},
+			}
+			ee := rc.NewExpressionEvaluator(context.Background())
+
+			for in, out := range map[string]interface{}{
+				"inputs.debug":       true,
+				"inputs.retries":     float64(3),
+				"inputs.retries > 2": true,
+				"inputs.target":      "production",
+			} {
+				value, err := ee.evaluate(context.Background(), in, exprparser.DefaultStatusCheckNone)
+				assert.NoError(t, err, in)
+				assert.Equal(t, out, value, in)
+			}
+		})
+	}
+}
+
+func TestCoerceInput(t *testing.T) {
+	assert.Equal(t, true, coerceInput("boolean", "true"))
+	assert.Equal(t, false, coerceInput("boolean", "false"))
+	assert.Equal(t, true, coerceInput("boolean", true))
+	assert.Equal(t, 1.5, coerceInput("number", "1.5"))
+	assert.Equal(t, float64(2), coerceInput("number", 2))
+	assert.Equal(t, "abc", coerceInput("number", "abc"))
+	assert.Equal(t, "true", coerceInput("string", "true"))
+}
@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// FailureRecap keeps the last lines of the output of the steps, the ones of the failed steps are
+// printed again after the run, so their errors don't have to be searched in the interleaved output
+// of the parallel jobs. It is safe for the parallel jobs.
+type FailureRecap struct {
+	Lines int // the number of lines kept of the output of each step
+
+	mu      sync.Mutex
+	running map[string]*FailedStep // the output of the running steps by job, step and stage
+	failed  []*FailedStep
+}
+
+// FailedStep is the end of the output of a failed step
+type FailedStep struct {
+	Job     string
+	Step    string
+	Output  []string // the last lines of the output, with their job prefix
+	Dropped int      // the number of lines before the output which aren't kept
+}
+
+// Failed returns the failed steps of the run in the order they failed
+func (r *FailureRecap) Failed() []*FailedStep {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*FailedStep{}, r.failed...)
+}
+
+// Write prints the output of each failed step in its own section
+func (r *FailureRecap) Write(w io.Writer) error {
+	var b strings.Builder
+	for i, step := range r.Failed() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		title := fmt.Sprintf("%s: %s", step.Job, step.Step)
+		fmt.Fprintf(&b, "----- \u274C  %s -----\n", title)
+		if step.Dropped > 0 {
+			fmt.Fprintf(&b, "... %d lines before\n", step.Dropped)
+		}
+		for _, line := range step.Output {
+			fmt.Fprintln(&b, line)
+		}
+		fmt.Fprintf(&b, "----- end of %s -----\n", title)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// record keeps the formatted entry of the output of a step, the output of the step is kept once
+// its result is logged if it failed
+func (r *FailureRecap) record(entry *logrus.Entry, formatted []byte) {
+	stepID, ok := entry.Data["stepID"]
+	if !ok || r.Lines <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := fmt.Sprint(entry.Data["job"])
+	key := fmt.Sprintf("%s/%v/%v", job, stepID, entry.Data["stage"])
+	step := r.running[key]
+	if step == nil {
+		if r.running == nil {
+			r.running = map[string]*FailedStep{}
+		}
+		step = &FailedStep{Job: job, Step: strings.TrimSpace(fmt.Sprintf("%v %v", entry.Data["stage"], entry.Data["step"]))}
+		r.running[key] = step
+	}
+	step.Output = append(step.Output, strings.Split(strings.TrimSuffix(string(formatted), "\n"), "\n")...)
+	// the lines are dropped in batches instead of on each line
+	if len(step.Output) >= 2*r.Lines {
+		step.keep(r.Lines)
+	}
+
+	switch entry.Data["stepResult"] {
+	case nil:
+	case model.StepStatusFailure:
+		step.keep(r.Lines)
+		r.failed = append(r.failed, step)
+		delete(r.running, key)
+	default:
+		delete(r.running, key)
+	}
+}
+
+// keep drops the lines before the last n lines of the output
+func (s *FailedStep) keep(n int) {
+	if extra := len(s.Output) - n; extra > 0 {
+		s.Dropped += extra
+		s.Output = append([]string{}, s.Output[extra:]...)
+	}
+}
+
+// recapFormatter records the output of the steps in the FailureRecap of the run
+type recapFormatter struct {
+	logrus.Formatter
+	recap *FailureRecap
+}
+
+func (f *recapFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b, err := f.Formatter.Format(entry)
+	if err == nil {
+		f.recap.record(entry, b)
+	}
+	return b, err
+}
@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestFailureRecap(t *testing.T) {
+	recap := &FailureRecap{Lines: 3}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetFormatter(&recapFormatter{Formatter: messageFormatter{}, recap: recap})
+
+	step := func(job, id, name string) *logrus.Entry {
+		return logger.WithFields(logrus.Fields{"job": job, "step": name, "stepID": []string{id}, "stage": "Main"})
+	}
+	build := step("build", "0", "make")
+	test := step("test", "0", "npm test")
+	for _, line := range []string{"one", "two", "three", "four", "five\nsix"} {
+		build.Info(line)
+		test.Info(line)
+	}
+	logger.WithField("job", "build").Info("outside of the steps")
+	build.WithField("stepResult", model.StepStatusFailure).Error("failure")
+	test.WithField("stepResult", model.StepStatusSuccess).Info("success")
+
+	failed := recap.Failed()
+	if assert.Len(t, failed, 1) {
+		assert.Equal(t, "build", failed[0].Job)
+		assert.Equal(t, "Main make", failed[0].Step)
+		assert.Equal(t, 4, failed[0].Dropped)
+		assert.Equal(t, []string{"five", "six", "failure"}, failed[0].Output)
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, recap.Write(&out))
+	assert.Equal(t, "----- ❌  build: Main make -----\n"+
+		"... 4 lines before\n"+
+		"five\nsix\nfailure\n"+
+		"----- end of build: Main make -----\n", out.String())
+
+	var disabled *FailureRecap
+	assert.Empty(t, disabled.Failed())
+}
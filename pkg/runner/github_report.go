@@ -0,0 +1,278 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// the GitHub APIs the results of the jobs are reported with
+const (
+	GitHubReportStatuses = "statuses" // a commit status per job
+	GitHubReportChecks   = "checks"   // a check run per job with the annotations of its steps
+)
+
+// maxCheckRunAnnotations is the number of annotations the Checks API accepts in a request
+const maxCheckRunAnnotations = 50
+
+// githubAnnotation is an annotation of a ::error, ::warning or ::notice command of a step
+type githubAnnotation struct {
+	Level   string // failure, warning or notice
+	File    string // the file as the step named it, relative to the workspace or absolute
+	Line    int
+	EndLine int
+	Title   string
+	Message string
+}
+
+// annotate records the annotation of a ::error, ::warning or ::notice command in the job, for
+// the check run of the job. The title and the message are masked like the log, also with
+// --insecure-secrets, the annotations are published on the commit.
+func (rc *RunContext) annotate(ctx context.Context, command string, kvPairs map[string]string, message string) {
+	if rc.Config == nil || rc.Config.GitHubReport != GitHubReportChecks {
+		return
+	}
+	levels := map[string]string{"error": "failure", "warning": "warning", "notice": "notice"}
+	line, _ := strconv.Atoi(kvPairs["line"])
+	endLine, _ := strconv.Atoi(kvPairs["endLine"])
+	if line <= 0 {
+		line = 1
+	}
+	if endLine < line {
+		endLine = line
+	}
+	// the steps of composite actions annotate the job running them
+	job := rc
+	for job.Parent != nil {
+		job = job.Parent
+	}
+	job.annotations = append(job.annotations, githubAnnotation{
+		Level:   levels[command],
+		File:    kvPairs["file"],
+		Line:    line,
+		EndLine: endLine,
+		Title:   rc.maskAnnotation(ctx, kvPairs["title"]),
+		Message: rc.maskAnnotation(ctx, message),
+	})
+}
+
+// maskAnnotation replaces the secrets, the --mask-regex matches and the ::add-mask:: values of the
+// job and of the composite actions running the step with ***
+func (rc *RunContext) maskAnnotation(ctx context.Context, value string) string {
+	value = valueMasker(false, maskedSecrets(rc.Config), rc.Config.MaskPatterns...)(&logrus.Entry{Context: ctx, Message: value}).Message
+	for r := rc; r != nil; r = r.Parent {
+		for _, mask := range r.Masks {
+			if mask != "" {
+				value = strings.ReplaceAll(value, mask, "***")
+			}
+		}
+	}
+	return value
+}
+
+// reportedToGitHub reports the job of rc to GitHub as pending when it starts and with its result
+// when executor finished, as a commit status or a check run of the commit of the github context.
+// The errors of the API only warn, they don't fail the job.
+func (rc *RunContext) reportedToGitHub(executor common.Executor) common.Executor {
+	if rc.Config == nil || rc.Config.GitHubReport == "" {
+		return executor
+	}
+	return func(ctx context.Context) error {
+		if common.Dryrun(ctx) {
+			return executor(ctx)
+		}
+		logger := common.Logger(ctx)
+		reporter, err := rc.newGitHubReporter(ctx)
+		if err != nil {
+			logger.Warnf("Unable to report the job %s to GitHub: %v", rc.String(), err)
+			return executor(ctx)
+		}
+
+		start := time.Now()
+		if err := reporter.start(ctx); err != nil {
+			logger.Warnf("Unable to report the start of the job %s to GitHub: %v", rc.String(), err)
+		}
+		err = executor(ctx)
+		// the result of a cancelled job is reported too
+		ctx, cancel := context.WithTimeout(common.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		if err := reporter.finish(ctx, rc.jobResult(err), time.Since(start), rc.maskAnnotation(ctx, rc.failedStep()), rc.annotations); err != nil {
+			logger.Warnf("Unable to report the result of the job %s to GitHub: %v", rc.String(), err)
+		}
+		return err
+	}
+}
+
+// githubReporter reports a job to the GitHub API
+type githubReporter struct {
+	api        string
+	apiURL     string
+	repository string
+	sha        string
+	token      string
+	workspace  string
+	workflow   string // the path of the workflow in the repository, for the annotations without a file
+	name       string
+	client     *http.Client
+
+	checkRunID int64
+}
+
+func (rc *RunContext) newGitHubReporter(ctx context.Context) (*githubReporter, error) {
+	github := rc.getGithubContext(ctx)
+	if github.Token == "" {
+		return nil, fmt.Errorf("the GITHUB_TOKEN secret is required to report to GitHub")
+	}
+	if github.Repository == "" {
+		return nil, fmt.Errorf("the repository is unknown, set it with --repository")
+	}
+	if !commitSHAPattern.MatchString(github.Sha) {
+		return nil, fmt.Errorf("'%s' isn't the sha of a commit, set it with --sha", github.Sha)
+	}
+	return &githubReporter{
+		api:        rc.Config.GitHubReport,
+		apiURL:     strings.TrimSuffix(github.APIURL, "/"),
+		repository: github.Repository,
+		sha:        github.Sha,
+		token:      github.Token,
+		workspace:  github.Workspace,
+		workflow:   path.Join(".github/workflows", filepath.Base(rc.Run.Workflow.File)),
+		name:       "act / " + rc.String(),
+		client:     http.DefaultClient,
+	}, nil
+}
+
+// start reports the job as pending
+func (r *githubReporter) start(ctx context.Context) error {
+	if r.api == GitHubReportStatuses {
+		return r.postStatus(ctx, "pending", "Running")
+	}
+	var checkRun struct {
+		ID int64 `json:"id"`
+	}
+	err := r.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/check-runs", r.repository), map[string]interface{}{
+		"name":       r.name,
+		"head_sha":   r.sha,
+		"status":     "in_progress",
+		"started_at": time.Now().UTC().Format(time.RFC3339),
+	}, &checkRun)
+	r.checkRunID = checkRun.ID
+	return err
+}
+
+// finish reports the result of the job, success, failure, cancelled or skipped
+func (r *githubReporter) finish(ctx context.Context, result string, duration time.Duration, failedStep string, annotations []githubAnnotation) error {
+	descriptions := map[string]string{"success": "Successful", "failure": "Failed", "cancelled": "Cancelled", "skipped": "Skipped"}
+	summary := fmt.Sprintf("%s in %s", descriptions[result], duration.Round(time.Second))
+	if failedStep != "" {
+		summary += ", failed at " + failedStep
+	}
+
+	if r.api == GitHubReportStatuses {
+		states := map[string]string{"success": "success", "failure": "failure", "cancelled": "error", "skipped": "success"}
+		return r.postStatus(ctx, states[result], summary)
+	}
+
+	output := map[string]interface{}{
+		"title":   summary,
+		"summary": fmt.Sprintf("The job ran with act on %s.", r.sha),
+	}
+	if len(annotations) > 0 {
+		list := make([]map[string]interface{}, 0, len(annotations))
+		for _, annotation := range annotations {
+			if len(list) == maxCheckRunAnnotations {
+				output["summary"] = fmt.Sprintf("%s Only the first %d of the %d annotations are shown.", output["summary"], maxCheckRunAnnotations, len(annotations))
+				break
+			}
+			entry := map[string]interface{}{
+				"path":             r.annotationPath(annotation.File),
+				"start_line":       annotation.Line,
+				"end_line":         annotation.EndLine,
+				"annotation_level": annotation.Level,
+				"message":          annotation.Message,
+			}
+			if annotation.Title != "" {
+				entry["title"] = annotation.Title
+			}
+			list = append(list, entry)
+		}
+		output["annotations"] = list
+	}
+	body := map[string]interface{}{
+		"name":         r.name,
+		"head_sha":     r.sha,
+		"status":       "completed",
+		"conclusion":   result,
+		"completed_at": time.Now().UTC().Format(time.RFC3339),
+		"output":       output,
+	}
+	if r.checkRunID == 0 {
+		// the check run couldn't be created when the job started
+		return r.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/check-runs", r.repository), body, nil)
+	}
+	return r.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/check-runs/%d", r.repository, r.checkRunID), body, nil)
+}
+
+// annotationPath returns the path in the repository of the file of an annotation, the workflow
+// if it has none
+func (r *githubReporter) annotationPath(file string) string {
+	if file == "" {
+		return r.workflow
+	}
+	file = path.Clean(filepath.ToSlash(file))
+	if r.workspace != "" && strings.HasPrefix(file, r.workspace+"/") {
+		file = strings.TrimPrefix(file, r.workspace+"/")
+	}
+	return strings.TrimPrefix(file, "./")
+}
+
+func (r *githubReporter) postStatus(ctx context.Context, state, description string) error {
+	// the API rejects longer descriptions
+	if len(description) > 140 {
+		description = description[:137] + "..."
+	}
+	return r.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", r.repository, r.sha), map[string]interface{}{
+		"state":       state,
+		"context":     r.name,
+		"description": description,
+	}, nil)
+}
+
+func (r *githubReporter) do(ctx context.Context, method, endpoint string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.apiURL+endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
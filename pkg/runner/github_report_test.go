@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// githubRequest is a request of the job to the fake GitHub API
+type githubRequest struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+func newGitHubReportTest(t *testing.T, report string) (*RunContext, func() []githubRequest) {
+	var mu sync.Mutex
+	var requests []githubRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		request := githubRequest{Method: r.Method, Path: r.URL.Path}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request.Body))
+		mu.Lock()
+		requests = append(requests, request)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	t.Cleanup(server.Close)
+
+	rc := createIfTestRunContext(map[string]*model.Job{"job1": createJob(t, "runs-on: ubuntu-latest", "")})
+	rc.Name = "build"
+	rc.Run.Workflow.File = "ci.yml"
+	rc.Config.GitHubReport = report
+	rc.Config.Token = "token"
+	rc.Config.Repository = "octo/hello"
+	rc.Config.Sha = "0123456789abcdef0123456789abcdef01234567"
+	rc.Config.Env = map[string]string{"GITHUB_API_URL": server.URL, "GITHUB_WORKSPACE": "/github/workspace"}
+	return rc, func() []githubRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]githubRequest{}, requests...)
+	}
+}
+
+func TestReportToGitHubStatuses(t *testing.T) {
+	rc, requests := newGitHubReportTest(t, GitHubReportStatuses)
+	err := rc.reportedToGitHub(func(ctx context.Context) error {
+		rc.legResult = "failure"
+		return errors.New("exit status 1")
+	})(context.Background())
+	assert.EqualError(t, err, "exit status 1")
+
+	got := requests()
+	require.Len(t, got, 2)
+	for _, request := range got {
+		assert.Equal(t, "/repos/octo/hello/statuses/0123456789abcdef0123456789abcdef01234567", request.Path)
+		assert.Equal(t, "act / test-workflow/build", request.Body["context"])
+	}
+	assert.Equal(t, "pending", got[0].Body["state"])
+	assert.Equal(t, "failure", got[1].Body["state"])
+	assert.Equal(t, "Failed in 0s", got[1].Body["description"])
+}
+
+func TestReportToGitHubChecks(t *testing.T) {
+	rc, requests := newGitHubReportTest(t, GitHubReportChecks)
+	err := rc.reportedToGitHub(func(ctx context.Context) error {
+		handler := rc.commandHandler(ctx)
+		handler("::error file=/github/workspace/src/main.go,line=12,endLine=14,title=Build::undefined: foo\n")
+		handler("::warning::deprecated input\n")
+		rc.legResult = "success"
+		return nil
+	})(context.Background())
+	assert.NoError(t, err)
+
+	got := requests()
+	require.Len(t, got, 2)
+	assert.Equal(t, http.MethodPost, got[0].Method)
+	assert.Equal(t, "/repos/octo/hello/check-runs", got[0].Path)
+	assert.Equal(t, "in_progress", got[0].Body["status"])
+	assert.Equal(t, "act / test-workflow/build", got[0].Body["name"])
+
+	assert.Equal(t, http.MethodPatch, got[1].Method)
+	assert.Equal(t, "/repos/octo/hello/check-runs/42", got[1].Path)
+	assert.Equal(t, "completed", got[1].Body["status"])
+	assert.Equal(t, "success", got[1].Body["conclusion"])
+	output := got[1].Body["output"].(map[string]interface{})
+	assert.Equal(t, "Successful in 0s", output["title"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"path": "src/main.go", "start_line": 12.0, "end_line": 14.0, "annotation_level": "failure", "title": "Build", "message": "undefined: foo"},
+		map[string]interface{}{"path": ".github/workflows/ci.yml", "start_line": 1.0, "end_line": 1.0, "annotation_level": "warning", "message": "deprecated input"},
+	}, output["annotations"])
+}
+
+func TestReportToGitHubChecksMasked(t *testing.T) {
+	rc, requests := newGitHubReportTest(t, GitHubReportChecks)
+	rc.Config.Secrets = map[string]string{"API_KEY": "s3cr3t"}
+	rc.Config.MaskPatterns = []*regexp.Regexp{regexp.MustCompile(`\d{12}`)}
+	err := rc.reportedToGitHub(func(ctx context.Context) error {
+		handler := rc.commandHandler(ctx)
+		handler("::add-mask::build.internal\n")
+		handler("::error title=Key s3cr3t::unable to reach build.internal with s3cr3t for 123456789012\n")
+		rc.legResult = "failure"
+		return nil
+	})(context.Background())
+	assert.NoError(t, err)
+
+	got := requests()
+	require.Len(t, got, 2)
+	output := got[1].Body["output"].(map[string]interface{})
+	annotation := output["annotations"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Key ***", annotation["title"])
+	assert.Equal(t, "unable to reach *** with *** for ***", annotation["message"])
+}
+
+func TestReportToGitHubWithoutToken(t *testing.T) {
+	rc, requests := newGitHubReportTest(t, GitHubReportStatuses)
+	rc.Config.Token = ""
+	ran := false
+	assert.NoError(t, rc.reportedToGitHub(func(ctx context.Context) error {
+		ran = true
+		return nil
+	})(context.Background()))
+	assert.True(t, ran)
+	assert.Empty(t, requests())
+}
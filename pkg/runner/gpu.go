@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// gpuOptions returns the options of the job container of --gpus and --gpu-runtime, the options of
+// the job win, e.g. a container with --gpus device=1 in its options gets only this GPU. The runtime
+// is only set for a container with GPUs.
+func (rc *RunContext) gpuOptions(options string) string {
+	args, _ := shellquote.Split(options)
+	gpus := make([]string, 0)
+	requested := containerOption(args, "--gpus") != ""
+	if rc.Config.GPUs != "" && !requested {
+		gpus = append(gpus, "--gpus", rc.Config.GPUs)
+		requested = true
+	}
+	if rc.Config.GPURuntime != "" && requested && containerOption(args, "--runtime") == "" {
+		gpus = append(gpus, "--runtime", rc.Config.GPURuntime)
+	}
+	return shellquote.Join(gpus...)
+}
+
+// containerOption returns the value of an option of docker run in the arguments, empty if it is
+// not set
+func containerOption(args []string, name string) string {
+	for i, arg := range args {
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			return strings.TrimPrefix(arg, name+"=")
+		}
+	}
+	return ""
+}
+
+// gpuEnv returns the variables of the NVIDIA Container Toolkit for the GPUs of the job container,
+// e.g. all or device=0,1 like docker run --gpus. The nvidia runtime of --runtime nvidia exposes the
+// GPUs and the driver libraries of the capabilities after them.
+func gpuEnv(gpus string) []string {
+	if gpus == "" {
+		return nil
+	}
+	devices := ""
+	capabilities := "compute,utility"
+	fields, err := csv.NewReader(strings.NewReader(gpus)).Read()
+	if err != nil {
+		return nil
+	}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		switch {
+		case !ok && key == "all":
+			devices = "all"
+		case key == "count" && value == "all":
+			devices = "all"
+		case key == "device":
+			devices = value
+		case key == "capabilities" && value != "gpu":
+			// gpu is the generic capability of docker, not one of the driver
+			capabilities = value
+		}
+	}
+	env := []string{"NVIDIA_DRIVER_CAPABILITIES=" + capabilities}
+	if devices != "" {
+		env = append(env, "NVIDIA_VISIBLE_DEVICES="+devices)
+	}
+	return env
+}
@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestGPUOptions(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: gpu
+on: push
+jobs:
+  train:
+    runs-on: ubuntu-latest
+    steps:
+      - run: nvidia-smi
+  test:
+    runs-on: ubuntu-latest
+    container:
+      image: nvidia/cuda:12.4.1-base-ubuntu22.04
+      options: --gpus '"device=1"'
+    steps:
+      - run: nvidia-smi
+`))
+	assert.NoError(t, err)
+
+	newRunContext := func(jobID string, config *Config) *RunContext {
+		rc := &RunContext{
+			Config: config,
+			Run:    &model.Run{Workflow: workflow, JobID: jobID},
+		}
+		rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+		return rc
+	}
+	ctx := context.Background()
+
+	assert.Equal(t, "", newRunContext("train", &Config{GPURuntime: "nvidia"}).options(ctx))
+	assert.Equal(t, "--gpus all", newRunContext("train", &Config{GPUs: "all"}).options(ctx))
+	assert.Equal(t, "--memory 4g --gpus all --runtime nvidia", newRunContext("train", &Config{ContainerOptions: "--memory 4g", GPUs: "all", GPURuntime: "nvidia"}).options(ctx))
+	assert.Equal(t, `--gpus '"device=1"' --runtime nvidia`, newRunContext("test", &Config{GPUs: "all", GPURuntime: "nvidia"}).options(ctx))
+}
+
+func TestGPUEnv(t *testing.T) {
+	assert.Nil(t, gpuEnv(""))
+	assert.Equal(t, []string{"NVIDIA_DRIVER_CAPABILITIES=compute,utility", "NVIDIA_VISIBLE_DEVICES=all"}, gpuEnv("all"))
+	assert.Equal(t, []string{"NVIDIA_DRIVER_CAPABILITIES=compute,utility", "NVIDIA_VISIBLE_DEVICES=0,1"}, gpuEnv(`"device=0,1"`))
+	assert.Equal(t, []string{"NVIDIA_DRIVER_CAPABILITIES=compute,utility,video", "NVIDIA_VISIBLE_DEVICES=all"}, gpuEnv(`count=all,"capabilities=compute,utility,video"`))
+	assert.Equal(t, []string{"NVIDIA_DRIVER_CAPABILITIES=compute,utility"}, gpuEnv("2"))
+}
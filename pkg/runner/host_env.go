@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// containerHostEnv returns the environment variables of the host matching the patterns of
+// --use-host-env as environment of the containers, e.g. cloud credentials or locale settings
+func (rc *RunContext) containerHostEnv() []string {
+	env := make([]string, 0)
+	if len(rc.Config.HostEnv) == 0 {
+		return env
+	}
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			continue
+		}
+		for _, pattern := range rc.Config.HostEnv {
+			if matchStepPattern(pattern, name) {
+				env = append(env, fmt.Sprintf("%s=%s", name, value))
+				break
+			}
+		}
+	}
+	sort.Strings(env)
+	return env
+}
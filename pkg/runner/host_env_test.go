@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunContext_ContainerHostEnv(t *testing.T) {
+	t.Setenv("ACT_TEST_AWS_REGION", "eu-west-1")
+	t.Setenv("ACT_TEST_AWS_PROFILE", "dev")
+	t.Setenv("ACT_TEST_LC_ALL", "de_DE.UTF-8")
+	t.Setenv("ACT_TEST_SECRET", "hidden")
+
+	rc := &RunContext{Config: &Config{}}
+	assert.Empty(t, rc.containerHostEnv())
+
+	rc.Config.HostEnv = []string{"ACT_TEST_AWS_*", "ACT_TEST_LC_ALL"}
+	assert.Equal(t, []string{
+		"ACT_TEST_AWS_PROFILE=dev",
+		"ACT_TEST_AWS_REGION=eu-west-1",
+		"ACT_TEST_LC_ALL=de_DE.UTF-8",
+	}, rc.containerHostEnv())
+}
@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"os/exec"
+	"path"
+	"path/filepath"
+
+	"github.com/nektos/act/pkg/container"
+)
+
+// hostPlatformLabels are the labels of the GitHub-hosted runners per GOOS whose jobs run natively on
+// a host of the same OS, there is no container image of them
+var hostPlatformLabels = map[string][]string{
+	"darwin":  {"macos-latest", "macos-12", "macos-13", "macos-14", "macos-15"},
+	"windows": {"windows-latest", "windows-2019", "windows-2022", "windows-2025"},
+}
+
+// hostPlatformSizes are the suffixes of the labels of the larger runners per GOOS
+var hostPlatformSizes = map[string][]string{
+	"darwin": {"-large", "-xlarge"},
+}
+
+// HostPlatforms returns the platforms of the runners of the OS of the host for --host-platforms,
+// e.g. the macos-* jobs run on the host on macOS and the windows-* jobs on Windows
+func HostPlatforms(goos string) map[string]string {
+	platforms := map[string]string{}
+	for _, label := range hostPlatformLabels[goos] {
+		platforms[label] = "-self-hosted"
+		for _, size := range hostPlatformSizes[goos] {
+			platforms[label+size] = "-self-hosted"
+		}
+	}
+	return platforms
+}
+
+// actFilePath returns the path of a file in the act directory of the job like the steps expect it,
+// the jobs on the host get native paths, e.g. with backslashes on Windows
+func (rc *RunContext) actFilePath(name string) string {
+	p := path.Join(rc.JobContainer.GetActPath(), name)
+	if rc.runsOnHost() {
+		return filepath.FromSlash(p)
+	}
+	return p
+}
+
+// runsOnHost reports whether the steps of the job run on the host
+func (rc *RunContext) runsOnHost() bool {
+	_, ok := rc.JobContainer.(*container.HostEnvironment)
+	return ok
+}
+
+// hostDefaultShell returns the shell of the run steps without one of the jobs on a host of the
+// GOOS, like the runner pwsh on Windows, or Windows PowerShell if pwsh isn't installed. Elsewhere
+// the shell is bash.
+func hostDefaultShell(goos string, lookPath func(string) (string, error)) string {
+	if goos != "windows" {
+		return ""
+	}
+	if _, err := lookPath("pwsh"); err != nil {
+		return "powershell"
+	}
+	return "pwsh"
+}
+
+// hostLookPath finds the shells of the jobs on the host
+var hostLookPath = exec.LookPath
@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/container"
+)
+
+func TestHostPlatforms(t *testing.T) {
+	platforms := HostPlatforms("darwin")
+	assert.Equal(t, "-self-hosted", platforms["macos-latest"])
+	assert.Equal(t, "-self-hosted", platforms["macos-14"])
+	assert.Equal(t, "-self-hosted", platforms["macos-13-xlarge"])
+	assert.NotContains(t, platforms, "ubuntu-latest")
+
+	platforms = HostPlatforms("windows")
+	assert.Equal(t, "-self-hosted", platforms["windows-latest"])
+	assert.Equal(t, "-self-hosted", platforms["windows-2022"])
+	assert.NotContains(t, platforms, "windows-latest-large")
+	assert.NotContains(t, platforms, "macos-latest")
+
+	assert.Empty(t, HostPlatforms("linux"))
+}
+
+func TestHostDefaultShell(t *testing.T) {
+	found := func(string) (string, error) { return "/bin/pwsh", nil }
+	missing := func(string) (string, error) { return "", errors.New("not found") }
+
+	assert.Equal(t, "pwsh", hostDefaultShell("windows", found))
+	assert.Equal(t, "powershell", hostDefaultShell("windows", missing))
+	assert.Equal(t, "", hostDefaultShell("darwin", found))
+	assert.Equal(t, "", hostDefaultShell("linux", found))
+}
+
+func TestActFilePath(t *testing.T) {
+	rc := &RunContext{JobContainer: &containerMock{}}
+	assert.Equal(t, "/var/run/act/workflow/envs.txt", rc.actFilePath("workflow/envs.txt"))
+	assert.False(t, rc.runsOnHost())
+
+	rc.JobContainer = &container.HostEnvironment{ActPath: filepath.Join("tmp", "act")}
+	assert.Equal(t, filepath.Join("tmp", "act", "workflow", "envs.txt"), rc.actFilePath("workflow/envs.txt"))
+	assert.True(t, rc.runsOnHost())
+}
@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// isolatesSteps reports whether the steps of the job run in fresh job containers, hosts and
+// microVMs can't be recreated
+func (rc *RunContext) isolatesSteps(ctx context.Context) bool {
+	return rc.Config.IsolateSteps && rc.JobContainer != nil && !rc.IsHostEnv(ctx) && !rc.isMicroVM(ctx)
+}
+
+// recreateJobContainer replaces the job container by a fresh one of the job image before a step
+// with IsolateSteps, like the containers of docker actions. The workspace, the act directory and
+// the volumes of the job are kept, the other changes a previous step made to the container, e.g.
+// the tools it installed or the files in the home directory, are lost, so the steps depending on
+// them fail. A job resumed with --checkpoint runs its steps in fresh containers of the image of
+// the checkpoint, with the changes of the step before it.
+func (rc *RunContext) recreateJobContainer() common.Executor {
+	return func(ctx context.Context) error {
+		if !rc.isolatesSteps(ctx) || common.Dryrun(ctx) {
+			return nil
+		}
+		common.Logger(ctx).Debugf("Recreating the job container %s", rc.jobContainerName())
+		return common.NewPipelineExecutor(
+			rc.JobContainer.Remove(),
+			rc.JobContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
+			rc.JobContainer.Start(false),
+			rc.inspectJobContainer(),
+			rc.copyMounts(),
+			rc.installCACertificates(),
+		)(ctx)
+	}
+}
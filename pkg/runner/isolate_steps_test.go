@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestIsolatesSteps(t *testing.T) {
+	for _, tt := range []struct {
+		job      string
+		isolate  bool
+		expected bool
+	}{
+		{"runs-on: ubuntu-latest", true, true},
+		{"runs-on: ubuntu-latest\ncontainer: node:20", true, true},
+		{"runs-on: ubuntu-latest", false, false},
+		{"runs-on: self-hosted", true, false},
+		{"runs-on: microvm", true, false},
+	} {
+		rc := createIfTestRunContext(map[string]*model.Job{"job1": createJob(t, tt.job, "")})
+		rc.Config.Platforms = map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+			"self-hosted":   "-self-hosted",
+			"microvm":       "microvm:///var/lib/act/ubuntu.ext4",
+		}
+		rc.Config.IsolateSteps = tt.isolate
+		rc.JobContainer = &containerMock{}
+		assert.Equal(t, tt.expected, rc.isolatesSteps(context.Background()), tt.job)
+	}
+}
+
+func TestRecreateJobContainerDisabled(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{"job1": createJob(t, "runs-on: ubuntu-latest", "")})
+	cm := &containerMock{}
+	rc.JobContainer = cm
+
+	// the mock fails on calls it doesn't expect
+	assert.NoError(t, rc.recreateJobContainer()(context.Background()))
+	cm.AssertExpectations(t)
+}
+
+func TestIsolateStepsRecreatesJobContainer(t *testing.T) {
+	inspect := inspectContainer
+	defer func() { inspectContainer = inspect }()
+	inspectContainer = func(context.Context, string) (*container.ContainerInfo, error) {
+		return &container.ContainerInfo{ID: "id"}, nil
+	}
+
+	for _, tt := range []struct {
+		name     string
+		resumed  int
+		expected []string
+	}{
+		{"all steps", 0, []string{"startContainer", "step1", "Remove", "Create", "Start", "step2", "Remove", "Create", "Start", "step3"}},
+		// the first step after the checkpoint runs in the container started from its image
+		{"resumed", 1, []string{"startContainer", "step2", "Remove", "Create", "Start", "step3"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := createIfTestRunContext(map[string]*model.Job{"job1": createJob(t, "runs-on: ubuntu-latest", "")})
+			rc.Config.IsolateSteps = true
+			rc.StepResults = map[string]*model.StepResult{}
+			order := []string{}
+			record := func(name string) func(context.Context) error {
+				return func(context.Context) error {
+					order = append(order, name)
+					return nil
+				}
+			}
+			cm := &containerMock{}
+			cm.On("Remove").Return(record("Remove"))
+			cm.On("Create", []string(nil), []string(nil)).Return(record("Create"))
+			cm.On("Start", false).Return(record("Start"))
+			rc.JobContainer = &stepLoggerContainerMock{cm}
+
+			jim := &jobInfoMock{}
+			sfm := &stepFactoryMock{}
+			steps := []*model.Step{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+			jim.On("steps").Return(steps)
+			jim.On("matrix").Return(map[string]interface{}{})
+			jim.On("startContainer").Return(func(context.Context) error {
+				order = append(order, "startContainer")
+				if tt.resumed > 0 {
+					rc.Config.Checkpoint, rc.Config.Resume = true, true
+					rc.checkpoint = &jobCheckpoint{Steps: tt.resumed}
+				}
+				return nil
+			})
+			jim.On("stopContainer").Return(func(context.Context) error { return nil })
+			jim.On("interpolateOutputs").Return(func(context.Context) error { return nil })
+			jim.On("closeContainer").Return(func(context.Context) error { return nil })
+			jim.On("result", "success")
+			for _, stepModel := range steps {
+				sm := &stepMock{}
+				sm.On("pre").Return(func(context.Context) error { return nil })
+				sm.On("main").Return(record("step" + stepModel.ID))
+				sm.On("post").Return(func(context.Context) error { return nil })
+				sfm.On("newStep", stepModel, rc).Return(sm, nil)
+			}
+
+			ctx := common.WithJobErrorContainer(context.Background())
+			require.NoError(t, newJobExecutor(jim, sfm, rc)(ctx))
+			assert.Equal(t, tt.expected, order)
+			assert.NoError(t, common.JobError(ctx))
+		})
+	}
+}
+
+// stepLoggerContainerMock is a container mock the loggers of the steps can write to
+type stepLoggerContainerMock struct {
+	*containerMock
+}
+
+func (*stepLoggerContainerMock) ReplaceLogWriter(io.Writer, io.Writer) (io.Writer, io.Writer) {
+	return nil, nil
+}
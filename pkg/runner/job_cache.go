@@ -0,0 +1,179 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// jobCacheEntry is the recorded result of a leg of a job which succeeded
+type jobCacheEntry struct {
+	Outputs map[string]string `json:"outputs,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+// jobCache hashes the workspace once per run for the fingerprints of the legs, it's hashed like it
+// was when the first leg started because the jobs change it while they run. The files the
+// .gitignore files ignore, e.g. the build outputs of the jobs, are left out.
+type jobCache struct {
+	once      sync.Once
+	workspace string
+	err       error
+}
+
+func (c *jobCache) workspaceHash(dir string) (string, error) {
+	c.once.Do(func() {
+		h := sha256.New()
+		var patterns []gitignore.Pattern
+		if dir != "" {
+			if patterns, c.err = gitignore.ReadPatterns(polyfill.New(osfs.New(dir)), nil); c.err != nil {
+				return
+			}
+		}
+		c.err = hashWorkspaceFiles(h, dir, gitignore.NewMatcher(patterns))
+		c.workspace = hex.EncodeToString(h.Sum(nil))
+	})
+	return c.workspace, c.err
+}
+
+func (rc *RunContext) jobCacheDir() string {
+	return filepath.Join(rc.ActionCacheDir(), "jobs")
+}
+
+// jobDefinition returns the definition of the job before its legs run, they replace the
+// expressions of its outputs with their values. The strategy is left out, the fingerprint of a
+// leg has its matrix.
+func jobDefinition(job *model.Job) (string, error) {
+	definition := *job
+	definition.Result = ""
+	definition.Strategy = nil
+	if definition.If.Kind == 0 {
+		// the default success() of GetJob is only a value
+		definition.If = yaml.Node{Kind: yaml.ScalarNode, Value: job.If.Value}
+	}
+	content, err := yaml.Marshal(&definition)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// jobCacheKey hashes everything a leg of a job depends on: the job and its definition, the
+// matrix, the image, the event, the env, the inputs, the secrets and the vars, the results and
+// outputs of the jobs it needs and the content of the workspace. The contents of the remote actions,
+// the digests of the images and the env of the host aren't hashed, the cache is opt-in for that.
+func (rc *RunContext) jobCacheKey(ctx context.Context) (string, error) {
+	workspace, err := rc.jobCache.workspaceHash(rc.Config.Workdir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "job=%s\nworkflow=%s\n", rc.String(), rc.Run.Workflow.File)
+	fmt.Fprintf(h, "definition=%s\n", rc.jobDefinition)
+	fmt.Fprintf(h, "image=%s\n", rc.platformImage(ctx))
+	fmt.Fprintf(h, "event=%s\n%s\n", rc.Config.EventName, rc.EventJSON)
+
+	env := mergeMaps(rc.Config.Env, rc.Run.Workflow.Env)
+	// the cache server of act listens on another port in every run
+	delete(env, "ACTIONS_CACHE_URL")
+	values := map[string]interface{}{
+		"matrix":   rc.Matrix,
+		"defaults": rc.Run.Workflow.Defaults,
+		"env":      env,
+		"inputs":   rc.Config.Inputs,
+		"secrets":  getWorkflowSecrets(ctx, rc),
+		"vars":     rc.vars(ctx),
+	}
+	if rc.caller != nil {
+		values["with"] = rc.caller.runContext.Run.Job().With
+	}
+	needs := rc.Run.Job().Needs()
+	sort.Strings(needs)
+	for _, need := range needs {
+		if job := rc.Run.Workflow.GetJob(need); job != nil {
+			values["needs."+need] = map[string]interface{}{
+				"result":  job.Result,
+				"outputs": job.Outputs,
+			}
+		}
+	}
+	// the keys of the maps are sorted
+	content, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	_, _ = h.Write(content)
+	fmt.Fprintf(h, "\nworkspace=%s\n", workspace)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// restoreJobCache skips the leg if it succeeded before with the same inputs and replays its
+// outputs. It returns false if the leg has to run.
+func (rc *RunContext) restoreJobCache(ctx context.Context) bool {
+	if !rc.Config.JobCache || rc.jobCache == nil || rc.jobDefinition == "" || common.Dryrun(ctx) {
+		return false
+	}
+	logger := common.Logger(ctx)
+	key, err := rc.jobCacheKey(ctx)
+	if err != nil {
+		logger.Warnf("Unable to fingerprint the job, running it: %v", err)
+		return false
+	}
+	rc.jobFingerprint = key
+
+	content, err := os.ReadFile(filepath.Join(rc.jobCacheDir(), key+".json"))
+	if err != nil {
+		return false
+	}
+	entry := &jobCacheEntry{}
+	if err := json.Unmarshal(content, entry); err != nil {
+		return false
+	}
+
+	logger.Infof("\u23ED  Skipping the job, it succeeded with the same inputs at %s, run it without --job-cache", entry.Time.Local().Format(time.RFC1123))
+	job := rc.Run.Job()
+	if len(entry.Outputs) > 0 && job.Outputs == nil {
+		job.Outputs = map[string]string{}
+	}
+	for k, v := range entry.Outputs {
+		job.Outputs[k] = v
+	}
+	setJobResult(ctx, rc, rc, true)
+	return true
+}
+
+// saveJobCache records the result of the leg once it succeeded, the next runs skip it until one of
+// its inputs changes. Only the user can read it, the outputs may contain secrets.
+func (rc *RunContext) saveJobCache(ctx context.Context) {
+	if rc.jobFingerprint == "" || rc.legResult != "success" {
+		return
+	}
+	content, err := json.Marshal(&jobCacheEntry{
+		Outputs: rc.Run.Job().Outputs,
+		Time:    time.Now(),
+	})
+	if err == nil {
+		err = os.MkdirAll(rc.jobCacheDir(), 0o700)
+	}
+	if err == nil {
+		err = common.WriteFileAtomic(filepath.Join(rc.jobCacheDir(), rc.jobFingerprint+".json"), content, 0o600)
+	}
+	if err != nil {
+		common.Logger(ctx).Warnf("Unable to record the result of the job for the next runs: %v", err)
+	}
+}
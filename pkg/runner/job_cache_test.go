@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestJobCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	workdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, ".gitignore"), []byte("out/\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "main.go"), []byte("package main"), 0o600))
+
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.version.outputs.version }}
+    steps:
+      - id: version
+        run: echo version=1 >> $GITHUB_OUTPUT
+`))
+	require.NoError(t, err)
+	job := workflow.GetJob("build")
+	definition, err := jobDefinition(job)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	newRunContext := func() *RunContext {
+		rc := &RunContext{
+			Config: &Config{
+				Workdir:   workdir,
+				JobCache:  true,
+				Platforms: map[string]string{"ubuntu-latest": "node:16-buster-slim"},
+				Env:       map[string]string{"ACTIONS_CACHE_URL": "http://127.0.0.1:1234/"},
+			},
+			Run:           &model.Run{JobID: "build", Workflow: workflow},
+			StepResults:   map[string]*model.StepResult{},
+			jobCache:      &jobCache{},
+			jobDefinition: definition,
+		}
+		rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+		return rc
+	}
+
+	rc := newRunContext()
+	assert.False(t, rc.restoreJobCache(ctx))
+	job.Outputs["version"] = "1"
+	rc.legResult = "success"
+	rc.saveJobCache(ctx)
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(rc.jobCacheDir(), rc.jobFingerprint+".json"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "the outputs may contain secrets")
+	}
+
+	// the next run skips the job and gets its outputs, also with another cache server
+	job.Outputs["version"] = "${{ steps.version.outputs.version }}"
+	rc = newRunContext()
+	rc.Config.Env["ACTIONS_CACHE_URL"] = "http://127.0.0.1:5678/"
+	assert.True(t, rc.restoreJobCache(ctx))
+	assert.Equal(t, "1", job.Outputs["version"])
+	assert.Equal(t, "success", job.Result)
+
+	// the ignored files don't change the fingerprint
+	assert.NoError(t, os.MkdirAll(filepath.Join(workdir, "out"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "out", "main"), []byte("binary"), 0o600))
+	assert.True(t, newRunContext().restoreJobCache(ctx))
+
+	rc = newRunContext()
+	rc.Config.Secrets = map[string]string{"TOKEN": "secret"}
+	assert.False(t, rc.restoreJobCache(ctx))
+
+	rc = newRunContext()
+	rc.Config.JobCache = false
+	assert.False(t, rc.restoreJobCache(ctx))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "main.go"), []byte("package other"), 0o600))
+	assert.False(t, newRunContext().restoreJobCache(ctx))
+}
+
+func TestJobCacheFailedJob(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	rc := &RunContext{Config: &Config{}, jobFingerprint: "key", legResult: "failure"}
+	rc.saveJobCache(context.Background())
+
+	_, err := os.Stat(filepath.Join(rc.jobCacheDir(), "key.json"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -38,7 +38,11 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 		return common.NewDebugExecutor("No steps found")
 	}
 
+	// with IsolateSteps the job container is recreated before each step but the first one which
+	// runs, also after the steps resumed from a checkpoint, which runs in the container the job started
+	fresh := true
 	preSteps = append(preSteps, func(ctx context.Context) error {
+		fresh = true
 		// Have to be skipped for some Tests
 		if rc.Run == nil {
 			return nil
@@ -46,10 +50,8 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 		rc.ExprEval = rc.NewExpressionEvaluator(ctx)
 		// evaluate environment variables since they can contain
 		// GitHub's special environment variables.
-		for k, v := range rc.GetEnv() {
-			rc.Env[k] = rc.ExprEval.Interpolate(ctx, v)
-		}
-		return nil
+		rc.evaluateEnv(ctx)
+		return rc.resetArtifactsExport()(ctx)
 	})
 
 	for i, stepModel := range infoSteps {
@@ -69,9 +71,15 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 			return common.NewErrorExecutor(err)
 		}
 
-		preSteps = append(preSteps, useStepLogger(rc, stepModel, stepStagePre, step.pre()))
+		preSteps = append(preSteps, useStepLogger(rc, stepModel, stepStagePre, rc.skipResumedStep(i, step.pre())))
 
-		stepExec := step.main()
+		i := i
+		stepExec := rc.recreateJobContainer().If(func(context.Context) bool {
+			return !fresh
+		}).Then(func(context.Context) error {
+			fresh = fresh && rc.resumed(i)
+			return nil
+		}).Then(rc.resumeStep(i, stepModel, step.main()))
 		steps = append(steps, useStepLogger(rc, stepModel, stepStageMain, func(ctx context.Context) error {
 			logger := common.Logger(ctx)
 			err := stepExec(ctx)
@@ -81,6 +89,10 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 			} else if ctx.Err() != nil {
 				logger.Errorf("%v", ctx.Err())
 				common.SetJobError(ctx, ctx.Err())
+			} else if result := rc.StepResults[stepModel.ID]; common.JobError(ctx) == nil && result != nil && result.Outcome == model.StepStatusSuccess && !rc.resumed(i) {
+				if err := rc.saveCheckpoint(ctx, i); err != nil {
+					logger.Warnf("Unable to save the checkpoint after %s: %v", stepModel, err)
+				}
 			}
 			return nil
 		}))
@@ -95,13 +107,30 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 	}
 
 	postExecutor = postExecutor.Finally(func(ctx context.Context) error {
+		if err := rc.copyWorkspaceBack()(ctx); err != nil {
+			common.Logger(ctx).Errorf("%v", err)
+			common.SetJobError(ctx, err)
+		}
+		if err := rc.exportArtifacts()(ctx); err != nil {
+			common.Logger(ctx).Warnf("%v", err)
+		}
 		jobError := common.JobError(ctx)
+		removeCheckpoint := func(ctx context.Context) error { return nil }
+		if jobError == nil {
+			removeCheckpoint = rc.dropCheckpoint()
+		}
 		var err error
-		if rc.Config.AutoRemove || jobError == nil {
+		// a microVM can't be attached to, it's always stopped
+		if (rc.Config.AutoRemove && !rc.Config.KeepContainers) || jobError == nil || rc.cancelled || rc.isMicroVM(ctx) {
 			// always allow 1 min for stopping and removing the runner, even if we were cancelled
-			ctx, cancel := context.WithTimeout(common.WithLogger(context.Background(), common.Logger(ctx)), time.Minute)
+			ctx, cancel := context.WithTimeout(common.WithoutCancel(ctx), time.Minute)
 			defer cancel()
 			err = info.stopContainer()(ctx)
+		} else if rc.JobContainer != nil && !rc.IsHostEnv(ctx) && !common.Dryrun(ctx) {
+			common.Logger(ctx).Infof("\U0001F4E6  Kept the containers of the failed job, open a shell in them with 'act attach %s'", rc.Run.JobID)
+		}
+		if err := removeCheckpoint(ctx); err != nil {
+			common.Logger(ctx).Warnf("Unable to remove the checkpoint of the job: %v", err)
 		}
 		setJobResult(ctx, info, rc, jobError == nil)
 		setJobOutputs(ctx, rc)
@@ -109,39 +138,76 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 		return err
 	})
 
-	pipeline := make([]common.Executor, 0)
-	pipeline = append(pipeline, preSteps...)
-	pipeline = append(pipeline, steps...)
-
-	return common.NewPipelineExecutor(info.startContainer(), common.NewPipelineExecutor(pipeline...).
-		Finally(func(ctx context.Context) error {
-			var cancel context.CancelFunc
-			if ctx.Err() == context.Canceled {
-				// in case of an aborted run, we still should execute the
-				// post steps to allow cleanup.
-				ctx, cancel = context.WithTimeout(common.WithLogger(context.Background(), common.Logger(ctx)), 5*time.Minute)
+	return common.NewPipelineExecutor(info.startContainer().Finally(removeCancelledContainer(info, rc)),
+		common.NewPipelineExecutor(preSteps...).Then(runSteps(rc, steps)).
+			Finally(func(ctx context.Context) error {
+				var cancel context.CancelFunc
+				if ctx.Err() != nil {
+					// in case of an aborted run, we still should execute the
+					// post steps to allow cleanup.
+					rc.cancel(ctx)
+					ctx, cancel = context.WithTimeout(common.WithoutCancel(ctx), 5*time.Minute)
+					defer cancel()
+				}
+				return postExecutor(ctx)
+			}).
+			Finally(info.interpolateOutputs()).
+			Finally(info.closeContainer()))
+}
+
+// runSteps runs the main steps one after the other. When the run is cancelled the job is
+// cancelled and the remaining steps run on for a while, only the ones with always() or
+// cancelled() in their if are enabled then.
+func runSteps(rc *RunContext, steps []common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		for _, step := range steps {
+			if ctx.Err() != nil && !rc.cancelled {
+				rc.cancel(ctx)
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(common.WithoutCancel(ctx), 5*time.Minute)
 				defer cancel()
 			}
-			return postExecutor(ctx)
-		}).
-		Finally(info.interpolateOutputs()).
-		Finally(info.closeContainer()))
+			if err := step(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// removeCancelledContainer removes the containers and the network of a job which was cancelled
+// while they started, the post steps of the job don't run then
+func removeCancelledContainer(info jobInfo, rc *RunContext) common.Executor {
+	return func(ctx context.Context) error {
+		if ctx.Err() == nil {
+			return nil
+		}
+		rc.cancel(ctx)
+		ctx, cancel := context.WithTimeout(common.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		err := info.stopContainer().Finally(info.closeContainer())(ctx)
+		setJobResult(ctx, info, rc, false)
+		return err
+	}
 }
 
 func setJobResult(ctx context.Context, info jobInfo, rc *RunContext, success bool) {
 	logger := common.Logger(ctx)
 
-	jobResult := "success"
+	rc.legResult = "success"
+	if rc.cancelled {
+		rc.legResult = "cancelled"
+	} else if !success {
+		rc.legResult = "failure"
+	}
+
+	jobResult := rc.legResult
 	// we have only one result for a whole matrix build, so we need
 	// to keep an existing result state if we run a matrix
-	if len(info.matrix()) > 0 && rc.Run.Job().Result != "" {
+	if len(info.matrix()) > 0 && rc.Run.Job().Result != "" && success && !rc.cancelled {
 		jobResult = rc.Run.Job().Result
 	}
 
-	if !success {
-		jobResult = "failure"
-	}
-
 	info.result(jobResult)
 	if rc.caller != nil {
 		// set reusable workflow job result
@@ -149,7 +215,9 @@ func setJobResult(ctx context.Context, info jobInfo, rc *RunContext, success boo
 	}
 
 	jobResultMessage := "succeeded"
-	if jobResult != "success" {
+	if jobResult == "cancelled" {
+		jobResultMessage = "cancelled"
+	} else if jobResult != "success" {
 		jobResultMessage = "failed"
 	}
 
@@ -175,15 +243,7 @@ func useStepLogger(rc *RunContext, stepModel *model.Step, stage stepStage, execu
 	return func(ctx context.Context) error {
 		ctx = withStepLogger(ctx, stepModel.ID, rc.ExprEval.Interpolate(ctx, stepModel.String()), stage.String())
 
-		rawLogger := common.Logger(ctx).WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
-			}
-			return true
-		})
+		logWriter := rc.newLogWriter(ctx)
 
 		oldout, olderr := rc.JobContainer.ReplaceLogWriter(logWriter, logWriter)
 		defer rc.JobContainer.ReplaceLogWriter(oldout, olderr)
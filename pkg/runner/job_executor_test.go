@@ -339,3 +339,108 @@ func TestNewJobExecutor(t *testing.T) {
 		})
 	}
 }
+
+func TestNewJobExecutorCancelled(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		cancelStart   bool
+		executedSteps []string
+	}{
+		{
+			name: "cancelledStep",
+			executedSteps: []string{
+				"startContainer",
+				"pre1",
+				"step1",
+				"step2 cancelled",
+				"post1",
+				"stopContainer",
+				"interpolateOutputs",
+				"closeContainer",
+			},
+		},
+		{
+			name:        "cancelledStart",
+			cancelStart: true,
+			executedSteps: []string{
+				"startContainer",
+				"stopContainer",
+				"closeContainer",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(common.WithJobErrorContainer(context.Background()))
+			defer cancel()
+			jim := &jobInfoMock{}
+			sfm := &stepFactoryMock{}
+			rc := &RunContext{
+				JobContainer: &jobContainerMock{},
+				Run: &model.Run{
+					JobID:    "test",
+					Workflow: &model.Workflow{Jobs: map[string]*model.Job{"test": {}}},
+				},
+				Config: &Config{},
+			}
+			rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+			executorOrder := make([]string, 0)
+			record := func(name string) func(context.Context) error {
+				return func(ctx context.Context) error {
+					executorOrder = append(executorOrder, name)
+					return nil
+				}
+			}
+
+			steps := []*model.Step{{ID: "1"}, {ID: "2"}}
+			jim.On("steps").Return(steps)
+			jim.On("matrix").Return(map[string]interface{}{})
+			jim.On("startContainer").Return(func(ctx context.Context) error {
+				executorOrder = append(executorOrder, "startContainer")
+				if tt.cancelStart {
+					cancel()
+					return ctx.Err()
+				}
+				return nil
+			})
+			jim.On("stopContainer").Return(func(ctx context.Context) error {
+				// the containers are removed although the run was cancelled
+				assert.NoError(t, ctx.Err())
+				executorOrder = append(executorOrder, "stopContainer")
+				return nil
+			})
+			jim.On("interpolateOutputs").Return(record("interpolateOutputs")).Maybe()
+			jim.On("closeContainer").Return(record("closeContainer"))
+			jim.On("result", "cancelled")
+
+			sm1 := &stepMock{}
+			sm1.On("pre").Return(record("pre1"))
+			sm1.On("main").Return(func(ctx context.Context) error {
+				executorOrder = append(executorOrder, "step1")
+				cancel()
+				return ctx.Err()
+			})
+			sm1.On("post").Return(record("post1"))
+			sm2 := &stepMock{}
+			sm2.On("pre").Return(func(ctx context.Context) error { return nil })
+			sm2.On("main").Return(func(ctx context.Context) error {
+				// only the steps with always() or cancelled() pass their if now
+				assert.NoError(t, ctx.Err())
+				executorOrder = append(executorOrder, "step2 "+rc.getJobContext().Status)
+				return nil
+			})
+			sm2.On("post").Return(func(ctx context.Context) error { return nil })
+			sfm.On("newStep", steps[0], rc).Return(sm1, nil)
+			sfm.On("newStep", steps[1], rc).Return(sm2, nil)
+
+			err := newJobExecutor(jim, sfm, rc)(ctx)
+			if tt.cancelStart {
+				assert.ErrorIs(t, err, context.Canceled)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.executedSteps, executorOrder)
+			assert.ErrorIs(t, common.JobError(ctx), context.Canceled)
+			jim.AssertExpectations(t)
+		})
+	}
+}
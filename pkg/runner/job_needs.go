@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// NeedsMode is what running a single job does with the jobs it needs
+type NeedsMode string
+
+const (
+	NeedsModeRun    NeedsMode = "run"    // run the needed jobs first
+	NeedsModeAssume NeedsMode = "assume" // assume the needed jobs succeeded with the given outputs
+	NeedsModeFail   NeedsMode = "fail"   // refuse to run a job which needs other jobs
+)
+
+// JobPlan applies the NeedsMode to the plan of a single job. With NeedsModeAssume only the job
+// runs, the jobs it needs get the result success and the outputs of outputs, by job id, so
+// needs.<job>.outputs of the job sees them like after a run of the needed jobs.
+func JobPlan(plan *model.Plan, jobID string, mode NeedsMode, outputs map[string]map[string]string) (*model.Plan, error) {
+	switch mode {
+	case NeedsModeRun, "":
+		if len(outputs) > 0 {
+			return nil, common.WithErrorClass(fmt.Errorf("outputs of needed jobs can only be set if they aren't run"), common.ErrorClassPlan)
+		}
+		return plan, nil
+	case NeedsModeFail, NeedsModeAssume:
+	default:
+		return nil, common.WithErrorClass(fmt.Errorf("unknown needs mode '%s', expected run, assume or fail", mode), common.ErrorClassPlan)
+	}
+
+	jobPlan := &model.Plan{}
+	stage := &model.Stage{}
+	needed := map[string]bool{}
+	for _, s := range plan.Stages {
+		for _, run := range s.Runs {
+			if run.JobID != jobID {
+				continue
+			}
+			needs := run.Job().Needs()
+			if mode == NeedsModeFail && len(needs) > 0 {
+				return nil, common.WithErrorClass(fmt.Errorf("job '%s' needs %s, run them first with --needs-mode run or assume they succeeded with --needs-mode assume", jobID, strings.Join(needs, ", ")), common.ErrorClassPlan)
+			}
+			for _, need := range needs {
+				needed[need] = true
+				job, ok := run.Workflow.Jobs[need]
+				if !ok {
+					continue
+				}
+				job.Result = "success"
+				job.Outputs = map[string]string{}
+				for k, v := range outputs[need] {
+					job.Outputs[k] = v
+				}
+			}
+			stage.Runs = append(stage.Runs, run)
+		}
+	}
+
+	unneeded := []string{}
+	for need := range outputs {
+		if !needed[need] {
+			unneeded = append(unneeded, need)
+		}
+	}
+	if len(unneeded) > 0 {
+		sort.Strings(unneeded)
+		return nil, common.WithErrorClass(fmt.Errorf("job '%s' doesn't need %s, whose outputs are set", jobID, strings.Join(unneeded, ", ")), common.ErrorClassPlan)
+	}
+
+	if len(stage.Runs) > 0 {
+		jobPlan.Stages = append(jobPlan.Stages, stage)
+	}
+	return jobPlan, nil
+}
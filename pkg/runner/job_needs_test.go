@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestJobPlan(t *testing.T) {
+	newPlan := func(t *testing.T) *model.Plan {
+		workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.version.outputs.version }}
+    steps:
+      - run: echo
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+  deploy:
+    needs: [build, lint]
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+`))
+		require.NoError(t, err)
+		return &model.Plan{Stages: []*model.Stage{
+			{Runs: []*model.Run{{Workflow: workflow, JobID: "build"}, {Workflow: workflow, JobID: "lint"}}},
+			{Runs: []*model.Run{{Workflow: workflow, JobID: "deploy"}}},
+		}}
+	}
+
+	plan := newPlan(t)
+	jobPlan, err := JobPlan(plan, "deploy", NeedsModeRun, nil)
+	assert.NoError(t, err)
+	assert.Same(t, plan, jobPlan)
+
+	_, err = JobPlan(newPlan(t), "deploy", NeedsModeFail, nil)
+	assert.EqualError(t, err, "job 'deploy' needs build, lint, run them first with --needs-mode run or assume they succeeded with --needs-mode assume")
+
+	jobPlan, err = JobPlan(newPlan(t), "build", NeedsModeFail, nil)
+	assert.NoError(t, err)
+	assert.Len(t, jobPlan.Stages, 1)
+
+	plan = newPlan(t)
+	jobPlan, err = JobPlan(plan, "deploy", NeedsModeAssume, map[string]map[string]string{"build": {"version": "1.2.3"}})
+	assert.NoError(t, err)
+	require.Len(t, jobPlan.Stages, 1)
+	require.Len(t, jobPlan.Stages[0].Runs, 1)
+	run := jobPlan.Stages[0].Runs[0]
+	assert.Equal(t, "deploy", run.JobID)
+	assert.Equal(t, "success", run.Workflow.Jobs["build"].Result)
+	assert.Equal(t, map[string]string{"version": "1.2.3"}, run.Workflow.Jobs["build"].Outputs)
+	assert.Equal(t, "success", run.Workflow.Jobs["lint"].Result)
+	assert.Empty(t, run.Workflow.Jobs["lint"].Outputs)
+
+	_, err = JobPlan(newPlan(t), "deploy", NeedsModeAssume, map[string]map[string]string{"test": {"passed": "true"}})
+	assert.EqualError(t, err, "job 'deploy' doesn't need test, whose outputs are set")
+
+	_, err = JobPlan(newPlan(t), "deploy", NeedsModeRun, map[string]map[string]string{"build": {"version": "1.2.3"}})
+	assert.Error(t, err)
+
+	_, err = JobPlan(newPlan(t), "deploy", "skip", nil)
+	assert.EqualError(t, err, "unknown needs mode 'skip', expected run, assume or fail")
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// jobSlots limits the number of the jobs running at the same time across the workflows of a run.
+// The jobs calling a reusable workflow don't take a slot, the jobs of the called workflow do.
+type jobSlots chan struct{}
+
+// run runs executor once a slot is free
+func (s jobSlots) run(executor common.Executor) common.Executor {
+	if s == nil {
+		return executor
+	}
+	return func(ctx context.Context) error {
+		select {
+		case s <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-s }()
+		return executor(ctx)
+	}
+}
+
+// maxJobs returns the number of the jobs which run at the same time, MaxJobs or else the number
+// of CPUs of the container engine
+func (runner *runnerImpl) maxJobs(ctx context.Context) int {
+	if runner.config.MaxJobs > 0 {
+		return runner.config.MaxJobs
+	}
+	info, err := container.GetHostInfo(ctx)
+	if err != nil {
+		common.Logger(ctx).Errorf("failed to obtain container engine info: %s", err)
+		return 1 // sane default?
+	}
+	if info.NCPU < 1 {
+		return 1
+	}
+	return info.NCPU
+}
@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+func TestJobSlots(t *testing.T) {
+	slots := make(jobSlots, 2)
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	job := slots.run(func(ctx context.Context) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	// two workflows with three jobs each share the slots
+	workflow := common.NewParallelExecutor(3, job, job, job)
+	err := common.NewParallelExecutor(2, workflow, workflow)(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, maxRunning)
+	assert.Len(t, slots, 0)
+}
+
+func TestJobSlotsCancelled(t *testing.T) {
+	slots := make(jobSlots, 1)
+	slots <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := slots.run(func(ctx context.Context) error {
+		t.Fatal("the job ran without a free slot")
+		return nil
+	})(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMaxJobs(t *testing.T) {
+	runner := &runnerImpl{config: &Config{MaxJobs: 3}}
+	assert.Equal(t, 3, runner.maxJobs(context.Background()))
+}
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/term"
@@ -97,8 +99,18 @@ func WithJobLogger(ctx context.Context, jobID string, jobName string, config *Co
 
 	logger.SetFormatter(&maskedFormatter{
 		Formatter: logger.Formatter,
-		masker:    valueMasker(config.InsecureSecrets, config.Secrets),
+		masker:    valueMasker(config.InsecureSecrets, maskedSecrets(config), config.MaskPatterns...),
 	})
+	if config.FailureRecap != nil {
+		logger.SetFormatter(&recapFormatter{Formatter: logger.Formatter, recap: config.FailureRecap})
+	}
+	if config.OnlyFailures {
+		logger.SetFormatter(newQuietFormatter(logger.Formatter))
+		// the output of the steps is needed for the failing ones
+		if logger.GetLevel() < logrus.InfoLevel {
+			logger.SetLevel(logrus.InfoLevel)
+		}
+	}
 	rtn := logger.WithFields(logrus.Fields{
 		"job":    jobName,
 		"jobID":  jobID,
@@ -142,12 +154,16 @@ func withStepLogger(ctx context.Context, stepID string, stepName string, stageNa
 
 type entryProcessor func(entry *logrus.Entry) *logrus.Entry
 
-func valueMasker(insecureSecrets bool, secrets map[string]string) entryProcessor {
+func valueMasker(insecureSecrets bool, secrets map[string]string, patterns ...*regexp.Regexp) entryProcessor {
 	return func(entry *logrus.Entry) *logrus.Entry {
 		if insecureSecrets {
 			return entry
 		}
 
+		for _, re := range patterns {
+			entry.Message = re.ReplaceAllLiteralString(entry.Message, "***")
+		}
+
 		masks := Masks(entry.Context)
 
 		for _, v := range secrets {
@@ -201,7 +217,7 @@ func (f *jobLogFormatter) printColored(b *bytes.Buffer, entry *logrus.Entry) {
 	}
 
 	if entry.Data["raw_output"] == true {
-		fmt.Fprintf(b, "\x1b[%dm|\x1b[0m %s", f.color, entry.Message)
+		fmt.Fprintf(b, "\x1b[%dm|\x1b[0m %s", f.color, groupMessage(entry))
 	} else if entry.Data["dryrun"] == true {
 		fmt.Fprintf(b, "\x1b[1m\x1b[%dm\x1b[7m*DRYRUN*\x1b[0m \x1b[%dm[%s] \x1b[0m%s%s", gray, f.color, jobName, debugFlag, entry.Message)
 	} else {
@@ -218,7 +234,7 @@ func (f *jobLogFormatter) print(b *bytes.Buffer, entry *logrus.Entry) {
 	}
 
 	if entry.Data["raw_output"] == true {
-		fmt.Fprintf(b, "[%s]   | %s", jobName, entry.Message)
+		fmt.Fprintf(b, "[%s]   | %s", jobName, groupMessage(entry))
 	} else if entry.Data["dryrun"] == true {
 		fmt.Fprintf(b, "*DRYRUN* [%s] %s%s", jobName, debugFlag, entry.Message)
 	} else {
@@ -226,6 +242,21 @@ func (f *jobLogFormatter) print(b *bytes.Buffer, entry *logrus.Entry) {
 	}
 }
 
+// groupMessage renders the header of a ::group:: section with a marker, which shows if the section
+// is folded, and indents the lines in the section
+func groupMessage(entry *logrus.Entry) string {
+	if _, ok := entry.Data["group"]; !ok {
+		return entry.Message
+	}
+	if entry.Data["groupHeader"] != true {
+		return "  " + entry.Message
+	}
+	if entry.Data["groupFolded"] == true {
+		return "\u25B6 " + entry.Message
+	}
+	return "\u25BC " + entry.Message
+}
+
 func (f *jobLogFormatter) isColored(entry *logrus.Entry) bool {
 	isColored := checkIfTerminal(entry.Logger.Out)
 
@@ -248,3 +279,150 @@ func checkIfTerminal(w io.Writer) bool {
 		return false
 	}
 }
+
+// quietFormatter prints only the failing steps with their whole output and the warnings and errors
+// outside of the steps. The entries of a step are buffered until its result is logged, they are
+// dropped if it succeeded or was skipped. The ::group:: sections are folded, only their headers
+// are printed.
+type quietFormatter struct {
+	logrus.Formatter
+
+	mu      sync.Mutex
+	buffers map[string]*stepOutput
+	keys    []string // the keys of buffers in the order the steps started
+}
+
+func newQuietFormatter(formatter logrus.Formatter) *quietFormatter {
+	return &quietFormatter{Formatter: formatter, buffers: map[string]*stepOutput{}}
+}
+
+// stepOutputMemory is the size of the output of a step which is buffered in memory, the rest is
+// written to a temporary file, so verbose builds don't grow the memory of act
+const stepOutputMemory = 1 << 20
+
+// stepOutput is the buffered output of a step
+type stepOutput struct {
+	memory []byte
+	file   *os.File
+}
+
+func (o *stepOutput) write(b []byte) {
+	if o.file == nil && len(o.memory)+len(b) > stepOutputMemory {
+		if file, err := os.CreateTemp("", "act-step-output-"); err == nil {
+			o.file = file
+		}
+	}
+	if o.file != nil {
+		if _, err := o.file.Write(b); err == nil {
+			return
+		}
+	}
+	o.memory = append(o.memory, b...)
+}
+
+// writeTo writes the output to w and releases it
+func (o *stepOutput) writeTo(w io.Writer) {
+	defer o.discard()
+	if _, err := w.Write(o.memory); err != nil || o.file == nil {
+		return
+	}
+	if _, err := o.file.Seek(0, io.SeekStart); err == nil {
+		_, _ = io.Copy(w, o.file)
+	}
+}
+
+// discard releases the output
+func (o *stepOutput) discard() {
+	o.memory = nil
+	if o.file != nil {
+		o.file.Close()
+		os.Remove(o.file.Name())
+		o.file = nil
+	}
+}
+
+func (f *quietFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if _, ok := entry.Data["group"]; ok {
+		if entry.Data["groupHeader"] != true {
+			return nil, nil
+		}
+		entry.Data["groupFolded"] = true
+	}
+	b, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job := fmt.Sprint(entry.Data["job"])
+	if _, ok := entry.Data["stepID"]; !ok {
+		if result, ok := entry.Data["jobResult"]; ok {
+			// the steps of a failed job which didn't log a result, e.g. when it was cancelled
+			keys := f.keys[:0]
+			for _, key := range f.keys {
+				if !strings.HasPrefix(key, job+"/") {
+					keys = append(keys, key)
+					continue
+				}
+				if result != "success" {
+					f.buffers[key].writeTo(entry.Logger.Out)
+				} else {
+					f.buffers[key].discard()
+				}
+				delete(f.buffers, key)
+			}
+			f.keys = keys
+			if result != "success" {
+				return b, nil
+			}
+			return nil, nil
+		}
+		if entry.Level <= logrus.WarnLevel {
+			return b, nil
+		}
+		return nil, nil
+	}
+
+	key := fmt.Sprintf("%s/%v/%v", job, entry.Data["stepID"], entry.Data["stage"])
+	switch entry.Data["stepResult"] {
+	case nil:
+		if entry.Level <= logrus.ErrorLevel {
+			return b, nil
+		}
+		if _, ok := f.buffers[key]; !ok {
+			f.keys = append(f.keys, key)
+			f.buffers[key] = &stepOutput{}
+		}
+		f.buffers[key].write(b)
+		return nil, nil
+	case model.StepStatusFailure:
+		// the output is copied to the output of the logger, which logrus locks while formatting,
+		// instead of holding all of it in memory at once
+		if buffer := f.remove(key); buffer != nil {
+			buffer.writeTo(entry.Logger.Out)
+		}
+		return b, nil
+	default:
+		if buffer := f.remove(key); buffer != nil {
+			buffer.discard()
+		}
+		return nil, nil
+	}
+}
+
+// remove takes the buffer of a step out of the formatter and returns it
+func (f *quietFormatter) remove(key string) *stepOutput {
+	buffer, ok := f.buffers[key]
+	if !ok {
+		return nil
+	}
+	delete(f.buffers, key)
+	for i, k := range f.keys {
+		if k == key {
+			f.keys = append(f.keys[:i], f.keys[i+1:]...)
+			break
+		}
+	}
+	return buffer
+}
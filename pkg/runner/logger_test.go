@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestValueMasker(t *testing.T) {
+	masks := []string{"added-mask"}
+	ctx := WithMasks(context.Background(), &masks)
+	masker := valueMasker(false, map[string]string{"TOKEN": "secret-value"}, regexp.MustCompile(`\b\d{12}\b`), regexp.MustCompile(`[a-z]+\.corp\.internal`))
+
+	entry := masker(&logrus.Entry{
+		Context: ctx,
+		Message: "secret-value added-mask 123456789012 build.corp.internal 1234",
+	})
+	assert.Equal(t, "*** *** *** *** 1234", entry.Message)
+
+	insecure := valueMasker(true, map[string]string{"TOKEN": "secret-value"}, regexp.MustCompile(`\d+`))
+	assert.Equal(t, "secret-value 1234", insecure(&logrus.Entry{Context: ctx, Message: "secret-value 1234"}).Message)
+}
+
+type messageFormatter struct{}
+
+func (messageFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return []byte(groupMessage(entry) + "\n"), nil
+}
+
+func TestQuietFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(out)
+	logger.SetFormatter(newQuietFormatter(messageFormatter{}))
+	job := logger.WithField("job", "CI/test")
+	step := func(id string) *logrus.Entry {
+		return job.WithFields(logrus.Fields{"stepID": []string{id}, "stage": "Main"})
+	}
+
+	job.Infof("Pulling image")
+	job.Warnf("deprecated")
+	step("1").Infof("Run make build")
+	step("1").WithField("raw_output", true).Infof("building")
+	step("1").WithField("stepResult", model.StepStatusSuccess).Infof("Success - make build")
+	step("2").Infof("Run make test")
+	step("2").WithFields(logrus.Fields{"raw_output": true, "group": "go test", "groupHeader": true}).Infof("go test")
+	step("2").WithFields(logrus.Fields{"raw_output": true, "group": "go test"}).Infof("=== RUN TestFoo")
+	step("2").WithField("raw_output", true).Infof("FAIL: TestFoo")
+	step("3").Infof("Evaluating if")
+	step("2").WithField("stepResult", model.StepStatusFailure).Errorf("Failure - make test")
+	step("2").Errorf("exitcode '1': failure")
+	step("3").WithField("stepResult", model.StepStatusSkipped).Infof("Skipping")
+	step("4").Infof("Run make deploy")
+	step("5").Infof("Run make publish")
+	job.WithField("jobResult", "failure").Infof("Job failed")
+
+	assert.Equal(t, strings.Join([]string{
+		"deprecated",
+		"Run make test",
+		"\u25B6 go test",
+		"FAIL: TestFoo",
+		"Failure - make test",
+		"exitcode '1': failure",
+		"Run make deploy",
+		"Run make publish",
+		"Job failed",
+	}, "\n")+"\n", out.String())
+
+	out.Reset()
+	step("1").Infof("Run make build")
+	job.WithField("jobResult", "success").Infof("Job succeeded")
+	assert.Empty(t, out.String())
+}
+
+func TestQuietFormatterLargeOutput(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(out)
+	formatter := newQuietFormatter(messageFormatter{})
+	logger.SetFormatter(formatter)
+	step := logger.WithFields(logrus.Fields{"job": "CI/test", "stepID": []string{"1"}, "stage": "Main"})
+
+	line := strings.Repeat("x", 1023)
+	for i := 0; i < 2*stepOutputMemory/1024; i++ {
+		step.WithField("raw_output", true).Infof("%s", line)
+	}
+	buffer := formatter.buffers["CI/test/[1]/Main"]
+	assert.Len(t, buffer.memory, stepOutputMemory)
+	assert.NotNil(t, buffer.file)
+	name := buffer.file.Name()
+
+	step.WithField("stepResult", model.StepStatusFailure).Errorf("Failure - make")
+	assert.Equal(t, strings.Repeat(line+"\n", 2*stepOutputMemory/1024)+"Failure - make\n", out.String())
+	assert.NoFileExists(t, name)
+	assert.Empty(t, formatter.buffers)
+}
+
+func TestJobLogFormatterGroups(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	f := &jobLogFormatter{}
+	format := func(fields logrus.Fields, message string) string {
+		entry := logrus.NewEntry(logger).WithField("job", "CI/test").WithField("raw_output", true).WithFields(fields)
+		entry.Message = message
+		b, err := f.Format(entry)
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, "[CI/test]   | \u25BC Install\n", format(logrus.Fields{"group": "Install", "groupHeader": true}, "Install"))
+	assert.Equal(t, "[CI/test]   |   npm ci\n", format(logrus.Fields{"group": "Install"}, "npm ci"))
+	assert.Equal(t, "[CI/test]   | \u25B6 Install\n", format(logrus.Fields{"group": "Install", "groupHeader": true, "groupFolded": true}, "Install"))
+	assert.Equal(t, "[CI/test]   | done\n", format(logrus.Fields{}, "done"))
+}
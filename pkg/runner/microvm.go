@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// microVMPlatformPrefix is the prefix of the platforms whose jobs run in a microVM booting the
+// root file system after it, e.g. -P ubuntu-latest=microvm:///var/lib/act/ubuntu.ext4
+const microVMPlatformPrefix = "microvm://"
+
+// MicroVMConfig configures the Firecracker microVMs of the jobs of the microvm:// platforms
+type MicroVMConfig struct {
+	Kernel    string // the uncompressed Linux kernel (vmlinux) the microVMs boot
+	VCPUs     int    // number of the vCPUs of a microVM
+	MemoryMiB int    // memory of a microVM in MiB
+	TapDevice string // the tap device of the network interface of the microVMs, none if empty
+}
+
+// microVMRootFS returns the root file system of the microVM of the job, empty if the job doesn't
+// run in a microVM. A job with a container runs in the container.
+func (rc *RunContext) microVMRootFS(ctx context.Context) string {
+	if rc.containerImage(ctx) != "" {
+		return ""
+	}
+	platform := rc.runsOnImage(ctx)
+	if !strings.HasPrefix(platform, microVMPlatformPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(platform, microVMPlatformPrefix)
+}
+
+func (rc *RunContext) isMicroVM(ctx context.Context) bool {
+	return rc.microVMRootFS(ctx) != ""
+}
+
+// errMicroVMDockerAction is returned for the docker actions of a job in a microVM, they would run
+// in a container on the host
+var errMicroVMDockerAction = errors.New("docker actions can't run in the microVM of the job, they would run in a container sharing the kernel of the host")
+
+func (rc *RunContext) startMicroVM() common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		rootFS := rc.microVMRootFS(ctx)
+		if rc.Config.MicroVM.Kernel == "" {
+			return fmt.Errorf("the job runs in a microVM, set its kernel with --microvm-kernel")
+		}
+		if len(rc.Run.Job().Services) > 0 {
+			logger.Warnf("services are not supported when running jobs in microVMs")
+		}
+		if len(rc.mountBinds()) > 0 {
+			logger.Warnf("--mount is not supported when running jobs in microVMs, except for the copied directories")
+		}
+		logWriter := rc.newLogWriter(ctx)
+
+		logger.Infof("\U0001f680  Start microVM rootfs=%s", rootFS)
+		rc.JobContainer = container.NewMicroVM(&container.NewMicroVMInput{
+			Kernel:    rc.Config.MicroVM.Kernel,
+			RootFS:    rootFS,
+			VCPUs:     rc.Config.MicroVM.VCPUs,
+			MemoryMiB: rc.Config.MicroVM.MemoryMiB,
+			TapDevice: rc.Config.MicroVM.TapDevice,
+			Dir:       filepath.Join(rc.ActionCacheDir(), rc.randomHex(8, "microvm", rc.String())),
+			Env:       rc.jobContainerEnv(ctx),
+			Stdout:    logWriter,
+			Stderr:    logWriter,
+		})
+		rc.cleanUpJobContainer = rc.JobContainer.Remove()
+
+		// nothing of the host is bound into the microVM, the workspace is always copied
+		dst := rc.JobContainer.ToContainerPath(rc.Config.Workdir)
+		src := rc.Config.Workdir + string(filepath.Separator) + "."
+		copyWorkspace := rc.JobContainer.CopyDir(dst, src, rc.Config.UseGitIgnore)
+		if tracked, ok := rc.JobContainer.(container.TrackedFilesContainer); ok && rc.Config.CopyTrackedOnly {
+			copyWorkspace = tracked.CopyTrackedDir(dst, src)
+		}
+
+		err := common.NewPipelineExecutor(
+			rc.JobContainer.Pull(false),
+			rc.timed(TimingCreate, "microVM", rc.JobContainer.Create(nil, nil)),
+			rc.JobContainer.Start(false),
+			rc.JobContainer.Copy(rc.JobContainer.GetActPath()+"/", &container.FileEntry{
+				Name: "workflow/event.json",
+				Mode: 0o644,
+				Body: rc.EventJSON,
+			}, &container.FileEntry{
+				Name: "workflow/envs.txt",
+				Mode: 0o666,
+				Body: "",
+			}),
+			copyWorkspace,
+			rc.setupWorkspaceGit(dst, rc.Config.Submodules, rc.Config.LFS),
+			rc.copyMounts(),
+			rc.installCACertificates(),
+		)(ctx)
+		if err != nil {
+			// unlike a container a microVM which failed to start can't be inspected, it's stopped
+			if err := rc.JobContainer.Remove()(common.WithoutCancel(ctx)); err != nil {
+				logger.Warnf("Unable to remove the microVM: %v", err)
+			}
+		}
+		return err
+	}
+}
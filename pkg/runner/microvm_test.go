@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestMicroVMRootFS(t *testing.T) {
+	for _, tt := range []struct {
+		job    string
+		rootFS string
+	}{
+		{"runs-on: ubuntu-latest", "/var/lib/act/ubuntu.ext4"},
+		{"runs-on: [self-hosted, ubuntu-latest]", "/var/lib/act/ubuntu.ext4"},
+		{"runs-on: ubuntu-latest\ncontainer: node:20", ""},
+		{"runs-on: windows-latest", ""},
+	} {
+		rc := createIfTestRunContext(map[string]*model.Job{"job1": createJob(t, tt.job, "")})
+		rc.Config.Platforms = map[string]string{
+			"ubuntu-latest":  "microvm:///var/lib/act/ubuntu.ext4",
+			"windows-latest": "-self-hosted",
+		}
+		assert.Equal(t, tt.rootFS, rc.microVMRootFS(context.Background()), tt.job)
+		assert.Equal(t, tt.rootFS != "", rc.isMicroVM(context.Background()), tt.job)
+	}
+}
+
+func TestStartMicroVMWithoutKernel(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{"job1": createJob(t, "runs-on: ubuntu-latest", "")})
+	rc.Config.Platforms = map[string]string{"ubuntu-latest": "microvm:///var/lib/act/ubuntu.ext4"}
+
+	err := rc.startContainer()(context.Background())
+	assert.EqualError(t, err, "the job runs in a microVM, set its kernel with --microvm-kernel")
+}
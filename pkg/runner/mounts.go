@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// Mount is a --mount flag, a path of the host the job container sees at Target
+type Mount struct {
+	Source   string // absolute path on the host
+	Target   string // absolute path in the container
+	ReadOnly bool
+	Copy     bool // the directory is copied into the job container when it starts instead of bound
+}
+
+// ParseMount parses a --mount flag of the form <host path>:<container path>[:<mode>], the modes
+// are ro, rw, bind and copy, combined with commas (e.g. ro,bind). A relative host path is
+// relative to the current directory, ~/ is the home directory, e.g. in a project config file.
+func ParseMount(s string) (Mount, error) {
+	spec := s
+	var modes []string
+	if i := strings.LastIndex(spec, ":"); i >= 0 && !strings.Contains(spec[i+1:], "/") {
+		modes = strings.Split(spec[i+1:], ",")
+		spec = spec[:i]
+	}
+	// the container path is absolute, the host path may have a windows drive letter
+	i := strings.LastIndex(spec, ":/")
+	if i <= 0 {
+		return Mount{}, fmt.Errorf("invalid --mount '%s', expected <host path>:<container path>[:ro|rw|bind|copy]", s)
+	}
+	source := spec[:i]
+	if strings.HasPrefix(source, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Mount{}, fmt.Errorf("invalid --mount '%s': %w", s, err)
+		}
+		source = filepath.Join(home, source[2:])
+	}
+	source, err := filepath.Abs(source)
+	if err != nil {
+		return Mount{}, fmt.Errorf("invalid --mount '%s': %w", s, err)
+	}
+	m := Mount{Source: source, Target: path.Clean(spec[i+1:])}
+	bind := false
+	for _, mode := range modes {
+		switch mode {
+		case "ro":
+			m.ReadOnly = true
+		case "rw":
+		case "bind":
+			bind = true
+		case "copy":
+			m.Copy = true
+		default:
+			return Mount{}, fmt.Errorf("invalid --mount '%s', unknown mode '%s', expected ro, rw, bind or copy", s, mode)
+		}
+	}
+	if m.Copy && (bind || m.ReadOnly) {
+		return Mount{}, fmt.Errorf("invalid --mount '%s', a copied directory can't be bound or read-only", s)
+	}
+	return m, nil
+}
+
+// mountBinds returns the binds of the mounts which aren't copied
+func (rc *RunContext) mountBinds() []string {
+	binds := []string{}
+	for _, m := range rc.Config.Mounts {
+		if m.Copy {
+			continue
+		}
+		bind := fmt.Sprintf("%s:%s", m.Source, m.Target)
+		if m.ReadOnly {
+			bind += ":ro"
+		}
+		binds = append(binds, bind)
+	}
+	return binds
+}
+
+// copyMounts copies the directories of the copied mounts into the job container
+func (rc *RunContext) copyMounts() common.Executor {
+	return func(ctx context.Context) error {
+		for _, m := range rc.Config.Mounts {
+			if !m.Copy {
+				continue
+			}
+			if fi, err := os.Stat(m.Source); err != nil {
+				return fmt.Errorf("failed to copy %s into the job container: %w", m.Source, err)
+			} else if !fi.IsDir() {
+				return fmt.Errorf("failed to copy %s into the job container: only directories can be copied", m.Source)
+			}
+			common.Logger(ctx).Debugf("Copying %s to %s", m.Source, m.Target)
+			if err := rc.JobContainer.CopyDir(m.Target+"/", m.Source+string(filepath.Separator)+".", false)(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
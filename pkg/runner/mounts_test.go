@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMount(t *testing.T) {
+	m, err := ParseMount("/home/user/.cache/pip:/root/.cache/pip")
+	require.NoError(t, err)
+	assert.Equal(t, Mount{Source: filepath.Clean("/home/user/.cache/pip"), Target: "/root/.cache/pip"}, m)
+
+	m, err = ParseMount("/data:/data/:ro,bind")
+	require.NoError(t, err)
+	assert.Equal(t, Mount{Source: filepath.Clean("/data"), Target: "/data", ReadOnly: true}, m)
+
+	m, err = ParseMount("fixtures:/fixtures:copy")
+	require.NoError(t, err)
+	abs, err := filepath.Abs("fixtures")
+	require.NoError(t, err)
+	assert.Equal(t, Mount{Source: abs, Target: "/fixtures", Copy: true}, m)
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	m, err = ParseMount("~/.npm:/root/.npm")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".npm"), m.Source)
+
+	for _, s := range []string{"/data", "/data:data", ":/data", "/data:/data:rx", "/data:/data:ro,copy", "/data:/data:bind,copy"} {
+		_, err := ParseMount(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRunContext_MountBinds(t *testing.T) {
+	rc := &RunContext{Config: &Config{Mounts: []Mount{
+		{Source: "/home/user/.cache/pip", Target: "/root/.cache/pip"},
+		{Source: "/data", Target: "/data", ReadOnly: true},
+		{Source: "/fixtures", Target: "/fixtures", Copy: true},
+	}}}
+	assert.Equal(t, []string{"/home/user/.cache/pip:/root/.cache/pip", "/data:/data:ro"}, rc.mountBinds())
+}
@@ -0,0 +1,273 @@
+package runner
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// nodeRuntimeVersions are the versions of the node runtimes the runner ships with in its externals
+var nodeRuntimeVersions = map[model.ActionRunsUsing]string{
+	model.ActionRunsUsingNode12: "12.22.12",
+	model.ActionRunsUsingNode16: "16.20.2",
+	model.ActionRunsUsingNode20: "20.11.1",
+}
+
+// the releases of node, the builds for musl (alpine) are only unofficial ones
+var (
+	nodeRuntimeURL     = "https://nodejs.org/dist"
+	nodeRuntimeMuslURL = "https://unofficial-builds.nodejs.org/download/release"
+)
+
+// reports the node on the PATH of the job container and the platform a node runtime must be built for
+const nodeRuntimeProbe = `version="$(node --version 2>/dev/null)"
+libc=""
+for f in /lib/ld-musl-*; do
+  [ -e "$f" ] && libc=musl
+done
+printf 'version=%s\nos=%s\narch=%s\nlibc=%s\n' "${version#v}" "$(uname -s)" "$(uname -m)" "$libc" > "$1"
+`
+
+var nodeRuntimeArchs = map[string]string{
+	"x86_64":  "x64",
+	"amd64":   "x64",
+	"aarch64": "arm64",
+	"arm64":   "arm64",
+	"armv7l":  "armv7l",
+}
+
+// nodeCommand returns the node which runs the actions of the runtime in the job container. Without
+// the major version of the runtime on the PATH, act provisions the node release the runner ships
+// with into the container, like the runner uses the node of its externals.
+func (rc *RunContext) nodeCommand(ctx context.Context, using model.ActionRunsUsing) (string, error) {
+	if forced, ok := rc.runnerFeatures().forcedNodeRuntimes[using]; ok {
+		using = forced
+	}
+	if !rc.Config.ProvisionNode || common.Dryrun(ctx) {
+		return "node", nil
+	}
+
+	// the steps of composite actions run in the job container too
+	job := rc
+	for job.Parent != nil {
+		job = job.Parent
+	}
+	if node, ok := job.nodeCommands[using]; ok {
+		return node, nil
+	}
+	node, err := rc.provisionNode(ctx, using)
+	if err != nil {
+		return "", err
+	}
+	if job.nodeCommands == nil {
+		job.nodeCommands = map[model.ActionRunsUsing]string{}
+	}
+	job.nodeCommands[using] = node
+	return node, nil
+}
+
+func (rc *RunContext) provisionNode(ctx context.Context, using model.ActionRunsUsing) (string, error) {
+	logger := common.Logger(ctx)
+	version, ok := nodeRuntimeVersions[using]
+	if !ok || rc.JobContainer.GetRunnerContext(ctx)["os"] == "Windows" {
+		return "node", nil
+	}
+
+	resultFile := path.Join(rc.JobContainer.GetActPath(), "workflow", "nodecmd.txt")
+	if err := rc.JobContainer.Exec([]string{"sh", "-c", nodeRuntimeProbe, "probe", resultFile}, map[string]string{}, "", "")(ctx); err != nil {
+		return "", err
+	}
+	probe := map[string]string{}
+	if err := rc.JobContainer.UpdateFromEnv(resultFile, &probe)(ctx); err != nil {
+		return "", err
+	}
+
+	major := strings.TrimPrefix(string(using), "node")
+	if strings.SplitN(probe["version"], ".", 2)[0] == major {
+		return "node", nil
+	}
+	platform, err := nodeRuntimePlatform(probe)
+	if err != nil {
+		logger.Warnf("Unable to provision Node.js %s, running the %s actions with the node of the job container: %v", version, using, err)
+		return "node", nil
+	}
+
+	name := fmt.Sprintf("node-v%s-%s", version, platform)
+	hostDir := filepath.Join(rc.ActionCacheDir(), "externals", name)
+	if _, err := os.Stat(filepath.Join(hostDir, "bin", "node")); err != nil {
+		// the act processes running at the same time share the action cache
+		unlock, err := common.LockFile(ctx, hostDir+".lock")
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+	}
+	if _, err := os.Stat(filepath.Join(hostDir, "bin", "node")); err != nil {
+		if rc.Config.ActionOfflineMode {
+			return "", fmt.Errorf("Node.js %s for %s actions isn't in the action cache and can't be downloaded in offline mode", version, using)
+		}
+		baseURL := nodeRuntimeURL
+		if strings.HasSuffix(platform, "-musl") {
+			baseURL = nodeRuntimeMuslURL
+		}
+		url := fmt.Sprintf("%s/v%s/%s.tar.gz", baseURL, version, name)
+		logger.Infof("  \u2B07  Downloading Node.js %s for %s actions from %s", version, using, url)
+		checksum, err := nodeRuntimeChecksum(ctx, fmt.Sprintf("%s/v%s/SHASUMS256.txt", baseURL, version), name+".tar.gz")
+		if err != nil {
+			return "", err
+		}
+		if err := downloadNodeRuntime(ctx, url, checksum, hostDir); err != nil {
+			return "", err
+		}
+	}
+
+	containerDir := path.Join(rc.JobContainer.GetActPath(), "externals", string(using))
+	logger.Debugf("Provisioning Node.js %s for %s actions in %s", version, using, containerDir)
+	if err := rc.JobContainer.CopyDir(containerDir+"/", hostDir+string(filepath.Separator), false)(ctx); err != nil {
+		return "", err
+	}
+	return path.Join(containerDir, "bin", "node"), nil
+}
+
+// nodeRuntimePlatform returns the platform of the node releases for the probed job container
+func nodeRuntimePlatform(probe map[string]string) (string, error) {
+	arch, ok := nodeRuntimeArchs[probe["arch"]]
+	if !ok {
+		return "", fmt.Errorf("no node releases for the architecture %s", probe["arch"])
+	}
+	switch probe["os"] {
+	case "Linux":
+		if probe["libc"] == "musl" {
+			if arch != "x64" {
+				return "", fmt.Errorf("no node releases for musl on %s", arch)
+			}
+			return "linux-x64-musl", nil
+		}
+		return "linux-" + arch, nil
+	case "Darwin":
+		return "darwin-" + arch, nil
+	}
+	return "", fmt.Errorf("no node releases for %s", probe["os"])
+}
+
+func getNodeRuntime(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// nodeRuntimeChecksum returns the sha256 of the file of a release from its SHASUMS256.txt
+func nodeRuntimeChecksum(ctx context.Context, url string, file string) (string, error) {
+	body, err := getNodeRuntime(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[1] == file {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum of %s", url, file)
+}
+
+// downloadNodeRuntime extracts the node binary of the release into dir/bin/node once the release
+// matches its checksum, the rest of the release isn't needed to run actions
+func downloadNodeRuntime(ctx context.Context, url string, checksum string, dir string) error {
+	body, err := getNodeRuntime(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "bin"), 0o755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	release, err := os.Create(filepath.Join(tmpDir, "release.tar.gz"))
+	if err != nil {
+		return err
+	}
+	defer release.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(release, h), body); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != checksum {
+		return fmt.Errorf("the checksum of %s is %s instead of %s", url, actual, checksum)
+	}
+	if _, err := release.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(release)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s has no bin/node", url)
+		} else if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || path.Base(header.Name) != "node" || path.Base(path.Dir(header.Name)) != "bin" {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(tmpDir, "bin", "node"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+		if err != nil {
+			return err
+		}
+		//nolint:gosec // the release matches the checksum published by the node project
+		if _, err := io.Copy(f, reader); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		break
+	}
+	release.Close()
+	if err := os.Remove(release.Name()); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dir)
+}
@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestNodeRuntimePlatform(t *testing.T) {
+	table := []struct {
+		probe    map[string]string
+		platform string
+	}{
+		{map[string]string{"os": "Linux", "arch": "x86_64"}, "linux-x64"},
+		{map[string]string{"os": "Linux", "arch": "aarch64"}, "linux-arm64"},
+		{map[string]string{"os": "Linux", "arch": "x86_64", "libc": "musl"}, "linux-x64-musl"},
+		{map[string]string{"os": "Darwin", "arch": "arm64"}, "darwin-arm64"},
+		{map[string]string{"os": "Linux", "arch": "aarch64", "libc": "musl"}, ""},
+		{map[string]string{"os": "Linux", "arch": "s390x"}, ""},
+		{map[string]string{"os": "FreeBSD", "arch": "x86_64"}, ""},
+	}
+	for _, tt := range table {
+		platform, err := nodeRuntimePlatform(tt.probe)
+		assert.Equal(t, tt.platform, platform, tt.probe)
+		assert.Equal(t, tt.platform == "", err != nil, tt.probe)
+	}
+}
+
+func nodeRuntimeRelease(t *testing.T, name string) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range []string{name + "/README.md", name + "/bin/node"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: file, Mode: 0o755, Size: int64(len(file)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(file))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestNodeCommand(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release := nodeRuntimeRelease(t, "node-v20.11.1-linux-x64")
+		switch r.URL.Path {
+		case "/v20.11.1/SHASUMS256.txt":
+			checksum := sha256.Sum256(release)
+			fmt.Fprintf(w, "%x  node-v20.11.1-linux-arm64.tar.gz\n%x  node-v20.11.1-linux-x64.tar.gz\n", sha256.Sum256(nil), checksum)
+		case "/v20.11.1/node-v20.11.1-linux-x64.tar.gz":
+			downloads++
+			_, _ = w.Write(release)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	origNodeRuntimeURL := nodeRuntimeURL
+	nodeRuntimeURL = server.URL
+	defer (func() {
+		nodeRuntimeURL = origNodeRuntimeURL
+	})()
+
+	probe := func(cm *containerMock, version string) {
+		cm.On("Exec", []string{"sh", "-c", nodeRuntimeProbe, "probe", "/var/run/act/workflow/nodecmd.txt"}, map[string]string{}, "", "").Return(func(ctx context.Context) error { return nil }).Once()
+		cm.On("UpdateFromEnv", "/var/run/act/workflow/nodecmd.txt", mock.Anything).Run(func(args mock.Arguments) {
+			env := args.Get(1).(*map[string]string)
+			(*env)["version"] = version
+			(*env)["os"] = "Linux"
+			(*env)["arch"] = "x86_64"
+		}).Return(func(ctx context.Context) error { return nil }).Once()
+	}
+
+	t.Run("major version in the container", func(t *testing.T) {
+		cm := &containerMock{}
+		probe(cm, "20.5.0")
+		rc := &RunContext{Config: &Config{ProvisionNode: true}, JobContainer: cm}
+
+		node, err := rc.nodeCommand(context.Background(), model.ActionRunsUsingNode20)
+		assert.NoError(t, err)
+		assert.Equal(t, "node", node)
+		cm.AssertExpectations(t)
+	})
+
+	t.Run("provisioned", func(t *testing.T) {
+		cm := &containerMock{}
+		probe(cm, "18.19.0")
+		hostDir := filepath.Join(cacheDir, "act", "externals", "node-v20.11.1-linux-x64") + string(filepath.Separator)
+		cm.On("CopyDir", "/var/run/act/externals/node20/", hostDir, false).Return(func(ctx context.Context) error { return nil }).Once()
+		parent := &RunContext{Config: &Config{ProvisionNode: true}, JobContainer: cm}
+		rc := &RunContext{Config: parent.Config, JobContainer: cm, Parent: parent}
+
+		node, err := rc.nodeCommand(context.Background(), model.ActionRunsUsingNode20)
+		assert.NoError(t, err)
+		assert.Equal(t, "/var/run/act/externals/node20/bin/node", node)
+		content, err := os.ReadFile(filepath.Join(hostDir, "bin", "node"))
+		assert.NoError(t, err)
+		assert.Equal(t, "node-v20.11.1-linux-x64/bin/node", string(content))
+
+		// the job container is probed once per job
+		node, err = parent.nodeCommand(context.Background(), model.ActionRunsUsingNode20)
+		assert.NoError(t, err)
+		assert.Equal(t, "/var/run/act/externals/node20/bin/node", node)
+		cm.AssertExpectations(t)
+	})
+
+	t.Run("cached release", func(t *testing.T) {
+		cm := &containerMock{}
+		probe(cm, "")
+		cm.On("CopyDir", "/var/run/act/externals/node20/", mock.Anything, false).Return(func(ctx context.Context) error { return nil }).Once()
+		rc := &RunContext{Config: &Config{ProvisionNode: true, ActionOfflineMode: true}, JobContainer: cm}
+
+		node, err := rc.nodeCommand(context.Background(), model.ActionRunsUsingNode20)
+		assert.NoError(t, err)
+		assert.Equal(t, "/var/run/act/externals/node20/bin/node", node)
+		assert.Equal(t, 1, downloads)
+		cm.AssertExpectations(t)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		rc := &RunContext{Config: &Config{}, JobContainer: &containerMock{}}
+
+		node, err := rc.nodeCommand(context.Background(), model.ActionRunsUsingNode20)
+		assert.NoError(t, err)
+		assert.Equal(t, "node", node)
+	})
+}
+
+func TestDownloadNodeRuntime(t *testing.T) {
+	release := nodeRuntimeRelease(t, "node-v20.11.1-linux-x64")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(release)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "node")
+	err := downloadNodeRuntime(context.Background(), server.URL, fmt.Sprintf("%x", sha256.Sum256(nil)), dir)
+	assert.ErrorContains(t, err, "checksum")
+	assert.NoDirExists(t, dir)
+
+	err = downloadNodeRuntime(context.Background(), server.URL, fmt.Sprintf("%x", sha256.Sum256(release)), dir)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.FileExists(t, filepath.Join(dir, "bin", "node"))
+}
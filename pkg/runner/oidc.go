@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// idTokenClaims returns the claims GitHub puts into the OIDC tokens of a job, overridden by --oidc-claim
+func (rc *RunContext) idTokenClaims(github *model.GithubContext) map[string]any {
+	subject := fmt.Sprintf("repo:%s:ref:%s", github.Repository, github.Ref)
+	if github.EventName == "pull_request" || github.EventName == "pull_request_target" {
+		subject = fmt.Sprintf("repo:%s:pull_request", github.Repository)
+	}
+
+	claims := map[string]any{
+		"sub":                subject,
+		"aud":                fmt.Sprintf("%s/%s", github.ServerURL, github.RepositoryOwner),
+		"actor":              github.Actor,
+		"base_ref":           github.BaseRef,
+		"event_name":         github.EventName,
+		"head_ref":           github.HeadRef,
+		"job_workflow_ref":   fmt.Sprintf("%s/.github/workflows/%s@%s", github.Repository, rc.Run.Workflow.File, github.Ref),
+		"ref":                github.Ref,
+		"ref_type":           github.RefType,
+		"repository":         github.Repository,
+		"repository_owner":   github.RepositoryOwner,
+		"run_attempt":        "1",
+		"run_id":             github.RunID,
+		"run_number":         github.RunNumber,
+		"runner_environment": "self-hosted",
+		"sha":                github.Sha,
+		"workflow":           github.Workflow,
+	}
+	for key, value := range rc.Config.IDTokenClaims {
+		claims[key] = value
+	}
+	return claims
+}
+
+// setIDTokenVars lets the steps request OIDC tokens from the emulated token endpoint
+func (rc *RunContext) setIDTokenVars(github *model.GithubContext, env map[string]string) {
+	if rc.idTokenRequestToken == "" {
+		rc.idTokenRequestToken = rc.Config.IDTokenIssuer.Register(rc.idTokenClaims(github))
+		rc.AddMask(rc.idTokenRequestToken)
+	}
+	env["ACTIONS_ID_TOKEN_REQUEST_URL"] = rc.Config.IDTokenIssuer.RequestURL()
+	env["ACTIONS_ID_TOKEN_REQUEST_TOKEN"] = rc.idTokenRequestToken
+}
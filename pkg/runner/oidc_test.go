@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/oidc"
+)
+
+func TestRunContextIDTokenClaims(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			IDTokenClaims: map[string]string{"environment": "staging"},
+		},
+		Run: &model.Run{
+			JobID:    "job1",
+			Workflow: &model.Workflow{File: "ci.yml"},
+		},
+	}
+	ghc := &model.GithubContext{
+		ServerURL:       "https://github.com",
+		Repository:      "octo/hello",
+		RepositoryOwner: "octo",
+		EventName:       "push",
+		Ref:             "refs/heads/main",
+	}
+
+	claims := rc.idTokenClaims(ghc)
+	assert.Equal(t, "repo:octo/hello:ref:refs/heads/main", claims["sub"])
+	assert.Equal(t, "https://github.com/octo", claims["aud"])
+	assert.Equal(t, "octo/hello/.github/workflows/ci.yml@refs/heads/main", claims["job_workflow_ref"])
+	assert.Equal(t, "staging", claims["environment"])
+
+	ghc.EventName = "pull_request"
+	rc.Config.IDTokenClaims["sub"] = "repo:octo/hello:environment:staging"
+	assert.Equal(t, "repo:octo/hello:environment:staging", rc.idTokenClaims(ghc)["sub"])
+}
+
+func TestRunContextSetIDTokenVars(t *testing.T) {
+	issuer, err := oidc.StartHandler("", "", "127.0.0.1", 0, nil)
+	require.NoError(t, err)
+	defer issuer.Close()
+
+	rc := &RunContext{
+		Config: &Config{IDTokenIssuer: issuer},
+		Run: &model.Run{
+			JobID:    "job1",
+			Workflow: &model.Workflow{File: "ci.yml"},
+		},
+	}
+	env := map[string]string{}
+	rc.setIDTokenVars(&model.GithubContext{}, env)
+	assert.Equal(t, issuer.RequestURL(), env["ACTIONS_ID_TOKEN_REQUEST_URL"])
+	assert.NotEmpty(t, env["ACTIONS_ID_TOKEN_REQUEST_TOKEN"])
+	assert.Contains(t, rc.Masks, env["ACTIONS_ID_TOKEN_REQUEST_TOKEN"])
+
+	// the steps of a job share the request token
+	requestToken := env["ACTIONS_ID_TOKEN_REQUEST_TOKEN"]
+	rc.setIDTokenVars(&model.GithubContext{}, env)
+	assert.Equal(t, requestToken, env["ACTIONS_ID_TOKEN_REQUEST_TOKEN"])
+}
@@ -0,0 +1,314 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/model"
+)
+
+// PlanReport is the plan of a dry run with the matrices expanded and the if conditions, images
+// and env evaluated, assuming every job and step before succeeds
+type PlanReport struct {
+	EventName string         `json:"event_name"`
+	Stages    []*StageReport `json:"stages"`
+}
+
+// StageReport holds the jobs of a stage, which run in parallel
+type StageReport struct {
+	Jobs []*JobReport `json:"jobs"`
+}
+
+// JobReport is a job of the plan, or a combination of its matrix
+type JobReport struct {
+	Workflow     string                 `json:"workflow"`
+	WorkflowFile string                 `json:"workflow_file"`
+	JobID        string                 `json:"job_id"`
+	Name         string                 `json:"name"`
+	Matrix       map[string]interface{} `json:"matrix,omitempty"`
+	If           string                 `json:"if,omitempty"`
+	Enabled      bool                   `json:"enabled"`
+	Reason       string                 `json:"reason,omitempty"` // why the job doesn't run
+	Image        string                 `json:"image,omitempty"`
+	Uses         string                 `json:"uses,omitempty"` // the reusable workflow called by the job
+	Env          map[string]string      `json:"env,omitempty"`
+	Steps        []*StepReport          `json:"steps,omitempty"`
+}
+
+// StepReport is a step of a job of the plan
+type StepReport struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Uses             string            `json:"uses,omitempty"`
+	Run              string            `json:"run,omitempty"`
+	If               string            `json:"if,omitempty"`
+	Enabled          bool              `json:"enabled"`
+	Reason           string            `json:"reason,omitempty"` // why the step doesn't run
+	Env              map[string]string `json:"env,omitempty"`
+	With             map[string]string `json:"with,omitempty"`
+	Shell            string            `json:"shell,omitempty"`             // the shell of a run step
+	WorkingDirectory string            `json:"working_directory,omitempty"` // the working directory of a run step
+}
+
+// NewPlanReport evaluates the plan without running it, neither docker nor the actions are used.
+// The jobs which run are assumed to succeed, so the jobs needing them see them as successful.
+func (runner *runnerImpl) NewPlanReport(ctx context.Context, plan *model.Plan) (*PlanReport, error) {
+	report := &PlanReport{
+		EventName: runner.config.EventName,
+		Stages:    make([]*StageReport, 0, len(plan.Stages)),
+	}
+	for range plan.Stages {
+		report.Stages = append(report.Stages, &StageReport{})
+	}
+	err := runner.walkPlan(ctx, plan, func(stage int, rc *RunContext, jobReport *JobReport) {
+		report.Stages[stage].Jobs = append(report.Stages[stage].Jobs, jobReport)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// walkPlan evaluates the jobs of the plan stage by stage and calls fn with the run context and
+// the report of each job, or of each combination of its matrix. The results of the jobs and their
+// evaluated matrices are set on a copy of the plan, which can still run afterwards.
+func (runner *runnerImpl) walkPlan(ctx context.Context, plan *model.Plan, fn func(stage int, rc *RunContext, report *JobReport)) error {
+	// the github contexts of the legs of the jobs read the repository once
+	ctx = git.WithLookupCache(ctx)
+	plan = copyPlan(plan)
+	for i, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			job := run.Job()
+			if job == nil {
+				continue
+			}
+			matrixes, err := runner.evaluateMatrixes(ctx, run)
+			if err != nil {
+				return err
+			}
+
+			result := "skipped"
+			for _, rc := range runner.matrixLegs(ctx, run, matrixes) {
+				jobReport := rc.jobReport(ctx)
+				if jobReport.Enabled {
+					result = "success"
+				}
+				fn(i, rc, jobReport)
+			}
+			job.Result = result
+		}
+	}
+	return nil
+}
+
+// copyPlan copies the workflows of the plan with their jobs, the runs of a workflow share its copy
+func copyPlan(plan *model.Plan) *model.Plan {
+	workflows := map[*model.Workflow]*model.Workflow{}
+	copied := &model.Plan{Stages: make([]*model.Stage, 0, len(plan.Stages))}
+	for _, stage := range plan.Stages {
+		copiedStage := &model.Stage{Runs: make([]*model.Run, 0, len(stage.Runs))}
+		for _, run := range stage.Runs {
+			workflow, ok := workflows[run.Workflow]
+			if !ok {
+				workflow = copyWorkflow(run.Workflow)
+				workflows[run.Workflow] = workflow
+			}
+			copiedStage.Runs = append(copiedStage.Runs, &model.Run{Workflow: workflow, JobID: run.JobID})
+		}
+		copied.Stages = append(copied.Stages, copiedStage)
+	}
+	return copied
+}
+
+func copyWorkflow(workflow *model.Workflow) *model.Workflow {
+	copied := *workflow
+	copied.Jobs = make(map[string]*model.Job, len(workflow.Jobs))
+	for id, job := range workflow.Jobs {
+		copiedJob := *job
+		if job.Strategy != nil {
+			strategy := *job.Strategy
+			strategy.RawMatrix = *copyYamlNode(&job.Strategy.RawMatrix)
+			copiedJob.Strategy = &strategy
+		}
+		copied.Jobs[id] = &copiedJob
+	}
+	return &copied
+}
+
+// copyYamlNode copies the node with its children, evaluating the expressions replaces them in place
+func copyYamlNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	if node.Content != nil {
+		copied.Content = make([]*yaml.Node, 0, len(node.Content))
+		for _, child := range node.Content {
+			copied.Content = append(copied.Content, copyYamlNode(child))
+		}
+	}
+	return &copied
+}
+
+// evaluateMatrixes returns the combinations of the matrix of the job selected by --matrix
+func (runner *runnerImpl) evaluateMatrixes(ctx context.Context, run *model.Run) ([]map[string]interface{}, error) {
+	job := run.Job()
+	if job.Strategy != nil {
+		strategyRc := runner.newRunContext(ctx, run, nil)
+		if err := strategyRc.NewExpressionEvaluator(ctx).EvaluateYamlNode(ctx, &job.Strategy.RawMatrix); err != nil {
+			return nil, fmt.Errorf("error while evaluating matrix: %w", err)
+		}
+	}
+	matrixes, err := job.GetMatrixes()
+	if err != nil {
+		return nil, err
+	}
+	selected := selectMatrixes(matrixes, runner.config.Matrix)
+	if len(selected) == 0 && len(matrixes) > 0 {
+		common.Logger(ctx).Warnf("No combination of the matrix of job '%s' matches --matrix", run.JobID)
+	}
+	return selected, nil
+}
+
+func (rc *RunContext) jobReport(ctx context.Context) *JobReport {
+	job := rc.Run.Job()
+	report := &JobReport{
+		Workflow:     rc.Run.Workflow.Name,
+		WorkflowFile: rc.Run.Workflow.File,
+		JobID:        rc.Run.JobID,
+		Name:         rc.Name,
+		Matrix:       rc.Matrix,
+		If:           job.If.Value,
+	}
+
+	enabled, err := EvalBool(ctx, rc.ExprEval, job.If.Value, exprparser.DefaultStatusCheckSuccess)
+	if err != nil {
+		report.Reason = fmt.Sprintf("error in if-expression: %v", err)
+		return report
+	}
+	if !enabled {
+		report.Reason = "if-expression is false"
+		return report
+	}
+
+	if job.Type() != model.JobTypeDefault {
+		report.Enabled = true
+		report.Uses = job.Uses
+		return report
+	}
+
+	switch {
+	case rc.IsHostEnv(ctx):
+		report.Image = "host"
+	case rc.platformImage(ctx) != "":
+		report.Image = rc.platformImage(ctx)
+	default:
+		report.Reason = fmt.Sprintf("unsupported platform %v, try -P", job.RunsOn())
+		return report
+	}
+	report.Enabled = true
+
+	jobEnv := mergeMaps(rc.Run.Workflow.Env, job.Environment())
+	report.Env = rc.reportValues(ctx, rc.ExprEval, jobEnv)
+	rc.evaluateEnv(ctx)
+
+	sf := &stepFactoryImpl{}
+	for i, stepModel := range job.Steps {
+		if stepModel == nil {
+			continue
+		}
+		if stepModel.ID == "" {
+			stepModel.ID = fmt.Sprintf("%d", i)
+		}
+		report.Steps = append(report.Steps, rc.stepReport(ctx, sf, stepModel))
+	}
+	return report
+}
+
+func (rc *RunContext) stepReport(ctx context.Context, sf stepFactory, stepModel *model.Step) *StepReport {
+	report := &StepReport{
+		ID:   stepModel.ID,
+		Name: strings.SplitN(stepModel.String(), "\n", 2)[0],
+		Uses: stepModel.Uses,
+		If:   stepModel.If.Value,
+	}
+	result := &model.StepResult{
+		Outcome:    model.StepStatusSkipped,
+		Conclusion: model.StepStatusSkipped,
+		Outputs:    map[string]string{},
+	}
+	rc.StepResults[stepModel.ID] = result
+
+	step, err := sf.newStep(stepModel, rc)
+	if err != nil {
+		report.Reason = err.Error()
+		return report
+	}
+	if _, ok := step.(*stepSkipped); ok {
+		report.Reason = "--skip-step"
+		return report
+	}
+	stepModel = step.getStepModel()
+	report.Uses = stepModel.Uses
+
+	env := step.getEnv()
+	*env = map[string]string{}
+	mergeIntoMap(step, env, rc.GetEnv(), stepModel.Environment())
+	exprEval := rc.NewExpressionEvaluatorWithEnv(ctx, *env)
+	report.Env = rc.reportValues(ctx, exprEval, stepModel.Environment())
+	report.With = rc.reportValues(ctx, exprEval, stepModel.With)
+	if stepModel.Run != "" {
+		report.Run = rc.maskValue(exprEval.Interpolate(ctx, stepModel.Run))
+	}
+	if sr, ok := step.(*stepRun); ok {
+		sr.setupShell(ctx)
+		sr.setupWorkingDirectory(ctx)
+		report.Shell = stepModel.Shell
+		report.WorkingDirectory = sr.WorkingDirectory
+	}
+
+	enabled, err := isStepEnabled(ctx, step.getIfExpression(ctx, stepStageMain), step, stepStageMain)
+	if err != nil {
+		report.Reason = strings.TrimSpace(err.Error())
+		return report
+	}
+	if !enabled {
+		report.Reason = "if-expression is false"
+		return report
+	}
+	report.Enabled = true
+	result.Outcome = model.StepStatusSuccess
+	result.Conclusion = model.StepStatusSuccess
+	return report
+}
+
+// reportValues interpolates the values of a map of the workflow and masks the secrets in them
+func (rc *RunContext) reportValues(ctx context.Context, exprEval ExpressionEvaluator, values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	interpolated := make(map[string]string, len(values))
+	for k, v := range values {
+		interpolated[k] = rc.maskValue(exprEval.Interpolate(ctx, v))
+	}
+	return interpolated
+}
+
+// maskValue replaces the secrets and the values matching --mask-regex with ***
+func (rc *RunContext) maskValue(value string) string {
+	if rc.Config.InsecureSecrets {
+		return value
+	}
+	for _, re := range rc.Config.MaskPatterns {
+		value = re.ReplaceAllLiteralString(value, "***")
+	}
+	for _, secret := range maskedSecrets(rc.Config) {
+		if secret != "" {
+			value = strings.ReplaceAll(value, secret, "***")
+		}
+	}
+	return value
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestNewPlanReport(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: report
+on: push
+env:
+  EVENT: ${{ github.event_name }}
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        node: [16, 18]
+    steps:
+      - id: hello
+        run: echo ${{ matrix.node }} ${{ secrets.TOKEN }}
+      - if: steps.hello.outcome == 'success'
+        uses: actions/checkout@v3
+        with:
+          ref: ${{ env.EVENT }}
+      - if: github.event_name == 'pull_request'
+        run: exit 1
+      - name: skipped
+        run: exit 1
+  deploy:
+    needs: build
+    if: needs.build.result == 'success'
+    runs-on: windows-latest
+    steps:
+      - run: exit 0
+`))
+	require.NoError(t, err)
+	workflow.File = "report.yml"
+
+	r, err := New(&Config{
+		Workdir:   t.TempDir(),
+		EventName: "push",
+		Platforms: map[string]string{"ubuntu-latest": "node:16-buster-slim"},
+		Secrets:   map[string]string{"TOKEN": "secret-token"},
+		SkipSteps: []string{"skipped"},
+	})
+	require.NoError(t, err)
+
+	plan := &model.Plan{Stages: []*model.Stage{
+		{Runs: []*model.Run{{JobID: "build", Workflow: workflow}}},
+		{Runs: []*model.Run{{JobID: "deploy", Workflow: workflow}}},
+	}}
+	report, err := r.NewPlanReport(context.Background(), plan)
+	require.NoError(t, err)
+	require.Len(t, report.Stages, 2)
+
+	builds := report.Stages[0].Jobs
+	require.Len(t, builds, 2)
+	assert.Equal(t, "build-2", builds[1].Name)
+	assert.Equal(t, map[string]interface{}{"node": 18}, builds[1].Matrix)
+
+	build := builds[0]
+	assert.True(t, build.Enabled)
+	assert.Equal(t, "node:16-buster-slim", build.Image)
+	assert.Equal(t, map[string]string{"EVENT": "push"}, build.Env)
+	require.Len(t, build.Steps, 4)
+	assert.True(t, build.Steps[0].Enabled)
+	assert.Equal(t, "echo 16 ***", build.Steps[0].Run)
+	assert.True(t, build.Steps[1].Enabled)
+	assert.Equal(t, map[string]string{"ref": "push"}, build.Steps[1].With)
+	assert.False(t, build.Steps[2].Enabled)
+	assert.Equal(t, "if-expression is false", build.Steps[2].Reason)
+	assert.False(t, build.Steps[3].Enabled)
+	assert.Equal(t, "--skip-step", build.Steps[3].Reason)
+
+	deploy := report.Stages[1].Jobs[0]
+	assert.False(t, deploy.Enabled)
+	assert.Equal(t, "unsupported platform [windows-latest], try -P", deploy.Reason)
+
+	// the plan can still run
+	assert.Empty(t, workflow.Jobs["build"].Result)
+	assert.Empty(t, workflow.Jobs["deploy"].Result)
+}
@@ -0,0 +1,237 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+// prefetchParallelism is the number of remote actions and of images fetched at the same time
+// before the jobs start
+const prefetchParallelism = 8
+
+// prefetched are the remote actions and images fetched before the jobs started, the jobs use them
+// without fetching them again
+type prefetched struct {
+	mu      sync.Mutex
+	actions map[string]bool // the directories of the actions in the action cache
+	images  map[string]bool
+	checked map[*model.Run]string // the job images checked before the jobs started
+}
+
+func newPrefetched() *prefetched {
+	return &prefetched{
+		actions: map[string]bool{},
+		images:  map[string]bool{},
+		checked: map[*model.Run]string{},
+	}
+}
+
+func (p *prefetched) hasAction(dir string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.actions[dir]
+}
+
+func (p *prefetched) hasImage(image string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.images[image]
+}
+
+// checkedImage reports whether the image was checked for the job of the run before the jobs started
+func (p *prefetched) checkedImage(run *model.Run, image string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.checked[run] == image
+}
+
+// prefetchImage is an image of the plan with the credentials to pull it
+type prefetchImage struct {
+	image    string
+	username string
+	password string
+}
+
+// prefetch fetches the remote actions and reusable workflows and pulls the images of the plan at
+// the same time before the jobs start, instead of one after the other when a job uses them. The
+// jobs fetch the ones which failed themselves, their errors are reported there.
+func (runner *runnerImpl) prefetch(plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		// the reusable workflows were prefetched with their caller
+		if !runner.config.Prefetch || runner.caller != nil || common.Dryrun(ctx) {
+			return nil
+		}
+		logger := common.Logger(ctx)
+		start := time.Now()
+
+		var mu sync.Mutex
+		actions, images := 0, 0
+		puller := &actionPuller{
+			runner:  runner,
+			pulled:  map[string]bool{},
+			fetches: make(chan struct{}, prefetchParallelism),
+			fetched: func(ctx context.Context, uses string, dir string) {
+				runner.prefetched.mu.Lock()
+				runner.prefetched.actions[dir] = true
+				runner.prefetched.mu.Unlock()
+				mu.Lock()
+				actions++
+				mu.Unlock()
+				logger.Infof("  ⬇  Fetched %s", uses)
+			},
+		}
+		pullActions := func(ctx context.Context) error {
+			if err := puller.pullPlan(ctx, plan); err != nil {
+				logger.Warnf("Unable to prefetch the actions, the jobs fetch them when they use them: %v", err)
+			}
+			return nil
+		}
+
+		planImages := runner.planImages(ctx, plan)
+		pulls := make([]common.Executor, 0, len(planImages))
+		for _, image := range planImages {
+			image := image
+			pulls = append(pulls, func(ctx context.Context) error {
+				err := container.NewDockerPullExecutor(container.NewDockerPullExecutorInput{
+					Image:     image.image,
+					ForcePull: runner.config.ForcePull,
+					Platform:  runner.config.ContainerArchitecture,
+					Username:  image.username,
+					Password:  image.password,
+				})(ctx)
+				if err != nil {
+					logger.Warnf("Unable to prefetch the image %s, the jobs pull it when they use it: %v", image.image, err)
+					return nil
+				}
+				runner.prefetched.mu.Lock()
+				runner.prefetched.images[image.image] = true
+				runner.prefetched.mu.Unlock()
+				mu.Lock()
+				images++
+				mu.Unlock()
+				logger.Infof("  ⬇  Pulled %s", image.image)
+				return nil
+			})
+		}
+		pullImages := common.NewParallelExecutor(prefetchParallelism, pulls...)
+
+		if err := common.NewParallelExecutor(2, pullActions, pullImages)(ctx); err != nil {
+			return err
+		}
+		if actions > 0 || images > 0 {
+			logger.Infof("⬇  Prefetched %d actions and %d images in %s", actions, images, time.Since(start).Round(time.Millisecond))
+		}
+		return nil
+	}
+}
+
+// planImages returns the images of the job containers, the services and the docker:// steps of the
+// plan. The images which depend on the matrix or on the outputs of other jobs are pulled by the jobs.
+func (runner *runnerImpl) planImages(ctx context.Context, plan *model.Plan) []prefetchImage {
+	seen := map[string]bool{}
+	images := make([]prefetchImage, 0)
+	add := func(image prefetchImage) {
+		if image.image == "" || seen[image.image] || strings.Contains(image.image, "://") {
+			return
+		}
+		seen[image.image] = true
+		images = append(images, image)
+	}
+
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			job := run.Job()
+			if job == nil || job.Type() != model.JobTypeDefault {
+				continue
+			}
+			rc := runner.newRunContext(ctx, run, nil)
+			if jobImage := planJobImage(ctx, rc); jobImage != "" {
+				image := prefetchImage{image: jobImage}
+				if username, password, err := rc.handleCredentials(ctx); err == nil {
+					image.username, image.password = username, password
+				}
+				add(image)
+			}
+			for _, service := range job.Services {
+				if service != nil && service.Credentials == nil && !strings.Contains(service.Image, "${{") {
+					add(prefetchImage{image: service.Image})
+				}
+			}
+			for _, step := range job.Steps {
+				if step != nil && step.Type() == model.StepTypeUsesDockerURL && !strings.Contains(step.Uses, "${{") {
+					add(prefetchImage{image: strings.TrimPrefix(step.Uses, "docker://")})
+				}
+			}
+		}
+	}
+	return images
+}
+
+// planJobImage returns the image of the job container of the run context, empty if the job runs on
+// the host or its image depends on the matrix or on the outputs of other jobs
+func planJobImage(ctx context.Context, rc *RunContext) string {
+	job := rc.Run.Job()
+	raw := strings.Join(job.RunsOn(), " ")
+	if c := job.Container(); c != nil {
+		raw = c.Image
+	}
+	if strings.Contains(raw, "${{") || rc.IsHostEnv(ctx) {
+		return ""
+	}
+	return rc.platformImage(ctx)
+}
+
+// checkPlanImages checks that the job images of the plan can run on the platform and have the
+// shells of the steps before the first job starts, rather than failing a job of a later stage. The
+// images which aren't pulled yet are checked by their jobs once they pulled them.
+func (runner *runnerImpl) checkPlanImages(plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		if runner.caller != nil || common.Dryrun(ctx) {
+			return nil
+		}
+		var failures []string
+		for _, stage := range plan.Stages {
+			for _, run := range stage.Runs {
+				job := run.Job()
+				if job == nil || job.Type() != model.JobTypeDefault {
+					continue
+				}
+				rc := runner.newRunContext(ctx, run, nil)
+				image := planJobImage(ctx, rc)
+				if image == "" {
+					continue
+				}
+				if exists, err := container.ImageExistsLocally(ctx, image, "any"); err != nil || !exists {
+					continue
+				}
+				if err := rc.checkJobImage(image)(ctx); err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %v", run, err))
+					continue
+				}
+				runner.prefetched.mu.Lock()
+				runner.prefetched.checked[run] = image
+				runner.prefetched.mu.Unlock()
+			}
+		}
+		if len(failures) > 0 {
+			return fmt.Errorf("the images of the jobs can't run them:\n%s", strings.Join(failures, "\n"))
+		}
+		return nil
+	}
+}
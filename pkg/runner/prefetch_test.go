@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunnerPrefetch(t *testing.T) {
+	workdir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	require.NoError(t, os.MkdirAll(filepath.Join(workdir, ".github", "workflows"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(workdir, ".github", "workflows", "push.yml"), []byte(`
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: org/composite@v1
+      - uses: org/node@v1
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: org/node@v1
+      - uses: org/other@v1
+`), 0o600))
+
+	var mu sync.Mutex
+	cloned := []string{}
+	origStepActionRemoteNewCloneExecutor := stepActionRemoteNewCloneExecutor
+	stepActionRemoteNewCloneExecutor = func(input git.NewGitCloneExecutorInput) common.Executor {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			cloned = append(cloned, fmt.Sprintf("%s@%s", input.URL, input.Ref))
+			mu.Unlock()
+			action := "runs:\n  using: node16\n  main: index.js\n"
+			if strings.HasSuffix(input.URL, "/composite") {
+				action = "runs:\n  using: composite\n  steps:\n    - uses: org/nested@v2\n"
+			}
+			if err := os.MkdirAll(input.Dir, 0o755); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(input.Dir, "action.yml"), []byte(action), 0o600)
+		}
+	}
+	defer (func() {
+		stepActionRemoteNewCloneExecutor = origStepActionRemoteNewCloneExecutor
+	})()
+
+	planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, ".github", "workflows"), true)
+	require.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	require.NoError(t, err)
+
+	r, err := New(&Config{
+		Workdir:        workdir,
+		GitHubInstance: "github.com",
+		Platforms:      map[string]string{"ubuntu-latest": "-self-hosted"},
+		Prefetch:       true,
+	})
+	require.NoError(t, err)
+	runner := r.(*runnerImpl)
+	require.NoError(t, runner.prefetch(plan)(context.Background()))
+
+	// every action is cloned once, also the ones both jobs use
+	sort.Strings(cloned)
+	assert.Equal(t, []string{
+		"https://github.com/org/composite@v1",
+		"https://github.com/org/nested@v2",
+		"https://github.com/org/node@v1",
+		"https://github.com/org/other@v1",
+	}, cloned)
+
+	assert.True(t, runner.prefetched.hasAction(filepath.Join(runner.config.ActionCacheDir(), "org-node@v1")))
+	assert.False(t, runner.prefetched.hasAction(filepath.Join(runner.config.ActionCacheDir(), "org-missing@v1")))
+	// the jobs run on the host, there is no image to pull
+	assert.Empty(t, runner.prefetched.images)
+}
+
+func TestPrefetchedNil(t *testing.T) {
+	var p *prefetched
+	assert.False(t, p.hasAction("dir"))
+	assert.False(t, p.hasImage("node:16"))
+	assert.False(t, p.checkedImage(&model.Run{}, "node:16"))
+}
+
+func TestPrefetchedCheckedImage(t *testing.T) {
+	p := newPrefetched()
+	run := &model.Run{JobID: "build"}
+	p.checked[run] = "node:16"
+	assert.True(t, p.checkedImage(run, "node:16"))
+	// a resumed job runs the image of its checkpoint, which wasn't checked
+	assert.False(t, p.checkedImage(run, "act-checkpoint-build"))
+	assert.False(t, p.checkedImage(&model.Run{JobID: "build"}, "node:16"))
+}
@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// prewarmPool creates the job containers of the legs of a matrix waiting for max-parallel ahead of
+// them, one after the other in the order the legs start. A leg starts in its running container
+// instead of pulling, creating and starting one. At most size prewarmed containers wait for their
+// legs at once, the ones of the legs which never started are removed with the pool.
+type prewarmPool struct {
+	slots  chan struct{}
+	mu     sync.Mutex
+	legs   map[*RunContext]*prewarmedContainer
+	cancel context.CancelFunc
+	done   chan struct{}
+	create func(rc *RunContext, ctx context.Context) (container.ExecutionsEnvironment, common.Executor)
+}
+
+// prewarmedContainer is the job container of a leg, nil until done is closed and if it couldn't be
+// prewarmed
+type prewarmedContainer struct {
+	done      chan struct{}
+	container container.ExecutionsEnvironment
+	remove    common.Executor
+}
+
+func newPrewarmPool(size int) *prewarmPool {
+	return &prewarmPool{
+		slots:  make(chan struct{}, size),
+		legs:   map[*RunContext]*prewarmedContainer{},
+		done:   make(chan struct{}),
+		create: (*RunContext).prewarmJobContainer,
+	}
+}
+
+// run prewarms the job containers of the waiting legs while the matrix runs
+func (p *prewarmPool) run(waiting []*RunContext, executor common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		prewarmCtx, cancel := context.WithCancel(ctx)
+		p.cancel = cancel
+		go p.prewarm(prewarmCtx, waiting)
+		defer p.close(ctx)
+		return executor(ctx)
+	}
+}
+
+func (p *prewarmPool) prewarm(ctx context.Context, waiting []*RunContext) {
+	defer close(p.done)
+	for _, rc := range waiting {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		p.mu.Lock()
+		if _, claimed := p.legs[rc]; claimed {
+			// the leg started before its turn came
+			p.mu.Unlock()
+			<-p.slots
+			continue
+		}
+		leg := &prewarmedContainer{done: make(chan struct{})}
+		p.legs[rc] = leg
+		p.mu.Unlock()
+
+		leg.container, leg.remove = p.create(rc, ctx)
+		if leg.container == nil {
+			<-p.slots
+		}
+		close(leg.done)
+	}
+}
+
+// claim returns the prewarmed job container of the leg, nil if it has none. A leg whose container
+// is being created waits for it, the container of a leg which started before is never created.
+func (p *prewarmPool) claim(rc *RunContext) container.ExecutionsEnvironment {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	leg, ok := p.legs[rc]
+	if !ok {
+		p.legs[rc] = &prewarmedContainer{}
+		p.mu.Unlock()
+		return nil
+	}
+	p.mu.Unlock()
+
+	<-leg.done
+	if leg.container != nil {
+		<-p.slots
+	}
+	return leg.container
+}
+
+// close stops prewarming and removes the containers no leg started in, e.g. of the legs skipped
+// after a leg failed fast. The legs remove the containers they started in like their own.
+func (p *prewarmPool) close(ctx context.Context) {
+	p.cancel()
+	<-p.done
+	ctx = common.WithoutCancel(ctx)
+	for rc, leg := range p.legs {
+		if leg.container == nil || rc.JobContainer == leg.container {
+			continue
+		}
+		if err := leg.remove.Finally(leg.container.Close())(ctx); err != nil {
+			common.Logger(ctx).Warnf("Unable to remove the prewarmed job container of %s: %v", rc.String(), err)
+		}
+	}
+}
+
+// prewarmJobContainer creates and starts the job container of the leg before it runs, nil if the
+// job container of the leg isn't created like the others, e.g. it runs on the host or resumes from
+// a checkpoint. The executor removes the container, its network and its volumes.
+func (rc *RunContext) prewarmJobContainer(ctx context.Context) (container.ExecutionsEnvironment, common.Executor) {
+	if rc.Config.ReuseContainers || rc.Config.Checkpoint || common.Dryrun(ctx) || rc.IsHostEnv(ctx) || rc.isMicroVM(ctx) {
+		return nil, nil
+	}
+	logger := common.Logger(ctx)
+	input, err := rc.jobContainerInput(ctx, rc.platformImage(ctx), io.Discard)
+	if err != nil {
+		logger.Debugf("Unable to prewarm the job container of %s: %v", rc.String(), err)
+		return nil, nil
+	}
+	_, runNetwork := rc.networkName()
+	c := container.NewContainer(input)
+	if c == nil {
+		return nil, nil
+	}
+	remove := c.Remove().
+		Then(container.NewDockerVolumeRemoveExecutor(input.Name, false)).
+		Then(container.NewDockerVolumeRemoveExecutor(input.Name+"-env", false))
+
+	logger.Debugf("Prewarming the job container %s", input.Name)
+	err = common.NewPipelineExecutor(
+		c.Pull(false),
+		// the containers of a previous run of the leg
+		remove,
+		rc.networks.create(rc.noNetwork()).IfBool(runNetwork),
+		c.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
+		c.Start(false),
+	)(ctx)
+	if err != nil {
+		logger.Debugf("Unable to prewarm the job container of %s: %v", rc.String(), err)
+		_ = remove(common.WithoutCancel(ctx))
+		return nil, nil
+	}
+	return c, remove
+}
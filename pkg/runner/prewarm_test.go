@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrewarmPool(t *testing.T) {
+	legs := []*RunContext{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	created := make(chan *RunContext, len(legs))
+	removed := make(chan *RunContext, len(legs))
+
+	pool := newPrewarmPool(1)
+	pool.create = func(rc *RunContext, ctx context.Context) (container.ExecutionsEnvironment, common.Executor) {
+		cm := &containerMock{}
+		cm.On("Close").Return(func(ctx context.Context) error { return nil })
+		created <- rc
+		return cm, func(ctx context.Context) error {
+			removed <- rc
+			return nil
+		}
+	}
+
+	err := pool.run(legs, func(ctx context.Context) error {
+		assert.Equal(t, legs[0], <-created)
+		legs[0].JobContainer = pool.claim(legs[0])
+		assert.NotNil(t, legs[0].JobContainer)
+
+		// the slot of a is free again, b waits for its leg in it
+		assert.Equal(t, legs[1], <-created)
+		// c starts before b took its container, it creates its own
+		assert.Nil(t, pool.claim(legs[2]))
+		return nil
+	})(context.Background())
+	assert.NoError(t, err)
+
+	// the container of b which never started is removed, the one of a is removed by its leg
+	close(removed)
+	var removedLegs []*RunContext
+	for rc := range removed {
+		removedLegs = append(removedLegs, rc)
+	}
+	assert.Equal(t, []*RunContext{legs[1]}, removedLegs)
+	assert.Len(t, created, 0)
+}
+
+func TestPrewarmJobContainerUnsupported(t *testing.T) {
+	for _, config := range []*Config{{ReuseContainers: true}, {Checkpoint: true}} {
+		rc := &RunContext{Config: config}
+		c, remove := rc.prewarmJobContainer(context.Background())
+		assert.Nil(t, c)
+		assert.Nil(t, remove)
+	}
+}
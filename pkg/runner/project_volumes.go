@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// The kinds of the project volumes
+const (
+	volumeWorkspace = "workspace"
+	volumeToolCache = "toolcache"
+	volumeCache     = "cache"
+)
+
+// projectVolume is an entry of the index of the project volumes
+type projectVolume struct {
+	Project  string    `json:"project"`
+	Kind     string    `json:"kind"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// projectVolumeName returns the name of the volume of the kind of the project of the run, the
+// workspace volumes are per job
+func (rc *RunContext) projectVolumeName(kind string) string {
+	if kind == volumeWorkspace {
+		return createContainerName("act", rc.Config.ProjectVolumes, kind, rc.String())
+	}
+	return createContainerName("act", rc.Config.ProjectVolumes, kind)
+}
+
+// projectVolumes returns the project volumes the job container mounts by name
+func (rc *RunContext) projectVolumes() map[string]string {
+	volumes := map[string]string{
+		rc.projectVolumeName(volumeCache): volumeCache,
+	}
+	if !rc.Config.BindWorkdir {
+		volumes[rc.projectVolumeName(volumeWorkspace)] = volumeWorkspace
+	}
+	if rc.Config.ToolCache == "" {
+		volumes[rc.projectVolumeName(volumeToolCache)] = volumeToolCache
+	}
+	return volumes
+}
+
+// clearProjectWorkspace empties the workspace volume of the job before the workdir is copied into
+// it, like actions/checkout cleans the workspace of a self-hosted runner. The files deleted from the
+// workdir since the last run would be left in the volume otherwise.
+func (rc *RunContext) clearProjectWorkspace() common.Executor {
+	return func(ctx context.Context) error {
+		if rc.Config.ProjectVolumes == "" || rc.Config.BindWorkdir {
+			return nil
+		}
+		dst := rc.JobContainer.ToContainerPath(rc.Config.Workdir)
+		common.Logger(ctx).Debugf("Clearing the workspace volume %s", rc.projectVolumeName(volumeWorkspace))
+		return rc.JobContainer.Exec([]string{"find", dst, "-mindepth", "1", "-delete"}, nil, "0", "")(ctx)
+	}
+}
+
+func volumeIndexFile(cacheDir string) string {
+	return filepath.Join(cacheDir, "volumes.json")
+}
+
+// updateVolumeIndex calls update with the index of the project volumes by name, locked against
+// the other act processes, and writes it back
+func updateVolumeIndex(ctx context.Context, cacheDir string, update func(index map[string]*projectVolume)) error {
+	unlock, err := common.LockFile(ctx, volumeIndexFile(cacheDir)+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	index := map[string]*projectVolume{}
+	content, err := os.ReadFile(volumeIndexFile(cacheDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	} else if err == nil {
+		if err := json.Unmarshal(content, &index); err != nil {
+			common.Logger(ctx).Warnf("Ignoring the broken index of the project volumes %s: %v", volumeIndexFile(cacheDir), err)
+		}
+	}
+	update(index)
+	if content, err = json.MarshalIndent(index, "", "  "); err != nil {
+		return err
+	}
+	return common.WriteFileAtomic(volumeIndexFile(cacheDir), content, 0o644)
+}
+
+// indexProjectVolumes records that the job uses its project volumes now
+func (rc *RunContext) indexProjectVolumes(ctx context.Context) error {
+	now := time.Now()
+	return updateVolumeIndex(ctx, rc.ActionCacheDir(), func(index map[string]*projectVolume) {
+		for name, kind := range rc.projectVolumes() {
+			if index[name] == nil {
+				index[name] = &projectVolume{Project: rc.Config.ProjectVolumes, Kind: kind, Created: now}
+			}
+			index[name].LastUsed = now
+		}
+	})
+}
+
+// removeExpiredVolumes removes the project volumes of all the projects which no run used for
+// longer than retention. The volumes remove fails for, e.g. because a kept container uses them,
+// stay in the index and are removed by a later run.
+func removeExpiredVolumes(ctx context.Context, cacheDir string, retention time.Duration, remove func(ctx context.Context, name string) error) error {
+	logger := common.Logger(ctx)
+	return updateVolumeIndex(ctx, cacheDir, func(index map[string]*projectVolume) {
+		names := make([]string, 0, len(index))
+		for name := range index {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			volume := index[name]
+			if time.Since(volume.LastUsed) <= retention {
+				continue
+			}
+			logger.Infof("Removing the %s volume %s of %s, unused since %s", volume.Kind, name, volume.Project, volume.LastUsed.Format(time.RFC3339))
+			if err := remove(ctx, name); err != nil {
+				logger.Warnf("Unable to remove the volume %s: %v", name, err)
+				continue
+			}
+			delete(index, name)
+		}
+	})
+}
+
+// removeDockerVolume removes the volume, if it still exists
+func removeDockerVolume(ctx context.Context, name string) error {
+	return container.NewDockerVolumeRemoveExecutor(name, false)(ctx)
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunContext_ProjectVolumes(t *testing.T) {
+	rc := &RunContext{
+		Name: "build",
+		Run:  &model.Run{Workflow: &model.Workflow{Name: "CI"}},
+		Config: &Config{
+			Workdir:        "/src/act",
+			ProjectVolumes: "nektos/act",
+		},
+	}
+	_, mounts := rc.GetBindsAndMounts()
+	assert.Equal(t, "/src/act", mounts[rc.projectVolumeName(volumeWorkspace)])
+	assert.Equal(t, "/opt/hostedtoolcache", mounts[rc.projectVolumeName(volumeToolCache)])
+	assert.Equal(t, "/toolcache", mounts[rc.projectVolumeName(volumeCache)])
+	assert.NotContains(t, mounts, "act-toolcache")
+	assert.NotContains(t, mounts, rc.jobContainerName())
+
+	// the volumes of another project or job are others
+	other := &RunContext{Name: "build", Run: rc.Run, Config: &Config{ProjectVolumes: "nektos/other"}}
+	assert.NotEqual(t, rc.projectVolumeName(volumeToolCache), other.projectVolumeName(volumeToolCache))
+	test := &RunContext{Name: "test", Run: rc.Run, Config: rc.Config}
+	assert.NotEqual(t, rc.projectVolumeName(volumeWorkspace), test.projectVolumeName(volumeWorkspace))
+	assert.Equal(t, rc.projectVolumeName(volumeCache), test.projectVolumeName(volumeCache))
+
+	rc.Config.ToolCache = "act-hostedtoolcache"
+	_, mounts = rc.GetBindsAndMounts()
+	assert.Equal(t, "/opt/hostedtoolcache", mounts["act-hostedtoolcache"])
+	assert.NotContains(t, mounts, rc.projectVolumeName(volumeToolCache))
+}
+
+func TestRunContext_VolumeLabels(t *testing.T) {
+	rc := &RunContext{
+		Name:   "build",
+		Run:    &model.Run{Workflow: &model.Workflow{Name: "CI"}},
+		Config: &Config{Workdir: "/src/act", ToolCache: "my-toolcache"},
+	}
+	_, mounts := rc.GetBindsAndMounts()
+	labels := rc.volumeLabels()
+	assert.Equal(t, container.ManagedRun, labels[rc.jobContainerName()])
+	assert.Equal(t, container.ManagedRun, labels[rc.jobContainerName()+"-env"])
+	assert.Equal(t, container.ManagedPersistent, labels["act-toolcache"])
+	assert.Contains(t, mounts, "my-toolcache")
+	assert.NotContains(t, labels, "my-toolcache", "the volumes named by the user aren't labeled")
+
+	rc.Config.ToolCache = ""
+	rc.Config.ProjectVolumes = "nektos/act"
+	_, mounts = rc.GetBindsAndMounts()
+	labels = rc.volumeLabels()
+	for name := range mounts {
+		if name != rc.jobContainerName()+"-env" {
+			assert.Equal(t, container.ManagedPersistent, labels[name], name)
+		}
+	}
+}
+
+func TestRunContext_ClearProjectWorkspace(t *testing.T) {
+	ctx := context.Background()
+	noop := func(ctx context.Context) error { return nil }
+	cm := &containerMock{}
+	cm.On("Exec", []string{"find", "/src/act", "-mindepth", "1", "-delete"}, map[string]string(nil), "0", "").Return(noop)
+	rc := &RunContext{
+		Name:         "build",
+		Run:          &model.Run{Workflow: &model.Workflow{Name: "CI"}},
+		Config:       &Config{Workdir: "/src/act", ProjectVolumes: "nektos/act"},
+		JobContainer: cm,
+	}
+	require.NoError(t, rc.clearProjectWorkspace()(ctx))
+	cm.AssertExpectations(t)
+
+	// the bound workdir and the workspaces removed after the job aren't cleared
+	cm = &containerMock{}
+	rc.JobContainer = cm
+	rc.Config.BindWorkdir = true
+	require.NoError(t, rc.clearProjectWorkspace()(ctx))
+	rc.Config = &Config{Workdir: "/src/act"}
+	require.NoError(t, rc.clearProjectWorkspace()(ctx))
+	cm.AssertNotCalled(t, "Exec")
+}
+
+func TestRemoveExpiredVolumes(t *testing.T) {
+	ctx := context.Background()
+	cacheDir := t.TempDir()
+	now := time.Now()
+	require.NoError(t, updateVolumeIndex(ctx, cacheDir, func(index map[string]*projectVolume) {
+		index["recent"] = &projectVolume{Project: "nektos/act", Kind: volumeCache, LastUsed: now.Add(-time.Hour)}
+		index["old"] = &projectVolume{Project: "nektos/act", Kind: volumeWorkspace, LastUsed: now.Add(-48 * time.Hour)}
+		index["in-use"] = &projectVolume{Project: "nektos/other", Kind: volumeToolCache, LastUsed: now.Add(-48 * time.Hour)}
+	}))
+
+	var removed []string
+	require.NoError(t, removeExpiredVolumes(ctx, cacheDir, 24*time.Hour, func(_ context.Context, name string) error {
+		if name == "in-use" {
+			return fmt.Errorf("volume is in use")
+		}
+		removed = append(removed, name)
+		return nil
+	}))
+	assert.Equal(t, []string{"old"}, removed)
+
+	content, err := os.ReadFile(volumeIndexFile(cacheDir))
+	require.NoError(t, err)
+	index := map[string]*projectVolume{}
+	require.NoError(t, json.Unmarshal(content, &index))
+	assert.Contains(t, index, "recent")
+	assert.Contains(t, index, "in-use", "the volumes which can't be removed stay in the index")
+	assert.NotContains(t, index, "old")
+}
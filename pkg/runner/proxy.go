@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+var proxyEnvNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"}
+
+// caCertificatesFile is the bundle of the additional CA certificates in the act directory
+const caCertificatesFile = "certs/ca-certificates.crt"
+
+// installs the CA certificates into the trust store of debian, alpine and redhat based images
+const caCertificatesScript = `if command -v update-ca-certificates >/dev/null 2>&1; then
+  mkdir -p /usr/local/share/ca-certificates
+  cp "$1" /usr/local/share/ca-certificates/act.crt
+  update-ca-certificates >/dev/null
+elif command -v update-ca-trust >/dev/null 2>&1; then
+  cp "$1" /etc/pki/ca-trust/source/anchors/act.crt
+  update-ca-trust extract
+else
+  echo "unable to install the CA certificates, neither update-ca-certificates nor update-ca-trust is available" >&2
+fi
+`
+
+// containerProxyEnv returns the proxy settings of act as environment of the containers
+func (rc *RunContext) containerProxyEnv() []string {
+	env := make([]string, 0)
+	if !rc.Config.ProxyEnv {
+		return env
+	}
+	for _, name := range proxyEnvNames {
+		for _, n := range []string{name, strings.ToLower(name)} {
+			if value, ok := os.LookupEnv(n); ok {
+				env = append(env, fmt.Sprintf("%s=%s", n, value))
+			}
+		}
+	}
+	return env
+}
+
+// caCertificatesEnv points node to the additional CA certificates, since it
+// doesn't use the trust store of the system
+func (rc *RunContext) caCertificatesEnv(actPath string) []string {
+	if len(rc.Config.CACertificates) == 0 {
+		return []string{}
+	}
+	return []string{fmt.Sprintf("NODE_EXTRA_CA_CERTS=%s", path.Join(actPath, caCertificatesFile))}
+}
+
+// readCACertificates concatenates the additional CA certificates to a bundle
+func readCACertificates(files []string) (string, error) {
+	bundle := &strings.Builder{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		if !strings.Contains(string(content), "-----BEGIN CERTIFICATE-----") {
+			return "", fmt.Errorf("CA certificate %s isn't PEM encoded", file)
+		}
+		bundle.Write(content)
+		if !strings.HasSuffix(string(content), "\n") {
+			bundle.WriteString("\n")
+		}
+	}
+	return bundle.String(), nil
+}
+
+// installCACertificates copies the additional CA certificates into the job container
+// and adds them to its trust store
+func (rc *RunContext) installCACertificates() common.Executor {
+	return func(ctx context.Context) error {
+		if len(rc.Config.CACertificates) == 0 {
+			return nil
+		}
+		bundle, err := readCACertificates(rc.Config.CACertificates)
+		if err != nil {
+			return err
+		}
+		actPath := rc.JobContainer.GetActPath()
+		return common.NewPipelineExecutor(
+			rc.JobContainer.Copy(actPath+"/", &container.FileEntry{
+				Name: caCertificatesFile,
+				Mode: 0o644,
+				Body: bundle,
+			}),
+			rc.JobContainer.Exec([]string{"sh", "-c", caCertificatesScript, "install", path.Join(actPath, caCertificatesFile)}, nil, "0", ""),
+		)(ctx)
+	}
+}
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerProxyEnv(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://proxy:3128")
+	t.Setenv("no_proxy", "localhost,.corp")
+
+	rc := &RunContext{Config: &Config{}}
+	assert.Empty(t, rc.containerProxyEnv())
+	assert.Empty(t, rc.caCertificatesEnv("/var/run/act"))
+
+	rc.Config = &Config{ProxyEnv: true, CACertificates: []string{"corp.pem"}}
+	env := rc.containerProxyEnv()
+	assert.Contains(t, env, "HTTPS_PROXY=http://proxy:3128")
+	assert.Contains(t, env, "no_proxy=localhost,.corp")
+	assert.Equal(t, []string{"NODE_EXTRA_CA_CERTS=/var/run/act/certs/ca-certificates.crt"}, rc.caCertificatesEnv("/var/run/act"))
+}
+
+func TestReadCACertificates(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.pem")
+	second := filepath.Join(dir, "second.pem")
+	invalid := filepath.Join(dir, "invalid.der")
+	assert.NoError(t, os.WriteFile(first, []byte("-----BEGIN CERTIFICATE-----\nfirst\n-----END CERTIFICATE-----"), 0o644))
+	assert.NoError(t, os.WriteFile(second, []byte("-----BEGIN CERTIFICATE-----\nsecond\n-----END CERTIFICATE-----\n"), 0o644))
+	assert.NoError(t, os.WriteFile(invalid, []byte{0x30, 0x82}, 0o644))
+
+	bundle, err := readCACertificates([]string{first, second})
+	assert.NoError(t, err)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----\nfirst\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\nsecond\n-----END CERTIFICATE-----\n", bundle)
+
+	_, err = readCACertificates([]string{invalid})
+	assert.EqualError(t, err, "CA certificate "+invalid+" isn't PEM encoded")
+	_, err = readCACertificates([]string{filepath.Join(dir, "missing.pem")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,243 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/model"
+)
+
+// actionPuller downloads the remote actions and reusable workflows of a plan into the action cache
+type actionPuller struct {
+	runner *runnerImpl
+	mu     sync.Mutex
+	pulled map[string]bool
+	audit  *actionAuditor // records the references for act audit
+
+	// fetches limits the clones running at the same time, the jobs, the steps and the composite
+	// actions are walked at the same time with it and one after the other without it
+	fetches chan struct{}
+	// fetched is called with each remote action and reusable workflow once it was fetched
+	fetched func(ctx context.Context, uses string, dir string)
+}
+
+// NewPullExecutor downloads the remote actions and reusable workflows of the plan into
+// the action cache, including the ones used by composite actions and reusable workflows
+func (runner *runnerImpl) NewPullExecutor(plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		puller := &actionPuller{
+			runner: runner,
+			pulled: map[string]bool{},
+		}
+		return puller.pullPlan(ctx, plan)
+	}
+}
+
+func (p *actionPuller) pullPlan(ctx context.Context, plan *model.Plan) error {
+	executors := make([]common.Executor, 0)
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			run := run
+			executors = append(executors, func(ctx context.Context) error {
+				return p.pullJob(ctx, p.runner.newRunContext(ctx, run, nil))
+			})
+		}
+	}
+	return p.each(ctx, executors)
+}
+
+// each runs the executors at the same time with fetches, otherwise one after the other until one
+// of them fails
+func (p *actionPuller) each(ctx context.Context, executors []common.Executor) error {
+	if p.fetches == nil {
+		return common.NewPipelineExecutor(executors...)(ctx)
+	}
+	return common.NewParallelExecutor(len(executors), executors...)(ctx)
+}
+
+// fetch clones the remote action or reusable workflow into the directory
+func (p *actionPuller) fetch(ctx context.Context, uses string, dir string, clone common.Executor) error {
+	if p.fetches != nil {
+		p.fetches <- struct{}{}
+		defer func() { <-p.fetches }()
+	}
+	if err := clone(ctx); err != nil {
+		return err
+	}
+	if p.fetched != nil {
+		p.fetched(ctx, uses, dir)
+	}
+	return nil
+}
+
+func (p *actionPuller) pullJob(ctx context.Context, rc *RunContext) error {
+	job := rc.Run.Job()
+	switch job.Type() {
+	case model.JobTypeReusableWorkflowLocal:
+		if p.visited(rc, job.Uses) {
+			return nil
+		}
+		return p.pullWorkflow(ctx, path.Join(rc.Config.Workdir, job.Uses))
+	case model.JobTypeReusableWorkflowRemote:
+		if p.audit != nil {
+			p.audit.use(rc, job.Uses, AuditTypeWorkflow)
+		}
+		if p.visited(rc, job.Uses) {
+			return nil
+		}
+		remoteReusableWorkflow := newRemoteReusableWorkflow(job.Uses)
+		if remoteReusableWorkflow == nil {
+			return fmt.Errorf("expected format {owner}/{repo}/.github/workflows/{filename}@{ref}. Actual '%s' Input string was not in a correct format", job.Uses)
+		}
+		filename := fmt.Sprintf("%s/%s@%s", remoteReusableWorkflow.Org, remoteReusableWorkflow.Repo, remoteReusableWorkflow.Ref)
+		workflowDir := fmt.Sprintf("%s/%s", rc.ActionCacheDir(), safeFilename(filename))
+		if p.audit != nil {
+			if !p.audit.fetch(ctx, rc, job.Uses, workflowDir, cloneIfRequired(rc, *remoteReusableWorkflow, workflowDir)) {
+				return nil
+			}
+		} else if err := p.fetch(ctx, job.Uses, workflowDir, cloneIfRequired(rc, *remoteReusableWorkflow, workflowDir)); err != nil {
+			return err
+		}
+		return p.pullWorkflow(ctx, path.Join(workflowDir, ".github", "workflows", remoteReusableWorkflow.Filename))
+	default:
+		return p.pullSteps(ctx, rc, job.Steps, "")
+	}
+}
+
+// visited marks the key as pulled and reports whether it was pulled before. act audit visits the
+// references again for every job to record the jobs using them, once per job so the reusable
+// workflows calling each other don't recurse forever.
+func (p *actionPuller) visited(rc *RunContext, key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.audit != nil {
+		key = rc.String() + "\n" + key
+	}
+	visited := p.pulled[key]
+	p.pulled[key] = true
+	return visited
+}
+
+// pullWorkflow pulls the jobs of a reusable workflow
+func (p *actionPuller) pullWorkflow(ctx context.Context, workflow string) error {
+	planner, err := model.NewStrictWorkflowPlanner(workflow, true, p.runner.config.StrictMode)
+	if err != nil {
+		return err
+	}
+	plan, err := planner.PlanEvent("workflow_call")
+	if err != nil {
+		return err
+	}
+	return p.pullPlan(ctx, plan)
+}
+
+// pullSteps pulls the actions used by the steps, compositeDir is the directory of the composite
+// action the steps are in
+func (p *actionPuller) pullSteps(ctx context.Context, rc *RunContext, steps []*model.Step, compositeDir string) error {
+	executors := make([]common.Executor, 0, len(steps))
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		step = rc.replaceAction(step)
+		if rc.skipStep(step) {
+			continue
+		}
+		step := step
+		executors = append(executors, func(ctx context.Context) error {
+			switch step.Type() {
+			case model.StepTypeUsesActionRemote:
+				if p.audit != nil {
+					p.audit.use(rc, step.Uses, AuditTypeAction)
+				}
+				if p.visited(rc, step.Uses) {
+					return nil
+				}
+				return p.pullRemoteAction(ctx, rc, step)
+			case model.StepTypeUsesActionLocal:
+				actionDir := localActionDir(rc.Config.Workdir, compositeDir, step)
+				if p.visited(rc, actionDir) {
+					return nil
+				}
+				return p.pullCompositeSteps(ctx, rc, step, actionDir, "")
+			case model.StepTypeUsesDockerURL:
+				if p.audit != nil {
+					p.audit.use(rc, step.Uses, AuditTypeDocker)
+				}
+			}
+			return nil
+		})
+	}
+	return p.each(ctx, executors)
+}
+
+func (p *actionPuller) pullRemoteAction(ctx context.Context, rc *RunContext, step *model.Step) error {
+	remoteAction := newRemoteAction(step.Uses)
+	if remoteAction == nil {
+		return fmt.Errorf("Expected format {org}/{repo}[/path]@ref. Actual '%s' Input string was not in a correct format", step.Uses)
+	}
+
+	github := rc.getGithubContext(ctx)
+	token, err := rc.actionSource(github, remoteAction)
+	if err != nil {
+		return err
+	}
+	github.Token = token
+
+	actionDir := fmt.Sprintf("%s/%s", rc.ActionCacheDir(), safeFilename(step.Uses))
+	clone := func(ctx context.Context) error {
+		err := stepActionRemoteNewCloneExecutor(git.NewGitCloneExecutorInput{
+			URL:   remoteAction.CloneURL(),
+			Ref:   remoteAction.Ref,
+			Dir:   actionDir,
+			Token: github.Token,
+		})(ctx)
+		if errors.Is(err, git.ErrShortRef) {
+			return fmt.Errorf("Unable to resolve action `%s`, the provided ref `%s` is the shortened version of a commit SHA, which is not supported. Please use the full commit SHA `%s` instead",
+				step.Uses, remoteAction.Ref, err.(*git.Error).Commit())
+		} else if err != nil && !errors.Is(err, gogit.ErrForceNeeded) {
+			return err
+		}
+		return nil
+	}
+	if p.audit != nil {
+		if !p.audit.fetch(ctx, rc, step.Uses, actionDir, clone) {
+			return nil
+		}
+	} else if err := p.fetch(ctx, step.Uses, actionDir, clone); err != nil {
+		return err
+	}
+
+	return p.pullCompositeSteps(ctx, rc, step, actionDir, remoteAction.Path)
+}
+
+// pullCompositeSteps pulls the actions used by the steps of a composite action
+func (p *actionPuller) pullCompositeSteps(ctx context.Context, rc *RunContext, step *model.Step, actionDir string, actionPath string) error {
+	reader := func(filename string) (io.Reader, io.Closer, error) {
+		f, err := os.Open(filepath.Join(actionDir, actionPath, filename))
+		return f, f, err
+	}
+	action, err := readActionImpl(ctx, step, actionDir, actionPath, reader, func(string, []byte, fs.FileMode) error { return nil })
+	if err != nil {
+		return err
+	}
+	if action.Runs.Using != model.ActionRunsUsingComposite {
+		return nil
+	}
+	steps := make([]*model.Step, 0, len(action.Runs.Steps))
+	for i := range action.Runs.Steps {
+		steps = append(steps, &action.Runs.Steps[i])
+	}
+	return p.pullSteps(ctx, rc, steps, filepath.Join(actionDir, actionPath))
+}
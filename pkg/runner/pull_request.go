@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+)
+
+// PullRequest is the pull request of the payload act builds for the pull_request events when
+// there is no --eventpath
+type PullRequest struct {
+	Number int
+	Base   string // the branch the pull request merges into, the default branch if empty
+	Head   string // the branch of the pull request, the current branch if empty
+	Draft  bool
+	Labels []string
+}
+
+// pullRequestEvent returns the payload of the opening of the pull request, the shas of its head and
+// base are the ones of the local branches, or of the branches of origin if they aren't local
+func pullRequestEvent(ctx context.Context, config *Config) map[string]interface{} {
+	logger := common.Logger(ctx)
+	pr := config.PullRequest
+
+	headSha := ""
+	head := pr.Head
+	if head == "" {
+		ref, err := git.FindGitRef(ctx, config.Workdir)
+		if err != nil {
+			logger.Warnf("unable to find the current branch for the head of the pull request: %v", err)
+		}
+		head = strings.TrimPrefix(ref, "refs/heads/")
+		if _, sha, err := git.FindGitRevision(ctx, config.Workdir); err == nil {
+			headSha = sha
+		}
+	} else {
+		headSha = resolveBranch(ctx, config.Workdir, head)
+	}
+
+	remoteName := config.RemoteName
+	if remoteName == "" {
+		remoteName = "origin"
+	}
+	defaultBranch := config.DefaultBranch
+	if defaultBranch == "" {
+		defaultBranch = git.FindDefaultBranch(config.Workdir, remoteName)
+	}
+	base := pr.Base
+	if base == "" {
+		base = defaultBranch
+	}
+	if base == "" {
+		// the default branch is main or master, whichever the repository has
+		base = "main"
+		if resolveBranch(ctx, config.Workdir, base) == "" && resolveBranch(ctx, config.Workdir, "master") != "" {
+			base = "master"
+		}
+	}
+	baseSha := resolveBranch(ctx, config.Workdir, base)
+	if baseSha == "" {
+		logger.Warnf("unable to find the branch %s for the base of the pull request", base)
+	}
+
+	repoName := config.Env["GITHUB_REPOSITORY"]
+	if repoName == "" {
+		var err error
+		if repoName, err = git.FindGithubRepo(ctx, config.Workdir, githubInstanceHost(config.GitHubInstance), config.RemoteName); err != nil {
+			logger.Debugf("unable to find the repository of the pull request: %v", err)
+		}
+	}
+	owner, name, _ := strings.Cut(repoName, "/")
+	actor := config.Actor
+	if actor == "" {
+		actor = "nektos/act"
+	}
+	repository := map[string]interface{}{
+		"full_name": repoName,
+		"name":      name,
+		"owner":     map[string]interface{}{"login": owner},
+		"html_url":  fmt.Sprintf("%s/%s", GitHubInstanceURL(config.GitHubInstance), repoName),
+	}
+	// the base of the pull request isn't necessarily the default branch, which is left out if it's unknown
+	if defaultBranch != "" {
+		repository["default_branch"] = defaultBranch
+	}
+	branch := func(ref, sha string) map[string]interface{} {
+		return map[string]interface{}{
+			"ref":   ref,
+			"sha":   sha,
+			"label": fmt.Sprintf("%s:%s", owner, ref),
+			"repo":  repository,
+			"user":  map[string]interface{}{"login": owner},
+		}
+	}
+
+	labels := make([]interface{}, 0, len(pr.Labels))
+	for _, label := range pr.Labels {
+		labels = append(labels, map[string]interface{}{"name": label})
+	}
+	number := pr.Number
+	if number == 0 {
+		number = 1
+	}
+	return map[string]interface{}{
+		"action": "opened",
+		"number": number,
+		"pull_request": map[string]interface{}{
+			"number":   number,
+			"state":    "open",
+			"title":    head,
+			"draft":    pr.Draft,
+			"merged":   false,
+			"labels":   labels,
+			"head":     branch(head, headSha),
+			"base":     branch(base, baseSha),
+			"user":     map[string]interface{}{"login": actor},
+			"html_url": fmt.Sprintf("%s/%s/pull/%d", GitHubInstanceURL(config.GitHubInstance), repoName, number),
+		},
+		"repository": repository,
+		"sender":     map[string]interface{}{"login": actor},
+	}
+}
+
+// resolveBranch returns the sha of the local branch or of the branch of origin, an empty string if
+// the repository has neither
+func resolveBranch(ctx context.Context, dir, branch string) string {
+	for _, rev := range []string{branch, "origin/" + branch} {
+		if sha, err := git.ResolveRevision(dir, rev); err == nil {
+			return sha
+		}
+	}
+	common.Logger(ctx).Debugf("unable to resolve the branch %s", branch)
+	return ""
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullRequestEvent(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	commit := func(name string) string {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600))
+		_, err := wt.Add(name)
+		require.NoError(t, err)
+		hash, err := wt.Commit(name, &git.CommitOptions{
+			Author: &object.Signature{Name: "act", Email: "act@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash.String()
+	}
+	baseSha := commit("base")
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}))
+	headSha := commit("feature")
+
+	config := &Config{
+		Workdir:     dir,
+		Actor:       "octocat",
+		Env:         map[string]string{"GITHUB_REPOSITORY": "nektos/act"},
+		PullRequest: &PullRequest{Number: 5, Draft: true, Labels: []string{"bug"}},
+	}
+	event := pullRequestEvent(context.Background(), config)
+
+	assert.Equal(t, "opened", event["action"])
+	assert.Equal(t, 5, event["number"])
+	pr := event["pull_request"].(map[string]interface{})
+	assert.Equal(t, true, pr["draft"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "bug"}}, pr["labels"])
+	head := pr["head"].(map[string]interface{})
+	assert.Equal(t, "feature", head["ref"])
+	assert.Equal(t, headSha, head["sha"])
+	assert.Equal(t, "nektos:feature", head["label"])
+	// go-git initializes the repository with master
+	base := pr["base"].(map[string]interface{})
+	assert.Equal(t, "master", base["ref"])
+	assert.Equal(t, baseSha, base["sha"])
+	assert.Equal(t, "nektos/act", event["repository"].(map[string]interface{})["full_name"])
+	assert.NotContains(t, event["repository"], "default_branch")
+	assert.Equal(t, "octocat", event["sender"].(map[string]interface{})["login"])
+
+	config.PullRequest = &PullRequest{Base: "feature", Head: "master"}
+	event = pullRequestEvent(context.Background(), config)
+	assert.Equal(t, 1, event["number"])
+	pr = event["pull_request"].(map[string]interface{})
+	assert.Equal(t, baseSha, pr["head"].(map[string]interface{})["sha"])
+	assert.Equal(t, headSha, pr["base"].(map[string]interface{})["sha"])
+	assert.NotContains(t, event["repository"], "default_branch")
+
+	// the default branch of the remote is the base
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/remotes/origin/feature", plumbing.NewHash(headSha))))
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.NewRemoteHEADReferenceName("origin"), "refs/remotes/origin/feature")))
+	config.PullRequest = &PullRequest{Head: "master"}
+	event = pullRequestEvent(context.Background(), config)
+	pr = event["pull_request"].(map[string]interface{})
+	assert.Equal(t, "feature", pr["base"].(map[string]interface{})["ref"])
+	assert.Equal(t, "feature", event["repository"].(map[string]interface{})["default_branch"])
+
+	config.DefaultBranch = "trunk"
+	config.PullRequest = &PullRequest{Base: "master", Head: "feature"}
+	event = pullRequestEvent(context.Background(), config)
+	assert.Equal(t, "trunk", event["repository"].(map[string]interface{})["default_branch"])
+}
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunnerPullExecutor(t *testing.T) {
+	workdir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	writeFile := func(name string, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+	writeFile(filepath.Join(workdir, ".github", "workflows", "push.yml"), `
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: org/composite@v1
+      - uses: ./local-action
+      - uses: docker://alpine:3
+      - run: echo
+  call:
+    uses: org/workflows/.github/workflows/reusable.yml@v1
+`)
+	writeFile(filepath.Join(workdir, "local-action", "action.yml"), `
+runs:
+  using: composite
+  steps:
+    - uses: org/from-local@v1
+    - uses: ./../shared-action
+    - uses: ${{ github.action_path }}/nested
+`)
+	// the actions next to a composite action are relative to its directory
+	writeFile(filepath.Join(workdir, "shared-action", "action.yml"), `
+runs:
+  using: composite
+  steps:
+    - uses: org/from-shared@v1
+`)
+	writeFile(filepath.Join(workdir, "local-action", "nested", "action.yml"), `
+runs:
+  using: composite
+  steps:
+    - uses: org/from-nested@v1
+`)
+	// reusable workflows already in the cache aren't cloned again
+	writeFile(filepath.Join(cacheDir, "act", "org-workflows@v1", ".github", "workflows", "reusable.yml"), `
+on: workflow_call
+jobs:
+  reused:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: org/from-workflow@v1
+      - uses: org/composite@v1
+`)
+
+	cloned := []string{}
+	origStepActionRemoteNewCloneExecutor := stepActionRemoteNewCloneExecutor
+	stepActionRemoteNewCloneExecutor = func(input git.NewGitCloneExecutorInput) common.Executor {
+		return func(ctx context.Context) error {
+			cloned = append(cloned, fmt.Sprintf("%s@%s", input.URL, input.Ref))
+			action := "runs:\n  using: node16\n  main: index.js\n"
+			if strings.HasSuffix(input.URL, "/composite") {
+				action = "runs:\n  using: composite\n  steps:\n    - uses: org/nested@v2\n"
+			}
+			writeFile(filepath.Join(input.Dir, "action.yml"), action)
+			return nil
+		}
+	}
+	defer (func() {
+		stepActionRemoteNewCloneExecutor = origStepActionRemoteNewCloneExecutor
+	})()
+
+	planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, ".github", "workflows"), true)
+	require.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	require.NoError(t, err)
+
+	r, err := New(&Config{
+		Workdir:        workdir,
+		GitHubInstance: "github.com",
+	})
+	require.NoError(t, err)
+	require.NoError(t, r.NewPullExecutor(plan)(context.Background()))
+
+	sort.Strings(cloned)
+	assert.Equal(t, []string{
+		"https://github.com/org/composite@v1",
+		"https://github.com/org/from-local@v1",
+		"https://github.com/org/from-nested@v1",
+		"https://github.com/org/from-shared@v1",
+		"https://github.com/org/from-workflow@v1",
+		"https://github.com/org/nested@v2",
+	}, cloned)
+}
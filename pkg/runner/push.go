@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+)
+
+// pushEvent returns the payload of the push of the commits of PushRange, before..after, to the
+// current branch
+func pushEvent(ctx context.Context, config *Config) (map[string]interface{}, error) {
+	before, after, ok := strings.Cut(config.PushRange, "..")
+	if !ok || before == "" || after == "" || strings.HasPrefix(after, ".") {
+		return nil, fmt.Errorf("invalid push range %q, expected before..after", config.PushRange)
+	}
+	push, err := git.FindPush(ctx, config.Workdir, before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := git.FindGitRef(ctx, config.Workdir)
+	if err != nil {
+		common.Logger(ctx).Warnf("unable to find the current branch for the ref of the push: %v", err)
+	}
+	repoName := config.Env["GITHUB_REPOSITORY"]
+	if repoName == "" {
+		if repoName, err = git.FindGithubRepo(ctx, config.Workdir, githubInstanceHost(config.GitHubInstance), config.RemoteName); err != nil {
+			common.Logger(ctx).Debugf("unable to find the repository of the push: %v", err)
+		}
+	}
+	owner, name, _ := strings.Cut(repoName, "/")
+	repoURL := fmt.Sprintf("%s/%s", GitHubInstanceURL(config.GitHubInstance), repoName)
+	actor := config.Actor
+	if actor == "" {
+		actor = "nektos/act"
+	}
+
+	commit := func(c git.Commit) map[string]interface{} {
+		return map[string]interface{}{
+			"id":        c.ID,
+			"tree_id":   c.TreeID,
+			"distinct":  true,
+			"message":   strings.TrimSuffix(c.Message, "\n"),
+			"timestamp": c.Timestamp.Format(time.RFC3339),
+			"url":       fmt.Sprintf("%s/commit/%s", repoURL, c.ID),
+			"author":    map[string]interface{}{"name": c.AuthorName, "email": c.AuthorEmail},
+			"committer": map[string]interface{}{"name": c.CommitterName, "email": c.CommitterEmail},
+			"added":     c.Added,
+			"removed":   c.Removed,
+			"modified":  c.Modified,
+		}
+	}
+	commits := make([]interface{}, 0, len(push.Commits))
+	for _, c := range push.Commits {
+		commits = append(commits, commit(c))
+	}
+
+	return map[string]interface{}{
+		"ref":         ref,
+		"before":      push.Before,
+		"after":       push.After,
+		"created":     false,
+		"deleted":     false,
+		"forced":      push.Forced,
+		"base_ref":    nil,
+		"compare":     fmt.Sprintf("%s/compare/%s...%s", repoURL, push.Before[:12], push.After[:12]),
+		"commits":     commits,
+		"head_commit": commit(push.Head),
+		"repository": map[string]interface{}{
+			"full_name":      repoName,
+			"name":           name,
+			"owner":          map[string]interface{}{"login": owner, "name": owner},
+			"default_branch": config.DefaultBranch,
+			"html_url":       repoURL,
+		},
+		"pusher": map[string]interface{}{"name": actor},
+		"sender": map[string]interface{}{"login": actor},
+	}, nil
+}
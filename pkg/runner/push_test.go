@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushEvent(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	commit := func(name, message string) string {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600))
+		_, err := wt.Add(name)
+		require.NoError(t, err)
+		hash, err := wt.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{Name: "Mona", Email: "mona@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash.String()
+	}
+	before := commit("README.md", "first")
+	commit("a.txt", "feat: a\n")
+	after := commit("b.txt", "fix: b\n")
+
+	config := &Config{
+		Workdir:   dir,
+		Actor:     "octocat",
+		Env:       map[string]string{"GITHUB_REPOSITORY": "nektos/act"},
+		PushRange: before[:7] + "..HEAD",
+	}
+	event, err := pushEvent(context.Background(), config)
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/master", event["ref"])
+	assert.Equal(t, before, event["before"])
+	assert.Equal(t, after, event["after"])
+	assert.Equal(t, false, event["forced"])
+	commits := event["commits"].([]interface{})
+	require.Len(t, commits, 2)
+	assert.Equal(t, "feat: a", commits[0].(map[string]interface{})["message"])
+	assert.Equal(t, []string{"a.txt"}, commits[0].(map[string]interface{})["added"])
+	assert.Equal(t, "Mona", commits[1].(map[string]interface{})["author"].(map[string]interface{})["name"])
+	assert.Equal(t, after, event["head_commit"].(map[string]interface{})["id"])
+
+	config.PushRange = "HEAD.." + before
+	event, err = pushEvent(context.Background(), config)
+	require.NoError(t, err)
+	assert.Equal(t, true, event["forced"])
+	assert.Empty(t, event["commits"])
+
+	config.PushRange = before
+	_, err = pushEvent(context.Background(), config)
+	assert.ErrorContains(t, err, "expected before..after")
+}
@@ -0,0 +1,206 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// resourceSampleInterval is how often the resource usage of the job container is sampled while a step runs
+var resourceSampleInterval = time.Second
+
+// oomWatchThreshold is the share of the memory limit above which --oom-watch warns about a step
+const oomWatchThreshold = 0.9
+
+// ResourceUsage is the peak memory and the CPU time of a step in the job container
+type ResourceUsage struct {
+	Job         string
+	Step        string
+	PeakMemory  uint64
+	MemoryLimit uint64
+	CPUTime     time.Duration
+}
+
+// ResourceUsages records the resource usage of the steps of a run, it is safe for the parallel jobs
+type ResourceUsages struct {
+	mu     sync.Mutex
+	usages []ResourceUsage
+}
+
+func (r *ResourceUsages) add(usage ResourceUsage) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usages = append(r.usages, usage)
+}
+
+// Usages returns the usages of the steps by job, in the order the steps ran, followed by the
+// usage of each job: the peak memory and the total CPU time of its steps
+func (r *ResourceUsages) Usages() []ResourceUsage {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	steps := append([]ResourceUsage{}, r.usages...)
+	r.mu.Unlock()
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Job < steps[j].Job })
+
+	usages := []ResourceUsage{}
+	for i := 0; i < len(steps); {
+		job := ResourceUsage{Job: steps[i].Job}
+		for ; i < len(steps) && steps[i].Job == job.Job; i++ {
+			usages = append(usages, steps[i])
+			if steps[i].PeakMemory > job.PeakMemory {
+				job.PeakMemory = steps[i].PeakMemory
+			}
+			if steps[i].MemoryLimit > job.MemoryLimit {
+				job.MemoryLimit = steps[i].MemoryLimit
+			}
+			job.CPUTime += steps[i].CPUTime
+		}
+		usages = append(usages, job)
+	}
+	return usages
+}
+
+// WriteTable writes the usages as a table, the rows of the jobs have no step
+func (r *ResourceUsages) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSTEP\tPEAK MEMORY\tCPU TIME")
+	for _, usage := range r.Usages() {
+		step := usage.Step
+		if step == "" {
+			step = "(job)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", usage.Job, step, formatBytes(usage.PeakMemory), usage.CPUTime.Round(time.Millisecond))
+	}
+	return tw.Flush()
+}
+
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes) / unit
+	for _, prefix := range []string{"KiB", "MiB", "GiB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, prefix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TiB", value)
+}
+
+// resourceMonitor samples the resource usage of the job container while a step runs
+type resourceMonitor struct {
+	container container.StatsContainer
+	step      string
+	oomWatch  bool
+
+	mu       sync.Mutex
+	samples  int
+	startCPU time.Duration
+	usage    ResourceUsage
+	warned   bool
+}
+
+func (m *resourceMonitor) sample(ctx context.Context) error {
+	stats, err := m.container.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.samples == 0 {
+		m.startCPU = stats.CPUTime
+	}
+	m.samples++
+	if stats.Memory > m.usage.PeakMemory {
+		m.usage.PeakMemory = stats.Memory
+	}
+	m.usage.MemoryLimit = stats.MemoryLimit
+	if stats.CPUTime > m.startCPU {
+		m.usage.CPUTime = stats.CPUTime - m.startCPU
+	}
+
+	if m.oomWatch && !m.warned && m.nearLimit() {
+		m.warned = true
+		common.Logger(ctx).Warnf("\u26A0  %s uses %s of the memory limit of %s of the job container, it may run out of memory",
+			m.step, formatBytes(stats.Memory), formatBytes(stats.MemoryLimit))
+	}
+	return nil
+}
+
+func (m *resourceMonitor) nearLimit() bool {
+	return m.usage.MemoryLimit > 0 && float64(m.usage.PeakMemory) >= oomWatchThreshold*float64(m.usage.MemoryLimit)
+}
+
+// monitorResources samples the resource usage of the job container while executor runs and
+// records the peak memory and the CPU time of the step. Only the job container is sampled, the
+// containers of docker actions and services aren't.
+func (rc *RunContext) monitorResources(step string, executor common.Executor) common.Executor {
+	if rc.Config == nil || rc.Config.ResourceUsages == nil && !rc.Config.OOMWatch {
+		return executor
+	}
+	return func(ctx context.Context) error {
+		stats, ok := rc.JobContainer.(container.StatsContainer)
+		if !ok {
+			common.Logger(ctx).Debugf("The resource usage of the job container isn't available")
+			return executor(ctx)
+		}
+		logger := common.Logger(ctx)
+		m := &resourceMonitor{container: stats, step: step, oomWatch: rc.Config.OOMWatch}
+		if err := m.sample(ctx); err != nil {
+			logger.Debugf("Unable to sample the resource usage of the job container: %v", err)
+			return executor(ctx)
+		}
+
+		done := make(chan struct{})
+		sampled := make(chan struct{})
+		go func() {
+			defer close(sampled)
+			ticker := time.NewTicker(resourceSampleInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if err := m.sample(ctx); err != nil {
+						logger.Debugf("Unable to sample the resource usage of the job container: %v", err)
+						return
+					}
+				}
+			}
+		}()
+
+		err := executor(ctx)
+		close(done)
+		<-sampled
+		if serr := m.sample(ctx); serr != nil {
+			logger.Debugf("Unable to sample the resource usage of the job container: %v", serr)
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.usage.Job = rc.String()
+		m.usage.Step = step
+		rc.Config.ResourceUsages.add(m.usage)
+		if err != nil && rc.Config.OOMWatch && m.nearLimit() {
+			logger.Warnf("\u26A0  %s failed with %s of the memory limit of %s in use, it probably ran out of memory",
+				step, formatBytes(m.usage.PeakMemory), formatBytes(m.usage.MemoryLimit))
+		}
+		return err
+	}
+}
@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+type statsContainerMock struct {
+	containerMock
+	mu    sync.Mutex
+	stats []container.ResourceStats
+}
+
+// Stats returns the stats one after the other, the last one again when they run out
+func (s *statsContainerMock) Stats(ctx context.Context) (*container.ResourceStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats[0]
+	if len(s.stats) > 1 {
+		s.stats = s.stats[1:]
+	}
+	return &stats, nil
+}
+
+// sampled waits until all but the last stats were returned
+func (s *statsContainerMock) sampled() {
+	for {
+		s.mu.Lock()
+		n := len(s.stats)
+		s.mu.Unlock()
+		if n <= 1 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMonitorResources(t *testing.T) {
+	defer func(interval time.Duration) { resourceSampleInterval = interval }(resourceSampleInterval)
+	resourceSampleInterval = time.Millisecond
+
+	const mib = 1024 * 1024
+	for _, tt := range []struct {
+		name     string
+		oomWatch bool
+		err      error
+		stats    []container.ResourceStats
+		expected ResourceUsage
+		warnings []string
+	}{
+		{
+			name: "peak memory and CPU time",
+			stats: []container.ResourceStats{
+				{Memory: 100 * mib, MemoryLimit: 1000 * mib, CPUTime: 2 * time.Second},
+				{Memory: 500 * mib, MemoryLimit: 1000 * mib, CPUTime: 3 * time.Second},
+				{Memory: 200 * mib, MemoryLimit: 1000 * mib, CPUTime: 5 * time.Second},
+			},
+			expected: ResourceUsage{PeakMemory: 500 * mib, MemoryLimit: 1000 * mib, CPUTime: 3 * time.Second},
+		},
+		{
+			name:     "oom watch",
+			oomWatch: true,
+			stats: []container.ResourceStats{
+				{Memory: 100 * mib, MemoryLimit: 1000 * mib},
+				{Memory: 950 * mib, MemoryLimit: 1000 * mib},
+			},
+			expected: ResourceUsage{PeakMemory: 950 * mib, MemoryLimit: 1000 * mib},
+			warnings: []string{"⚠  Main make uses 950.0 MiB of the memory limit of 1000.0 MiB of the job container, it may run out of memory"},
+		},
+		{
+			name:     "oom watch failure",
+			oomWatch: true,
+			err:      errors.New("exit code 137"),
+			stats: []container.ResourceStats{
+				{Memory: 100 * mib, MemoryLimit: 1000 * mib},
+				{Memory: 990 * mib, MemoryLimit: 1000 * mib},
+			},
+			expected: ResourceUsage{PeakMemory: 990 * mib, MemoryLimit: 1000 * mib},
+			warnings: []string{
+				"⚠  Main make uses 990.0 MiB of the memory limit of 1000.0 MiB of the job container, it may run out of memory",
+				"⚠  Main make failed with 990.0 MiB of the memory limit of 1000.0 MiB in use, it probably ran out of memory",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, hook := test.NewNullLogger()
+			ctx := common.WithLogger(context.Background(), logger)
+
+			stats := &statsContainerMock{stats: tt.stats}
+			rc := &RunContext{
+				Name:         "test",
+				Run:          &model.Run{Workflow: &model.Workflow{Name: "CI"}},
+				Config:       &Config{ResourceUsages: &ResourceUsages{}, OOMWatch: tt.oomWatch},
+				JobContainer: stats,
+			}
+			err := rc.monitorResources("Main make", func(ctx context.Context) error {
+				stats.sampled()
+				return tt.err
+			})(ctx)
+			assert.Equal(t, tt.err, err)
+
+			tt.expected.Job = "CI/test"
+			tt.expected.Step = "Main make"
+			assert.Equal(t, []ResourceUsage{tt.expected, {
+				Job:         "CI/test",
+				PeakMemory:  tt.expected.PeakMemory,
+				MemoryLimit: tt.expected.MemoryLimit,
+				CPUTime:     tt.expected.CPUTime,
+			}}, rc.Config.ResourceUsages.Usages())
+
+			warnings := []string{}
+			for _, entry := range hook.AllEntries() {
+				warnings = append(warnings, entry.Message)
+			}
+			assert.Equal(t, len(tt.warnings), len(warnings), warnings)
+			for i := range tt.warnings {
+				assert.Equal(t, tt.warnings[i], warnings[i])
+			}
+		})
+	}
+}
+
+func TestMonitorResourcesDisabled(t *testing.T) {
+	rc := &RunContext{Config: &Config{}, JobContainer: &statsContainerMock{}}
+	called := false
+	assert.NoError(t, rc.monitorResources("Main make", func(ctx context.Context) error {
+		called = true
+		return nil
+	})(context.Background()))
+	assert.True(t, called)
+}
+
+func TestResourceUsagesWriteTable(t *testing.T) {
+	usages := &ResourceUsages{usages: []ResourceUsage{
+		{Job: "CI/test", Step: "Main make", PeakMemory: 512 * 1024 * 1024, CPUTime: 2 * time.Second},
+		{Job: "CI/build", Step: "Main go build", PeakMemory: 3 * 1024 * 1024 * 1024, CPUTime: 90 * time.Second},
+		{Job: "CI/test", Step: "Main make lint", PeakMemory: 100 * 1024, CPUTime: 1500 * time.Millisecond},
+	}}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, usages.WriteTable(buf))
+	assert.Equal(t, [][]string{
+		{"JOB", "STEP", "PEAK", "MEMORY", "CPU", "TIME"},
+		{"CI/build", "Main", "go", "build", "3.0", "GiB", "1m30s"},
+		{"CI/build", "(job)", "3.0", "GiB", "1m30s"},
+		{"CI/test", "Main", "make", "512.0", "MiB", "2s"},
+		{"CI/test", "Main", "make", "lint", "100.0", "KiB", "1.5s"},
+		{"CI/test", "(job)", "512.0", "MiB", "3.5s"},
+	}, fields(buf.String()))
+}
+
+func fields(table string) [][]string {
+	rows := [][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n") {
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
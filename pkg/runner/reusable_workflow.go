@@ -35,6 +35,15 @@ func newRemoteReusableWorkflowExecutor(rc *RunContext) common.Executor {
 
 	return common.NewPipelineExecutor(
 		newMutexExecutor(cloneIfRequired(rc, *remoteReusableWorkflow, workflowDir)),
+		func(ctx context.Context) error {
+			name := fmt.Sprintf("%s/%s", remoteReusableWorkflow.Org, remoteReusableWorkflow.Repo)
+			if err := rc.verifyAction(ctx, uses, name, remoteReusableWorkflow.Ref, workflowDir); err != nil {
+				return err
+			}
+			url, _ := rc.cloneSource(rc.getGithubContext(ctx), remoteReusableWorkflow.Org, remoteReusableWorkflow.Repo)
+			rc.recordAction(ctx, SBOMReusableWorkflow, name, remoteReusableWorkflow.Ref, fmt.Sprintf("%s/%s", url, name), workflowDir)
+			return nil
+		},
 		newReusableWorkflowExecutor(rc, workflowDir, fmt.Sprintf("./.github/workflows/%s", remoteReusableWorkflow.Filename)),
 	)
 }
@@ -60,12 +69,14 @@ func cloneIfRequired(rc *RunContext, remoteReusableWorkflow remoteReusableWorkfl
 			return notExists
 		},
 		func(ctx context.Context) error {
-			remoteReusableWorkflow.URL = rc.getGithubContext(ctx).ServerURL
+			var token string
+			remoteReusableWorkflow.URL, token = rc.cloneSource(rc.getGithubContext(ctx), remoteReusableWorkflow.Org, remoteReusableWorkflow.Repo)
 			return git.NewGitCloneExecutor(git.NewGitCloneExecutorInput{
-				URL:   remoteReusableWorkflow.CloneURL(),
-				Ref:   remoteReusableWorkflow.Ref,
-				Dir:   targetDirectory,
-				Token: rc.Config.Token,
+				URL:         remoteReusableWorkflow.CloneURL(),
+				Ref:         remoteReusableWorkflow.Ref,
+				Dir:         targetDirectory,
+				Token:       token,
+				OfflineMode: rc.Config.ActionOfflineMode,
 			})(ctx)
 		},
 		nil,
@@ -74,7 +85,7 @@ func cloneIfRequired(rc *RunContext, remoteReusableWorkflow remoteReusableWorkfl
 
 func newReusableWorkflowExecutor(rc *RunContext, directory string, workflow string) common.Executor {
 	return func(ctx context.Context) error {
-		planner, err := model.NewWorkflowPlanner(path.Join(directory, workflow), true)
+		planner, err := model.NewStrictWorkflowPlanner(path.Join(directory, workflow), true, rc.Config.StrictMode)
 		if err != nil {
 			return err
 		}
@@ -100,6 +111,11 @@ func NewReusableWorkflowRunner(rc *RunContext) (Runner, error) {
 		caller: &caller{
 			runContext: rc,
 		},
+		slots:        rc.jobSlots,
+		jobCache:     rc.jobCache,
+		prefetched:   rc.prefetched,
+		networks:     rc.networks,
+		workspaceGit: rc.workspaceGit,
 	}
 
 	return runner.configure()
@@ -4,19 +4,20 @@ import (
 	"archive/tar"
 	"bufio"
 	"context"
-	"crypto/rand"
 	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
+	"github.com/kballard/go-shellquote"
 	"github.com/opencontainers/selinux/go-selinux"
 
 	"github.com/nektos/act/pkg/common"
@@ -45,8 +46,39 @@ type RunContext struct {
 	ActionPath          string
 	Parent              *RunContext
 	Masks               []string
+	Deprecations        []string
+	ServiceContainers   map[string]container.ExecutionsEnvironment
+	ServicePorts        map[string]map[string]string
+	ServiceIDs          map[string]string
+	JobContainerID      string
+	validatedSteps      map[*model.Step]bool
+	actionDir           string // directory of the composite action on the host
+	jobIndex            int    // index of the matrix leg of the job, starting at 0
+	jobTotal            int    // number of matrix legs of the job
+	cancelled           bool
+	legResult           string                           // the result of this leg of the matrix of the job, empty until it finished
+	checkpoint          *jobCheckpoint                   // the last checkpoint of the job, also the one it resumes from
+	nodeCommands        map[model.ActionRunsUsing]string // node of each runtime in the job container
+	serviceLogFiles     []*os.File
 	cleanUpJobContainer common.Executor
-	caller              *caller // job calling this RunContext (reusable workflows)
+	caller              *caller  // job calling this RunContext (reusable workflows)
+	jobSlots            jobSlots // the slots of the jobs of the run, passed to the reusable workflow the job calls
+	idTokenRequestToken string
+
+	// prewarmed is the job container of the leg created ahead by the prewarm pool of the matrix
+	prewarmed container.ExecutionsEnvironment
+
+	jobCache       *jobCache // hashes the workspace for the fingerprints of the legs of the run
+	jobDefinition  string    // the definition of the job before its legs ran, empty unless the job cache is enabled
+	jobFingerprint string    // the fingerprint the result of the leg is recorded with once it succeeded
+
+	// prefetched are the actions and images of the run fetched before the jobs started
+	prefetched *prefetched
+
+	networks     *runNetworks  // the networks of the run the job containers join
+	workspaceGit *workspaceGit // the submodules and git lfs files the workdir lacks
+
+	annotations []githubAnnotation // the annotations of the steps for the check run of the job
 }
 
 func (rc *RunContext) AddMask(mask string) {
@@ -83,10 +115,78 @@ func (rc *RunContext) GetEnv() map[string]string {
 	return rc.Env
 }
 
+// evaluateEnv evaluates the expressions in the env of the job scope by scope like GitHub, the
+// workflow env first and then the job env and the --env values, each against the env before it.
+// Values which replaced the ones of the workflow, like those of the runner, aren't evaluated.
+func (rc *RunContext) evaluateEnv(ctx context.Context) {
+	env := rc.GetEnv()
+	if rc.Run == nil || rc.Run.Workflow == nil || rc.Run.Job() == nil {
+		return
+	}
+	scopes := []map[string]string{rc.Run.Workflow.Env, rc.Run.Job().Environment(), rc.Config.Env}
+	raw := mergeMaps(scopes...)
+	evaluated := map[string]string{}
+	for _, scope := range scopes {
+		ee := rc.NewExpressionEvaluatorWithEnv(ctx, evaluated)
+		values := make(map[string]string, len(scope))
+		for k, v := range scope {
+			values[k] = ee.Interpolate(ctx, v)
+		}
+		evaluated = mergeMaps(evaluated, values)
+	}
+	for k, v := range evaluated {
+		if current, ok := env[k]; ok && current == raw[k] {
+			env[k] = v
+		}
+	}
+}
+
 func (rc *RunContext) jobContainerName() string {
 	return createContainerName("act", rc.String())
 }
 
+// Labels of the job and service containers, act attach finds the kept containers of a job by them
+const (
+	LabelJob      = "act.job"
+	LabelWorkflow = "act.workflow"
+	LabelWorkdir  = "act.workdir"
+	LabelService  = "act.service"
+)
+
+// DebugShell runs bash in a container if the image has it and sh otherwise
+var DebugShell = []string{"sh", "-c", "if command -v bash >/dev/null; then exec bash; else exec sh; fi"}
+
+// containerLabels returns the labels of the job container, or of a service container if service isn't empty
+func (rc *RunContext) containerLabels(service string) map[string]string {
+	labels := map[string]string{
+		LabelJob:      rc.Run.JobID,
+		LabelWorkflow: rc.Run.Workflow.Name,
+		LabelWorkdir:  rc.Config.Workdir,
+	}
+	if service != "" {
+		labels[LabelService] = service
+	}
+	return labels
+}
+
+// networkName returns the network of the job container and its services and whether
+// it is a network of the run. By default the jobs of a run share a bridge network, so
+// the services can be reached by their name like on GitHub. Without network access
+// jobs without services have no network at all, the jobs with services share an
+// internal network.
+func (rc *RunContext) networkName() (string, bool) {
+	if rc.noNetwork() {
+		if len(rc.Run.Job().Services) == 0 {
+			return "none", false
+		}
+		return rc.networks.name(true), true
+	}
+	if rc.Config.ContainerNetworkMode != "" {
+		return rc.Config.ContainerNetworkMode, false
+	}
+	return rc.networks.name(false), true
+}
+
 func getDockerDaemonSocketMountPath(daemonPath string) string {
 	if protoIndex := strings.Index(daemonPath, "://"); protoIndex != -1 {
 		scheme := daemonPath[:protoIndex]
@@ -114,7 +214,7 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 	}
 
 	binds := []string{}
-	if rc.Config.ContainerDaemonSocket != "-" {
+	if profile, _ := rc.sandboxProfile(); rc.Config.ContainerDaemonSocket != "-" && (profile == nil || !profile.NoDockerSocket) {
 		daemonPath := getDockerDaemonSocketMountPath(rc.Config.ContainerDaemonSocket)
 		binds = append(binds, fmt.Sprintf("%s:%s", daemonPath, "/var/run/docker.sock"))
 	}
@@ -125,22 +225,36 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 		"act-toolcache": "/toolcache",
 		name + "-env":   ext.GetActPath(),
 	}
+	if rc.Config.ProjectVolumes != "" {
+		delete(mounts, "act-toolcache")
+		mounts[rc.projectVolumeName(volumeCache)] = "/toolcache"
+		if rc.Config.ToolCache == "" {
+			mounts[rc.projectVolumeName(volumeToolCache)] = "/opt/hostedtoolcache"
+		}
+	}
+
+	// a persistent tool cache lets the setup actions find the toolchains of previous runs
+	if toolCache := rc.Config.ToolCache; toolCache != "" {
+		if filepath.IsAbs(toolCache) {
+			binds = append(binds, fmt.Sprintf("%s:%s", toolCache, "/opt/hostedtoolcache"))
+		} else {
+			mounts[toolCache] = "/opt/hostedtoolcache"
+		}
+	}
 
 	if job := rc.Run.Job(); job != nil {
 		if container := job.Container(); container != nil {
-			for _, v := range container.Volumes {
-				if !strings.Contains(v, ":") || filepath.IsAbs(v) {
-					// Bind anonymous volume or host file.
-					binds = append(binds, v)
-				} else {
-					// Mount existing volume.
-					paths := strings.SplitN(v, ":", 2)
-					mounts[paths[0]] = paths[1]
-				}
+			specBinds, specMounts := containerSpecVolumes(interpolateList(context.Background(), rc.ExprEval, container.Volumes))
+			binds = append(binds, specBinds...)
+			for k, v := range specMounts {
+				mounts[k] = v
 			}
 		}
 	}
 
+	binds = append(binds, rc.credentialBinds()...)
+	binds = append(binds, rc.mountBinds()...)
+
 	if rc.Config.BindWorkdir {
 		bindModifiers := ""
 		if runtime.GOOS == "darwin" {
@@ -150,6 +264,8 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 			bindModifiers = ":z"
 		}
 		binds = append(binds, fmt.Sprintf("%s:%s%s", rc.Config.Workdir, ext.ToContainerPath(rc.Config.Workdir), bindModifiers))
+	} else if rc.Config.ProjectVolumes != "" {
+		mounts[rc.projectVolumeName(volumeWorkspace)] = ext.ToContainerPath(rc.Config.Workdir)
 	} else {
 		mounts[name] = ext.ToContainerPath(rc.Config.Workdir)
 	}
@@ -157,22 +273,36 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 	return binds, mounts
 }
 
+// volumeLabels returns the values of the act.managed label of the volumes of GetBindsAndMounts act
+// creates. The volumes of the job are removed by act prune, the shared tool cache and the project
+// volumes persist, the volumes named by the user aren't labeled.
+func (rc *RunContext) volumeLabels() map[string]string {
+	name := rc.jobContainerName()
+	labels := map[string]string{
+		name + "-env":   container.ManagedRun,
+		name:            container.ManagedRun,
+		"act-toolcache": container.ManagedPersistent,
+	}
+	if rc.Config.ProjectVolumes != "" {
+		for _, volume := range []string{volumeCache, volumeToolCache, volumeWorkspace} {
+			labels[rc.projectVolumeName(volume)] = container.ManagedPersistent
+		}
+	}
+	return labels
+}
+
 func (rc *RunContext) startHostEnvironment() common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
-		rawLogger := logger.WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
-			}
-			return true
-		})
+		logWriter := rc.newLogWriter(ctx)
+		if len(rc.Run.Job().Services) > 0 {
+			logger.Warnf("services are not supported when running jobs on the host")
+		}
+		if len(rc.Config.Mounts) > 0 {
+			logger.Warnf("--mount is not supported when running jobs on the host")
+		}
 		cacheDir := rc.ActionCacheDir()
-		randBytes := make([]byte, 8)
-		_, _ = rand.Read(randBytes)
-		miscpath := filepath.Join(cacheDir, hex.EncodeToString(randBytes))
+		miscpath := filepath.Join(cacheDir, rc.randomHex(8, "tmp", rc.String()))
 		actPath := filepath.Join(miscpath, "act")
 		if err := os.MkdirAll(actPath, 0o777); err != nil {
 			return err
@@ -186,6 +316,9 @@ func (rc *RunContext) startHostEnvironment() common.Executor {
 			return err
 		}
 		toolCache := filepath.Join(cacheDir, "tool_cache")
+		if filepath.IsAbs(rc.Config.ToolCache) {
+			toolCache = rc.Config.ToolCache
+		}
 		rc.JobContainer = &container.HostEnvironment{
 			Path:      path,
 			TmpDir:    runnerTmp,
@@ -222,80 +355,158 @@ func (rc *RunContext) startHostEnvironment() common.Executor {
 				Mode: 0o666,
 				Body: "",
 			}),
+			rc.prepareWorkspace(),
 		)(ctx)
 	}
 }
 
+// containerSpecVolumes splits the volumes of a job or service container into binds and named volume mounts
+func containerSpecVolumes(volumes []string) ([]string, map[string]string) {
+	binds := []string{}
+	mounts := map[string]string{}
+	for _, v := range volumes {
+		if !strings.Contains(v, ":") || filepath.IsAbs(v) {
+			// Bind anonymous volume or host file.
+			binds = append(binds, v)
+		} else {
+			// Mount existing volume.
+			paths := strings.SplitN(v, ":", 2)
+			mounts[paths[0]] = paths[1]
+		}
+	}
+	return binds, mounts
+}
+
+// jobContainerEnv returns the env of the job container, the steps get the env of the job on top
+func (rc *RunContext) jobContainerEnv(ctx context.Context) []string {
+	envList := make([]string, 0)
+
+	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", "/opt/hostedtoolcache"))
+	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_OS", "Linux"))
+	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_ARCH", container.RunnerArch(ctx)))
+	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", "/tmp"))
+	envList = append(envList, fmt.Sprintf("%s=%s", "LANG", "C.UTF-8")) // Use same locale as GitHub Actions
+
+	ext := container.LinuxContainerEnvironmentExtensions{}
+	envList = append(envList, rc.containerProxyEnv()...)
+	envList = append(envList, rc.containerHostEnv()...)
+	envList = append(envList, rc.caCertificatesEnv(ext.GetActPath())...)
+	envList = append(envList, rc.credentialEnv()...)
+	return envList
+}
+
+// jobContainerInput returns the input of the job container of the leg running the image
+func (rc *RunContext) jobContainerInput(ctx context.Context, image string, logWriter io.Writer) (*container.NewContainerInput, error) {
+	username, password, err := rc.handleCredentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to handle credentials: %s", err)
+	}
+
+	options := rc.options(ctx)
+	envList := rc.jobContainerEnv(ctx)
+	if args, err := shellquote.Split(options); err == nil {
+		envList = append(envList, gpuEnv(containerOption(args, "--gpus"))...)
+	}
+	ext := container.LinuxContainerEnvironmentExtensions{}
+	binds, mounts := rc.GetBindsAndMounts()
+	networkName, _ := rc.networkName()
+
+	var ports []string
+	if c := rc.Run.Job().Container(); c != nil {
+		ports = interpolateList(ctx, rc.ExprEval, c.Ports)
+	}
+
+	return &container.NewContainerInput{
+		Cmd:          nil,
+		Entrypoint:   []string{"tail", "-f", "/dev/null"},
+		WorkingDir:   ext.ToContainerPath(rc.Config.Workdir),
+		Image:        image,
+		Username:     username,
+		Password:     password,
+		Name:         rc.jobContainerName(),
+		Env:          envList,
+		Mounts:       mounts,
+		VolumeLabels: rc.volumeLabels(),
+		NetworkMode:  networkName,
+		Binds:        binds,
+		Stdout:       logWriter,
+		Stderr:       logWriter,
+		Privileged:   rc.Config.Privileged,
+		UsernsMode:   rc.Config.UsernsMode,
+		Platform:     rc.Config.ContainerArchitecture,
+		Options:      options,
+		Ports:        ports,
+		Labels:       rc.containerLabels(""),
+	}, nil
+}
+
 func (rc *RunContext) startJobContainer() common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
 		image := rc.platformImage(ctx)
-		rawLogger := logger.WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
-			}
-			return true
-		})
+		logWriter := rc.newLogWriter(ctx)
+		checkpointImage := rc.loadCheckpoint(ctx, image)
 
-		username, password, err := rc.handleCredentials(ctx)
+		containerImage := image
+		if checkpointImage != "" {
+			containerImage = checkpointImage
+		}
+		input, err := rc.jobContainerInput(ctx, containerImage, logWriter)
 		if err != nil {
-			return fmt.Errorf("failed to handle credentials: %s", err)
+			return err
 		}
 
-		logger.Infof("\U0001f680  Start image=%s", image)
-		name := rc.jobContainerName()
-
-		envList := make([]string, 0)
+		if _, err := rc.sandboxProfile(); err != nil {
+			return err
+		}
 
-		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", "/opt/hostedtoolcache"))
-		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_OS", "Linux"))
-		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_ARCH", container.RunnerArch(ctx)))
-		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", "/tmp"))
-		envList = append(envList, fmt.Sprintf("%s=%s", "LANG", "C.UTF-8")) // Use same locale as GitHub Actions
+		logger.Infof("\U0001f680  Start image=%s", image)
+		if rc.Config.ProjectVolumes != "" && !common.Dryrun(ctx) {
+			if err := rc.indexProjectVolumes(ctx); err != nil {
+				logger.Warnf("Unable to index the project volumes: %v", err)
+			}
+		}
 
-		ext := container.LinuxContainerEnvironmentExtensions{}
-		binds, mounts := rc.GetBindsAndMounts()
+		rc.ServiceContainers, err = rc.newServiceContainers(ctx)
+		if err != nil {
+			return err
+		}
 
+		_, runNetwork := rc.networkName()
 		rc.cleanUpJobContainer = func(ctx context.Context) error {
 			if rc.JobContainer != nil && !rc.Config.ReuseContainers {
 				return rc.JobContainer.Remove().
-					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName(), false)).
-					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-env", false))(ctx)
+					Then(rc.removeServiceContainers()).
+					// the workspace of a job with a checkpoint is in the volumes, not in the image
+					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName(), false).IfBool(rc.checkpoint == nil)).
+					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-env", false).IfBool(rc.checkpoint == nil))(ctx)
 			}
 			return nil
 		}
 
-		rc.JobContainer = container.NewContainer(&container.NewContainerInput{
-			Cmd:         nil,
-			Entrypoint:  []string{"tail", "-f", "/dev/null"},
-			WorkingDir:  ext.ToContainerPath(rc.Config.Workdir),
-			Image:       image,
-			Username:    username,
-			Password:    password,
-			Name:        name,
-			Env:         envList,
-			Mounts:      mounts,
-			NetworkMode: "host",
-			Binds:       binds,
-			Stdout:      logWriter,
-			Stderr:      logWriter,
-			Privileged:  rc.Config.Privileged,
-			UsernsMode:  rc.Config.UsernsMode,
-			Platform:    rc.Config.ContainerArchitecture,
-			Options:     rc.options(ctx),
-		})
+		// the prewarmed job container of the leg is already running on its network
+		prewarmed := rc.prewarmed != nil
+		if prewarmed {
+			logger.Debugf("Using the prewarmed job container %s", input.Name)
+			rc.JobContainer = rc.prewarmed
+			rc.JobContainer.ReplaceLogWriter(logWriter, logWriter)
+		} else {
+			rc.JobContainer = container.NewContainer(input)
+		}
 		if rc.JobContainer == nil {
 			return errors.New("Failed to create job container")
 		}
 
 		return common.NewPipelineExecutor(
-			rc.JobContainer.Pull(rc.Config.ForcePull),
-			rc.stopJobContainer(),
-			rc.JobContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
-			rc.JobContainer.Start(false),
+			rc.timed(TimingPull, image, rc.JobContainer.Pull(rc.Config.ForcePull && !rc.prefetched.hasImage(image))).IfBool(checkpointImage == "" && !prewarmed),
+			rc.checkJobImage(containerImage).IfBool(!rc.prefetched.checkedImage(rc.Run, containerImage)),
+			rc.recordImage(SBOMJobImage, image),
+			rc.stopJobContainer().IfBool(!prewarmed),
+			rc.networks.create(rc.noNetwork()).IfBool(runNetwork && !prewarmed),
+			rc.startServiceContainers(),
+			rc.timed(TimingCreate, "job container", rc.JobContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop)).IfBool(!prewarmed),
+			rc.JobContainer.Start(false).IfBool(!prewarmed),
+			rc.inspectJobContainer(),
 			rc.JobContainer.Copy(rc.JobContainer.GetActPath()+"/", &container.FileEntry{
 				Name: "workflow/event.json",
 				Mode: 0o644,
@@ -305,10 +516,137 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				Mode: 0o666,
 				Body: "",
 			}),
+			// the workspace of a resumed job is still in its volume, the workdir is copied over it again
+			// for the edits made since the failed run, the files the steps created are kept
+			rc.clearProjectWorkspace().IfBool(checkpointImage == ""),
+			rc.prepareWorkspace(),
+			rc.copyMounts().IfBool(checkpointImage == ""),
+			rc.installCACertificates(),
+			rc.waitForServiceContainers(),
 		)(ctx)
 	}
 }
 
+// inspectContainer inspects the job container once it started
+var inspectContainer = container.InspectContainer
+
+// inspectJobContainer records the ID of the started job container for job.container.id
+func (rc *RunContext) inspectJobContainer() common.Executor {
+	return func(ctx context.Context) error {
+		if common.Dryrun(ctx) {
+			return nil
+		}
+		info, err := inspectContainer(ctx, rc.jobContainerName())
+		if err != nil {
+			return fmt.Errorf("failed to inspect the job container: %w", err)
+		}
+		rc.JobContainerID = info.ID
+		return nil
+	}
+}
+
+// checkJobImage fails early if the job image can't run on the requested platform
+// or lacks the shells used by the steps, instead of failing with exec format errors
+func (rc *RunContext) checkJobImage(image string) common.Executor {
+	return func(ctx context.Context) error {
+		if common.Dryrun(ctx) {
+			return nil
+		}
+		imagePlatform, err := container.ImagePlatform(ctx, image)
+		if err != nil {
+			return err
+		}
+
+		platform := rc.Config.ContainerArchitecture
+		explicit := platform != ""
+		if !explicit {
+			info, err := container.GetHostInfo(ctx)
+			if err != nil {
+				return err
+			}
+			platform = fmt.Sprintf("%s/%s", info.OSType, normalizeArch(info.Architecture))
+		}
+
+		warning, err := checkImagePlatform(image, imagePlatform, platform, explicit, rc.stepShells())
+		if warning != "" {
+			common.Logger(ctx).Warn(warning)
+		}
+		return err
+	}
+}
+
+// stepShells returns the shells used by the run steps of the job
+func (rc *RunContext) stepShells() map[string]string {
+	job := rc.Run.Job()
+	shells := map[string]string{}
+	for i, step := range job.Steps {
+		if step == nil || step.Run == "" {
+			continue
+		}
+		shell := step.Shell
+		if shell == "" {
+			shell = job.Defaults.Run.Shell
+		}
+		if shell == "" {
+			shell = rc.Run.Workflow.Defaults.Run.Shell
+		}
+		if shell != "" && !strings.Contains(shell, "${{") {
+			name := step.String()
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+			shells[name] = shell
+		}
+	}
+	return shells
+}
+
+func normalizeArch(arch string) string {
+	switch arch {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	}
+	return arch
+}
+
+// checkImagePlatform compares the os/arch of an image with the requested platform,
+// a differing architecture is only an error if the platform was requested explicitly,
+// since docker may be able to emulate it
+func checkImagePlatform(image, imagePlatform, platform string, explicit bool, shells map[string]string) (string, error) {
+	imageParts := strings.SplitN(imagePlatform, "/", 3)
+	parts := strings.SplitN(platform, "/", 3)
+	if len(imageParts) < 2 || len(parts) < 2 {
+		return "", nil
+	}
+
+	if imageParts[0] != parts[0] {
+		return "", fmt.Errorf("image '%s' is built for %s and can't run on %s", image, imagePlatform, platform)
+	}
+
+	steps := make([]string, 0, len(shells))
+	for step := range shells {
+		steps = append(steps, step)
+	}
+	sort.Strings(steps)
+	for _, step := range steps {
+		shell := shells[step]
+		command := strings.Fields(shell)[0]
+		if (command == "cmd" || command == "powershell") && imageParts[0] != "windows" {
+			return "", fmt.Errorf("step '%s' uses shell '%s' which requires a windows image, but image '%s' is built for %s", step, command, image, imagePlatform)
+		}
+	}
+
+	if imageParts[1] != parts[1] {
+		if explicit {
+			return "", fmt.Errorf("image '%s' is built for %s but --container-architecture requested %s", image, imagePlatform, platform)
+		}
+		return fmt.Sprintf("image '%s' is built for %s and needs emulation to run on %s, steps may fail with 'exec format error'", image, imagePlatform, platform), nil
+	}
+	return "", nil
+}
+
 func (rc *RunContext) execJobContainer(cmd []string, env map[string]string, user, workdir string) common.Executor {
 	return func(ctx context.Context) error {
 		return rc.JobContainer.Exec(cmd, env, user, workdir)(ctx)
@@ -383,17 +721,7 @@ func (rc *RunContext) stopJobContainer() common.Executor {
 
 // ActionCacheDir is for rc
 func (rc *RunContext) ActionCacheDir() string {
-	var xdgCache string
-	var ok bool
-	if xdgCache, ok = os.LookupEnv("XDG_CACHE_HOME"); !ok || xdgCache == "" {
-		if home, err := os.UserHomeDir(); err == nil {
-			xdgCache = filepath.Join(home, ".cache")
-		} else if xdgCache, err = filepath.Abs("."); err != nil {
-			// It's almost impossible to get here, so the temp dir is a good fallback
-			xdgCache = os.TempDir()
-		}
-	}
-	return filepath.Join(xdgCache, "act")
+	return rc.Config.ActionCacheDir()
 }
 
 // Interpolate outputs after a job is done
@@ -413,9 +741,12 @@ func (rc *RunContext) interpolateOutputs() common.Executor {
 func (rc *RunContext) startContainer() common.Executor {
 	return func(ctx context.Context) error {
 		if rc.IsHostEnv(ctx) {
-			return rc.startHostEnvironment()(ctx)
+			return common.WithErrorClass(rc.startHostEnvironment()(ctx), common.ErrorClassInfrastructure)
+		}
+		if rc.isMicroVM(ctx) {
+			return common.WithErrorClass(rc.startMicroVM()(ctx), common.ErrorClassInfrastructure)
 		}
-		return rc.startJobContainer()(ctx)
+		return common.WithErrorClass(rc.startJobContainer()(ctx), common.ErrorClassInfrastructure)
 	}
 }
 
@@ -456,7 +787,7 @@ func (rc *RunContext) Executor() common.Executor {
 
 	switch rc.Run.Job().Type() {
 	case model.JobTypeDefault:
-		executor = newJobExecutor(rc, &stepFactoryImpl{}, rc)
+		executor = rc.withToolCacheLock(newJobExecutor(rc, &stepFactoryImpl{}, rc))
 	case model.JobTypeReusableWorkflowLocal:
 		executor = newLocalReusableWorkflowExecutor(rc)
 	case model.JobTypeReusableWorkflowRemote:
@@ -469,7 +800,15 @@ func (rc *RunContext) Executor() common.Executor {
 			return err
 		}
 		if res {
-			return executor(ctx)
+			if rc.restoreJobCache(ctx) {
+				return nil
+			}
+			// the job fails without a job error when e.g. an action can't be fetched
+			if err := executor(ctx); err != nil {
+				return err
+			}
+			rc.saveJobCache(ctx)
+			return nil
 		}
 		return nil
 	}
@@ -513,13 +852,38 @@ func (rc *RunContext) platformImage(ctx context.Context) string {
 }
 
 func (rc *RunContext) options(ctx context.Context) string {
-	job := rc.Run.Job()
-	c := job.Container()
-	if c == nil {
-		return rc.Config.ContainerOptions
+	options := rc.Config.ContainerOptions
+	if c := rc.Run.Job().Container(); c != nil {
+		options = rc.containerSpecOptions(ctx, c.Options)
+	}
+
+	profile, _ := rc.sandboxProfile()
+	parts := make([]string, 0, 3)
+	for _, o := range []string{options, profile.containerOptions(), rc.gpuOptions(options)} {
+		if o = strings.TrimSpace(o); o != "" {
+			parts = append(parts, o)
+		}
 	}
+	return strings.Join(parts, " ")
+}
 
-	return c.Options
+// containerSpecOptions evaluates the options of a job or service container and
+// warns about the options GitHub does not support for them
+func (rc *RunContext) containerSpecOptions(ctx context.Context, options string) string {
+	options = rc.ExprEval.Interpolate(ctx, options)
+	args, err := shellquote.Split(options)
+	if err != nil {
+		// the error is reported once the options are parsed by the container
+		return options
+	}
+	for _, arg := range args {
+		name := strings.SplitN(arg, "=", 2)[0]
+		switch name {
+		case "--network", "--net", "--entrypoint":
+			common.Logger(ctx).Warnf("container option '%s' is not supported by GitHub Actions and may behave differently there", name)
+		}
+	}
+	return options
 }
 
 func (rc *RunContext) isEnabled(ctx context.Context) (bool, error) {
@@ -563,10 +927,11 @@ func mergeMaps(maps ...map[string]string) map[string]string {
 	return rtnMap
 }
 
+var containerNameInvalidChars = regexp.MustCompile("[^a-zA-Z0-9]")
+
 func createContainerName(parts ...string) string {
 	name := strings.Join(parts, "-")
-	pattern := regexp.MustCompile("[^a-zA-Z0-9]")
-	name = pattern.ReplaceAllString(name, "-")
+	name = containerNameInvalidChars.ReplaceAllString(name, "-")
 	name = strings.ReplaceAll(name, "--", "-")
 	hash := sha256.Sum256([]byte(name))
 
@@ -586,6 +951,39 @@ func trimToLen(s string, l int) string {
 	return s
 }
 
+// getStrategyContext returns the strategy context of the matrix leg, a job without a matrix is
+// its only leg
+func (rc *RunContext) getStrategyContext() map[string]interface{} {
+	jobTotal := rc.jobTotal
+	if jobTotal < 1 {
+		jobTotal = 1
+	}
+	strategy := map[string]interface{}{
+		"fail-fast":    true,
+		"max-parallel": jobTotal,
+		"job-index":    rc.jobIndex,
+		"job-total":    jobTotal,
+	}
+	if job := rc.Run.Job(); job != nil && job.Strategy != nil {
+		strategy["fail-fast"] = job.Strategy.FailFast
+		if job.Strategy.MaxParallelString != "" {
+			strategy["max-parallel"] = job.Strategy.MaxParallel
+		}
+	}
+	return strategy
+}
+
+// cancel marks the job as cancelled after the run was cancelled, the remaining steps only run
+// when their if uses always() or cancelled()
+func (rc *RunContext) cancel(ctx context.Context) {
+	if rc.cancelled {
+		return
+	}
+	rc.cancelled = true
+	common.Logger(ctx).Warnf("\u26D4  The run was cancelled, running the steps with always() or cancelled() and cleaning up")
+	common.SetJobError(ctx, ctx.Err())
+}
+
 func (rc *RunContext) getJobContext() *model.JobContext {
 	jobStatus := "success"
 	for _, stepStatus := range rc.StepResults {
@@ -594,9 +992,31 @@ func (rc *RunContext) getJobContext() *model.JobContext {
 			break
 		}
 	}
-	return &model.JobContext{
+	if rc.cancelled {
+		jobStatus = "cancelled"
+	}
+	jobContext := &model.JobContext{
 		Status: jobStatus,
 	}
+	networkName, _ := rc.networkName()
+	jobContext.Container.ID = rc.JobContainerID
+	jobContext.Container.Network = networkName
+	if len(rc.ServiceContainers) > 0 {
+		jobContext.Services = make(map[string]model.JobServiceContext, len(rc.ServiceContainers))
+		for id := range rc.ServiceContainers {
+			serviceID := rc.ServiceIDs[id]
+			if serviceID == "" {
+				// the name identifies the container as well until it is inspected
+				serviceID = createContainerName(rc.jobContainerName(), id)
+			}
+			jobContext.Services[id] = model.JobServiceContext{
+				ID:      serviceID,
+				Network: networkName,
+				Ports:   rc.ServicePorts[id],
+			}
+		}
+	}
+	return jobContext
 }
 
 func (rc *RunContext) getStepsContext() map[string]*model.StepResult {
@@ -630,12 +1050,12 @@ func (rc *RunContext) getGithubContext(ctx context.Context) *model.GithubContext
 		Workspace:        rc.Config.Env["GITHUB_WORKSPACE"],
 	}
 	if rc.JobContainer != nil {
-		ghc.EventPath = rc.JobContainer.GetActPath() + "/workflow/event.json"
+		ghc.EventPath = rc.actFilePath("workflow/event.json")
 		ghc.Workspace = rc.JobContainer.ToContainerPath(rc.Config.Workdir)
 	}
 
 	if ghc.RunID == "" {
-		ghc.RunID = "1"
+		ghc.RunID = rc.seededRunID()
 	}
 
 	if ghc.RunNumber == "" {
@@ -656,6 +1076,19 @@ func (rc *RunContext) getGithubContext(ctx context.Context) *model.GithubContext
 		ghc.Actor = "nektos/act"
 	}
 
+	if rc.Config.Repository != "" {
+		ghc.Repository = rc.Config.Repository
+	}
+	if rc.Config.Sha != "" {
+		ghc.Sha = rc.Config.Sha
+	}
+	if ref := rc.Config.Ref; ref != "" {
+		if !strings.HasPrefix(ref, "refs/") {
+			ref = "refs/heads/" + ref
+		}
+		ghc.Ref = ref
+	}
+
 	if rc.EventJSON != "" {
 		err := json.Unmarshal([]byte(rc.EventJSON), &ghc.Event)
 		if err != nil {
@@ -665,7 +1098,7 @@ func (rc *RunContext) getGithubContext(ctx context.Context) *model.GithubContext
 
 	ghc.SetBaseAndHeadRef()
 	repoPath := rc.Config.Workdir
-	ghc.SetRepositoryAndOwner(ctx, rc.Config.GitHubInstance, rc.Config.RemoteName, repoPath)
+	ghc.SetRepositoryAndOwner(ctx, githubInstanceHost(rc.Config.GitHubInstance), rc.Config.RemoteName, repoPath)
 	if ghc.Ref == "" {
 		ghc.SetRef(ctx, rc.Config.DefaultBranch, repoPath)
 	}
@@ -680,10 +1113,11 @@ func (rc *RunContext) getGithubContext(ctx context.Context) *model.GithubContext
 	ghc.APIURL = "https://api.github.com"
 	ghc.GraphQLURL = "https://api.github.com/graphql"
 	// per GHES
-	if rc.Config.GitHubInstance != "github.com" {
-		ghc.ServerURL = fmt.Sprintf("https://%s", rc.Config.GitHubInstance)
-		ghc.APIURL = fmt.Sprintf("https://%s/api/v3", rc.Config.GitHubInstance)
-		ghc.GraphQLURL = fmt.Sprintf("https://%s/api/graphql", rc.Config.GitHubInstance)
+	if githubInstanceHost(rc.Config.GitHubInstance) != "github.com" {
+		serverURL := GitHubInstanceURL(rc.Config.GitHubInstance)
+		ghc.ServerURL = serverURL
+		ghc.APIURL = fmt.Sprintf("%s/api/v3", serverURL)
+		ghc.GraphQLURL = fmt.Sprintf("%s/api/graphql", serverURL)
 	}
 	// allow to be overridden by user
 	if rc.Config.Env["GITHUB_SERVER_URL"] != "" {
@@ -699,30 +1133,68 @@ func (rc *RunContext) getGithubContext(ctx context.Context) *model.GithubContext
 	return ghc
 }
 
-func isLocalCheckout(ghc *model.GithubContext, step *model.Step) bool {
-	if step.Type() == model.StepTypeInvalid {
-		// This will be errored out by the executor later, we need this here to avoid a null panic though
-		return false
+// GitHubInstanceURL returns the URL of the GitHub instance, which is configured by its
+// host name or, e.g. for instances without TLS or on another port, by its URL
+func GitHubInstanceURL(instance string) string {
+	if instance == "" {
+		instance = "github.com"
 	}
-	if step.Type() != model.StepTypeUsesActionRemote {
+	if !strings.Contains(instance, "://") {
+		instance = "https://" + instance
+	}
+	return strings.TrimSuffix(instance, "/")
+}
+
+// githubInstanceHost returns the host of the GitHub instance, as used by git remotes
+func githubInstanceHost(instance string) string {
+	u, err := url.Parse(GitHubInstanceURL(instance))
+	if err != nil || u.Host == "" {
+		return instance
+	}
+	return u.Host
+}
+
+// cloneSource returns the server URL and the token to clone a repository with actions or
+// reusable workflows from, which is github.com for the ones of --replace-ghe-action-with-github-com
+func (rc *RunContext) cloneSource(ghc *model.GithubContext, org string, repo string) (string, string) {
+	for _, action := range rc.Config.ReplaceGheActionWithGithubCom {
+		if strings.EqualFold(fmt.Sprintf("%s/%s", org, repo), action) {
+			return "https://github.com", rc.Config.ReplaceGheActionTokenWithGithubCom
+		}
+	}
+	return ghc.ServerURL, ghc.Token
+}
+
+// isLocalCheckout reports whether an actions/checkout step checks out the repository and ref act
+// runs in, with is the inputs of the step with their expressions evaluated
+func isLocalCheckout(ghc *model.GithubContext, step *model.Step, with map[string]string) bool {
+	if !isCheckoutStep(step) {
 		return false
 	}
-	remoteAction := newRemoteAction(step.Uses)
-	if remoteAction == nil {
-		// IsCheckout() will nil panic if we dont bail out early
+
+	if repository := with["repository"]; repository != "" && !strings.EqualFold(repository, ghc.Repository) {
 		return false
 	}
-	if !remoteAction.IsCheckout() {
+	if ref := with["ref"]; ref != "" && ref != ghc.Ref {
 		return false
 	}
+	return true
+}
 
-	if repository, ok := step.With["repository"]; ok && repository != ghc.Repository {
+func isCheckoutStep(step *model.Step) bool {
+	if step.Type() == model.StepTypeInvalid {
+		// This will be errored out by the executor later, we need this here to avoid a null panic though
+		return false
+	}
+	if step.Type() != model.StepTypeUsesActionRemote {
 		return false
 	}
-	if repository, ok := step.With["ref"]; ok && repository != ghc.Ref {
+	remoteAction := newRemoteAction(step.Uses)
+	if remoteAction == nil {
+		// IsCheckout() will nil panic if we dont bail out early
 		return false
 	}
-	return true
+	return remoteAction.IsCheckout()
 }
 
 func nestedMapLookup(m map[string]interface{}, ks ...string) (rval interface{}) {
@@ -752,6 +1224,7 @@ func (rc *RunContext) withGithubEnv(ctx context.Context, github *model.GithubCon
 	env["GITHUB_ACTION_REPOSITORY"] = github.ActionRepository
 	env["GITHUB_ACTION_REF"] = github.ActionRef
 	env["GITHUB_ACTIONS"] = "true"
+	rc.withCompatEnv(env)
 	env["GITHUB_ACTOR"] = github.Actor
 	env["GITHUB_REPOSITORY"] = github.Repository
 	env["GITHUB_EVENT_NAME"] = github.EventName
@@ -777,6 +1250,10 @@ func (rc *RunContext) withGithubEnv(ctx context.Context, github *model.GithubCon
 		setActionRuntimeVars(rc, env)
 	}
 
+	if rc.Config.IDTokenIssuer != nil {
+		rc.setIDTokenVars(github, env)
+	}
+
 	job := rc.Run.Job()
 	if job.RunsOn() != nil {
 		for _, runnerLabel := range job.RunsOn() {
@@ -800,22 +1277,32 @@ func setActionRuntimeVars(rc *RunContext, env map[string]string) {
 	actionsRuntimeURL := os.Getenv("ACTIONS_RUNTIME_URL")
 	if actionsRuntimeURL == "" {
 		actionsRuntimeURL = fmt.Sprintf("http://%s:%s/", rc.Config.ArtifactServerAddr, rc.Config.ArtifactServerPort)
+		if rc.Config.ArtifactsExportDir != "" {
+			// the artifact server records the artifacts of the leg for the export
+			actionsRuntimeURL += "jobs/" + rc.exportName() + "/"
+		}
 	}
 	env["ACTIONS_RUNTIME_URL"] = actionsRuntimeURL
 
 	actionsRuntimeToken := os.Getenv("ACTIONS_RUNTIME_TOKEN")
 	if actionsRuntimeToken == "" {
 		actionsRuntimeToken = "token"
+		if rc.Config.Seed != 0 {
+			actionsRuntimeToken = rc.randomHex(20, "actions_runtime_token")
+		}
 	}
 	env["ACTIONS_RUNTIME_TOKEN"] = actionsRuntimeToken
 }
 
 func (rc *RunContext) handleCredentials(ctx context.Context) (username, password string, err error) {
+	return rc.handleContainerCredentials(ctx, rc.Run.Job().Container())
+}
+
+func (rc *RunContext) handleContainerCredentials(ctx context.Context, container *model.ContainerSpec) (username, password string, err error) {
 	// TODO: remove below 2 lines when we can release act with breaking changes
 	username = rc.Config.Secrets["DOCKER_USERNAME"]
 	password = rc.Config.Secrets["DOCKER_PASSWORD"]
 
-	container := rc.Run.Job().Container()
 	if container == nil || container.Credentials == nil {
 		return
 	}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/nektos/act/pkg/common"
 	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
 
@@ -339,6 +340,47 @@ func TestRunContext_GetBindsAndMounts(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("ToolCache", func(t *testing.T) {
+		rc := &RunContext{
+			Name: "TestRCName",
+			Run: &model.Run{
+				Workflow: &model.Workflow{
+					Name: "TestWorkflowName",
+				},
+			},
+			Config: &Config{
+				ToolCache: "act-hostedtoolcache",
+			},
+		}
+		_, gotmount := rc.GetBindsAndMounts()
+		assert.Equal(t, "/opt/hostedtoolcache", gotmount["act-hostedtoolcache"])
+
+		rc.Config.ToolCache = "/home/user/.cache/act/hostedtoolcache"
+		gotbind, _ := rc.GetBindsAndMounts()
+		assert.Contains(t, gotbind, "/home/user/.cache/act/hostedtoolcache:/opt/hostedtoolcache")
+	})
+
+	t.Run("InterpolatedVolumes", func(t *testing.T) {
+		job := &model.Job{}
+		assert.NoError(t, job.RawContainer.Encode(map[string][]string{
+			"volumes": {"${{ matrix.volume }}:/data", "/cache/${{ matrix.os }}:/cache"},
+		}))
+		rc := &RunContext{
+			Name:   "TestRCName",
+			Matrix: map[string]interface{}{"volume": "data-volume", "os": "linux"},
+			Run: &model.Run{
+				JobID:    "job1",
+				Workflow: &model.Workflow{Name: "TestWorkflowName", Jobs: map[string]*model.Job{"job1": job}},
+			},
+			Config: &Config{},
+		}
+		rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+
+		gotbind, gotmount := rc.GetBindsAndMounts()
+		assert.Contains(t, gotbind, "/cache/linux:/cache")
+		assert.Equal(t, "/data", gotmount["data-volume"])
+	})
 }
 
 func TestGetGitHubContext(t *testing.T) {
@@ -397,6 +439,80 @@ func TestGetGitHubContext(t *testing.T) {
 	assert.Equal(t, ghc.Job, "job1")
 }
 
+func TestGetGitHubContextOverrides(t *testing.T) {
+	cwd, err := os.Getwd()
+	assert.Nil(t, err)
+
+	for ref, want := range map[string][]string{
+		"feature":          {"refs/heads/feature", "branch", "feature"},
+		"refs/tags/v1.0.0": {"refs/tags/v1.0.0", "tag", "v1.0.0"},
+	} {
+		rc := &RunContext{
+			Config: &Config{
+				EventName:  "push",
+				Workdir:    cwd,
+				Actor:      "octocat",
+				Repository: "octo/hello",
+				Sha:        "0123456789abcdef0123456789abcdef01234567",
+				Ref:        ref,
+			},
+			Run: &model.Run{
+				JobID:    "job1",
+				Workflow: &model.Workflow{Name: "GitHubContextTest"},
+			},
+			EventJSON: `{"ref": "refs/heads/main"}`,
+		}
+
+		ghc := rc.getGithubContext(context.Background())
+		assert.Equal(t, "octocat", ghc.Actor)
+		assert.Equal(t, "octo/hello", ghc.Repository)
+		assert.Equal(t, "octo", ghc.RepositoryOwner)
+		assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", ghc.Sha)
+		assert.Equal(t, want, []string{ghc.Ref, ghc.RefType, ghc.RefName})
+	}
+}
+
+func TestGetGitHubContextInstance(t *testing.T) {
+	for instance, urls := range map[string][3]string{
+		"":                         {"https://github.com", "https://api.github.com", "https://api.github.com/graphql"},
+		"github.com":               {"https://github.com", "https://api.github.com", "https://api.github.com/graphql"},
+		"https://github.com/":      {"https://github.com", "https://api.github.com", "https://api.github.com/graphql"},
+		"ghe.example.com":          {"https://ghe.example.com", "https://ghe.example.com/api/v3", "https://ghe.example.com/api/graphql"},
+		"http://ghe.example.com:8": {"http://ghe.example.com:8", "http://ghe.example.com:8/api/v3", "http://ghe.example.com:8/api/graphql"},
+	} {
+		rc := &RunContext{
+			Config: &Config{
+				Workdir:        t.TempDir(),
+				GitHubInstance: instance,
+			},
+			Run: &model.Run{
+				JobID:    "job1",
+				Workflow: &model.Workflow{Name: "GitHubContextTest"},
+			},
+		}
+		ghc := rc.getGithubContext(context.Background())
+		assert.Equal(t, urls, [3]string{ghc.ServerURL, ghc.APIURL, ghc.GraphQLURL}, instance)
+	}
+}
+
+func TestRunContextCloneSource(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			ReplaceGheActionWithGithubCom:      []string{"actions/cache"},
+			ReplaceGheActionTokenWithGithubCom: "github-com-token",
+		},
+	}
+	ghc := &model.GithubContext{ServerURL: "https://ghe.example.com", Token: "ghe-token"}
+
+	serverURL, token := rc.cloneSource(ghc, "mycorp", "action")
+	assert.Equal(t, "https://ghe.example.com", serverURL)
+	assert.Equal(t, "ghe-token", token)
+
+	serverURL, token = rc.cloneSource(ghc, "Actions", "Cache")
+	assert.Equal(t, "https://github.com", serverURL)
+	assert.Equal(t, "github-com-token", token)
+}
+
 func TestGetGithubContextRef(t *testing.T) {
 	table := []struct {
 		event string
@@ -572,6 +688,19 @@ if: always()`, ""),
 	})
 	rc.Run.JobID = "job2"
 	assertObject.True(rc.isEnabled(context.Background()))
+
+	// secrets
+	jobs := map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest
+if: secrets.DEPLOY_KEY != ''`, ""),
+	}
+	rc = createIfTestRunContext(jobs)
+	assertObject.False(rc.isEnabled(context.Background()))
+
+	rc = createIfTestRunContext(jobs)
+	rc.Config.Secrets = map[string]string{"DEPLOY_KEY": "key"}
+	rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+	assertObject.True(rc.isEnabled(context.Background()))
 }
 
 func TestRunContextGetEnv(t *testing.T) {
@@ -624,3 +753,238 @@ func TestRunContextGetEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestRunContextGetStrategyContext(t *testing.T) {
+	job := &model.Job{}
+	rc := &RunContext{
+		Config: &Config{},
+		Run: &model.Run{
+			JobID:    "test",
+			Workflow: &model.Workflow{Jobs: map[string]*model.Job{"test": job}},
+		},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"fail-fast":    true,
+		"max-parallel": 1,
+		"job-index":    0,
+		"job-total":    1,
+	}, rc.getStrategyContext())
+
+	job.Strategy = &model.Strategy{FailFastString: "false"}
+	job.Strategy.FailFast = job.Strategy.GetFailFast()
+	job.Strategy.MaxParallel = job.Strategy.GetMaxParallel()
+	rc.jobIndex = 2
+	rc.jobTotal = 6
+	assert.Equal(t, map[string]interface{}{
+		"fail-fast":    false,
+		"max-parallel": 6,
+		"job-index":    2,
+		"job-total":    6,
+	}, rc.getStrategyContext())
+
+	job.Strategy.MaxParallelString = "3"
+	job.Strategy.MaxParallel = job.Strategy.GetMaxParallel()
+	assert.Equal(t, 3, rc.getStrategyContext()["max-parallel"])
+
+	ee := rc.NewExpressionEvaluator(context.Background())
+	assert.Equal(t, "shard 2 of 6", ee.Interpolate(context.Background(), "shard ${{ strategy.job-index }} of ${{ strategy.job-total }}"))
+}
+
+func TestRunContextEvaluateEnv(t *testing.T) {
+	job := &model.Job{}
+	assert.NoError(t, job.Env.Encode(map[string]string{
+		"JOB":      "${{ env.WORKFLOW }}-job",
+		"OVERRIDE": "job",
+	}))
+	rc := &RunContext{
+		Config: &Config{
+			Env: map[string]string{"CLI": "${{ env.JOB }}-cli"},
+		},
+		Matrix: map[string]interface{}{"os": "linux"},
+		Run: &model.Run{
+			JobID: "test",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{"test": job},
+				Env: map[string]string{
+					"WORKFLOW": "workflow-${{ matrix.os }}",
+					"OVERRIDE": "workflow",
+					"RUNNER":   "${{ env.WORKFLOW }}",
+				},
+			},
+		},
+	}
+	rc.GetEnv()
+	// a value of the runner replaced the one of the workflow, it isn't evaluated
+	rc.Env["RUNNER"] = "${{ runner.temp }}"
+
+	ctx := context.Background()
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	rc.evaluateEnv(ctx)
+
+	assert.Equal(t, "workflow-linux", rc.Env["WORKFLOW"])
+	assert.Equal(t, "workflow-linux-job", rc.Env["JOB"])
+	assert.Equal(t, "workflow-linux-job-cli", rc.Env["CLI"])
+	assert.Equal(t, "job", rc.Env["OVERRIDE"])
+	assert.Equal(t, "${{ runner.temp }}", rc.Env["RUNNER"])
+	assert.Equal(t, "workflow-linux", rc.ExprEval.Interpolate(ctx, "${{ env.WORKFLOW }}"))
+}
+
+func TestRunContextContainerOptions(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: options
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    container:
+      image: node:16
+      options: --cpus ${{ matrix.cpus }} --memory 1g
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+
+	rc := &RunContext{
+		Config: &Config{ContainerOptions: "--privileged"},
+		Matrix: map[string]interface{}{"cpus": 2},
+		Run: &model.Run{
+			Workflow: workflow,
+			JobID:    "test",
+		},
+	}
+	ctx := context.Background()
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+
+	assert.Equal(t, "--cpus 2 --memory 1g", rc.options(ctx))
+}
+
+func TestRunContextGetJobContextStatus(t *testing.T) {
+	rc := &RunContext{
+		Name:   "job",
+		Config: &Config{},
+		Run: &model.Run{
+			JobID:    "job",
+			Workflow: &model.Workflow{Name: "workflow", Jobs: map[string]*model.Job{"job": {}}},
+		},
+		StepResults: map[string]*model.StepResult{
+			"lint": {Conclusion: model.StepStatusSuccess, Outcome: model.StepStatusFailure},
+		},
+	}
+	// a step which failed with continue-on-error doesn't fail the job
+	assert.Equal(t, "success", rc.getJobContext().Status)
+
+	rc.StepResults["test"] = &model.StepResult{Conclusion: model.StepStatusFailure, Outcome: model.StepStatusFailure}
+	assert.Equal(t, "failure", rc.getJobContext().Status)
+}
+
+func TestRunContextNetworkName(t *testing.T) {
+	newRunContext := func(name string, networks *runNetworks) *RunContext {
+		return &RunContext{
+			Name:   name,
+			Config: &Config{},
+			Run: &model.Run{
+				JobID:    name,
+				Workflow: &model.Workflow{Name: "workflow", Jobs: map[string]*model.Job{name: {}}},
+			},
+			networks: networks,
+		}
+	}
+	networks := newRunNetworks(&Config{})
+	rc := newRunContext("job", networks)
+
+	// the jobs of a run share its network
+	networkName, create := rc.networkName()
+	assert.True(t, create)
+	assert.Equal(t, networks.name(false), networkName)
+	assert.Equal(t, networkName, rc.getJobContext().Container.Network)
+	other, _ := newRunContext("other", networks).networkName()
+	assert.Equal(t, networkName, other)
+	other, _ = newRunContext("job", newRunNetworks(&Config{})).networkName()
+	assert.NotEqual(t, networkName, other)
+
+	rc.Config.ContainerNetworkMode = "host"
+	networkName, create = rc.networkName()
+	assert.False(t, create)
+	assert.Equal(t, "host", networkName)
+	assert.Equal(t, "host", rc.getJobContext().Container.Network)
+
+	rc.Config = &Config{NoNetwork: true}
+	networkName, create = rc.networkName()
+	assert.False(t, create)
+	assert.Equal(t, "none", networkName)
+
+	rc.Run.Workflow.Jobs["job"].Services = map[string]*model.ContainerSpec{"redis": {Image: "redis"}}
+	networkName, create = rc.networkName()
+	assert.True(t, create)
+	assert.Equal(t, networks.name(true), networkName)
+
+	_, err := New(&Config{NoNetwork: true, ContainerNetworkMode: "host"})
+	assert.EqualError(t, err, "--no-network can't be combined with --network host")
+}
+
+func TestRunNetworks(t *testing.T) {
+	ctx := common.WithDryrun(context.Background(), true)
+	networks := newRunNetworks(&Config{})
+
+	assert.NoError(t, networks.create(false)(ctx))
+	assert.NoError(t, networks.create(false)(ctx))
+	assert.NoError(t, networks.create(true)(ctx))
+	assert.Equal(t, []string{networks.name(false), networks.name(true)}, networks.created)
+
+	assert.NoError(t, networks.remove()(ctx))
+	assert.Empty(t, networks.created)
+
+	// the networks of the reused containers are kept
+	networks = newRunNetworks(&Config{ReuseContainers: true})
+	assert.Equal(t, createContainerName("act", "network"), networks.name(false))
+	assert.NoError(t, networks.create(false)(ctx))
+	assert.NoError(t, networks.remove()(ctx))
+	assert.Len(t, networks.created, 1)
+}
+
+func TestRunContextContainerLabels(t *testing.T) {
+	rc := &RunContext{
+		Name:   "job",
+		Config: &Config{Workdir: "/repo"},
+		Run: &model.Run{
+			JobID:    "job",
+			Workflow: &model.Workflow{Name: "workflow", Jobs: map[string]*model.Job{"job": {}}},
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		LabelJob:      "job",
+		LabelWorkflow: "workflow",
+		LabelWorkdir:  "/repo",
+	}, rc.containerLabels(""))
+	assert.Equal(t, "redis", rc.containerLabels("redis")[LabelService])
+}
+
+func TestCheckImagePlatform(t *testing.T) {
+	tables := []struct {
+		imagePlatform string
+		platform      string
+		explicit      bool
+		shells        map[string]string
+		warning       bool
+		err           string
+	}{
+		{"linux/amd64", "linux/amd64", false, map[string]string{"build": "bash"}, false, ""},
+		{"linux/arm64", "linux/amd64", false, nil, true, ""},
+		{"linux/arm64", "linux/amd64", true, nil, false, "image 'node:16' is built for linux/arm64 but --container-architecture requested linux/amd64"},
+		{"windows/amd64", "linux/amd64", false, nil, false, "image 'node:16' is built for windows/amd64 and can't run on linux/amd64"},
+		{"linux/amd64", "linux/amd64", false, map[string]string{"build": "cmd /D /E:ON /V:OFF /S /C \"CALL \"{0}\"\""}, false, "step 'build' uses shell 'cmd' which requires a windows image, but image 'node:16' is built for linux/amd64"},
+	}
+
+	for _, table := range tables {
+		t.Run(table.imagePlatform+"-"+table.platform, func(t *testing.T) {
+			warning, err := checkImagePlatform("node:16", table.imagePlatform, table.platform, table.explicit, table.shells)
+			assert.Equal(t, table.warning, warning != "")
+			if table.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, table.err)
+			}
+		})
+	}
+}
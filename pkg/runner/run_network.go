@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// runNetworks are the bridge networks of a run, which the job containers and the services of all
+// its jobs join. A network is created by the first job which needs it and removed once the run is
+// done, the jobs without network access share an internal one.
+type runNetworks struct {
+	id string // part of the names of the networks, empty when the containers are reused
+
+	mu      sync.Mutex
+	created []string
+}
+
+func newRunNetworks(config *Config) *runNetworks {
+	n := &runNetworks{}
+	// the reused job containers are still attached to the networks of the previous run
+	if !config.ReuseContainers {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		n.id = hex.EncodeToString(b)
+	}
+	return n
+}
+
+// name returns the name of the network of the run. The run contexts created without a runner,
+// e.g. to export a job, have no networks and get the names of reused containers.
+func (n *runNetworks) name(internal bool) string {
+	var id string
+	if n != nil {
+		id = n.id
+	}
+	if internal {
+		return createContainerName("act", id, "internal", "network")
+	}
+	return createContainerName("act", id, "network")
+}
+
+// create creates the network unless it was created for another job of the run
+func (n *runNetworks) create(internal bool) common.Executor {
+	return func(ctx context.Context) error {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		name := n.name(internal)
+		for _, created := range n.created {
+			if created == name {
+				return nil
+			}
+		}
+		if err := container.NewDockerNetworkCreateExecutor(name, internal)(ctx); err != nil {
+			return err
+		}
+		n.created = append(n.created, name)
+		return nil
+	}
+}
+
+// remove removes the networks created for the jobs of the run
+func (n *runNetworks) remove() common.Executor {
+	return func(ctx context.Context) error {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if n.id == "" {
+			return nil
+		}
+		for _, name := range n.created {
+			if err := container.NewDockerNetworkRemoveExecutor(name)(ctx); err != nil {
+				common.Logger(ctx).Warnf("Unable to remove the network %s: %v", name, err)
+			}
+		}
+		n.created = nil
+		return nil
+	}
+}
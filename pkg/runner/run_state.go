@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// RunState holds the results of the jobs of a run, so --rerun-failed can run only the jobs
+// which failed and the jobs needing them
+type RunState struct {
+	EventName string               `json:"event_name"`
+	Jobs      map[string]*JobState `json:"jobs"` // by workflow file and job id, e.g. ci.yml/build
+}
+
+// JobState is the result and the outputs of a job of a run
+type JobState struct {
+	Result  string            `json:"result"`
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+func runStateKey(run *model.Run) string {
+	return run.Workflow.File + "/" + run.JobID
+}
+
+// NewRunState returns the state of the jobs of a plan which has run
+func NewRunState(eventName string, plan *model.Plan) *RunState {
+	state := &RunState{
+		EventName: eventName,
+		Jobs:      map[string]*JobState{},
+	}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			job := run.Job()
+			if job == nil {
+				continue
+			}
+			state.Jobs[runStateKey(run)] = &JobState{
+				Result:  job.Result,
+				Outputs: job.Outputs,
+			}
+		}
+	}
+	return state
+}
+
+// ReadRunState reads the state of a previous run
+func ReadRunState(path string) (*RunState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &RunState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Write saves the state for the next run
+func (s *RunState) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// RerunPlan returns the runs of the plan whose jobs didn't succeed in the state and the runs
+// needing them. The other jobs get their result and outputs from the state, so the jobs
+// needing them see them like in the previous run.
+func (s *RunState) RerunPlan(plan *model.Plan) *model.Plan {
+	rerun := map[string]bool{}
+	for _, stage := range plan.Stages {
+		// the stages are ordered by the needs of the jobs
+		for _, run := range stage.Runs {
+			job := run.Job()
+			if job == nil {
+				continue
+			}
+			jobState, ok := s.Jobs[runStateKey(run)]
+			rerun[runStateKey(run)] = !ok || jobState.Result != "success"
+			for _, need := range job.Needs() {
+				if rerun[run.Workflow.File+"/"+need] {
+					rerun[runStateKey(run)] = true
+				}
+			}
+		}
+	}
+
+	rerunPlan := &model.Plan{}
+	for _, stage := range plan.Stages {
+		rerunStage := &model.Stage{}
+		for _, run := range stage.Runs {
+			if rerun[runStateKey(run)] {
+				rerunStage.Runs = append(rerunStage.Runs, run)
+			} else if job := run.Job(); job != nil {
+				job.Result = s.Jobs[runStateKey(run)].Result
+				job.Outputs = s.Jobs[runStateKey(run)].Outputs
+			}
+		}
+		if len(rerunStage.Runs) > 0 {
+			rerunPlan.Stages = append(rerunPlan.Stages, rerunStage)
+		}
+	}
+	return rerunPlan
+}
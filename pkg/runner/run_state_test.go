@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func planJobIDs(plan *model.Plan) [][]string {
+	ids := [][]string{}
+	for _, stage := range plan.Stages {
+		stageIDs := stage.GetJobIDs()
+		sort.Strings(stageIDs)
+		ids = append(ids, stageIDs)
+	}
+	return ids
+}
+
+func TestRunStateRerunPlan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ci.yml"), []byte(`
+on: push
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: exit 0
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.version.outputs.version }}
+    steps:
+      - run: exit 0
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: exit 1
+  deploy:
+    needs: [build, test]
+    runs-on: ubuntu-latest
+    steps:
+      - run: exit 0
+  notify:
+    needs: deploy
+    runs-on: ubuntu-latest
+    steps:
+      - run: exit 0
+  package:
+    needs: build
+    runs-on: ubuntu-latest
+    steps:
+      - run: exit 0
+`), 0o600))
+
+	newPlan := func() *model.Plan {
+		planner, err := model.NewWorkflowPlanner(dir, true)
+		require.NoError(t, err)
+		plan, err := planner.PlanEvent("push")
+		require.NoError(t, err)
+		return plan
+	}
+
+	plan := newPlan()
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			run.Job().Result = "success"
+			if run.JobID == "test" {
+				run.Job().Result = "failure"
+			} else if run.JobID == "build" {
+				run.Job().Outputs["version"] = "1.2.3"
+			} else if run.JobID == "deploy" || run.JobID == "notify" {
+				run.Job().Result = ""
+			}
+		}
+	}
+
+	stateFile := filepath.Join(dir, "state", "last-run.json")
+	require.NoError(t, NewRunState("push", plan).Write(stateFile))
+	state, err := ReadRunState(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, "push", state.EventName)
+
+	plan = newPlan()
+	rerunPlan := state.RerunPlan(plan)
+	assert.Equal(t, [][]string{{"test"}, {"deploy"}, {"notify"}}, planJobIDs(rerunPlan))
+
+	// the jobs which aren't run again keep their results and outputs for the jobs needing them
+	build := plan.Stages[0].Runs[0].Workflow.GetJob("build")
+	assert.Equal(t, "success", build.Result)
+	assert.Equal(t, map[string]string{"version": "1.2.3"}, build.Outputs)
+}
@@ -5,24 +5,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
 	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/oidc"
 )
 
 // Runner provides capabilities to run GitHub actions
 type Runner interface {
 	NewPlanExecutor(plan *model.Plan) common.Executor
+	NewPullExecutor(plan *model.Plan) common.Executor
+	NewAuditReport(ctx context.Context, plan *model.Plan) (*AuditReport, error)
+	NewExecExecutor(run *model.Run, command []string) common.Executor
+	NewPlanReport(ctx context.Context, plan *model.Plan) (*PlanReport, error)
+	NewShellExport(ctx context.Context, plan *model.Plan) ([]*ExportFile, error)
+	NewComposeExport(ctx context.Context, plan *model.Plan) ([]*ExportFile, error)
 }
 
 // Config contains the config for a new runner
 type Config struct {
 	Actor                              string                     // the user that triggered the event
+	Repository                         string                     // the repository of the github context overriding the one of the git remote, e.g. owner/name
+	Sha                                string                     // the commit sha of the github context overriding HEAD
+	Ref                                string                     // the ref of the github context overriding the one of the event, a branch name is a ref in refs/heads
 	Workdir                            string                     // path to working directory
 	BindWorkdir                        bool                       // bind the workdir to the job container
+	CopyWorkspace                      bool                       // copy the workdir into the job container when the job starts
+	CopyTrackedOnly                    bool                       // copy only the files tracked by git into the job container
+	CopyBack                           []string                   // paths in the workspace copied back to the workdir after the job, owned by the owner of the workdir
+	Submodules                         string                     // "true" or "recursive" initializes the submodules of the workspace in the job container, like the submodules input of actions/checkout
+	LFS                                bool                       // fetch the git lfs files of the workspace in the job container, like the lfs input of actions/checkout
 	EventName                          string                     // name of event to run
 	EventPath                          string                     // path to JSON file to use for event.json in containers
 	DefaultBranch                      string                     // name of the main branch for this repository
@@ -30,10 +51,19 @@ type Config struct {
 	ForcePull                          bool                       // force pulling of the image, even if already present
 	ForceRebuild                       bool                       // force rebuilding local docker image action
 	LogOutput                          bool                       // log the output from docker run
+	OnlyFailures                       bool                       // print only the failing steps with their output, and warnings and errors
 	JSONLogger                         bool                       // use json or text logger
 	Env                                map[string]string          // env for containers
 	Inputs                             map[string]string          // manually passed action inputs
 	Secrets                            map[string]string          // list of secrets
+	Vars                               map[string]string          // list of configuration variables of the vars context
+	Environments                       map[string]*Environment    // secrets and configuration variables of the deployment environments by name
+	PullRequest                        *PullRequest               // pull request of the payload of the pull_request events without EventPath
+	PushRange                          string                     // commits of the payload of the push events without EventPath, before..after
+	EventFields                        map[string]string          // fields of the payload built without EventPath by dotted path, non-nil to build the template of the event
+	MaskPatterns                       []*regexp.Regexp           // values matching these are masked in the output like secrets
+	BreakBefore                        []string                   // glob patterns of the names, ids or actions of the steps to pause before with a debug shell
+	BreakOnFailure                     bool                       // open a debug shell when a step fails
 	Token                              string                     // GitHub token
 	InsecureSecrets                    bool                       // switch hiding output when printing to terminal
 	Platforms                          map[string]string          // list of platforms
@@ -43,18 +73,114 @@ type Config struct {
 	ContainerDaemonSocket              string                     // Path to Docker daemon socket
 	ContainerOptions                   string                     // Options for the job container
 	UseGitIgnore                       bool                       // controls if paths in .gitignore should not be copied into container, default true
-	GitHubInstance                     string                     // GitHub instance to use, default "github.com"
+	GitHubInstance                     string                     // host name or URL of the GitHub instance to use, default "github.com"
+	Compat                             string                     // run the workflows like the runners of gitea or forgejo, empty for GitHub
+	DefaultActionsURL                  string                     // server the actions without a URL in uses: are cloned from, empty for the default of the compat mode
 	ContainerCapAdd                    []string                   // list of kernel capabilities to add to the containers
 	ContainerCapDrop                   []string                   // list of kernel capabilities to remove from the containers
 	AutoRemove                         bool                       // controls if the container is automatically removed upon workflow completion
+	KeepContainers                     bool                       // keep the containers and the network of failed jobs for act attach, even with AutoRemove
+	IsolateSteps                       bool                       // run each step in a fresh job container sharing the workspace and the volumes of the job
 	ArtifactServerPath                 string                     // the path where the artifact server stores uploads
 	ArtifactServerAddr                 string                     // the address the artifact server binds to
 	ArtifactServerPort                 string                     // the port the artifact server binds to
+	ArtifactsExportDir                 string                     // the directory the artifacts and ExportPaths of the legs are exported to, in <run id>/<job>
+	ExportPaths                        []string                   // paths in the workspace exported with the artifacts after the job
 	NoSkipCheckout                     bool                       // do not skip actions/checkout
 	RemoteName                         string                     // remote name in local git repo config
 	ReplaceGheActionWithGithubCom      []string                   // Use actions from GitHub Enterprise instance to GitHub
 	ReplaceGheActionTokenWithGithubCom string                     // Token of private action repo on GitHub.
 	Matrix                             map[string]map[string]bool // Matrix config to run
+	JobLeg                             *JobLeg                    // the leg of a matrix selected with -j by its rendered name
+	MaxWorkflows                       int                        // number of the workflows running at the same time, 0 for all of them
+	MaxJobs                            int                        // number of the jobs running at the same time across the workflows, 0 for the CPUs of the container engine
+	MicroVM                            MicroVMConfig              // the microVMs of the jobs of the microvm:// platforms
+	GPUs                               string                     // GPUs of the job containers like docker run --gpus, e.g. all
+	GPURuntime                         string                     // container runtime of the job containers with GPUs, e.g. nvidia
+	PrewarmContainers                  int                        // number of job containers created ahead for the legs of a matrix waiting for max-parallel
+	UseBuildKit                        bool                       // build docker actions with BuildKit instead of the legacy builder
+	BuildCacheFrom                     []string                   // images to consider as cache sources when building docker actions
+	BuildCacheTo                       []string                   // cache export destinations when building docker actions
+	StepCache                          bool                       // skip run steps whose script, env and workspace match a previous successful execution
+	JobCache                           bool                       // skip the legs of jobs whose definition, inputs and workspace match a previous successful run
+	Prefetch                           bool                       // fetch the remote actions and pull the images of the plan in parallel before the jobs start
+	Checkpoint                         bool                       // commit the job container after each successful step, a failed job can resume from it
+	Resume                             bool                       // continue the jobs with a checkpoint after their last successful step
+	RunnerVersion                      string                     // GitHub runner release whose behaviour is emulated
+	FailOnDeprecation                  bool                       // fail steps that use deprecated features
+	SetupFastPath                      bool                       // satisfy setup-go and setup-node from the tool cache
+	ServiceTimeout                     time.Duration              // how long to wait for service containers to become healthy
+	ServiceLogs                        bool                       // stream the output of service containers into the run output
+	ServiceLogsDir                     string                     // directory for the log files of service containers
+	ContainerNetworkMode               string                     // network of the job containers, by default the jobs of a run share a network
+	Seed                               int64                      // seed for generated ids, temp directory names and tokens, 0 uses random values
+	NoNetwork                          bool                       // run job containers without network access, only services are reachable
+	ProxyEnv                           bool                       // pass the proxy environment variables to the containers
+	CACertificates                     []string                   // PEM files with additional CA certificates trusted in the job containers
+	SandboxProfile                     string                     // sandbox profile of the jobs without a sandbox:<profile> runs-on label
+	JobSandboxProfiles                 map[string]string          // sandbox profiles per job id
+	ForwardSSHAgent                    bool                       // mount the ssh agent and known_hosts of the host into the containers
+	ForwardGitConfig                   bool                       // mount .gitconfig and .git-credentials of the host into the containers
+	ToolCache                          string                     // volume name or absolute host directory mounted as RUNNER_TOOL_CACHE
+	ProjectVolumes                     string                     // repository the named volumes of the workspaces, tool cache and act cache are namespaced by, which persist between runs, empty for volumes per run
+	VolumeRetention                    time.Duration              // project volumes unused for longer are removed at the start of the run, 0 keeps them
+	Mounts                             []Mount                    // paths of the host bound or copied into the job containers
+	HostEnv                            []string                   // patterns of the environment variables of the host passed to the job and step containers, e.g. AWS_*
+	ActionOfflineMode                  bool                       // use only the actions already in the action cache, never fetch them
+	ProvisionNode                      bool                       // run node actions with the node release of the runner when the job container lacks its major version
+	ActionReplacements                 map[string]string          // action references replaced by other remote actions or actions in the working directory
+	StrictMode                         model.StrictMode           // what the planner does with the unknown keys of the reusable workflows
+	ActionVerifier                     string                     // command which verifies the clone of a remote action or reusable workflow before it runs, e.g. gitsign verify
+	ImageVerifier                      string                     // command which verifies the images of docker:// steps and actions once they were pulled, e.g. cosign verify
+	EnforceActionVerification          bool                       // refuse the actions and images the verifiers fail for instead of warning about them
+	SkipSteps                          []string                   // glob patterns of the names, ids or actions of the steps to skip
+	Retries                            []StepRetry                // steps which run again when they fail, the x-act-retry of a step takes precedence
+	RetryBackoff                       time.Duration              // time to wait before the second attempt of a failed step, doubled for each further one
+	IDTokenIssuer                      *oidc.Handler              // emulated OIDC token endpoint of the jobs, nil if disabled
+	IDTokenClaims                      map[string]string          // claims of the OIDC tokens overriding the defaults, e.g. sub
+	Timings                            *Timings                   // records the durations of the plan, jobs, image pulls, container creation and steps, nil if disabled
+	ResourceUsages                     *ResourceUsages            // records the peak memory and CPU time of the steps in the job containers, nil if disabled
+	GitHubReport                       string                     // reports the results of the jobs to GitHub with the commit statuses or the check runs API, GitHubReportStatuses or GitHubReportChecks, empty if disabled
+	FailureRecap                       *FailureRecap              // keeps the end of the output of the failed steps to print it again after the run, nil if disabled
+	Summary                            *Summary                   // collects the results of the jobs for the table at the end of the run, nil if disabled
+	OOMWatch                           bool                       // warn about steps which come close to the memory limit of the job container
+	SBOM                               *SBOM                      // records the images and remote actions of the run, nil if disabled
+}
+
+// ActionCacheDir returns the directory of the clones of the actions and the other caches of act
+func (config *Config) ActionCacheDir() string {
+	var xdgCache string
+	var ok bool
+	if xdgCache, ok = os.LookupEnv("XDG_CACHE_HOME"); !ok || xdgCache == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			xdgCache = filepath.Join(home, ".cache")
+		} else if xdgCache, err = filepath.Abs("."); err != nil {
+			// It's almost impossible to get here, so the temp dir is a good fallback
+			xdgCache = os.TempDir()
+		}
+	}
+	return filepath.Join(xdgCache, "act")
+}
+
+// Environment is a deployment environment, its secrets and configuration variables override the
+// ones of the repository for the jobs with environment: set to it
+type Environment struct {
+	Secrets map[string]string
+	Vars    map[string]string
+}
+
+func (e *Environment) secrets() map[string]string {
+	if e == nil {
+		return nil
+	}
+	return e.Secrets
+}
+
+func (e *Environment) vars() map[string]string {
+	if e == nil {
+		return nil
+	}
+	return e.Vars
 }
 
 type caller struct {
@@ -62,21 +188,66 @@ type caller struct {
 }
 
 type runnerImpl struct {
-	config    *Config
-	eventJSON string
-	caller    *caller // the job calling this runner (caller of a reusable workflow)
+	config     *Config
+	eventJSON  string
+	caller     *caller // the job calling this runner (caller of a reusable workflow)
+	slots      jobSlots
+	jobCache   *jobCache
+	prefetched *prefetched // the actions and images fetched before the jobs started
+	networks   *runNetworks
+	// the git state of the workdir, scanned once for the jobs of the run
+	workspaceGit *workspaceGit
 }
 
 // New Creates a new Runner
 func New(runnerConfig *Config) (Runner, error) {
 	runner := &runnerImpl{
-		config: runnerConfig,
+		config:       runnerConfig,
+		jobCache:     &jobCache{},
+		prefetched:   newPrefetched(),
+		networks:     newRunNetworks(runnerConfig),
+		workspaceGit: &workspaceGit{},
 	}
 
 	return runner.configure()
 }
 
 func (runner *runnerImpl) configure() (Runner, error) {
+	if _, err := runnerFeaturesFor(runner.config.RunnerVersion); err != nil {
+		return nil, err
+	}
+	if runner.config.Compat != "" {
+		if _, err := lookupCompatMode(runner.config.Compat); err != nil {
+			return nil, err
+		}
+	}
+	if runner.config.SandboxProfile != "" {
+		if _, err := lookupSandboxProfile(runner.config.SandboxProfile); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range runner.config.JobSandboxProfiles {
+		if _, err := lookupSandboxProfile(name); err != nil {
+			return nil, err
+		}
+	}
+	for pattern, replacement := range runner.config.ActionReplacements {
+		if err := validateActionReplacement(pattern, replacement); err != nil {
+			return nil, err
+		}
+	}
+	if runner.config.EnforceActionVerification && runner.config.ActionVerifier == "" && runner.config.ImageVerifier == "" {
+		return nil, fmt.Errorf("--enforce-action-verification requires an --action-verifier or an --image-verifier")
+	}
+	for _, cacheTo := range runner.config.BuildCacheTo {
+		if !container.IsInlineCacheTo(cacheTo) && !runner.config.UseBuildKit {
+			return nil, fmt.Errorf("--cache-to %s requires BuildKit, only type=inline is supported with --no-buildkit", cacheTo)
+		}
+	}
+	if runner.config.NoNetwork && runner.config.ContainerNetworkMode != "" {
+		return nil, fmt.Errorf("--no-network can't be combined with --network %s", runner.config.ContainerNetworkMode)
+	}
+
 	runner.eventJSON = "{}"
 	if runner.config.EventPath != "" {
 		log.Debugf("Reading event.json from %s", runner.config.EventPath)
@@ -85,6 +256,14 @@ func (runner *runnerImpl) configure() (Runner, error) {
 			return nil, err
 		}
 		runner.eventJSON = string(eventJSONBytes)
+	} else if event, err := buildEvent(context.Background(), runner.config); err != nil {
+		return nil, err
+	} else if event != nil {
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		runner.eventJSON = string(eventJSON)
 	} else if len(runner.config.Inputs) != 0 {
 		eventMap := map[string]map[string]string{
 			"inputs": runner.config.Inputs,
@@ -100,8 +279,43 @@ func (runner *runnerImpl) configure() (Runner, error) {
 
 // NewPlanExecutor ...
 func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
+	// the workflows run at the same time, the legs of their jobs log with the name padded to the longest one
+	var maxJobNameLenMu sync.Mutex
 	maxJobNameLen := 0
+	// the slots of the run, the runner of a reusable workflow has the ones of its caller
+	slots := runner.slots
+
+	workflows := plan.Workflows()
+	workflowPipeline := make([]common.Executor, 0, len(workflows))
+	for _, workflowPlan := range workflows {
+		workflowPipeline = append(workflowPipeline, runner.newWorkflowExecutor(workflowPlan, &slots, &maxJobNameLenMu, &maxJobNameLen))
+	}
+
+	maxWorkflows := runner.config.MaxWorkflows
+	if maxWorkflows <= 0 || maxWorkflows > len(workflows) {
+		maxWorkflows = len(workflows)
+	}
+
+	executor := runner.checkDaemonFeatures(plan).Then(runner.expireProjectVolumes()).Then(runner.prefetch(plan)).Then(runner.checkPlanImages(plan)).Then(func(ctx context.Context) error {
+		if slots == nil {
+			slots = make(jobSlots, runner.maxJobs(ctx))
+		}
+		return nil
+	}).Then(common.NewParallelExecutor(maxWorkflows, workflowPipeline...))
+	// the networks are removed once the jobs of the run are done, the reusable workflows are part of the run of their caller
+	if runner.caller == nil && runner.networks != nil {
+		executor = executor.Finally(runner.networks.remove())
+	}
+	executor = executor.Then(handleFailure(plan))
+	return func(ctx context.Context) error {
+		// the github contexts of the legs of the jobs read the repository once
+		return executor(git.WithLookupCache(ctx))
+	}
+}
 
+// newWorkflowExecutor runs the stages of the plan of a workflow one after the other, the legs of
+// the jobs of a stage take a slot of the run each
+func (runner *runnerImpl) newWorkflowExecutor(plan *model.Plan, slots *jobSlots, maxJobNameLenMu *sync.Mutex, maxJobNameLen *int) common.Executor {
 	stagePipeline := make([]common.Executor, 0)
 	for i := range plan.Stages {
 		stage := plan.Stages[i]
@@ -123,9 +337,15 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 					log.Errorf("Error while get job's matrix: %v", err)
 				} else {
 					matrixes = selectMatrixes(m, runner.config.Matrix)
+					if len(matrixes) == 0 && len(m) > 0 {
+						log.Warnf("No combination of the matrix of job '%s' matches --matrix", run.JobID)
+					}
 				}
 				log.Debugf("Final matrix after applying user inclusions '%v'", matrixes)
 
+				// steps whose inputs were checked here aren't checked again when the job runs
+				validatedSteps := validateCachedActionInputs(ctx, runner.config.ActionCacheDir(), runner.config.ActionReplacements, job)
+
 				maxParallel := 4
 				if job.Strategy != nil {
 					maxParallel = job.Strategy.MaxParallel
@@ -135,36 +355,69 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 					maxParallel = len(matrixes)
 				}
 
-				for i, matrix := range matrixes {
-					matrix := matrix
-					rc := runner.newRunContext(ctx, run, matrix)
-					rc.JobName = rc.Name
-					if len(matrixes) > 1 {
-						rc.Name = fmt.Sprintf("%s-%d", rc.Name, i+1)
+				// the legs replace the expressions of the outputs of the job when they finish
+				var definition string
+				if runner.config.JobCache && job.Type() == model.JobTypeDefault {
+					var err error
+					if definition, err = jobDefinition(job); err != nil {
+						log.Warnf("Unable to fingerprint the job '%s', running it: %v", run.JobID, err)
 					}
-					if len(rc.String()) > maxJobNameLen {
-						maxJobNameLen = len(rc.String())
+				}
+
+				legs := runner.matrixLegs(ctx, run, matrixes)
+				var pool *prewarmPool
+				if runner.config.PrewarmContainers > 0 && job.Type() == model.JobTypeDefault && maxParallel > 0 && len(legs) > maxParallel {
+					pool = newPrewarmPool(runner.config.PrewarmContainers)
+				}
+
+				for _, rc := range legs {
+					rc := rc
+					rc.validatedSteps = validatedSteps
+					rc.jobDefinition = definition
+					rc.jobSlots = *slots
+					maxJobNameLenMu.Lock()
+					if len(rc.String()) > *maxJobNameLen {
+						*maxJobNameLen = len(rc.String())
+					}
+					maxJobNameLenMu.Unlock()
+					executor := rc.reportedToGitHub(rc.summarized(rc.timed(TimingJob, rc.String(), rc.Executor())))
+					if job.Type() == model.JobTypeDefault {
+						executor = rc.jobSlots.run(executor)
 					}
 					stageExecutor = append(stageExecutor, func(ctx context.Context) error {
-						jobName := fmt.Sprintf("%-*s", maxJobNameLen, rc.String())
-						return rc.Executor()(common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, jobName, rc.Config, &rc.Masks, matrix)))
+						maxJobNameLenMu.Lock()
+						jobName := fmt.Sprintf("%-*s", *maxJobNameLen, rc.String())
+						maxJobNameLenMu.Unlock()
+						rc.prewarmed = pool.claim(rc)
+						return executor(common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, jobName, rc.Config, &rc.Masks, rc.Matrix)))
 					})
 				}
-				pipeline = append(pipeline, common.NewParallelExecutor(maxParallel, stageExecutor...))
-			}
-			var ncpu int
-			info, err := container.GetHostInfo(ctx)
-			if err != nil {
-				log.Errorf("failed to obtain container engine info: %s", err)
-				ncpu = 1 // sane default?
-			} else {
-				ncpu = info.NCPU
+				matrixExecutor := common.NewParallelExecutor(maxParallel, stageExecutor...)
+				if pool != nil {
+					// the first legs start right away, the job containers of the others are created while they run
+					matrixExecutor = pool.run(legs[maxParallel:], matrixExecutor)
+				}
+				pipeline = append(pipeline, matrixExecutor)
 			}
-			return common.NewParallelExecutor(ncpu, pipeline...)(ctx)
+			// the slots limit the jobs running at once
+			return common.NewParallelExecutor(len(pipeline), pipeline...)(ctx)
 		})
 	}
+	return common.NewPipelineExecutor(stagePipeline...)
+}
 
-	return common.NewPipelineExecutor(stagePipeline...).Then(handleFailure(plan))
+// expireProjectVolumes removes the project volumes unused for longer than VolumeRetention
+func (runner *runnerImpl) expireProjectVolumes() common.Executor {
+	return func(ctx context.Context) error {
+		if runner.config.VolumeRetention <= 0 || common.Dryrun(ctx) {
+			return nil
+		}
+		cacheDir := runner.config.ActionCacheDir()
+		if err := removeExpiredVolumes(ctx, cacheDir, runner.config.VolumeRetention, removeDockerVolume); err != nil {
+			common.Logger(ctx).Warnf("Unable to remove the expired project volumes: %v", err)
+		}
+		return nil
+	}
 }
 
 func handleFailure(plan *model.Plan) common.Executor {
@@ -172,7 +425,12 @@ func handleFailure(plan *model.Plan) common.Executor {
 		for _, stage := range plan.Stages {
 			for _, run := range stage.Runs {
 				if run.Job().Result == "failure" {
-					return fmt.Errorf("Job '%s' failed", run.String())
+					name := run.String()
+					if strings.Contains(name, "${{") {
+						// the legs of the matrix have the rendered names, the summary shows the failed ones
+						name = run.JobID
+					}
+					return common.WithErrorClass(fmt.Errorf("Job '%s' failed", name), common.ErrorClassJob)
 				}
 			}
 		}
@@ -180,16 +438,69 @@ func handleFailure(plan *model.Plan) common.Executor {
 	}
 }
 
+// JobLeg selects the legs of the matrix of a job by the rendered name of the job, e.g.
+// -j "test (ubuntu-latest, node 18)" for the name "test (${{ matrix.os }}, node ${{ matrix.node }})"
+type JobLeg struct {
+	JobID string
+	Name  string
+}
+
+// matrixLegs returns the run contexts of the combinations of the matrix of the job. A leg is named
+// by the name of the job with its expressions evaluated, the number of the leg is appended if the
+// name has no expressions or the names of the legs aren't unique. Only the legs named like JobLeg
+// are returned for its job.
+func (runner *runnerImpl) matrixLegs(ctx context.Context, run *model.Run, matrixes []map[string]interface{}) []*RunContext {
+	leg := runner.config.JobLeg
+	if leg != nil && leg.JobID != run.JobID {
+		leg = nil
+	}
+	rcs := make([]*RunContext, 0, len(matrixes))
+	names := map[string]int{}
+	for i, matrix := range matrixes {
+		rc := runner.newRunContext(ctx, run, matrix)
+		if leg != nil && rc.Name != leg.Name {
+			continue
+		}
+		rc.JobName = rc.Name
+		rc.jobIndex = i
+		rc.jobTotal = len(matrixes)
+		names[rc.Name]++
+		rcs = append(rcs, rc)
+	}
+	if leg != nil && len(rcs) == 0 {
+		common.Logger(ctx).Warnf("No combination of the matrix of job '%s' is named '%s'", run.JobID, leg.Name)
+	}
+
+	rendered := strings.Contains(run.String(), "${{")
+	for i, rc := range rcs {
+		if len(rcs) > 1 && (!rendered || names[rc.Name] > 1) {
+			rc.Name = fmt.Sprintf("%s-%d", rc.Name, i+1)
+		}
+	}
+	return rcs
+}
+
+// selectMatrixes returns the combinations of the matrix with one of the values of --matrix for
+// each of its keys. The keys of nested values are joined with dots (e.g. config.os), a key which
+// none of the combinations has doesn't restrict the matrix.
 func selectMatrixes(originalMatrixes []map[string]interface{}, targetMatrixValues map[string]map[string]bool) []map[string]interface{} {
+	keys := make([]string, 0, len(targetMatrixValues))
+	for key := range targetMatrixValues {
+		for _, original := range originalMatrixes {
+			if _, ok := matrixValue(original, key); ok {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+
 	matrixes := make([]map[string]interface{}, 0)
 	for _, original := range originalMatrixes {
 		flag := true
-		for key, val := range original {
-			if allowedVals, ok := targetMatrixValues[key]; ok {
-				valToString := fmt.Sprintf("%v", val)
-				if _, ok := allowedVals[valToString]; !ok {
-					flag = false
-				}
+		for _, key := range keys {
+			val, ok := matrixValue(original, key)
+			if !ok || !targetMatrixValues[key][fmt.Sprintf("%v", val)] {
+				flag = false
 			}
 		}
 		if flag {
@@ -199,14 +510,34 @@ func selectMatrixes(originalMatrixes []map[string]interface{}, targetMatrixValue
 	return matrixes
 }
 
+// matrixValue returns the value of a key of a combination, or of a nested value for a key with dots
+func matrixValue(matrix map[string]interface{}, key string) (interface{}, bool) {
+	if val, ok := matrix[key]; ok {
+		return val, true
+	}
+	name, rest, found := strings.Cut(key, ".")
+	if !found {
+		return nil, false
+	}
+	nested, ok := matrix[name].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return matrixValue(nested, rest)
+}
+
 func (runner *runnerImpl) newRunContext(ctx context.Context, run *model.Run, matrix map[string]interface{}) *RunContext {
 	rc := &RunContext{
-		Config:      runner.config,
-		Run:         run,
-		EventJSON:   runner.eventJSON,
-		StepResults: make(map[string]*model.StepResult),
-		Matrix:      matrix,
-		caller:      runner.caller,
+		Config:       runner.config,
+		Run:          run,
+		EventJSON:    runner.eventJSON,
+		StepResults:  make(map[string]*model.StepResult),
+		Matrix:       matrix,
+		caller:       runner.caller,
+		jobCache:     runner.jobCache,
+		prefetched:   runner.prefetched,
+		networks:     runner.networks,
+		workspaceGit: runner.workspaceGit,
 	}
 	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
 	rc.Name = rc.ExprEval.Interpolate(ctx, run.String())
@@ -92,7 +92,7 @@ func TestGraphMissingFirst(t *testing.T) {
 	assert.NoError(t, err)
 
 	plan, err := planner.PlanEvent("push")
-	assert.EqualError(t, err, "unable to build dependency graph for no first (no-first.yml)")
+	assert.EqualError(t, err, "unable to build dependency graph for no first (no-first.yml): job 'second' needs unknown job 'first'")
 	assert.NotNil(t, plan)
 	assert.Equal(t, 0, len(plan.Stages))
 }
@@ -109,7 +109,7 @@ func TestGraphWithMissing(t *testing.T) {
 	plan, err := planner.PlanEvent("push")
 	assert.NotNil(t, plan)
 	assert.Equal(t, 0, len(plan.Stages))
-	assert.EqualError(t, err, "unable to build dependency graph for missing (missing.yml)")
+	assert.EqualError(t, err, "unable to build dependency graph for missing (missing.yml): job 'second' needs unknown job 'first'")
 	assert.Contains(t, buf.String(), "unable to build dependency graph for missing (missing.yml)")
 	log.SetOutput(out)
 }
@@ -236,6 +236,7 @@ func TestRunEvent(t *testing.T) {
 		{workdir, "uses-composite", "push", "", platforms, secrets},
 		{workdir, "uses-composite-with-error", "push", "Job 'failing-composite-action' failed", platforms, secrets},
 		{workdir, "uses-nested-composite", "push", "", platforms, secrets},
+		{workdir, "uses-nested-composite-relative", "push", "", platforms, secrets},
 		{workdir, "remote-action-composite-js-pre-with-defaults", "push", "", platforms, secrets},
 		{workdir, "uses-workflow", "push", "", platforms, map[string]string{"secret": "keep_it_private"}},
 		{workdir, "uses-workflow", "pull_request", "", platforms, map[string]string{"secret": "keep_it_private"}},
@@ -258,6 +259,7 @@ func TestRunEvent(t *testing.T) {
 		{workdir, "job-container-non-root", "push", "", platforms, secrets},
 		{workdir, "job-container-invalid-credentials", "push", "failed to handle credentials: failed to interpolate container.credentials.password", platforms, secrets},
 		{workdir, "container-hostname", "push", "", platforms, secrets},
+		{workdir, "services", "push", "", platforms, secrets},
 		{workdir, "remote-action-docker", "push", "", platforms, secrets},
 		{workdir, "remote-action-js", "push", "", platforms, secrets},
 		{workdir, "remote-action-js-node-user", "push", "", platforms, secrets}, // Test if this works with non root container
@@ -347,6 +349,8 @@ func TestRunEventHostEnvironment(t *testing.T) {
 			{workdir, "uses-composite", "push", "", platforms, secrets},
 			{workdir, "uses-composite-with-error", "push", "Job 'failing-composite-action' failed", platforms, secrets},
 			{workdir, "uses-nested-composite", "push", "", platforms, secrets},
+			{workdir, "uses-nested-composite-relative", "push", "", platforms, secrets},
+			{workdir, "action-missing-required-input", "push", "Job 'test' failed", platforms, secrets},
 			{workdir, "act-composite-env-test", "push", "", platforms, secrets},
 
 			// Eval
@@ -585,6 +589,88 @@ func TestRunEventPullRequest(t *testing.T) {
 	tjfi.runTest(context.Background(), t, &Config{EventPath: filepath.Join(workdir, workflowPath, "event.json")})
 }
 
+func TestMatrixLegs(t *testing.T) {
+	planner, err := model.NewWorkflowPlanner("../model/testdata/matrix-job-name/push.yml", true)
+	assert.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	assert.NoError(t, err)
+	runs := map[string]*model.Run{}
+	for _, run := range plan.Stages[0].Runs {
+		runs[run.JobID] = run
+	}
+
+	ctx := context.Background()
+	r := &runnerImpl{config: &Config{}}
+	matrixes := []map[string]interface{}{{"os": "ubuntu-latest", "node": 16}, {"os": "ubuntu-latest", "node": 18}}
+	names := func(rcs []*RunContext) []string {
+		names := []string{}
+		for _, rc := range rcs {
+			names = append(names, rc.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"test (ubuntu-latest, node 16)", "test (ubuntu-latest, node 18)"}, names(r.matrixLegs(ctx, runs["test"], matrixes)))
+	assert.Equal(t, []string{"Lint-1", "Lint-2"}, names(r.matrixLegs(ctx, runs["lint"], matrixes)))
+
+	// legs with the same rendered name are numbered
+	same := []map[string]interface{}{{"os": "ubuntu-latest", "node": 16}, {"os": "ubuntu-latest", "node": 16}}
+	assert.Equal(t, []string{"test (ubuntu-latest, node 16)-1", "test (ubuntu-latest, node 16)-2"}, names(r.matrixLegs(ctx, runs["test"], same)))
+
+	r.config.JobLeg = &JobLeg{JobID: "test", Name: "test (ubuntu-latest, node 18)"}
+	legs := r.matrixLegs(ctx, runs["test"], matrixes)
+	assert.Equal(t, []string{"test (ubuntu-latest, node 18)"}, names(legs))
+	assert.Equal(t, 1, legs[0].jobIndex)
+	assert.Equal(t, []string{"Lint-1", "Lint-2"}, names(r.matrixLegs(ctx, runs["lint"], matrixes)), "the leg only selects legs of its job")
+}
+
+func TestSelectMatrixes(t *testing.T) {
+	matrixes := []map[string]interface{}{
+		{"os": "ubuntu-latest", "node": 18, "config": map[string]interface{}{"arch": "amd64"}},
+		{"os": "ubuntu-latest", "node": 20, "config": map[string]interface{}{"arch": "arm64"}},
+		{"os": "windows-latest", "node": 20},
+		{"experimental": true},
+	}
+
+	assert.Equal(t, matrixes, selectMatrixes(matrixes, map[string]map[string]bool{}))
+	assert.Equal(t, matrixes[1:2], selectMatrixes(matrixes, map[string]map[string]bool{
+		"os":   {"ubuntu-latest": true},
+		"node": {"20": true},
+	}))
+	assert.Equal(t, matrixes[1:3], selectMatrixes(matrixes, map[string]map[string]bool{
+		"node": {"20": true},
+	}))
+	assert.Equal(t, matrixes[1:2], selectMatrixes(matrixes, map[string]map[string]bool{
+		"config.arch": {"arm64": true},
+	}))
+	assert.Equal(t, matrixes, selectMatrixes(matrixes, map[string]map[string]bool{
+		"python": {"3.11": true},
+	}), "keys which aren't in the matrix don't restrict it")
+	assert.Empty(t, selectMatrixes(matrixes, map[string]map[string]bool{
+		"os": {"macos-latest": true},
+	}))
+	assert.Equal(t, []map[string]interface{}{{}}, selectMatrixes([]map[string]interface{}{{}}, map[string]map[string]bool{
+		"os": {"ubuntu-latest": true},
+	}), "jobs without a matrix aren't restricted")
+}
+
+func TestHandleFailure(t *testing.T) {
+	workflow := &model.Workflow{Name: "CI", Jobs: map[string]*model.Job{
+		"build": {Result: "success"},
+		"test":  {Result: "failure"},
+	}}
+	plan := &model.Plan{Stages: []*model.Stage{{Runs: []*model.Run{
+		{Workflow: workflow, JobID: "build"},
+		{Workflow: workflow, JobID: "test"},
+	}}}}
+
+	err := handleFailure(plan)(context.Background())
+	assert.EqualError(t, err, "Job 'test' failed")
+	assert.Equal(t, common.ErrorClassJob, common.ErrorClassOf(err))
+
+	workflow.Jobs["test"].Result = "success"
+	assert.NoError(t, handleFailure(plan)(context.Background()))
+}
+
 func TestRunMatrixWithUserDefinedInclusions(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// runnerFeatures describes the behaviour of the GitHub Actions runner release act emulates
+type runnerFeatures struct {
+	version            string
+	unsecureCommands   bool                                            // ::set-env:: and ::add-path:: work without ACTIONS_ALLOW_UNSECURE_COMMANDS
+	deprecatedUnsecure bool                                            // ::set-env:: and ::add-path:: print deprecation warnings when they work
+	deprecatedCommands bool                                            // ::set-output:: and ::save-state:: print deprecation warnings
+	deprecatedNode12   bool                                            // node12 actions print deprecation warnings
+	nodeRuntimes       map[model.ActionRunsUsing]bool                  // node versions the runner ships with
+	forcedNodeRuntimes map[model.ActionRunsUsing]model.ActionRunsUsing // node versions that are replaced by a newer one
+}
+
+// runnerFeaturesFor returns the features of the given runner release, an empty
+// version keeps the default act behaviour
+func runnerFeaturesFor(version string) (*runnerFeatures, error) {
+	features := &runnerFeatures{
+		version:            version,
+		unsecureCommands:   true,
+		deprecatedUnsecure: true,
+		deprecatedCommands: true,
+		deprecatedNode12:   true,
+		nodeRuntimes: map[model.ActionRunsUsing]bool{
+			model.ActionRunsUsingNode12: true,
+			model.ActionRunsUsingNode16: true,
+			model.ActionRunsUsingNode20: true,
+		},
+		forcedNodeRuntimes: map[model.ActionRunsUsing]model.ActionRunsUsing{},
+	}
+	if version == "" {
+		return features, nil
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid runner version '%s': %w", version, err)
+	}
+	atLeast := func(release string) bool {
+		return !v.LessThan(semver.MustParse(release))
+	}
+
+	// https://github.blog/changelog/2020-10-01-github-actions-deprecating-set-env-and-add-path-commands/
+	features.unsecureCommands = !atLeast("2.273.5")
+	features.deprecatedUnsecure = false
+	// https://github.blog/changelog/2022-10-11-github-actions-deprecating-save-state-and-set-output-commands/
+	features.deprecatedCommands = atLeast("2.298.2")
+	// https://github.blog/changelog/2022-09-22-github-actions-all-actions-will-begin-running-on-node16-instead-of-node12/
+	features.deprecatedNode12 = atLeast("2.297.0")
+	features.nodeRuntimes[model.ActionRunsUsingNode16] = atLeast("2.285.0")
+	// https://github.com/actions/runner/releases/tag/v2.308.0
+	features.nodeRuntimes[model.ActionRunsUsingNode20] = atLeast("2.308.0")
+	// https://github.blog/changelog/2023-05-04-github-actions-all-actions-will-run-on-node16-instead-of-node12/
+	if atLeast("2.309.0") {
+		delete(features.nodeRuntimes, model.ActionRunsUsingNode12)
+		features.forcedNodeRuntimes[model.ActionRunsUsingNode12] = model.ActionRunsUsingNode16
+	}
+	return features, nil
+}
+
+func (rc *RunContext) runnerFeatures() *runnerFeatures {
+	version := ""
+	if rc.Config != nil {
+		version = rc.Config.RunnerVersion
+	}
+	features, err := runnerFeaturesFor(version)
+	if err != nil {
+		// the version is validated when the runner is created
+		features, _ = runnerFeaturesFor("")
+	}
+	return features
+}
+
+// nodeRuntime returns the node runtime the emulated runner uses for an action
+func (rc *RunContext) nodeRuntime(ctx context.Context, action string, using model.ActionRunsUsing) (model.ActionRunsUsing, error) {
+	f := rc.runnerFeatures()
+	if forced, ok := f.forcedNodeRuntimes[using]; ok {
+		rc.deprecated(ctx, "Node.js 12 actions are deprecated. The following actions are run with %s instead: %s. For more information see: https://github.blog/changelog/2023-05-04-github-actions-all-actions-will-run-on-node16-instead-of-node12/", forced, action)
+		return forced, nil
+	}
+	if !f.nodeRuntimes[using] {
+		return "", fmt.Errorf("runner %s does not support actions using %s", f.version, using)
+	}
+	if using == model.ActionRunsUsingNode12 && f.deprecatedNode12 {
+		rc.deprecated(ctx, "Node.js 12 actions are deprecated. Please update the following actions to use Node.js 16: %s. For more information see: https://github.blog/changelog/2022-09-22-github-actions-all-actions-will-begin-running-on-node16-instead-of-node12/", action)
+	}
+	return using, nil
+}
+
+// deprecated prints the warning GitHub shows for a deprecated feature and records it,
+// so the step can be failed with --fail-on-deprecation
+func (rc *RunContext) deprecated(ctx context.Context, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	common.Logger(ctx).Warnf("  \U0001F6A7  %s", msg)
+	rc.Deprecations = append(rc.Deprecations, msg)
+}
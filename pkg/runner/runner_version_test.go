@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunnerFeaturesFor(t *testing.T) {
+	tables := []struct {
+		version            string
+		unsecureCommands   bool
+		deprecatedCommands bool
+		node12             model.ActionRunsUsing
+		node16             bool
+		node20             bool
+	}{
+		{"", true, true, model.ActionRunsUsingNode12, true, true},
+		{"2.272.0", true, false, model.ActionRunsUsingNode12, false, false},
+		{"2.285.0", false, false, model.ActionRunsUsingNode12, true, false},
+		{"2.298.2", false, true, model.ActionRunsUsingNode12, true, false},
+		{"2.308.0", false, true, model.ActionRunsUsingNode12, true, true},
+		{"2.317", false, true, model.ActionRunsUsingNode16, true, true},
+	}
+
+	ctx := context.Background()
+	for _, table := range tables {
+		t.Run(table.version, func(t *testing.T) {
+			features, err := runnerFeaturesFor(table.version)
+			assert.NoError(t, err)
+			assert.Equal(t, table.unsecureCommands, features.unsecureCommands)
+			assert.Equal(t, table.deprecatedCommands, features.deprecatedCommands)
+
+			rc := &RunContext{Config: &Config{RunnerVersion: table.version}}
+			node12, err := rc.nodeRuntime(ctx, "actions/checkout@v2", model.ActionRunsUsingNode12)
+			assert.NoError(t, err)
+			assert.Equal(t, table.node12, node12)
+
+			_, err = rc.nodeRuntime(ctx, "actions/checkout@v3", model.ActionRunsUsingNode16)
+			assert.Equal(t, table.node16, err == nil)
+
+			_, err = rc.nodeRuntime(ctx, "actions/checkout@v4", model.ActionRunsUsingNode20)
+			assert.Equal(t, table.node20, err == nil)
+		})
+	}
+
+	_, err := runnerFeaturesFor("latest")
+	assert.Error(t, err)
+}
+
+func TestDeprecatedCommands(t *testing.T) {
+	ctx := context.Background()
+	rc := &RunContext{
+		Config:      &Config{},
+		CurrentStep: "my-step",
+		StepResults: map[string]*model.StepResult{
+			"my-step": {Outputs: map[string]string{}},
+		},
+	}
+	handler := rc.commandHandler(ctx)
+
+	handler("::set-output name=x::valz\n")
+	handler("::save-state name=x::valz\n")
+	handler("::set-env name=x::valz\n")
+	assert.Len(t, rc.Deprecations, 3)
+	assert.Contains(t, rc.Deprecations[0], "The `set-output` command is deprecated")
+	assert.Contains(t, rc.Deprecations[2], "The `set-env` command is disabled on GitHub")
+
+	rc = &RunContext{Config: &Config{RunnerVersion: "2.250.0"}}
+	rc.commandHandler(ctx)("::set-env name=x::valz\n")
+	assert.Empty(t, rc.Deprecations)
+}
+
+func TestSetEnvDisabledByRunnerVersion(t *testing.T) {
+	ctx := context.Background()
+	newRunContext := func(env map[string]string) *RunContext {
+		return &RunContext{
+			Config: &Config{RunnerVersion: "2.300.0", Env: env},
+			Run: &model.Run{
+				JobID: "test",
+				Workflow: &model.Workflow{
+					Jobs: map[string]*model.Job{"test": {}},
+				},
+			},
+		}
+	}
+
+	rc := newRunContext(nil)
+	rc.commandHandler(ctx)("::set-env name=x::valz\n")
+	assert.Empty(t, rc.Env["x"])
+
+	rc = newRunContext(map[string]string{"ACTIONS_ALLOW_UNSECURE_COMMANDS": "true"})
+	rc.commandHandler(ctx)("::set-env name=x::valz\n")
+	assert.Equal(t, "valz", rc.Env["x"])
+}
@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// SandboxProfile bundles the isolation features of the containers of a job
+type SandboxProfile struct {
+	NoNetwork      bool   // only the services of the job are reachable
+	ReadOnlyRootfs bool   // the root filesystem is read-only, only the workspace, the tool cache and /tmp are writable
+	NoDockerSocket bool   // the docker socket isn't mounted into the job container
+	SeccompProfile string // seccomp profile of the job container, empty for the default of docker
+	Memory         string // memory limit of the job container
+	CPUs           string // cpu limit of the job container
+	PidsLimit      int    // process limit of the job container
+}
+
+// sandboxLabelPrefix selects the sandbox profile of a job in its runs-on labels, e.g. sandbox:untrusted
+const sandboxLabelPrefix = "sandbox:"
+
+var sandboxProfiles = map[string]SandboxProfile{
+	// trusted jobs may use ptrace, e.g. for debuggers
+	"trusted": {
+		SeccompProfile: "unconfined",
+	},
+	// default is the isolation act always used
+	"default": {},
+	// untrusted jobs can't reach the internet, the docker daemon or use unlimited resources
+	"untrusted": {
+		NoNetwork:      true,
+		ReadOnlyRootfs: true,
+		NoDockerSocket: true,
+		Memory:         "4g",
+		CPUs:           "2",
+		PidsLimit:      1024,
+	},
+}
+
+func sandboxProfileNames() string {
+	names := make([]string, 0, len(sandboxProfiles))
+	for name := range sandboxProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func lookupSandboxProfile(name string) (*SandboxProfile, error) {
+	profile, ok := sandboxProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sandbox profile '%s', valid profiles are %s", name, sandboxProfileNames())
+	}
+	return &profile, nil
+}
+
+// sandboxProfile returns the sandbox profile of the job, selected by --job-sandbox,
+// a sandbox:<profile> runs-on label or --sandbox, in this order. It is nil if the job
+// has no profile.
+func (rc *RunContext) sandboxProfile() (*SandboxProfile, error) {
+	if name, ok := rc.Config.JobSandboxProfiles[rc.Run.JobID]; ok {
+		return lookupSandboxProfile(name)
+	}
+	if job := rc.Run.Job(); job != nil {
+		for _, label := range job.RunsOn() {
+			if strings.HasPrefix(label, sandboxLabelPrefix) {
+				return lookupSandboxProfile(strings.TrimPrefix(label, sandboxLabelPrefix))
+			}
+		}
+	}
+	if rc.Config.SandboxProfile != "" {
+		return lookupSandboxProfile(rc.Config.SandboxProfile)
+	}
+	return nil, nil
+}
+
+// noNetwork reports whether the job container must not have network access
+func (rc *RunContext) noNetwork() bool {
+	profile, _ := rc.sandboxProfile()
+	return rc.Config.NoNetwork || (profile != nil && profile.NoNetwork)
+}
+
+// containerOptions returns the docker run options enforcing the profile
+func (p *SandboxProfile) containerOptions() string {
+	if p == nil {
+		return ""
+	}
+	options := make([]string, 0)
+	if p.ReadOnlyRootfs {
+		options = append(options, "--read-only", "--tmpfs", "/tmp")
+	}
+	if p.SeccompProfile != "" {
+		options = append(options, "--security-opt", "seccomp="+p.SeccompProfile)
+	}
+	if p.Memory != "" {
+		options = append(options, "--memory", p.Memory)
+	}
+	if p.CPUs != "" {
+		options = append(options, "--cpus", p.CPUs)
+	}
+	if p.PidsLimit > 0 {
+		options = append(options, "--pids-limit", fmt.Sprint(p.PidsLimit))
+	}
+	return shellquote.Join(options...)
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestSandboxProfile(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: sandbox
+on: push
+jobs:
+  build:
+    runs-on: [ubuntu-latest, "sandbox:untrusted"]
+    steps:
+      - run: echo
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+
+	newRunContext := func(jobID string, config *Config) *RunContext {
+		rc := &RunContext{
+			Config: config,
+			Run:    &model.Run{Workflow: workflow, JobID: jobID},
+		}
+		rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+		return rc
+	}
+	ctx := context.Background()
+
+	rc := newRunContext("build", &Config{ContainerOptions: "--cpus 4"})
+	profile, err := rc.sandboxProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, sandboxProfiles["untrusted"], *profile)
+	assert.Equal(t, "--cpus 4 --read-only --tmpfs /tmp --memory 4g --cpus 2 --pids-limit 1024", rc.options(ctx))
+	assert.True(t, rc.noNetwork())
+	binds, _ := rc.GetBindsAndMounts()
+	assert.NotContains(t, binds, "/var/run/docker.sock:/var/run/docker.sock")
+
+	rc = newRunContext("deploy", &Config{})
+	profile, err = rc.sandboxProfile()
+	assert.NoError(t, err)
+	assert.Nil(t, profile)
+	assert.Equal(t, "", rc.options(ctx))
+	assert.False(t, rc.noNetwork())
+	binds, _ = rc.GetBindsAndMounts()
+	assert.Contains(t, binds, "/var/run/docker.sock:/var/run/docker.sock")
+
+	rc = newRunContext("deploy", &Config{SandboxProfile: "trusted"})
+	assert.Equal(t, "--security-opt seccomp=unconfined", rc.options(ctx))
+
+	rc = newRunContext("build", &Config{SandboxProfile: "trusted", JobSandboxProfiles: map[string]string{"build": "default"}})
+	profile, err = rc.sandboxProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, SandboxProfile{}, *profile)
+
+	rc = newRunContext("deploy", &Config{SandboxProfile: "paranoid"})
+	_, err = rc.sandboxProfile()
+	assert.EqualError(t, err, "unknown sandbox profile 'paranoid', valid profiles are default, trusted, untrusted")
+
+	_, err = New(&Config{JobSandboxProfiles: map[string]string{"build": "paranoid"}})
+	assert.Error(t, err)
+}
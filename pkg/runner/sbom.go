@@ -0,0 +1,357 @@
+package runner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// SBOMKind is the role of a component of the SBOM in the run
+type SBOMKind string
+
+const (
+	SBOMJobImage         SBOMKind = "job-image"         // the image of a job container
+	SBOMServiceImage     SBOMKind = "service-image"     // the image of a service container
+	SBOMStepImage        SBOMKind = "step-image"        // the image of a docker action or a docker:// step
+	SBOMAction           SBOMKind = "action"            // a remote action
+	SBOMReusableWorkflow SBOMKind = "reusable-workflow" // the repository of a remote reusable workflow
+)
+
+// SBOMComponent is an image or a remote action a run used
+type SBOMComponent struct {
+	Kind    SBOMKind `json:"kind"`
+	Name    string   `json:"name"`             // the repository of the image or the action
+	Version string   `json:"version"`          // the tag of the image or the ref of the action
+	Digest  string   `json:"digest,omitempty"` // the digest or id of the image, the commit of the action
+	URL     string   `json:"url,omitempty"`    // the repository the action was cloned from
+	Jobs    []string `json:"jobs"`
+}
+
+// SBOM records the images and remote actions of a run, it is safe for the parallel jobs
+type SBOM struct {
+	Version string // the version of act, reported as the tool creating the SBOM
+
+	mu         sync.Mutex
+	components []*SBOMComponent
+}
+
+func (s *SBOM) add(job string, component *SBOMComponent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.components {
+		if c.Kind == component.Kind && c.Name == component.Name && c.Version == component.Version && c.Digest == component.Digest {
+			for _, j := range c.Jobs {
+				if j == job {
+					return
+				}
+			}
+			c.Jobs = append(c.Jobs, job)
+			return
+		}
+	}
+	component.Jobs = []string{job}
+	s.components = append(s.components, component)
+}
+
+// AddImage records an image a job used with its digest, the image id for local images
+func (s *SBOM) AddImage(job string, kind SBOMKind, image string, digest string) {
+	if s == nil {
+		return
+	}
+	name, version := splitImageReference(image)
+	s.add(job, &SBOMComponent{
+		Kind:    kind,
+		Name:    name,
+		Version: version,
+		Digest:  digest,
+	})
+}
+
+// AddAction records a remote action or reusable workflow a job used with the commit its ref resolved to
+func (s *SBOM) AddAction(job string, kind SBOMKind, name string, ref string, sha string, cloneURL string) {
+	if s == nil {
+		return
+	}
+	s.add(job, &SBOMComponent{
+		Kind:    kind,
+		Name:    name,
+		Version: ref,
+		Digest:  sha,
+		URL:     cloneURL,
+	})
+}
+
+// Components returns the components sorted by kind and name
+func (s *SBOM) Components() []*SBOMComponent {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	components := append([]*SBOMComponent{}, s.components...)
+	sort.SliceStable(components, func(i, j int) bool {
+		if components[i].Kind != components[j].Kind {
+			return components[i].Kind < components[j].Kind
+		}
+		if components[i].Name != components[j].Name {
+			return components[i].Name < components[j].Name
+		}
+		return components[i].Version < components[j].Version
+	})
+	return components
+}
+
+// splitImageReference splits an image into its repository and its tag or digest
+func splitImageReference(image string) (string, string) {
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		return name, digest
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// purl returns the package URL of the component
+func (c *SBOMComponent) purl() string {
+	switch c.Kind {
+	case SBOMAction, SBOMReusableWorkflow:
+		parts := strings.SplitN(c.Name, "/", 3)
+		purl := "pkg:github/" + parts[0]
+		if len(parts) > 1 {
+			purl += "/" + parts[1]
+		}
+		version := c.Digest
+		if version == "" {
+			version = c.Version
+		}
+		purl += "@" + url.PathEscape(version)
+		if len(parts) == 3 {
+			purl += "#" + parts[2]
+		}
+		return purl
+	}
+	name := c.Name
+	repositoryURL := ""
+	if i := strings.Index(name, "/"); i >= 0 && strings.ContainsAny(name[:i], ".:") {
+		repositoryURL, name = name[:i], name[i+1:]
+	}
+	version := c.Digest
+	if !strings.HasPrefix(version, "sha256:") {
+		version = c.Version
+	}
+	purl := fmt.Sprintf("pkg:docker/%s@%s", name, url.PathEscape(version))
+	qualifiers := []string{}
+	if repositoryURL != "" {
+		qualifiers = append(qualifiers, "repository_url="+url.QueryEscape(repositoryURL))
+	}
+	if version != c.Version && !strings.HasPrefix(c.Version, "sha256:") {
+		qualifiers = append(qualifiers, "tag="+url.QueryEscape(c.Version))
+	}
+	if len(qualifiers) > 0 {
+		purl += "?" + strings.Join(qualifiers, "&")
+	}
+	return purl
+}
+
+func newSBOMUUID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+type cycloneDXComponent struct {
+	Type               string                       `json:"type"`
+	BOMRef             string                       `json:"bom-ref"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version"`
+	Purl               string                       `json:"purl"`
+	Hashes             []cycloneDXHash              `json:"hashes,omitempty"`
+	ExternalReferences []cycloneDXExternalReference `json:"externalReferences,omitempty"`
+	Properties         []cycloneDXProperty          `json:"properties"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// WriteCycloneDX writes the components as a CycloneDX 1.5 JSON document
+func (s *SBOM) WriteCycloneDX(w io.Writer) error {
+	components := []cycloneDXComponent{}
+	for _, c := range s.Components() {
+		component := cycloneDXComponent{
+			Type:       "container",
+			BOMRef:     fmt.Sprintf("%s:%s", c.Kind, c.purl()),
+			Name:       c.Name,
+			Version:    c.Version,
+			Purl:       c.purl(),
+			Properties: []cycloneDXProperty{{Name: "act:kind", Value: string(c.Kind)}},
+		}
+		switch c.Kind {
+		case SBOMAction, SBOMReusableWorkflow:
+			component.Type = "application"
+			if c.Digest != "" {
+				component.Hashes = []cycloneDXHash{{Alg: "SHA-1", Content: c.Digest}}
+			}
+			if c.URL != "" {
+				component.ExternalReferences = []cycloneDXExternalReference{{Type: "vcs", URL: c.URL}}
+			}
+		default:
+			if strings.HasPrefix(c.Digest, "sha256:") {
+				component.Hashes = []cycloneDXHash{{Alg: "SHA-256", Content: strings.TrimPrefix(c.Digest, "sha256:")}}
+			}
+		}
+		for _, job := range c.Jobs {
+			component.Properties = append(component.Properties, cycloneDXProperty{Name: "act:job", Value: job})
+		}
+		components = append(components, component)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + newSBOMUUID(),
+		"version":      1,
+		"metadata": map[string]interface{}{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"tools": map[string]interface{}{
+				"components": []map[string]string{{"type": "application", "name": "act", "version": s.Version}},
+			},
+		},
+		"components": components,
+	})
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+	Comment          string            `json:"comment"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// WriteSPDX writes the components as an SPDX 2.3 JSON document
+func (s *SBOM) WriteSPDX(w io.Writer) error {
+	packages := []spdxPackage{}
+	relationships := []spdxRelationship{}
+	for i, c := range s.Components() {
+		pkg := spdxPackage{
+			Name:             c.Name,
+			SPDXID:           fmt.Sprintf("SPDXRef-Package-%d", i+1),
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs:     []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: c.purl()}},
+			Comment:          fmt.Sprintf("%s used by %s", c.Kind, strings.Join(c.Jobs, ", ")),
+		}
+		switch c.Kind {
+		case SBOMAction, SBOMReusableWorkflow:
+			if c.URL != "" {
+				pkg.DownloadLocation = "git+" + c.URL
+				if c.Digest != "" {
+					pkg.DownloadLocation += "@" + c.Digest
+				}
+			}
+			if c.Digest != "" {
+				pkg.Checksums = []spdxChecksum{{Algorithm: "SHA1", ChecksumValue: c.Digest}}
+			}
+		default:
+			if strings.HasPrefix(c.Digest, "sha256:") {
+				pkg.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: strings.TrimPrefix(c.Digest, "sha256:")}}
+			}
+		}
+		packages = append(packages, pkg)
+		relationships = append(relationships, spdxRelationship{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: pkg.SPDXID,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              "act run",
+		"documentNamespace": "https://github.com/nektos/act/sbom/" + newSBOMUUID(),
+		"creationInfo": map[string]interface{}{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: act-" + s.Version},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	})
+}
+
+// recordImage adds the image to the SBOM of the run once it was pulled or built
+func (rc *RunContext) recordImage(kind SBOMKind, image string) common.Executor {
+	return func(ctx context.Context) error {
+		if rc.Config == nil || rc.Config.SBOM == nil || common.Dryrun(ctx) {
+			return nil
+		}
+		digest, err := container.ImageDigest(ctx, image)
+		if err != nil {
+			common.Logger(ctx).Debugf("Unable to look up the digest of %s: %v", image, err)
+		}
+		rc.Config.SBOM.AddImage(rc.String(), kind, image, digest)
+		return nil
+	}
+}
+
+// recordAction adds a remote action or reusable workflow cloned into dir to the SBOM of the run
+func (rc *RunContext) recordAction(ctx context.Context, kind SBOMKind, name string, ref string, cloneURL string, dir string) {
+	if rc.Config == nil || rc.Config.SBOM == nil || common.Dryrun(ctx) {
+		return
+	}
+	sha, err := headCommit(dir)
+	if err != nil {
+		common.Logger(ctx).Debugf("Unable to resolve the commit of %s@%s: %v", name, ref, err)
+	}
+	rc.Config.SBOM.AddAction(rc.String(), kind, name, ref, sha, cloneURL)
+}
@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestSplitImageReference(t *testing.T) {
+	table := []struct {
+		image   string
+		name    string
+		version string
+	}{
+		{"node:16-buster-slim", "node", "16-buster-slim"},
+		{"ubuntu", "ubuntu", "latest"},
+		{"localhost:5000/org/image", "localhost:5000/org/image", "latest"},
+		{"ghcr.io/org/image:v1", "ghcr.io/org/image", "v1"},
+		{"alpine@sha256:51b67269f354137895d43f3b3d810bfacd3945438e94dc5ac55fdac340352f48", "alpine", "sha256:51b67269f354137895d43f3b3d810bfacd3945438e94dc5ac55fdac340352f48"},
+	}
+	for _, tt := range table {
+		name, version := splitImageReference(tt.image)
+		assert.Equal(t, tt.name, name, tt.image)
+		assert.Equal(t, tt.version, version, tt.image)
+	}
+}
+
+func TestSBOMComponentPurl(t *testing.T) {
+	table := []struct {
+		component SBOMComponent
+		purl      string
+	}{
+		{SBOMComponent{Kind: SBOMAction, Name: "actions/checkout", Version: "v4", Digest: "8ade135a41bc03ea155e62e844d188df1ea18608"}, "pkg:github/actions/checkout@8ade135a41bc03ea155e62e844d188df1ea18608"},
+		{SBOMComponent{Kind: SBOMAction, Name: "org/repo/path/action", Version: "v1"}, "pkg:github/org/repo@v1#path/action"},
+		{SBOMComponent{Kind: SBOMJobImage, Name: "node", Version: "16", Digest: "sha256:abc"}, "pkg:docker/node@sha256:abc?tag=16"},
+		{SBOMComponent{Kind: SBOMServiceImage, Name: "ghcr.io/org/db", Version: "v2"}, "pkg:docker/org/db@v2?repository_url=ghcr.io"},
+		{SBOMComponent{Kind: SBOMStepImage, Name: "alpine", Version: "sha256:abc", Digest: "sha256:abc"}, "pkg:docker/alpine@sha256:abc"},
+	}
+	for _, tt := range table {
+		assert.Equal(t, tt.purl, tt.component.purl())
+	}
+}
+
+func newTestSBOM() *SBOM {
+	sbom := &SBOM{Version: "1.0.0"}
+	sbom.AddImage("ci/build", SBOMJobImage, "node:16-buster-slim", "sha256:0123")
+	sbom.AddImage("ci/test", SBOMJobImage, "node:16-buster-slim", "sha256:0123")
+	sbom.AddImage("ci/test", SBOMServiceImage, "postgres:15", "")
+	sbom.AddAction("ci/build", SBOMAction, "actions/checkout", "v4", "8ade135a41bc03ea155e62e844d188df1ea18608", "https://github.com/actions/checkout")
+	sbom.AddAction("ci/build", SBOMAction, "actions/checkout", "v4", "8ade135a41bc03ea155e62e844d188df1ea18608", "https://github.com/actions/checkout")
+	return sbom
+}
+
+func TestSBOMComponents(t *testing.T) {
+	assert.Equal(t, []*SBOMComponent{
+		{Kind: SBOMAction, Name: "actions/checkout", Version: "v4", Digest: "8ade135a41bc03ea155e62e844d188df1ea18608", URL: "https://github.com/actions/checkout", Jobs: []string{"ci/build"}},
+		{Kind: SBOMJobImage, Name: "node", Version: "16-buster-slim", Digest: "sha256:0123", Jobs: []string{"ci/build", "ci/test"}},
+		{Kind: SBOMServiceImage, Name: "postgres", Version: "15", Jobs: []string{"ci/test"}},
+	}, newTestSBOM().Components())
+
+	// recording is a no-op when disabled
+	var sbom *SBOM
+	sbom.AddImage("ci/build", SBOMJobImage, "node:16", "")
+	assert.Empty(t, sbom.Components())
+}
+
+func TestSBOMWriteCycloneDX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, newTestSBOM().WriteCycloneDX(buf))
+
+	var bom struct {
+		BOMFormat   string `json:"bomFormat"`
+		SpecVersion string `json:"specVersion"`
+		Components  []struct {
+			Type   string `json:"type"`
+			Name   string `json:"name"`
+			Purl   string `json:"purl"`
+			Hashes []struct {
+				Alg     string `json:"alg"`
+				Content string `json:"content"`
+			} `json:"hashes"`
+			Properties []cycloneDXProperty `json:"properties"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &bom))
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "1.5", bom.SpecVersion)
+	require.Len(t, bom.Components, 3)
+	assert.Equal(t, "application", bom.Components[0].Type)
+	assert.Equal(t, "SHA-1", bom.Components[0].Hashes[0].Alg)
+	assert.Equal(t, "container", bom.Components[1].Type)
+	assert.Equal(t, "pkg:docker/node@sha256:0123?tag=16-buster-slim", bom.Components[1].Purl)
+	assert.Equal(t, "0123", bom.Components[1].Hashes[0].Content)
+	assert.Equal(t, []cycloneDXProperty{{Name: "act:kind", Value: "job-image"}, {Name: "act:job", Value: "ci/build"}, {Name: "act:job", Value: "ci/test"}}, bom.Components[1].Properties)
+	assert.Empty(t, bom.Components[2].Hashes)
+}
+
+func TestSBOMWriteSPDX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, newTestSBOM().WriteSPDX(buf))
+
+	var doc struct {
+		SPDXVersion string             `json:"spdxVersion"`
+		Packages    []spdxPackage      `json:"packages"`
+		Relations   []spdxRelationship `json:"relationships"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	require.Len(t, doc.Packages, 3)
+	assert.Equal(t, "git+https://github.com/actions/checkout@8ade135a41bc03ea155e62e844d188df1ea18608", doc.Packages[0].DownloadLocation)
+	assert.Equal(t, []spdxChecksum{{Algorithm: "SHA1", ChecksumValue: "8ade135a41bc03ea155e62e844d188df1ea18608"}}, doc.Packages[0].Checksums)
+	assert.Equal(t, "job-image used by ci/build, ci/test", doc.Packages[1].Comment)
+	assert.Equal(t, "NOASSERTION", doc.Packages[2].DownloadLocation)
+	assert.Len(t, doc.Relations, 3)
+}
+
+func TestRecordAction(t *testing.T) {
+	dir := t.TempDir()
+	sbom := &SBOM{}
+	rc := &RunContext{
+		Name:   "build",
+		Run:    &model.Run{JobID: "build", Workflow: &model.Workflow{Name: "ci", Jobs: map[string]*model.Job{"build": {}}}},
+		Config: &Config{SBOM: sbom},
+	}
+	// the commit is left out when the clone can't be resolved
+	rc.recordAction(context.Background(), SBOMAction, "org/repo", "v1", "https://github.com/org/repo", dir)
+	assert.Equal(t, []*SBOMComponent{{Kind: SBOMAction, Name: "org/repo", Version: "v1", URL: "https://github.com/org/repo", Jobs: []string{"ci/build"}}}, sbom.Components())
+}
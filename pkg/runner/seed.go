@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/fnv"
+	"io"
+	mathrand "math/rand"
+	"strconv"
+)
+
+// seededReader returns a random source for the given purpose. With --seed it is
+// derived from the seed and the purpose, so snapshot tests see the same values on
+// every machine, otherwise it is crypto/rand.
+func (rc *RunContext) seededReader(purpose ...string) io.Reader {
+	if rc.Config == nil || rc.Config.Seed == 0 {
+		return rand.Reader
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strconv.FormatInt(rc.Config.Seed, 10)))
+	for _, p := range purpose {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(p))
+	}
+	//nolint:gosec // the values are only reproducible placeholders
+	return mathrand.New(mathrand.NewSource(int64(h.Sum64())))
+}
+
+// randomHex returns n random bytes hex encoded
+func (rc *RunContext) randomHex(n int, purpose ...string) string {
+	b := make([]byte, n)
+	_, _ = io.ReadFull(rc.seededReader(purpose...), b)
+	return hex.EncodeToString(b)
+}
+
+// seededRunID returns the run id used if GITHUB_RUN_ID isn't set
+func (rc *RunContext) seededRunID() string {
+	if rc.Config == nil || rc.Config.Seed == 0 {
+		return "1"
+	}
+	b := make([]byte, 4)
+	_, _ = io.ReadFull(rc.seededReader("run_id"), b)
+	return strconv.FormatUint(uint64(binary.BigEndian.Uint32(b)), 10)
+}
@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeededValues(t *testing.T) {
+	seeded := &RunContext{Config: &Config{Seed: 42}}
+	again := &RunContext{Config: &Config{Seed: 42}}
+	other := &RunContext{Config: &Config{Seed: 43}}
+
+	assert.Equal(t, seeded.seededRunID(), again.seededRunID())
+	assert.NotEqual(t, seeded.seededRunID(), other.seededRunID())
+	assert.Equal(t, seeded.randomHex(8, "tmp", "job"), again.randomHex(8, "tmp", "job"))
+	assert.NotEqual(t, seeded.randomHex(8, "tmp", "job"), seeded.randomHex(8, "tmp", "other-job"))
+	assert.Len(t, seeded.randomHex(8, "tmp", "job"), 16)
+
+	env := map[string]string{}
+	t.Setenv("ACTIONS_RUNTIME_TOKEN", "")
+	setActionRuntimeVars(seeded, env)
+	assert.Equal(t, seeded.randomHex(20, "actions_runtime_token"), env["ACTIONS_RUNTIME_TOKEN"])
+
+	random := &RunContext{Config: &Config{}}
+	assert.Equal(t, "1", random.seededRunID())
+	assert.NotEqual(t, random.randomHex(8, "tmp"), random.randomHex(8, "tmp"))
+	setActionRuntimeVars(random, env)
+	assert.Equal(t, "token", env["ACTIONS_RUNTIME_TOKEN"])
+}
@@ -0,0 +1,215 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+const defaultServiceTimeout = 5 * time.Minute
+
+// newServiceContainers creates the references to the service containers of the job, keyed by service id
+func (rc *RunContext) newServiceContainers(ctx context.Context) (map[string]container.ExecutionsEnvironment, error) {
+	job := rc.Run.Job()
+	services := make(map[string]container.ExecutionsEnvironment, len(job.Services))
+	networkName, _ := rc.networkName()
+	for id, spec := range job.Services {
+		if spec == nil {
+			continue
+		}
+		username, password, err := rc.handleContainerCredentials(ctx, spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to handle credentials of service %s: %w", id, err)
+		}
+
+		envKeys := make([]string, 0, len(spec.Env))
+		for k := range spec.Env {
+			envKeys = append(envKeys, k)
+		}
+		sort.Strings(envKeys)
+		env := make([]string, 0, len(spec.Env))
+		for _, k := range envKeys {
+			env = append(env, fmt.Sprintf("%s=%s", k, rc.ExprEval.Interpolate(ctx, spec.Env[k])))
+		}
+		env = append(env, rc.containerProxyEnv()...)
+
+		name := createContainerName(rc.jobContainerName(), id)
+		logWriter, err := rc.serviceLogWriter(ctx, id, name)
+		if err != nil {
+			return nil, err
+		}
+
+		binds, mounts := containerSpecVolumes(interpolateList(ctx, rc.ExprEval, spec.Volumes))
+		services[id] = container.NewContainer(&container.NewContainerInput{
+			Name:           name,
+			Image:          rc.ExprEval.Interpolate(ctx, spec.Image),
+			Username:       username,
+			Password:       password,
+			Env:            env,
+			Binds:          binds,
+			Mounts:         mounts,
+			NetworkMode:    networkName,
+			Privileged:     rc.Config.Privileged,
+			UsernsMode:     rc.Config.UsernsMode,
+			Platform:       rc.Config.ContainerArchitecture,
+			Options:        rc.containerSpecOptions(ctx, spec.Options),
+			Ports:          interpolateList(ctx, rc.ExprEval, spec.Ports),
+			NetworkAliases: []string{id},
+			Labels:         rc.containerLabels(id),
+			Stdout:         logWriter,
+			Stderr:         logWriter,
+			StreamOutput:   logWriter != nil,
+		})
+	}
+	return services, nil
+}
+
+// serviceLogWriter returns the writer for the output of a service container,
+// or nil if neither --service-logs nor --service-logs-dir are used
+func (rc *RunContext) serviceLogWriter(ctx context.Context, id string, name string) (io.Writer, error) {
+	writers := make([]io.Writer, 0, 2)
+	if rc.Config.ServiceLogs {
+		rawLogger := common.Logger(ctx).WithField("raw_output", true)
+		writers = append(writers, common.NewLineWriter(func(s string) bool {
+			rawLogger.Infof("[%s] %s", id, s)
+			return true
+		}))
+	}
+	if rc.Config.ServiceLogsDir != "" {
+		if err := os.MkdirAll(rc.Config.ServiceLogsDir, 0o755); err != nil {
+			return nil, err
+		}
+		f, err := os.Create(filepath.Join(rc.Config.ServiceLogsDir, name+".log"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the log file of service %s: %w", id, err)
+		}
+		rc.serviceLogFiles = append(rc.serviceLogFiles, f)
+		writers = append(writers, f)
+	}
+	if len(writers) == 0 {
+		return nil, nil
+	}
+	return io.MultiWriter(writers...), nil
+}
+
+// interpolateList evaluates the expressions in values like the ports and volumes of a container,
+// without an expression evaluator the values stay as they are
+func interpolateList(ctx context.Context, ee ExpressionEvaluator, values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	if ee == nil {
+		return values
+	}
+	interpolated := make([]string, 0, len(values))
+	for _, value := range values {
+		interpolated = append(interpolated, ee.Interpolate(ctx, value))
+	}
+	return interpolated
+}
+
+func (rc *RunContext) startServiceContainers() common.Executor {
+	return func(ctx context.Context) error {
+		executors := make([]common.Executor, 0, len(rc.ServiceContainers))
+		for id, c := range rc.ServiceContainers {
+			image := rc.ExprEval.Interpolate(ctx, rc.Run.Job().Services[id].Image)
+			executors = append(executors, common.NewPipelineExecutor(
+				common.NewInfoExecutor("\U0001f680  Start service %s", id),
+				rc.timed(TimingPull, "service "+id, c.Pull(rc.Config.ForcePull && !rc.prefetched.hasImage(image))),
+				rc.recordImage(SBOMServiceImage, image),
+				rc.timed(TimingCreate, "service "+id, c.Create(nil, nil)),
+				c.Start(false),
+				rc.inspectServiceContainer(id),
+			))
+		}
+		return common.NewPipelineExecutor(executors...)(ctx)
+	}
+}
+
+// inspectServiceContainer records the ID and the host ports of a started service for
+// job.services.<service_id>.id and job.services.<service_id>.ports
+func (rc *RunContext) inspectServiceContainer(id string) common.Executor {
+	return func(ctx context.Context) error {
+		if common.Dryrun(ctx) {
+			return nil
+		}
+		info, err := container.InspectContainer(ctx, createContainerName(rc.jobContainerName(), id))
+		if err != nil {
+			return fmt.Errorf("failed to inspect service %s: %w", id, err)
+		}
+		if rc.ServicePorts == nil {
+			rc.ServicePorts = map[string]map[string]string{}
+		}
+		if rc.ServiceIDs == nil {
+			rc.ServiceIDs = map[string]string{}
+		}
+		rc.ServicePorts[id] = info.Ports
+		rc.ServiceIDs[id] = info.ID
+		return nil
+	}
+}
+
+func (rc *RunContext) removeServiceContainers() common.Executor {
+	return func(ctx context.Context) error {
+		executors := make([]common.Executor, 0, len(rc.ServiceContainers))
+		for _, c := range rc.ServiceContainers {
+			executors = append(executors, c.Remove().Finally(c.Close()))
+		}
+		return common.NewPipelineExecutor(executors...).Finally(rc.closeServiceLogFiles())(ctx)
+	}
+}
+
+func (rc *RunContext) closeServiceLogFiles() common.Executor {
+	return func(ctx context.Context) error {
+		for _, f := range rc.serviceLogFiles {
+			f.Close()
+		}
+		rc.serviceLogFiles = nil
+		return nil
+	}
+}
+
+// waitForServiceContainers blocks until all services with a health check are healthy
+func (rc *RunContext) waitForServiceContainers() common.Executor {
+	return func(ctx context.Context) error {
+		timeout := rc.Config.ServiceTimeout
+		if timeout <= 0 {
+			timeout = defaultServiceTimeout
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		executors := make([]common.Executor, 0, len(rc.ServiceContainers))
+		for id, c := range rc.ServiceContainers {
+			executors = append(executors, waitForServiceContainer(id, c))
+		}
+		return common.NewParallelExecutor(len(executors), executors...)(ctx)
+	}
+}
+
+func waitForServiceContainer(id string, c container.ExecutionsEnvironment) common.Executor {
+	return func(ctx context.Context) error {
+		for {
+			wait, err := c.IsHealthy(ctx)
+			if err != nil {
+				return fmt.Errorf("service %s failed to start: %w", id, err)
+			}
+			if wait == 0 {
+				return nil
+			}
+			common.Logger(ctx).Infof("  \u23F3  Waiting for service %s to become healthy", id)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("service %s did not become healthy in time: %w", id, ctx.Err())
+			case <-time.After(wait):
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWaitForServiceContainer(t *testing.T) {
+	ctx := context.Background()
+
+	cm := &containerMock{}
+	cm.On("IsHealthy", mock.Anything).Return(time.Millisecond, nil).Once()
+	cm.On("IsHealthy", mock.Anything).Return(time.Duration(0), nil).Once()
+	assert.NoError(t, waitForServiceContainer("postgres", cm)(ctx))
+	cm.AssertExpectations(t)
+
+	cm = &containerMock{}
+	cm.On("IsHealthy", mock.Anything).Return(time.Duration(-1), errors.New("container is unhealthy"))
+	assert.EqualError(t, waitForServiceContainer("postgres", cm)(ctx), "service postgres failed to start: container is unhealthy")
+
+	cm = &containerMock{}
+	cm.On("IsHealthy", mock.Anything).Return(time.Hour, nil)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, waitForServiceContainer("postgres", cm)(timeoutCtx), context.DeadlineExceeded)
+}
+
+func TestContainerSpecVolumes(t *testing.T) {
+	binds, mounts := containerSpecVolumes([]string{"/data", "/host/path:/container/path", "my-volume:/var/lib/data"})
+	assert.Equal(t, []string{"/data", "/host/path:/container/path"}, binds)
+	assert.Equal(t, map[string]string{"my-volume": "/var/lib/data"}, mounts)
+}
+
+func TestServiceContainerPortsAndNetwork(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: services
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    services:
+      postgres:
+        image: postgres:14
+        ports:
+          - ${{ matrix.port }}:5432
+          - 6379
+        volumes:
+          - pg-${{ matrix.port }}:/var/lib/postgresql/data
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+
+	rc := &RunContext{
+		Config: &Config{},
+		Matrix: map[string]interface{}{"port": 15432},
+		Run: &model.Run{
+			Workflow: workflow,
+			JobID:    "test",
+		},
+	}
+	ctx := context.Background()
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+
+	assert.Equal(t, []string{"15432:5432", "6379"}, interpolateList(ctx, rc.ExprEval, workflow.Jobs["test"].Services["postgres"].Ports))
+	assert.Equal(t, []string{"pg-15432:/var/lib/postgresql/data"}, interpolateList(ctx, rc.ExprEval, workflow.Jobs["test"].Services["postgres"].Volumes))
+	assert.Equal(t, []string{"${{ matrix.port }}:5432"}, interpolateList(ctx, nil, []string{"${{ matrix.port }}:5432"}))
+	networkName, _ := rc.networkName()
+
+	rc.ServiceContainers = map[string]container.ExecutionsEnvironment{"postgres": &containerMock{}}
+	rc.ServicePorts = map[string]map[string]string{"postgres": {"5432": "15432", "6379": "49153"}}
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	assert.Equal(t, "15432", rc.ExprEval.Interpolate(ctx, "${{ job.services.postgres.ports[5432] }}"))
+	assert.Equal(t, "49153", rc.ExprEval.Interpolate(ctx, "${{ job.services.postgres.ports['6379'] }}"))
+	assert.Equal(t, networkName, rc.ExprEval.Interpolate(ctx, "${{ job.services.postgres.network }}"))
+	assert.Equal(t, createContainerName(rc.jobContainerName(), "postgres"), rc.ExprEval.Interpolate(ctx, "${{ job.services.postgres.id }}"))
+
+	rc.ServiceIDs = map[string]string{"postgres": "4f2a9c"}
+	rc.JobContainerID = "8b1e07"
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	assert.Equal(t, "4f2a9c", rc.ExprEval.Interpolate(ctx, "${{ job.services.postgres.id }}"))
+	assert.Equal(t, "8b1e07", rc.ExprEval.Interpolate(ctx, "${{ job.container.id }}"))
+}
+
+func TestServiceLogWriter(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	ctx := common.WithLogger(context.Background(), logger)
+
+	rc := &RunContext{Config: &Config{}}
+	w, err := rc.serviceLogWriter(ctx, "postgres", "act-job-postgres")
+	assert.NoError(t, err)
+	assert.Nil(t, w)
+
+	dir := filepath.Join(t.TempDir(), "logs")
+	rc.Config = &Config{ServiceLogs: true, ServiceLogsDir: dir}
+	w, err = rc.serviceLogWriter(ctx, "postgres", "act-job-postgres")
+	assert.NoError(t, err)
+	fmt.Fprintln(w, "database system is ready to accept connections")
+	assert.NoError(t, rc.closeServiceLogFiles()(ctx))
+
+	assert.Equal(t, "[postgres] database system is ready to accept connections\n", hook.LastEntry().Message)
+	content, err := os.ReadFile(filepath.Join(dir, "act-job-postgres.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "database system is ready to accept connections\n", string(content))
+}
@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// setupTool describes a setup action which can be satisfied from the tool cache
+type setupTool struct {
+	tool   string // directory of the tool in the tool cache
+	input  string // input with the requested version
+	output string // output with the installed version
+}
+
+var setupTools = map[string]setupTool{
+	"actions/setup-go":   {tool: "go", input: "go-version", output: "go-version"},
+	"actions/setup-node": {tool: "node", input: "node-version", output: "node-version"},
+}
+
+// setupFastPath is a toolchain found in the tool cache
+type setupFastPath struct {
+	setupTool
+	version string
+	binDir  string
+}
+
+// lists the cached versions of a tool in the layout of @actions/tool-cache,
+// <tool cache>/<tool>/<version>/<arch> with a <arch>.complete marker
+const setupFastPathProbe = `dir="$RUNNER_TOOL_CACHE/$1"
+versions=""
+for f in "$dir"/*/"$2".complete; do
+  [ -e "$f" ] && versions="$versions,$(basename "$(dirname "$f")")"
+done
+echo "versions=${versions#,}" > "$3"
+`
+
+var partialVersion = regexp.MustCompile(`^v?\d+(\.\d+)?$`)
+
+// matchToolVersion returns the highest cached version satisfying the version spec
+// of the setup action, or "" if the spec can't be resolved without the network
+func matchToolVersion(spec string, versions []string) string {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return ""
+	}
+	if partialVersion.MatchString(spec) {
+		spec = strings.TrimPrefix(spec, "v") + ".x"
+	}
+	constraint, err := semver.NewConstraint(spec)
+	if err != nil {
+		return ""
+	}
+
+	var best *semver.Version
+	bestName := ""
+	for _, name := range versions {
+		v, err := semver.NewVersion(name)
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best = v
+			bestName = name
+		}
+	}
+	return bestName
+}
+
+// prepareSetupFastPath looks for the toolchain requested from actions/setup-go or
+// actions/setup-node in the tool cache of the job, so the action doesn't need to be fetched
+func (sar *stepActionRemote) prepareSetupFastPath(ctx context.Context) (*setupFastPath, error) {
+	rc := sar.RunContext
+	if !rc.Config.SetupFastPath || sar.remoteAction.Path != "" {
+		return nil, nil
+	}
+	tool, ok := setupTools[strings.ToLower(fmt.Sprintf("%s/%s", sar.remoteAction.Org, sar.remoteAction.Repo))]
+	if !ok {
+		return nil, nil
+	}
+
+	eval := rc.NewExpressionEvaluator(ctx)
+	if eval.Interpolate(ctx, sar.Step.With["check-latest"]) == "true" {
+		return nil, nil
+	}
+	spec := eval.Interpolate(ctx, sar.Step.With[tool.input])
+	if spec == "" {
+		return nil, nil
+	}
+
+	runnerContext := rc.JobContainer.GetRunnerContext(ctx)
+	toolCache := fmt.Sprint(runnerContext["tool_cache"])
+	arch := strings.ToLower(fmt.Sprint(runnerContext["arch"]))
+	resultFile := path.Join(rc.JobContainer.GetActPath(), "workflow", "setupcache.txt")
+
+	err := rc.JobContainer.Exec([]string{"sh", "-c", setupFastPathProbe, "probe", tool.tool, arch, resultFile},
+		map[string]string{"RUNNER_TOOL_CACHE": toolCache}, "", "")(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]string{}
+	if err := rc.JobContainer.UpdateFromEnv(resultFile, &result)(ctx); err != nil {
+		return nil, err
+	}
+
+	version := ""
+	if result["versions"] != "" {
+		version = matchToolVersion(spec, strings.Split(result["versions"], ","))
+	}
+	if version == "" {
+		common.Logger(ctx).Debugf("%s %s isn't in the tool cache, running %s", tool.tool, spec, sar.Step.Uses)
+		return nil, nil
+	}
+	return &setupFastPath{
+		setupTool: tool,
+		version:   version,
+		binDir:    path.Join(toolCache, tool.tool, version, arch, "bin"),
+	}, nil
+}
+
+// runSetupFastPath sets up the cached toolchain like the setup action would
+func (sar *stepActionRemote) runSetupFastPath() common.Executor {
+	return func(ctx context.Context) error {
+		rc := sar.RunContext
+		common.Logger(ctx).Infof("  \u26A1  Using %s %s from the tool cache", sar.setupFastPath.tool, sar.setupFastPath.version)
+		rc.addPath(ctx, sar.setupFastPath.binDir)
+		rc.setOutput(ctx, map[string]string{"name": sar.setupFastPath.output}, sar.setupFastPath.version)
+		return nil
+	}
+}
+
+// setupFastPathAction replaces the action model of a setup action satisfied from
+// the tool cache, it has no pre and post steps
+var setupFastPathAction = &model.Action{
+	Name: "(Tool cache)",
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestMatchToolVersion(t *testing.T) {
+	versions := []string{"1.19.8", "1.20.3", "1.20.5", "18.16.0", "20.1.0"}
+
+	assert.Equal(t, "1.20.5", matchToolVersion("1.20", versions))
+	assert.Equal(t, "1.20.5", matchToolVersion("1.20.x", versions))
+	assert.Equal(t, "1.20.3", matchToolVersion("1.20.3", versions))
+	assert.Equal(t, "1.20.5", matchToolVersion("^1.19", versions))
+	assert.Equal(t, "18.16.0", matchToolVersion("v18", versions))
+	assert.Equal(t, "20.1.0", matchToolVersion(">=18", versions))
+	assert.Equal(t, "", matchToolVersion("1.21", versions))
+	assert.Equal(t, "", matchToolVersion("lts/*", versions))
+	assert.Equal(t, "", matchToolVersion("", versions))
+}
+
+func TestSetupFastPath(t *testing.T) {
+	ctx := context.Background()
+	cm := &containerMock{}
+
+	sar := &stepActionRemote{
+		Step: &model.Step{
+			ID:   "setup",
+			Uses: "actions/setup-go@v4",
+			With: map[string]string{"go-version": "${{ matrix.go }}"},
+		},
+		RunContext: &RunContext{
+			Config:       &Config{SetupFastPath: true},
+			Matrix:       map[string]interface{}{"go": "1.20"},
+			Run:          &model.Run{JobID: "1", Workflow: &model.Workflow{Jobs: map[string]*model.Job{"1": {}}}},
+			JobContainer: cm,
+			StepResults:  map[string]*model.StepResult{"setup": {Outputs: map[string]string{}}},
+			CurrentStep:  "setup",
+		},
+		remoteAction: newRemoteAction("actions/setup-go@v4"),
+	}
+
+	cm.On("Exec", mock.MatchedBy(func(cmd []string) bool {
+		return strings.Join(cmd[len(cmd)-3:], " ") == "go "+strings.ToLower(cm.GetRunnerContext(ctx)["arch"].(string))+" /var/run/act/workflow/setupcache.txt"
+	}), map[string]string{"RUNNER_TOOL_CACHE": "/opt/hostedtoolcache"}, "", "").Return(func(ctx context.Context) error { return nil })
+	cm.On("UpdateFromEnv", "/var/run/act/workflow/setupcache.txt", mock.AnythingOfType("*map[string]string")).Run(func(args mock.Arguments) {
+		(*args.Get(1).(*map[string]string))["versions"] = "1.19.8,1.20.5"
+	}).Return(func(ctx context.Context) error { return nil })
+
+	fastPath, err := sar.prepareSetupFastPath(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, fastPath)
+	assert.Equal(t, "1.20.5", fastPath.version)
+
+	sar.setupFastPath = fastPath
+	assert.NoError(t, sar.runSetupFastPath()(ctx))
+	assert.Equal(t, "1.20.5", sar.RunContext.StepResults["setup"].Outputs["go-version"])
+	assert.Equal(t, fastPath.binDir, sar.RunContext.ExtraPath[0])
+	cm.AssertExpectations(t)
+
+	sar.RunContext.Config.SetupFastPath = false
+	fastPath, err = sar.prepareSetupFastPath(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, fastPath)
+}
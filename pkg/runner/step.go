@@ -105,19 +105,19 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 		actPath := rc.JobContainer.GetActPath()
 
 		outputFileCommand := path.Join("workflow", "outputcmd.txt")
-		(*step.getEnv())["GITHUB_OUTPUT"] = path.Join(actPath, outputFileCommand)
+		(*step.getEnv())["GITHUB_OUTPUT"] = rc.actFilePath(outputFileCommand)
 
 		stateFileCommand := path.Join("workflow", "statecmd.txt")
-		(*step.getEnv())["GITHUB_STATE"] = path.Join(actPath, stateFileCommand)
+		(*step.getEnv())["GITHUB_STATE"] = rc.actFilePath(stateFileCommand)
 
 		pathFileCommand := path.Join("workflow", "pathcmd.txt")
-		(*step.getEnv())["GITHUB_PATH"] = path.Join(actPath, pathFileCommand)
+		(*step.getEnv())["GITHUB_PATH"] = rc.actFilePath(pathFileCommand)
 
 		envFileCommand := path.Join("workflow", "envs.txt")
-		(*step.getEnv())["GITHUB_ENV"] = path.Join(actPath, envFileCommand)
+		(*step.getEnv())["GITHUB_ENV"] = rc.actFilePath(envFileCommand)
 
 		summaryFileCommand := path.Join("workflow", "SUMMARY.md")
-		(*step.getEnv())["GITHUB_STEP_SUMMARY"] = path.Join(actPath, summaryFileCommand)
+		(*step.getEnv())["GITHUB_STEP_SUMMARY"] = rc.actFilePath(summaryFileCommand)
 
 		_ = rc.JobContainer.Copy(actPath, &container.FileEntry{
 			Name: outputFileCommand,
@@ -138,7 +138,16 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 
 		timeoutctx, cancelTimeOut := evaluateStepTimeout(ctx, rc.ExprEval, stepModel)
 		defer cancelTimeOut()
-		err = executor(timeoutctx)
+		deprecations := len(rc.Deprecations)
+		stepName := fmt.Sprintf("%s %s", stage, stepString)
+		err = rc.timed(TimingStep, stepName, rc.monitorResources(stepName, func(ctx context.Context) error {
+			return rc.runWithRetries(ctx, step, stage, func(ctx context.Context) error {
+				return rc.runWithBreakpoints(ctx, step, stage, executor)
+			})
+		}))(timeoutctx)
+		if err == nil && rc.Config.FailOnDeprecation && len(rc.Deprecations) > deprecations {
+			err = fmt.Errorf("step uses deprecated features: %s", strings.Join(rc.Deprecations[deprecations:], "; "))
+		}
 
 		if err == nil {
 			logger.WithField("stepResult", stepResult.Outcome).Infof("  \u2705  Success - %s %s", stage, stepString)
@@ -175,6 +184,9 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 		if err != nil {
 			return err
 		}
+		if actionStep, ok := step.(actionStep); ok && stage == stepStageMain {
+			declareActionOutputs(ctx, actionStep, stepResult)
+		}
 		err = rc.UpdateExtraPath(ctx, path.Join(actPath, pathFileCommand))
 		if err != nil {
 			return err
@@ -196,28 +208,48 @@ func evaluateStepTimeout(ctx context.Context, exprEval ExpressionEvaluator, step
 	return ctx, func() {}
 }
 
+// setupEnv merges the env of the step over the env of the job and the job container like GitHub:
+// workflow env < job env < step env < the variables of the runner. Only the values of the step are
+// evaluated here, against the env of the job, the env of the job was evaluated when it started.
 func setupEnv(ctx context.Context, step step) error {
 	rc := step.getRunContext()
 
 	mergeEnv(ctx, step)
-	// merge step env last, since it should not be overwritten
-	mergeIntoMap(step, step.getEnv(), step.getStepModel().GetEnv())
 
+	stepEnv := step.getStepModel().GetEnv()
 	exprEval := rc.NewExpressionEvaluator(ctx)
-	for k, v := range *step.getEnv() {
+	values := map[string]string{}
+	for k, v := range stepEnv {
 		if !strings.HasPrefix(k, "INPUT_") {
-			(*step.getEnv())[k] = exprEval.Interpolate(ctx, v)
+			values[k] = exprEval.Interpolate(ctx, v)
 		}
 	}
+	// merge step env last, since it should not be overwritten
+	mergeIntoMap(step, step.getEnv(), values)
+
 	// after we have an evaluated step context, update the expressions evaluator with a new env context
 	// you can use step level env in the with property of a uses construct
 	exprEval = rc.NewExpressionEvaluatorWithEnv(ctx, *step.getEnv())
-	for k, v := range *step.getEnv() {
+	inputs := map[string]string{}
+	for k, v := range stepEnv {
 		if strings.HasPrefix(k, "INPUT_") {
-			(*step.getEnv())[k] = exprEval.Interpolate(ctx, v)
+			inputs[k] = exprEval.Interpolate(ctx, v)
+		}
+	}
+	mergeIntoMap(step, step.getEnv(), inputs)
+
+	// an action used in a composite action doesn't inherit the inputs of the composite action
+	if _, ok := step.(actionStep); ok && rc.Parent != nil {
+		for k := range *step.getEnv() {
+			if _, ok := inputs[k]; !ok && strings.HasPrefix(k, "INPUT_") {
+				delete(*step.getEnv(), k)
+			}
 		}
 	}
 
+	// the workflow can't override the variables of the runner
+	rc.withGithubEnv(ctx, step.getGithubContext(ctx), *step.getEnv())
+
 	common.Logger(ctx).Debugf("setupEnv => %v", *step.getEnv())
 
 	return nil
@@ -228,14 +260,15 @@ func mergeEnv(ctx context.Context, step step) {
 	rc := step.getRunContext()
 	job := rc.Run.Job()
 
-	c := job.Container()
-	if c != nil {
-		mergeIntoMap(step, env, rc.GetEnv(), c.Env)
-	} else {
-		mergeIntoMap(step, env, rc.GetEnv())
+	mergeIntoMap(step, env, rc.GetEnv())
+	if c := job.Container(); c != nil && len(c.Env) > 0 {
+		exprEval := rc.NewExpressionEvaluator(ctx)
+		values := make(map[string]string, len(c.Env))
+		for k, v := range c.Env {
+			values[k] = exprEval.Interpolate(ctx, v)
+		}
+		mergeIntoMap(step, env, values)
 	}
-
-	rc.withGithubEnv(ctx, step.getGithubContext(ctx), *env)
 }
 
 func isStepEnabled(ctx context.Context, expr string, step step, stage stepStage) (bool, error) {
@@ -248,7 +281,12 @@ func isStepEnabled(ctx context.Context, expr string, step step, stage stepStage)
 		defaultStatusCheck = exprparser.DefaultStatusCheckSuccess
 	}
 
-	runStep, err := EvalBool(ctx, rc.NewStepExpressionEvaluator(ctx, step), expr, defaultStatusCheck)
+	// the pre-if and post-if of an action are in its action.yml
+	ee := rc.NewStepExpressionEvaluator(ctx, step)
+	if stage != stepStageMain {
+		ee = rc.NewActionExpressionEvaluator(ctx, step)
+	}
+	runStep, err := EvalBool(ctx, ee, expr, defaultStatusCheck)
 	if err != nil {
 		return false, fmt.Errorf("  \u274C  Error in if-expression: \"if: %s\" (%s)", expr, err)
 	}
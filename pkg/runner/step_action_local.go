@@ -6,7 +6,6 @@ import (
 	"io"
 	"os"
 	"path"
-	"path/filepath"
 
 	"github.com/nektos/act/pkg/common"
 	"github.com/nektos/act/pkg/model"
@@ -37,7 +36,7 @@ func (sal *stepActionLocal) main() common.Executor {
 			return nil
 		}
 
-		actionDir := filepath.Join(sal.getRunContext().Config.Workdir, sal.Step.Uses)
+		actionDir := localActionDir(sal.RunContext.Config.Workdir, sal.RunContext.actionDir, sal.Step)
 
 		localReader := func(ctx context.Context) actionYamlReader {
 			_, cpath := getContainerActionPaths(sal.Step, path.Join(actionDir, ""), sal.RunContext)
@@ -60,6 +59,9 @@ func (sal *stepActionLocal) main() common.Executor {
 		}
 
 		sal.action = actionModel
+		if err := sal.RunContext.checkActionInputs(ctx, sal.Step, actionModel); err != nil {
+			return err
+		}
 
 		return sal.runAction(sal, actionDir, nil)(ctx)
 	})
@@ -101,10 +103,10 @@ func (sal *stepActionLocal) getActionModel() *model.Action {
 
 func (sal *stepActionLocal) getCompositeRunContext(ctx context.Context) *RunContext {
 	if sal.compositeRunContext == nil {
-		actionDir := filepath.Join(sal.RunContext.Config.Workdir, sal.Step.Uses)
+		actionDir := localActionDir(sal.RunContext.Config.Workdir, sal.RunContext.actionDir, sal.Step)
 		_, containerActionDir := getContainerActionPaths(sal.getStepModel(), actionDir, sal.RunContext)
 
-		sal.compositeRunContext = newCompositeRunContext(ctx, sal.RunContext, sal, containerActionDir)
+		sal.compositeRunContext = newCompositeRunContext(ctx, sal.RunContext, sal, actionDir, containerActionDir)
 		sal.compositeSteps = sal.compositeRunContext.compositeExecutor(sal.action)
 	}
 	return sal.compositeRunContext
@@ -101,6 +101,69 @@ func TestStepActionLocalTest(t *testing.T) {
 	salm.AssertExpectations(t)
 }
 
+func TestStepActionLocalOutputs(t *testing.T) {
+	ctx := context.Background()
+
+	cm := &containerMock{}
+	salm := &stepActionLocalMocks{}
+
+	sal := &stepActionLocal{
+		readAction: salm.readAction,
+		runAction:  salm.runAction,
+		RunContext: &RunContext{
+			StepResults: map[string]*model.StepResult{},
+			ExprEval:    &expressionEvaluator{},
+			Config: &Config{
+				Workdir: "/tmp",
+			},
+			Run: &model.Run{
+				JobID: "1",
+				Workflow: &model.Workflow{
+					Jobs: map[string]*model.Job{"1": {}},
+				},
+			},
+			JobContainer: cm,
+		},
+		Step: &model.Step{
+			ID:   "1",
+			Uses: "./path/to/action",
+		},
+	}
+
+	salm.On("readAction", sal.Step, filepath.Clean("/tmp/path/to/action"), "", mock.Anything, mock.Anything).
+		Return(&model.Action{
+			Outputs: map[string]model.Output{"version": {}, "path": {}},
+			Runs:    model.ActionRuns{Using: model.ActionRunsUsingNode16, Main: "index.js"},
+		}, nil)
+	salm.On("runAction", sal, filepath.Clean("/tmp/path/to/action"), (*remoteAction)(nil)).Return(func(ctx context.Context) error {
+		return nil
+	})
+
+	cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("UpdateFromEnv", "/var/run/act/workflow/envs.txt", mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("UpdateFromEnv", "/var/run/act/workflow/statecmd.txt", mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	// the action wrote version and an undeclared output to GITHUB_OUTPUT
+	var outputs *map[string]string
+	cm.On("UpdateFromEnv", "/var/run/act/workflow/outputcmd.txt", mock.AnythingOfType("*map[string]string")).Run(func(args mock.Arguments) {
+		outputs = args.Get(1).(*map[string]string)
+	}).Return(func(ctx context.Context) error {
+		(*outputs)["version"] = "1.2.3"
+		(*outputs)["extra"] = "x"
+		return nil
+	})
+	cm.On("GetContainerArchive", ctx, "/var/run/act/workflow/pathcmd.txt").Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+	assert.NoError(t, sal.pre()(ctx))
+	assert.NoError(t, sal.main()(ctx))
+	assert.Equal(t, map[string]string{"version": "1.2.3", "path": "", "extra": "x"}, sal.RunContext.StepResults["1"].Outputs)
+}
+
 func TestStepActionLocalPost(t *testing.T) {
 	table := []struct {
 		name               string
@@ -28,6 +28,7 @@ type stepActionRemote struct {
 	action              *model.Action
 	env                 map[string]string
 	remoteAction        *remoteAction
+	setupFastPath       *setupFastPath
 }
 
 var (
@@ -47,26 +48,36 @@ func (sar *stepActionRemote) prepareActionExecutor() common.Executor {
 		}
 
 		github := sar.getGithubContext(ctx)
-		sar.remoteAction.URL = github.ServerURL
+		if !sar.remoteAction.absolute {
+			sar.remoteAction.URL = github.ServerURL
+		}
 
-		if sar.remoteAction.IsCheckout() && isLocalCheckout(github, sar.Step) && !sar.RunContext.Config.NoSkipCheckout {
-			common.Logger(ctx).Debugf("Skipping local actions/checkout because workdir was already copied")
+		if sar.remoteAction.IsCheckout() && isEmulatedCheckout(github, sar.Step, sar.checkoutWith(ctx)) && !sar.RunContext.Config.NoSkipCheckout {
+			common.Logger(ctx).Debugf("Skipping actions/checkout because act checks out the repository itself")
 			return nil
 		}
 
-		for _, action := range sar.RunContext.Config.ReplaceGheActionWithGithubCom {
-			if strings.EqualFold(fmt.Sprintf("%s/%s", sar.remoteAction.Org, sar.remoteAction.Repo), action) {
-				sar.remoteAction.URL = "https://github.com"
-				github.Token = sar.RunContext.Config.ReplaceGheActionTokenWithGithubCom
-			}
+		fastPath, err := sar.prepareSetupFastPath(ctx)
+		if err != nil {
+			common.Logger(ctx).Debugf("Unable to look up %s in the tool cache: %v", sar.Step.Uses, err)
+		} else if fastPath != nil {
+			sar.setupFastPath = fastPath
+			sar.action = setupFastPathAction
+			return nil
+		}
+
+		if github.Token, err = sar.RunContext.actionSource(github, sar.remoteAction); err != nil {
+			return err
 		}
 
 		actionDir := fmt.Sprintf("%s/%s", sar.RunContext.ActionCacheDir(), safeFilename(sar.Step.Uses))
+		// the prefetched actions are up to date already
 		gitClone := stepActionRemoteNewCloneExecutor(git.NewGitCloneExecutorInput{
-			URL:   sar.remoteAction.CloneURL(),
-			Ref:   sar.remoteAction.Ref,
-			Dir:   actionDir,
-			Token: github.Token,
+			URL:         sar.remoteAction.CloneURL(),
+			Ref:         sar.remoteAction.Ref,
+			Dir:         actionDir,
+			Token:       github.Token,
+			OfflineMode: sar.RunContext.Config.ActionOfflineMode || sar.RunContext.prefetched.hasAction(actionDir),
 		})
 		var ntErr common.Executor
 		if err := gitClone(ctx); err != nil {
@@ -79,6 +90,12 @@ func (sar *stepActionRemote) prepareActionExecutor() common.Executor {
 				return err
 			}
 		}
+		repository := fmt.Sprintf("%s/%s", sar.remoteAction.Org, sar.remoteAction.Repo)
+		if err := sar.RunContext.verifyAction(ctx, sar.Step.Uses, repository, sar.remoteAction.Ref, actionDir); err != nil {
+			return err
+		}
+		name, _, _ := strings.Cut(sar.Step.Uses, "@")
+		sar.RunContext.recordAction(ctx, SBOMAction, name, sar.remoteAction.Ref, sar.remoteAction.CloneURL(), actionDir)
 
 		remoteReader := func(ctx context.Context) actionYamlReader {
 			return func(filename string) (io.Reader, io.Closer, error) {
@@ -110,15 +127,20 @@ func (sar *stepActionRemote) main() common.Executor {
 	return common.NewPipelineExecutor(
 		sar.prepareActionExecutor(),
 		runStepExecutor(sar, stepStageMain, func(ctx context.Context) error {
+			if err := sar.RunContext.checkActionInputs(ctx, sar.Step, sar.action); err != nil {
+				return err
+			}
+			if sar.setupFastPath != nil {
+				return sar.runSetupFastPath()(ctx)
+			}
+
 			github := sar.getGithubContext(ctx)
-			if sar.remoteAction.IsCheckout() && isLocalCheckout(github, sar.Step) && !sar.RunContext.Config.NoSkipCheckout {
-				if sar.RunContext.Config.BindWorkdir {
-					common.Logger(ctx).Debugf("Skipping local actions/checkout because you bound your workspace")
-					return nil
+			with := sar.checkoutWith(ctx)
+			if sar.remoteAction.IsCheckout() && isEmulatedCheckout(github, sar.Step, with) && !sar.RunContext.Config.NoSkipCheckout {
+				if !isLocalCheckout(github, sar.Step, with) {
+					return sar.runEmulatedCheckout()(ctx)
 				}
-				eval := sar.RunContext.NewExpressionEvaluator(ctx)
-				copyToPath := path.Join(sar.RunContext.JobContainer.ToContainerPath(sar.RunContext.Config.Workdir), eval.Interpolate(ctx, sar.Step.With["path"]))
-				return sar.RunContext.JobContainer.CopyDir(copyToPath, sar.RunContext.Config.Workdir+string(filepath.Separator)+".", sar.RunContext.Config.UseGitIgnore)(ctx)
+				return sar.runLocalCheckout()(ctx)
 			}
 
 			actionDir := fmt.Sprintf("%s/%s", sar.RunContext.ActionCacheDir(), safeFilename(sar.Step.Uses))
@@ -128,6 +150,48 @@ func (sar *stepActionRemote) main() common.Executor {
 	)
 }
 
+// runLocalCheckout copies the working directory into the workspace for an actions/checkout step of
+// the repository act runs in, and initializes the submodules and git lfs files the step asks for
+func (sar *stepActionRemote) runLocalCheckout() common.Executor {
+	return func(ctx context.Context) error {
+		rc := sar.RunContext
+		with := sar.checkoutWith(ctx)
+		workspace := rc.JobContainer.ToContainerPath(rc.Config.Workdir)
+		checkoutPath, err := checkoutPath(workspace, with["path"])
+		if err != nil {
+			return err
+		}
+		copyToPath := path.Join(workspace, checkoutPath)
+		submodules := with["submodules"]
+		lfs := with["lfs"] == "true"
+
+		// a bound or copied workspace is prepared when the job starts already, the working
+		// directory isn't copied into itself for the checkouts to other paths of a bound workspace
+		prepared := rc.Config.BindWorkdir || rc.Config.CopyWorkspace && checkoutPath == ""
+		if prepared {
+			if rc.Config.BindWorkdir && checkoutPath != "" {
+				common.Logger(ctx).Warnf("Skipping local actions/checkout to '%s' because you bound your workspace, the repository is in the workspace", checkoutPath)
+			} else if rc.Config.BindWorkdir {
+				common.Logger(ctx).Debugf("Skipping local actions/checkout because you bound your workspace")
+			} else {
+				common.Logger(ctx).Debugf("Skipping local actions/checkout because the workspace was copied into the job container")
+			}
+			if (submodules == "" || submodules == "false" || submodules == rc.Config.Submodules) && (!lfs || rc.Config.LFS) {
+				return nil
+			}
+			return rc.setupWorkspaceGit(workspace, submodules, lfs)(ctx)
+		}
+
+		if submodules == "" {
+			submodules = rc.Config.Submodules
+		}
+		return common.NewPipelineExecutor(
+			rc.JobContainer.CopyDir(copyToPath, rc.Config.Workdir+string(filepath.Separator)+".", rc.Config.UseGitIgnore),
+			rc.setupWorkspaceGit(copyToPath, submodules, lfs || rc.Config.LFS),
+		)(ctx)
+	}
+}
+
 func (sar *stepActionRemote) post() common.Executor {
 	return runStepExecutor(sar, stepStagePost, runPostStep(sar)).If(hasPostStep(sar)).If(shouldRunPostStep(sar))
 }
@@ -161,8 +225,8 @@ func (sar *stepActionRemote) getIfExpression(ctx context.Context, stage stepStag
 	switch stage {
 	case stepStagePre:
 		github := sar.getGithubContext(ctx)
-		if sar.remoteAction.IsCheckout() && isLocalCheckout(github, sar.Step) && !sar.RunContext.Config.NoSkipCheckout {
-			// skip local checkout pre step
+		if sar.remoteAction.IsCheckout() && isEmulatedCheckout(github, sar.Step, sar.checkoutWith(ctx)) && !sar.RunContext.Config.NoSkipCheckout {
+			// skip checkout pre step
 			return "false"
 		}
 		return sar.action.Runs.PreIf
@@ -184,7 +248,7 @@ func (sar *stepActionRemote) getCompositeRunContext(ctx context.Context) *RunCon
 		actionLocation := path.Join(actionDir, sar.remoteAction.Path)
 		_, containerActionDir := getContainerActionPaths(sar.getStepModel(), actionLocation, sar.RunContext)
 
-		sar.compositeRunContext = newCompositeRunContext(ctx, sar.RunContext, sar, containerActionDir)
+		sar.compositeRunContext = newCompositeRunContext(ctx, sar.RunContext, sar, actionLocation, containerActionDir)
 		sar.compositeSteps = sar.compositeRunContext.compositeExecutor(sar.action)
 	} else {
 		// Re-evaluate environment here. For remote actions the environment
@@ -210,6 +274,8 @@ type remoteAction struct {
 	Repo string
 	Path string
 	Ref  string
+
+	absolute bool // uses: has the URL of the server, like Gitea and Forgejo support
 }
 
 func (ra *remoteAction) CloneURL() string {
@@ -223,12 +289,26 @@ func (ra *remoteAction) IsCheckout() bool {
 	return false
 }
 
+// absoluteRemoteActionPattern matches the uses: of actions with the URL of their server, e.g.
+// https://code.forgejo.org/actions/checkout@v4
+var absoluteRemoteActionPattern = regexp.MustCompile(`^(https?://[^/@]+)/([^/@]+)/([^/@]+)(/([^@]*))?@(.+)$`)
+
 func newRemoteAction(action string) *remoteAction {
 	// GitHub's document[^] describes:
 	// > We strongly recommend that you include the version of
 	// > the action you are using by specifying a Git ref, SHA, or Docker tag number.
 	// Actually, the workflow stops if there is the uses directive that hasn't @ref.
 	// [^]: https://docs.github.com/en/actions/reference/workflow-syntax-for-github-actions
+	if matches := absoluteRemoteActionPattern.FindStringSubmatch(action); matches != nil {
+		return &remoteAction{
+			URL:      matches[1],
+			Org:      matches[2],
+			Repo:     matches[3],
+			Path:     matches[5],
+			Ref:      matches[6],
+			absolute: true,
+		}
+	}
 	r := regexp.MustCompile(`^([^/@]+)/([^/@]+)(/([^@]*))?(@(.*))?$`)
 	matches := r.FindStringSubmatch(action)
 	if len(matches) < 7 || matches[6] == "" {
@@ -0,0 +1,201 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// stepCacheEntry is the recorded effect of a successful run step
+type stepCacheEntry struct {
+	Outputs   map[string]string `json:"outputs"`
+	Env       map[string]string `json:"env"`
+	ExtraPath []string          `json:"path"`
+}
+
+func (rc *RunContext) stepCacheDir() string {
+	return filepath.Join(rc.ActionCacheDir(), "steps")
+}
+
+// stepCacheKey hashes everything a run step depends on: the image, the command line,
+// the script, the working directory, the environment and the content of the workspace
+func (sr *stepRun) stepCacheKey(ctx context.Context) (string, error) {
+	rc := sr.getRunContext()
+	h := sha256.New()
+	fmt.Fprintf(h, "image=%s\n", rc.platformImage(ctx))
+	fmt.Fprintf(h, "cmd=%q\n", sr.cmd)
+	fmt.Fprintf(h, "script=%s\n", sr.script)
+	fmt.Fprintf(h, "workdir=%s\n", sr.WorkingDirectory)
+
+	keys := make([]string, 0, len(sr.env))
+	for k := range sr.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, "env:%s=%s\n", k, sr.env[k])
+	}
+
+	if err := hashWorkspace(h, rc.Config.Workdir); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashWorkspace writes the path, mode and content of every file below dir into h
+func hashWorkspace(h io.Writer, dir string) error {
+	return hashWorkspaceFiles(h, dir, nil)
+}
+
+// hashWorkspaceFiles is hashWorkspace without the files and directories the ignorer matches
+func hashWorkspaceFiles(h io.Writer, dir string, ignorer gitignore.Matcher) error {
+	if dir == "" {
+		return nil
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if ignorer != nil && rel != "." && ignorer.Match(strings.Split(filepath.ToSlash(rel), "/"), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file:%s %v\n", filepath.ToSlash(rel), info.Mode())
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+}
+
+func (rc *RunContext) loadStepCache(key string) (*stepCacheEntry, bool) {
+	content, err := os.ReadFile(filepath.Join(rc.stepCacheDir(), key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	entry := &stepCacheEntry{}
+	if err := json.Unmarshal(content, entry); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (rc *RunContext) saveStepCache(key string, entry *stepCacheEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(rc.stepCacheDir(), 0o755); err != nil {
+		return err
+	}
+	return common.WriteFileAtomic(filepath.Join(rc.stepCacheDir(), key+".json"), content, 0o644)
+}
+
+// restoreStepCache replays the recorded effects of a previous execution of the step.
+// It returns false if the step has to be executed.
+func (sr *stepRun) restoreStepCache(ctx context.Context) (bool, error) {
+	rc := sr.getRunContext()
+	if !rc.Config.StepCache || common.Dryrun(ctx) {
+		return false, nil
+	}
+	key, err := sr.stepCacheKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	entry, ok := rc.loadStepCache(key)
+	if !ok {
+		sr.cacheKey = key
+		return false, nil
+	}
+
+	common.Logger(ctx).Infof("  \u267B  Using cached result of '%s'", sr.Step)
+	for k, v := range entry.Env {
+		rc.setEnv(ctx, map[string]string{"name": k}, v)
+	}
+	for k, v := range entry.Outputs {
+		rc.setOutput(ctx, map[string]string{"name": k}, v)
+	}
+	for i := len(entry.ExtraPath) - 1; i >= 0; i-- {
+		rc.addPath(ctx, entry.ExtraPath[i])
+	}
+	return true, nil
+}
+
+// recordStepCache wraps the step executor and records its effects once it succeeded
+func (sr *stepRun) recordStepCache(executor common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		rc := sr.getRunContext()
+		sr.cacheKey = ""
+		globalEnv := make(map[string]string, len(rc.GlobalEnv))
+		for k, v := range rc.GlobalEnv {
+			globalEnv[k] = v
+		}
+		extraPath := make(map[string]bool, len(rc.ExtraPath))
+		for _, p := range rc.ExtraPath {
+			extraPath[p] = true
+		}
+
+		if err := executor(ctx); err != nil {
+			return err
+		}
+
+		result, ok := rc.StepResults[sr.Step.ID]
+		if sr.cacheKey == "" || !ok || result.Outcome != model.StepStatusSuccess {
+			return nil
+		}
+
+		entry := &stepCacheEntry{
+			Outputs: result.Outputs,
+			Env:     map[string]string{},
+		}
+		for k, v := range rc.GlobalEnv {
+			if old, ok := globalEnv[k]; !ok || old != v {
+				entry.Env[k] = v
+			}
+		}
+		for _, p := range rc.ExtraPath {
+			if !extraPath[p] {
+				entry.ExtraPath = append(entry.ExtraPath, p)
+			}
+		}
+		if err := rc.saveStepCache(sr.cacheKey, entry); err != nil {
+			common.Logger(ctx).Warnf("unable to save the result of '%s' in the step cache: %v", sr.Step, err)
+		}
+		return nil
+	}
+}
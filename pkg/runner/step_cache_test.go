@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestStepCacheKey(t *testing.T) {
+	workdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "main.go"), []byte("package main"), 0o600))
+
+	sr := &stepRun{
+		RunContext: &RunContext{
+			Config: &Config{Workdir: workdir},
+			Run: &model.Run{
+				JobID: "1",
+				Workflow: &model.Workflow{
+					Jobs: map[string]*model.Job{"1": {}},
+				},
+			},
+		},
+		Step:   &model.Step{ID: "1", Run: "go build"},
+		cmd:    []string{"bash", "/var/run/act/workflow/1.sh"},
+		env:    map[string]string{"FOO": "bar"},
+		script: "go build",
+	}
+
+	ctx := context.Background()
+	key, err := sr.stepCacheKey(ctx)
+	assert.NoError(t, err)
+
+	again, err := sr.stepCacheKey(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, key, again)
+
+	sr.env["FOO"] = "baz"
+	changedEnv, err := sr.stepCacheKey(ctx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, changedEnv)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "main.go"), []byte("package other"), 0o600))
+	changedWorkspace, err := sr.stepCacheKey(ctx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, changedEnv, changedWorkspace)
+}
+
+func TestHashWorkspaceSkipsGitDir(t *testing.T) {
+	workdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "file"), []byte("content"), 0o600))
+
+	before := sha256.New()
+	assert.NoError(t, hashWorkspace(before, workdir))
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(workdir, ".git"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, ".git", "HEAD"), []byte("ref"), 0o600))
+
+	after := sha256.New()
+	assert.NoError(t, hashWorkspace(after, workdir))
+	assert.Equal(t, before.Sum(nil), after.Sum(nil))
+}
+
+func TestStepCacheRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	rc := &RunContext{Config: &Config{}}
+
+	_, ok := rc.loadStepCache("missing")
+	assert.False(t, ok)
+
+	entry := &stepCacheEntry{
+		Outputs:   map[string]string{"out": "value"},
+		Env:       map[string]string{"ENV": "value"},
+		ExtraPath: []string{"/opt/bin"},
+	}
+	assert.NoError(t, rc.saveStepCache("key", entry))
+
+	loaded, ok := rc.loadStepCache("key")
+	assert.True(t, ok)
+	assert.Equal(t, entry, loaded)
+}
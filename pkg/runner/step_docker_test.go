@@ -10,6 +10,7 @@ import (
 	"github.com/nektos/act/pkg/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gopkg.in/yaml.v3"
 )
 
 func TestStepDockerMain(t *testing.T) {
@@ -53,6 +54,20 @@ func TestStepDockerMain(t *testing.T) {
 			ID:               "1",
 			Uses:             "docker://node:14",
 			WorkingDirectory: "workdir",
+			With: map[string]string{
+				"args":       "--name ${{ env.NAME }} 'quoted arg'",
+				"entrypoint": "/bin/${{ env.SHELL_NAME }}",
+				"some-input": "value",
+			},
+			Env: yaml.Node{
+				Kind: yaml.MappingNode,
+				Content: []*yaml.Node{
+					{Kind: yaml.ScalarNode, Value: "NAME"},
+					{Kind: yaml.ScalarNode, Value: "world"},
+					{Kind: yaml.ScalarNode, Value: "SHELL_NAME"},
+					{Kind: yaml.ScalarNode, Value: "sh"},
+				},
+			},
 		},
 	}
 	sd.RunContext.ExprEval = sd.RunContext.NewExpressionEvaluator(ctx)
@@ -99,6 +114,10 @@ func TestStepDockerMain(t *testing.T) {
 	assert.Nil(t, err)
 
 	assert.Equal(t, "node:14", input.Image)
+	assert.Equal(t, []string{"--name", "world", "quoted arg"}, input.Cmd)
+	assert.Equal(t, []string{"/bin/sh"}, input.Entrypoint)
+	assert.Contains(t, input.Env, "INPUT_SOME-INPUT=value")
+	assert.Contains(t, input.Env, "NAME=world")
 
 	cm.AssertExpectations(t)
 }
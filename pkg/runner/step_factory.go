@@ -13,6 +13,14 @@ type stepFactory interface {
 type stepFactoryImpl struct{}
 
 func (sf *stepFactoryImpl) newStep(stepModel *model.Step, rc *RunContext) (step, error) {
+	stepModel = rc.replaceAction(stepModel)
+	if stepModel.Type() != model.StepTypeInvalid && rc.skipStep(stepModel) {
+		return &stepSkipped{
+			Step:       stepModel,
+			RunContext: rc,
+		}, nil
+	}
+
 	switch stepModel.Type() {
 	case model.StepTypeInvalid:
 		return nil, fmt.Errorf("Invalid run/uses syntax for job:%s step:%+v", rc.Run, stepModel)
@@ -67,6 +67,21 @@ func TestStepFactoryNewStep(t *testing.T) {
 	}
 }
 
+func TestStepFactorySkippedStep(t *testing.T) {
+	sf := &stepFactoryImpl{}
+	rc := &RunContext{
+		Config: &Config{SkipSteps: []string{"codecov/*"}},
+	}
+
+	step, err := sf.newStep(&model.Step{Uses: "codecov/codecov-action@v3"}, rc)
+	assert.Nil(t, err)
+	assert.IsType(t, &stepSkipped{}, step)
+
+	step, err = sf.newStep(&model.Step{Uses: "actions/checkout@v3"}, rc)
+	assert.Nil(t, err)
+	assert.IsType(t, &stepActionRemote{}, step)
+}
+
 func TestStepFactoryInvalidStep(t *testing.T) {
 	model := &model.Step{
 		Uses: "remote/action@v1",
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// StepRetry is a --retry flag, the steps matching Pattern run again when they fail until they
+// succeed or ran MaxAttempts times
+type StepRetry struct {
+	Pattern     string // glob pattern of the names, ids or actions of the steps
+	MaxAttempts int
+}
+
+// ParseStepRetry parses a --retry flag of the form <pattern>=<max-attempts>
+func ParseStepRetry(s string) (StepRetry, error) {
+	i := strings.LastIndex(s, "=")
+	if i <= 0 {
+		return StepRetry{}, fmt.Errorf("invalid --retry '%s', expected <pattern>=<max-attempts>", s)
+	}
+	attempts, err := strconv.Atoi(s[i+1:])
+	if err != nil || attempts < 1 {
+		return StepRetry{}, fmt.Errorf("invalid --retry '%s', the maximum number of attempts must be a positive number", s)
+	}
+	return StepRetry{Pattern: s[:i], MaxAttempts: attempts}, nil
+}
+
+// stepRetry returns how often the main stage of a step may run and how long to wait before the
+// second attempt. The x-act-retry extension of the step takes precedence over --retry.
+func (rc *RunContext) stepRetry(ctx context.Context, step step) (int, time.Duration) {
+	stepModel := step.getStepModel()
+	backoff := rc.Config.RetryBackoff
+	if retry := stepModel.Retry; retry != nil {
+		if retry.Backoff != "" {
+			d, err := time.ParseDuration(retry.Backoff)
+			if err != nil {
+				common.Logger(ctx).Warnf("Invalid backoff '%s' of x-act-retry, using %s: %v", retry.Backoff, backoff, err)
+			} else {
+				backoff = d
+			}
+		}
+		return retry.MaxAttempts, backoff
+	}
+	for _, retry := range rc.Config.Retries {
+		for _, value := range []string{stepModel.ID, stepModel.Name, stepModel.Uses} {
+			if value != "" && matchStepPattern(retry.Pattern, value) {
+				return retry.MaxAttempts, backoff
+			}
+		}
+	}
+	return 1, backoff
+}
+
+// runWithRetries runs the main stage of a step again after it failed, as often as x-act-retry or
+// --retry allow, waiting twice as long before each further attempt. Every failed attempt is logged
+// with its number.
+func (rc *RunContext) runWithRetries(ctx context.Context, step step, stage stepStage, executor common.Executor) error {
+	if stage != stepStageMain {
+		return executor(ctx)
+	}
+	maxAttempts, backoff := rc.stepRetry(ctx, step)
+	logger := common.Logger(ctx)
+	for attempt := 1; ; attempt++ {
+		err := executor(ctx)
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil {
+			if attempt > 1 {
+				logger.WithField("stepAttempt", attempt).Infof("\U0001F501  Attempt %d of %d of %s: %s", attempt, maxAttempts, step.getStepModel(), attemptOutcome(err))
+			}
+			return err
+		}
+		logger.WithField("stepAttempt", attempt).Warnf("\U0001F501  Attempt %d of %d of %s failed, retrying in %s: %v", attempt, maxAttempts, step.getStepModel(), backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func attemptOutcome(err error) string {
+	if err != nil {
+		return fmt.Sprintf("failed: %v", err)
+	}
+	return "succeeded"
+}
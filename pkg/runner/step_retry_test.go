@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestParseStepRetry(t *testing.T) {
+	retry, err := ParseStepRetry("integration*=3")
+	assert.NoError(t, err)
+	assert.Equal(t, StepRetry{Pattern: "integration*", MaxAttempts: 3}, retry)
+
+	retry, err = ParseStepRetry("run a=b=2")
+	assert.NoError(t, err)
+	assert.Equal(t, StepRetry{Pattern: "run a=b", MaxAttempts: 2}, retry)
+
+	for _, s := range []string{"integration", "=3", "integration=0", "integration=many"} {
+		_, err := ParseStepRetry(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRunContextStepRetry(t *testing.T) {
+	rc := &RunContext{Config: &Config{
+		Retries:      []StepRetry{{Pattern: "Integration *", MaxAttempts: 3}, {Pattern: "docker/*", MaxAttempts: 2}},
+		RetryBackoff: time.Second,
+	}}
+	ctx := context.Background()
+
+	attempts, backoff := rc.stepRetry(ctx, &stepRun{Step: &model.Step{Name: "Integration tests"}})
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, time.Second, backoff)
+
+	attempts, _ = rc.stepRetry(ctx, &stepActionRemote{Step: &model.Step{Uses: "docker/login-action@v2"}})
+	assert.Equal(t, 2, attempts)
+
+	attempts, _ = rc.stepRetry(ctx, &stepRun{Step: &model.Step{Name: "Build"}})
+	assert.Equal(t, 1, attempts)
+
+	// the extension of the step takes precedence over the flags
+	attempts, backoff = rc.stepRetry(ctx, &stepRun{Step: &model.Step{
+		Name:  "Integration tests",
+		Retry: &model.StepRetry{MaxAttempts: 5, Backoff: "10s"},
+	}})
+	assert.Equal(t, 5, attempts)
+	assert.Equal(t, 10*time.Second, backoff)
+}
+
+func TestRunContextRunWithRetries(t *testing.T) {
+	rc := &RunContext{Config: &Config{RetryBackoff: time.Millisecond}}
+	step := &stepRun{Step: &model.Step{ID: "test", Retry: &model.StepRetry{MaxAttempts: 3}}}
+
+	runs := 0
+	flaky := func(ctx context.Context) error {
+		runs++
+		if runs < 2 {
+			return fmt.Errorf("exit code 1")
+		}
+		return nil
+	}
+	assert.NoError(t, rc.runWithRetries(context.Background(), step, stepStageMain, flaky))
+	assert.Equal(t, 2, runs)
+
+	runs = 0
+	failing := func(ctx context.Context) error {
+		runs++
+		return fmt.Errorf("exit code %d", runs)
+	}
+	assert.EqualError(t, rc.runWithRetries(context.Background(), step, stepStageMain, failing), "exit code 3")
+	assert.Equal(t, 3, runs)
+
+	// the pre and post stages aren't retried
+	runs = 0
+	assert.EqualError(t, rc.runWithRetries(context.Background(), step, stepStagePost, failing), "exit code 1")
+	assert.Equal(t, 1, runs)
+
+	// a cancelled run isn't retried
+	runs = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.EqualError(t, rc.runWithRetries(ctx, step, stepStageMain, failing), "exit code 1")
+	assert.Equal(t, 1, runs)
+}
@@ -3,6 +3,8 @@ package runner
 import (
 	"context"
 	"fmt"
+	"path"
+	"runtime"
 	"strings"
 
 	"github.com/kballard/go-shellquote"
@@ -17,6 +19,8 @@ type stepRun struct {
 	RunContext       *RunContext
 	cmd              []string
 	env              map[string]string
+	script           string
+	cacheKey         string
 	WorkingDirectory string
 }
 
@@ -28,13 +32,16 @@ func (sr *stepRun) pre() common.Executor {
 
 func (sr *stepRun) main() common.Executor {
 	sr.env = map[string]string{}
-	return runStepExecutor(sr, stepStageMain, common.NewPipelineExecutor(
+	return sr.recordStepCache(runStepExecutor(sr, stepStageMain, common.NewPipelineExecutor(
 		sr.setupShellCommandExecutor(),
 		func(ctx context.Context) error {
 			sr.getRunContext().ApplyExtraPath(ctx, &sr.env)
+			if cached, err := sr.restoreStepCache(ctx); err != nil || cached {
+				return err
+			}
 			return sr.getRunContext().JobContainer.Exec(sr.cmd, sr.env, "", sr.WorkingDirectory)(ctx)
 		},
-	))
+	)))
 }
 
 func (sr *stepRun) post() common.Executor {
@@ -69,6 +76,7 @@ func (sr *stepRun) setupShellCommandExecutor() common.Executor {
 		if err != nil {
 			return err
 		}
+		sr.script = script
 
 		rc := sr.getRunContext()
 		return rc.JobContainer.Copy(rc.JobContainer.GetActPath(), &container.FileEntry{
@@ -105,25 +113,10 @@ func (sr *stepRun) setupShellCommand(ctx context.Context) (name, script string,
 
 	name = getScriptName(sr.RunContext, step)
 
-	// Reference: https://github.com/actions/runner/blob/8109c962f09d9acc473d92c595ff43afceddb347/src/Runner.Worker/Handlers/ScriptHandlerHelpers.cs#L47-L64
-	// Reference: https://github.com/actions/runner/blob/8109c962f09d9acc473d92c595ff43afceddb347/src/Runner.Worker/Handlers/ScriptHandlerHelpers.cs#L19-L27
-	runPrepend := ""
-	runAppend := ""
-	switch step.Shell {
-	case "bash", "sh":
-		name += ".sh"
-	case "pwsh", "powershell":
-		name += ".ps1"
-		runPrepend = "$ErrorActionPreference = 'stop'"
-		runAppend = "if ((Test-Path -LiteralPath variable:/LASTEXITCODE)) { exit $LASTEXITCODE }"
-	case "cmd":
-		name += ".cmd"
-		runPrepend = "@echo off"
-	case "python":
-		name += ".py"
-	}
-
-	script = fmt.Sprintf("%s\n%s\n%s", runPrepend, script, runAppend)
+	shell := scriptShell(scCmd)
+	ext, runPrepend, runAppend := scriptWrapper(shell)
+	name += ext
+	script = scriptFileBody(shell, fmt.Sprintf("%s\n%s\n%s", runPrepend, script, runAppend))
 
 	if !strings.Contains(script, "::add-mask::") && !sr.RunContext.Config.InsecureSecrets {
 		logger.Debugf("Wrote command \n%s\n to '%s'", script, name)
@@ -132,12 +125,82 @@ func (sr *stepRun) setupShellCommand(ctx context.Context) (name, script string,
 	}
 
 	rc := sr.getRunContext()
-	scriptPath := fmt.Sprintf("%s/%s", rc.JobContainer.GetActPath(), name)
-	sr.cmd, err = shellquote.Split(strings.Replace(scCmd, `{0}`, scriptPath, 1))
+	sr.cmd, err = shellCommandArgs(scCmd, rc.actFilePath(name))
 
 	return name, script, err
 }
 
+// shellCommandArgs splits a shell command template like "perl -w {0}" into the command to run the
+// script. The arguments are split before {0} is replaced, so the script path stays one argument.
+func shellCommandArgs(shellCommand string, scriptPath string) ([]string, error) {
+	args, err := shellquote.Split(shellCommand)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shell '%s': %w", shellCommand, err)
+	}
+	script := false
+	for i, arg := range args {
+		if strings.Contains(arg, "{0}") {
+			args[i] = strings.ReplaceAll(arg, "{0}", scriptPath)
+			script = true
+		}
+	}
+	if !script {
+		return nil, fmt.Errorf("invalid shell '%s', the shell must be bash, pwsh, python, sh, cmd or powershell or a command with {0} for the script, e.g. 'perl -w {0}'", shellCommand)
+	}
+	if args[0] == "%ComSpec%" {
+		// the command isn't run by a shell which expands the variable, cmd is found in the PATH
+		args[0] = "cmd"
+	}
+	return args, nil
+}
+
+// scriptWrapper returns the extension of the script file of the shell and the lines the script is
+// wrapped in
+//
+// Reference: https://github.com/actions/runner/blob/8109c962f09d9acc473d92c595ff43afceddb347/src/Runner.Worker/Handlers/ScriptHandlerHelpers.cs#L47-L64
+// Reference: https://github.com/actions/runner/blob/8109c962f09d9acc473d92c595ff43afceddb347/src/Runner.Worker/Handlers/ScriptHandlerHelpers.cs#L19-L27
+func scriptWrapper(shell string) (ext string, before string, after string) {
+	switch shell {
+	case "bash", "sh":
+		return ".sh", "", ""
+	case "pwsh", "powershell":
+		return ".ps1", "$ErrorActionPreference = 'stop'", "if ((Test-Path -LiteralPath variable:/LASTEXITCODE)) { exit $LASTEXITCODE }"
+	case "cmd":
+		return ".cmd", "@echo off", ""
+	case "python":
+		return ".py", "", ""
+	}
+	return "", "", ""
+}
+
+// scriptFileBody encodes the script file like the runner on Windows, cmd reads the batch files with
+// CRLF line endings and Windows PowerShell reads the files without a BOM in the ANSI code page
+func scriptFileBody(shell string, script string) string {
+	switch shell {
+	case "cmd":
+		return strings.ReplaceAll(strings.ReplaceAll(script, "\r\n", "\n"), "\n", "\r\n")
+	case "powershell":
+		return "\ufeff" + script
+	}
+	return script
+}
+
+// scriptShell returns the program of a shell command without its directory and extension, like the
+// runner it picks the extension and the fix-ups of the script file, e.g. pwsh for "/usr/bin/pwsh -File {0}"
+func scriptShell(shellCommand string) string {
+	program, _, _ := strings.Cut(strings.TrimSpace(shellCommand), " ")
+	if strings.HasPrefix(program, "'") || strings.HasPrefix(program, `"`) {
+		if args, err := shellquote.Split(shellCommand); err == nil && len(args) > 0 {
+			program = args[0]
+		}
+	}
+	if program == "%ComSpec%" {
+		return "cmd"
+	}
+	program = program[strings.LastIndexAny(program, `/\`)+1:]
+	return strings.TrimSuffix(program, path.Ext(program))
+}
+
 func (sr *stepRun) setupShell(ctx context.Context) {
 	rc := sr.RunContext
 	step := sr.Step
@@ -157,10 +220,12 @@ func (sr *stepRun) setupShell(ctx context.Context) {
 	// if `bash` is available, and provides `bash` if it is
 	// for now I'm going to leave below logic, will address it in different PR
 	// https://github.com/actions/runner/blob/9a829995e02d2db64efb939dc2f283002595d4d9/src/Runner.Worker/Handlers/ScriptHandler.cs#L87-L91
-	if rc.Run.Job().Container() != nil {
-		if rc.Run.Job().Container().Image != "" && step.Shell == "" {
-			step.Shell = "sh"
-		}
+	if rc.containerImage(ctx) != "" && step.Shell == "" {
+		step.Shell = "sh"
+	}
+
+	if rc.runsOnHost() && step.Shell == "" {
+		step.Shell = hostDefaultShell(runtime.GOOS, hostLookPath)
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -92,3 +93,84 @@ func TestStepRunPrePost(t *testing.T) {
 	err = sr.post()(ctx)
 	assert.Nil(t, err)
 }
+
+func TestStepRunScriptFile(t *testing.T) {
+	for _, tt := range []struct {
+		shell  string
+		name   string
+		cmd    []string
+		prefix string
+	}{
+		{shell: "", name: "workflow/1.sh", cmd: []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "/var/run/act/workflow/1.sh"}},
+		{shell: "sh", name: "workflow/1.sh", cmd: []string{"sh", "-e", "/var/run/act/workflow/1.sh"}},
+		{shell: "bash -x {0}", name: "workflow/1.sh", cmd: []string{"bash", "-x", "/var/run/act/workflow/1.sh"}},
+		{shell: "pwsh", name: "workflow/1.ps1", cmd: []string{"pwsh", "-command", ". '/var/run/act/workflow/1.ps1'"}, prefix: "$ErrorActionPreference = 'stop'"},
+		{shell: "/usr/bin/pwsh -File {0}", name: "workflow/1.ps1", cmd: []string{"/usr/bin/pwsh", "-File", "/var/run/act/workflow/1.ps1"}, prefix: "$ErrorActionPreference = 'stop'"},
+		{shell: "python", name: "workflow/1.py", cmd: []string{"python", "/var/run/act/workflow/1.py"}},
+		{shell: "python {0}", name: "workflow/1.py", cmd: []string{"python", "/var/run/act/workflow/1.py"}},
+		{shell: "cmd", name: "workflow/1.cmd", cmd: []string{"cmd", "/D", "/E:ON", "/V:OFF", "/S", "/C", "CALL /var/run/act/workflow/1.cmd"}, prefix: "@echo off\r"},
+		{shell: "perl -w {0}", name: "workflow/1", cmd: []string{"perl", "-w", "/var/run/act/workflow/1"}},
+		{shell: "/bin/bash", name: "workflow/1.sh", cmd: []string{"/bin/bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "/var/run/act/workflow/1.sh"}},
+	} {
+		t.Run(tt.shell, func(t *testing.T) {
+			sr := &stepRun{
+				RunContext: &RunContext{
+					ExprEval:     &expressionEvaluator{},
+					Config:       &Config{},
+					Run:          &model.Run{JobID: "1", Workflow: &model.Workflow{Jobs: map[string]*model.Job{"1": {}}}},
+					JobContainer: &containerMock{},
+				},
+				Step: &model.Step{ID: "1", Run: "cmd", Shell: tt.shell},
+			}
+			name, script, err := sr.setupShellCommand(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.cmd, sr.cmd)
+			assert.True(t, strings.HasPrefix(script, tt.prefix+"\n"), script)
+		})
+	}
+}
+
+func TestShellCommandArgs(t *testing.T) {
+	const script = "/var/run/act/workflow/my step.sh"
+	for _, tt := range []struct {
+		command  string
+		expected []string
+	}{
+		{"perl -w {0}", []string{"perl", "-w", script}},
+		{"bash -leo pipefail {0}", []string{"bash", "-leo", "pipefail", script}},
+		{`pwsh -command ". '{0}'"`, []string{"pwsh", "-command", ". '" + script + "'"}},
+		{"'/opt/my tools/python' -u {0} --verbose", []string{"/opt/my tools/python", "-u", script, "--verbose"}},
+		{`%ComSpec% /D /E:ON /V:OFF /S /C "CALL "{0}""`, []string{"cmd", "/D", "/E:ON", "/V:OFF", "/S", "/C", "CALL " + script}},
+	} {
+		args, err := shellCommandArgs(tt.command, script)
+		assert.NoError(t, err, tt.command)
+		assert.Equal(t, tt.expected, args, tt.command)
+	}
+
+	_, err := shellCommandArgs("perl", script)
+	assert.EqualError(t, err, "invalid shell 'perl', the shell must be bash, pwsh, python, sh, cmd or powershell or a command with {0} for the script, e.g. 'perl -w {0}'")
+	_, err = shellCommandArgs("perl '{0}", script)
+	assert.Error(t, err)
+}
+
+func TestScriptFileBody(t *testing.T) {
+	assert.Equal(t, "@echo off\r\ndir\r\n", scriptFileBody("cmd", "@echo off\ndir\r\n"))
+	assert.Equal(t, "\ufeff$ErrorActionPreference = 'stop'\n", scriptFileBody("powershell", "$ErrorActionPreference = 'stop'\n"))
+	assert.Equal(t, "echo\n", scriptFileBody("pwsh", "echo\n"))
+	assert.Equal(t, "echo\n", scriptFileBody("bash", "echo\n"))
+}
+
+func TestScriptShell(t *testing.T) {
+	for command, expected := range map[string]string{
+		"bash --noprofile --norc -e -o pipefail {0}":       "bash",
+		"/usr/bin/pwsh -File {0}":                          "pwsh",
+		`C:\tools\pwsh.exe -command . '{0}'`:               "pwsh",
+		"%ComSpec% /D /E:ON /V:OFF /S /C \"CALL \"{0}\"\"": "cmd",
+		"python {0}":                 "python",
+		"perl {0}":                   "perl",
+		"'/opt/my tools/python' {0}": "python",
+	} {
+		assert.Equal(t, expected, scriptShell(command), command)
+	}
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// stepSkipped is a step matching --skip-step, its action isn't fetched and it is
+// reported as skipped, like a step whose if: condition is false
+type stepSkipped struct {
+	Step       *model.Step
+	RunContext *RunContext
+	env        map[string]string
+}
+
+// matchStepPattern reports whether the value matches the pattern, in which * matches
+// any characters including / and ? matches a single character
+func matchStepPattern(pattern string, value string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	matched, _ := regexp.MatchString("^"+expr+"$", value)
+	return matched
+}
+
+// skipStep reports whether the name, the id or the action of the step matches a --skip-step pattern
+func (rc *RunContext) skipStep(step *model.Step) bool {
+	if rc.Config == nil {
+		return false
+	}
+	for _, pattern := range rc.Config.SkipSteps {
+		for _, value := range []string{step.ID, step.Name, step.Uses} {
+			if value != "" && matchStepPattern(pattern, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ss *stepSkipped) pre() common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (ss *stepSkipped) main() common.Executor {
+	return func(ctx context.Context) error {
+		rc := ss.RunContext
+		rc.CurrentStep = ss.Step.ID
+		rc.StepResults[rc.CurrentStep] = &model.StepResult{
+			Outcome:    model.StepStatusSkipped,
+			Conclusion: model.StepStatusSkipped,
+			Outputs:    make(map[string]string),
+		}
+		common.Logger(ctx).WithField("stepResult", model.StepStatusSkipped).Infof("\u23ED  Skipping %s due to --skip-step", ss.Step)
+		return nil
+	}
+}
+
+func (ss *stepSkipped) post() common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (ss *stepSkipped) getRunContext() *RunContext {
+	return ss.RunContext
+}
+
+func (ss *stepSkipped) getGithubContext(ctx context.Context) *model.GithubContext {
+	return ss.getRunContext().getGithubContext(ctx)
+}
+
+func (ss *stepSkipped) getStepModel() *model.Step {
+	return ss.Step
+}
+
+func (ss *stepSkipped) getEnv() *map[string]string {
+	return &ss.env
+}
+
+func (ss *stepSkipped) getIfExpression(context context.Context, stage stepStage) string {
+	return "false"
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestMatchStepPattern(t *testing.T) {
+	assert.True(t, matchStepPattern("*upload-artifact*", "actions/upload-artifact@v3"))
+	assert.True(t, matchStepPattern("codecov/*", "codecov/codecov-action@v3"))
+	assert.True(t, matchStepPattern("Upload coverage", "Upload coverage"))
+	assert.True(t, matchStepPattern("test-?", "test-1"))
+	assert.False(t, matchStepPattern("codecov/*", "actions/codecov@v3"))
+	assert.False(t, matchStepPattern("test-?", "test-10"))
+	assert.False(t, matchStepPattern("a.c", "abc"))
+}
+
+func TestRunContextSkipStep(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{SkipSteps: []string{"*upload-artifact*", "deploy", "Publish *"}},
+	}
+
+	assert.True(t, rc.skipStep(&model.Step{Uses: "actions/upload-artifact@v3"}))
+	assert.True(t, rc.skipStep(&model.Step{ID: "deploy", Run: "make deploy"}))
+	assert.True(t, rc.skipStep(&model.Step{Name: "Publish docs", Run: "make docs"}))
+	assert.False(t, rc.skipStep(&model.Step{ID: "build", Name: "Build", Run: "make"}))
+	assert.False(t, (&RunContext{}).skipStep(&model.Step{ID: "deploy"}))
+}
+
+func TestStepSkippedMain(t *testing.T) {
+	rc := &RunContext{
+		StepResults: map[string]*model.StepResult{},
+	}
+	step := &stepSkipped{
+		Step:       &model.Step{ID: "upload", Uses: "actions/upload-artifact@v3"},
+		RunContext: rc,
+	}
+
+	assert.NoError(t, step.pre()(context.Background()))
+	assert.NoError(t, step.main()(context.Background()))
+	assert.NoError(t, step.post()(context.Background()))
+	assert.Equal(t, model.StepStatusSkipped, rc.StepResults["upload"].Outcome)
+	assert.Equal(t, model.StepStatusSkipped, rc.StepResults["upload"].Conclusion)
+}
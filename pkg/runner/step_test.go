@@ -171,17 +171,17 @@ func TestSetupEnv(t *testing.T) {
 		"GITHUB_ACTION_PATH":       "",
 		"GITHUB_ACTION_REF":        "",
 		"GITHUB_ACTION_REPOSITORY": "",
-		"GITHUB_API_URL":           "https:///api/v3",
+		"GITHUB_API_URL":           "https://api.github.com",
 		"GITHUB_BASE_REF":          "",
 		"GITHUB_EVENT_NAME":        "",
 		"GITHUB_EVENT_PATH":        "/var/run/act/workflow/event.json",
-		"GITHUB_GRAPHQL_URL":       "https:///api/graphql",
+		"GITHUB_GRAPHQL_URL":       "https://api.github.com/graphql",
 		"GITHUB_HEAD_REF":          "",
 		"GITHUB_JOB":               "1",
 		"GITHUB_RETENTION_DAYS":    "0",
 		"GITHUB_RUN_ID":            "runId",
 		"GITHUB_RUN_NUMBER":        "1",
-		"GITHUB_SERVER_URL":        "https://",
+		"GITHUB_SERVER_URL":        "https://github.com",
 		"GITHUB_TOKEN":             "",
 		"GITHUB_WORKFLOW":          "",
 		"INPUT_STEP_WITH":          "with-value",
@@ -193,6 +193,105 @@ func TestSetupEnv(t *testing.T) {
 	cm.AssertExpectations(t)
 }
 
+func TestSetupEnvPrecedence(t *testing.T) {
+	cm := &containerMock{}
+	sm := &stepMock{}
+
+	job := &model.Job{}
+	assert.NoError(t, job.RawContainer.Encode(map[string]interface{}{
+		"image": "node:16",
+		"env":   map[string]string{"CONTAINER": "${{ env.JOB }}-container"},
+	}))
+	rc := &RunContext{
+		Config: &Config{},
+		Run: &model.Run{
+			JobID:    "1",
+			Workflow: &model.Workflow{Jobs: map[string]*model.Job{"1": job}},
+		},
+		Env: map[string]string{
+			"WORKFLOW": "workflow",
+			"JOB":      "job",
+			"OVERRIDE": "job",
+			// written to GITHUB_ENV by a previous step, it isn't evaluated again
+			"FROM_FILE": "${{ env.JOB }}",
+		},
+		JobContainer: cm,
+	}
+	step := &model.Step{
+		Env: yaml.Node{},
+		With: map[string]string{
+			"input": "${{ env.STEP }}",
+		},
+	}
+	assert.NoError(t, step.Env.Encode(map[string]string{
+		"STEP":       "${{ env.WORKFLOW }}-step",
+		"OVERRIDE":   "step",
+		"GITHUB_JOB": "step",
+		"CI":         "false",
+	}))
+	env := map[string]string{}
+
+	sm.On("getRunContext").Return(rc)
+	sm.On("getGithubContext").Return(rc)
+	sm.On("getStepModel").Return(step)
+	sm.On("getEnv").Return(&env)
+
+	assert.NoError(t, setupEnv(context.Background(), sm))
+
+	assert.Equal(t, "workflow", env["WORKFLOW"])
+	assert.Equal(t, "job-container", env["CONTAINER"])
+	assert.Equal(t, "workflow-step", env["STEP"])
+	assert.Equal(t, "step", env["OVERRIDE"])
+	assert.Equal(t, "${{ env.JOB }}", env["FROM_FILE"])
+	assert.Equal(t, "workflow-step", env["INPUT_INPUT"])
+	// the variables of the runner win over the env of the workflow
+	assert.Equal(t, "1", env["GITHUB_JOB"])
+	assert.Equal(t, "true", env["CI"])
+}
+
+func TestSetupEnvCompositeInputs(t *testing.T) {
+	parent := &RunContext{
+		Config: &Config{},
+		Run: &model.Run{
+			JobID:    "1",
+			Workflow: &model.Workflow{Jobs: map[string]*model.Job{"1": {}}},
+		},
+	}
+	rc := &RunContext{
+		Config: &Config{},
+		Run: &model.Run{
+			JobID:    "composite-job",
+			Workflow: &model.Workflow{Jobs: map[string]*model.Job{"composite-job": {}}},
+		},
+		Env: map[string]string{
+			"INPUT_NAME":  "outer",
+			"INPUT_OTHER": "outer-other",
+		},
+		JobContainer: &containerMock{},
+		Parent:       parent,
+	}
+	sal := &stepActionLocal{
+		RunContext: rc,
+		Step: &model.Step{
+			Uses: "./../inner",
+			With: map[string]string{
+				"name": "${{ inputs.name }}-inner",
+			},
+		},
+		env: map[string]string{},
+	}
+
+	assert.NoError(t, setupEnv(context.Background(), sal))
+
+	// the with of the step sees the inputs of the composite action, the action only gets its own
+	assert.Equal(t, "outer-inner", sal.env["INPUT_NAME"])
+	assert.NotContains(t, sal.env, "INPUT_OTHER")
+
+	inputs := getEvaluatorInputs(context.Background(), rc, sal, rc.getGithubContext(context.Background()))
+	assert.Equal(t, "outer", inputs["name"])
+	assert.Equal(t, "outer-other", inputs["other"])
+}
+
 func TestIsStepEnabled(t *testing.T) {
 	createTestStep := func(t *testing.T, input string) step {
 		var step *model.Step
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// JobSummary is the result of a job, or of a combination of its matrix, at the end of the run
+type JobSummary struct {
+	Job        string // the workflow and the name of the job
+	Matrix     map[string]interface{}
+	Result     string // success, failure, cancelled or skipped
+	Duration   time.Duration
+	FailedStep string // the first step which failed, empty if none did
+}
+
+// Summary collects the results of the jobs of a run for the table at its end, it is safe for the
+// parallel jobs
+type Summary struct {
+	mu   sync.Mutex
+	jobs []JobSummary
+}
+
+// Record adds the result of a job
+func (s *Summary) Record(job JobSummary) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+// Jobs returns the results of the jobs in the order they finished
+func (s *Summary) Jobs() []JobSummary {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]JobSummary{}, s.jobs...)
+}
+
+// Failed returns the number of the jobs which failed or were cancelled
+func (s *Summary) Failed() int {
+	failed := 0
+	for _, job := range s.Jobs() {
+		if job.Result == "failure" || job.Result == "cancelled" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteTable writes the results of the jobs as a table
+func (s *Summary) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tMATRIX\tRESULT\tDURATION\tFAILED STEP")
+	for _, job := range s.Jobs() {
+		matrix := "-"
+		if len(job.Matrix) > 0 {
+			matrix = formatMatrix(job.Matrix)
+		}
+		failedStep := job.FailedStep
+		if failedStep == "" {
+			failedStep = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", job.Job, matrix, job.Result, job.Duration.Round(time.Millisecond), failedStep)
+	}
+	return tw.Flush()
+}
+
+// formatMatrix formats a combination of a matrix like "node: 18, os: ubuntu-latest"
+func formatMatrix(matrix map[string]interface{}) string {
+	keys := make([]string, 0, len(matrix))
+	for key := range matrix {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, fmt.Sprintf("%s: %v", key, matrix[key]))
+	}
+	return strings.Join(values, ", ")
+}
+
+// summarized records the result of the job of rc in the summary of the run when executor finished
+func (rc *RunContext) summarized(executor common.Executor) common.Executor {
+	if rc.Config == nil || rc.Config.Summary == nil {
+		return executor
+	}
+	return func(ctx context.Context) error {
+		start := time.Now()
+		err := executor(ctx)
+		rc.Config.Summary.Record(JobSummary{
+			Job:        rc.String(),
+			Matrix:     rc.Matrix,
+			Result:     rc.jobResult(err),
+			Duration:   time.Since(start),
+			FailedStep: rc.failedStep(),
+		})
+		return err
+	}
+}
+
+// jobResult returns the result of the job of rc once it finished with err, success, failure,
+// cancelled or skipped
+func (rc *RunContext) jobResult(err error) string {
+	result := rc.legResult
+	if result == "" {
+		// a job calling a reusable workflow gets the result of the called jobs
+		result = rc.Run.Job().Result
+	}
+	if err != nil && (result == "" || result == "success") {
+		result = "failure"
+	} else if result == "" {
+		result = "skipped"
+	}
+	return result
+}
+
+// failedStep returns the name of the first step of the job which failed
+func (rc *RunContext) failedStep() string {
+	for _, step := range rc.Run.Job().Steps {
+		if step == nil {
+			continue
+		}
+		if result, ok := rc.StepResults[step.ID]; ok && result.Conclusion == model.StepStatusFailure {
+			return step.String()
+		}
+	}
+	return ""
+}
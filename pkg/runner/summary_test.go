@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunContext_Summarized(t *testing.T) {
+	summary := &Summary{}
+	job := &model.Job{Name: "test", Steps: []*model.Step{
+		{ID: "checkout", Uses: "actions/checkout@v3"},
+		{ID: "flaky", Name: "Flaky", Run: "exit 1", RawContinueOnError: "true"},
+		{ID: "build", Name: "Build", Run: "make"},
+		{ID: "deploy", Name: "Deploy", Run: "make deploy"},
+	}}
+	newRunContext := func(matrix map[string]interface{}) *RunContext {
+		return &RunContext{
+			Name:   "test",
+			Config: &Config{Summary: summary},
+			Matrix: matrix,
+			Run: &model.Run{
+				JobID:    "test",
+				Workflow: &model.Workflow{Name: "CI", Jobs: map[string]*model.Job{"test": job}},
+			},
+			StepResults: map[string]*model.StepResult{},
+		}
+	}
+
+	rc := newRunContext(map[string]interface{}{"os": "ubuntu-latest", "node": 18})
+	rc.StepResults["flaky"] = &model.StepResult{Outcome: model.StepStatusFailure, Conclusion: model.StepStatusSuccess}
+	rc.StepResults["build"] = &model.StepResult{Outcome: model.StepStatusFailure, Conclusion: model.StepStatusFailure}
+	err := rc.summarized(func(ctx context.Context) error {
+		rc.legResult = "failure"
+		return nil
+	})(context.Background())
+	require.NoError(t, err)
+
+	rc = newRunContext(map[string]interface{}{"os": "ubuntu-latest", "node": 20})
+	err = rc.summarized(func(ctx context.Context) error {
+		rc.legResult = "success"
+		return nil
+	})(context.Background())
+	require.NoError(t, err)
+
+	// a job which isn't enabled has no result, one failing before it ran failed
+	require.NoError(t, newRunContext(nil).summarized(func(ctx context.Context) error { return nil })(context.Background()))
+	require.Error(t, newRunContext(nil).summarized(func(ctx context.Context) error { return errors.New("invalid if") })(context.Background()))
+
+	jobs := summary.Jobs()
+	require.Len(t, jobs, 4)
+	assert.Equal(t, "CI/test", jobs[0].Job)
+	assert.Equal(t, "failure", jobs[0].Result)
+	assert.Equal(t, "Build", jobs[0].FailedStep)
+	assert.Equal(t, "success", jobs[1].Result)
+	assert.Equal(t, "", jobs[1].FailedStep)
+	assert.Equal(t, "skipped", jobs[2].Result)
+	assert.Equal(t, "failure", jobs[3].Result)
+	assert.Equal(t, 2, summary.Failed())
+
+	out := &bytes.Buffer{}
+	require.NoError(t, summary.WriteTable(out))
+	assert.Contains(t, out.String(), "FAILED STEP")
+	assert.Contains(t, out.String(), "node: 18, os: ubuntu-latest")
+	assert.Regexp(t, `CI/test +node: 18, os: ubuntu-latest +failure +\S+ +Build`, out.String())
+}
+
+func TestSummary_Nil(t *testing.T) {
+	var summary *Summary
+	summary.Record(JobSummary{Job: "CI/test", Result: "failure"})
+	assert.Empty(t, summary.Jobs())
+	assert.Equal(t, 0, summary.Failed())
+}
@@ -0,0 +1,200 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// TimingKind is what a Timing measured
+type TimingKind string
+
+const (
+	TimingPlan   TimingKind = "plan"   // reading and planning the workflows
+	TimingJob    TimingKind = "job"    // a job, including its containers and steps
+	TimingPull   TimingKind = "pull"   // pulling an image
+	TimingCreate TimingKind = "create" // creating a container
+	TimingStep   TimingKind = "step"   // a pre, main or post step
+)
+
+// Timing is the wall-clock duration of a part of the run
+type Timing struct {
+	Job      string        `json:"job,omitempty"`
+	Kind     TimingKind    `json:"kind"`
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Timings records the durations of the plan, the jobs, the image pulls, the container creation
+// and the steps of a run, it is safe for the parallel jobs
+type Timings struct {
+	mu      sync.Mutex
+	timings []Timing
+}
+
+// Record adds the time since start
+func (t *Timings) Record(job string, kind TimingKind, name string, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings = append(t.timings, Timing{
+		Job:      job,
+		Kind:     kind,
+		Name:     name,
+		Start:    start,
+		Duration: time.Since(start),
+	})
+}
+
+// Timed returns an executor which records the duration of executor, also when it fails
+func (t *Timings) Timed(job string, kind TimingKind, name string, executor common.Executor) common.Executor {
+	if t == nil {
+		return executor
+	}
+	return func(ctx context.Context) error {
+		start := time.Now()
+		defer t.Record(job, kind, name, start)
+		return executor(ctx)
+	}
+}
+
+// Sorted returns the timings sorted by duration, longest first, by start or by job and name
+func (t *Timings) Sorted(by string) ([]Timing, error) {
+	var less func(a, b Timing) bool
+	switch by {
+	case "duration", "":
+		less = func(a, b Timing) bool { return a.Duration > b.Duration }
+	case "start":
+		less = func(a, b Timing) bool { return a.Start.Before(b.Start) }
+	case "name":
+		less = func(a, b Timing) bool {
+			if a.Job != b.Job {
+				return a.Job < b.Job
+			}
+			return a.Name < b.Name
+		}
+	default:
+		return nil, fmt.Errorf("unknown timing sort '%s', expected duration, start or name", by)
+	}
+	if t == nil {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	timings := append([]Timing{}, t.timings...)
+	t.mu.Unlock()
+	sort.SliceStable(timings, func(i, j int) bool { return less(timings[i], timings[j]) })
+	return timings, nil
+}
+
+// WriteTable writes the timings as a table sorted by by
+func (t *Timings) WriteTable(w io.Writer, by string) error {
+	timings, err := t.Sorted(by)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DURATION\tJOB\tKIND\tNAME")
+	for _, timing := range timings {
+		job := timing.Job
+		if job == "" {
+			job = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", timing.Duration.Round(time.Millisecond), job, timing.Kind, timing.Name)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the timings sorted by start as a JSON array, durations in nanoseconds
+func (t *Timings) WriteJSON(w io.Writer) error {
+	timings, err := t.Sorted("start")
+	if err != nil {
+		return err
+	}
+	if timings == nil {
+		timings = []Timing{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(timings)
+}
+
+// WriteFlamegraph writes the timings in the folded stack format of flamegraph.pl and speedscope,
+// one line "job;step;pull image milliseconds" per timing with its self time, the time not spent
+// in the timings nested in it, e.g. the pull of a docker action is nested in its step.
+func (t *Timings) WriteFlamegraph(w io.Writer) error {
+	timings, err := t.Sorted("start")
+	if err != nil {
+		return err
+	}
+	frame := func(timing Timing) string {
+		name := timing.Name
+		if timing.Kind != TimingJob {
+			name = fmt.Sprintf("%s %s", timing.Kind, timing.Name)
+		}
+		// ; separates the frames and the last space the value
+		return strings.NewReplacer(";", ":", "\n", " ").Replace(name)
+	}
+
+	parents := make([]int, len(timings))
+	self := make([]time.Duration, len(timings))
+	for i, timing := range timings {
+		parents[i] = -1
+		self[i] = timing.Duration
+		for j, parent := range timings {
+			if timing.Job == "" || j == i || parent.Job != timing.Job || !contains(parent, timing) {
+				continue
+			}
+			switch {
+			case parent.Kind == TimingJob && timing.Kind != TimingJob && parents[i] == -1,
+				parent.Kind == TimingStep && timing.Kind != TimingStep && timing.Kind != TimingJob:
+				parents[i] = j
+			}
+		}
+	}
+	for i, parent := range parents {
+		if parent >= 0 {
+			self[parent] -= timings[i].Duration
+		}
+	}
+
+	for i, timing := range timings {
+		frames := []string{frame(timing)}
+		for parent := parents[i]; parent >= 0; parent = parents[parent] {
+			frames = append([]string{frame(timings[parent])}, frames...)
+		}
+		if parents[i] == -1 && timing.Job != "" && timing.Kind != TimingJob {
+			frames = append([]string{timing.Job}, frames...)
+		}
+		if self[i] < 0 {
+			self[i] = 0
+		}
+		if _, err := fmt.Fprintf(w, "%s %d\n", strings.Join(frames, ";"), self[i].Milliseconds()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func contains(parent Timing, child Timing) bool {
+	return !child.Start.Before(parent.Start) && !child.Start.Add(child.Duration).After(parent.Start.Add(parent.Duration))
+}
+
+// timed records the duration of executor for the job of rc if the timings are recorded
+func (rc *RunContext) timed(kind TimingKind, name string, executor common.Executor) common.Executor {
+	if rc.Config == nil || rc.Config.Timings == nil {
+		return executor
+	}
+	return rc.Config.Timings.Timed(rc.String(), kind, name, executor)
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTimings() *Timings {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	return &Timings{timings: []Timing{
+		{Kind: TimingPlan, Name: "push", Start: at(0), Duration: 1 * time.Second},
+		{Job: "CI/test", Kind: TimingJob, Name: "CI/test", Start: at(1), Duration: 60 * time.Second},
+		{Job: "CI/test", Kind: TimingPull, Name: "node:16", Start: at(2), Duration: 20 * time.Second},
+		{Job: "CI/test", Kind: TimingCreate, Name: "job container", Start: at(22), Duration: 2 * time.Second},
+		{Job: "CI/test", Kind: TimingStep, Name: "Main docker://alpine", Start: at(30), Duration: 10 * time.Second},
+		{Job: "CI/test", Kind: TimingPull, Name: "alpine", Start: at(31), Duration: 4 * time.Second},
+		{Job: "CI/test", Kind: TimingStep, Name: "Main make test", Start: at(40), Duration: 15 * time.Second},
+	}}
+}
+
+func TestTimingsTimed(t *testing.T) {
+	timings := &Timings{}
+	err := timings.Timed("CI/test", TimingStep, "Main fail", func(ctx context.Context) error {
+		return errors.New("failed")
+	})(context.Background())
+	assert.EqualError(t, err, "failed")
+
+	sorted, err := timings.Sorted("start")
+	assert.NoError(t, err)
+	if assert.Len(t, sorted, 1) {
+		assert.Equal(t, "CI/test", sorted[0].Job)
+		assert.Equal(t, TimingStep, sorted[0].Kind)
+		assert.Equal(t, "Main fail", sorted[0].Name)
+	}
+
+	var disabled *Timings
+	called := false
+	err = disabled.Timed("CI/test", TimingStep, "Main", func(ctx context.Context) error {
+		called = true
+		return nil
+	})(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestTimingsSorted(t *testing.T) {
+	timings := newTestTimings()
+
+	names := func(by string) []string {
+		sorted, err := timings.Sorted(by)
+		assert.NoError(t, err)
+		names := []string{}
+		for _, timing := range sorted {
+			names = append(names, timing.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"CI/test", "node:16", "Main make test", "Main docker://alpine", "alpine", "job container", "push"}, names("duration"))
+	assert.Equal(t, []string{"push", "CI/test", "node:16", "job container", "Main docker://alpine", "alpine", "Main make test"}, names("start"))
+	assert.Equal(t, []string{"push", "CI/test", "Main docker://alpine", "Main make test", "alpine", "job container", "node:16"}, names("name"))
+
+	_, err := timings.Sorted("size")
+	assert.EqualError(t, err, "unknown timing sort 'size', expected duration, start or name")
+	_, err = (*Timings)(nil).Sorted("size")
+	assert.Error(t, err)
+}
+
+func TestTimingsWriteTable(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, newTestTimings().WriteTable(buf, "duration"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 8) {
+		assert.Equal(t, []string{"DURATION", "JOB", "KIND", "NAME"}, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"1m0s", "CI/test", "job", "CI/test"}, strings.Fields(lines[1]))
+		assert.Equal(t, []string{"1s", "-", "plan", "push"}, strings.Fields(lines[7]))
+	}
+}
+
+func TestTimingsWriteJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, newTestTimings().WriteJSON(buf))
+	var timings []Timing
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &timings))
+	if assert.Len(t, timings, 7) {
+		assert.Equal(t, Timing{
+			Job:      "CI/test",
+			Kind:     TimingPull,
+			Name:     "node:16",
+			Start:    time.Date(2023, 1, 1, 0, 0, 2, 0, time.UTC),
+			Duration: 20 * time.Second,
+		}, timings[2])
+	}
+
+	buf.Reset()
+	assert.NoError(t, (&Timings{}).WriteJSON(buf))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestTimingsWriteFlamegraph(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, newTestTimings().WriteFlamegraph(buf))
+	assert.Equal(t, strings.Join([]string{
+		"plan push 1000",
+		"CI/test 13000",
+		"CI/test;pull node:16 20000",
+		"CI/test;create job container 2000",
+		"CI/test;step Main docker://alpine 6000",
+		"CI/test;step Main docker://alpine;pull alpine 4000",
+		"CI/test;step Main make test 15000",
+	}, "\n")+"\n", buf.String())
+}
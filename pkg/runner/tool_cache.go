@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"sync"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// toolCacheLocks are the locks of the tool caches held by the jobs of this act process. The jobs of
+// one run share a tool cache like the jobs of a runner, the jobs of other act processes wait until
+// they are done: the setup actions don't expect another runner to write the tool cache.
+var toolCacheLocks = struct {
+	sync.Mutex
+	held map[string]*toolCacheLock
+}{held: map[string]*toolCacheLock{}}
+
+type toolCacheLock struct {
+	jobs   int
+	unlock func()
+}
+
+// toolCacheName returns the host directory or the volume of the persistent tool cache, empty when
+// the job uses the shared act-toolcache volume
+func (rc *RunContext) toolCacheName() string {
+	if rc.Config.ToolCache != "" {
+		return rc.Config.ToolCache
+	}
+	if rc.Config.ProjectVolumes != "" {
+		return rc.projectVolumeName(volumeToolCache)
+	}
+	return ""
+}
+
+// withToolCacheLock runs the job holding the lock of its persistent tool cache
+func (rc *RunContext) withToolCacheLock(executor common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		name := rc.toolCacheName()
+		if name == "" || common.Dryrun(ctx) {
+			return executor(ctx)
+		}
+		sum := sha256.Sum256([]byte(name))
+		path := filepath.Join(rc.ActionCacheDir(), "locks", "toolcache-"+hex.EncodeToString(sum[:8])+".lock")
+		release, err := holdToolCacheLock(ctx, path)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return executor(ctx)
+	}
+}
+
+func holdToolCacheLock(ctx context.Context, path string) (func(), error) {
+	toolCacheLocks.Lock()
+	defer toolCacheLocks.Unlock()
+
+	lock, ok := toolCacheLocks.held[path]
+	if !ok {
+		unlock, err := common.LockFile(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		lock = &toolCacheLock{unlock: unlock}
+		toolCacheLocks.held[path] = lock
+	}
+	lock.jobs++
+
+	return func() {
+		toolCacheLocks.Lock()
+		defer toolCacheLocks.Unlock()
+		lock.jobs--
+		if lock.jobs == 0 {
+			lock.unlock()
+			delete(toolCacheLocks.held, path)
+		}
+	}, nil
+}
@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+func TestHoldToolCacheLock(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "toolcache.lock")
+
+	// the jobs of the process share the lock
+	first, err := holdToolCacheLock(context.Background(), path)
+	assert.NoError(err)
+	second, err := holdToolCacheLock(context.Background(), path)
+	assert.NoError(err)
+
+	locked := func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		unlock, err := common.LockFile(ctx, path)
+		if err != nil {
+			return true
+		}
+		unlock()
+		return false
+	}
+
+	first()
+	assert.True(locked(), "the lock is held until the last job is done")
+	second()
+	assert.False(locked())
+}
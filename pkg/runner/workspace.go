@@ -0,0 +1,156 @@
+package runner
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+)
+
+// prepareWorkspace copies the workdir into the job container when it starts, the local
+// actions/checkout steps of the job don't copy it again. A bound or copied workspace gets its
+// submodules and git lfs files here.
+func (rc *RunContext) prepareWorkspace() common.Executor {
+	return func(ctx context.Context) error {
+		dst := rc.JobContainer.ToContainerPath(rc.Config.Workdir)
+		if rc.Config.BindWorkdir {
+			return rc.setupWorkspaceGit(dst, rc.Config.Submodules, rc.Config.LFS)(ctx)
+		}
+		if !rc.Config.CopyWorkspace {
+			return nil
+		}
+		src := rc.Config.Workdir + string(filepath.Separator) + "."
+		var copyDir common.Executor
+		if tracked, ok := rc.JobContainer.(container.TrackedFilesContainer); ok && rc.Config.CopyTrackedOnly {
+			copyDir = tracked.CopyTrackedDir(dst, src)
+		} else {
+			if rc.Config.CopyTrackedOnly {
+				common.Logger(ctx).Warnf("The job container can't copy only the files tracked by git, copying all files")
+			}
+			copyDir = rc.JobContainer.CopyDir(dst, src, rc.Config.UseGitIgnore)
+		}
+		return copyDir.Then(rc.setupWorkspaceGit(dst, rc.Config.Submodules, rc.Config.LFS))(ctx)
+	}
+}
+
+// copyWorkspaceBack copies the paths of CopyBack from the workspace in the job container to the
+// workdir. The copied files are owned by the owner of the workdir, not by the user of the container.
+func (rc *RunContext) copyWorkspaceBack() common.Executor {
+	return func(ctx context.Context) error {
+		if len(rc.Config.CopyBack) == 0 || rc.Config.BindWorkdir || rc.JobContainer == nil || common.Dryrun(ctx) {
+			return nil
+		}
+		fi, err := os.Stat(rc.Config.Workdir)
+		if err != nil {
+			return err
+		}
+		chown := func(string) error { return nil }
+		if uid, gid, ok := fileOwner(fi); ok && uid != os.Geteuid() {
+			chown = func(name string) error { return os.Lchown(name, uid, gid) }
+		}
+
+		for _, p := range rc.Config.CopyBack {
+			rel, err := workspacePath(p)
+			if err != nil {
+				return err
+			}
+			common.Logger(ctx).Infof("\U0001F4E5  Copying %s back to the workdir", rel)
+			archive, err := rc.JobContainer.GetContainerArchive(ctx, path.Join(rc.JobContainer.ToContainerPath(rc.Config.Workdir), rel))
+			if err != nil {
+				return fmt.Errorf("failed to copy %s back to the workdir: %w", rel, err)
+			}
+			err = extractWorkspaceArchive(archive, filepath.Join(rc.Config.Workdir, filepath.FromSlash(rel)), chown)
+			archive.Close()
+			if err != nil {
+				return fmt.Errorf("failed to copy %s back to the workdir: %w", rel, err)
+			}
+		}
+		return nil
+	}
+}
+
+// workspacePath returns p as a clean slash separated path relative to the workspace
+func workspacePath(p string) (string, error) {
+	rel := path.Clean(filepath.ToSlash(p))
+	if path.IsAbs(rel) || filepath.IsAbs(p) || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("the path '%s' to copy back isn't in the workspace", p)
+	}
+	return rel, nil
+}
+
+// extractWorkspaceArchive extracts a tar archive of the container into dst, the top level entry of
+// the archive becomes dst. chown is called for every extracted file.
+func extractWorkspaceArchive(archive io.Reader, dst string, chown func(string) error) error {
+	tr := tar.NewReader(archive)
+	symlinks := []string{}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[i+1:]
+		} else {
+			name = "."
+		}
+		if name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return fmt.Errorf("the archive entry '%s' is outside of the copied path", header.Name)
+		}
+		for _, symlink := range symlinks {
+			if strings.HasPrefix(name, symlink+"/") {
+				return fmt.Errorf("the archive entry '%s' is inside of the symlink '%s'", header.Name, symlink)
+			}
+		}
+		target := filepath.Join(dst, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode).Perm()|0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			_ = os.Remove(target)
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			_ = os.Remove(target)
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+			symlinks = append(symlinks, name)
+		default:
+			continue
+		}
+		if err := chown(target); err != nil {
+			return err
+		}
+	}
+}
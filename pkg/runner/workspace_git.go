@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// lfsPointerPrefix starts the pointer files git lfs checks in instead of the content of a file
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/v1"
+
+// lfsPointerMaxSize is the size of the largest pointer file git lfs writes
+const lfsPointerMaxSize = 1024
+
+// workspaceGitState is what the workspace lacks compared to a checkout on a hosted runner
+type workspaceGitState struct {
+	Submodules  []string // paths of the submodules which aren't initialized
+	LFSPointers []string // paths of the files which are git lfs pointers instead of their content
+}
+
+// workspaceGit is the git state of the workdir, the jobs of a run and their actions/checkout steps
+// scan the index of the repository once
+type workspaceGit struct {
+	once  sync.Once
+	state *workspaceGitState
+	err   error
+}
+
+func (w *workspaceGit) detect(workdir string) (*workspaceGitState, error) {
+	if w == nil {
+		return detectWorkspaceGitState(workdir)
+	}
+	w.once.Do(func() {
+		w.state, w.err = detectWorkspaceGitState(workdir)
+	})
+	return w.state, w.err
+}
+
+// detectWorkspaceGitState finds the submodules which aren't initialized and the git lfs pointers of
+// the repository in workdir, a workdir which isn't a git repository has neither
+func detectWorkspaceGitState(workdir string) (*workspaceGitState, error) {
+	state := &workspaceGitState{}
+	repo, err := gogit.PlainOpen(workdir)
+	if errors.Is(err, gogit.ErrRepositoryNotExists) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	submodules, err := wt.Submodules()
+	if err != nil {
+		return nil, err
+	}
+	for _, submodule := range submodules {
+		p := submodule.Config().Path
+		if entries, err := os.ReadDir(filepath.Join(workdir, filepath.FromSlash(p))); err != nil || len(entries) == 0 {
+			state.Submodules = append(state.Submodules, p)
+		}
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range idx.Entries {
+		if entry.Mode != filemode.Regular && entry.Mode != filemode.Executable || entry.Size > lfsPointerMaxSize {
+			continue
+		}
+		if isLFSPointer(filepath.Join(workdir, filepath.FromSlash(entry.Name))) {
+			state.LFSPointers = append(state.LFSPointers, entry.Name)
+		}
+	}
+	return state, nil
+}
+
+func isLFSPointer(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	prefix := make([]byte, len(lfsPointerPrefix))
+	if _, err := io.ReadFull(f, prefix); err != nil {
+		return false
+	}
+	return bytes.Equal(prefix, []byte(lfsPointerPrefix))
+}
+
+// setupWorkspaceGit initializes the submodules and fetches the git lfs files of the workspace at dir
+// in the job container like actions/checkout with the submodules and lfs inputs would. Submodules
+// and git lfs pointers which aren't requested are reported with a warning. The bound workdir isn't
+// written, the files git would create in the job container would belong to its user.
+func (rc *RunContext) setupWorkspaceGit(dir string, submodules string, lfs bool) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		state, err := rc.workspaceGit.detect(rc.Config.Workdir)
+		if err != nil {
+			logger.Debugf("Unable to inspect the git repository of the workspace: %v", err)
+			return nil
+		}
+
+		recursive := submodules == "recursive"
+		if rc.Config.BindWorkdir {
+			if len(state.Submodules) > 0 {
+				logger.Warnf("The submodules %s of the bound workdir aren't initialized, initialize them with git submodule update --init",
+					strings.Join(state.Submodules, ", "))
+			}
+			if len(state.LFSPointers) > 0 {
+				logger.Warnf("The bound workdir has %d git lfs pointers instead of the files, e.g. %s, fetch them with git lfs pull",
+					len(state.LFSPointers), state.LFSPointers[0])
+			}
+			return nil
+		}
+		if len(state.Submodules) > 0 {
+			if submodules != "true" && !recursive {
+				logger.Warnf("The submodules %s of the workspace aren't initialized, initialize them in the job container with --submodules true or with the submodules input of actions/checkout",
+					strings.Join(state.Submodules, ", "))
+			} else {
+				command := []string{"git", "-c", "safe.directory=*", "submodule", "update", "--init"}
+				if recursive {
+					command = append(command, "--recursive")
+				}
+				logger.Infof("\U0001F4E6  Initializing the submodules %s", strings.Join(state.Submodules, ", "))
+				if err := rc.JobContainer.Exec(command, nil, "", dir)(ctx); err != nil {
+					return fmt.Errorf("failed to initialize the submodules in the job container: %w", err)
+				}
+			}
+		}
+
+		if len(state.LFSPointers) > 0 {
+			if !lfs {
+				logger.Warnf("The workspace has %d git lfs pointers instead of the files, e.g. %s, fetch them in the job container with --lfs or with the lfs input of actions/checkout",
+					len(state.LFSPointers), state.LFSPointers[0])
+			} else {
+				logger.Infof("\U0001F4E6  Fetching %d git lfs files", len(state.LFSPointers))
+				if err := rc.JobContainer.Exec([]string{"git", "-c", "safe.directory=*", "lfs", "pull"}, nil, "", dir)(ctx); err != nil {
+					return fmt.Errorf("failed to fetch the git lfs files in the job container: %w", err)
+				}
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+func newTestWorkspaceRepo(t *testing.T) string {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	assert.NoError(t, err)
+	for name, content := range map[string]string{
+		".gitmodules":     "[submodule \"libs/sub\"]\n\tpath = libs/sub\n\turl = https://github.com/nektos/sub\n",
+		"main.go":         "package main\n",
+		"assets/logo.png": lfsPointerPrefix + "\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n",
+	} {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+	wt, err := repo.Worktree()
+	assert.NoError(t, err)
+	assert.NoError(t, wt.AddGlob("."))
+	// the directory of a submodule which isn't initialized is empty
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "libs", "sub"), 0o755))
+	return dir
+}
+
+func TestDetectWorkspaceGitState(t *testing.T) {
+	state, err := detectWorkspaceGitState(newTestWorkspaceRepo(t))
+	assert.NoError(t, err)
+	assert.Equal(t, &workspaceGitState{
+		Submodules:  []string{"libs/sub"},
+		LFSPointers: []string{"assets/logo.png"},
+	}, state)
+
+	state, err = detectWorkspaceGitState(t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, &workspaceGitState{}, state)
+}
+
+func TestWorkspaceGitDetect(t *testing.T) {
+	workdir := newTestWorkspaceRepo(t)
+	w := &workspaceGit{}
+	state, err := w.detect(workdir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"libs/sub"}, state.Submodules)
+
+	// the index is scanned once per run
+	assert.NoError(t, os.Remove(filepath.Join(workdir, "assets", "logo.png")))
+	cached, err := w.detect(workdir)
+	assert.NoError(t, err)
+	assert.Same(t, state, cached)
+	assert.Equal(t, []string{"assets/logo.png"}, cached.LFSPointers)
+}
+
+func TestSetupWorkspaceGitBind(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	ctx := common.WithLogger(context.Background(), logger)
+	cm := &containerMock{}
+	rc := &RunContext{Config: &Config{Workdir: newTestWorkspaceRepo(t), BindWorkdir: true}, JobContainer: cm}
+
+	// nothing is written into the bound workdir
+	assert.NoError(t, rc.setupWorkspaceGit("/github/workspace", "recursive", true)(ctx))
+	cm.AssertNotCalled(t, "Exec")
+	assert.Len(t, hook.AllEntries(), 2)
+	assert.Contains(t, hook.AllEntries()[0].Message, "git submodule update --init")
+	assert.Contains(t, hook.AllEntries()[1].Message, "git lfs pull")
+}
+
+func TestSetupWorkspaceGit(t *testing.T) {
+	workdir := newTestWorkspaceRepo(t)
+	noop := func(ctx context.Context) error { return nil }
+
+	for _, tt := range []struct {
+		name       string
+		submodules string
+		lfs        bool
+		commands   [][]string
+		warnings   int
+	}{
+		{name: "not requested", warnings: 2},
+		{
+			name:       "submodules",
+			submodules: "true",
+			commands:   [][]string{{"git", "-c", "safe.directory=*", "submodule", "update", "--init"}},
+			warnings:   1,
+		},
+		{
+			name:       "recursive submodules and lfs",
+			submodules: "recursive",
+			lfs:        true,
+			commands: [][]string{
+				{"git", "-c", "safe.directory=*", "submodule", "update", "--init", "--recursive"},
+				{"git", "-c", "safe.directory=*", "lfs", "pull"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, hook := test.NewNullLogger()
+			ctx := common.WithLogger(context.Background(), logger)
+			cm := &containerMock{}
+			for _, command := range tt.commands {
+				cm.On("Exec", command, map[string]string(nil), "", "/github/workspace").Return(noop)
+			}
+			rc := &RunContext{Config: &Config{Workdir: workdir}, JobContainer: cm}
+
+			assert.NoError(t, rc.setupWorkspaceGit("/github/workspace", tt.submodules, tt.lfs)(ctx))
+			cm.AssertExpectations(t)
+			warnings := 0
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel {
+					warnings++
+				}
+			}
+			assert.Equal(t, tt.warnings, warnings)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows && !plan9
+
+package runner
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner returns the user and group owning a file
+func fileOwner(fi os.FileInfo) (int, int, bool) {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(stat.Uid), int(stat.Gid), true
+}
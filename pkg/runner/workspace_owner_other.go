@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package runner
+
+import (
+	"os"
+)
+
+// fileOwner returns false, files have no numeric owner on this platform
+func fileOwner(fi os.FileInfo) (int, int, bool) {
+	return 0, 0, false
+}
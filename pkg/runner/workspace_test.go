@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+type trackedFilesContainerMock struct {
+	containerMock
+}
+
+func (cm *trackedFilesContainerMock) CopyTrackedDir(destPath string, srcPath string) common.Executor {
+	args := cm.Called(destPath, srcPath)
+	return args.Get(0).(func(context.Context) error)
+}
+
+func TestPrepareWorkspace(t *testing.T) {
+	workdir := filepath.Join(string(filepath.Separator), "home", "act", "repo")
+	src := workdir + string(filepath.Separator) + "."
+	noop := func(ctx context.Context) error { return nil }
+
+	for _, tt := range []struct {
+		name    string
+		config  *Config
+		tracked bool
+	}{
+		{name: "disabled", config: &Config{Workdir: workdir}},
+		{name: "bound", config: &Config{Workdir: workdir, CopyWorkspace: true, BindWorkdir: true}},
+		{name: "all files", config: &Config{Workdir: workdir, CopyWorkspace: true, UseGitIgnore: true}},
+		{name: "tracked files", config: &Config{Workdir: workdir, CopyWorkspace: true, CopyTrackedOnly: true}, tracked: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &trackedFilesContainerMock{}
+			rc := &RunContext{Config: tt.config, JobContainer: cm}
+			dst := cm.ToContainerPath(workdir)
+			if tt.tracked {
+				cm.On("CopyTrackedDir", dst, src).Return(noop)
+			} else if tt.config.CopyWorkspace && !tt.config.BindWorkdir {
+				cm.On("CopyDir", dst, src, tt.config.UseGitIgnore).Return(noop)
+			}
+			assert.NoError(t, rc.prepareWorkspace()(context.Background()))
+			cm.AssertExpectations(t)
+		})
+	}
+}
+
+type testArchiveEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+}
+
+func newTestArchive(t *testing.T, entries ...testArchiveEntry) io.ReadCloser {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, entry := range entries {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Mode:     0o644,
+			Size:     int64(len(entry.body)),
+			Linkname: entry.linkname,
+		}))
+		_, err := tw.Write([]byte(entry.body))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return io.NopCloser(buf)
+}
+
+func TestCopyWorkspaceBack(t *testing.T) {
+	workdir := t.TempDir()
+	cm := &containerMock{}
+	rc := &RunContext{
+		Config:       &Config{Workdir: workdir, CopyBack: []string{"dist", "./coverage.out"}},
+		JobContainer: cm,
+	}
+	containerWorkdir := cm.ToContainerPath(workdir)
+	cm.On("GetContainerArchive", mock.Anything, containerWorkdir+"/dist").Return(newTestArchive(t,
+		testArchiveEntry{name: "dist/", typeflag: tar.TypeDir},
+		testArchiveEntry{name: "dist/app.js", typeflag: tar.TypeReg, body: "app"},
+		testArchiveEntry{name: "dist/lib/util.js", typeflag: tar.TypeReg, body: "util"},
+		testArchiveEntry{name: "dist/main.js", typeflag: tar.TypeSymlink, linkname: "app.js"},
+	), nil)
+	cm.On("GetContainerArchive", mock.Anything, containerWorkdir+"/coverage.out").Return(newTestArchive(t,
+		testArchiveEntry{name: "coverage.out", typeflag: tar.TypeReg, body: "mode: set"},
+	), nil)
+
+	assert.NoError(t, rc.copyWorkspaceBack()(context.Background()))
+	cm.AssertExpectations(t)
+
+	for name, body := range map[string]string{
+		"dist/app.js":      "app",
+		"dist/lib/util.js": "util",
+		"dist/main.js":     "app",
+		"coverage.out":     "mode: set",
+	} {
+		content, err := os.ReadFile(filepath.Join(workdir, filepath.FromSlash(name)))
+		assert.NoError(t, err, name)
+		assert.Equal(t, body, string(content), name)
+	}
+
+	rc.Config.CopyBack = []string{"../outside"}
+	assert.EqualError(t, rc.copyWorkspaceBack()(context.Background()), "the path '../outside' to copy back isn't in the workspace")
+}
+
+func TestExtractWorkspaceArchive(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "dist")
+	chowned := []string{}
+	chown := func(name string) error {
+		rel, err := filepath.Rel(dst, name)
+		chowned = append(chowned, filepath.ToSlash(rel))
+		return err
+	}
+	assert.NoError(t, extractWorkspaceArchive(newTestArchive(t,
+		testArchiveEntry{name: "dist/", typeflag: tar.TypeDir},
+		testArchiveEntry{name: "dist/lib/util.js", typeflag: tar.TypeReg, body: "util"},
+	), dst, chown))
+	sort.Strings(chowned)
+	assert.Equal(t, []string{".", "lib/util.js"}, chowned)
+
+	err := extractWorkspaceArchive(newTestArchive(t,
+		testArchiveEntry{name: "dist/../../../evil", typeflag: tar.TypeReg, body: "evil"},
+	), dst, chown)
+	assert.EqualError(t, err, "the archive entry 'dist/../../../evil' is outside of the copied path")
+
+	err = extractWorkspaceArchive(newTestArchive(t,
+		testArchiveEntry{name: "dist/etc", typeflag: tar.TypeSymlink, linkname: "/etc"},
+		testArchiveEntry{name: "dist/etc/passwd", typeflag: tar.TypeReg, body: "evil"},
+	), dst, chown)
+	assert.EqualError(t, err, "the archive entry 'dist/etc/passwd' is inside of the symlink 'etc'")
+}
+
+func TestWorkspacePath(t *testing.T) {
+	for p, expected := range map[string]string{
+		"dist":         "dist",
+		"./dist/":      "dist",
+		"a/../b":       "b",
+		".":            ".",
+		"node_modules": "node_modules",
+	} {
+		rel, err := workspacePath(p)
+		assert.NoError(t, err, p)
+		assert.Equal(t, expected, rel, p)
+	}
+	for _, p := range []string{"..", "../dist", "/etc", "a/../../b"} {
+		_, err := workspacePath(p)
+		assert.Error(t, err, p)
+	}
+}
@@ -0,0 +1,178 @@
+// Package secretprovider resolves the secrets of a run from external sources like HashiCorp Vault,
+// AWS Secrets Manager or a helper command.
+package secretprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// Provider resolves the values of secrets at run time, so they don't have to be stored in files
+type Provider interface {
+	// Secrets returns the values of the secrets with the given uppercased names it knows
+	Secrets(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// NewProvider creates the provider of a --secret-provider value, which is one of
+//
+//	cmd=<command>     runs the command with the name of each secret as last argument, its output is the value
+//	vault=<path>      reads the secrets at the path of HashiCorp Vault, using VAULT_ADDR and VAULT_TOKEN
+//	aws=<secret-id>   reads a JSON object of secrets from AWS Secrets Manager with the aws CLI
+func NewProvider(spec string) (Provider, error) {
+	kind, value, ok := strings.Cut(spec, "=")
+	if !ok || value == "" {
+		return nil, fmt.Errorf("invalid secret provider '%s', expected cmd=<command>, vault=<path> or aws=<secret-id>", spec)
+	}
+	switch kind {
+	case "cmd":
+		args, err := shellquote.Split(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret provider command '%s': %w", value, err)
+		}
+		return &execProvider{args: args}, nil
+	case "vault":
+		return &vaultProvider{
+			addr:  strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/"),
+			token: os.Getenv("VAULT_TOKEN"),
+			path:  strings.Trim(value, "/"),
+		}, nil
+	case "aws":
+		return &execProvider{
+			args:   []string{"aws", "secretsmanager", "get-secret-value", "--secret-id", value, "--query", "SecretString", "--output", "text"},
+			object: true,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown secret provider '%s', expected cmd, vault or aws", kind)
+}
+
+// Resolve sets the secrets which have no value from the providers, the first provider knowing a secret wins
+func Resolve(ctx context.Context, providers []Provider, names []string, secrets map[string]string) error {
+	for _, provider := range providers {
+		defined := make(map[string]bool, len(secrets))
+		for name := range secrets {
+			defined[strings.ToUpper(name)] = true
+		}
+		missing := make([]string, 0, len(names))
+		for _, name := range names {
+			if !defined[strings.ToUpper(name)] {
+				missing = append(missing, strings.ToUpper(name))
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		values, err := provider.Secrets(ctx, missing)
+		if err != nil {
+			return err
+		}
+		for name, value := range values {
+			secrets[name] = value
+		}
+	}
+	return nil
+}
+
+// pick returns the values of the names in an object of secrets, whose keys are case insensitive
+func pick(object map[string]interface{}, names []string) map[string]string {
+	values := make(map[string]string, len(names))
+	for key, value := range object {
+		for _, name := range names {
+			if strings.EqualFold(key, name) {
+				values[name] = fmt.Sprint(value)
+			}
+		}
+	}
+	return values
+}
+
+type execProvider struct {
+	args   []string
+	object bool // the command prints a JSON object with all secrets instead of the value of one secret
+}
+
+func (p *execProvider) run(ctx context.Context, args []string, name string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	//nolint:gosec // the command is given by the user
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "ACT_SECRET_NAME="+name)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("secret provider '%s' failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(stdout.String(), "\n"), "\r"), nil
+}
+
+func (p *execProvider) Secrets(ctx context.Context, names []string) (map[string]string, error) {
+	if p.object {
+		output, err := p.run(ctx, p.args, "")
+		if err != nil {
+			return nil, err
+		}
+		object := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(output), &object); err != nil {
+			return nil, fmt.Errorf("secret provider '%s' didn't print a JSON object: %w", strings.Join(p.args, " "), err)
+		}
+		return pick(object, names), nil
+	}
+
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := p.run(ctx, append(append([]string{}, p.args...), name), name)
+		if err != nil {
+			return nil, err
+		}
+		// an empty output means the helper doesn't know the secret
+		if value != "" {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+type vaultProvider struct {
+	addr  string
+	token string
+	path  string
+}
+
+func (p *vaultProvider) Secrets(ctx context.Context, names []string) (map[string]string, error) {
+	if p.addr == "" || p.token == "" {
+		return nil, fmt.Errorf("VAULT_ADDR and VAULT_TOKEN are required to read secrets from vault")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s", p.addr, p.path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to read %s from vault: %s", p.path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	// the kv secrets engine version 2 nests the secrets, e.g. at secret/data/<path>
+	if data, ok := body.Data["data"].(map[string]interface{}); ok {
+		if _, ok := body.Data["metadata"]; ok {
+			body.Data = data
+		}
+	}
+	return pick(body.Data, names), nil
+}
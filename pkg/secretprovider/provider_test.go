@@ -0,0 +1,81 @@
+package secretprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	for _, spec := range []string{"cmd", "cmd=", "vault", "unknown=value", "cmd='unterminated"} {
+		_, err := NewProvider(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestExecProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the helper is a shell script")
+	}
+	helper := filepath.Join(t.TempDir(), "helper.sh")
+	require.NoError(t, os.WriteFile(helper, []byte(`#!/bin/sh
+if [ "$1" = "TOKEN" ] && [ "$ACT_SECRET_NAME" = "TOKEN" ]; then
+  echo "token-value"
+fi
+`), 0o700))
+
+	provider, err := NewProvider("cmd=" + helper)
+	require.NoError(t, err)
+
+	secrets := map[string]string{"defined": "value"}
+	require.NoError(t, Resolve(context.Background(), []Provider{provider}, []string{"token", "DEFINED", "UNKNOWN"}, secrets))
+	assert.Equal(t, map[string]string{"defined": "value", "TOKEN": "token-value"}, secrets)
+
+	failing, err := NewProvider("cmd=false")
+	require.NoError(t, err)
+	assert.Error(t, Resolve(context.Background(), []Provider{failing}, []string{"TOKEN"}, map[string]string{}))
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/act":
+			fmt.Fprint(w, `{"data":{"data":{"token":"kv2-value"},"metadata":{"version":1}}}`)
+		case "/v1/kv/act":
+			fmt.Fprint(w, `{"data":{"TOKEN":"kv1-value","PORT":8080}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL+"/")
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	for path, expected := range map[string]map[string]string{
+		"secret/data/act": {"TOKEN": "kv2-value"},
+		"/kv/act":         {"TOKEN": "kv1-value", "PORT": "8080"},
+	} {
+		provider, err := NewProvider("vault=" + path)
+		require.NoError(t, err)
+		values, err := provider.Secrets(context.Background(), []string{"TOKEN", "PORT"})
+		require.NoError(t, err)
+		assert.Equal(t, expected, values, path)
+	}
+
+	provider, err := NewProvider("vault=missing")
+	require.NoError(t, err)
+	_, err = provider.Secrets(context.Background(), []string{"TOKEN"})
+	assert.Error(t, err)
+}
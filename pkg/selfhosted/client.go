@@ -0,0 +1,629 @@
+package selfhosted
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // the runner protocol encrypts the session key with RSA-OAEP SHA-1
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Version is the version of the runner act registers as, GitHub refuses runners which are too old
+	Version = "2.319.1"
+
+	apiVersion   = "api-version=6.0-preview"
+	lockToken    = "00000000-0000-0000-0000-000000000000"
+	emptySession = "00000000-0000-0000-0000-000000000000"
+)
+
+// Results of a job
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+	ResultCancelled = "cancelled"
+)
+
+// Options are the options of the registration of the runner
+type Options struct {
+	URL     string   // the URL of the repository, e.g. https://github.com/octo/app
+	Token   string   // the registration token of the repository
+	Name    string   // the name of the runner
+	Labels  []string // the labels of the runner besides self-hosted
+	Version string   // the version of the runner, defaults to Version
+
+	// APIURL is the URL of the API of GitHub, it defaults to https://api.github.com for github.com
+	// and https://<host>/api/v3 for GitHub Enterprise Server
+	APIURL string
+}
+
+// Runner is an ephemeral self-hosted runner of a repository
+type Runner struct {
+	client  *http.Client
+	logger  logrus.FieldLogger
+	options Options
+
+	tenantURL   string
+	tenantToken string
+
+	key              *rsa.PrivateKey
+	poolID           int64
+	agentID          int64
+	clientID         string
+	authorizationURL string
+	accessToken      string
+	accessExpires    time.Time
+
+	sessionID string
+	aesKey    []byte
+}
+
+// Request is a job GitHub assigned to the runner
+type Request struct {
+	Message *JobMessage
+
+	messageID      int64
+	runServiceURL  string // the run service of the job, empty for the jobs of the pool
+	billingOwnerID string
+}
+
+// Register registers the runner with the repository and opens a session for the jobs
+func Register(ctx context.Context, options Options, logger logrus.FieldLogger) (*Runner, error) {
+	if options.Version == "" {
+		options.Version = Version
+	}
+	if logger == nil {
+		l := logrus.New()
+		l.SetOutput(io.Discard)
+		logger = l
+	}
+	r := &Runner{
+		client:  &http.Client{},
+		logger:  logger.WithField("module", "selfhosted"),
+		options: options,
+	}
+	if err := r.register(ctx); err != nil {
+		return nil, fmt.Errorf("failed to register the runner: %w", err)
+	}
+	if err := r.createSession(ctx); err != nil {
+		r.deleteAgent(ctx)
+		return nil, fmt.Errorf("failed to create the session of the runner: %w", err)
+	}
+	return r, nil
+}
+
+// Name returns the name of the runner
+func (r *Runner) Name() string {
+	return r.options.Name
+}
+
+func (r *Runner) apiURL() (string, error) {
+	if r.options.APIURL != "" {
+		return strings.TrimSuffix(r.options.APIURL, "/"), nil
+	}
+	u, err := url.Parse(r.options.URL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("the URL %s of the repository has no host", r.options.URL)
+	}
+	if strings.EqualFold(u.Host, "github.com") {
+		return "https://api.github.com", nil
+	}
+	return fmt.Sprintf("%s://%s/api/v3", u.Scheme, u.Host), nil
+}
+
+func (r *Runner) register(ctx context.Context) error {
+	api, err := r.apiURL()
+	if err != nil {
+		return err
+	}
+	var tenant struct {
+		URL   string `json:"url"`
+		Token string `json:"token"`
+	}
+	err = r.do(ctx, http.MethodPost, api+"/actions/runner-registration", "RemoteAuth "+r.options.Token, map[string]string{
+		"url":          r.options.URL,
+		"runner_event": "register",
+	}, &tenant)
+	if err != nil {
+		return err
+	}
+	r.tenantURL = strings.TrimSuffix(tenant.URL, "/")
+	r.tenantToken = tenant.Token
+
+	var pools struct {
+		Value []struct {
+			ID       int64  `json:"id"`
+			Name     string `json:"name"`
+			IsHosted bool   `json:"isHosted"`
+		} `json:"value"`
+	}
+	if err := r.do(ctx, http.MethodGet, r.tenantURL+"/_apis/distributedtask/pools?poolType=Automation&"+apiVersion, r.tenantBearer(), nil, &pools); err != nil {
+		return err
+	}
+	for _, pool := range pools.Value {
+		if !pool.IsHosted {
+			r.poolID = pool.ID
+			break
+		}
+	}
+	if r.poolID == 0 {
+		return errors.New("the repository has no pool for self-hosted runners")
+	}
+
+	if r.key, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		return err
+	}
+	labels := []map[string]string{}
+	for _, label := range append([]string{"self-hosted", runnerOS(), runnerArch()}, r.options.Labels...) {
+		labels = append(labels, map[string]string{"name": label, "type": "system"})
+	}
+	var agent struct {
+		ID            int64 `json:"id"`
+		Authorization struct {
+			AuthorizationURL string `json:"authorizationUrl"`
+			ClientID         string `json:"clientId"`
+		} `json:"authorization"`
+	}
+	err = r.do(ctx, http.MethodPost, r.poolURL("agents")+"?api-version=6.0-preview.2", r.tenantBearer(), map[string]any{
+		"name":          r.options.Name,
+		"version":       r.options.Version,
+		"osDescription": runnerOS(),
+		"enabled":       true,
+		"ephemeral":     true,
+		"disableUpdate": true,
+		"labels":        labels,
+		"authorization": map[string]any{
+			"publicKey": map[string]string{
+				"exponent": base64.StdEncoding.EncodeToString(big.NewInt(int64(r.key.E)).Bytes()),
+				"modulus":  base64.StdEncoding.EncodeToString(r.key.N.Bytes()),
+			},
+		},
+		"maxParallelism": 1,
+	}, &agent)
+	if err != nil {
+		return err
+	}
+	r.agentID = agent.ID
+	r.clientID = agent.Authorization.ClientID
+	r.authorizationURL = agent.Authorization.AuthorizationURL
+	r.logger.Debugf("registered the runner %s with the id %d", r.options.Name, r.agentID)
+	return nil
+}
+
+func (r *Runner) tenantBearer() string {
+	return "Bearer " + r.tenantToken
+}
+
+func (r *Runner) poolURL(path string) string {
+	return fmt.Sprintf("%s/_apis/distributedtask/pools/%d/%s", r.tenantURL, r.poolID, path)
+}
+
+// bearer returns the access token of the runner, it is renewed before it expires
+func (r *Runner) bearer(ctx context.Context) (string, error) {
+	if r.accessToken != "" && time.Now().Add(time.Minute).Before(r.accessExpires) {
+		return "Bearer " + r.accessToken, nil
+	}
+	now := time.Now()
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	assertion, err := r.sign(map[string]any{
+		"sub": r.clientID,
+		"iss": r.clientID,
+		"aud": r.authorizationURL,
+		"jti": hex.EncodeToString(jti),
+		"nbf": now.Add(-30 * time.Second).Unix(),
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	form.Set("client_assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.authorizationURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := r.send(req, &token); err != nil {
+		return "", fmt.Errorf("failed to get the access token of the runner: %w", err)
+	}
+	r.accessToken = token.AccessToken
+	r.accessExpires = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return "Bearer " + r.accessToken, nil
+}
+
+// sign returns the claims as a JWT signed with RS256 by the key of the runner
+func (r *Runner) sign(claims map[string]any) (string, error) {
+	header, err := json.Marshal(map[string]any{
+		"typ": "JWT",
+		"alg": "RS256",
+	})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, r.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (r *Runner) createSession(ctx context.Context) error {
+	bearer, err := r.bearer(ctx)
+	if err != nil {
+		return err
+	}
+	var session struct {
+		SessionID     string `json:"sessionId"`
+		EncryptionKey struct {
+			Encrypted bool   `json:"encrypted"`
+			Value     string `json:"value"`
+		} `json:"encryptionKey"`
+	}
+	err = r.do(ctx, http.MethodPost, r.poolURL("sessions")+"?"+apiVersion, bearer, map[string]any{
+		"sessionId": emptySession,
+		"ownerName": r.options.Name,
+		"agent": map[string]any{
+			"id":            r.agentID,
+			"name":          r.options.Name,
+			"version":       r.options.Version,
+			"osDescription": runnerOS(),
+		},
+	}, &session)
+	if err != nil {
+		return err
+	}
+	r.sessionID = session.SessionID
+	if session.EncryptionKey.Value == "" {
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(session.EncryptionKey.Value)
+	if err != nil {
+		return err
+	}
+	if session.EncryptionKey.Encrypted {
+		//nolint:gosec // the runner protocol encrypts the session key with RSA-OAEP SHA-1
+		if key, err = rsa.DecryptOAEP(sha1.New(), rand.Reader, r.key, key, nil); err != nil {
+			return fmt.Errorf("failed to decrypt the key of the session: %w", err)
+		}
+	}
+	r.aesKey = key
+	return nil
+}
+
+// GetJob waits for the job GitHub assigns to the runner
+func (r *Runner) GetJob(ctx context.Context) (*Request, error) {
+	for {
+		bearer, err := r.bearer(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var message struct {
+			MessageID   int64  `json:"messageId"`
+			MessageType string `json:"messageType"`
+			IV          string `json:"iv"`
+			Body        string `json:"body"`
+		}
+		query := url.Values{}
+		query.Set("sessionId", r.sessionID)
+		query.Set("status", "Online")
+		query.Set("runnerVersion", r.options.Version)
+		err = r.do(ctx, http.MethodGet, r.poolURL("messages")+"?"+query.Encode()+"&"+apiVersion, bearer, nil, &message)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to get the messages of the runner: %w", err)
+		}
+		if message.MessageID == 0 {
+			// the long poll timed out without a message
+			continue
+		}
+		body, err := r.decrypt(message.IV, message.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.deleteMessage(ctx, message.MessageID); err != nil {
+			r.logger.Warnf("failed to delete the message %d: %v", message.MessageID, err)
+		}
+
+		r.logger.Debugf("message %d of type %s", message.MessageID, message.MessageType)
+		switch message.MessageType {
+		case "PipelineAgentJobRequest":
+			request := &Request{Message: &JobMessage{}, messageID: message.MessageID}
+			if err := json.Unmarshal(body, request.Message); err != nil {
+				return nil, fmt.Errorf("failed to read the job: %w", err)
+			}
+			return request, nil
+		case "RunnerJobRequest":
+			var job struct {
+				RunnerRequestID string `json:"runner_request_id"`
+				RunServiceURL   string `json:"run_service_url"`
+				BillingOwnerID  string `json:"billing_owner_id"`
+			}
+			if err := json.Unmarshal(body, &job); err != nil {
+				return nil, fmt.Errorf("failed to read the job: %w", err)
+			}
+			request := &Request{
+				Message:        &JobMessage{},
+				messageID:      message.MessageID,
+				runServiceURL:  strings.TrimSuffix(job.RunServiceURL, "/"),
+				billingOwnerID: job.BillingOwnerID,
+			}
+			err := r.do(ctx, http.MethodPost, request.runServiceURL+"/acquirejob", bearer, map[string]string{
+				"jobMessageId":   job.RunnerRequestID,
+				"runnerOS":       runnerOS(),
+				"billingOwnerId": job.BillingOwnerID,
+			}, request.Message)
+			if err != nil {
+				return nil, fmt.Errorf("failed to acquire the job: %w", err)
+			}
+			return request, nil
+		case "JobCancellation":
+			r.logger.Debugf("the job of the message %d was cancelled", message.MessageID)
+		default:
+			r.logger.Debugf("ignoring the message %d of type %s", message.MessageID, message.MessageType)
+		}
+	}
+}
+
+func runnerOS() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS"
+	case "windows":
+		return "Windows"
+	}
+	return "Linux"
+}
+
+// runnerArch returns the architecture of the runner as in the runs-on labels and runner.arch
+func runnerArch() string {
+	return actionArch(runtime.GOARCH)
+}
+
+func actionArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "X64"
+	case "386":
+		return "X86"
+	case "arm64":
+		return "ARM64"
+	case "arm":
+		return "ARM"
+	}
+	return arch
+}
+
+// decrypt decrypts the body of a message with the key of the session, the bodies without an iv
+// aren't encrypted
+func (r *Runner) decrypt(iv, body string) ([]byte, error) {
+	if iv == "" {
+		return []byte(body), nil
+	}
+	vector, err := base64.StdEncoding.DecodeString(iv)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(r.aesKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 || len(vector) != block.BlockSize() {
+		return nil, errors.New("the message isn't encrypted with the key of the session")
+	}
+	cipher.NewCBCDecrypter(block, vector).CryptBlocks(data, data)
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, errors.New("the message isn't encrypted with the key of the session")
+	}
+	return bytes.TrimPrefix(data[:len(data)-padding], []byte("\xef\xbb\xbf")), nil
+}
+
+func (r *Runner) deleteMessage(ctx context.Context, id int64) error {
+	bearer, err := r.bearer(ctx)
+	if err != nil {
+		return err
+	}
+	return r.do(ctx, http.MethodDelete, fmt.Sprintf("%s?sessionId=%s&%s", r.poolURL("messages/"+strconv.FormatInt(id, 10)), url.QueryEscape(r.sessionID), apiVersion), bearer, nil, nil)
+}
+
+// Renew renews the lock of the runner on the job, GitHub gives the job to another runner if the
+// lock expires
+func (r *Runner) Renew(ctx context.Context, request *Request) error {
+	bearer, err := r.bearer(ctx)
+	if err != nil {
+		return err
+	}
+	if request.runServiceURL != "" {
+		return r.do(ctx, http.MethodPost, request.runServiceURL+"/renewjob", bearer, map[string]string{
+			"planId": request.Message.Plan.PlanID,
+			"jobId":  request.Message.JobID,
+		}, nil)
+	}
+	return r.do(ctx, http.MethodPatch, r.jobRequestURL(request), bearer, map[string]any{
+		"requestId": request.Message.RequestID,
+	}, nil)
+}
+
+// KeepRenewing renews the lock on the job every minute until the context is done
+func (r *Runner) KeepRenewing(ctx context.Context, request *Request) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Renew(ctx, request); err != nil && ctx.Err() == nil {
+				r.logger.Warnf("failed to renew the job: %v", err)
+			}
+		}
+	}
+}
+
+func (r *Runner) jobRequestURL(request *Request) string {
+	return fmt.Sprintf("%s?lockToken=%s&%s", r.poolURL("jobrequests/"+strconv.FormatInt(request.Message.RequestID, 10)), lockToken, apiVersion)
+}
+
+// Complete reports the result and the outputs of the job
+func (r *Runner) Complete(ctx context.Context, request *Request, result string, outputs map[string]string) error {
+	bearer, err := r.bearer(ctx)
+	if err != nil {
+		return err
+	}
+	message := request.Message
+	values := map[string]map[string]string{}
+	for key, value := range outputs {
+		values[key] = map[string]string{"value": value}
+	}
+
+	if request.runServiceURL != "" {
+		conclusion := map[string]string{
+			ResultSucceeded: "success",
+			ResultFailed:    "failure",
+			ResultCancelled: "cancelled",
+		}[result]
+		return r.do(ctx, http.MethodPost, request.runServiceURL+"/completejob", bearer, map[string]any{
+			"planId":         message.Plan.PlanID,
+			"jobId":          message.JobID,
+			"conclusion":     conclusion,
+			"outputs":        values,
+			"billingOwnerId": request.billingOwnerID,
+		}, nil)
+	}
+
+	// the plan of the job is completed with the connection of the job
+	if endpoint := message.systemConnection(); endpoint != nil {
+		err := r.do(ctx, http.MethodPost, fmt.Sprintf("%s/_apis/distributedtask/hubs/%s/plans/%s/events?api-version=2.0-preview.1",
+			strings.TrimSuffix(endpoint.URL, "/"), message.Plan.PlanType, message.Plan.PlanID), "Bearer "+endpoint.Authorization.Parameters["AccessToken"], map[string]any{
+			"name":      "JobCompleted",
+			"jobId":     message.JobID,
+			"requestId": message.RequestID,
+			"result":    result,
+			"outputs":   values,
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to complete the plan of the job: %w", err)
+		}
+	}
+	return r.do(ctx, http.MethodPatch, r.jobRequestURL(request), bearer, map[string]any{
+		"requestId":  message.RequestID,
+		"finishTime": time.Now().UTC().Format(time.RFC3339),
+		"result":     result,
+	}, nil)
+}
+
+// Close deletes the session and the registration of the runner
+func (r *Runner) Close(ctx context.Context) error {
+	var err error
+	if r.sessionID != "" {
+		bearer, berr := r.bearer(ctx)
+		if berr == nil {
+			err = r.do(ctx, http.MethodDelete, r.poolURL("sessions/"+r.sessionID)+"?"+apiVersion, bearer, nil, nil)
+		} else {
+			err = berr
+		}
+		r.sessionID = ""
+	}
+	r.deleteAgent(ctx)
+	return err
+}
+
+func (r *Runner) deleteAgent(ctx context.Context) {
+	if r.agentID == 0 {
+		return
+	}
+	// GitHub deletes ephemeral runners after their job, the runner is gone if it had one
+	err := r.do(ctx, http.MethodDelete, r.poolURL("agents/"+strconv.FormatInt(r.agentID, 10))+"?"+apiVersion, r.tenantBearer(), nil, nil)
+	if err != nil {
+		r.logger.Debugf("failed to delete the runner %d: %v", r.agentID, err)
+	}
+	r.agentID = 0
+}
+
+// do sends the body as JSON and reads the response into v, a response without content leaves v
+// untouched
+func (r *Runner) do(ctx context.Context, method, u, authorization string, body any, v any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", authorization)
+	return r.send(req, v)
+}
+
+func (r *Runner) send(req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "act-runner/"+r.options.Version)
+	r.logger.Debugf("%s %s", req.Method, req.URL.Redacted())
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s %s", req.Method, req.URL.Path, res.Status, strings.TrimSpace(string(data)))
+	}
+	if v == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), v)
+}
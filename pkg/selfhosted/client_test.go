@@ -0,0 +1,202 @@
+package selfhosted
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // the runner protocol encrypts the session key with RSA-OAEP SHA-1
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitHub is the part of GitHub an ephemeral runner talks to
+type fakeGitHub struct {
+	t      *testing.T
+	server *httptest.Server
+	job    []byte
+
+	mu        sync.Mutex
+	publicKey *rsa.PublicKey
+	aesKey    []byte
+	sent      bool
+	agent     map[string]any
+	requests  []string
+	completed map[string]any
+	finished  map[string]any
+}
+
+func newFakeGitHub(t *testing.T, job []byte) *fakeGitHub {
+	f := &fakeGitHub{t: t, job: job, aesKey: make([]byte, 32)}
+	_, err := rand.Read(f.aesKey)
+	require.NoError(t, err)
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	var body map[string]any
+	if r.Header.Get("Content-Type") == "application/json" {
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&body))
+	}
+	respond := func(v any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	pool := "/tenant/_apis/distributedtask/pools/7/"
+
+	switch {
+	case r.URL.Path == "/api/v3/actions/runner-registration":
+		assert.Equal(f.t, "RemoteAuth registration-token", r.Header.Get("Authorization"))
+		assert.Equal(f.t, "register", body["runner_event"])
+		respond(map[string]string{"url": f.server.URL + "/tenant", "token": "tenant-token"})
+	case r.URL.Path == "/tenant/_apis/distributedtask/pools":
+		assert.Equal(f.t, "Bearer tenant-token", r.Header.Get("Authorization"))
+		respond(map[string]any{"value": []map[string]any{{"id": 1, "isHosted": true}, {"id": 7, "isHosted": false}}})
+	case r.URL.Path == pool+"agents" && r.Method == http.MethodPost:
+		assert.Equal(f.t, true, body["ephemeral"])
+		f.agent = body
+		key := body["authorization"].(map[string]any)["publicKey"].(map[string]any)
+		modulus, _ := base64.StdEncoding.DecodeString(key["modulus"].(string))
+		exponent, _ := base64.StdEncoding.DecodeString(key["exponent"].(string))
+		f.publicKey = &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(new(big.Int).SetBytes(exponent).Int64())}
+		respond(map[string]any{"id": 3, "authorization": map[string]string{
+			"authorizationUrl": f.server.URL + "/oauth",
+			"clientId":         "client",
+		}})
+	case r.URL.Path == "/oauth":
+		require.NoError(f.t, r.ParseForm())
+		assert.Equal(f.t, "client_credentials", r.Form.Get("grant_type"))
+		assert.Len(f.t, strings.Split(r.Form.Get("client_assertion"), "."), 3)
+		respond(map[string]any{"access_token": "access-token", "expires_in": 3600})
+	case r.URL.Path == pool+"sessions":
+		assert.Equal(f.t, "Bearer access-token", r.Header.Get("Authorization"))
+		encrypted, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, f.publicKey, f.aesKey, nil) //nolint:gosec
+		require.NoError(f.t, err)
+		respond(map[string]any{"sessionId": "session", "encryptionKey": map[string]any{
+			"encrypted": true,
+			"value":     base64.StdEncoding.EncodeToString(encrypted),
+		}})
+	case r.URL.Path == pool+"messages" && r.Method == http.MethodGet:
+		assert.Equal(f.t, "session", r.URL.Query().Get("sessionId"))
+		if f.sent {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		f.sent = true
+		iv, body := f.encrypt(append([]byte("\xef\xbb\xbf"), f.job...))
+		respond(map[string]any{"messageId": 5, "messageType": "PipelineAgentJobRequest", "iv": iv, "body": body})
+	case r.URL.Path == "/plan/_apis/distributedtask/hubs/actions/plans/plan-1/events":
+		assert.Equal(f.t, "Bearer plan-token", r.Header.Get("Authorization"))
+		f.completed = body
+	case r.URL.Path == pool+"jobrequests/42" && r.Method == http.MethodPatch:
+		if _, ok := body["result"]; ok {
+			f.finished = body
+		}
+	}
+}
+
+func (f *fakeGitHub) encrypt(data []byte) (string, string) {
+	block, err := aes.NewCipher(f.aesKey)
+	require.NoError(f.t, err)
+	padding := block.BlockSize() - len(data)%block.BlockSize()
+	data = append(data, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	iv := make([]byte, block.BlockSize())
+	_, err = rand.Read(iv)
+	require.NoError(f.t, err)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return base64.StdEncoding.EncodeToString(iv), base64.StdEncoding.EncodeToString(data)
+}
+
+func TestRunner(t *testing.T) {
+	job, err := os.ReadFile("testdata/job.json")
+	require.NoError(t, err)
+	var message map[string]any
+	require.NoError(t, json.Unmarshal(job, &message))
+	f := newFakeGitHub(t, nil)
+	message["resources"] = map[string]any{"endpoints": []map[string]any{{
+		"name":          "SystemVssConnection",
+		"url":           f.server.URL + "/plan/",
+		"authorization": map[string]any{"scheme": "OAuth", "parameters": map[string]string{"AccessToken": "plan-token"}},
+	}}}
+	f.job, err = json.Marshal(message)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	r, err := Register(ctx, Options{
+		URL:    f.server.URL + "/octo/app",
+		Token:  "registration-token",
+		Name:   "act",
+		Labels: []string{"gpu"},
+	}, nil)
+	require.NoError(t, err)
+
+	request, err := r.GetJob(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "test (18)", request.Message.JobDisplayName)
+	assert.Equal(t, int64(42), request.Message.RequestID)
+	assert.Equal(t, runnerOS(), f.agent["osDescription"])
+	assert.Equal(t, []any{
+		map[string]any{"name": "self-hosted", "type": "system"},
+		map[string]any{"name": runnerOS(), "type": "system"},
+		map[string]any{"name": runnerArch(), "type": "system"},
+		map[string]any{"name": "gpu", "type": "system"},
+	}, f.agent["labels"])
+
+	require.NoError(t, r.Renew(ctx, request))
+	require.NoError(t, r.Complete(ctx, request, ResultSucceeded, map[string]string{"result": "ok"}))
+	require.NoError(t, r.Close(ctx))
+
+	assert.Equal(t, "JobCompleted", f.completed["name"])
+	assert.Equal(t, "succeeded", f.completed["result"])
+	assert.Equal(t, map[string]any{"result": map[string]any{"value": "ok"}}, f.completed["outputs"])
+	assert.Equal(t, "succeeded", f.finished["result"])
+	assert.Contains(t, f.requests, "DELETE /tenant/_apis/distributedtask/pools/7/messages/5")
+	assert.Contains(t, f.requests, "DELETE /tenant/_apis/distributedtask/pools/7/sessions/session")
+	assert.Contains(t, f.requests, "DELETE /tenant/_apis/distributedtask/pools/7/agents/3")
+}
+
+func TestRegisterFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "Bad credentials", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := Register(context.Background(), Options{URL: server.URL + "/octo/app", Token: "expired"}, nil)
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestRunnerPlatform(t *testing.T) {
+	assert.Contains(t, []string{"Linux", "macOS", "Windows"}, runnerOS())
+	assert.Equal(t, "X64", actionArch("amd64"))
+	assert.Equal(t, "ARM64", actionArch("arm64"))
+	assert.Equal(t, "riscv64", actionArch("riscv64"))
+}
+
+func TestAPIURL(t *testing.T) {
+	for url, api := range map[string]string{
+		"https://github.com/octo/app":      "https://api.github.com",
+		"https://ghe.example.com/octo/app": "https://ghe.example.com/api/v3",
+	} {
+		r := &Runner{options: Options{URL: url}}
+		got, err := r.apiURL()
+		require.NoError(t, err)
+		assert.Equal(t, api, got)
+	}
+}
@@ -0,0 +1,10 @@
+// Package selfhosted registers act with GitHub as an ephemeral self-hosted runner.
+//
+// The runner is registered with a registration token of a repository or an organization like
+// the config.sh of the official runner, see https://github.com/actions/runner, and takes one job
+// of the queue. The job message, which has the steps of the job with their expressions still
+// to evaluate, is converted into a workflow with the job, so act runs it with its container
+// engine, and the result and the outputs of the job are reported back to GitHub.
+//
+// The logs of the job are printed locally only, GitHub shows the result of the job but no logs.
+package selfhosted
@@ -0,0 +1,270 @@
+package selfhosted
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultPlatform is the label the job runs on if act has no image for the labels of the runner
+const defaultPlatform = "ubuntu-latest"
+
+var jobIDInvalid = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Job is a job of the queue of GitHub converted for act
+type Job struct {
+	Message *JobMessage
+
+	ID           string            // the id of the job in the workflow
+	Workflow     []byte            // the workflow with the job and the jobs it needs
+	EventName    string            // the event which triggered the workflow
+	Event        []byte            // the payload of the event
+	Repository   string            // the repository of the workflow, e.g. octo/app
+	Sha          string            // the commit the workflow runs on
+	Ref          string            // the ref the workflow runs on
+	ServerURL    string            // the URL of GitHub, e.g. https://github.com
+	Secrets      map[string]string // the secrets the job refers to, with GITHUB_TOKEN
+	Vars         map[string]string // the configuration variables
+	NeedsOutputs []string          // the outputs of the jobs the job needs, e.g. build.version=1.2.3
+}
+
+// NewJob converts a job message into a workflow with the job. The job runs on the labels of the
+// runner, with the image of act for ubuntu-latest if act has none for them. The jobs it needs are
+// stubs whose outputs are the ones of the job message.
+func NewJob(message *JobMessage, labels []string) (*Job, error) {
+	github, _ := message.ContextData["github"].Value().(map[string]interface{})
+	if github == nil {
+		return nil, fmt.Errorf("the job message has no github context")
+	}
+	job := &Job{
+		Message:    message,
+		EventName:  stringValue(github, "event_name"),
+		Repository: stringValue(github, "repository"),
+		Sha:        stringValue(github, "sha"),
+		Ref:        stringValue(github, "ref"),
+		ServerURL:  stringValue(github, "server_url"),
+		Secrets:    map[string]string{},
+		Vars:       map[string]string{},
+	}
+	job.ID = strings.Trim(jobIDInvalid.ReplaceAllString(stringValue(github, "job"), "-"), "-")
+	if job.ID == "" {
+		job.ID = "job"
+	}
+
+	event, _ := github["event"].(map[string]interface{})
+	if event == nil {
+		event = map[string]interface{}{}
+	}
+	var err error
+	if job.Event, err = json.Marshal(event); err != nil {
+		return nil, err
+	}
+
+	for name, variable := range message.Variables {
+		switch {
+		case name == "system.github.token":
+			job.Secrets["GITHUB_TOKEN"] = variable.Value
+		case variable.IsSecret && !strings.Contains(name, "."):
+			job.Secrets[name] = variable.Value
+		}
+	}
+	if vars, ok := message.ContextData["vars"].Value().(map[string]interface{}); ok {
+		for name, value := range vars {
+			job.Vars[name] = fmt.Sprint(value)
+		}
+	}
+
+	workflow, err := job.workflow(github, labels)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(workflow); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	job.Workflow = buf.Bytes()
+	return job, nil
+}
+
+func stringValue(context map[string]interface{}, key string) string {
+	if s, ok := context[key].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// workflow returns the workflow with the job of the message
+func (j *Job) workflow(github map[string]interface{}, labels []string) (*yaml.Node, error) {
+	message := j.Message
+	workflow := mapping()
+	name := stringValue(github, "workflow")
+	if name == "" {
+		name = message.JobDisplayName
+	}
+	setString(workflow, "name", name)
+	setNode(workflow, "on", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: j.EventName})
+
+	jobs := mapping()
+	job := mapping()
+	setString(job, "name", message.JobDisplayName)
+
+	// the jobs the job needs are stubs, act assumes their outputs with --needs-output
+	if needs, ok := message.ContextData["needs"].Value().(map[string]interface{}); ok && len(needs) > 0 {
+		ids := make([]string, 0, len(needs))
+		for id := range needs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		needsNode := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, id := range ids {
+			needsNode.Content = append(needsNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: id})
+			stub := mapping()
+			setString(stub, "runs-on", defaultPlatform)
+			step := mapping()
+			setString(step, "run", "true")
+			setNode(stub, "steps", &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{step}})
+			setNode(jobs, id, stub)
+
+			need, _ := needs[id].(map[string]interface{})
+			outputs, _ := need["outputs"].(map[string]interface{})
+			keys := make([]string, 0, len(outputs))
+			for key := range outputs {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				j.NeedsOutputs = append(j.NeedsOutputs, fmt.Sprintf("%s.%s=%v", id, key, outputs[key]))
+			}
+		}
+		setNode(job, "needs", needsNode)
+	}
+
+	runsOn := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, label := range append(append([]string{}, labels...), defaultPlatform) {
+		runsOn.Content = append(runsOn.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: label})
+	}
+	setNode(job, "runs-on", runsOn)
+
+	// the job is the combination of the matrix the message is for
+	if matrix, ok := message.ContextData["matrix"].Value().(map[string]interface{}); ok && len(matrix) > 0 {
+		include := &yaml.Node{}
+		if err := include.Encode([]interface{}{matrix}); err != nil {
+			return nil, err
+		}
+		matrixNode := mapping()
+		setNode(matrixNode, "include", include)
+		strategy := mapping()
+		setNode(strategy, "matrix", matrixNode)
+		setNode(job, "strategy", strategy)
+	}
+
+	setNode(job, "container", message.JobContainer.Node())
+	setNode(job, "services", message.JobServiceContainers.Node())
+	setNode(job, "defaults", message.Defaults.Node())
+	env := mapping()
+	for _, token := range message.EnvironmentVariables {
+		if node := token.Node(); node != nil && node.Kind == yaml.MappingNode {
+			env.Content = append(env.Content, node.Content...)
+		}
+	}
+	setNode(job, "env", env)
+	setNode(job, "outputs", message.JobOutputs.Node())
+
+	steps := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, step := range message.Steps {
+		node, err := actionStep(step)
+		if err != nil {
+			return nil, err
+		}
+		steps.Content = append(steps.Content, node)
+	}
+	setNode(job, "steps", steps)
+
+	setNode(jobs, j.ID, job)
+	setNode(workflow, "jobs", jobs)
+	return workflow, nil
+}
+
+// actionStep converts a step of a job message into a step of a workflow
+func actionStep(step *ActionStep) (*yaml.Node, error) {
+	node := mapping()
+	// the runner names the steps without an id __run, __actions_checkout etc.
+	if !strings.HasPrefix(step.ContextName, "__") {
+		setString(node, "id", step.ContextName)
+	}
+	if name := step.DisplayNameToken.Node(); name != nil {
+		setNode(node, "name", name)
+	} else {
+		setString(node, "name", step.DisplayName)
+	}
+	if step.Condition != "" && step.Condition != "success()" {
+		setString(node, "if", step.Condition)
+	}
+	setNode(node, "continue-on-error", step.ContinueOnError.Node())
+	setNode(node, "timeout-minutes", step.TimeoutInMinutes.Node())
+	setNode(node, "env", step.Environment.Node())
+
+	inputs := step.Inputs.Node()
+	reference := step.Reference
+	switch reference.Type {
+	case "script":
+		// the inputs of scripts are script, shell and workingDirectory
+		for i := 0; inputs != nil && i+1 < len(inputs.Content); i += 2 {
+			switch key, value := inputs.Content[i].Value, inputs.Content[i+1]; key {
+			case "script":
+				setNode(node, "run", value)
+			case "shell":
+				setNode(node, "shell", value)
+			case "workingDirectory":
+				setNode(node, "working-directory", value)
+			}
+		}
+		return node, nil
+	case "repository":
+		uses := reference.Name
+		if reference.RepositoryType == "self" {
+			uses = "./" + strings.TrimPrefix(reference.Path, "./")
+		} else {
+			if reference.Path != "" {
+				uses += "/" + reference.Path
+			}
+			uses += "@" + reference.Ref
+		}
+		setString(node, "uses", uses)
+	case "containerRegistry":
+		setString(node, "uses", "docker://"+reference.Image)
+	default:
+		return nil, fmt.Errorf("the step %s has the unknown reference type '%s'", step.DisplayName, reference.Type)
+	}
+	setNode(node, "with", inputs)
+	return node, nil
+}
+
+func mapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode}
+}
+
+// setNode appends the key to the mapping, nil and empty values are left out
+func setNode(m *yaml.Node, key string, value *yaml.Node) {
+	if value == nil || (value.Kind != yaml.ScalarNode && len(value.Content) == 0) || value.Tag == "!!null" {
+		return
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func setString(m *yaml.Node, key string, value string) {
+	if value == "" {
+		return
+	}
+	setNode(m, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
@@ -0,0 +1,87 @@
+package selfhosted
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func readJobMessage(t *testing.T) *JobMessage {
+	data, err := os.ReadFile("testdata/job.json")
+	require.NoError(t, err)
+	message := &JobMessage{}
+	require.NoError(t, json.Unmarshal(data, message))
+	return message
+}
+
+func TestNewJob(t *testing.T) {
+	job, err := NewJob(readJobMessage(t), []string{"gpu"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", job.ID)
+	assert.Equal(t, "push", job.EventName)
+	assert.Equal(t, "octo/app", job.Repository)
+	assert.Equal(t, "abc123", job.Sha)
+	assert.Equal(t, "https://github.com", job.ServerURL)
+	assert.JSONEq(t, `{"ref": "refs/heads/main", "forced": false}`, string(job.Event))
+	assert.Equal(t, map[string]string{"GITHUB_TOKEN": "ghs_token", "DEPLOY_KEY": "s3cr3t"}, job.Secrets)
+	assert.Equal(t, map[string]string{"REGION": "eu"}, job.Vars)
+	assert.Equal(t, []string{"build.version=1.2.3"}, job.NeedsOutputs)
+
+	workflow, err := model.ReadWorkflow(bytes.NewReader(job.Workflow))
+	require.NoError(t, err, string(job.Workflow))
+	assert.Equal(t, "CI", workflow.Name)
+	assert.Equal(t, []string{"push"}, workflow.On())
+	require.Contains(t, workflow.Jobs, "build")
+	test := workflow.Jobs["test"]
+	require.NotNil(t, test)
+	assert.Equal(t, []string{"build"}, test.Needs())
+	assert.Equal(t, []string{"gpu", "ubuntu-latest"}, test.RunsOn())
+	assert.Equal(t, "node:18", test.Container().Image)
+	assert.Equal(t, map[string]string{"CI": "true", "VERSION": "${{ needs.build.outputs.version }}"}, test.Environment())
+	assert.Equal(t, map[string]string{"result": "${{ steps.test.outputs.result }}"}, test.Outputs)
+	matrixes, err := test.GetMatrixes()
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"node": 18}}, matrixes)
+
+	require.Len(t, test.Steps, 4)
+	assert.Equal(t, "", test.Steps[0].ID)
+	assert.Equal(t, "actions/checkout@v4", test.Steps[0].Uses)
+	assert.Equal(t, "", test.Steps[0].If.Value)
+	assert.Equal(t, map[string]string{"fetch-depth": "0"}, test.Steps[0].With)
+	assert.Equal(t, "./.github/actions/setup", test.Steps[1].Uses)
+	assert.Equal(t, "test", test.Steps[2].ID)
+	assert.Equal(t, "npm test", test.Steps[2].Run)
+	assert.Equal(t, "bash", test.Steps[2].Shell)
+	assert.Equal(t, "always()", test.Steps[2].If.Value)
+	assert.Equal(t, "10", test.Steps[2].TimeoutMinutes)
+	assert.Equal(t, map[string]string{"NODE": "${{ matrix.node }}"}, test.Steps[2].Environment())
+	assert.Equal(t, "docker://alpine:3", test.Steps[3].Uses)
+	assert.Equal(t, "${{ format('Run {0}', 'alpine') }}", test.Steps[3].Name)
+}
+
+func TestNewJobWithoutGithubContext(t *testing.T) {
+	_, err := NewJob(&JobMessage{}, nil)
+	assert.Error(t, err)
+}
+
+func TestTemplateTokenInsertExpression(t *testing.T) {
+	token := &TemplateToken{}
+	err := json.Unmarshal([]byte(`{"type": 2, "map": [{"Key": {"type": 4, "expr": "insert"}, "Value": {"type": 2, "map": []}}]}`), token)
+	assert.Error(t, err)
+}
+
+func TestTemplateTokenNode(t *testing.T) {
+	token := &TemplateToken{}
+	require.NoError(t, json.Unmarshal([]byte(`{"type": 1, "seq": ["a", {"type": 5, "bool": true}, {"type": 7}]}`), token))
+	var values []interface{}
+	require.NoError(t, token.Node().Decode(&values))
+	assert.Equal(t, []interface{}{"a", true, nil}, values)
+	assert.Nil(t, (*TemplateToken)(nil).Node())
+}
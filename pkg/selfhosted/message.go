@@ -0,0 +1,294 @@
+package selfhosted
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JobMessage is the job request of GitHub, the AgentJobRequestMessage of the runner
+type JobMessage struct {
+	Plan struct {
+		ScopeIdentifier string `json:"scopeIdentifier"`
+		PlanType        string `json:"planType"`
+		PlanID          string `json:"planId"`
+	} `json:"plan"`
+	Timeline struct {
+		ID string `json:"id"`
+	} `json:"timeline"`
+	JobID                string                   `json:"jobId"`
+	JobDisplayName       string                   `json:"jobDisplayName"`
+	JobName              string                   `json:"jobName"`
+	RequestID            int64                    `json:"requestId"`
+	JobContainer         *TemplateToken           `json:"jobContainer"`
+	JobServiceContainers *TemplateToken           `json:"jobServiceContainers"`
+	JobOutputs           *TemplateToken           `json:"jobOutputs"`
+	Defaults             *TemplateToken           `json:"defaults"`
+	EnvironmentVariables []*TemplateToken         `json:"environmentVariables"`
+	ContextData          map[string]*ContextData  `json:"contextData"`
+	Variables            map[string]VariableValue `json:"variables"`
+	Steps                []*ActionStep            `json:"steps"`
+	Resources            *JobResources            `json:"resources"`
+}
+
+// VariableValue is a variable of a job message, the secrets are variables too
+type VariableValue struct {
+	Value    string `json:"value"`
+	IsSecret bool   `json:"isSecret"`
+}
+
+// JobResources are the endpoints of a job message, the connection to the service of the plan
+// of the job is SystemVssConnection
+type JobResources struct {
+	Endpoints []jobEndpoint `json:"endpoints"`
+}
+
+type jobEndpoint struct {
+	Name          string `json:"name"`
+	URL           string `json:"url"`
+	Authorization struct {
+		Scheme     string            `json:"scheme"`
+		Parameters map[string]string `json:"parameters"`
+	} `json:"authorization"`
+}
+
+// ActionStep is a step of a job message
+type ActionStep struct {
+	Type      string `json:"type"`
+	Reference struct {
+		Type           string `json:"type"` // repository, script or containerRegistry
+		Name           string `json:"name"`
+		Ref            string `json:"ref"`
+		RepositoryType string `json:"repositoryType"` // self for the actions of the repository of the workflow
+		Path           string `json:"path"`
+		Image          string `json:"image"`
+	} `json:"reference"`
+	ContextName      string         `json:"contextName"`
+	DisplayName      string         `json:"displayName"`
+	DisplayNameToken *TemplateToken `json:"displayNameToken"`
+	Condition        string         `json:"condition"`
+	ContinueOnError  *TemplateToken `json:"continueOnError"`
+	TimeoutInMinutes *TemplateToken `json:"timeoutInMinutes"`
+	Inputs           *TemplateToken `json:"inputs"`
+	Environment      *TemplateToken `json:"environment"`
+}
+
+// template token types of the runner
+const (
+	tokenString = iota
+	tokenSequence
+	tokenMapping
+	tokenBasicExpression
+	tokenInsertExpression
+	tokenBoolean
+	tokenNumber
+	tokenNull
+)
+
+// TemplateToken is a value of the workflow in a job message, with the expressions which are
+// evaluated by the runner
+type TemplateToken struct {
+	node *yaml.Node
+}
+
+// UnmarshalJSON reads the serialization of the template tokens of the runner, literal strings are
+// JSON strings, the other tokens objects with their type
+func (t *TemplateToken) UnmarshalJSON(data []byte) error {
+	node, err := templateNode(data)
+	if err != nil {
+		return err
+	}
+	t.node = node
+	return nil
+}
+
+// Node returns the token as yaml, the expressions are ${{ }} strings like in a workflow
+func (t *TemplateToken) Node() *yaml.Node {
+	if t == nil {
+		return nil
+	}
+	return t.node
+}
+
+func templateNode(data []byte) (*yaml.Node, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	switch raw := raw.(type) {
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: raw}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(raw)}, nil
+	case float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(raw, 'f', -1, 64)}, nil
+	}
+
+	var token struct {
+		Type int                    `json:"type"`
+		Lit  string                 `json:"lit"`
+		Expr string                 `json:"expr"`
+		Bool bool                   `json:"bool"`
+		Num  float64                `json:"num"`
+		Seq  []json.RawMessage      `json:"seq"`
+		Map  []templateMappingEntry `json:"map"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	switch token.Type {
+	case tokenString:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: token.Lit}, nil
+	case tokenBasicExpression:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprintf("${{ %s }}", token.Expr)}, nil
+	case tokenInsertExpression:
+		return nil, fmt.Errorf("the insert expression ${{ %s }} isn't supported", token.Expr)
+	case tokenBoolean:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(token.Bool)}, nil
+	case tokenNumber:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(token.Num, 'f', -1, 64)}, nil
+	case tokenNull:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	case tokenSequence:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range token.Seq {
+			child, err := templateNode(item)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case tokenMapping:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, entry := range token.Map {
+			key, err := templateNode(entry.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := templateNode(entry.Value)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, key, value)
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("unknown template token type %d", token.Type)
+}
+
+type templateMappingEntry struct {
+	Key   json.RawMessage `json:"Key"`
+	Value json.RawMessage `json:"Value"`
+}
+
+// context data types of the runner
+const (
+	contextString = iota
+	contextArray
+	contextDictionary
+	contextBoolean
+	contextNumber
+	contextCaseSensitiveDictionary
+)
+
+// ContextData is a value of the contexts of a job message, e.g. the github context with the
+// payload of the event
+type ContextData struct {
+	value interface{}
+}
+
+// UnmarshalJSON reads the serialization of the context data of the runner, strings are JSON
+// strings, the other values objects with their type
+func (c *ContextData) UnmarshalJSON(data []byte) error {
+	value, err := contextValue(data)
+	if err != nil {
+		return err
+	}
+	c.value = value
+	return nil
+}
+
+// Value returns the data as the values of encoding/json, e.g. map[string]interface{} for a dictionary
+func (c *ContextData) Value() interface{} {
+	if c == nil {
+		return nil
+	}
+	return c.value
+}
+
+func contextValue(data []byte) (interface{}, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if _, ok := raw.(map[string]interface{}); !ok {
+		return raw, nil
+	}
+
+	var value struct {
+		T int               `json:"t"`
+		S string            `json:"s"`
+		B bool              `json:"b"`
+		N float64           `json:"n"`
+		A []json.RawMessage `json:"a"`
+		D []struct {
+			K string          `json:"k"`
+			V json.RawMessage `json:"v"`
+		} `json:"d"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	switch value.T {
+	case contextString:
+		return value.S, nil
+	case contextBoolean:
+		return value.B, nil
+	case contextNumber:
+		return value.N, nil
+	case contextArray:
+		array := make([]interface{}, 0, len(value.A))
+		for _, item := range value.A {
+			v, err := contextValue(item)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, v)
+		}
+		return array, nil
+	case contextDictionary, contextCaseSensitiveDictionary:
+		dictionary := make(map[string]interface{}, len(value.D))
+		for _, entry := range value.D {
+			if len(entry.V) == 0 {
+				// the values which are null are left out
+				dictionary[entry.K] = nil
+				continue
+			}
+			v, err := contextValue(entry.V)
+			if err != nil {
+				return nil, err
+			}
+			dictionary[entry.K] = v
+		}
+		return dictionary, nil
+	}
+	return nil, fmt.Errorf("unknown context data type %d", value.T)
+}
+
+// systemConnection returns the endpoint of the service of the plan of the job
+func (m *JobMessage) systemConnection() *jobEndpoint {
+	if m.Resources == nil {
+		return nil
+	}
+	for i, endpoint := range m.Resources.Endpoints {
+		if endpoint.Name == "SystemVssConnection" {
+			return &m.Resources.Endpoints[i]
+		}
+	}
+	return nil
+}
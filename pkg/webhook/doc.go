@@ -0,0 +1,7 @@
+// Package webhook receives the webhook deliveries of GitHub for act listen.
+//
+// The deliveries are validated with the secret of the webhook, see
+// https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries, and passed on
+// with the event name of the X-GitHub-Event header and the payload, which is the event payload
+// of the workflows the event triggers.
+package webhook
@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Path is the path the deliveries are posted to
+	Path = "/"
+
+	// maxPayloadSize is the limit of GitHub for the payloads of the deliveries
+	maxPayloadSize = 25 << 20
+
+	signaturePrefix = "sha256="
+)
+
+// ErrNotRun is returned by the deliver function of a handler if the delivery doesn't run
+// a workflow, e.g. because no workflow runs on the event
+var ErrNotRun = errors.New("no workflow runs on the event")
+
+// Delivery is a webhook delivery of GitHub
+type Delivery struct {
+	ID      string // the GUID of X-GitHub-Delivery
+	Event   string // the event name of X-GitHub-Event, e.g. push
+	Payload []byte // the JSON payload of the event
+}
+
+// Handler receives the deliveries of a webhook
+type Handler struct {
+	listener net.Listener
+	server   *http.Server
+	logger   logrus.FieldLogger
+
+	secret  []byte
+	deliver func(*Delivery) error
+}
+
+// StartHandler listens for the deliveries on addr and port, the deliveries whose signature matches
+// the secret of the webhook are passed to deliver
+func StartHandler(secret string, addr string, port uint16, deliver func(*Delivery) error, logger logrus.FieldLogger) (*Handler, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("the secret of the webhook is required to validate the deliveries")
+	}
+	h := &Handler{
+		secret:  []byte(secret),
+		deliver: deliver,
+	}
+
+	if logger == nil {
+		discard := logrus.New()
+		discard.Out = io.Discard
+		logger = discard
+	}
+	h.logger = logger.WithField("module", "webhook")
+
+	router := httprouter.New()
+	router.POST(Path, h.receive)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", addr, port))
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{
+		ReadHeaderTimeout: 2 * time.Second,
+		Handler:           router,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, http.ErrServerClosed) {
+			h.logger.Errorf("http serve: %v", err)
+		}
+	}()
+	h.listener = listener
+	h.server = server
+
+	return h, nil
+}
+
+// Addr returns the address the handler listens on
+func (h *Handler) Addr() net.Addr {
+	return h.listener.Addr()
+}
+
+func (h *Handler) Close() error {
+	if h == nil {
+		return nil
+	}
+	var retErr error
+	if h.server != nil {
+		if err := h.server.Close(); err != nil {
+			retErr = err
+		}
+		h.server = nil
+	}
+	if h.listener != nil {
+		err := h.listener.Close()
+		if errors.Is(err, net.ErrClosed) {
+			err = nil
+		}
+		if err != nil {
+			retErr = err
+		}
+		h.listener = nil
+	}
+	return retErr
+}
+
+// POST /
+func (h *Handler) receive(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxPayloadSize {
+		http.Error(w, "the payload is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := Verify(h.secret, body, r.Header.Get("X-Hub-Signature-256")); err != nil {
+		h.logger.Warnf("Rejected the delivery %s: %v", r.Header.Get("X-GitHub-Delivery"), err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	delivery := &Delivery{
+		ID:    r.Header.Get("X-GitHub-Delivery"),
+		Event: r.Header.Get("X-GitHub-Event"),
+	}
+	if delivery.Event == "" {
+		http.Error(w, "the delivery has no X-GitHub-Event header", http.StatusBadRequest)
+		return
+	}
+	delivery.Payload, err = payload(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// GitHub sends a ping when the webhook is created
+	if delivery.Event == "ping" {
+		h.logger.Infof("Received the ping %s", delivery.ID)
+		fmt.Fprintln(w, "pong")
+		return
+	}
+
+	if err := h.deliver(delivery); err != nil {
+		if errors.Is(err, ErrNotRun) {
+			h.logger.Infof("Ignored the delivery %s of %s: %v", delivery.ID, delivery.Event, err)
+			fmt.Fprintln(w, err.Error())
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "running the workflows of %s\n", delivery.Event)
+}
+
+// payload returns the JSON payload of a delivery, which is the payload form field of the deliveries
+// with the content type application/x-www-form-urlencoded
+func payload(contentType string, body []byte) ([]byte, error) {
+	if !strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return body, nil
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the form of the delivery: %w", err)
+	}
+	if !values.Has("payload") {
+		return nil, fmt.Errorf("the form of the delivery has no payload")
+	}
+	return []byte(values.Get("payload")), nil
+}
+
+// Verify checks the X-Hub-Signature-256 of a delivery, the HMAC of the body with the secret of the webhook
+func Verify(secret []byte, body []byte, signature string) error {
+	if signature == "" {
+		return fmt.Errorf("the delivery isn't signed, set the secret of the webhook")
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return fmt.Errorf("unsupported signature '%s'", signature)
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return fmt.Errorf("the signature doesn't match the secret")
+	}
+	return nil
+}
+
+// Sign returns the X-Hub-Signature-256 of a body, e.g. to send test deliveries
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
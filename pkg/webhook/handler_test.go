@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	secret := []byte("It's a Secret to Everybody")
+	body := []byte("Hello, World!")
+
+	// the example of the docs of GitHub
+	assert.NoError(t, Verify(secret, body, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"))
+	assert.Equal(t, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", Sign(secret, body))
+
+	assert.ErrorContains(t, Verify(secret, body, ""), "isn't signed")
+	assert.ErrorContains(t, Verify(secret, body, "sha1=abc"), "unsupported signature")
+	assert.ErrorContains(t, Verify(secret, body, "sha256=xyz"), "invalid signature")
+	assert.ErrorContains(t, Verify([]byte("other"), body, Sign(secret, body)), "doesn't match")
+}
+
+func TestHandler(t *testing.T) {
+	secret := []byte("s3cret")
+	delivered := []*Delivery{}
+	deliver := func(d *Delivery) error {
+		if d.Event == "release" {
+			return fmt.Errorf("%w release", ErrNotRun)
+		}
+		delivered = append(delivered, d)
+		return nil
+	}
+
+	_, err := StartHandler("", "127.0.0.1", 0, deliver, nil)
+	assert.Error(t, err, "the secret is required")
+
+	handler, err := StartHandler(string(secret), "127.0.0.1", 0, deliver, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, handler.Close())
+		assert.Nil(t, handler.server)
+		assert.Nil(t, handler.listener)
+	}()
+	base := fmt.Sprintf("http://%s%s", handler.Addr(), Path)
+
+	post := func(t *testing.T, event string, contentType string, body []byte, signature string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, base, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+		req.Header.Set("X-Hub-Signature-256", signature)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		body := []byte(`{"ref":"refs/heads/main"}`)
+		code, _ := post(t, "push", "application/json", body, Sign(secret, body))
+		assert.Equal(t, http.StatusAccepted, code)
+		require.Len(t, delivered, 1)
+		assert.Equal(t, &Delivery{ID: "72d3162e-cc78-11e3-81ab-4c9367dc0958", Event: "push", Payload: body}, delivered[0])
+	})
+
+	t.Run("form", func(t *testing.T) {
+		body := []byte(url.Values{"payload": {`{"action":"opened"}`}}.Encode())
+		code, _ := post(t, "issues", "application/x-www-form-urlencoded", body, Sign(secret, body))
+		assert.Equal(t, http.StatusAccepted, code)
+		require.Len(t, delivered, 2)
+		assert.Equal(t, `{"action":"opened"}`, string(delivered[1].Payload))
+	})
+
+	t.Run("invalid-signature", func(t *testing.T) {
+		body := []byte(`{}`)
+		code, _ := post(t, "push", "application/json", body, Sign([]byte("other"), body))
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Len(t, delivered, 2)
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		body := []byte(`{"zen":"Keep it logically awesome."}`)
+		code, text := post(t, "ping", "application/json", body, Sign(secret, body))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "pong\n", text)
+		assert.Len(t, delivered, 2)
+	})
+
+	t.Run("not-run", func(t *testing.T) {
+		body := []byte(`{}`)
+		code, text := post(t, "release", "application/json", body, Sign(secret, body))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "no workflow runs on the event release\n", text)
+	})
+}